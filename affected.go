@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+)
+
+// checkAffected dry-runs the command on tiers with max_affected and
+// reports whether it touches more objects than allowed, in which case it
+// needs break-glass approval: a confirmation that --yes can't skip and the
+// tier's approval_command. The returned error is the reason to block the
+// command. If the dry run fails, the impact is unknown and uncertain says
+// so, for a confirmation --yes can't skip. Commands run offline aren't
+// counted.
+func checkAffected(decision policy.Decision) (breakGlass bool, uncertain string, err error) {
+	if !affectedCounted(decision) {
+		return false, "", nil
+	}
+	limit := decision.Rules.MaxAffected
+
+	objects, err := preview.Affected(decision.Context, decision.Args)
+	if err != nil {
+		return false, fmt.Sprintf("the objects affected are unknown: %v", err), nil
+	}
+	if len(objects) <= limit {
+		return false, "", nil
+	}
+
+	tooMany := fmt.Sprintf("%s would affect %d objects; tier '%s' allows %d", decision.Action, len(objects), decision.Tier, limit)
+	if decision.Rules.OverMaxAffected != config.OverMaxBreakGlass {
+		return false, "", fmt.Errorf("%s", tooMany)
+	}
+	if decision.Rules.ApprovalCommand == "" {
+		return false, "", fmt.Errorf("%s and has no approval_command for break-glass", tooMany)
+	}
+	output.PrintSublog(tooMany + "; break-glass approval required")
+	return true, "", nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// expandAlias replaces a first word that names an alias with the args it
+// stands for, keeping the args that follow. Aliases aren't expanded again,
+// so one may start with the word it names.
+func expandAlias(cfg *config.Config, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	expansion, ok := cfg.Aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	words, err := shell.SplitArgs(expansion)
+	if err != nil {
+		return nil, fmt.Errorf("alias '%s': %w", args[0], err)
+	}
+	return append(words, args[1:]...), nil
+}
+
+// isKctlCommand reports whether name is handled by kctl before aliases are
+// expanded, so an alias of that name would never run
+func isKctlCommand(name string) bool {
+	switch name {
+	case "help", "config", "macros", "alias":
+		return true
+	}
+	for _, c := range kctlCommands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAlias lists, adds and removes aliases in the config file
+func handleAlias(args []string) int {
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		fmt.Printf(`%s alias - Short names for kubectl commands
+
+Usage:
+  %[1]s alias                          List the aliases
+  %[1]s alias add NAME "EXPANSION"     Add or replace an alias
+  %[1]s alias rm NAME                  Remove an alias
+
+'%[1]s NAME ARGS...' runs '%[1]s EXPANSION ARGS...'. The expanded command is
+checked against the rules like any other, so aliases don't bypass them the
+way shell aliases for kubectl do. Aliases are kept under 'aliases' in the
+config.
+
+Example:
+  %[1]s alias add gp "get pods -A -o wide"
+`, progName)
+		return 0
+	}
+
+	switch {
+	case len(args) == 0:
+		cfg := readConfig()
+		if len(cfg.Aliases) == 0 {
+			fmt.Printf("No aliases defined; add one with '%s alias add NAME \"EXPANSION\"'\n", progName)
+			return 0
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tEXPANSION")
+		names := make([]string, 0, len(cfg.Aliases))
+		for name := range cfg.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, cfg.Aliases[name])
+		}
+		w.Flush()
+		return 0
+	case len(args) == 3 && args[0] == "add":
+		name, expansion := args[1], args[2]
+		if isKctlCommand(name) {
+			output.PrintError(fmt.Sprintf("'%s' is a %s command; pick another name", name, progName))
+			return 1
+		}
+		if _, err := shell.SplitArgs(expansion); err != nil {
+			output.PrintError(fmt.Sprintf("Invalid expansion: %v", err))
+			return 1
+		}
+		doc, err := loadConfigDocument()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+			return 1
+		}
+		if err := doc.Set([]string{"aliases", name}, expansion); err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		if err := saveConfigDocument(doc); err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		output.PrintSuccess(fmt.Sprintf("%s now runs '%s %s'", name, progName, expansion))
+		return 0
+	case len(args) == 2 && args[0] == "rm":
+		doc, err := config.LoadDocument(config.ConfigPath())
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+			return 1
+		}
+		if !doc.Delete([]string{"aliases", args[1]}) {
+			output.PrintError(fmt.Sprintf("No alias named %s", args[1]))
+			return 1
+		}
+		if err := saveConfigDocument(doc); err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		output.PrintSuccess(fmt.Sprintf("Removed alias %s", args[1]))
+		return 0
+	default:
+		output.PrintError(fmt.Sprintf("Usage: %s alias [add NAME \"EXPANSION\" | rm NAME]", progName))
+		return 1
+	}
+}
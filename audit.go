@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/editdiff"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lease"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/verify"
+)
+
+// recordAudit appends a guarded command to the audit log when enabled.
+// Failures are reported but never affect the command's exit code.
+func recordAudit(cfg *config.Config, decision policy.Decision, entry audit.Entry) {
+	if !cfg.Audit.Enabled {
+		return
+	}
+	entry.Time = time.Now().UTC()
+	entry.User = lease.Identity()
+	entry.Context = decision.Context
+	entry.Tier = decision.Tier
+	entry.Action = decision.Action
+	entry.Args = decision.Args
+	entry.Verdict = string(decision.Verdict)
+	entry.Rule = decision.Rule
+	entry.Ticket = decision.Ticket
+	entry.Identity = decision.Identity
+	if entry.Reason == "" {
+		entry.Reason = decision.Reason
+	}
+	if err := audit.Append(audit.Path(cfg.Audit), entry); err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not write audit log: %v", err))
+	}
+}
+
+// capturedOutput holds copies of kubectl's output for the audit log
+type capturedOutput struct {
+	stdout, stderr *audit.Capture
+}
+
+// captureOutput returns where to copy the output of a command for the audit
+// log, or nil when it isn't recorded: only confirmed destructive commands
+// are, and only non-interactive ones, since capturing pipes the output
+func captureOutput(cfg *config.Config, decision policy.Decision) *capturedOutput {
+	if !cfg.Audit.Enabled || !cfg.Audit.CaptureOutput || decision.Verdict != policy.Confirm ||
+		!rbac.IsDestructive(decision.Action) || !spinnerSafe(decision.Args) {
+		return nil
+	}
+	return &capturedOutput{
+		stdout: audit.NewCapture(cfg.Audit.MaxOutputBytes),
+		stderr: audit.NewCapture(cfg.Audit.MaxOutputBytes),
+	}
+}
+
+// record returns the captured output for an audit entry
+func (c *capturedOutput) record(cfg *config.Config) *audit.Output {
+	if c == nil {
+		return nil
+	}
+	out, err := audit.NewOutput(c.stdout, c.stderr, cfg.Audit.CompressOutput)
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not record command output: %v", err))
+		return nil
+	}
+	return out
+}
+
+// snapshotEdit fetches the objects an edit targets, so the audit log can
+// record what it changed. It returns nil when there is no audit log or the
+// objects can't be fetched.
+func snapshotEdit(cfg *config.Config, decision policy.Decision) editdiff.Snapshot {
+	if !cfg.Audit.Enabled || decision.Offline || decision.Action != rbac.ActionEdit || !editdiff.Supported(decision.Args) {
+		return nil
+	}
+	before, err := editdiff.Take(decision.Context, decision.Args)
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Edit changes won't be recorded: %v", err))
+		return nil
+	}
+	return before
+}
+
+// editChanges fetches the edited objects again and returns what changed
+// since before
+func editChanges(decision policy.Decision, before editdiff.Snapshot) []audit.Change {
+	if before == nil {
+		return nil
+	}
+	after, err := editdiff.Take(decision.Context, decision.Args)
+	if err == nil {
+		var changes []audit.Change
+		if changes, err = editdiff.Diff(before, after); err == nil {
+			return changes
+		}
+	}
+	output.PrintWarning(fmt.Sprintf("Edit changes won't be recorded: %v", err))
+	return nil
+}
+
+// verifyOutcome checks the effect of a confirmed destructive command when
+// verification is enabled, reporting the result. It returns nil when the
+// command can't be verified.
+func verifyOutcome(cfg *config.Config, decision policy.Decision) *audit.Verification {
+	if !cfg.Verify.Enabled || decision.Offline || decision.Verdict != policy.Confirm {
+		return nil
+	}
+	check, ok := verify.For(decision.Context, decision.Args)
+	if !ok {
+		return nil
+	}
+	timeout := verify.DefaultTimeout
+	if d, err := time.ParseDuration(cfg.Verify.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+
+	output.PrintSublog(fmt.Sprintf("Verifying: %s", check.Description))
+	result := &audit.Verification{Check: check.Description, OK: true}
+	if err := check.Run(timeout); err != nil {
+		result.OK, result.Detail = false, err.Error()
+		output.PrintWarning(fmt.Sprintf("Verification failed: %s", err))
+	} else {
+		output.PrintSuccess(fmt.Sprintf("Verified: %s", check.Description))
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// handleAudit dispatches the audit log subcommands
+func handleAudit(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl audit - Work with the audit log
+
+Usage:
+  kctl audit replay --policy PATH [--file PATH]
+
+Run 'kctl audit <subcommand> --help' for its flags.
+`)
+		return 0
+	}
+	if args[0] == "replay" {
+		return auditReplay(args[1:])
+	}
+	output.PrintError(fmt.Sprintf("Unknown audit subcommand: %s", args[0]))
+	return 1
+}
+
+// auditReplay re-evaluates the audit log against a candidate config and
+// reports the commands it would decide differently
+func auditReplay(args []string) int {
+	policyPath, logPath := "", ""
+	for i := 0; i < len(args); i++ {
+		switch flag := args[i]; flag {
+		case "--help", "-h":
+			fmt.Print(`kctl audit replay - See how other rules would have decided past commands
+
+Usage:
+  kctl audit replay --policy PATH [--file PATH]
+
+Flags:
+  --policy PATH  Candidate config to evaluate the commands against
+  --file PATH    Audit log to replay. Default: audit.path, or audit.jsonl in
+                 the data directory
+
+Lists the audited commands the candidate decides differently, grouped into
+newly blocked, newly needing confirmation and loosened. Commands decided by
+'kctl lock' are skipped. Tiers are matched using the contexts' current
+servers; cluster_meta lookups are skipped.
+`)
+			return 0
+		case "--policy", "--file":
+			if i+1 >= len(args) {
+				output.PrintError(fmt.Sprintf("%s requires a value", flag))
+				return 1
+			}
+			i++
+			if flag == "--policy" {
+				policyPath = args[i]
+			} else {
+				logPath = args[i]
+			}
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", flag))
+			return 1
+		}
+	}
+	if policyPath == "" {
+		output.PrintError("--policy is required")
+		return 1
+	}
+
+	cfg := readPinnedConfig()
+	candidate, err := config.LoadFromPath(policyPath)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not load %s: %v", policyPath, err))
+		return 1
+	}
+	servers := map[string]string{}
+	candidate.ServerLookup = func(context string) (string, error) {
+		server, ok := servers[context]
+		if !ok {
+			server, _ = kubectl.GetServer(context)
+			servers[context] = server
+		}
+		return server, nil
+	}
+	if logPath == "" {
+		logPath = audit.Path(cfg.Audit)
+	}
+
+	entries, err := audit.Load(logPath)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read %s: %v", logPath, err))
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No audit entries in %s\n", logPath)
+		return 0
+	}
+
+	diffs, replayed := audit.Replay(candidate, entries)
+	fmt.Printf("Replayed %d commands from %s to %s against %s\n", replayed,
+		entries[0].Time.Local().Format("2006-01-02"), entries[len(entries)-1].Time.Local().Format("2006-01-02"), policyPath)
+
+	groups := []struct {
+		title string
+		match func(audit.Difference) bool
+	}{
+		{"Newly blocked", func(d audit.Difference) bool { return d.Decision.Verdict == policy.Block }},
+		{"Newly needing confirmation", func(d audit.Difference) bool { return d.Tighter() && d.Decision.Verdict == policy.Confirm }},
+		{"Loosened", func(d audit.Difference) bool { return !d.Tighter() }},
+	}
+	for _, group := range groups {
+		var matched []audit.Difference
+		for _, d := range diffs {
+			if group.match(d) {
+				matched = append(matched, d)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d):\n", group.title, len(matched))
+		for _, d := range matched {
+			rule := ""
+			if d.Decision.Rule != "" {
+				rule = ", rule " + d.Decision.Rule
+			}
+			fmt.Printf("  %s  %s  %s  kubectl %s  (%s -> %s%s)\n",
+				d.Entry.Time.Local().Format("2006-01-02 15:04"), d.Entry.User, d.Entry.Context,
+				shell.JoinArgs(d.Entry.Args), d.Entry.Verdict, d.Decision.Verdict, rule)
+		}
+	}
+	fmt.Printf("\n%d unchanged, %d changed\n", replayed-len(diffs), len(diffs))
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/apiresources"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// handleCache manages what kctl caches about clusters
+func handleCache(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl cache - Manage what kctl caches about clusters
+
+Usage:
+  kctl cache clear [--context NAME]
+
+clear forgets the resource types read with 'kubectl api-resources', which
+resolve short names and CRD plurals to kinds. They are kept for a day per
+context and read again sooner when the context points at another cluster;
+clear after installing or removing CRDs to pick up the change now.
+`)
+		return 0
+	}
+	if args[0] != "clear" {
+		output.PrintError(fmt.Sprintf("Unknown cache command: %s", args[0]))
+		return 1
+	}
+
+	context := ""
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		if rest[i] != "--context" || i+1 >= len(rest) {
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", rest[i]))
+			return 1
+		}
+		context = rest[i+1]
+		i++
+	}
+
+	cleared, err := apiresources.NewResolver().Clear(context)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not clear the cache: %v", err))
+		return 1
+	}
+	switch {
+	case !cleared && context != "":
+		fmt.Printf("Nothing cached for context '%s'\n", context)
+	case !cleared:
+		fmt.Println("Nothing cached")
+	case context != "":
+		output.PrintSuccess(fmt.Sprintf("Cleared cached api-resources for context '%s'", context))
+	default:
+		output.PrintSuccess("Cleared cached api-resources for all contexts")
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/capacity"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/quantity"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// capacityNotes warns when a scale-up beyond the tier's scale_up_factor
+// adds more pods than the schedulable nodes have room for. Dry runs and
+// commands run offline aren't checked, and neither are scale-ups whose
+// estimate fails.
+func capacityNotes(decision policy.Decision) []string {
+	factor := decision.Rules.ScaleUpFactor
+	if factor <= 0 || decision.Action != rbac.ActionScale || decision.Offline || rbac.IsDryRun(decision.Args) {
+		return nil
+	}
+	e, ok, err := capacity.Check(decision.Context, decision.Args, factor)
+	if err != nil || !ok || !e.Short() {
+		return nil
+	}
+	var each string
+	switch {
+	case e.CPU > 0 && e.Memory > 0:
+		each = fmt.Sprintf("%s CPU and %s memory", quantity.CPU(e.CPU), quantity.Bytes(e.Memory))
+	case e.CPU > 0:
+		each = quantity.CPU(e.CPU) + " CPU"
+	default:
+		each = quantity.Bytes(e.Memory) + " memory"
+	}
+	return []string{fmt.Sprintf("The cluster has room for about %d of the %d new pods of %s (each requests %s); the rest would stay Pending",
+		e.Fits, e.New(), e.Ref, each)}
+}
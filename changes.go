@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/editdiff"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// maxShownChanges is how many changed fields the confirmation lists
+const maxShownChanges = 10
+
+// maxShownValue is how much of a changed field's value is shown
+const maxShownValue = 60
+
+// maxShownSelected is how many objects matched by a selector the
+// confirmation lists
+const maxShownSelected = 10
+
+// printChanges lists the fields a scale or patch will change in the
+// confirmation header, so the user sees more than "Patch resource"
+func printChanges(decision policy.Decision) {
+	if decision.Offline || !editdiff.Previewable(decision.Args) {
+		return
+	}
+	changes, err := editdiff.Preview(decision.Context, decision.Args)
+	switch {
+	case err != nil:
+		output.PrintSublog(fmt.Sprintf("Changes: unknown (%v)", err))
+		return
+	case len(changes) == 0:
+		output.PrintSublog("Changes: none, the objects are already as requested")
+		return
+	}
+	output.PrintSublog("Changes:")
+	for i, c := range changes {
+		if i == maxShownChanges {
+			output.PrintSublog(fmt.Sprintf("  ... and %d more", len(changes)-i))
+			break
+		}
+		output.PrintSublog(fmt.Sprintf("  %s %s: %s → %s", c.Object, c.Path, shownValue(c.Before), shownValue(c.After)))
+	}
+}
+
+// shownValue shortens a changed field's JSON value for the confirmation
+func shownValue(value string) string {
+	switch {
+	case value == "":
+		return "(unset)"
+	case len(value) > maxShownValue:
+		return value[:maxShownValue-3] + "..."
+	}
+	return value
+}
+
+// printSelected lists the objects a mutating command's label selector
+// matches in the confirmation header: selectors are where a command meant
+// for a few pods turns out to hit many
+func printSelected(decision policy.Decision) {
+	selector, ok := preview.Selector(decision.Args)
+	if decision.Offline || !ok || !rbac.IsDestructive(decision.Action) {
+		return
+	}
+	objects, err := preview.Selected(decision.Context, decision.Args)
+	switch {
+	case err != nil:
+		output.PrintSublog(fmt.Sprintf("Selector %s: could not list what it matches (%v)", selector, err))
+		return
+	case len(objects) == 0:
+		output.PrintSublog(fmt.Sprintf("Selector %s matches nothing", selector))
+		return
+	}
+	output.PrintSublog(fmt.Sprintf("Selector %s matches %d objects:", selector, len(objects)))
+	for i, o := range objects {
+		if i == maxShownSelected {
+			output.PrintSublog(fmt.Sprintf("  ... and %d more", len(objects)-i))
+			break
+		}
+		if o.Namespace != "" {
+			output.PrintSublog(fmt.Sprintf("  %s (%s)", o.Name, o.Namespace))
+		} else {
+			output.PrintSublog("  " + o.Name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/apiresources"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// completionTTL is how long namespaces and object names read for
+// completion are reused
+const completionTTL = 30 * time.Second
+
+// clusterCompletions completes kubectl commands at the shell and edit
+// prompts with the namespaces, resource types and object names of the
+// cluster they run against. Resource types come from the api-resources
+// cache; lists of names are kept for completionTTL.
+func clusterCompletions() func(previous []string, word string) []string {
+	cache := shell.NewListCache(completionTTL)
+	resolver := apiresources.NewResolver()
+	return shell.ClusterSources{
+		Contexts: func() []string {
+			return cache.Get("contexts", kubectl.GetAllContexts)
+		},
+		Namespaces: func(context string) []string {
+			return cache.Get("namespaces\x00"+context, func() ([]string, error) {
+				return listNames(context, "", "namespaces")
+			})
+		},
+		ResourceTypes: func(context string) []string {
+			if context == "" {
+				context, _ = kubectl.GetCurrentContext()
+			}
+			resources, _ := resolver.Resources(context)
+			var types []string
+			for _, res := range resources {
+				types = append(types, res.Name)
+				types = append(types, res.ShortNames...)
+			}
+			return types
+		},
+		Names: func(context, namespace, resource string) []string {
+			return cache.Get(strings.Join([]string{"names", context, namespace, resource}, "\x00"), func() ([]string, error) {
+				return listNames(context, namespace, resource)
+			})
+		},
+	}.Complete
+}
+
+// listNames returns the names of the objects of resource, in namespace
+// and on context when they aren't ""
+func listNames(context, namespace, resource string) ([]string, error) {
+	args := []string{"get", resource, "-o", "jsonpath={.items[*].metadata.name}", "--request-timeout=5s"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if context != "" {
+		args = append(args, "--context", context)
+	}
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput(args)
+	if exitCode != 0 {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+	return strings.Fields(stdout), nil
+}
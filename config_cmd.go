@@ -0,0 +1,491 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/gitsync"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// Timeouts for pulling the shared policy repository. Automatic syncs run
+// before a kubectl command, so they give up sooner.
+const (
+	autoSyncTimeout   = 5 * time.Second
+	manualSyncTimeout = 60 * time.Second
+)
+
+// kctlConfigCommands are the 'config' subcommands handled by kctl itself
+var kctlConfigCommands = map[string]bool{
+	"show":     true,
+	"get":      true,
+	"set":      true,
+	"unset":    true,
+	"sync":     true,
+	"validate": true,
+}
+
+// isKctlConfigCommand reports whether 'config <sub>' is a kctl command
+// rather than a kubectl config subcommand
+func isKctlConfigCommand(sub string) bool {
+	return kctlConfigCommands[sub]
+}
+
+// handleConfig dispatches kctl's config subcommands
+func handleConfig(args []string) int {
+	switch args[0] {
+	case "show":
+		return handleConfigShow(args[1:])
+	case "get":
+		return handleConfigGet(args[1:])
+	case "set":
+		return handleConfigSet(args[1:])
+	case "unset":
+		return handleConfigUnset(args[1:])
+	case "sync":
+		return handleConfigSync(args[1:])
+	case "validate":
+		return handleConfigValidate(args[1:])
+	}
+	return 1
+}
+
+// effectiveConfig is the document printed by 'kctl config show'
+type effectiveConfig struct {
+	Sources  []string              `yaml:"sources"`
+	Context  string                `yaml:"context,omitempty"`
+	Resolved *config.ResolvedRules `yaml:"resolved,omitempty"`
+	Config   *config.Config        `yaml:"config"`
+}
+
+// configSources lists where the effective configuration came from, base
+// layer first
+func configSources() []string {
+	path := config.ConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return []string{"built-in defaults"}
+	}
+
+	var sources []string
+	if src, err := config.LoadSource(path); err == nil && src.Type != "" {
+		if shared, err := gitsync.ConfigFile(src); err == nil {
+			if _, err := os.Stat(shared); err == nil {
+				label := fmt.Sprintf("%s (%s", shared, src.URL)
+				if rev, err := gitsync.Revision(src); err == nil {
+					label += " @ " + rev
+				}
+				sources = append(sources, label+")")
+			}
+		}
+	}
+	return append(sources, path)
+}
+
+// handleConfigShow prints the effective configuration and the rules
+// resolved for a context
+func handleConfigShow(args []string) int {
+	format := "yaml"
+	context := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl config show - Print the effective configuration
+
+Usage:
+  kctl config show [--context NAME] [--format yaml|json]
+
+Prints the configuration kctl is actually using, where it was loaded from,
+and the rules resolved for a context (default: the current context).
+
+Flags:
+  --context NAME    Resolve rules for NAME instead of the current context
+  --format FORMAT   Output format: yaml (default) or json
+`)
+			return 0
+		case "--context":
+			if i+1 >= len(args) {
+				output.PrintError("--context requires a value")
+				return 1
+			}
+			context = args[i+1]
+			i++
+		case "--format", "-o":
+			if i+1 >= len(args) {
+				output.PrintError("--format requires a value")
+				return 1
+			}
+			format = args[i+1]
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+	if format != "yaml" && format != "json" {
+		output.PrintError(fmt.Sprintf("Unknown format %q (use yaml or json)", format))
+		return 1
+	}
+
+	cfg := readPinnedConfig()
+	view := effectiveConfig{
+		Sources: configSources(),
+		Config:  cfg,
+	}
+
+	if context == "" && kubectl.CheckKubectlAvailable() {
+		context, _ = kubectl.GetCurrentContext()
+	}
+	if context != "" {
+		rules := cfg.GetClusterRules(context)
+		view.Context = context
+		view.Resolved = &rules
+	}
+
+	if err := printStructured(view, format); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// printStructured prints v as YAML, or as JSON with the same field names
+// when format is "json"
+func printStructured(v interface{}, format string) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if format != "json" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	// Round-trip through YAML so JSON output uses the same field names
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(generic)
+}
+
+// loadConfigDocument opens the config file for editing. When no file exists
+// yet it starts from the built-in defaults, so a single 'set' doesn't leave
+// a config without tiers.
+func loadConfigDocument() (*config.Document, error) {
+	doc, err := config.LoadDocument(config.ConfigPath())
+	if err == nil {
+		return doc, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	data, err := yaml.Marshal(config.Default())
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseDocument(data)
+}
+
+// saveConfigDocument validates doc and writes it to the config path
+func saveConfigDocument(doc *config.Document) error {
+	if _, err := doc.Config(); err != nil {
+		return fmt.Errorf("refusing to write invalid config: %w", err)
+	}
+	return doc.Save(config.ConfigPath())
+}
+
+// handleConfigGet prints the value at a dotted config path
+func handleConfigGet(args []string) int {
+	if len(args) != 1 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl config get - Print a single configuration value
+
+Usage:
+  kctl config get <path>
+
+Scalars are printed as-is; lists and sections are printed as YAML.
+Quote path segments that contain dots: clusters."api.example.com".tier
+
+Examples:
+  kctl config get clusters.my-prod.tier
+  kctl config get tiers.production.blocked_actions
+`)
+		if len(args) != 1 {
+			return 1
+		}
+		return 0
+	}
+
+	path, err := config.ParsePath(args[0])
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	doc, err := loadConfigDocument()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		return 1
+	}
+
+	node := doc.Get(path)
+	if node == nil {
+		output.PrintError(fmt.Sprintf("%s is not set", args[0]))
+		return 1
+	}
+	if node.Kind == yaml.ScalarNode {
+		fmt.Println(node.Value)
+		return 0
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	encoder.Close()
+	return 0
+}
+
+// handleConfigSet changes one value in the config file, keeping comments
+func handleConfigSet(args []string) int {
+	if len(args) != 2 {
+		fmt.Print(`kctl config set - Change a single configuration value
+
+Usage:
+  kctl config set <path> <value>
+
+Edits the kctl config file in place; comments and ordering are kept.
+Lists take comma-separated values; an empty value clears the list.
+This shadows 'kubectl config set'; run kubectl directly to edit kubeconfig.
+
+Examples:
+  kctl config set tiers.production.blocked_actions delete,drain
+  kctl config set clusters.my-prod.tier production
+  kctl config set output.chrome_stream stderr
+`)
+		if len(args) == 1 && (args[0] == "--help" || args[0] == "-h") {
+			return 0
+		}
+		return 1
+	}
+
+	path, err := config.ParsePath(args[0])
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	value, err := config.ParseValue(path, args[1])
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	doc, err := loadConfigDocument()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		return 1
+	}
+	if err := doc.Set(path, value); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	if err := saveConfigDocument(doc); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Set %s", args[0]))
+	return 0
+}
+
+// handleConfigUnset removes a key from the config file
+func handleConfigUnset(args []string) int {
+	if len(args) != 1 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl config unset - Remove a configuration value
+
+Usage:
+  kctl config unset <path>
+
+This shadows 'kubectl config unset'; run kubectl directly to edit kubeconfig.
+
+Example:
+  kctl config unset clusters.old-cluster
+`)
+		if len(args) != 1 {
+			return 1
+		}
+		return 0
+	}
+
+	path, err := config.ParsePath(args[0])
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	doc, err := config.LoadDocument(config.ConfigPath())
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		return 1
+	}
+	if !doc.Delete(path) {
+		output.PrintError(fmt.Sprintf("%s is not set", args[0]))
+		return 1
+	}
+	if err := saveConfigDocument(doc); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Removed %s", args[0]))
+	return 0
+}
+
+// handleConfigSync pulls the shared policy repository named in source
+func handleConfigSync(args []string) int {
+	if len(args) > 0 {
+		fmt.Print(`kctl config sync - Update the shared policy repository
+
+Usage:
+  kctl config sync
+
+Clones or pulls the repository configured under 'source' and checks the
+resulting config. kctl also syncs automatically every source.sync_interval
+and falls back to the last synced copy when the repository is unreachable.
+`)
+		if args[0] == "--help" || args[0] == "-h" {
+			return 0
+		}
+		return 1
+	}
+
+	path := config.ConfigPath()
+	src, err := config.LoadSource(path)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		return 1
+	}
+	if src.Type == "" {
+		output.PrintError(fmt.Sprintf("No source configured in %s", path))
+		output.PrintSublog("Set one with: kctl config set source.type git && kctl config set source.url <repo>")
+		return 1
+	}
+
+	output.PrintInfo(fmt.Sprintf("Syncing %s", src.URL))
+	if err := gitsync.Sync(src, manualSyncTimeout); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	shared, _ := gitsync.ConfigFile(src)
+	if _, err := config.LoadWithBase(shared, path); err != nil {
+		output.PrintError(fmt.Sprintf("Synced config is not usable: %v", err))
+		return 1
+	}
+	rev, _ := gitsync.Revision(src)
+	output.PrintSuccess(fmt.Sprintf("Shared config at %s", rev))
+	output.PrintSublog(shared)
+	return 0
+}
+
+// expiryNotice is how far ahead 'config validate' mentions upcoming expiries
+const expiryNotice = 72 * time.Hour
+
+// handleConfigValidate checks a config file and reports expired temporary
+// rules
+func handleConfigValidate(args []string) int {
+	path := config.ConfigPath()
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl config validate - Check the configuration
+
+Usage:
+  kctl config validate [--file PATH]
+
+Reports syntax errors, unknown keys, invalid patterns and tier inheritance,
+and temporary rules (expires_at) that have expired and are being ignored.
+Exits non-zero when the config has errors; expired rules only warn.
+`)
+			return 0
+		case "--file", "-f":
+			if i+1 >= len(args) {
+				output.PrintError("--file requires a value")
+				return 1
+			}
+			path = args[i+1]
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	problems := 0
+	if err := config.CheckKnownFields(data); err != nil {
+		output.PrintError(err.Error())
+		problems++
+	}
+
+	load := func() error {
+		_, err := config.LoadFromPath(path)
+		return err
+	}
+	if src, err := config.LoadSource(path); err == nil && src.Type != "" {
+		if err := gitsync.Validate(src); err != nil {
+			output.PrintError(err.Error())
+			problems++
+		} else if shared, _ := gitsync.ConfigFile(src); fileExists(shared) {
+			load = func() error {
+				_, err := config.LoadWithBase(shared, path)
+				return err
+			}
+		} else {
+			output.PrintWarning("Shared config has not been synced; validating the local file alone")
+		}
+	}
+	if err := load(); err != nil {
+		output.PrintError(err.Error())
+		problems++
+	}
+
+	doc, err := config.ParseDocument(data)
+	if err == nil {
+		expirations, err := doc.Expirations()
+		if err != nil {
+			output.PrintError(err.Error())
+			problems++
+		}
+		for _, e := range expirations {
+			switch {
+			case e.Expired():
+				output.PrintWarning(fmt.Sprintf("%s expired %s and is ignored; remove it",
+					e.Path, e.ExpiresAt.Local().Format(time.RFC1123)))
+			case time.Until(e.ExpiresAt) < expiryNotice:
+				output.PrintSublog(fmt.Sprintf("%s expires %s", e.Path, e.ExpiresAt.Local().Format(time.RFC1123)))
+			}
+		}
+	}
+
+	if problems > 0 {
+		output.PrintError(fmt.Sprintf("%s has %d error(s)", path, problems))
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("%s is valid", path))
+	return 0
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
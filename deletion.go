@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/deletion"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// inspectDelete finds what a delete removes, with the finalizers, owners
+// and children of each target. Dry runs and commands run offline aren't
+// inspected. When the inspection fails what the delete removes is
+// unknown, which err reports.
+func inspectDelete(decision policy.Decision) ([]deletion.Target, error) {
+	if !deleteInspected(decision) {
+		return nil, nil
+	}
+	targets, err := deletion.Inspect(decision.Context, decision.Args)
+	if err != nil {
+		return nil, fmt.Errorf("what the delete removes is unknown: %v", err)
+	}
+	return targets, nil
+}
+
+// deletionNotes warns about targets whose delete can hang on finalizers,
+// be undone by an owner that recreates them, or take their children with
+// them
+func deletionNotes(targets []deletion.Target) []string {
+	var notes []string
+	for _, t := range targets {
+		if len(t.Finalizers) > 0 {
+			notes = append(notes, fmt.Sprintf("%s has finalizers (%s); the delete waits until their controllers remove them and can hang",
+				t, strings.Join(t.Finalizers, ", ")))
+		}
+		if len(t.Owners) > 0 {
+			notes = append(notes, fmt.Sprintf("%s is owned by %s, which may recreate it", t, strings.Join(t.Owners, ", ")))
+		}
+		if t.Children > 0 {
+			notes = append(notes, fmt.Sprintf("%s owns %d %s, which are deleted with it", t, t.Children, t.ChildResource))
+		}
+	}
+	return notes
+}
+
+// printNotes prints notes such as deletionNotes in the confirmation header,
+// or as warnings when there is none
+func printNotes(notes []string, header bool) {
+	for i, note := range notes {
+		if i == maxShownSelected {
+			note = fmt.Sprintf("... and %d more", len(notes)-i)
+		}
+		if header {
+			output.PrintSublog(note)
+		} else {
+			output.PrintWarning(note)
+		}
+		if i == maxShownSelected {
+			break
+		}
+	}
+}
+
+// deleteProgressInterval is how often a waiting delete shows what is left
+const deleteProgressInterval = 5 * time.Second
+
+// forceDeleteWait makes a delete on a tier with wait_for_deletes wait until
+// its objects are gone: --wait=true replaces any --wait, and kubectl gives
+// up after the tier's wait. It returns the wait, zero for other commands.
+func forceDeleteWait(decision policy.Decision, args []string) ([]string, time.Duration) {
+	wait := decision.Rules.DeleteWait()
+	if wait <= 0 || decision.Action != rbac.ActionDelete || rbac.IsDryRun(args) {
+		return args, 0
+	}
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			kept = append(kept, args[i:]...)
+			break
+		}
+		switch name, _, _ := strings.Cut(arg, "="); {
+		case arg == "--timeout":
+			i++
+		case name != "--wait" && name != "--timeout":
+			kept = append(kept, arg)
+		}
+	}
+	kept, _ = rbac.AddFlags(kept, []string{"--wait=true", "--timeout=" + wait.String()})
+	return kept, wait
+}
+
+// watchDeletion shows, while a delete waits, how many of targets are left
+// and the finalizers holding them. The function it returns stops the
+// watch and, when the delete failed with objects left, explains where it
+// stands.
+func watchDeletion(context string, targets []deletion.Target, wait time.Duration) func(exitCode int) {
+	if wait <= 0 || len(targets) == 0 {
+		return func(int) {}
+	}
+	start := time.Now()
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(deleteProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			remaining, err := deletion.Remaining(context, targets)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err == nil && len(remaining) > 0 {
+				output.PrintSublog(fmt.Sprintf("Deleting: %d of %d objects left after %s", len(remaining), len(targets), time.Since(start).Round(time.Second)))
+				printHeld(remaining)
+			}
+		}
+	}()
+
+	return func(exitCode int) {
+		close(stop)
+		if exitCode == 0 {
+			return
+		}
+		remaining, err := deletion.Remaining(context, targets)
+		if err != nil || len(remaining) == 0 {
+			return
+		}
+		output.PrintWarning(fmt.Sprintf("The delete didn't finish within %s; %d of %d objects are still there", wait, len(remaining), len(targets)))
+		held := false
+		for i, t := range remaining {
+			if i == maxShownSelected {
+				output.PrintSublog(fmt.Sprintf("  ... and %d more", len(remaining)-i))
+				break
+			}
+			if len(t.Finalizers) > 0 {
+				held = true
+				output.PrintSublog(fmt.Sprintf("  %s waits on finalizers %s", t, strings.Join(t.Finalizers, ", ")))
+			} else {
+				output.PrintSublog("  " + t.String())
+			}
+		}
+		if held {
+			output.PrintSublog("Finalizers are removed by their controllers; check those are running before removing one by hand")
+		}
+
+		first := remaining[0]
+		follow := []string{"kubectl", "--context", context, "wait", "--for=delete"}
+		for _, t := range remaining {
+			if t.Namespace == first.Namespace {
+				follow = append(follow, strings.ToLower(t.Kind)+"/"+t.Name)
+			}
+		}
+		if first.Namespace != "" {
+			follow = append(follow, "-n", first.Namespace)
+		}
+		output.PrintSublog("The API server goes on deleting them; to follow, run:")
+		output.PrintSublog("  " + shell.JoinArgs(append(follow, "--timeout="+wait.String())))
+	}
+}
+
+// printHeld lists the objects finalizers are holding
+func printHeld(remaining []deletion.Target) {
+	held := 0
+	for _, t := range remaining {
+		if len(t.Finalizers) == 0 {
+			continue
+		}
+		if held++; held > maxShownSelected {
+			continue
+		}
+		output.PrintSublog(fmt.Sprintf("  %s waits on finalizers %s", t, strings.Join(t.Finalizers, ", ")))
+	}
+	if held > maxShownSelected {
+		output.PrintSublog(fmt.Sprintf("  ... and %d more held by finalizers", held-maxShownSelected))
+	}
+}
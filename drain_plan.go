@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/eviction"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+const planFlag = "--kctl-plan"
+
+// extractPlanFlag removes --kctl-plan from args and reports whether it was
+// present
+func extractPlanFlag(args []string) (bool, []string) {
+	plan := false
+	filteredArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == planFlag {
+			plan = true
+		} else {
+			filteredArgs = append(filteredArgs, arg)
+		}
+	}
+	return plan, filteredArgs
+}
+
+// planDrain prints, for each node the drain in args names, which pods
+// would be evicted and which would stop the drain, without touching the
+// node. It returns 1 when a drain wouldn't finish.
+func planDrain(context string, args []string) int {
+	positional := rbac.Positional(args)
+	if len(positional) < 2 || positional[0] != "drain" {
+		output.PrintError(planFlag + " plans drains: kctl drain <node> " + planFlag)
+		return 1
+	}
+
+	exitCode := 0
+	for _, node := range positional[1:] {
+		plan, err := eviction.Plan(context, node, args)
+		if err != nil {
+			output.PrintError(err.Error())
+			exitCode = 1
+			continue
+		}
+		counts := make(map[string]int)
+		for _, p := range plan {
+			counts[p.Result]++
+		}
+		fmt.Printf("Node %s: %d pods evict, %d blocked, %d skipped", node, counts[eviction.Evicts], counts[eviction.Blocked], counts[eviction.Skipped])
+		if counts[eviction.Failed] > 0 {
+			fmt.Printf(", %d failed", counts[eviction.Failed])
+		}
+		fmt.Println()
+		if len(plan) > 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAMESPACE\tPOD\tRESULT\tREASON")
+			for _, p := range plan {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Namespace, p.Name, p.Result, p.Reason)
+			}
+			w.Flush()
+		}
+		if counts[eviction.Blocked] > 0 || counts[eviction.Failed] > 0 {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
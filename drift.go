@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/drift"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// noticeDrift warns, once per context, about kubeconfig contexts that no
+// cluster entry or tier covers, and with defaults.watch_kubeconfig about
+// contexts and users added to the kubeconfig and servers that changed
+func noticeDrift(cfg *config.Config) {
+	for _, context := range drift.Unwarned(cfg, drift.StatePath(), time.Now()) {
+		msg := fmt.Sprintf("Context '%s' matches no cluster entry or tier and gets the default rules; run '%s init --merge' to classify it", context, progName)
+		if cfg.Defaults.BlockUnclassifiedProd && config.LooksProduction(context) {
+			msg += " (its changes are blocked until then)"
+		}
+		output.PrintWarning(msg)
+	}
+	if cfg.Defaults.WatchKubeconfig {
+		for _, change := range drift.KubeconfigChanges(kubectl.KubeconfigPaths(), drift.KubeconfigStatePath()) {
+			output.PrintWarning(change.String())
+		}
+	}
+}
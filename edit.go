@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// editHeader explains the file opened by editCommand
+const editHeader = `# Edit the command, save and quit to re-check and run it.
+# Lines starting with # are ignored; an empty command cancels.
+`
+
+// editPrompt is shown in front of a command edited at the prompt
+const editPrompt = "kubectl "
+
+// editAtPrompt lets the user edit args on the prompt line, with Tab
+// completing namespaces, resource types and object names from the cluster.
+// Ctrl-X, or a terminal that can't edit in place, opens the command in
+// the editor instead.
+func editAtPrompt(args []string) ([]string, error) {
+	in, closeInput, ok := output.PromptTerminal()
+	if !ok {
+		return editCommand(args)
+	}
+	output.PrintSublog("Edit the command; Tab completes, Enter runs it, Ctrl-X opens your editor")
+	line, err := shell.EditLine(in, os.Stderr, editPrompt, shell.JoinArgs(args), clusterCompletions())
+	closeInput()
+	switch {
+	case errors.Is(err, shell.ErrOpenEditor):
+		if edited, err := shell.SplitArgs(line); err == nil && len(edited) > 0 {
+			args = edited
+		}
+		return editCommand(args)
+	case err == io.EOF:
+		return nil, fmt.Errorf("empty command")
+	case err != nil:
+		return nil, err
+	}
+	return parseEditedCommand(line)
+}
+
+// editCommand opens args as a kubectl command line in $VISUAL or $EDITOR
+// (default vi) and returns the edited arguments
+func editCommand(args []string) ([]string, error) {
+	f, err := os.CreateTemp("", "kctl-command-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintf(f, "%skubectl %s\n", editHeader, shell.JoinArgs(args))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// Through sh so editors configured with arguments ("code --wait") work
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return parseEditedCommand(string(data))
+}
+
+// parseEditedCommand extracts the arguments from an edited command file:
+// non-comment lines are joined, and a leading kubectl or kctl is dropped
+func parseEditedCommand(text string) ([]string, error) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\\"))
+	}
+	args, err := shell.SplitArgs(strings.Join(lines, " "))
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && (args[0] == "kubectl" || args[0] == "kctl") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return args, nil
+}
+
+// contextAfterEdit returns the context edited args run against: their own
+// --context, else the original context unless the edit removed --context
+func contextAfterEdit(original string, args, edited []string) (string, error) {
+	if context, ok := kubectl.GetContextFromArgs(edited); ok {
+		return context, nil
+	}
+	if _, ok := kubectl.GetContextFromArgs(args); ok {
+		return kubectl.GetCurrentContext()
+	}
+	return original, nil
+}
+
+// runEdited lets the user edit a command at its confirmation prompt, then
+// evaluates the edited command from scratch
+func runEdited(cfg *config.Config, context string, args []string, ticketID string, skipConfirm bool) int {
+	if ticketID != "" {
+		args = append([]string{ticketFlag, ticketID}, args...)
+	}
+	edited, err := editAtPrompt(args)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Operation cancelled: %v", err))
+		return 1
+	}
+	kubectl.UseKubeconfigFrom(edited)
+	context, err = contextAfterEdit(context, args, edited)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		return 1
+	}
+	output.PrintSublog(fmt.Sprintf("Edited: kubectl %s", shell.JoinArgs(edited)))
+	return runGuarded(cfg, context, edited, skipConfirm)
+}
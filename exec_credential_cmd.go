@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// handleExecCredential runs as a kubeconfig exec credential plugin: it
+// runs the real credential command and passes on the ExecCredential it
+// prints, unless kctl is locked or a freeze window is open and the request
+// comes from kubectl run outside kctl
+func handleExecCredential(args []string) int {
+	context := ""
+	var command []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			printExecCredentialUsage()
+			return 0
+		case "--context":
+			if i+1 >= len(args) {
+				output.PrintError("--context needs a context name")
+				return 1
+			}
+			context = args[i+1]
+			i++
+		case "--":
+			command = args[i+1:]
+			i = len(args)
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+	if context == "" || len(command) == 0 {
+		output.PrintError("Usage: kctl exec-credential --context NAME -- <credential command>")
+		return 1
+	}
+
+	// kctl has checked its own commands already, and its lock and freeze
+	// windows block only what they should there
+	if !kubectl.Wrapped(wrappedDir(), os.Getenv(kubectl.WrappedEnv)) {
+		cfg := readConfig()
+		cfg.TierLookup = nil // Asking the cluster would need these credentials
+		decision := policy.Decision{
+			Verdict: policy.Block,
+			Action:  "exec-credential",
+			Context: context,
+			Tier:    cfg.GetClusterRules(context).Tier,
+		}
+		if state := currentLock(); state != nil {
+			decision.Locked, decision.Rule, decision.Reason = true, "lock", policy.LockReason(state)
+		} else if freeze := cfg.ActiveFreeze(context); freeze != "" {
+			decision.Rule, decision.Reason = "freeze", fmt.Sprintf("freeze window '%s' is open", freeze)
+		}
+		if decision.Reason != "" {
+			decision.Reason += "; credentials are refused to kubectl run outside kctl"
+			output.PrintError(fmt.Sprintf("kctl: not issuing credentials for %s: %s", context, decision.Reason))
+			recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeBlocked})
+			return 1
+		}
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		output.PrintError(fmt.Sprintf("kctl: credential command failed: %v", err))
+		return 1
+	}
+	return 0
+}
+
+func printExecCredentialUsage() {
+	fmt.Print(`kctl exec-credential - Issue cluster credentials through kctl
+
+Usage:
+  kctl exec-credential --context NAME -- <credential command> [args...]
+
+Wraps a kubeconfig exec credential plugin, such as 'aws eks get-token', so
+kctl's lock and freeze windows also apply to kubectl run directly, and to
+tools like k9s: while kctl is locked ('kctl lock', or 'kctl lock --block
+--duration 2h' as a freeze) or a freeze_windows entry covering the context
+is open, credentials are refused to anything but kctl itself. Otherwise the
+credential command runs as before and its output is passed on.
+
+In the kubeconfig user, replace the command with kctl and put the original
+command and its arguments after --:
+
+  users:
+  - name: prod-admin
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1beta1
+        command: kctl
+        args: [exec-credential, --context, prod, --, aws, eks, get-token, --cluster-name, prod]
+`)
+}
+
+// wrappedDir is where kctl registers the nonces of the kubectl it runs
+func wrappedDir() string {
+	return filepath.Join(config.DataDir(), "wrapped")
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// defaultSummaryAfter is how long a gated command runs before its summary
+// is printed, unless output.summary_after says otherwise
+const defaultSummaryAfter = 10 * time.Second
+
+// defaultSpinnerAfter is how long a command must be silent before the
+// spinner appears, unless output.spinner_after says otherwise
+const defaultSpinnerAfter = 2 * time.Second
+
+// spinnerCommands run fine with their output piped through the spinner.
+// Interactive commands (exec, edit, attach, ...) need the real terminal.
+var spinnerCommands = map[string]bool{
+	"apply": true, "create": true, "replace": true, "delete": true, "patch": true,
+	"scale": true, "rollout": true, "drain": true, "cordon": true, "uncordon": true,
+	"wait": true, "label": true, "annotate": true, "taint": true, "set": true,
+	"get": true, "describe": true, "top": true, "api-resources": true,
+}
+
+// timeoutExitCode is returned for a command ended by its timeout, as by
+// timeout(1)
+const timeoutExitCode = 124
+
+// execute runs kubectl, showing a spinner on stderr while a non-interactive
+// command produces no output and coloring 'get' tables when configured.
+// When capture is set, it also gets a copy of kubectl's output. A command
+// still running after timeout (zero for none) or when ctx ends is
+// interrupted, with a warning shortly before the timeout. Read-only
+// commands that can't reach the API server are retried per kubectl.Retry.
+func execute(ctx context.Context, cfg *config.Config, args []string, capture *capturedOutput, timeout time.Duration) int {
+	stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	piped := false
+
+	var spinner *output.Spinner
+	if delay, ok := durationSetting(cfg.Output.SpinnerAfter, defaultSpinnerAfter); ok && spinnerSafe(args) {
+		if spinner = output.StartSpinner(delay); spinner != nil {
+			defer spinner.Stop()
+			stdout, stderr = spinner.Wrap(stdout), spinner.Wrap(stderr)
+			piped = true
+		}
+	}
+	if cfg.Output.Pager != "" && cfg.Output.Pager != "off" && pageable(args) {
+		command := cfg.Output.Pager
+		if command == "auto" {
+			command = ""
+		}
+		if pager := output.NewPager(command, stdout); pager != nil {
+			// The spinner would draw over the pager
+			pager.OnStart = spinner.Stop
+			defer pager.Close()
+			stdout = pager
+			piped = true
+		}
+	}
+	if cfg.Output.Colorize && colorizable(args) && output.IsStdoutTerminal() {
+		colorizer := output.NewStatusColorizer(stdout)
+		defer colorizer.Close()
+		stdout = colorizer
+		piped = true
+	}
+
+	if capture != nil {
+		stdout, stderr = io.MultiWriter(stdout, capture.stdout), io.MultiWriter(stderr, capture.stderr)
+		piped = true
+	}
+
+	if retryable(args) {
+		// Keep each attempt's errors to tell whether it is worth another.
+		// An attempt that printed anything isn't repeated, so no output is
+		// shown twice.
+		var attemptStderr bytes.Buffer
+		stderr = io.MultiWriter(stderr, &attemptStderr)
+		tracked := &writeTracker{w: stdout}
+		return kubectl.Retry.Run(func() (string, int) {
+			attemptStderr.Reset()
+			exitCode := run(ctx, args, tracked, stderr, true, timeout)
+			if tracked.wrote {
+				return "", exitCode
+			}
+			return attemptStderr.String(), exitCode
+		})
+	}
+	return run(ctx, args, stdout, stderr, piped, timeout)
+}
+
+// run runs kubectl once, with its output sent straight to the terminal
+// unless piped
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, piped bool, timeout time.Duration) int {
+	if timeout > 0 || ctx.Done() != nil {
+		return executeWithTimeout(ctx, args, stdout, stderr, timeout)
+	}
+	if !piped {
+		return kubectl.Execute(args)
+	}
+	return kubectl.ExecuteTo(args, stdout, stderr)
+}
+
+// writeTracker passes writes to w, noting whether any were made
+type writeTracker struct {
+	w     io.Writer
+	wrote bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.wrote = true
+	}
+	return t.w.Write(p)
+}
+
+// retryable reports whether args only read from the cluster and print
+// finite output, so running them again after a connection failure is safe
+func retryable(args []string) bool {
+	return pageable(args)
+}
+
+// reportRetry warns that a command couldn't reach the cluster and is about
+// to be retried
+func reportRetry(reason string, delay time.Duration, attempt, attempts int) {
+	output.PrintWarning(fmt.Sprintf("Could not reach the cluster (%s); retrying in %s (attempt %d of %d)", reason, delay, attempt+1, attempts))
+}
+
+// executeWithTimeout runs kubectl until it exits, ctx ends or timeout
+// (zero for none) passes, warning on stderr a tenth of the way before the
+// deadline (at most a minute before it)
+func executeWithTimeout(ctx context.Context, args []string, stdout, stderr io.Writer, timeout time.Duration) int {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		notice := min(timeout/10, time.Minute)
+		warning := time.AfterFunc(timeout-notice, func() {
+			output.PrintWarning(fmt.Sprintf("This command will be ended in %s (timeout %s)", notice, timeout))
+		})
+		defer warning.Stop()
+	}
+
+	exitCode := kubectl.ExecuteContext(ctx, args, stdout, stderr)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		output.PrintError(fmt.Sprintf("Command ended after its %s timeout", timeout))
+		return timeoutExitCode
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		output.PrintError(fmt.Sprintf("Command ended early: %v", cause))
+		if exitCode == 0 {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// pageCommands print finite, read-only output worth paging
+var pageCommands = map[string]bool{
+	"get": true, "describe": true, "explain": true, "logs": true, "top": true,
+	"events": true, "api-resources": true, "api-versions": true,
+}
+
+// pageable reports whether args print output that can be held back and paged.
+// Watches and followed logs stream forever, so they are never paged.
+func pageable(args []string) bool {
+	positional := rbac.Positional(args)
+	if len(positional) == 0 || !pageCommands[positional[0]] {
+		return false
+	}
+	if rbac.HasFlag(args, "-w", "--watch", "--watch-only") {
+		return false
+	}
+	return positional[0] != "logs" || !rbac.HasFlag(args, "-f", "--follow")
+}
+
+// colorizable reports whether args print a table the colorizer understands
+func colorizable(args []string) bool {
+	positional := rbac.Positional(args)
+	if len(positional) == 0 || positional[0] != "get" {
+		return false
+	}
+	format, ok := rbac.FlagValue(args, "-o", "--output")
+	return !ok || format == "wide"
+}
+
+// spinnerSafe reports whether args can run with piped output
+func spinnerSafe(args []string) bool {
+	positional := rbac.Positional(args)
+	if len(positional) == 0 || !spinnerCommands[positional[0]] {
+		return false
+	}
+	return !rbac.HasFlag(args, "-i", "-t", "-it", "--stdin", "--tty")
+}
+
+// durationSetting parses an output timing setting: "" means def, "off"
+// disables the feature and "0" means always
+func durationSetting(value string, def time.Duration) (time.Duration, bool) {
+	switch value {
+	case "":
+		return def, true
+	case "off":
+		return 0, false
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def, true
+	}
+	return d, true
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/deletion"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/ownership"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/ticket"
+)
+
+// guard is a command on its way through the gates: its decision and what
+// each gate found. runGuarded and simulate send commands through the same
+// gates, so a simulation asks for what running the command would.
+type guard struct {
+	cfg         *config.Config
+	decision    policy.Decision
+	skipConfirm bool
+	// simulate keeps the gates from prompting, calling out or touching the
+	// cluster; they add what they would check to requirements instead
+	simulate     bool
+	requirements []string
+
+	deleteWait    time.Duration
+	unreachable   error
+	ticket        *ticket.Ticket
+	needsApproval bool
+	breakGlass    bool
+	// uncertain are what couldn't be checked, each needing confirmation
+	uncertain     []string
+	nameNotes     []string
+	foreign       []ownership.Object
+	ownersUnknown error
+	healthLines   []string
+	degraded      bool
+	retype        []string
+	targets       []deletion.Target
+	owning        []deletion.Target
+}
+
+// decide evaluates args on context under the rules and the local lock,
+// with the flags the tier adds and the wait it forces on deletes
+func decide(cfg *config.Config, context string, args []string, ticketID string, skipConfirm, simulate bool) *guard {
+	decision := policy.ApplyLock(policy.Evaluate(cfg, context, args), currentLock())
+	decision.Ticket = ticketID
+	g := &guard{cfg: cfg, skipConfirm: skipConfirm, simulate: simulate}
+	decision.Args, g.deleteWait = forceDeleteWait(decision, decision.Args)
+	g.decision = decision
+	return g
+}
+
+// gateStop is why a gate ended a command
+type gateStop struct {
+	err error
+	// outcome is audit.OutcomeBlocked, or OutcomeCancelled when the
+	// command is a mistake rather than against the rules
+	outcome string
+	// rule is the rule that blocked it, for simulate
+	rule string
+}
+
+// blocked stops a command with err as the reason
+func blocked(err error) *gateStop {
+	return &gateStop{err: err, outcome: audit.OutcomeBlocked}
+}
+
+// gates are the checks a command that isn't blocked passes before it is
+// confirmed, in order
+var gates = []func(g *guard) *gateStop{
+	gateUnreachable,
+	gateNames,
+	gateTicket,
+	gateOnCall,
+	gateAffected,
+	gateBreakGlass,
+	gateOwnership,
+	gateHealth,
+	gateDeletion,
+}
+
+// runGates sends g through the gates, stopping at the first that ends it
+func (g *guard) runGates() *gateStop {
+	for _, gate := range gates {
+		if stop := gate(g); stop != nil {
+			return stop
+		}
+	}
+	return nil
+}
+
+// require records what a simulated gate would check
+func (g *guard) require(format string, a ...any) {
+	g.requirements = append(g.requirements, fmt.Sprintf(format, a...))
+}
+
+// gateUnreachable blocks commands on tiers that fail closed when the API
+// server can't be reached
+func gateUnreachable(g *guard) *gateStop {
+	if g.unreachable != nil {
+		return blocked(g.unreachable)
+	}
+	return nil
+}
+
+// gateNames makes sure the names a delete or scale gives exist, and points
+// out lookalikes
+func gateNames(g *guard) *gateStop {
+	if g.simulate {
+		if namesChecked(g.decision) {
+			g.require("the objects it names to exist")
+		}
+		return nil
+	}
+	notes, err := checkNames(g.decision)
+	if err != nil {
+		return &gateStop{err: err, outcome: audit.OutcomeCancelled}
+	}
+	g.nameNotes = notes
+	return nil
+}
+
+// gateTicket asks for an approved change ticket for destructive actions on
+// some tiers
+func gateTicket(g *guard) *gateStop {
+	if g.simulate && ticketRequired(g.decision) && g.decision.Ticket != "" {
+		g.require("ticket %s to pass validation", g.decision.Ticket)
+		return nil
+	}
+	// Without a ticket it is refused before anything is looked up
+	t, err := checkTicket(g.cfg, g.decision)
+	if err != nil {
+		stop := blocked(err)
+		stop.rule = "require_ticket"
+		return stop
+	}
+	g.ticket = t
+	return nil
+}
+
+// gateOnCall asks engineers who aren't on call for approval on some tiers
+func gateOnCall(g *guard) *gateStop {
+	if g.simulate {
+		if onCallRequired(g.decision) {
+			g.require("being on call, or approval")
+		}
+		return nil
+	}
+	needsApproval, err := checkOnCall(g.cfg, g.decision)
+	if err != nil {
+		return blocked(err)
+	}
+	g.needsApproval = g.needsApproval || needsApproval
+	return nil
+}
+
+// gateAffected blocks commands touching more objects than the tier allows,
+// or asks for break-glass approval. What can't be counted needs
+// confirmation.
+func gateAffected(g *guard) *gateStop {
+	if g.simulate {
+		if affectedCounted(g.decision) {
+			limit := fmt.Sprintf("at most %d affected objects (counted by a server-side dry run)", g.decision.Rules.MaxAffected)
+			if g.decision.Rules.OverMaxAffected == config.OverMaxBreakGlass {
+				limit += "; more needs break-glass confirmation and approval"
+			}
+			g.require("%s", limit)
+		}
+		return nil
+	}
+	breakGlass, unknown, err := checkAffected(g.decision)
+	if unknown != "" {
+		g.uncertain = append(g.uncertain, unknown)
+	}
+	if err != nil {
+		return blocked(err)
+	}
+	g.breakGlass = g.breakGlass || breakGlass
+	g.needsApproval = g.needsApproval || breakGlass
+	return nil
+}
+
+// gateBreakGlass asks for break-glass approval to delete everything in
+// every namespace
+func gateBreakGlass(g *guard) *gateStop {
+	if g.decision.BreakGlass {
+		if !g.simulate {
+			output.PrintSublog("delete --all --all-namespaces empties every namespace; break-glass approval required")
+		}
+		g.breakGlass, g.needsApproval = true, true
+	}
+	return nil
+}
+
+// gateOwnership asks for confirmation to change another team's objects
+func gateOwnership(g *guard) *gateStop {
+	if g.simulate {
+		if ownershipChecked(g.cfg, g.decision) {
+			g.require("confirmation if it changes another team's objects")
+		}
+		return nil
+	}
+	g.foreign, g.ownersUnknown = foreignObjects(g.cfg, g.decision)
+	return nil
+}
+
+// gateHealth shows how the cluster is doing before drains and restarts on
+// some tiers
+func gateHealth(g *guard) *gateStop {
+	if g.simulate {
+		if healthChecked(g.decision) {
+			g.require("a look at the cluster's health")
+		}
+		return nil
+	}
+	g.healthLines, g.degraded = checkHealth(g.decision)
+	return nil
+}
+
+// gateDeletion finds what a delete removes. Deleting a namespace deletes
+// everything in it, so on every tier its name must be typed to confirm;
+// so must the names of objects owning more children than the tier's
+// retype_children. Deletes whose namespaces can't be told need
+// confirmation instead.
+func gateDeletion(g *guard) *gateStop {
+	retype, err := namespacesToRetype(g.cfg, g.decision)
+	if err != nil {
+		g.uncertain = append(g.uncertain, err.Error())
+	}
+	g.retype = retype
+	if g.simulate {
+		if limit := g.decision.Rules.RetypeChildren; limit > 0 && deleteInspected(g.decision) {
+			g.require("typing the names of objects owning %d or more children, if any", limit)
+		}
+		return nil
+	}
+	targets, err := inspectDelete(g.decision)
+	if err != nil {
+		g.uncertain = append(g.uncertain, err.Error())
+	}
+	g.targets = targets
+	g.owning = deletion.Owning(targets, g.decision.Rules.RetypeChildren)
+	return nil
+}
+
+// confirmation returns whether the command must be confirmed and the
+// phrase to type, if any. A lock's, break-glass, namespace, owner or
+// uncertain confirmation can't be skipped with --yes.
+func (g *guard) confirmation() (prompt bool, phrase string) {
+	d := g.decision
+	prompt = g.breakGlass || len(g.retype) > 0 || len(g.owning) > 0 || len(g.uncertain) > 0 ||
+		(d.Verdict == policy.Confirm || len(g.foreign) > 0 || g.ownersUnknown != nil) && (!g.skipConfirm || d.Locked)
+	phrase = d.Rules.ConfirmationPhrase
+	if len(g.retype) > 0 || len(g.owning) > 0 {
+		names := append([]string(nil), g.retype...)
+		for _, t := range g.owning {
+			names = append(names, t.Name)
+		}
+		phrase = strings.Join(names, " ")
+	}
+	return prompt, phrase
+}
+
+// needsApprovalCommand reports whether the tier's approval_command must
+// approve the command
+func (g *guard) needsApprovalCommand() bool {
+	return (g.decision.Verdict == policy.Confirm || g.needsApproval) && g.decision.Rules.ApprovalCommand != ""
+}
+
+// namesChecked reports whether checkNames looks up the names in decision
+func namesChecked(decision policy.Decision) bool {
+	return (decision.Action == rbac.ActionDelete || decision.Action == rbac.ActionScale) &&
+		!decision.Offline && !rbac.IsDryRun(decision.Args) && !rbac.HasFlag(decision.Args, "--ignore-not-found")
+}
+
+// ticketRequired reports whether decision needs a change ticket
+func ticketRequired(decision policy.Decision) bool {
+	return decision.Rules.RequireTicket && rbac.IsDestructive(decision.Action)
+}
+
+// onCallRequired reports whether decision needs its user on call
+func onCallRequired(decision policy.Decision) bool {
+	return decision.Rules.RequireOnCall && rbac.IsDestructive(decision.Action)
+}
+
+// affectedCounted reports whether checkAffected counts what decision
+// affects
+func affectedCounted(decision policy.Decision) bool {
+	return decision.Rules.MaxAffected > 0 && !decision.Offline && preview.Supported(decision.Args)
+}
+
+// ownershipChecked reports whether foreignObjects looks up who owns what
+// decision changes
+func ownershipChecked(cfg *config.Config, decision policy.Decision) bool {
+	return cfg.Ownership.Enabled && rbac.IsDestructive(decision.Action) && !decision.Offline &&
+		!rbac.IsDryRun(decision.Args) && preview.Supported(decision.Args)
+}
+
+// healthChecked reports whether checkHealth looks at the cluster before
+// decision runs: drains and rollout restarts on tiers with health_check
+func healthChecked(decision policy.Decision) bool {
+	if !decision.Rules.HealthCheck || decision.Offline || rbac.IsDryRun(decision.Args) {
+		return false
+	}
+	positional := rbac.Positional(decision.Args)
+	restart := decision.Action == rbac.ActionRollout && len(positional) > 1 && positional[1] == "restart"
+	return decision.Action == rbac.ActionDrain || restart
+}
+
+// deleteInspected reports whether inspectDelete looks up what decision
+// deletes
+func deleteInspected(decision policy.Decision) bool {
+	return decision.Action == rbac.ActionDelete && !decision.Offline && !rbac.IsDryRun(decision.Args)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// TestGates_SimulateMatchesRun checks that simulate reaches the verdict
+// runGuarded would, by sending each command through the gates both ways
+func TestGates_SimulateMatchesRun(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir()) // no lock
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		t.Errorf("ran kubectl %v", args)
+		return "", "", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	cfg := &config.Config{Tiers: map[string]config.TierConfig{
+		"production": {
+			Patterns:               []string{"*-prod"},
+			RequireConfirmation:    []string{"delete", "scale"},
+			BlockedActions:         config.Actions("drain"),
+			RequireExplicitContext: true,
+			RequireTicket:          true,
+		},
+		"staging": {
+			Patterns:            []string{"*-staging"},
+			RequireConfirmation: []string{"delete"},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		context  string
+		args     []string
+		verdict  policy.Verdict
+		rule     string
+		prompted bool
+	}{
+		{"read", "app-prod", []string{"get", "pods"}, policy.Allow, "", false},
+		{"blocked action", "app-prod", []string{"--context", "app-prod", "drain", "node-1"}, policy.Block, "drain", false},
+		{"implicit context", "app-prod", []string{"delete", "pod", "web"}, policy.Block, "require_explicit_context", false},
+		{"no ticket", "app-prod", []string{"--context", "app-prod", "delete", "pod", "web", "--dry-run=server"}, policy.Block, "require_ticket", false},
+		{"confirmed", "app-staging", []string{"delete", "pod", "web", "--dry-run=server"}, policy.Confirm, "", true},
+		{"confirmation skipped", "app-staging", []string{"delete", "pod", "web", "--dry-run=server", "--yes"}, policy.Confirm, "", false},
+		{"unmatched", "app-dev", []string{"delete", "pod", "web", "--dry-run=server"}, policy.Allow, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// As runGuarded does, without the prompts and the cluster
+			ticketID, args := extractTicketFlag(tt.args)
+			skipConfirm, args := extractYesFlag(args)
+			g := decide(cfg, tt.context, args, ticketID, skipConfirm, false)
+			run, prompted := g.decision, false
+			if run.Verdict != policy.Block {
+				if stop := g.runGates(); stop != nil {
+					run.Verdict, run.Rule = policy.Block, stop.rule
+				} else {
+					prompted, _ = g.confirmation()
+				}
+			}
+			if run.Verdict != tt.verdict || tt.rule != "" && run.Rule != tt.rule || prompted != tt.prompted {
+				t.Errorf("run: verdict %s, rule %q, prompted %v; want %s, %q, %v", run.Verdict, run.Rule, prompted, tt.verdict, tt.rule, tt.prompted)
+			}
+
+			sim := simulate(cfg, tt.context, tt.args)
+			if sim.Verdict != run.Verdict || sim.Rule != run.Rule {
+				t.Errorf("simulate: verdict %s, rule %q; run: %s, %q", sim.Verdict, sim.Rule, run.Verdict, run.Rule)
+			}
+			if simPrompted := simulatedPrompt(sim); simPrompted != prompted {
+				t.Errorf("simulate: prompts %v (%q); run prompts %v", simPrompted, sim.Requirements, prompted)
+			}
+		})
+	}
+}
+
+// simulatedPrompt reports whether sim requires a confirmation prompt
+func simulatedPrompt(sim simulation) bool {
+	for _, r := range sim.Requirements {
+		if r == "confirmation" || strings.HasPrefix(r, "typing ") || strings.HasPrefix(r, "confirmation (not") ||
+			strings.HasPrefix(r, "break-glass confirmation") {
+			return true
+		}
+	}
+	return false
+}
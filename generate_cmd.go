@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/admission"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbacgen"
+)
+
+// handleGenerate writes manifests derived from the rules to stdout
+func handleGenerate(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl generate - Derive cluster manifests from the rules
+
+Usage:
+  kctl generate admission-policy [flags] > policy.yaml
+  kctl generate rbac --group GROUP [flags] > rbac.yaml
+
+Subcommands:
+  admission-policy   Enforce the blocked actions in the cluster
+  rbac               Approximate the rules with a role bound to a group
+
+Run 'kctl generate <subcommand> --help' for its flags.
+`)
+		return 0
+	}
+	switch args[0] {
+	case "admission-policy":
+		return generateAdmissionPolicy(args[1:])
+	case "rbac":
+		return generateRBAC(args[1:])
+	}
+	output.PrintError(fmt.Sprintf("Unknown generate subcommand: %s", args[0]))
+	return 1
+}
+
+// generateAdmissionPolicy prints a ValidatingAdmissionPolicy or Kyverno
+// policy rejecting the actions the rules block
+func generateAdmissionPolicy(args []string) int {
+	flags, ok := parseGenerateFlags(args, `kctl generate admission-policy - Enforce blocked actions in the cluster
+
+Usage:
+  kctl generate admission-policy [--context NAME | --tier NAME]
+                                 [--format vap|kyverno] [--name NAME]
+                                 [--exempt-group GROUP]...
+
+Flags:
+  --context NAME        Context whose rules to use. Default: the current context
+  --tier NAME           Use a tier's rules instead
+  --format F            vap: ValidatingAdmissionPolicy and binding (default)
+                        kyverno: Kyverno ClusterPolicy
+  --name NAME           Policy name. Default: kctl-<tier>
+  --exempt-group GROUP  Group allowed to bypass the policy (repeatable)
+
+The policy rejects the blocked actions that map to API requests (delete,
+drain, scale, edit, apply, exec, rollout, node-access, ...) for users
+outside system:. Confirmations can't be asked for in the cluster and stay
+client-side; the manifest lists them in its header.
+`, "--context", "--tier", "--format", "--name", "--exempt-group")
+	if !ok {
+		return flags.exit
+	}
+	rules, ok := generateRules(flags)
+	if !ok {
+		return 1
+	}
+
+	manifest, err := admission.Generate(admission.NewPlan(rules), admission.Options{
+		Name:         flags.last("--name"),
+		Format:       flags.last("--format"),
+		ExemptGroups: flags.values["--exempt-group"],
+	})
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	os.Stdout.Write(manifest)
+	return 0
+}
+
+// generateRBAC prints a role granting what the rules don't block, bound to
+// a group
+func generateRBAC(args []string) int {
+	flags, ok := parseGenerateFlags(args, `kctl generate rbac - Approximate the rules with Kubernetes RBAC
+
+Usage:
+  kctl generate rbac --group GROUP [--context NAME | --tier NAME]
+                     [--name NAME] [--namespace NS]
+
+Flags:
+  --group GROUP    Group to bind the role to, e.g. sre@corp (required)
+  --context NAME   Context whose rules to use. Default: the current context
+  --tier NAME      Use a tier's rules instead
+  --name NAME      Role and binding name. Default: kctl-<tier>
+  --namespace NS   Make a Role and RoleBinding in NS instead of a ClusterRole
+                   and ClusterRoleBinding
+
+The role grants read access to everything plus the verbs of each action
+the rules don't block; confirmed actions are granted outright. RBAC can only
+allow, so the header notes where the role is looser than the rules.
+`, "--group", "--context", "--tier", "--name", "--namespace")
+	if !ok {
+		return flags.exit
+	}
+	if flags.last("--group") == "" {
+		output.PrintError("--group is required")
+		return 1
+	}
+	rules, ok := generateRules(flags)
+	if !ok {
+		return 1
+	}
+
+	manifest, err := rbacgen.Generate(rbacgen.NewPlan(rules), rbacgen.Options{
+		Group:     flags.last("--group"),
+		Name:      flags.last("--name"),
+		Namespace: flags.last("--namespace"),
+	})
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	os.Stdout.Write(manifest)
+	return 0
+}
+
+// generateFlags holds the values of a generate subcommand's flags
+type generateFlags struct {
+	values map[string][]string
+	exit   int
+}
+
+func (f generateFlags) last(name string) string {
+	if v := f.values[name]; len(v) > 0 {
+		return v[len(v)-1]
+	}
+	return ""
+}
+
+// parseGenerateFlags reads args made of the given value flags. ok is false
+// when the command should exit with flags.exit instead: after --help, or
+// on a bad flag.
+func parseGenerateFlags(args []string, help string, names ...string) (generateFlags, bool) {
+	flags := generateFlags{values: map[string][]string{}}
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		switch {
+		case flag == "--help" || flag == "-h":
+			fmt.Print(help)
+			return flags, false
+		case !known[flag]:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", flag))
+			flags.exit = 1
+			return flags, false
+		case i+1 >= len(args):
+			output.PrintError(fmt.Sprintf("%s requires a value", flag))
+			flags.exit = 1
+			return flags, false
+		}
+		flags.values[flag] = append(flags.values[flag], args[i+1])
+		i++
+	}
+	return flags, true
+}
+
+// generateRules returns the rules named by --tier or --context, defaulting
+// to the current context's
+func generateRules(flags generateFlags) (config.ResolvedRules, bool) {
+	cfg := readPinnedConfig()
+	if tier := flags.last("--tier"); tier != "" {
+		rules, ok := cfg.GetTierRules(tier)
+		if !ok {
+			output.PrintError(fmt.Sprintf("Unknown tier: %s", tier))
+		}
+		return rules, ok
+	}
+
+	context := flags.last("--context")
+	if context == "" {
+		current, err := kubectl.GetCurrentContext()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+			return config.ResolvedRules{}, false
+		}
+		context = current
+	}
+	return cfg.GetClusterRules(context), true
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/health"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// checkHealth summarizes the cluster's health before a drain or rollout
+// restart on tiers with health_check, so a drain isn't piled onto a
+// cluster that is already degraded. Dry runs and commands run offline
+// aren't checked.
+func checkHealth(decision policy.Decision) (lines []string, degraded bool) {
+	if !healthChecked(decision) {
+		return nil, false
+	}
+	summary, err := health.Check(decision.Context, time.Now())
+	if err != nil {
+		return []string{fmt.Sprintf("Cluster health unknown: %v", err)}, false
+	}
+	return summary.Lines(), summary.Degraded()
+}
+
+// printHealth prints checkHealth's summary in the confirmation header, or
+// on its own when there is none
+func printHealth(lines []string, degraded, header bool) {
+	if len(lines) == 0 {
+		return
+	}
+	title := "Cluster health:"
+	if degraded {
+		title = "Cluster health: degraded; check it can take this before going on"
+	}
+	if degraded && !header {
+		output.PrintWarning(title)
+	} else {
+		output.PrintSublog(title)
+	}
+	for _, line := range lines {
+		output.PrintSublog("  " + line)
+	}
+}
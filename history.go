@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/history"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// recordHistory stores an executed command, with its secret values
+// redacted, unless history is disabled. Failures are reported but never
+// affect the command's exit code.
+func recordHistory(cfg *config.Config, context string, args []string, exitCode int) {
+	if cfg.History.Disabled {
+		return
+	}
+	err := history.Append(history.Path(), history.Entry{
+		Time:     time.Now().UTC(),
+		Context:  context,
+		Args:     redact.Args(args),
+		ExitCode: exitCode,
+	})
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not record history: %v", err))
+	}
+}
+
+// handleHistory lists recorded commands
+func handleHistory(args []string) int {
+	limit := 50
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl history - List previously executed commands
+
+Usage:
+  kctl history [-n N]
+
+Flags:
+  -n N    Show only the last N entries (default: 50, 0 for all)
+
+Use 'kctl rerun <number>' to run an entry again through the rules.
+`)
+			return 0
+		case "-n":
+			if i+1 >= len(args) {
+				output.PrintError("-n requires a number")
+				return 1
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				output.PrintError(fmt.Sprintf("Invalid number: %s", args[i+1]))
+				return 1
+			}
+			limit = n
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+
+	entries, err := history.Load(history.Path())
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read history: %v", err))
+		return 1
+	}
+
+	start := 0
+	if limit > 0 && len(entries) > limit {
+		start = len(entries) - limit
+	}
+	for i := start; i < len(entries); i++ {
+		entry := entries[i]
+		status := ""
+		if entry.ExitCode != 0 {
+			status = fmt.Sprintf("  (exit %d)", entry.ExitCode)
+		}
+		fmt.Printf("%5d  %s  %s  %s%s\n", i+1,
+			entry.Time.Local().Format("2006-01-02 15:04"), entry.Context,
+			shell.JoinArgs(entry.Args), status)
+	}
+	return 0
+}
+
+// handleRerun re-evaluates and re-executes a history entry on the context
+// it originally ran against
+func handleRerun(args []string) int {
+	skipConfirm, args := extractYesFlag(args)
+	if len(args) != 1 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl rerun - Re-run a command from history
+
+Usage:
+  kctl rerun <number> [--yes]
+
+The command is evaluated against the current rules for the context it
+originally ran on, exactly as if it had been typed again. If the current
+context differs, --context is added so the command targets the same cluster.
+`)
+		if len(args) != 1 {
+			return 1
+		}
+		return 0
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Invalid history number: %s", args[0]))
+		return 1
+	}
+
+	entries, err := history.Load(history.Path())
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read history: %v", err))
+		return 1
+	}
+	if n < 1 || n > len(entries) {
+		output.PrintError(fmt.Sprintf("No history entry %d (have %d)", n, len(entries)))
+		return 1
+	}
+	entry := entries[n-1]
+	if redact.Redacted(entry.Args) {
+		output.PrintError(fmt.Sprintf("#%d had secret values redacted when it was recorded; run it again by hand", n))
+		return 1
+	}
+
+	cfg := loadConfig()
+	cmdArgs := entry.Args
+	kubectl.UseKubeconfigFrom(cmdArgs)
+	if current, err := kubectl.GetCurrentContext(); err != nil || current != entry.Context {
+		if !hasContextFlag(cmdArgs) {
+			cmdArgs = append([]string{"--context", entry.Context}, cmdArgs...)
+			output.PrintSublog(fmt.Sprintf("Targeting original context %s", entry.Context))
+		}
+	}
+
+	output.PrintSublog(fmt.Sprintf("Re-running #%d: kubectl %s", n, shell.JoinArgs(cmdArgs)))
+	return runGuarded(cfg, entry.Context, cmdArgs, skipConfirm)
+}
+
+// hasContextFlag reports whether args already select a context explicitly
+func hasContextFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--context" || strings.HasPrefix(arg, "--context=") {
+			return true
+		}
+	}
+	return false
+}
+
+// historyLines returns recorded commands formatted for the shell's history
+func historyLines() []string {
+	entries, err := history.Load(history.Path())
+	if err != nil {
+		return nil
+	}
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, shell.JoinArgs(entry.Args))
+	}
+	return lines
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// imageNotes warns about the images of an apply or create that break the
+// tier's allowed_registries or require_digests, or can't be checked. With
+// untrusted_images: block, the command was blocked instead.
+func imageNotes(decision policy.Decision) []string {
+	if decision.Rules.UntrustedImages == config.UntrustedImagesBlock {
+		return nil
+	}
+	problems, err := policy.ImageProblems(decision.Args, decision.Action, decision.Rules)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// offlineCommands don't talk to the API server, so version skew doesn't
+// matter to them
+var offlineCommands = map[string]bool{
+	"config": true, "version": true, "completion": true, "plugin": true,
+	"help": true, "options": true, "kustomize": true,
+}
+
+// pinKubectl points kubectl.Binary at the kubectl in PATH. With kubectl_pin
+// set, it must be the pinned binary, and is run by its full path from then
+// on.
+func pinKubectl(cfg *config.Config) error {
+	kubectl.Binary = "kubectl"
+	pin := cfg.KubectlPin
+	if pin == (config.KubectlPinConfig{}) {
+		return nil
+	}
+	path, err := kubectl.VerifyBinary("kubectl", pin.Path, pin.SHA256)
+	if err != nil {
+		return fmt.Errorf("kubectl_pin: %w", err)
+	}
+	kubectl.Binary = path
+	return nil
+}
+
+// selectKubectl points kubectl.Binary at the kubectl configured for
+// context in kubectl_binaries, or the one in PATH (see pinKubectl), and warns when it is
+// more than one minor version away from the cluster's API server
+func selectKubectl(cfg *config.Config, context string, args []string) error {
+	if err := pinKubectl(cfg); err != nil {
+		return err
+	}
+	if spec, ok := cfg.KubectlBinary(context); ok {
+		path, err := kubectl.ResolveBinary(spec)
+		if err != nil {
+			return fmt.Errorf("kubectl_binaries: %w", err)
+		}
+		kubectl.Binary = path
+	}
+	if context == "" || offlineCommands[rbac.DetectAction(args)] {
+		return nil
+	}
+
+	cache := filepath.Join(config.CacheDir(), "kubectl-versions.json")
+	client, server := kubectl.CachedVersions(context, cache, time.Now())
+	if skew, ok := kubectl.MinorSkew(client, server); ok && (skew > 1 || skew < -1) {
+		direction := "newer"
+		if skew < 0 {
+			direction, skew = "older", -skew
+		}
+		output.PrintWarning(fmt.Sprintf("kubectl %s is %d minor versions %s than the %s API server of %s; kubectl supports one. Pin a matching kubectl in kubectl_binaries.",
+			client, skew, direction, server, context))
+	}
+	return nil
+}
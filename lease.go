@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lease"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// takeLease acquires the cluster's operation lease when the action needs
+// one. It returns the context to run the command in, a function releasing
+// the lease, and false when the command must not run because the lease is
+// held or couldn't be taken. The context ends when the lease is lost or
+// kctl is interrupted, so the command stops and the lease is released
+// rather than left to lapse. The lease shows the command to everyone who
+// can read it, so its secret values are redacted.
+func takeLease(cfg *config.Config, decision policy.Decision) (context.Context, func(), bool) {
+	if !cfg.Lease.Enabled || decision.Offline || !leaseCovers(cfg.Lease, decision.Action) {
+		return context.Background(), func() {}, true
+	}
+
+	l := lease.New(decision.Context, cfg.Lease)
+	if err := l.Acquire(shell.JoinArgs(redact.Args(decision.Args))); err != nil {
+		var held *lease.HeldError
+		if errors.As(err, &held) {
+			output.PrintBlocked(decision.Action, decision.Context,
+				fmt.Sprintf("Lease %s/%s is held by %s", l.Namespace, l.Name, held.Holder), output.Guidance{})
+			output.PrintSublog(held.Error())
+			return nil, nil, false
+		}
+		output.PrintError(fmt.Sprintf("Could not take lease %s/%s: %v", l.Namespace, l.Name, err))
+		output.PrintSublog("Set lease.enabled: false to run without coordination")
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			cancel(fmt.Errorf("interrupted by %s", sig))
+		case <-done:
+		}
+	}()
+	stop := l.KeepAlive(func(err error) {
+		cancel(fmt.Errorf("lost lease %s/%s: %w", l.Namespace, l.Name, err))
+	})
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		stop()
+		cancel(nil)
+		if err := l.Release(); err != nil {
+			output.PrintWarning(fmt.Sprintf("Could not release lease %s/%s (it lapses after %s): %v",
+				l.Namespace, l.Name, l.Duration, err))
+		}
+	}, true
+}
+
+// leaseCovers reports whether action takes the lease
+func leaseCovers(cfg config.LeaseConfig, action string) bool {
+	for _, a := range lease.Actions(cfg) {
+		if a == action || a == "*" {
+			return true
+		}
+	}
+	return false
+}
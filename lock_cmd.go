@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/identity"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lock"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// currentLock returns the active lock, warning when the lock file can't be
+// read. An unreadable lock file is treated as a block so a lock is never
+// lost silently.
+func currentLock() *lock.State {
+	state, err := lock.Load(lock.Path())
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not read lock: %v (treating as locked; run 'kctl unlock' to clear)", err))
+		return &lock.State{Mode: lock.ModeBlock, Reason: "unreadable lock file"}
+	}
+	return state
+}
+
+// handleLock makes every mutating action need confirmation, or be blocked,
+// on every cluster until unlocked or expired
+func handleLock(args []string) int {
+	state := lock.State{Mode: lock.ModeConfirm, LockedAt: time.Now().UTC()}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl lock - Make every cluster read-only for a while
+
+Usage:
+  kctl lock [--duration 2h] [--block] [--reason TEXT]
+
+Flags:
+  --duration D   Lift the lock automatically after D (e.g. 30m, 2h)
+  --block        Block mutating actions instead of requiring confirmation
+  --reason TEXT  Shown with every confirmation or block
+
+While locked, every mutating action (delete, apply, scale, exec, ...) needs
+confirmation on every cluster, even where the rules allow it, and --yes does
+not skip it. Read-only commands are unaffected. Run 'kctl unlock' to lift it.
+`)
+			return 0
+		case "--duration", "-d":
+			if i+1 >= len(args) {
+				output.PrintError("--duration requires a value")
+				return 1
+			}
+			d, err := time.ParseDuration(args[i+1])
+			if err != nil || d <= 0 {
+				output.PrintError(fmt.Sprintf("Invalid duration: %s", args[i+1]))
+				return 1
+			}
+			state.ExpiresAt = state.LockedAt.Add(d)
+			i++
+		case "--block":
+			state.Mode = lock.ModeBlock
+		case "--reason":
+			if i+1 >= len(args) {
+				output.PrintError("--reason requires a value")
+				return 1
+			}
+			state.Reason = args[i+1]
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+
+	if err := lock.Save(lock.Path(), state); err != nil {
+		output.PrintError(fmt.Sprintf("Could not save lock: %v", err))
+		return 1
+	}
+	output.PrintSuccess("Locked: " + describeLock(&state))
+	return 0
+}
+
+// handleUnlock lifts the lock
+func handleUnlock(args []string) int {
+	if len(args) > 0 {
+		fmt.Print(`kctl unlock - Lift a lock set with 'kctl lock'
+
+Usage:
+  kctl unlock
+`)
+		if args[0] != "--help" && args[0] != "-h" {
+			return 1
+		}
+		return 0
+	}
+
+	active, err := lock.Clear(lock.Path())
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not remove lock: %v", err))
+		return 1
+	}
+	if !active {
+		output.PrintInfo("kctl was not locked")
+		return 0
+	}
+	output.PrintSuccess("Unlocked")
+	return 0
+}
+
+// handleStatus shows the lock and the rules for the current context
+func handleStatus(args []string) int {
+	if len(args) > 0 {
+		fmt.Print(`kctl status - Show the lock and the current context
+
+Usage:
+  kctl status
+`)
+		if args[0] != "--help" && args[0] != "-h" {
+			return 1
+		}
+		return 0
+	}
+
+	state := currentLock()
+	if state == nil {
+		fmt.Println("Lock:     unlocked")
+	} else {
+		fmt.Printf("Lock:     %s\n", describeLock(state))
+	}
+
+	cfg := readPinnedConfig()
+	if context, err := kubectl.GetCurrentContext(); err == nil {
+		rules := cfg.GetClusterRules(context)
+		fmt.Printf("Context:  %s (%s)\n", context, rules.Tier)
+		if id := identity.Resolve(context); id.Username != "" {
+			fmt.Printf("Identity: %s (from %s)\n", id.Username, id.Source)
+		}
+		if rules.Maintenance != "" {
+			fmt.Printf("Maintenance: window '%s' is open; blocked actions need confirmation\n", rules.Maintenance)
+		}
+		if rules.Freeze != "" {
+			fmt.Printf("Freeze:   window '%s' is open; changes are blocked\n", rules.Freeze)
+		}
+	} else {
+		fmt.Println("Context:  none")
+	}
+	if profile := config.Profile(); profile != "" {
+		fmt.Printf("Config:   %s (profile %s)\n", config.ConfigPath(), profile)
+	} else {
+		fmt.Printf("Config:   %s\n", config.ConfigPath())
+	}
+	return 0
+}
+
+// describeLock summarizes state for humans
+func describeLock(state *lock.State) string {
+	parts := []string{"mutating actions need confirmation"}
+	if state.Mode == lock.ModeBlock {
+		parts[0] = "mutating actions are blocked"
+	}
+	if state.ExpiresAt.IsZero() {
+		parts = append(parts, "until 'kctl unlock'")
+	} else {
+		remaining := output.FormatDuration(time.Until(state.ExpiresAt).Round(time.Minute))
+		parts = append(parts, fmt.Sprintf("until %s (%s left)", state.ExpiresAt.Local().Format("15:04 Jan 2"), remaining))
+	}
+	if state.Reason != "" {
+		parts = append(parts, "— "+state.Reason)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// expandMacro turns 'run NAME param=value...' into the kubectl args of the
+// macro NAME. ok is false when args don't name a macro, which leaves 'run'
+// to kubectl.
+func expandMacro(cfg *config.Config, args []string) (expanded []string, ok bool, err error) {
+	if len(args) < 2 || args[0] != "run" {
+		return nil, false, nil
+	}
+	name := args[1]
+	macro, ok := cfg.Macros[name]
+	if !ok {
+		return nil, false, nil
+	}
+	values := make(map[string]string)
+	for _, arg := range args[2:] {
+		param, value, found := strings.Cut(arg, "=")
+		if !found || param == "" {
+			return nil, true, fmt.Errorf("macro '%s' takes parameters as name=value, got '%s'", name, arg)
+		}
+		values[param] = value
+	}
+	expanded, err = macro.Expand(values)
+	if err != nil {
+		return nil, true, fmt.Errorf("macro '%s': %w", name, err)
+	}
+	output.PrintSublog(fmt.Sprintf("Macro %s: kubectl %s", name, shell.JoinArgs(expanded)))
+	return expanded, true, nil
+}
+
+// handleMacros lists the configured macros
+func handleMacros(args []string) int {
+	if len(args) > 0 {
+		fmt.Printf(`%s macros - List the macros defined in the config
+
+Usage:
+  %[1]s macros
+
+Run one with '%[1]s run NAME param=value...'. The command it expands to is
+checked against the rules like any other.
+`, progName)
+		if args[0] != "--help" && args[0] != "-h" {
+			return 1
+		}
+		return 0
+	}
+
+	cfg := readConfig()
+	if len(cfg.Macros) == 0 {
+		fmt.Println("No macros defined; add them under 'macros' in the config")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPARAMETERS\tDESCRIPTION")
+	names := make([]string, 0, len(cfg.Macros))
+	for name := range cfg.Macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := cfg.Macros[name]
+		var params []string
+		for _, param := range m.Params() {
+			if value, ok := m.Defaults[param]; ok {
+				param += "=" + value
+			}
+			params = append(params, param)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, strings.Join(params, " "), m.Description)
+	}
+	w.Flush()
+	return 0
+}
@@ -3,14 +3,22 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/apiresources"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/approval"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/clustermeta"
 	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/gitsync"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/identity"
 	initpkg "github.com/bobbydrake/kubectl-enhanced-cli/pkg/init"
 	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
 	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
 	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
 )
 
 // Version information (set at build time with -ldflags)
@@ -24,8 +32,26 @@ func main() {
 
 	// Detect if running as kubectl plugin (kubectl enhanced ...)
 	// In plugin mode, kubectl strips "enhanced" from args
-	execName := filepath.Base(os.Args[0])
-	isPlugin := execName == "kubectl-enhanced"
+	execName := invokedAs()
+	if execName == completionBinary {
+		os.Exit(handleCompletion(args))
+	}
+	isPlugin := execName == pluginBinary
+	if isPlugin {
+		progName = "kubectl enhanced"
+	}
+
+	// Pick the config file before anything reads it
+	profile, args := extractProfileFlag(args)
+
+	// As a credential plugin kctl runs under kubectl; every other command
+	// marks the kubectl it runs as checked
+	if len(args) > 0 && args[0] == "exec-credential" {
+		os.Exit(handleExecCredential(args[1:]))
+	}
+	// Without a registered nonce exec-credential treats kctl's own kubectl
+	// as raw kubectl, which only matters while it refuses credentials
+	_ = kubectl.MarkWrapped(wrappedDir())
 
 	// Handle version flag
 	if len(args) > 0 && (args[0] == "--version" || args[0] == "-v") {
@@ -34,11 +60,16 @@ func main() {
 	}
 
 	// Handle help flag
-	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" || args[0] == "help" {
 		printUsage(isPlugin)
 		os.Exit(0)
 	}
 
+	if err := selectProfile(profile, args); err != nil {
+		output.PrintError(err.Error())
+		os.Exit(1)
+	}
+
 	// Handle config-path flag
 	if len(args) > 0 && args[0] == "--config-path" {
 		fmt.Println(config.ConfigPath())
@@ -51,30 +82,249 @@ func main() {
 		return
 	}
 
+	// Handle shell command
+	if len(args) > 0 && args[0] == "shell" {
+		os.Exit(handleShell(args[1:]))
+	}
+
+	// Handle history commands
+	if len(args) > 0 && args[0] == "history" {
+		os.Exit(handleHistory(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "rerun" {
+		os.Exit(handleRerun(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "pick" {
+		os.Exit(handlePick(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "alias" {
+		os.Exit(handleAlias(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "macros" {
+		os.Exit(handleMacros(args[1:]))
+	}
+
+	// Handle kctl's own config subcommands; the rest go to kubectl config
+	if len(args) > 1 && args[0] == "config" && isKctlConfigCommand(args[1]) {
+		os.Exit(handleConfig(args[1:]))
+	}
+
+	// Handle the read-only lock
+	if len(args) > 0 && args[0] == "lock" {
+		os.Exit(handleLock(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "unlock" {
+		os.Exit(handleUnlock(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "status" {
+		os.Exit(handleStatus(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "whoami" {
+		os.Exit(handleWhoami(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "mfa" {
+		os.Exit(handleMFA(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "secret" {
+		os.Exit(handleSecret(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "generate" {
+		os.Exit(handleGenerate(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "schedule" {
+		os.Exit(handleSchedule(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		os.Exit(handleServe(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "proxy" {
+		os.Exit(handleProxy(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "ui" {
+		os.Exit(handleUI(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "simulate" {
+		os.Exit(handleSimulate(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "policy" {
+		os.Exit(handlePolicy(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "audit" {
+		os.Exit(handleAudit(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "maintenance" {
+		os.Exit(handleMaintenance(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "cache" {
+		os.Exit(handleCache(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "self-update" {
+		os.Exit(handleSelfUpdate(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "profile" {
+		os.Exit(handleProfile(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "telemetry" {
+		os.Exit(handleTelemetry(args[1:]))
+	}
+
+	// Handle context/namespace switchers
+	if len(args) > 0 && args[0] == "ctx" {
+		os.Exit(handleCtx(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "ns" {
+		os.Exit(handleNs(args[1:]))
+	}
+
+	if isPlugin {
+		args = withPluginGlobalFlags(args)
+	}
+	cfg := loadConfig()
+	pruneIfDue(cfg)
+	noticeUpdate(cfg)
+	noticeDrift(cfg)
+
+	// Expand an alias before anything looks at the command
+	args, err := expandAlias(cfg, args)
+	if err != nil {
+		output.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	// Extract --yes/-y flags before processing
+	hasYesFlag, args := extractYesFlag(args)
+
+	// Expand 'run NAME param=value...' when NAME is a macro
+	if expanded, ok, err := expandMacro(cfg, args); ok {
+		if err != nil {
+			output.PrintError(err.Error())
+			os.Exit(1)
+		}
+		args = expanded
+	}
+
+	// Read the kubeconfig the command names, as kubectl will
+	kubectl.UseKubeconfigFrom(args)
+
+	// Resolve the context the command will run against
+	context, err := resolveContext(args)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		output.PrintSublog("Make sure kubectl is configured with a valid context")
+		os.Exit(1)
+	}
+
+	// Plan a drain without touching the node
+	if plan, args := extractPlanFlag(args); plan {
+		os.Exit(planDrain(context, args))
+	}
+
+	os.Exit(runGuarded(cfg, context, args, hasYesFlag))
+}
+
+// resolveContext returns the context selected with --context in args,
+// falling back to the current kubectl context
+func resolveContext(args []string) (string, error) {
+	if context, ok := kubectl.GetContextFromArgs(args); ok {
+		return context, nil
+	}
+	return kubectl.GetCurrentContext()
+}
+
+// loadConfig checks for kubectl and loads the configuration, falling back
+// to defaults when no config file exists
+func loadConfig() *config.Config {
 	// Check if kubectl is available
 	if !kubectl.CheckKubectlAvailable() {
 		output.PrintError("kubectl not found in PATH")
 		os.Exit(1)
 	}
 
+	return readPinnedConfig()
+}
+
+// readPinnedConfig loads the configuration and points kubectl.Binary at the
+// pinned kubectl, for commands that work without kubectl but must never run
+// one kubectl_pin rejects
+func readPinnedConfig() *config.Config {
+	cfg := readConfig()
+	if err := pinKubectl(cfg); err != nil {
+		output.PrintError(err.Error())
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// readConfig loads the configuration without requiring kubectl
+func readConfig() *config.Config {
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfigFile()
 	if err != nil {
 		if !os.IsNotExist(err) {
 			output.PrintWarning(fmt.Sprintf("Could not load config: %v (using defaults)", err))
 		}
 		cfg = config.Default()
 	}
+	return prepareConfig(cfg)
+}
 
-	// Get current kubectl context
-	context, err := kubectl.GetCurrentContext()
-	if err != nil {
-		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
-		output.PrintSublog("Make sure kubectl is configured with a valid context")
-		os.Exit(1)
+// prepareConfig connects cfg to the cluster lookups and applies its output
+// settings
+func prepareConfig(cfg *config.Config) *config.Config {
+	cfg.ServerLookup = kubectl.GetServer
+	cfg.ResourceLookup = apiresources.NewResolver().Lookup
+	cfg.NamespaceLookup = kubectl.GetContextNamespace
+	attempts, backoff, maxBackoff := cfg.Retry.Policy()
+	kubectl.Retry = kubectl.RetryPolicy{Attempts: attempts, Backoff: backoff, MaxBackoff: maxBackoff, Notify: reportRetry}
+	if cfg.ClusterMeta.Enabled {
+		cfg.TierLookup = clustermeta.NewResolver(cfg.ClusterMeta).Tier
 	}
 
-	// Extract --yes/-y flags before processing
+	if !output.SetChromeStream(cfg.Output.ChromeStream) {
+		output.PrintWarning(fmt.Sprintf("Unknown output.chrome_stream %q (using auto)", cfg.Output.ChromeStream))
+	}
+	for name, v := range map[string]string{"summary_after": cfg.Output.SummaryAfter, "spinner_after": cfg.Output.SpinnerAfter} {
+		if _, err := time.ParseDuration(v); err != nil && v != "" && v != "off" {
+			output.PrintWarning(fmt.Sprintf("Invalid output.%s %q (using the default)", name, v))
+		}
+	}
+
+	return cfg
+}
+
+// guidance returns the configured message and docs link for a decision
+func guidance(d policy.Decision) output.Guidance {
+	return output.Guidance{Message: d.Message, DocsURL: d.DocsURL, Suggestions: d.Suggestions}
+}
+
+// loadConfigFile loads the config file, layered over the shared policy
+// repository when it names one
+func loadConfigFile() (*config.Config, error) {
+	path := config.ConfigPath()
+	src, err := config.LoadSource(path)
+	if err != nil || src.Type == "" {
+		return config.Load()
+	}
+	if err := gitsync.Validate(src); err != nil {
+		output.PrintWarning(fmt.Sprintf("Ignoring config source: %v", err))
+		return config.Load()
+	}
+
+	if gitsync.Due(src) {
+		if err := gitsync.Sync(src, autoSyncTimeout); err != nil {
+			output.PrintWarning(fmt.Sprintf("Could not sync shared config (using last synced copy): %v", err))
+		}
+	}
+	shared, _ := gitsync.ConfigFile(src)
+	if _, err := os.Stat(shared); err != nil {
+		output.PrintWarning(fmt.Sprintf("Shared config %s has not been synced yet; run 'kctl config sync'", src.URL))
+		return config.Load()
+	}
+	return config.LoadWithBase(shared, path)
+}
+
+// extractYesFlag removes --yes/-y from args and reports whether it was present
+func extractYesFlag(args []string) (bool, []string) {
 	hasYesFlag := false
 	filteredArgs := make([]string, 0, len(args))
 	for _, arg := range args {
@@ -84,44 +334,190 @@ func main() {
 			filteredArgs = append(filteredArgs, arg)
 		}
 	}
-	args = filteredArgs
-
-	// Detect the action from kubectl args
-	action := rbac.DetectAction(args)
+	return hasYesFlag, filteredArgs
+}
 
-	// Get rules for the current cluster
-	rules := cfg.GetClusterRules(context)
+// runGuarded evaluates args against the rules for context and runs kubectl
+// if allowed, prompting for confirmation when required. Returns the exit code.
+func runGuarded(cfg *config.Config, context string, args []string, skipConfirm bool) int {
+	ticketID, args := extractTicketFlag(args)
+	if err := selectKubectl(cfg, context, args); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	// A manifest piped to -f - is read first, so its objects are decided
+	// and the confirmation can show what it holds
+	if err := readPipedManifest(args); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	g := decide(cfg, context, args, ticketID, skipConfirm, false)
+	recordUsage(cfg, g.decision)
+	args = g.decision.Args
+	if len(g.decision.AddedFlags) > 0 {
+		output.PrintSublog(fmt.Sprintf("Adding %s (tier %s)", strings.Join(g.decision.AddedFlags, " "), g.decision.Tier))
+	}
+	// Deletes on some tiers wait until their objects are gone
+	if g.deleteWait > 0 {
+		output.PrintSublog(fmt.Sprintf("Waiting up to %s for the delete to finish (tier %s)", g.deleteWait, g.decision.Tier))
+	}
+	g.decision.Offline, g.unreachable = checkReachable(cfg, g.decision)
+	if cfg.Audit.Enabled || g.decision.Verdict != policy.Allow || g.decision.Rules.ApprovalCommand != "" {
+		if g.decision.Offline {
+			g.decision.Identity = identity.ResolveOffline(context).Username
+		} else {
+			g.decision.Identity = identity.Resolve(context).Username
+		}
+	}
 
 	// Check if action is blocked
-	if rbac.IsBlocked(action, rules) {
-		output.PrintBlocked(action, context, fmt.Sprintf("Action '%s' is configured as blocked for tier '%s'", action, rules.Tier))
-		os.Exit(1)
+	if g.decision.Verdict == policy.Block {
+		output.PrintBlocked(g.decision.Action, context, g.decision.Reason, guidance(g.decision))
+		recordAudit(cfg, g.decision, audit.Entry{Outcome: audit.OutcomeBlocked})
+		return 1
 	}
-
-	// Check if confirmation is required
-	if rbac.RequiresConfirmation(action, rules) && !hasYesFlag {
+	if stop := g.runGates(); stop != nil {
+		if stop.outcome == audit.OutcomeCancelled {
+			output.PrintError(stop.err.Error())
+		} else {
+			output.PrintBlocked(g.decision.Action, context, stop.err.Error(), guidance(g.decision))
+		}
+		recordAudit(cfg, g.decision, audit.Entry{Outcome: stop.outcome, Reason: stop.err.Error()})
+		return 1
+	}
+	decision := g.decision
+
+	promptConfirm, phrase := g.confirmation()
+	notes := deletionNotes(g.targets)
+	notes = append(notes, capacityNotes(decision)...)
+	notes = append(notes, quotaNotes(decision)...)
+	notes = append(notes, imageNotes(decision)...)
+	notes = append(notes, g.nameNotes...)
+	mfaRequired := needsMFA(decision)
+	if promptConfirm || mfaRequired {
 		namespace := kubectl.GetNamespace(args)
-		
+
 		output.PrintConfirmationHeader(
-			rbac.DescribeAction(action),
+			rbac.DescribeAction(decision.Action),
 			context,
-			rules.Tier,
+			decision.Tier,
+			guidance(decision),
 		)
+		if decision.Locked || decision.Maintenance != "" {
+			output.PrintSublog(decision.Reason)
+		}
+		if decision.Identity != "" {
+			output.PrintSublog(fmt.Sprintf("Identity: %s", decision.Identity))
+		}
 		output.PrintSublog(fmt.Sprintf("Namespace: %s", namespace))
-		output.PrintSublog(fmt.Sprintf("Command: kubectl %s", formatArgs(args)))
+		if g.ticket != nil && g.ticket.State != "" {
+			output.PrintSublog(fmt.Sprintf("Ticket: %s (%s)", g.ticket.ID, g.ticket.State))
+		} else if ticketID != "" {
+			output.PrintSublog(fmt.Sprintf("Ticket: %s", ticketID))
+		}
+		output.PrintSublog(fmt.Sprintf("Command: kubectl %s", formatArgs(redact.Args(args))))
+		printChanges(decision)
+		printSelected(decision)
+		printSources(decision)
+		if decision.Plugin != "" {
+			output.PrintSublog(fmt.Sprintf("Plugin: %s", decision.Plugin))
+		}
+		if len(g.retype) > 0 {
+			output.PrintSublog(fmt.Sprintf("Deletes namespace %s and everything in it", strings.Join(g.retype, ", ")))
+		}
+		for _, reason := range g.uncertain {
+			output.PrintWarning(fmt.Sprintf("Confirmation required: %s", reason))
+		}
+		printNotes(notes, true)
+		printForeign(g.foreign)
+		if g.ownersUnknown != nil {
+			output.PrintWarning(fmt.Sprintf("Confirmation required: %v", g.ownersUnknown))
+		}
+		printHealth(g.healthLines, g.degraded, true)
+		if mfaRequired {
+			output.PrintSublog("Critical action: a TOTP code is required")
+		}
 		fmt.Fprintln(os.Stderr) // Empty line for spacing
 
-		confirmed := output.PromptConfirmation("Do you want to proceed?")
-		if !confirmed {
-			output.PrintSublog("Operation cancelled by user")
-			os.Exit(0)
+		if promptConfirm {
+			confirmed, edit := output.PromptConfirmationOrEdit("Do you want to proceed?", phrase)
+			if edit {
+				recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeCancelled, Reason: "edited before running"})
+				return runEdited(cfg, context, args, ticketID, skipConfirm)
+			}
+			if !confirmed {
+				output.PrintSublog("Operation cancelled by user")
+				recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeCancelled})
+				return 0
+			}
+		}
+		if mfaRequired {
+			if err := promptMFA(); err != nil {
+				output.PrintBlocked(decision.Action, context, err.Error(), guidance(decision))
+				recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeBlocked, Reason: err.Error()})
+				return 1
+			}
 		}
 		fmt.Fprintln(os.Stderr) // Empty line before output
 	}
+	if !promptConfirm && !mfaRequired {
+		printNotes(notes, false)
+		printHealth(g.healthLines, g.degraded, false)
+		if g.ownersUnknown != nil {
+			output.PrintWarning(fmt.Sprintf("Skipped with --yes: %v", g.ownersUnknown))
+		}
+	}
+
+	// Gated actions also need the tier's external approval, if any
+	if g.needsApprovalCommand() {
+		output.PrintSublog("Requesting approval...")
+		if err := approval.Run(decision.Rules.ApprovalCommand, decision); err != nil {
+			output.PrintBlocked(decision.Action, context, err.Error(), guidance(decision))
+			recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeBlocked, Reason: err.Error()})
+			return 1
+		}
+	}
+
+	if decision.Rules.Banner {
+		output.PrintBanner(context, decision.Tier)
+	}
+
+	leaseCtx, release, ok := takeLease(cfg, decision)
+	if !ok {
+		recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeBlocked, Reason: "operation lease not acquired"})
+		return 1
+	}
+	defer release()
 
 	// Execute kubectl command
-	exitCode := kubectl.Execute(args)
-	os.Exit(exitCode)
+	start := time.Now()
+	capture := captureOutput(cfg, decision)
+	beforeEdit := snapshotEdit(cfg, decision)
+	stopWatching := watchDeletion(context, g.targets, g.deleteWait)
+	exitCode := execute(leaseCtx, cfg, args, capture, decision.Rules.TimeoutFor(decision.Action))
+	stopWatching(exitCode)
+	elapsed := time.Since(start)
+	if threshold, ok := durationSetting(cfg.Output.SummaryAfter, defaultSummaryAfter); ok && decision.Verdict == policy.Confirm && elapsed >= threshold {
+		output.PrintSummary(decision.Action, context, exitCode, elapsed)
+	}
+
+	var verification *audit.Verification
+	if exitCode == 0 {
+		verification = verifyOutcome(cfg, decision)
+	}
+	recordHistory(cfg, context, args, exitCode)
+	recordAudit(cfg, decision, audit.Entry{
+		Outcome:      audit.OutcomeExecuted,
+		ExitCode:     exitCode,
+		DurationMs:   elapsed.Milliseconds(),
+		Verification: verification,
+		Output:       capture.record(cfg),
+		Changes:      editChanges(decision, beforeEdit),
+	})
+	if exitCode == 0 {
+		watchRollouts(cfg, decision)
+	}
+	return exitCode
 }
 
 func printUsage(isPlugin bool) {
@@ -137,6 +533,15 @@ func printUsage(isPlugin bool) {
 Usage:
   %s <kubectl-args>
   %s init [flags]            # Create/configure config file
+  %s shell                   # Interactive guarded kubectl prompt
+  %s history                 # List previously executed commands
+  %s rerun <n>               # Re-run history entry n through the rules
+  %s ctx [name]              # List or switch contexts (shows tiers)
+  %s config show             # Print the effective kctl configuration
+  %s config get|set <path>   # Read or change one config value
+  %s ns [name]               # List or switch the current namespace
+  %s lock [--duration 2h]    # Require confirmation for every mutation
+  %s run <macro> [k=v...]    # Run a macro from the config through the rules
 
 Description:
   A kubectl wrapper that adds safety controls for production clusters.
@@ -150,12 +555,75 @@ Invocation Modes:
 Commands:
   init          Create a configuration file (interactive or scripted)
                 Run '%s init --help' for more information
+  shell         Interactive prompt that checks every command against the rules
+  history       List previously executed commands (-n N for the last N)
+  rerun <n>     Re-evaluate and re-run history entry n on its original context
+  run <macro> [name=value...]
+                Run a macro defined under 'macros' in the config; the command
+                it expands to is checked like any other (other 'run' commands
+                are passed to kubectl)
+  macros        List the macros defined in the config
+  pick TYPE [-- COMMAND]
+                Choose an object of TYPE from a list (recently used first)
+                and run COMMAND on it, e.g. 'pick pods -- logs -f'
+  alias         List aliases; 'alias add NAME "EXPANSION"' adds one and
+                'alias rm NAME' removes it. 'kctl NAME ARGS' runs the
+                expansion followed by ARGS, checked against the rules
+  ctx [name]    List contexts with tiers, or switch (fuzzy; prod needs confirmation)
+  ns [name]     List namespaces, or set the current context's namespace (fuzzy)
+  lock          Make mutating actions need confirmation (--block: be blocked)
+                on every cluster until 'unlock' or --duration elapses
+  unlock        Lift the lock
+  status        Show the lock and the current context
+  whoami        Show the context, tier, namespace, user and groups, lock,
+                temporary rules and resolved rules in one view (-o yaml|json)
+  mfa enroll    Set up the TOTP code asked for before critical actions
+  secret        Store integration tokens in the OS keyring (set/get/rm)
+  generate admission-policy
+                Print a ValidatingAdmissionPolicy or Kyverno policy enforcing
+                the context's blocked actions in the cluster
+  generate rbac Print a Role/ClusterRole and binding approximating the rules
+  schedule      Run a command later (--at 22:00), checked when queued and
+                again when it runs; list, cancel and run the queue
+  simulate -- <kubectl-args>
+                Show the verdict and matched rule for a command without
+                running it
+  policy test   Check the rules against expected decisions in a tests file
+  audit replay  Show which audited commands a candidate config would decide
+                differently (--policy new-config.yaml)
+  serve         Answer policy decisions over HTTP for bots and CI
+  proxy         Serve a context's API locally like 'kubectl proxy', refusing
+                requests the rules block (for curl, k9s and scripts)
+  ui            Launch k9s (or ui.command), read-only on production tiers
+                and while locked; the session is audited
+  maintenance gc
+                Prune the audit log to audit.max_age / audit.max_size
+  cache clear   Forget the resource types read with 'kubectl api-resources'
+                (--context NAME for one context)
+  self-update   Install the latest release (--check to only look)
+  profile       List profiles; 'profile use NAME' sets the default one
+  telemetry     Opt in to anonymous usage reports (on, off, status)
+  exec-credential --context NAME -- <command>
+                Wrap a kubeconfig credential plugin so raw kubectl gets no
+                credentials while kctl is locked
+  config show   Print the effective configuration and resolved rules
+  config get    Print one config value, e.g. 'config get clusters.prod.tier'
+  config set    Change one config value, e.g.
+                'config set tiers.production.blocked_actions delete,drain'
+  config unset  Remove a config value
+  config sync   Pull the shared policy repository named in 'source'
+  config validate  Check the config and report expired temporary rules
+                (other 'config' subcommands are passed to kubectl)
 
 Flags:
   --yes, -y       Skip confirmation prompts
   --version, -v   Print version information
   --help, -h      Print this help message
   --config-path   Print the config file path
+  --kctl-profile NAME
+                  Use the rules of profile NAME (also: KCTL_PROFILE)
+  --kctl-plan     With drain: show which pods would evict and which would
+                  block it, without touching the node
 
 Configuration:
   Config file: %s
@@ -177,7 +645,7 @@ Protected Actions (configurable):
   - drain     Drain/cordon nodes
 
 For more information, see the README.md
-`, cmdExample, cmdExample, cmdExample, config.ConfigPath(), cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample)
+`, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, config.ConfigPath(), cmdExample, cmdExample, cmdExample, cmdExample, cmdExample, cmdExample)
 }
 
 func formatArgs(args []string) string {
@@ -199,6 +667,11 @@ func handleInit(args []string) {
 			opts.NonInteractive = true
 		case "--force", "-f":
 			opts.Force = true
+		case "--merge", "-m":
+			opts.Merge = true
+		case "--from-kubeconfig":
+			opts.FromKubeconfig = true
+			opts.NonInteractive = true
 		case "--output", "-o":
 			if i+1 < len(args) {
 				opts.OutputPath = args[i+1]
@@ -251,10 +724,10 @@ func handleInit(args []string) {
 }
 
 func printInitUsage() {
-	fmt.Printf(`kctl init - Create a configuration file
+	fmt.Printf(`%[2]s init - Create a configuration file
 
 Usage:
-  kctl init [flags]
+  %[2]s init [flags]
 
 Description:
   Creates a configuration file for kubectl-enhanced-cli. By default, runs in
@@ -265,7 +738,12 @@ Flags:
   -h, --help              Show this help message
   -n, --non-interactive   Run in non-interactive mode (uses defaults or provided flags)
   -f, --force             Overwrite existing config file without prompting
-  -o, --output PATH       Write config to a custom path (default: %s)
+  -m, --merge             Add new clusters, tiers and actions to an existing config,
+                          keeping existing entries and comments
+  -o, --output PATH       Write config to a custom path (default: %[1]s)
+  --from-kubeconfig       Classify every kubeconfig context by patterns, server URL
+                          and provider, and write an explicit cluster entry for each
+                          (non-interactive; prints a classification report)
 
 Non-interactive mode options:
   --prod-patterns PATTERNS      Comma-separated production cluster patterns
@@ -282,20 +760,26 @@ Non-interactive mode options:
 
 Examples:
   # Interactive mode (recommended for first-time setup)
-  kctl init
+  %[2]s init
 
   # Non-interactive with defaults
-  kctl init --non-interactive
+  %[2]s init --non-interactive
 
   # Non-interactive with custom patterns
-  kctl init -n --prod-patterns "prod-*,*-prd" --prod-actions "delete,drain,scale"
+  %[2]s init -n --prod-patterns "prod-*,*-prd" --prod-actions "delete,drain,scale"
+
+  # Add newly detected clusters to an existing config
+  %[2]s init --merge
+
+  # Write a reviewable entry per kubeconfig context
+  %[2]s init --from-kubeconfig
 
   # Overwrite existing config
-  kctl init --force
+  %[2]s init --force
 
   # Write to custom location
-  kctl init -o /path/to/config.yaml
-`, config.ConfigPath())
+  %[2]s init -o /path/to/config.yaml
+`, config.ConfigPath(), progName)
 }
 
 func parseCommaSeparated(input string) []string {
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/retention"
+)
+
+// handleMaintenance runs housekeeping on kctl's own data
+func handleMaintenance(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl maintenance - Housekeeping for kctl's data
+
+Usage:
+  kctl maintenance gc [--dry-run]
+
+gc prunes the audit log to audit.max_age and audit.max_size, oldest entries
+first. It also runs on its own once a day when either is set. With
+--dry-run it reports what would be removed without changing anything.
+`)
+		return 0
+	}
+	if args[0] != "gc" {
+		output.PrintError(fmt.Sprintf("Unknown maintenance command: %s", args[0]))
+		return 1
+	}
+
+	dryRun := false
+	for _, arg := range args[1:] {
+		if arg != "--dry-run" {
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", arg))
+			return 1
+		}
+		dryRun = true
+	}
+
+	cfg := readConfig()
+	policy, err := retention.ParsePolicy(cfg.Audit.MaxAge, cfg.Audit.MaxSize)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Invalid audit retention: %v", err))
+		return 1
+	}
+	if !policy.Enabled() {
+		fmt.Println("No retention configured (audit.max_age, audit.max_size); nothing to prune")
+		return 0
+	}
+
+	result, err := retention.Prune(audit.Path(cfg.Audit), policy, time.Now(), dryRun)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not prune the audit log: %v", err))
+		return 1
+	}
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("Audit log: %s %d entries (%d bytes), kept %d\n", verb, result.Removed, result.Freed, result.Kept)
+	if !dryRun {
+		if err := retention.MarkRun(retention.StampPath(), time.Now()); err != nil {
+			output.PrintWarning(fmt.Sprintf("Could not record the run: %v", err))
+		}
+	}
+	return 0
+}
+
+// pruneIfDue applies the audit retention when it hasn't run for a day.
+// Failures are reported but never stop the command.
+func pruneIfDue(cfg *config.Config) {
+	policy, err := retention.ParsePolicy(cfg.Audit.MaxAge, cfg.Audit.MaxSize)
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Invalid audit retention: %v (keeping everything)", err))
+		return
+	}
+	stamp := retention.StampPath()
+	if !policy.Enabled() || !retention.Due(stamp, time.Now()) {
+		return
+	}
+	if _, err := retention.Prune(audit.Path(cfg.Audit), policy, time.Now(), false); err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not prune the audit log: %v", err))
+	}
+	// Stamp even on failure so a broken log doesn't warn on every command
+	if err := retention.MarkRun(stamp, time.Now()); err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not record the audit log pruning: %v", err))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lease"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/mfa"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+)
+
+// mfaAttempts is how many codes may be tried before the command is blocked
+const mfaAttempts = 3
+
+// mfaStepPath records the time step of the last accepted TOTP code, so it
+// can't be used twice
+func mfaStepPath() string {
+	return filepath.Join(config.DataDir(), "mfa-step")
+}
+
+// handleMFA manages the TOTP secret asked for before critical actions
+func handleMFA(args []string) int {
+	sub := ""
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "enroll":
+		force := len(args) > 1 && args[1] == "--force"
+		return mfaEnroll(force)
+	case "status":
+		if _, err := secrets.Get(mfa.SecretName); err != nil {
+			fmt.Println("Not enrolled")
+			return 0
+		}
+		fmt.Println("Enrolled")
+		return 0
+	case "remove":
+		if err := secrets.Delete(mfa.SecretName); err != nil {
+			output.PrintError(fmt.Sprintf("Could not remove TOTP secret: %v", err))
+			return 1
+		}
+		output.PrintSuccess("TOTP secret removed")
+		return 0
+	case "", "--help", "-h":
+		fmt.Print(`kctl mfa - Manage the TOTP second factor for critical actions
+
+Usage:
+  kctl mfa enroll [--force]   Create a secret and add it to your authenticator app
+  kctl mfa status             Show whether a secret is enrolled
+  kctl mfa remove             Delete the enrolled secret
+
+Tiers with require_mfa ask for a code before critical actions: deleting
+namespaces, nodes, persistent volumes or CRDs, or deleting with --all or -A.
+The secret is stored in the OS keyring.
+`)
+		return 0
+	}
+	output.PrintError(fmt.Sprintf("Unknown mfa command: %s", sub))
+	return 1
+}
+
+// mfaEnroll creates a secret, shows it for the authenticator app and saves
+// it once a valid code proves the app has it
+func mfaEnroll(force bool) int {
+	if _, err := secrets.Get(mfa.SecretName); err == nil && !force {
+		output.PrintError("A TOTP secret is already enrolled; use --force to replace it")
+		return 1
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not generate secret: %v", err))
+		return 1
+	}
+	fmt.Println("Add this account to your authenticator app:")
+	fmt.Println()
+	fmt.Printf("  %s\n", mfa.URI(secret, lease.Identity()))
+	fmt.Println()
+	fmt.Printf("or enter the secret manually: %s\n", secret)
+	fmt.Println()
+
+	code, ok := output.PromptInput("Code from the app to confirm")
+	if !ok {
+		return 1
+	}
+	if err := mfa.Use(mfaStepPath(), secret, code, time.Now()); err != nil {
+		output.PrintError(fmt.Sprintf("Code not accepted (%v); nothing was enrolled", err))
+		return 1
+	}
+	if err := secrets.Set(mfa.SecretName, secret); err != nil {
+		output.PrintError(fmt.Sprintf("Could not store secret: %v", err))
+		return 1
+	}
+	output.PrintSuccess("TOTP enrolled")
+	return 0
+}
+
+// needsMFA reports whether the decision is a critical action on a tier that
+// requires a TOTP code
+func needsMFA(decision policy.Decision) bool {
+	return decision.Rules.RequireMFA && decision.Severity == rbac.SeverityCritical
+}
+
+// promptMFA asks for a TOTP code, allowing a few attempts. The returned
+// error is the reason to block the command.
+func promptMFA() error {
+	secret, err := secrets.Get(mfa.SecretName)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("critical action requires a TOTP code but none is enrolled; run 'kctl mfa enroll'")
+	}
+	if err != nil {
+		return fmt.Errorf("could not read TOTP secret: %w", err)
+	}
+
+	for attempt := 1; attempt <= mfaAttempts; attempt++ {
+		code, ok := output.PromptInput("TOTP code")
+		if !ok {
+			break
+		}
+		switch err := mfa.Use(mfaStepPath(), secret, code, time.Now()); {
+		case err == nil:
+			return nil
+		case errors.Is(err, mfa.ErrReused):
+			output.PrintWarning("Code already used; wait for the next one")
+		case errors.Is(err, mfa.ErrInvalid):
+			output.PrintWarning("Invalid code")
+		default:
+			return fmt.Errorf("could not check the TOTP code: %w", err)
+		}
+	}
+	return fmt.Errorf("TOTP verification failed")
+}
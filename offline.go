@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+)
+
+// checkReachable makes sure the API server answers before a gated command,
+// or one the rules check against the cluster, goes ahead. It reports
+// whether the command should run offline, skipping the checks that need the
+// server; the returned error is the reason to block it when its rules fail
+// closed (unreachable: block).
+func checkReachable(cfg *config.Config, decision policy.Decision) (bool, error) {
+	if decision.Verdict == policy.Block || decision.Verdict != policy.Confirm && !checksCluster(cfg, decision) {
+		return false, nil
+	}
+	err := kubectl.Reachable(decision.Context, cfg.Retry.ReachableTries())
+	if err == nil {
+		return false, nil
+	}
+
+	if decision.Rules.Unreachable == config.UnreachableBlock {
+		return false, fmt.Errorf("the API server of %s can't be reached (%v) and tier '%s' fails closed", decision.Context, err, decision.Tier)
+	}
+	output.PrintWarning(fmt.Sprintf("The API server of %s can't be reached (%v); skipping the checks that need it", decision.Context, err))
+	return true, nil
+}
+
+// checksCluster reports whether the rules check the command against the
+// cluster before it runs
+func checksCluster(cfg *config.Config, decision policy.Decision) bool {
+	return decision.Rules.MaxAffected > 0 && preview.Supported(decision.Args) ||
+		cfg.Lease.Enabled && leaseCovers(cfg.Lease, decision.Action)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/oncall"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// checkOnCall reports whether a destructive action on a require_oncall tier
+// needs approval because the user isn't on call. If PagerDuty can't be asked,
+// the user is treated as not on call. The returned error is the reason to
+// block the command when there is no approval_command to fall back on.
+func checkOnCall(cfg *config.Config, decision policy.Decision) (bool, error) {
+	if !onCallRequired(decision) {
+		return false, nil
+	}
+
+	email := oncall.Email(cfg.OnCall)
+	onCall, err := oncall.IsOnCall(cfg.OnCall, email)
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not check on-call status: %v", err))
+	}
+	if onCall {
+		return false, nil
+	}
+
+	who := email
+	if who == "" {
+		who = "You"
+	}
+	if decision.Rules.ApprovalCommand == "" {
+		return false, fmt.Errorf("%s is not on call and tier '%s' has no approval_command", who, decision.Tier)
+	}
+	output.PrintSublog(fmt.Sprintf("%s is not on call; approval required", who))
+	return true, nil
+}
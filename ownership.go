@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/ownership"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// foreignObjects returns the objects a mutating command changes that
+// another team owns, when ownership checks are on. Dry runs, commands kctl
+// can't dry-run and commands run offline aren't checked. When the owners
+// can't be looked up, err says so and the command needs confirmation as
+// if it changed another team's objects.
+func foreignObjects(cfg *config.Config, decision policy.Decision) ([]ownership.Object, error) {
+	if !ownershipChecked(cfg, decision) {
+		return nil, nil
+	}
+	foreign, err := ownership.Foreign(decision.Context, decision.Args, cfg.Ownership.Team, cfg.Ownership.OwnerKeys())
+	if err != nil {
+		return nil, fmt.Errorf("could not check who owns the objects: %v", err)
+	}
+	return foreign, nil
+}
+
+// printForeign lists the objects of other teams in the confirmation
+// header, by team
+func printForeign(foreign []ownership.Object) {
+	var teams []string
+	byTeam := make(map[string][]string)
+	for _, o := range foreign {
+		if _, seen := byTeam[o.Team]; !seen {
+			teams = append(teams, o.Team)
+		}
+		byTeam[o.Team] = append(byTeam[o.Team], o.String())
+	}
+	for _, team := range teams {
+		objects := byTeam[team]
+		if len(objects) > maxShownSelected {
+			objects = append(objects[:maxShownSelected:maxShownSelected], fmt.Sprintf("... and %d more", len(objects)-maxShownSelected))
+		}
+		output.PrintSublog(fmt.Sprintf("Owned by team '%s': %s", team, strings.Join(objects, ", ")))
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/fuzzy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/history"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// pickListed is how many candidates the picker shows before asking for a
+// filter
+const pickListed = 20
+
+// pickItem is an object offered by the picker
+type pickItem struct {
+	namespace, name string
+	recent          bool
+}
+
+// label is how the picker shows an item
+func (p pickItem) label() string {
+	if p.namespace == "" {
+		return p.name
+	}
+	return p.namespace + "/" + p.name
+}
+
+// handlePick lets the user choose an object of one type and runs a command
+// on it, or prints it when no command is given
+func handlePick(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Printf(`%s pick - Choose an object from a list instead of typing its name
+
+Usage:
+  %[1]s pick TYPE [get flags] [-- COMMAND...]
+
+Lists the objects of TYPE in the current namespace (or as the flags say,
+e.g. -n NAMESPACE, -l SELECTOR, -A), recently used ones first. Type a
+number to choose one, or part of a name to narrow the list. COMMAND is then
+run with the choice in place of {}, or appended when there is no {}, and
+is checked against the rules like any other command. Without COMMAND the
+choice is printed as TYPE/NAME.
+
+Examples:
+  %[1]s pick pods -- logs -f
+  %[1]s pick pods -A -- describe
+  %[1]s pick deployments -n shop -- rollout restart {}
+  %[1]s pick pods -- delete          # Confirmed like any delete
+`, progName)
+		if len(args) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	kind, getArgs, command := args[0], args[1:], []string(nil)
+	for i, arg := range getArgs {
+		if arg == "--" {
+			getArgs, command = getArgs[:i], getArgs[i+1:]
+			break
+		}
+	}
+	if strings.Contains(kind, ",") || strings.Contains(kind, "/") {
+		output.PrintError("pick takes a single resource type, such as pods")
+		return 1
+	}
+
+	cfg := loadConfig()
+	kubectl.UseKubeconfigFrom(getArgs)
+	context, err := resolveContext(getArgs)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		return 1
+	}
+	items, err := listPickItems(kind, getArgs, context)
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	if len(items) == 0 {
+		output.PrintError(fmt.Sprintf("No %s found", kind))
+		return 1
+	}
+
+	item, ok := pickFrom(items)
+	if !ok {
+		return 1
+	}
+	target := kind + "/" + item.name
+	if len(command) == 0 {
+		if item.namespace != "" {
+			output.PrintSublog("Namespace: " + item.namespace)
+		}
+		fmt.Println(target)
+		return 0
+	}
+
+	skipConfirm, command := extractYesFlag(command)
+	run := pickCommand(command, target, item.namespace, getArgs)
+	// The command may name its own --context or --kubeconfig; it is decided
+	// and run where it goes, which must be where the object was picked
+	kubectl.UseKubeconfigFrom(run)
+	runContext, err := resolveContext(run)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		return 1
+	}
+	if runContext != context {
+		output.PrintError(fmt.Sprintf("%s was picked on context '%s', but the command runs on '%s'", target, context, runContext))
+		return 1
+	}
+	output.PrintSublog("Running: kubectl " + shell.JoinArgs(run))
+	return runGuarded(cfg, runContext, run, skipConfirm)
+}
+
+// listPickItems lists the objects of kind as 'kubectl get' with getArgs
+// would, those named in recent history for context first
+func listPickItems(kind string, getArgs []string, context string) ([]pickItem, error) {
+	listArgs := append([]string{"get", kind}, getArgs...)
+	listArgs = append(listArgs, "-o", `jsonpath={range .items[*]}{.metadata.namespace}{"\t"}{.metadata.name}{"\n"}{end}`)
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput(listArgs)
+	if exitCode != 0 {
+		return nil, fmt.Errorf("failed to list %s: %s", kind, strings.TrimSpace(stderr))
+	}
+
+	recent := recentNames(context)
+	var items, rest []pickItem
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		namespace, name, found := strings.Cut(line, "\t")
+		if !found || name == "" {
+			continue
+		}
+		item := pickItem{namespace: namespace, name: name, recent: recent[name]}
+		if item.recent {
+			items = append(items, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	return append(items, rest...), nil
+}
+
+// recentNames returns the words of the commands recently run on context,
+// which the picker uses to put the objects they named first
+func recentNames(context string) map[string]bool {
+	entries, err := history.Load(history.Path())
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	for i := len(entries) - 1; i >= 0 && len(names) < 100; i-- {
+		if entries[i].Context != context {
+			continue
+		}
+		for _, arg := range entries[i].Args {
+			if _, name, found := strings.Cut(arg, "/"); found {
+				arg = name
+			}
+			names[arg] = true
+		}
+	}
+	return names
+}
+
+// pickFrom shows items and asks for a number or a filter until one is
+// chosen. ok is false when the user gives up or there is no terminal.
+func pickFrom(items []pickItem) (pickItem, bool) {
+	shown := items
+	for {
+		w := output.ChromeWriter()
+		for i, item := range shown {
+			if i == pickListed {
+				fmt.Fprintf(w, "     ... %d more; type part of a name to narrow the list\n", len(shown)-pickListed)
+				break
+			}
+			marker := ""
+			if item.recent {
+				marker = "  (recent)"
+			}
+			fmt.Fprintf(w, "%4d %s%s\n", i+1, item.label(), marker)
+		}
+
+		answer, ok := output.PromptInput("Pick a number or filter (empty to cancel)")
+		if !ok || answer == "" {
+			return pickItem{}, false
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n >= 1 && n <= len(shown) && n <= pickListed {
+				return shown[n-1], true
+			}
+			output.PrintWarning(fmt.Sprintf("%d isn't one of the numbers listed", n))
+			continue
+		}
+
+		matched := filterPickItems(answer, items)
+		switch len(matched) {
+		case 0:
+			output.PrintWarning(fmt.Sprintf("Nothing matches '%s'", answer))
+			shown = items
+		case 1:
+			return matched[0], true
+		default:
+			shown = matched
+		}
+	}
+}
+
+// filterPickItems returns the items whose label fuzzy-matches query, best
+// matches first
+func filterPickItems(query string, items []pickItem) []pickItem {
+	byLabel := make(map[string]pickItem, len(items))
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		byLabel[item.label()] = item
+		labels = append(labels, item.label())
+	}
+	var matched []pickItem
+	for _, label := range fuzzy.Find(query, labels) {
+		matched = append(matched, byLabel[label])
+	}
+	return matched
+}
+
+// pickConnectionFlags are the flags of the listing that choose where the
+// picked object lives, and so where the command must run
+var pickConnectionFlags = []string{"--kubeconfig", "--context", "--cluster", "--user"}
+
+// pickCommand puts target into command in place of {}, or after it, and
+// adds the namespace of the choice and the connection flags of getArgs,
+// before any "--", unless command sets its own
+func pickCommand(command []string, target, namespace string, getArgs []string) []string {
+	run := make([]string, 0, len(command)+1)
+	substituted := false
+	for _, arg := range command {
+		if strings.Contains(arg, "{}") {
+			arg = strings.ReplaceAll(arg, "{}", target)
+			substituted = true
+		}
+		run = append(run, arg)
+	}
+	if !substituted {
+		run = append(run, target)
+	}
+	var flags []string
+	if _, ok := rbac.Namespace(command); namespace != "" && !ok {
+		flags = append(flags, "--namespace="+namespace)
+	}
+	for _, flag := range pickConnectionFlags {
+		if value, ok := rbac.FlagValue(getArgs, flag); ok {
+			flags = append(flags, flag+"="+value)
+		}
+	}
+	run, _ = rbac.AddFlags(run, flags)
+	return run
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPickCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   []string
+		namespace string
+		getArgs   []string
+		want      []string
+	}{
+		{"appends the target", []string{"logs", "-f"}, "shop", nil,
+			[]string{"logs", "-f", "pods/web", "--namespace=shop"}},
+		{"fills in {}", []string{"rollout", "restart", "{}"}, "", nil,
+			[]string{"rollout", "restart", "pods/web"}},
+		{"carries the connection", []string{"describe"}, "shop", []string{"--kubeconfig", "/tmp/kc", "--context=prod", "-n", "shop"},
+			[]string{"describe", "pods/web", "--namespace=shop", "--kubeconfig=/tmp/kc", "--context=prod"}},
+		{"keeps the command's own flags", []string{"describe", "-nother", "--context", "dev"}, "shop", []string{"--context=prod"},
+			[]string{"describe", "-nother", "--context", "dev", "pods/web"}},
+		{"adds flags before --", []string{"exec", "{}", "--", "sh"}, "shop", []string{"--context=prod"},
+			[]string{"exec", "pods/web", "--namespace=shop", "--context=prod", "--", "sh"}},
+	}
+	for _, tt := range tests {
+		if got := pickCommand(tt.command, "pods/web", tt.namespace, tt.getArgs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: pickCommand = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterPickItems(t *testing.T) {
+	items := []pickItem{
+		{namespace: "shop", name: "web-7d9f"},
+		{namespace: "shop", name: "worker-5c2a"},
+		{namespace: "billing", name: "web-1a2b"},
+	}
+	if got := filterPickItems("zzz", items); len(got) != 0 {
+		t.Errorf("filterPickItems(zzz) = %+v, want none", got)
+	}
+	got := filterPickItems("billing/web", items)
+	if len(got) == 0 || got[0] != items[2] {
+		t.Errorf("filterPickItems(billing/web) = %+v, want %+v first", got, items[2])
+	}
+	for _, item := range filterPickItems("web", items) {
+		if item == items[1] {
+			t.Errorf("filterPickItems(web) matched %+v", item)
+		}
+	}
+}
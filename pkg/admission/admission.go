@@ -0,0 +1,324 @@
+// Package admission turns a cluster's kctl rules into an in-cluster policy
+// (a ValidatingAdmissionPolicy or a Kyverno ClusterPolicy) that rejects the
+// blocked actions server-side, for clients that bypass the wrapper
+package admission
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Output formats
+const (
+	FormatVAP     = "vap"
+	FormatKyverno = "kyverno"
+)
+
+// Options control the generated policy
+type Options struct {
+	// Name of the policy and its binding
+	Name string
+	// Format is FormatVAP (default) or FormatKyverno
+	Format string
+	// ExemptGroups may still perform blocked actions, e.g. a break-glass group
+	ExemptGroups []string
+}
+
+// request describes the API requests a kubectl action turns into
+type request struct {
+	Operations []string
+	APIGroups  []string
+	Resources  []string // VAP resources, "pods/exec" for subresources
+	Kinds      []string // Kyverno kinds, "Pod/exec" for subresources
+	// Condition is a CEL expression picking the action's requests out of
+	// those matched
+	Condition string
+}
+
+// requests maps the actions that can be enforced server-side to their API
+// requests. Server-side, edit and patch are indistinguishable from apply's
+// updates.
+var requests = map[string]request{
+	rbac.ActionDelete: {
+		Operations: []string{"DELETE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*"}, Kinds: []string{"*"},
+		Condition: "request.operation == 'DELETE'",
+	},
+	rbac.ActionDrain: {
+		Operations: []string{"CREATE"},
+		APIGroups:  []string{""}, Resources: []string{"pods/eviction"}, Kinds: []string{"Pod/eviction"},
+		Condition: "request.subResource == 'eviction'",
+	},
+	rbac.ActionCordon: {
+		Operations: []string{"UPDATE"},
+		APIGroups:  []string{""}, Resources: []string{"nodes"}, Kinds: []string{"Node"},
+		Condition: "request.operation == 'UPDATE' && request.resource.resource == 'nodes' && " +
+			"(has(object.spec.unschedulable) && object.spec.unschedulable) != " +
+			"(has(oldObject.spec.unschedulable) && oldObject.spec.unschedulable)",
+	},
+	rbac.ActionScale: {
+		Operations: []string{"UPDATE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*/scale"}, Kinds: []string{"*/scale"},
+		Condition: "request.subResource == 'scale'",
+	},
+	rbac.ActionEdit: {
+		Operations: []string{"UPDATE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*"}, Kinds: []string{"*"},
+		Condition: "request.operation == 'UPDATE' && request.subResource == ''",
+	},
+	rbac.ActionPatch: {
+		Operations: []string{"UPDATE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*"}, Kinds: []string{"*"},
+		Condition: "request.operation == 'UPDATE' && request.subResource == ''",
+	},
+	rbac.ActionApply: {
+		Operations: []string{"CREATE", "UPDATE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*"}, Kinds: []string{"*"},
+		Condition: "request.operation in ['CREATE', 'UPDATE'] && request.subResource == ''",
+	},
+	rbac.ActionCreate: {
+		Operations: []string{"CREATE"},
+		APIGroups:  []string{"*"}, Resources: []string{"*"}, Kinds: []string{"*"},
+		Condition: "request.operation == 'CREATE' && request.subResource == ''",
+	},
+	rbac.ActionExec: {
+		Operations: []string{"CONNECT"},
+		APIGroups:  []string{""}, Resources: []string{"pods/exec", "pods/attach"}, Kinds: []string{"Pod/exec", "Pod/attach"},
+		Condition: "request.subResource in ['exec', 'attach']",
+	},
+	rbac.ActionRollout: {
+		Operations: []string{"UPDATE"},
+		APIGroups:  []string{"apps"}, Resources: []string{"deployments", "statefulsets", "daemonsets"},
+		Kinds: []string{"Deployment", "StatefulSet", "DaemonSet"},
+		Condition: "request.operation == 'UPDATE' && request.subResource == '' && " +
+			"request.resource.resource in ['deployments', 'statefulsets', 'daemonsets']",
+	},
+	rbac.ActionNodeAccess: {
+		Operations: []string{"CREATE"},
+		APIGroups:  []string{""}, Resources: []string{"pods"}, Kinds: []string{"Pod"},
+		Condition: "request.operation == 'CREATE' && request.resource.resource == 'pods' && request.subResource == '' && " +
+			"has(object.spec.hostPID) && object.spec.hostPID",
+	},
+}
+
+// Plan lists what a policy for a set of rules enforces
+type Plan struct {
+	Tier string
+	// Blocked are the actions rejected server-side
+	Blocked []string
+	// Unenforced are blocked actions with no server-side equivalent
+	// (read-only commands, plugins)
+	Unenforced []string
+	// Confirmed are actions needing confirmation, which a server can't ask for
+	Confirmed []string
+}
+
+// NewPlan works out which actions the rules block or confirm
+func NewPlan(rules config.ResolvedRules) Plan {
+	plan := Plan{Tier: rules.Tier}
+	conditions := map[string]bool{}
+	for _, action := range sortedActions() {
+		switch outcome, _ := rbac.Resolve(action, rules); outcome {
+		case rbac.OutcomeBlock:
+			// edit and patch look the same to the API server
+			if cond := requests[action].Condition; !conditions[cond] {
+				conditions[cond] = true
+				plan.Blocked = append(plan.Blocked, action)
+			}
+		case rbac.OutcomeConfirm:
+			plan.Confirmed = append(plan.Confirmed, action)
+		}
+	}
+	for _, action := range rules.BlockedActions {
+		if _, ok := requests[action]; !ok && action != rbac.Wildcard && !contains(plan.Unenforced, action) {
+			plan.Unenforced = append(plan.Unenforced, action)
+		}
+	}
+	return plan
+}
+
+// Generate renders the policy for plan as a multi-document YAML manifest.
+// It fails when the plan blocks nothing that can be enforced server-side.
+func Generate(plan Plan, opts Options) ([]byte, error) {
+	if len(plan.Blocked) == 0 {
+		return nil, fmt.Errorf("tier '%s' blocks no action that can be enforced in the cluster", plan.Tier)
+	}
+	if opts.Name == "" {
+		opts.Name = "kctl-" + plan.Tier
+	}
+
+	var docs []any
+	switch opts.Format {
+	case "", FormatVAP:
+		docs = validatingAdmissionPolicy(plan, opts)
+	case FormatKyverno:
+		docs = kyvernoPolicy(plan, opts)
+	default:
+		return nil, fmt.Errorf("unknown format %q (use %s or %s)", opts.Format, FormatVAP, FormatKyverno)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header(plan))
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// header explains what the manifest does and doesn't enforce
+func header(plan Plan) string {
+	lines := []string{
+		fmt.Sprintf("# Generated by 'kctl generate admission-policy' from tier '%s'", plan.Tier),
+		"# Blocks: " + strings.Join(plan.Blocked, ", "),
+		"# Requests from system: users (controllers, nodes, service accounts) are not checked.",
+	}
+	if len(plan.Unenforced) > 0 {
+		lines = append(lines, "# Not enforceable in the cluster: "+strings.Join(plan.Unenforced, ", "))
+	}
+	if len(plan.Confirmed) > 0 {
+		lines = append(lines, "# Confirmations stay client-side: "+strings.Join(plan.Confirmed, ", "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func message(action, tier string) string {
+	return fmt.Sprintf("%s is blocked on tier '%s' by kctl policy", action, tier)
+}
+
+// condition is a named CEL expression
+type condition struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+}
+
+// kyvernoRule is one rule of a Kyverno ClusterPolicy
+type kyvernoRule struct {
+	Name             string         `yaml:"name"`
+	Match            map[string]any `yaml:"match"`
+	CELPreconditions []condition    `yaml:"celPreconditions"`
+	Validate         map[string]any `yaml:"validate"`
+}
+
+// matchConditions skips system users and the exempt groups
+func matchConditions(opts Options) []condition {
+	conditions := []condition{{
+		Name:       "exclude-system-users",
+		Expression: "!request.userInfo.username.startsWith('system:')",
+	}}
+	if len(opts.ExemptGroups) > 0 {
+		conditions = append(conditions, condition{
+			Name:       "exclude-exempt-groups",
+			Expression: fmt.Sprintf("!request.userInfo.groups.exists(g, g in %s)", celList(opts.ExemptGroups)),
+		})
+	}
+	return conditions
+}
+
+func validatingAdmissionPolicy(plan Plan, opts Options) []any {
+	var resourceRules, validations []map[string]any
+	for _, action := range plan.Blocked {
+		req := requests[action]
+		resourceRules = append(resourceRules, map[string]any{
+			"apiGroups":   req.APIGroups,
+			"apiVersions": []string{"*"},
+			"operations":  req.Operations,
+			"resources":   req.Resources,
+		})
+		validations = append(validations, map[string]any{
+			"expression": "!(" + req.Condition + ")",
+			"message":    message(action, plan.Tier),
+		})
+	}
+
+	policy := map[string]any{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingAdmissionPolicy",
+		"metadata":   map[string]any{"name": opts.Name},
+		"spec": map[string]any{
+			"failurePolicy":    "Fail",
+			"matchConstraints": map[string]any{"resourceRules": resourceRules},
+			"matchConditions":  matchConditions(opts),
+			"validations":      validations,
+		},
+	}
+	binding := map[string]any{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingAdmissionPolicyBinding",
+		"metadata":   map[string]any{"name": opts.Name},
+		"spec": map[string]any{
+			"policyName":        opts.Name,
+			"validationActions": []string{"Deny"},
+		},
+	}
+	return []any{policy, binding}
+}
+
+func kyvernoPolicy(plan Plan, opts Options) []any {
+	var rules []kyvernoRule
+	for _, action := range plan.Blocked {
+		req := requests[action]
+		rules = append(rules, kyvernoRule{
+			Name: "block-" + action,
+			Match: map[string]any{"any": []any{map[string]any{
+				"resources": map[string]any{"kinds": req.Kinds, "operations": req.Operations},
+			}}},
+			CELPreconditions: matchConditions(opts),
+			Validate: map[string]any{
+				"cel": map[string]any{"expressions": []any{map[string]string{
+					"expression": "!(" + req.Condition + ")",
+					"message":    message(action, plan.Tier),
+				}}},
+			},
+		})
+	}
+	return []any{map[string]any{
+		"apiVersion": "kyverno.io/v1",
+		"kind":       "ClusterPolicy",
+		"metadata":   map[string]any{"name": opts.Name},
+		"spec": map[string]any{
+			"validationFailureAction": "Enforce",
+			"background":              false,
+			"rules":                   rules,
+		},
+	}}
+}
+
+func celList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "\\'") + "'"
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedActions() []string {
+	actions := make([]string, 0, len(requests))
+	for action := range requests {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package admission
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestNewPlan(t *testing.T) {
+	plan := NewPlan(config.ResolvedRules{
+		Tier:                "production",
+		BlockedActions:      []string{"delete", "edit", "node-shell", "logs"},
+		RequireConfirmation: []string{"drain"},
+	})
+	// edit covers patch, which needs no rule of its own
+	if want := []string{"delete", "edit"}; !reflect.DeepEqual(plan.Blocked, want) {
+		t.Errorf("Blocked = %v, want %v", plan.Blocked, want)
+	}
+	if want := []string{"node-shell", "logs"}; !reflect.DeepEqual(plan.Unenforced, want) {
+		t.Errorf("Unenforced = %v, want %v", plan.Unenforced, want)
+	}
+	if want := []string{"cordon", "drain"}; !reflect.DeepEqual(plan.Confirmed, want) {
+		t.Errorf("Confirmed = %v, want %v", plan.Confirmed, want)
+	}
+
+	if plan := NewPlan(config.ResolvedRules{Default: config.DefaultDeny, AllowedActions: []string{"apply"}}); contains(plan.Blocked, "apply") || !contains(plan.Blocked, "exec") {
+		t.Errorf("default deny: Blocked = %v, want everything but apply and create", plan.Blocked)
+	}
+}
+
+func TestGenerate_VAP(t *testing.T) {
+	plan := Plan{Tier: "production", Blocked: []string{"delete", "exec"}, Confirmed: []string{"drain"}}
+	out, err := Generate(plan, Options{ExemptGroups: []string{"sre-breakglass"}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	text := string(out)
+	for _, want := range []string{
+		"# Confirmations stay client-side: drain",
+		"kind: ValidatingAdmissionPolicy",
+		"kind: ValidatingAdmissionPolicyBinding",
+		"name: kctl-production",
+		"expression: '!(request.operation == ''DELETE'')'",
+		"g in [''sre-breakglass'']",
+		"- pods/exec",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("manifest lacks %q:\n%s", want, text)
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(text))
+	var docs int
+	for {
+		var doc map[string]any
+		if decoder.Decode(&doc) != nil {
+			break
+		}
+		docs++
+	}
+	if docs != 2 {
+		t.Errorf("manifest has %d documents, want 2", docs)
+	}
+}
+
+func TestGenerate_Kyverno(t *testing.T) {
+	out, err := Generate(Plan{Tier: "production", Blocked: []string{"scale"}}, Options{Name: "no-scale", Format: FormatKyverno})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{"kind: ClusterPolicy", "name: no-scale", "name: block-scale", "- '*/scale'", "validationFailureAction: Enforce"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("manifest lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := Generate(Plan{Tier: "dev"}, Options{}); err == nil {
+		t.Error("Generate() with nothing blocked should fail")
+	}
+	if _, err := Generate(Plan{Tier: "prod", Blocked: []string{"delete"}}, Options{Format: "opa"}); err == nil {
+		t.Error("Generate() with an unknown format should fail")
+	}
+}
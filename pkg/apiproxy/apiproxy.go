@@ -0,0 +1,228 @@
+// Package apiproxy puts the rules in front of the Kubernetes API: a local
+// endpoint, like 'kubectl proxy', that decides every request from its HTTP
+// verb and path before passing it on, so clients that bypass kctl (curl,
+// k9s, scripts) get the same verdicts
+package apiproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Call is what a REST request does, in kubectl's terms
+type Call struct {
+	Action      string // kubectl verb, e.g. get, delete, exec
+	Resource    string // plural resource, e.g. pods or deployments.apps
+	Name        string
+	Namespace   string
+	Subresource string // e.g. scale, exec, status
+	Selector    string // labelSelector of a collection request
+}
+
+// Parse maps a request to the call it makes. ok is false for paths that
+// don't address a resource, such as /version or /openapi/v2.
+func Parse(method string, u *url.URL) (call Call, ok bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var group string
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:] // api/v1
+	case len(parts) >= 4 && parts[0] == "apis":
+		group, parts = parts[1], parts[3:] // apis/apps/v1
+	default:
+		return Call{}, false
+	}
+
+	// namespaces/{ns} is the Namespace itself unless a resource follows
+	if len(parts) >= 3 && parts[0] == "namespaces" && parts[2] != "status" && parts[2] != "finalize" {
+		call.Namespace, parts = parts[1], parts[2:]
+	}
+	if len(parts) == 0 || len(parts) > 3 {
+		return Call{}, false
+	}
+	call.Resource = parts[0]
+	if group != "" {
+		call.Resource += "." + group
+	}
+	if len(parts) > 1 {
+		call.Name = parts[1]
+	}
+	if len(parts) > 2 {
+		call.Subresource = parts[2]
+	}
+	call.Selector = u.Query().Get("labelSelector")
+	call.Action = action(method, call.Subresource)
+	return call, true
+}
+
+// action returns the kubectl verb whose rules cover a request
+func action(method, subresource string) string {
+	// kubectl opens exec, attach and port-forward streams with a GET that
+	// upgrades to a websocket or SPDY, so these go by the subresource
+	switch subresource {
+	case "exec", "attach":
+		return rbac.ActionExec
+	case "portforward":
+		return "port-forward"
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "get"
+	case http.MethodDelete:
+		return rbac.ActionDelete
+	case http.MethodPost:
+		switch subresource {
+		case "eviction":
+			// Evicting a pod deletes it, within its disruption budget
+			return rbac.ActionDelete
+		}
+		return rbac.ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		if subresource == "scale" {
+			return rbac.ActionScale
+		}
+		if method == http.MethodPut {
+			return rbac.ActionEdit
+		}
+		return rbac.ActionPatch
+	}
+	return rbac.ActionUnknown
+}
+
+// Args returns kubectl arguments doing what the call does, to be decided
+// like a command typed at kctl
+func (c Call) Args() []string {
+	resource := c.Resource
+	if c.Subresource == "eviction" {
+		resource = "pods"
+	}
+	var args []string
+	switch c.Action {
+	case rbac.ActionExec, "port-forward":
+		args = []string{c.Action, "pod/" + c.Name}
+	default:
+		args = []string{c.Action, resource}
+		if c.Name != "" {
+			args = append(args, c.Name)
+		} else if c.Action == rbac.ActionDelete && c.Selector == "" {
+			args = append(args, "--all")
+		}
+	}
+	if c.Selector != "" {
+		args = append(args, "-l", c.Selector)
+	}
+	if c.Namespace != "" {
+		args = append(args, "-n", c.Namespace)
+	} else if c.Name == "" && !rbac.IsClusterScoped([]string{c.Action, resource}) {
+		args = append(args, "--all-namespaces")
+	}
+	return args
+}
+
+// Options configure Handler
+type Options struct {
+	// Upstream is the API, normally a 'kubectl proxy' that authenticates
+	Upstream *url.URL
+	// Transport, if set, is how requests reach Upstream, e.g. over a unix
+	// socket only the handler knows
+	Transport http.RoundTripper
+	// Context is the context requests go to
+	Context string
+	// Config returns the config to decide a request under; it is called
+	// once per request, so a reload never splits one
+	Config func() *config.Config
+	// Decide returns the verdict for kubectl arguments
+	Decide func(cfg *config.Config, args []string) policy.Decision
+	// Record, if set, is called with each request that isn't a read, and
+	// whether it was passed on
+	Record func(cfg *config.Config, d policy.Decision, forwarded bool)
+}
+
+// Handler passes allowed requests on to opts.Upstream. Requests the rules
+// block, or that need a confirmation no HTTP client can give, are refused
+// with a 403 Status the way the API server refuses them, so kubectl and
+// other clients show the reason. Reads of paths that address no resource
+// pass; writes to them are refused, since there is nothing to decide them
+// by. Requests are decided one at a time, as the config's lookups are
+// shared; only passing them on runs concurrently.
+func Handler(opts Options) http.Handler {
+	upstream := httputil.NewSingleHostReverseProxy(opts.Upstream)
+	if opts.Transport != nil {
+		upstream.Transport = opts.Transport
+	}
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call, ok := Parse(r.Method, r.URL)
+		if !ok && isRead(r.Method) {
+			upstream.ServeHTTP(w, r)
+			return
+		}
+
+		mu.Lock()
+		cfg := opts.Config()
+		var d policy.Decision
+		if ok {
+			d = opts.Decide(cfg, call.Args())
+		} else {
+			d = policy.Decision{
+				Verdict: policy.Block,
+				Action:  rbac.ActionUnknown,
+				Context: opts.Context,
+				Args:    []string{r.Method, r.URL.Path},
+				Reason:  fmt.Sprintf("%s %s addresses no resource the rules can decide", r.Method, r.URL.Path),
+			}
+		}
+		forwarded := d.Verdict == policy.Allow
+		if opts.Record != nil && (!ok || call.Action != "get") {
+			opts.Record(cfg, d, forwarded)
+		}
+		mu.Unlock()
+
+		if !forwarded {
+			refuse(w, d)
+			return
+		}
+		upstream.ServeHTTP(w, r)
+	})
+}
+
+// isRead reports whether a request with method changes nothing
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// status is the part of a Kubernetes Status the proxy returns
+type status struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	Reason     string `json:"reason"`
+	Code       int    `json:"code"`
+}
+
+func refuse(w http.ResponseWriter, d policy.Decision) {
+	msg := "kctl: " + d.Reason
+	if d.Verdict == policy.Confirm {
+		msg += "; it needs confirmation, so run it through kctl"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(status{
+		Kind:       "Status",
+		APIVersion: "v1",
+		Status:     "Failure",
+		Message:    msg,
+		Reason:     "Forbidden",
+		Code:       http.StatusForbidden,
+	})
+}
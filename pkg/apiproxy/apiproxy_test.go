@@ -0,0 +1,210 @@
+package apiproxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         []string
+	}{
+		{"GET", "/api/v1/namespaces/shop/pods", []string{"get", "pods", "-n", "shop"}},
+		{"GET", "/api/v1/pods?watch=true", []string{"get", "pods", "--all-namespaces"}},
+		{"GET", "/api/v1/nodes/node-1", []string{"get", "nodes", "node-1"}},
+		{"DELETE", "/api/v1/namespaces/shop", []string{"delete", "namespaces", "shop"}},
+		{"PUT", "/api/v1/namespaces/shop/finalize", []string{"edit", "namespaces", "shop"}},
+		{"DELETE", "/api/v1/namespaces/shop/pods/web-1", []string{"delete", "pods", "web-1", "-n", "shop"}},
+		{"DELETE", "/api/v1/namespaces/shop/pods", []string{"delete", "pods", "--all", "-n", "shop"}},
+		{"DELETE", "/api/v1/namespaces/shop/pods?labelSelector=app%3Dweb", []string{"delete", "pods", "-l", "app=web", "-n", "shop"}},
+		{"PATCH", "/apis/apps/v1/namespaces/shop/deployments/web", []string{"patch", "deployments.apps", "web", "-n", "shop"}},
+		{"PUT", "/apis/apps/v1/namespaces/shop/deployments/web/scale", []string{"scale", "deployments.apps", "web", "-n", "shop"}},
+		{"POST", "/apis/apps/v1/namespaces/shop/deployments", []string{"create", "deployments.apps", "-n", "shop"}},
+		{"POST", "/api/v1/namespaces/shop/pods/web-1/exec?command=sh", []string{"exec", "pod/web-1", "-n", "shop"}},
+		{"POST", "/api/v1/namespaces/shop/pods/web-1/eviction", []string{"delete", "pods", "web-1", "-n", "shop"}},
+		{"GET", "/api/v1/namespaces/shop/pods/web-1/exec?command=sh&stdin=true&tty=true", []string{"exec", "pod/web-1", "-n", "shop"}},
+		{"GET", "/api/v1/namespaces/shop/pods/web-1/attach?stdin=true", []string{"exec", "pod/web-1", "-n", "shop"}},
+		{"GET", "/api/v1/namespaces/shop/pods/web-1/portforward?ports=8080", []string{"port-forward", "pod/web-1", "-n", "shop"}},
+		{"GET", "/api/v1/namespaces/shop/pods/web-1/log", []string{"get", "pods", "web-1", "-n", "shop"}},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.path)
+		call, ok := Parse(tt.method, u)
+		if !ok {
+			t.Errorf("Parse(%s %s) found no resource", tt.method, tt.path)
+			continue
+		}
+		if got := call.Args(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%s %s).Args() = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"/version", "/openapi/v2", "/apis", "/api/v1"} {
+		u, _ := url.Parse(path)
+		if call, ok := Parse("GET", u); ok {
+			t.Errorf("Parse(GET %s) = %+v, want no resource", path, call)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var reached []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = append(reached, r.Method+" "+r.URL.Path)
+		io.WriteString(w, "{}")
+	}))
+	defer api.Close()
+	upstream, _ := url.Parse(api.URL)
+
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"scale"},
+				BlockedActions:      config.Actions("delete"),
+			},
+		},
+	}
+	var recorded []policy.Verdict
+	proxy := httptest.NewServer(Handler(Options{
+		Upstream: upstream,
+		Context:  "app-prod",
+		Config:   func() *config.Config { return cfg },
+		Decide:   func(cfg *config.Config, args []string) policy.Decision { return policy.Evaluate(cfg, "app-prod", args) },
+		Record: func(cfg *config.Config, d policy.Decision, forwarded bool) {
+			recorded = append(recorded, d.Verdict)
+		},
+	}))
+	defer proxy.Close()
+
+	do := func(method, path string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, proxy.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := do("GET", "/api/v1/namespaces/shop/pods"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET pods = %d", resp.StatusCode)
+	}
+	if resp := do("GET", "/version"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /version = %d", resp.StatusCode)
+	}
+	for _, path := range []string{"/api/v1/namespaces/shop", "/apis/apps/v1/namespaces/shop/deployments/web/scale", "/apis/apps/v1"} {
+		method := "DELETE"
+		switch {
+		case strings.HasSuffix(path, "/scale"):
+			method = "PATCH"
+		case path == "/apis/apps/v1":
+			method = "POST"
+		}
+		resp := do(method, path)
+		var st status
+		json.NewDecoder(resp.Body).Decode(&st)
+		if resp.StatusCode != http.StatusForbidden || st.Kind != "Status" || !strings.HasPrefix(st.Message, "kctl: ") {
+			t.Errorf("%s %s = %d %+v, want a 403 Status", method, path, resp.StatusCode, st)
+		}
+	}
+
+	if want := []string{"GET /api/v1/namespaces/shop/pods", "GET /version"}; !reflect.DeepEqual(reached, want) {
+		t.Errorf("upstream got %v, want %v", reached, want)
+	}
+	if want := []policy.Verdict{policy.Block, policy.Confirm, policy.Block}; !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded %v, want %v", recorded, want)
+	}
+}
+
+func TestHandler_Concurrent(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "{}")
+	}))
+	defer api.Close()
+	upstream, _ := url.Parse(api.URL)
+
+	cfg := &config.Config{}
+	decided := 0
+	proxy := httptest.NewServer(Handler(Options{
+		Upstream: upstream,
+		Config:   func() *config.Config { return cfg },
+		Decide: func(cfg *config.Config, args []string) policy.Decision {
+			decided++
+			return policy.Evaluate(cfg, "kind-dev", args)
+		},
+		Record: func(*config.Config, policy.Decision, bool) {},
+	}))
+	defer proxy.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("DELETE", proxy.URL+"/api/v1/namespaces/shop/pods/web", nil)
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if decided != 20 {
+		t.Errorf("decided %d requests, want 20", decided)
+	}
+}
+
+func TestHandler_Streams(t *testing.T) {
+	reached := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+	}))
+	defer api.Close()
+	upstream, _ := url.Parse(api.URL)
+
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {Patterns: []string{"*-prod"}, BlockedActions: config.Actions("exec", "port-forward")},
+		},
+	}
+	proxy := httptest.NewServer(Handler(Options{
+		Upstream: upstream,
+		Context:  "app-prod",
+		Config:   func() *config.Config { return cfg },
+		Decide:   func(cfg *config.Config, args []string) policy.Decision { return policy.Evaluate(cfg, "app-prod", args) },
+	}))
+	defer proxy.Close()
+
+	// Streams are opened with a GET asking to upgrade the connection
+	for _, path := range []string{
+		"/api/v1/namespaces/shop/pods/web-1/exec?command=sh&stdin=true&tty=true",
+		"/api/v1/namespaces/shop/pods/web-1/attach?stdin=true",
+		"/api/v1/namespaces/shop/pods/web-1/portforward?ports=8080",
+	} {
+		req, _ := http.NewRequest("GET", proxy.URL+path, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("GET %s = %d, want 403", path, resp.StatusCode)
+		}
+	}
+	if reached != 0 {
+		t.Errorf("upstream reached %d times", reached)
+	}
+}
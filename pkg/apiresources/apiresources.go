@@ -0,0 +1,250 @@
+// Package apiresources reads the resource types a cluster serves with
+// 'kubectl api-resources' and caches them per context, so short names and
+// CRD plurals resolve to the kinds rules are written against
+package apiresources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// DefaultTTL is how long a context's resource list is reused
+const DefaultTTL = 24 * time.Hour
+
+// retryInterval is how long an unreachable cluster isn't asked again
+const retryInterval = 5 * time.Minute
+
+// Resource is a resource type served by a cluster
+type Resource struct {
+	Name       string   `json:"name"` // Plural, e.g. "deployments"
+	ShortNames []string `json:"short_names,omitempty"`
+	Group      string   `json:"group,omitempty"` // "" for the core group
+	Namespaced bool     `json:"namespaced"`
+	Kind       string   `json:"kind"` // e.g. "Deployment"
+}
+
+// entry is a context's cached resource list. Server records the cluster
+// it was read from, so a context pointed at another cluster reads it again.
+type entry struct {
+	Server    string     `json:"server,omitempty"`
+	Resources []Resource `json:"resources"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+// Resolver resolves resource names per context, going to the cluster only
+// when the cached list has expired
+type Resolver struct {
+	TTL       time.Duration
+	CachePath string
+
+	// Fetch reads the resource types from the cluster
+	Fetch func(context string) ([]Resource, error)
+	// Server identifies the cluster behind a context, so a cached list is
+	// dropped when the context changes clusters
+	Server func(context string) (string, error)
+
+	now func() time.Time
+	// mu guards indexes, as servers look names up concurrently
+	mu      sync.Mutex
+	indexes map[string]map[string]Resource
+}
+
+// NewResolver returns a Resolver that talks to clusters through kubectl
+func NewResolver() *Resolver {
+	return &Resolver{
+		TTL:       DefaultTTL,
+		CachePath: CachePath(),
+		Fetch:     fetchFromCluster,
+		Server:    kubectl.GetServer,
+		now:       time.Now,
+	}
+}
+
+// CachePath returns where resource lists are cached
+func CachePath() string {
+	return filepath.Join(config.CacheDir(), "api-resources.json")
+}
+
+// Lookup resolves resource, as typed on the command line, on the cluster
+// behind context. kind is the lowercase singular kind, e.g. "deployment"
+// for "deploy" or "certificate" for "certificates.cert-manager.io". found
+// is false when the cluster doesn't serve the name or can't be asked.
+func (r *Resolver) Lookup(context, resource string) (kind string, namespaced, found bool) {
+	r.mu.Lock()
+	index, ok := r.indexes[context]
+	r.mu.Unlock()
+	if !ok {
+		resources, err := r.Resources(context)
+		index = indexOf(resources)
+		// Only a list the cluster gave is kept; after a failure the next
+		// lookup asks again once the retry interval has passed
+		if err == nil && len(resources) > 0 {
+			r.mu.Lock()
+			if r.indexes == nil {
+				r.indexes = make(map[string]map[string]Resource)
+			}
+			r.indexes[context] = index
+			r.mu.Unlock()
+		}
+	}
+	res, ok := index[strings.ToLower(resource)]
+	if !ok {
+		return "", false, false
+	}
+	return strings.ToLower(res.Kind), res.Namespaced, true
+}
+
+// Resources returns the resource types the cluster behind context serves.
+// When the cluster can't be reached, the last known list is returned along
+// with the error and the cluster is asked again after a few minutes.
+func (r *Resolver) Resources(context string) ([]Resource, error) {
+	server := ""
+	if r.Server != nil {
+		server, _ = r.Server(context)
+	}
+
+	cached, ok := statefile.ReadCache[entry](r.CachePath)[context]
+	if ok && cached.Server != server {
+		cached, ok = entry{}, false
+	}
+	if ok && r.now().Before(cached.ExpiresAt) {
+		return cached.Resources, nil
+	}
+
+	resources, err := r.Fetch(context)
+	if err != nil {
+		r.store(context, entry{Server: server, Resources: cached.Resources, ExpiresAt: r.now().Add(retryInterval)})
+		return cached.Resources, err
+	}
+	r.store(context, entry{Server: server, Resources: resources, ExpiresAt: r.now().Add(r.TTL)})
+	return resources, nil
+}
+
+// Clear forgets the cached list of context, or of every context when
+// context is "". It returns whether anything was cached.
+func (r *Resolver) Clear(context string) (bool, error) {
+	cache := statefile.ReadCache[entry](r.CachePath)
+	r.mu.Lock()
+	delete(r.indexes, context)
+	if context == "" {
+		r.indexes = nil
+	}
+	r.mu.Unlock()
+	if context == "" {
+		if len(cache) == 0 {
+			return false, nil
+		}
+		if err := os.Remove(r.CachePath); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		return true, nil
+	}
+	if _, ok := cache[context]; !ok {
+		return false, nil
+	}
+	statefile.UpdateCache(r.CachePath, func(cache map[string]entry) { delete(cache, context) })
+	return true, nil
+}
+
+// store caches e as the list of context
+func (r *Resolver) store(context string, e entry) {
+	statefile.UpdateCache(r.CachePath, func(cache map[string]entry) { cache[context] = e })
+}
+
+// indexOf maps every name kubectl accepts for a resource type to it: the
+// plural, short names and kind, alone and qualified with the group. The
+// first type listed wins a name, as it does in kubectl.
+func indexOf(resources []Resource) map[string]Resource {
+	index := make(map[string]Resource)
+	add := func(name string, res Resource) {
+		if _, taken := index[name]; !taken && name != "" {
+			index[name] = res
+		}
+	}
+	for _, res := range resources {
+		names := append([]string{res.Name, strings.ToLower(res.Kind)}, res.ShortNames...)
+		for _, name := range names {
+			add(name, res)
+			if res.Group != "" {
+				add(name+"."+res.Group, res)
+			}
+		}
+	}
+	return index
+}
+
+// Parse reads the table printed by 'kubectl api-resources'. Columns are
+// found by their headers, since SHORTNAMES is often empty; both the
+// APIVERSION column of current kubectl and the older APIGROUP are read.
+func Parse(table string) ([]Resource, error) {
+	lines := strings.Split(strings.TrimRight(table, "\n"), "\n")
+	header := lines[0]
+	columns := map[string]int{}
+	for _, field := range strings.Fields(header) {
+		columns[field] = strings.Index(header, field)
+	}
+	for _, required := range []string{"NAME", "NAMESPACED", "KIND"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("unexpected api-resources output: no %s column", required)
+		}
+	}
+
+	var resources []Resource
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		field := func(name string) string {
+			start, ok := columns[name]
+			if !ok || start >= len(line) {
+				return ""
+			}
+			rest := line[start:]
+			if end := strings.IndexByte(rest, ' '); end >= 0 {
+				rest = rest[:end]
+			}
+			return rest
+		}
+		res := Resource{
+			Name:       field("NAME"),
+			Namespaced: field("NAMESPACED") == "true",
+			Kind:       field("KIND"),
+			Group:      field("APIGROUP"),
+		}
+		if version := field("APIVERSION"); strings.Contains(version, "/") {
+			res.Group, _, _ = strings.Cut(version, "/")
+		}
+		if short := field("SHORTNAMES"); short != "" {
+			res.ShortNames = strings.Split(short, ",")
+		}
+		if res.Name == "" || res.Kind == "" {
+			continue
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// fetchFromCluster lists the resource types with kubectl. kubectl exits
+// non-zero when an aggregated API is down but still prints the rest, which
+// is used when present.
+func fetchFromCluster(context string) ([]Resource, error) {
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput([]string{
+		"--context", context, "--request-timeout=5s", "api-resources",
+	})
+	if strings.TrimSpace(stdout) == "" {
+		if exitCode == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing api-resources: %s", strings.TrimSpace(stderr))
+	}
+	return Parse(stdout)
+}
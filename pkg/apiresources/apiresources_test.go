@@ -0,0 +1,183 @@
+package apiresources
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+const table = `NAME                              SHORTNAMES   APIVERSION                        NAMESPACED   KIND
+bindings                                       v1                                true         Binding
+nodes                             no           v1                                false        Node
+deployments                       deploy       apps/v1                           true         Deployment
+events                            ev           v1                                true         Event
+events                            ev           events.k8s.io/v1                  true         Event
+clusterissuers                    ciss         cert-manager.io/v1                false        ClusterIssuer
+networkpolicies                   netpol       networking.k8s.io/v1              true         NetworkPolicy
+`
+
+func TestParse(t *testing.T) {
+	resources, err := Parse(table)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resources) != 7 {
+		t.Fatalf("Parse returned %d resources, want 7", len(resources))
+	}
+	if r := resources[0]; r.Name != "bindings" || r.ShortNames != nil || r.Group != "" || !r.Namespaced || r.Kind != "Binding" {
+		t.Errorf("bindings = %+v", r)
+	}
+	if r := resources[5]; r.Name != "clusterissuers" || r.ShortNames[0] != "ciss" || r.Group != "cert-manager.io" || r.Namespaced {
+		t.Errorf("clusterissuers = %+v", r)
+	}
+
+	old := "NAME      SHORTNAMES   APIGROUP   NAMESPACED   KIND\ncronjobs  cj           batch      true         CronJob\n"
+	if resources, err := Parse(old); err != nil || len(resources) != 1 || resources[0].Group != "batch" {
+		t.Errorf("Parse(APIGROUP table) = %+v, %v", resources, err)
+	}
+	if _, err := Parse("error: the server doesn't have a resource type\n"); err == nil {
+		t.Error("Parse accepted output without a header")
+	}
+}
+
+func newTestResolver(t *testing.T) (*Resolver, *int, *string, *time.Time) {
+	t.Helper()
+	fetches, server, now := 0, "https://a.example.com", time.Now()
+	r := &Resolver{
+		TTL:       time.Hour,
+		CachePath: filepath.Join(t.TempDir(), "api-resources.json"),
+		Fetch: func(context string) ([]Resource, error) {
+			fetches++
+			return Parse(table)
+		},
+		Server: func(context string) (string, error) { return server, nil },
+		now:    func() time.Time { return now },
+	}
+	return r, &fetches, &server, &now
+}
+
+func TestLookup(t *testing.T) {
+	r, fetches, _, _ := newTestResolver(t)
+	tests := []struct {
+		resource   string
+		kind       string
+		namespaced bool
+		found      bool
+	}{
+		{"deploy", "deployment", true, true},
+		{"deployments.apps", "deployment", true, true},
+		{"Deployment", "deployment", true, true},
+		{"ciss", "clusterissuer", false, true},
+		{"clusterissuers.cert-manager.io", "clusterissuer", false, true},
+		{"no", "node", false, true},
+		{"widgets", "", false, false},
+	}
+	for _, tt := range tests {
+		kind, namespaced, found := r.Lookup("prod", tt.resource)
+		if kind != tt.kind || namespaced != tt.namespaced || found != tt.found {
+			t.Errorf("Lookup(%q) = %q, %v, %v, want %q, %v, %v", tt.resource, kind, namespaced, found, tt.kind, tt.namespaced, tt.found)
+		}
+	}
+	if *fetches != 1 {
+		t.Errorf("fetched %d times for one context, want 1", *fetches)
+	}
+}
+
+func TestLookup_Concurrent(t *testing.T) {
+	r := &Resolver{
+		TTL:       time.Hour,
+		CachePath: filepath.Join(t.TempDir(), "api-resources.json"),
+		Fetch:     func(context string) ([]Resource, error) { return Parse(table) },
+		now:       time.Now,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if kind, _, found := r.Lookup("app-dev", "deploy"); !found || kind != "deployment" {
+				t.Errorf("Lookup(deploy) = %q, %v", kind, found)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLookup_AfterFailure(t *testing.T) {
+	r, fetches, _, now := newTestResolver(t)
+	r.Fetch = func(string) ([]Resource, error) {
+		*fetches++
+		return nil, errors.New("unreachable")
+	}
+	if _, _, found := r.Lookup("prod", "deploy"); found {
+		t.Fatal("found deploy on an unreachable cluster")
+	}
+	if _, _, found := r.Lookup("prod", "deploy"); found || *fetches != 1 {
+		t.Errorf("fetches = %d, want no refetch within the retry interval", *fetches)
+	}
+
+	*now = now.Add(retryInterval + time.Second)
+	r.Fetch = func(string) ([]Resource, error) { return Parse(table) }
+	if kind, _, found := r.Lookup("prod", "deploy"); !found || kind != "deployment" {
+		t.Errorf("Lookup(deploy) once reachable = %q, %v; want the failure forgotten", kind, found)
+	}
+}
+
+func TestResources_Cache(t *testing.T) {
+	r, fetches, server, now := newTestResolver(t)
+
+	if _, err := r.Resources("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _ = r.Resources("prod"); *fetches != 1 {
+		t.Errorf("fetches = %d, want the cached list reused", *fetches)
+	}
+	if _, _ = r.Resources("staging"); *fetches != 2 {
+		t.Errorf("fetches = %d, want each context cached separately", *fetches)
+	}
+
+	*server = "https://b.example.com"
+	if _, _ = r.Resources("prod"); *fetches != 3 {
+		t.Errorf("fetches = %d, want a refetch after the context changed clusters", *fetches)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	r.Fetch = func(string) ([]Resource, error) { return nil, errors.New("unreachable") }
+	resources, err := r.Resources("prod")
+	if err == nil || len(resources) != 7 {
+		t.Errorf("unreachable cluster = %d resources, %v; want the last known list and the error", len(resources), err)
+	}
+	r.Fetch = func(string) ([]Resource, error) { t.Error("fetched again within the retry interval"); return nil, nil }
+	if resources, _ := r.Resources("prod"); len(resources) != 7 {
+		t.Errorf("after a failed fetch = %d resources, want 7", len(resources))
+	}
+}
+
+func TestClear(t *testing.T) {
+	r, fetches, _, _ := newTestResolver(t)
+	r.Lookup("prod", "deploy")
+	r.Lookup("staging", "deploy")
+
+	if cleared, err := r.Clear("prod"); err != nil || !cleared {
+		t.Fatalf("Clear(prod) = %v, %v", cleared, err)
+	}
+	if cleared, _ := r.Clear("prod"); cleared {
+		t.Error("Clear(prod) reported clearing twice")
+	}
+	r.Lookup("staging", "deploy")
+	r.Lookup("prod", "deploy")
+	if *fetches != 3 {
+		t.Errorf("fetches = %d, want only the cleared context read again", *fetches)
+	}
+
+	if cleared, err := r.Clear(""); err != nil || !cleared {
+		t.Fatalf("Clear(all) = %v, %v", cleared, err)
+	}
+	if len(statefile.ReadCache[entry](r.CachePath)) != 0 {
+		t.Error("Clear(all) left cached contexts")
+	}
+}
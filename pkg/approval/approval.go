@@ -0,0 +1,56 @@
+// Package approval runs the external approval command configured for a
+// tier, so kctl can defer to approval systems it knows nothing about
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+)
+
+// DeniedError reports that the approval command refused the action
+type DeniedError struct {
+	ExitCode int
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("approval command denied the action (exit %d)", e.ExitCode)
+}
+
+// Run runs command with the decision as JSON on stdin, its secret values
+// redacted (see package redact). The command's output goes to stderr so it
+// never mixes with kubectl's output. It returns nil when the command exits
+// 0 and a *DeniedError when it exits non-zero.
+func Run(command string, decision policy.Decision) error {
+	decision.Args = redact.Args(decision.Args)
+	decision.Reason = redact.Text(decision.Reason)
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(string(data))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"KCTL_CONTEXT="+decision.Context,
+		"KCTL_TIER="+decision.Tier,
+		"KCTL_ACTION="+decision.Action,
+		"KCTL_IDENTITY="+decision.Identity,
+	)
+
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &DeniedError{ExitCode: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("running approval command: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package approval
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+func TestRun(t *testing.T) {
+	decision := policy.Decision{
+		Verdict: policy.Confirm,
+		Action:  "delete",
+		Context: "app-prod",
+		Tier:    "production",
+		Args:    []string{"delete", "pod", "x", "--token", "abc123"},
+	}
+
+	captured := filepath.Join(t.TempDir(), "decision.json")
+	if err := Run("cat > "+captured+"; test \"$KCTL_ACTION\" = delete", decision); err != nil {
+		t.Fatalf("Run = %v, want approval", err)
+	}
+	data, _ := os.ReadFile(captured)
+	for _, want := range []string{`"verdict":"confirm"`, `"context":"app-prod"`, `"args":["delete","pod","x","--token","REDACTED"]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("decision JSON %s missing %s", data, want)
+		}
+	}
+
+	err := Run("exit 3", decision)
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.ExitCode != 3 {
+		t.Errorf("Run(exit 3) = %v, want DeniedError with exit 3", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Package audit records every guarded kubectl command, including blocked
+// and cancelled ones, as JSON lines. Secret values are redacted before an
+// entry is written.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Outcomes of a guarded command
+const (
+	OutcomeExecuted  = "executed"
+	OutcomeBlocked   = "blocked"
+	OutcomeCancelled = "cancelled"
+)
+
+// Entry is a single audited command
+type Entry struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user"`
+	// Identity is the cluster user the command ran as, where User is the
+	// local login
+	Identity string   `json:"identity,omitempty"`
+	Context  string   `json:"context"`
+	Tier     string   `json:"tier"`
+	Action   string   `json:"action"`
+	Args     []string `json:"args"`
+	Verdict  string   `json:"verdict"`
+	// Rule is the rule that decided the verdict (see policy.Decision.Rule)
+	Rule    string `json:"rule,omitempty"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	// Ticket is the change ticket given with --kctl-ticket
+	Ticket string `json:"ticket,omitempty"`
+	// ExitCode is kubectl's exit code; only set when the command was executed
+	ExitCode int `json:"exit_code"`
+	// DurationMs is kubectl's wall time in milliseconds
+	DurationMs   int64         `json:"duration_ms"`
+	Verification *Verification `json:"verification,omitempty"`
+	// Output is what kubectl printed, for confirmed destructive commands
+	// when audit.capture_output is on
+	Output *Output `json:"output,omitempty"`
+	// Changes are what an edit changed, one per edited object
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Change is what a command did to one object, as a JSON patch (RFC 6902)
+// from its previous state
+type Change struct {
+	Object    string    `json:"object"` // kind/name
+	Namespace string    `json:"namespace,omitempty"`
+	Patch     []PatchOp `json:"patch"`
+}
+
+// PatchOp is one JSON patch operation
+type PatchOp struct {
+	Op    string          `json:"op"` // add, remove or replace
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Verification is the result of checking a command's effect afterwards
+type Verification struct {
+	Check  string `json:"check"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Path returns the audit log location for cfg
+func Path(cfg config.AuditConfig) string {
+	if cfg.Path != "" {
+		return cfg.Path
+	}
+	return filepath.Join(config.DataDir(), "audit.jsonl")
+}
+
+// Append adds an entry to the audit log at path, with the secret values in
+// its command, reason, output and changes redacted (see package redact)
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(redacted(entry))
+	if err != nil {
+		return err
+	}
+
+	return statefile.Append(path, append(line, '\n'))
+}
+
+// redacted returns entry without secret values
+func redacted(entry Entry) Entry {
+	entry.Args = redact.Args(entry.Args)
+	entry.Reason = redact.Text(entry.Reason)
+	if entry.Output != nil {
+		entry.Output = entry.Output.redacted()
+	}
+	if len(entry.Changes) == 0 {
+		return entry
+	}
+	changes := make([]Change, len(entry.Changes))
+	for i, c := range entry.Changes {
+		kind, _, _ := strings.Cut(c.Object, "/")
+		c.Patch = append([]PatchOp(nil), c.Patch...)
+		for j, op := range c.Patch {
+			if op.Value != nil && redact.Field(kind, op.Path) {
+				c.Patch[j].Value = json.RawMessage(`"` + redact.Placeholder + `"`)
+			}
+		}
+		changes[i] = c
+	}
+	entry.Changes = changes
+	return entry
+}
+
+// Load reads all entries from the audit log at path, oldest first. A
+// missing file yields no entries.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	// Entries with captured output can be long
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip corrupt lines rather than losing the whole log
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
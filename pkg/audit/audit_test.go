@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.jsonl")
+
+	entries := []Entry{
+		{Time: time.Now().UTC(), Context: "app-prod", Action: "delete", Args: []string{"delete", "pod", "x"},
+			Verdict: "block", Outcome: OutcomeBlocked, Reason: "blocked"},
+		{Time: time.Now().UTC(), Context: "app-prod", Action: "scale", Args: []string{"scale", "deploy/app", "--replicas=2"},
+			Verdict: "confirm", Outcome: OutcomeExecuted,
+			Verification: &Verification{Check: "deploy/app has 2 ready replicas", OK: true}},
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// A corrupt line doesn't lose the rest
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("not json\n")
+	f.Close()
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Outcome != OutcomeBlocked || got[1].Verification == nil || !got[1].Verification.OK {
+		t.Errorf("Load = %+v", got)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("audit log mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestAppend_Redacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	entry := Entry{
+		Context: "app-prod", Action: "create",
+		Args: []string{"create", "secret", "generic", "db", "--from-literal=password=hunter2"},
+		Changes: []Change{{Object: "secret/db", Patch: []PatchOp{
+			{Op: "replace", Path: "/data/password", Value: json.RawMessage(`"aHVudGVyMg=="`)},
+			{Op: "add", Path: "/metadata/labels/app", Value: json.RawMessage(`"db"`)},
+		}}},
+	}
+	if err := Append(path, entry); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "aHVudGVyMg==") {
+		t.Errorf("audit log holds the secret: %s", data)
+	}
+	got, _ := Load(path)
+	if len(got) != 1 || got[0].Args[4] != "--from-literal=password=REDACTED" ||
+		string(got[0].Changes[0].Patch[0].Value) != `"REDACTED"` || string(got[0].Changes[0].Patch[1].Value) != `"db"` {
+		t.Errorf("Load = %+v", got)
+	}
+	if string(entry.Changes[0].Patch[0].Value) != `"aHVudGVyMg=="` {
+		t.Error("Append changed the caller's entry")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	entries, err := Load(filepath.Join(t.TempDir(), "none.jsonl"))
+	if err != nil || entries != nil {
+		t.Errorf("Load of missing file = %v, %v; want nil, nil", entries, err)
+	}
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+)
+
+// DefaultMaxOutput caps each captured stream, in bytes
+const DefaultMaxOutput = 64 * 1024
+
+// EncodingGzip marks streams stored gzip-compressed and base64-encoded
+const EncodingGzip = "gzip+base64"
+
+// Output is what kubectl printed for an audited command
+type Output struct {
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	// Truncated is set when a stream exceeded the size cap
+	Truncated bool `json:"truncated,omitempty"`
+	// Encoding is EncodingGzip for compressed streams, empty for plain text
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Capture is a writer keeping the first limit bytes written to it
+type Capture struct {
+	limit     int
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// NewCapture returns a Capture keeping up to limit bytes, or
+// DefaultMaxOutput when limit isn't positive
+func NewCapture(limit int) *Capture {
+	if limit <= 0 {
+		limit = DefaultMaxOutput
+	}
+	return &Capture{limit: limit}
+}
+
+// Write keeps what fits under the cap and never fails, so it can sit
+// behind an io.MultiWriter without disturbing the real output
+func (c *Capture) Write(p []byte) (int, error) {
+	room := c.limit - c.buf.Len()
+	if len(p) > room {
+		c.truncated = true
+		c.buf.Write(p[:max(room, 0)])
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// NewOutput builds the record of two captured streams, compressing them
+// when asked. Append redacts their secret values.
+func NewOutput(stdout, stderr *Capture, compress bool) (*Output, error) {
+	out := &Output{Truncated: stdout.truncated || stderr.truncated}
+	if err := out.set(stdout.buf.String(), stderr.buf.String(), compress); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// set stores the streams, compressed when asked
+func (o *Output) set(stdout, stderr string, compress bool) error {
+	o.Stdout, o.Stderr, o.Encoding = stdout, stderr, ""
+	if !compress {
+		return nil
+	}
+	var err error
+	if o.Stdout, err = gzipBase64([]byte(stdout)); err != nil {
+		return err
+	}
+	if o.Stderr, err = gzipBase64([]byte(stderr)); err != nil {
+		return err
+	}
+	o.Encoding = EncodingGzip
+	return nil
+}
+
+// redacted returns a copy of o with the secret values in its streams
+// redacted, encoded as o is. Streams that can't be decoded are dropped
+// rather than written as they are.
+func (o *Output) redacted() *Output {
+	out := &Output{Truncated: o.Truncated}
+	stdout, stderr, err := o.Text()
+	if err == nil {
+		err = out.set(redact.Text(stdout), redact.Text(stderr), o.Encoding == EncodingGzip)
+	}
+	if err != nil {
+		out.Stdout, out.Stderr, out.Encoding = redact.Placeholder, redact.Placeholder, ""
+	}
+	return out
+}
+
+// Text returns the streams as plain text, decompressing them if needed
+func (o *Output) Text() (stdout, stderr string, err error) {
+	if o.Encoding != EncodingGzip {
+		return o.Stdout, o.Stderr, nil
+	}
+	if stdout, err = gunzipBase64(o.Stdout); err != nil {
+		return "", "", err
+	}
+	if stderr, err = gunzipBase64(o.Stderr); err != nil {
+		return "", "", err
+	}
+	return stdout, stderr, nil
+}
+
+func gzipBase64(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func gunzipBase64(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	text, err := io.ReadAll(zr)
+	return string(text), err
+}
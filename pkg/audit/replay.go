@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// Difference is an audited command that other rules decide differently
+type Difference struct {
+	Entry    Entry
+	Decision policy.Decision
+}
+
+// Tighter reports whether the new decision is stricter than the recorded
+// one
+func (d Difference) Tighter() bool {
+	return d.Decision.Verdict.Stricter(policy.Verdict(d.Entry.Verdict))
+}
+
+// Replay evaluates the audited commands against cfg and returns those it
+// decides differently, along with how many were replayed. Entries decided
+// by the lock are skipped; the lock isn't part of the rules.
+func Replay(cfg *config.Config, entries []Entry) ([]Difference, int) {
+	var diffs []Difference
+	replayed := 0
+	for _, e := range entries {
+		if e.Rule == "lock" || len(e.Args) == 0 {
+			continue
+		}
+		replayed++
+		d := policy.Evaluate(cfg, e.Context, e.Args)
+		if string(d.Verdict) != e.Verdict {
+			diffs = append(diffs, Difference{Entry: e, Decision: d})
+		}
+	}
+	return diffs, replayed
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestReplay(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"scale"},
+				BlockedActions:      config.Actions("delete"),
+			},
+		},
+	}
+	entries := []Entry{
+		{Context: "app-prod", Args: []string{"delete", "pod", "web"}, Verdict: "confirm"},
+		{Context: "app-prod", Args: []string{"get", "pods"}, Verdict: "allow"},
+		{Context: "app-prod", Args: []string{"drain", "node-1"}, Verdict: "confirm"},
+		{Context: "app-prod", Args: []string{"scale", "deploy/web", "--replicas=0"}, Verdict: "block", Rule: "lock"},
+	}
+
+	diffs, replayed := Replay(cfg, entries)
+	if replayed != 3 {
+		t.Errorf("replayed = %d, want 3 (the lock's decision is skipped)", replayed)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("Replay = %+v, want 2 differences", diffs)
+	}
+	if diffs[0].Decision.Verdict != "block" || !diffs[0].Tighter() {
+		t.Errorf("delete: %+v, want newly blocked", diffs[0].Decision)
+	}
+	if diffs[1].Decision.Verdict != "allow" || diffs[1].Tighter() {
+		t.Errorf("drain: %+v, want newly allowed", diffs[1].Decision)
+	}
+}
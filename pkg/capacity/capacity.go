@@ -0,0 +1,181 @@
+// Package capacity estimates whether a cluster has room for the pods a
+// scale-up adds, from the requests of the workload's pods and what the
+// schedulable nodes have left
+package capacity
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/quantity"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Estimate is what a scale-up asks of the cluster
+type Estimate struct {
+	// Ref is the scaled workload as given, e.g. deployment/web
+	Ref             string
+	Current, Target int
+	// CPU (cores) and Memory (bytes) are requested by each pod
+	CPU, Memory float64
+	// Fits is how many new pods the schedulable nodes have room for
+	Fits int
+}
+
+// New is how many pods the scale-up adds
+func (e Estimate) New() int {
+	return e.Target - e.Current
+}
+
+// Short reports whether the cluster likely can't schedule every new pod
+func (e Estimate) Short() bool {
+	return e.Fits < e.New()
+}
+
+// containers holds the requests of a pod's containers
+type containers []struct {
+	Resources struct {
+		Requests map[string]string `json:"requests"`
+	} `json:"resources"`
+}
+
+// requests sums the CPU and memory requests of c
+func (c containers) requests() (cpu, memory float64) {
+	for _, container := range c {
+		v, _ := quantity.Parse(container.Resources.Requests["cpu"])
+		cpu += v
+		v, _ = quantity.Parse(container.Resources.Requests["memory"])
+		memory += v
+	}
+	return cpu, memory
+}
+
+// Check estimates the scale in args on context when it takes a workload to
+// more than factor times its current replicas. ok is false for other
+// commands, smaller scale-ups and pods that request neither CPU nor memory,
+// which can't be estimated.
+func Check(context string, args []string, factor float64) (e Estimate, ok bool, err error) {
+	positional := rbac.Positional(args)
+	replicas, hasReplicas := rbac.FlagValue(args, "--replicas")
+	target, convErr := strconv.Atoi(replicas)
+	if len(positional) < 2 || positional[0] != "scale" || !hasReplicas || convErr != nil ||
+		rbac.HasFlag(args, "-f", "--filename", "-l", "--selector", "--all") {
+		return e, false, nil
+	}
+	e.Target = target
+	switch operands := positional[1:]; {
+	case len(operands) == 1 && strings.Contains(operands[0], "/"):
+		e.Ref = operands[0]
+	case len(operands) == 2 && !strings.Contains(operands[0], "/"):
+		e.Ref = operands[0] + "/" + operands[1]
+	default:
+		return e, false, nil
+	}
+
+	base := []string{"--context", context, "--request-timeout=10s", "get"}
+	var workload struct {
+		Spec struct {
+			Replicas *int `json:"replicas"`
+			Template struct {
+				Spec struct {
+					Containers containers `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	workloadArgs := append(base, e.Ref)
+	if namespace, ok := rbac.FlagValue(args, "-n", "--namespace"); ok {
+		workloadArgs = append(workloadArgs, "-n", namespace)
+	}
+	if err := kubectl.GetJSON(workloadArgs, &workload); err != nil {
+		return e, false, err
+	}
+	if workload.Spec.Replicas != nil {
+		e.Current = *workload.Spec.Replicas
+	}
+	if float64(e.Target) <= float64(max(e.Current, 1))*factor {
+		return e, false, nil
+	}
+	e.CPU, e.Memory = workload.Spec.Template.Spec.Containers.requests()
+	if e.CPU == 0 && e.Memory == 0 {
+		return e, false, nil
+	}
+
+	free, err := freeOnNodes(base)
+	if err != nil {
+		return e, false, err
+	}
+	for _, f := range free {
+		fits := f.pods
+		if e.CPU > 0 {
+			fits = math.Min(fits, math.Floor(f.cpu/e.CPU))
+		}
+		if e.Memory > 0 {
+			fits = math.Min(fits, math.Floor(f.memory/e.Memory))
+		}
+		if fits > 0 {
+			e.Fits += int(fits)
+		}
+	}
+	return e, true, nil
+}
+
+// room is what a node has left
+type room struct{ cpu, memory, pods float64 }
+
+// freeOnNodes returns what each schedulable node has left once the
+// requests of the pods running on it are taken from its allocatable
+func freeOnNodes(base []string) (map[string]*room, error) {
+	var nodes struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Unschedulable bool `json:"unschedulable"`
+			} `json:"spec"`
+			Status struct {
+				Allocatable map[string]string `json:"allocatable"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := kubectl.GetJSON(append(base, "nodes"), &nodes); err != nil {
+		return nil, err
+	}
+	free := make(map[string]*room)
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		r := &room{}
+		r.cpu, _ = quantity.Parse(node.Status.Allocatable["cpu"])
+		r.memory, _ = quantity.Parse(node.Status.Allocatable["memory"])
+		r.pods, _ = quantity.Parse(node.Status.Allocatable["pods"])
+		free[node.Metadata.Name] = r
+	}
+
+	var pods struct {
+		Items []struct {
+			Spec struct {
+				NodeName   string     `json:"nodeName"`
+				Containers containers `json:"containers"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := kubectl.GetJSON(append(base, "pods", "-A", "--field-selector=status.phase!=Succeeded,status.phase!=Failed"), &pods); err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		r, ok := free[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		cpu, memory := pod.Spec.Containers.requests()
+		r.cpu -= cpu
+		r.memory -= memory
+		r.pods--
+	}
+	return free, nil
+}
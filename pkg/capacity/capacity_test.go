@@ -0,0 +1,52 @@
+package capacity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestCheck(t *testing.T) {
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		switch command := strings.Join(args, " "); {
+		case strings.Contains(command, "get deployment/web -n shop"):
+			return `{"spec":{"replicas":2,"template":{"spec":{"containers":[
+  {"resources":{"requests":{"cpu":"500m","memory":"1Gi"}}},
+  {"resources":{"requests":{"cpu":"500m"}}}
+]}}}}`, "", 0
+		case strings.Contains(command, "get nodes"):
+			return `{"items":[
+  {"metadata":{"name":"n1"},"status":{"allocatable":{"cpu":"8","memory":"16Gi","pods":"110"}}},
+  {"metadata":{"name":"n2"},"status":{"allocatable":{"cpu":"4","memory":"32Gi","pods":"110"}}},
+  {"metadata":{"name":"n3"},"spec":{"unschedulable":true},"status":{"allocatable":{"cpu":"64","memory":"256Gi","pods":"110"}}}
+]}`, "", 0
+		case strings.Contains(command, "get pods -A"):
+			return `{"items":[
+  {"spec":{"nodeName":"n1","containers":[{"resources":{"requests":{"cpu":"2","memory":"8Gi"}}}]}},
+  {"spec":{"nodeName":"n3","containers":[{"resources":{"requests":{"cpu":"1"}}}]}}
+]}`, "", 0
+		}
+		t.Errorf("unexpected kubectl %v", args)
+		return "", "", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	// n1 has 6 cores and 8Gi left: 6 pods by CPU, 8 by memory; n2 4 by CPU
+	e, ok, err := Check("app-prod", []string{"scale", "deployment/web", "--replicas=20", "-n", "shop"}, 2)
+	if err != nil || !ok {
+		t.Fatalf("Check = %v, %v", ok, err)
+	}
+	if e.Current != 2 || e.New() != 18 || e.CPU != 1 || e.Memory != 1<<30 || e.Fits != 10 || !e.Short() {
+		t.Errorf("Check = %+v, want 10 of 18 new pods to fit", e)
+	}
+
+	// Within the factor, nothing is fetched beyond the workload
+	if _, ok, _ := Check("app-prod", []string{"scale", "deployment", "web", "--replicas=4", "-n", "shop"}, 2); ok {
+		t.Error("Check estimated a scale within the factor")
+	}
+	if _, ok, _ := Check("app-prod", []string{"scale", "deployment/web", "--replicas=4", "-l", "app=web"}, 2); ok {
+		t.Error("Check estimated a scale by selector")
+	}
+}
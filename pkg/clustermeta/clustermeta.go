@@ -0,0 +1,129 @@
+// Package clustermeta reads the tier a cluster declares for itself in a
+// ConfigMap and caches it locally
+package clustermeta
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Defaults for the ConfigMap holding the declaration
+const (
+	DefaultNamespace = "kube-public"
+	DefaultName      = "kctl-cluster-meta"
+	DefaultKey       = "tier"
+	DefaultTTL       = 24 * time.Hour
+)
+
+// retryInterval is how long an unreachable cluster isn't asked again
+const retryInterval = 5 * time.Minute
+
+// entry is a cached lookup result. An empty Tier records that the cluster
+// declares nothing, so it isn't asked again until the entry expires.
+type entry struct {
+	Tier      string    `json:"tier"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Resolver looks up declared tiers, going to the cluster only when the
+// cached answer has expired
+type Resolver struct {
+	Namespace string
+	Name      string
+	Key       string
+	TTL       time.Duration
+	CachePath string
+
+	// Fetch reads the declaration from the cluster. found is false when the
+	// ConfigMap or key doesn't exist; err is set when the cluster can't be asked.
+	Fetch func(context, namespace, name, key string) (tier string, found bool, err error)
+	// Server identifies the cluster behind a context for caching, so a renamed
+	// context still finds its cached tier
+	Server func(context string) (string, error)
+
+	now func() time.Time
+}
+
+// NewResolver returns a Resolver for cfg that talks to the cluster through kubectl
+func NewResolver(cfg config.ClusterMetaConfig) *Resolver {
+	r := &Resolver{
+		Namespace: cfg.Namespace,
+		Name:      cfg.Name,
+		Key:       cfg.Key,
+		TTL:       DefaultTTL,
+		CachePath: filepath.Join(config.CacheDir(), "cluster-meta.json"),
+		Fetch:     fetchFromCluster,
+		Server:    kubectl.GetServer,
+		now:       time.Now,
+	}
+	if r.Namespace == "" {
+		r.Namespace = DefaultNamespace
+	}
+	if r.Name == "" {
+		r.Name = DefaultName
+	}
+	if r.Key == "" {
+		r.Key = DefaultKey
+	}
+	if ttl, err := time.ParseDuration(cfg.CacheTTL); err == nil {
+		r.TTL = ttl
+	}
+	return r
+}
+
+// Tier returns the tier declared by the cluster behind context, or "" when
+// it declares none. When the cluster can't be reached, the last known answer
+// is kept and the cluster is asked again after a few minutes.
+func (r *Resolver) Tier(context string) (string, error) {
+	key := "context:" + context
+	if r.Server != nil {
+		if server, err := r.Server(context); err == nil && server != "" {
+			key = server
+		}
+	}
+
+	cached, ok := statefile.ReadCache[entry](r.CachePath)[key]
+	if ok && r.now().Before(cached.ExpiresAt) {
+		return cached.Tier, nil
+	}
+
+	tier, found, err := r.Fetch(context, r.Namespace, r.Name, r.Key)
+	if err != nil {
+		r.store(key, entry{Tier: cached.Tier, ExpiresAt: r.now().Add(retryInterval)})
+		return cached.Tier, err
+	}
+	if !found {
+		tier = ""
+	}
+
+	r.store(key, entry{Tier: tier, ExpiresAt: r.now().Add(r.TTL)})
+	return tier, nil
+}
+
+// store caches e under key
+func (r *Resolver) store(key string, e entry) {
+	statefile.UpdateCache(r.CachePath, func(cache map[string]entry) { cache[key] = e })
+}
+
+// fetchFromCluster reads the declaration with kubectl
+func fetchFromCluster(context, namespace, name, key string) (string, bool, error) {
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput([]string{
+		"--context", context, "--request-timeout=5s",
+		"get", "configmap", name, "-n", namespace,
+		"-o", "jsonpath={.data." + key + "}",
+	})
+	if exitCode != 0 {
+		if strings.Contains(stderr, "NotFound") || strings.Contains(stderr, "not found") {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("reading %s/%s: %s", namespace, name, strings.TrimSpace(stderr))
+	}
+	tier := strings.TrimSpace(stdout)
+	return tier, tier != "", nil
+}
@@ -0,0 +1,94 @@
+package clustermeta
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+type fakeCluster struct {
+	tier    string
+	found   bool
+	err     error
+	fetches int
+}
+
+func (f *fakeCluster) fetch(context, namespace, name, key string) (string, bool, error) {
+	f.fetches++
+	return f.tier, f.found, f.err
+}
+
+func newTestResolver(t *testing.T, cluster *fakeCluster, now *time.Time) *Resolver {
+	r := NewResolver(config.ClusterMetaConfig{Enabled: true})
+	r.CachePath = filepath.Join(t.TempDir(), "cluster-meta.json")
+	r.Fetch = cluster.fetch
+	r.Server = func(context string) (string, error) { return "https://api.example:6443", nil }
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestResolver_CachesByServer(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster := &fakeCluster{tier: "production", found: true}
+	r := newTestResolver(t, cluster, &now)
+
+	for _, ctx := range []string{"prod", "renamed-dev"} {
+		tier, err := r.Tier(ctx)
+		if err != nil || tier != "production" {
+			t.Fatalf("Tier(%q) = %q, %v; want production", ctx, tier, err)
+		}
+	}
+	if cluster.fetches != 1 {
+		t.Errorf("fetched %d times, want 1 (same server is cached)", cluster.fetches)
+	}
+
+	now = now.Add(DefaultTTL + time.Minute)
+	cluster.tier = "staging"
+	if tier, _ := r.Tier("prod"); tier != "staging" {
+		t.Errorf("Tier after TTL = %q, want staging", tier)
+	}
+}
+
+func TestResolver_UnreachableKeepsLastAnswer(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster := &fakeCluster{tier: "production", found: true}
+	r := newTestResolver(t, cluster, &now)
+	r.Tier("prod")
+
+	now = now.Add(DefaultTTL + time.Minute)
+	cluster.err = errors.New("connection refused")
+	tier, err := r.Tier("prod")
+	if tier != "production" || err == nil {
+		t.Errorf("Tier while unreachable = %q, %v; want production and an error", tier, err)
+	}
+
+	// Not asked again until the retry interval has passed
+	fetches := cluster.fetches
+	r.Tier("prod")
+	if cluster.fetches != fetches {
+		t.Error("unreachable cluster was asked again before the retry interval")
+	}
+	now = now.Add(retryInterval + time.Second)
+	r.Tier("prod")
+	if cluster.fetches != fetches+1 {
+		t.Error("unreachable cluster was not retried after the retry interval")
+	}
+}
+
+func TestResolver_NotDeclared(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster := &fakeCluster{found: false}
+	r := newTestResolver(t, cluster, &now)
+
+	for i := 0; i < 2; i++ {
+		if tier, err := r.Tier("dev"); tier != "" || err != nil {
+			t.Errorf("Tier = %q, %v; want empty", tier, err)
+		}
+	}
+	if cluster.fetches != 1 {
+		t.Errorf("fetched %d times, want 1 (absence is cached)", cluster.fetches)
+	}
+}
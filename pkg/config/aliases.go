@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkAliases reports alias names that aren't a single word, or that look
+// like a flag, and aliases that expand to nothing
+func (c *Config) checkAliases() error {
+	for _, name := range sortedKeys(c.Aliases) {
+		if name == "" || strings.ContainsAny(name, " \t") || strings.HasPrefix(name, "-") {
+			return fmt.Errorf("alias '%s': names must be a single word and can't start with '-'", name)
+		}
+		if strings.TrimSpace(c.Aliases[name]) == "" {
+			return fmt.Errorf("alias '%s': expansion is empty", name)
+		}
+	}
+	return nil
+}
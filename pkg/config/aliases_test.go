@@ -0,0 +1,25 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAliases(t *testing.T) {
+	tests := []struct {
+		name, expansion string
+		want            string
+	}{
+		{"gp", "get pods -A -o wide", ""},
+		{"g p", "get pods", "single word"},
+		{"-gp", "get pods", "can't start with '-'"},
+		{"gp", "  ", "expansion is empty"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Aliases: map[string]string{tt.name: tt.expansion}}
+		err := cfg.checkAliases()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("checkAliases(%q: %q) = %v, want %q", tt.name, tt.expansion, err, tt.want)
+		}
+	}
+}
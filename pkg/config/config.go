@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gobwas/glob"
 	"gopkg.in/yaml.v3"
@@ -13,40 +18,506 @@ type Config struct {
 	Defaults DefaultsConfig          `yaml:"defaults"`
 	Clusters map[string]ClusterRules `yaml:"clusters"`
 	Tiers    map[string]TierConfig   `yaml:"tiers"`
+	Output   OutputConfig            `yaml:"output,omitempty"`
+	History  HistoryConfig           `yaml:"history,omitempty"`
+	// ClusterMeta lets clusters declare their own tier in a ConfigMap
+	ClusterMeta ClusterMetaConfig `yaml:"cluster_meta,omitempty"`
+	// Source names a shared config this file is layered on top of
+	Source SourceConfig `yaml:"source,omitempty"`
+	// Audit records every guarded command, including blocked ones
+	Audit AuditConfig `yaml:"audit,omitempty"`
+	// Verify checks the effect of confirmed destructive commands
+	Verify VerifyConfig `yaml:"verify,omitempty"`
+	// Tickets configures how --kctl-ticket change tickets are validated
+	Tickets TicketConfig `yaml:"tickets,omitempty"`
+	// OnCall configures the PagerDuty schedule checked by require_oncall
+	OnCall OnCallConfig `yaml:"oncall,omitempty"`
+	// Lease makes risky actions take a per-cluster Lease first
+	Lease LeaseConfig `yaml:"lease,omitempty"`
+	// Update configures 'kctl self-update' and the new version notice
+	Update UpdateConfig `yaml:"update,omitempty"`
+	// Telemetry says where usage counts go once a user opts in
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty"`
+	// Suggestions are safer alternatives printed when an action is blocked,
+	// keyed by "action kind" or "action"; they replace DefaultSuggestions
+	Suggestions map[string][]string `yaml:"suggestions,omitempty"`
+	// Severity replaces built-in action severities and raises those of kinds
+	Severity SeverityConfig `yaml:"severity,omitempty"`
+	// MaintenanceWindows downgrade blocked actions to confirmation while open
+	MaintenanceWindows map[string]MaintenanceWindow `yaml:"maintenance_windows,omitempty"`
+	// FreezeWindows block every change to matching clusters while open, even
+	// in a maintenance window, and keep 'kctl exec-credential' from issuing
+	// credentials to kubectl run outside kctl. They are written like
+	// maintenance windows.
+	FreezeWindows map[string]MaintenanceWindow `yaml:"freeze_windows,omitempty"`
+	// Namespaces assigns tiers to namespaces by pattern, keyed by tier name.
+	// A command in such a namespace gets the stricter of the verdicts of the
+	// cluster's tier and the namespace's.
+	Namespaces map[string][]string `yaml:"namespaces,omitempty"`
+	// KubectlBinaries maps context names or patterns to the kubectl to run
+	// for them: a path, or a version such as "1.28" found in PATH as
+	// kubectl1.28, kubectl-1.28 or kubectl-v1.28
+	KubectlBinaries map[string]string `yaml:"kubectl_binaries,omitempty"`
+	// KubectlPin makes kctl refuse to run a kubectl from PATH that isn't the
+	// expected one
+	KubectlPin KubectlPinConfig `yaml:"kubectl_pin,omitempty"`
+	// Retry re-runs lookups and read-only commands that couldn't reach the
+	// API server
+	Retry RetryConfig `yaml:"retry,omitempty"`
+	// UI configures the terminal UI 'kctl ui' launches
+	UI UIConfig `yaml:"ui,omitempty"`
+	// Ownership confirms changes to objects another team owns
+	Ownership OwnershipConfig `yaml:"ownership,omitempty"`
+	// Macros are blessed kubectl commands with parameters, run with 'kctl
+	// run NAME param=value...' and checked like any other command
+	Macros map[string]Macro `yaml:"macros,omitempty"`
+	// Aliases map a first word to the kubectl args it stands for, expanded
+	// before the command is checked against the rules
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// TierLookup returns the tier a cluster declares for itself, or "".
+	// nil disables in-cluster tier declarations.
+	TierLookup func(context string) (string, error) `yaml:"-"`
+	declared   map[string]string
+
+	// ServerLookup returns the API server URL for a context. It is only
+	// called when a tier has server patterns; nil disables server matching.
+	ServerLookup func(context string) (string, error) `yaml:"-"`
+	servers      map[string]string
+
+	// ResourceLookup resolves a resource type as typed on the command line
+	// to the lowercase singular kind the context's cluster serves it as.
+	// found is false for names the cluster doesn't know; nil leaves kubectl's
+	// built-in short names as the only ones understood.
+	ResourceLookup func(context, resource string) (kind string, namespaced, found bool) `yaml:"-"`
+
+	// NamespaceLookup returns the default namespace of a context, used for
+	// namespace tiers when a command has no -n. It is only called when
+	// Namespaces is set; nil assumes "default".
+	NamespaceLookup func(context string) (string, error) `yaml:"-"`
 }
 
 // DefaultsConfig represents global default settings
 type DefaultsConfig struct {
-	RequireConfirmation bool     `yaml:"require_confirmation"`
-	BlockedActions      []string `yaml:"blocked_actions"`
+	RequireConfirmation bool       `yaml:"require_confirmation"`
+	BlockedActions      ActionList `yaml:"blocked_actions"`
+	// Messages apply to every cluster unless its rules override them
+	Messages map[string]ActionMessage `yaml:"messages,omitempty"`
+	// RetypeNamespaceDeletes makes deleting a namespace, on any tier, need
+	// its name typed to confirm. Default: true
+	RetypeNamespaceDeletes *bool `yaml:"retype_namespace_deletes,omitempty"`
+	// BlockUnclassifiedProd blocks actions that can change a cluster whose
+	// context no cluster entry or tier covers but whose name contains "prod"
+	BlockUnclassifiedProd bool `yaml:"block_unclassified_prod,omitempty"`
+	// WatchKubeconfig warns when the kubeconfig gains contexts or users, or
+	// a context's server URL changes
+	WatchKubeconfig bool `yaml:"watch_kubeconfig,omitempty"`
+	// DeleteAllNamespaces decides deletes combining --all with
+	// --all-namespaces on every tier: break_glass (default), block or rules
+	DeleteAllNamespaces string `yaml:"delete_all_namespaces,omitempty"`
+}
+
+// RetypesNamespaceDeletes reports whether namespace deletes need the
+// namespace's name typed to confirm
+func (d DefaultsConfig) RetypesNamespaceDeletes() bool {
+	return d.RetypeNamespaceDeletes == nil || *d.RetypeNamespaceDeletes
+}
+
+// ActionMessage is guidance shown when an action is blocked or needs
+// confirmation, keyed by action name (or "*" for any action)
+type ActionMessage struct {
+	Message string `yaml:"message,omitempty"`
+	DocsURL string `yaml:"docs_url,omitempty"`
+}
+
+// OutputConfig controls how the wrapper writes its own messages
+type OutputConfig struct {
+	// ChromeStream selects where informational wrapper output goes:
+	// "auto" (default), "stdout" or "stderr"
+	ChromeStream string `yaml:"chrome_stream,omitempty"`
+	// SummaryAfter prints a one-line summary after gated commands that ran
+	// at least this long. Default: 10s; "0" after every one; "off" never
+	SummaryAfter string `yaml:"summary_after,omitempty"`
+	// SpinnerAfter shows a spinner on stderr once a command has been silent
+	// this long. Default: 2s; "off" never
+	SpinnerAfter string `yaml:"spinner_after,omitempty"`
+	// Colorize colors statuses in 'kubectl get' tables written to a terminal
+	Colorize bool `yaml:"colorize,omitempty"`
+	// Pager pages output taller than the terminal: "auto" uses $PAGER or
+	// less -R, any other value is the pager command. Default: off
+	Pager string `yaml:"pager,omitempty"`
+}
+
+// HistoryConfig controls the local command history used by 'kctl history'
+type HistoryConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// Values for the default setting of a cluster or tier
+const (
+	DefaultAllow   = "allow"
+	DefaultConfirm = "confirm"
+	DefaultDeny    = "deny"
+)
+
+// Values for unreachable: what happens to gated commands when the API
+// server can't be reached
+const (
+	UnreachableWarn  = "warn"
+	UnreachableBlock = "block"
+)
+
+// Values for untrusted_images: what happens to applies whose images break
+// allowed_registries or require_digests
+const (
+	UntrustedImagesWarn  = "warn"
+	UntrustedImagesBlock = "block"
+)
+
+// Values for over_max_affected: what happens when a command's dry run
+// touches more than max_affected objects
+const (
+	OverMaxBlock      = "block"
+	OverMaxBreakGlass = "break_glass"
+)
+
+// ClusterMetaConfig controls reading a cluster's self-declared tier
+type ClusterMetaConfig struct {
+	Enabled   bool   `yaml:"enabled,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"` // Default: kube-public
+	Name      string `yaml:"name,omitempty"`      // ConfigMap name. Default: kctl-cluster-meta
+	Key       string `yaml:"key,omitempty"`       // Data key holding the tier. Default: tier
+	CacheTTL  string `yaml:"cache_ttl,omitempty"` // How long a lookup is reused. Default: 24h
+}
+
+// AuditConfig controls the local audit log
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Path    string `yaml:"path,omitempty"` // Default: audit.jsonl in the data directory
+	// CaptureOutput records kubectl's output of confirmed destructive
+	// commands, up to MaxOutputBytes per stream (default 64 KiB)
+	CaptureOutput  bool `yaml:"capture_output,omitempty"`
+	MaxOutputBytes int  `yaml:"max_output_bytes,omitempty"`
+	CompressOutput bool `yaml:"compress_output,omitempty"` // Store output gzipped and base64-encoded
+	// MaxAge and MaxSize prune the log, oldest entries first, once a day
+	// and on 'kctl maintenance gc'. Default: keep everything.
+	MaxAge  string `yaml:"max_age,omitempty"`  // e.g. 90d or 720h
+	MaxSize string `yaml:"max_size,omitempty"` // e.g. 50MB or 1GiB
+}
+
+// VerifyConfig controls checking the outcome of confirmed destructive
+// commands: deleted resources are gone, scaled workloads reach their
+// replicas, drained nodes are cordoned
+type VerifyConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"` // How long to poll. Default: 30s
+}
+
+// TicketConfig describes the change-ticket system. Without a URL, tickets
+// are only checked against Pattern.
+type TicketConfig struct {
+	Pattern string `yaml:"pattern,omitempty"` // Regex a ticket must match, e.g. ^CHG-[0-9]+$
+	// URL of the ticket in a REST API; {ticket} is replaced with the ticket ID,
+	// e.g. https://jira.example.com/rest/api/2/issue/{ticket}
+	URL            string   `yaml:"url,omitempty"`
+	TokenEnv       string   `yaml:"token_env,omitempty"`       // Environment variable holding the API token
+	TokenSecret    string   `yaml:"token_secret,omitempty"`    // Keyring entry holding the API token (see 'kctl secret')
+	AuthScheme     string   `yaml:"auth_scheme,omitempty"`     // Authorization scheme for the token. Default: Bearer
+	StatusField    string   `yaml:"status_field,omitempty"`    // Dotted path to the state in the response, e.g. fields.status.name
+	ApprovedStates []string `yaml:"approved_states,omitempty"` // States allowing the change; empty accepts any existing ticket
+	Timeout        string   `yaml:"timeout,omitempty"`         // Default: 10s
+}
+
+// OnCallConfig describes the PagerDuty schedule whose current on-call
+// engineers may run destructive actions on require_oncall tiers unapproved
+type OnCallConfig struct {
+	ScheduleID  string `yaml:"schedule_id,omitempty"`
+	TokenEnv    string `yaml:"token_env,omitempty"`    // Environment variable holding a PagerDuty API token
+	TokenSecret string `yaml:"token_secret,omitempty"` // Keyring entry holding the token (see 'kctl secret')
+	Email       string `yaml:"email,omitempty"`        // Your PagerDuty login. Default: git config user.email
+	APIURL      string `yaml:"api_url,omitempty"`      // Default: https://api.pagerduty.com
+	Timeout     string `yaml:"timeout,omitempty"`      // Default: 10s
+}
+
+// UpdateConfig controls where kctl looks for new releases
+type UpdateConfig struct {
+	// Check prints a notice, at most once a day, when a newer release exists
+	Check      bool   `yaml:"check,omitempty"`
+	Repository string `yaml:"repository,omitempty"` // GitHub owner/name publishing releases
+	APIURL     string `yaml:"api_url,omitempty"`    // Default: https://api.github.com
+	// PublicKey is the base64 Ed25519 key releases' checksums are signed
+	// with; when set, unsigned or badly signed releases aren't installed
+	PublicKey string `yaml:"public_key,omitempty"`
+}
+
+// TelemetryConfig names where anonymous usage counts are reported. Nothing
+// is sent unless the user also runs 'kctl telemetry on'.
+type TelemetryConfig struct {
+	Endpoint string `yaml:"endpoint,omitempty"` // URL the daily report is POSTed to as JSON
+}
+
+// KubectlPinConfig says which kubectl PATH must lead to. Either setting may
+// be used alone.
+type KubectlPinConfig struct {
+	Path   string `yaml:"path,omitempty"`   // Absolute path 'kubectl' must resolve to
+	SHA256 string `yaml:"sha256,omitempty"` // Hex SHA-256 of the kubectl binary
+}
+
+// LeaseConfig controls the coordination Lease taken before risky actions so
+// two engineers don't operate on the same cluster at once
+type LeaseConfig struct {
+	Enabled   bool     `yaml:"enabled,omitempty"`
+	Actions   []string `yaml:"actions,omitempty"`   // Actions that take the lease. Default: drain, delete
+	Namespace string   `yaml:"namespace,omitempty"` // Default: kube-system
+	Name      string   `yaml:"name,omitempty"`      // Lease name. Default: kctl-operations
+	Duration  string   `yaml:"duration,omitempty"`  // Lapses unless renewed within. Default: 10m
+}
+
+// SourceConfig describes a shared policy repository
+type SourceConfig struct {
+	Type         string `yaml:"type,omitempty"`          // Only "git" is supported
+	URL          string `yaml:"url,omitempty"`           // Repository to clone
+	Ref          string `yaml:"ref,omitempty"`           // Branch or tag. Default: the remote's default branch
+	Path         string `yaml:"path,omitempty"`          // Config file within the repository. Default: config.yaml
+	SyncInterval string `yaml:"sync_interval,omitempty"` // How often to pull automatically. Default: 1h; "0" disables
+	// TokenSecret names a keyring entry (see 'kctl secret') holding an access
+	// token for an HTTPS repository
+	TokenSecret string `yaml:"token_secret,omitempty"`
 }
 
 // ClusterRules represents rules for a specific cluster
 type ClusterRules struct {
-	Tier                string   `yaml:"tier"`
-	RequireConfirmation []string `yaml:"require_confirmation"`
-	BlockedActions      []string `yaml:"blocked_actions"`
+	Tier                string     `yaml:"tier"`
+	RequireConfirmation []string   `yaml:"require_confirmation"`
+	BlockedActions      ActionList `yaml:"blocked_actions"`
+	AllowedActions      []string   `yaml:"allowed_actions,omitempty"` // Exceptions to "*" rules and default
+	Default             string     `yaml:"default,omitempty"`         // Verdict for unlisted actions: allow, confirm or deny
+	Banner              bool       `yaml:"banner,omitempty"`          // Print a context banner before every command
+	// Block destructive commands that rely on the implicit current-context
+	RequireExplicitContext bool `yaml:"require_explicit_context,omitempty"`
+	// Block mutating commands on namespaced resources without -n
+	RequireExplicitNamespace bool `yaml:"require_explicit_namespace,omitempty"`
+	// Block deletes without a name, selector or -f, and --all without -n
+	BlockUnqualifiedDeletes bool `yaml:"block_unqualified_deletes,omitempty"`
+	// Priority orders overlapping glob entries; higher wins
+	Priority int                      `yaml:"priority,omitempty"`
+	Messages map[string]ActionMessage `yaml:"messages,omitempty"`
+	// ApprovalCommand runs before gated actions; they proceed only if it exits 0
+	ApprovalCommand string `yaml:"approval_command,omitempty"`
+	// ConfirmationPhrase must be typed exactly to confirm, instead of y
+	ConfirmationPhrase string `yaml:"confirmation_phrase,omitempty"`
+	// AddFlags are appended to commands by action unless already set, e.g.
+	// delete: ["--wait=true", "--timeout=120s"]
+	AddFlags map[string][]string `yaml:"add_flags,omitempty"`
+	// RequireTicket makes destructive actions need a valid --kctl-ticket
+	RequireTicket bool `yaml:"require_ticket,omitempty"`
+	// RequireOnCall makes destructive actions by engineers who aren't on call
+	// need approval_command
+	RequireOnCall bool `yaml:"require_oncall,omitempty"`
+	// RequireMFA asks for a TOTP code (see 'kctl mfa enroll') before
+	// critical actions
+	RequireMFA bool `yaml:"require_mfa,omitempty"`
+	// HealthCheck shows a summary of node readiness, pending pods and
+	// recent warning events before a drain or rollout restart
+	HealthCheck bool `yaml:"health_check,omitempty"`
+	// CheckQuota warns when applied or created objects won't fit their
+	// namespace's ResourceQuotas and LimitRanges
+	CheckQuota bool `yaml:"check_quota,omitempty"`
+	// MaxAffected escalates mutating commands whose server-side dry run
+	// touches more objects; OverMaxAffected is block (default) or break_glass
+	MaxAffected     int    `yaml:"max_affected,omitempty"`
+	OverMaxAffected string `yaml:"over_max_affected,omitempty"`
+	// RetypeChildren makes deleting an object that owns at least this many
+	// others (a Deployment's ReplicaSets, a Job's Pods) need its name typed
+	// to confirm
+	RetypeChildren int `yaml:"retype_children,omitempty"`
+	// ScaleUpFactor makes scales to more than this many times the current
+	// replicas check the cluster has room for the new pods
+	ScaleUpFactor float64 `yaml:"scale_up_factor,omitempty"`
+	// ConfirmSeverity and BlockSeverity gate commands by severity (see
+	// SeverityConfig): "high" for exactly high, "medium+" for medium or above
+	ConfirmSeverity string `yaml:"confirm_severity,omitempty"`
+	BlockSeverity   string `yaml:"block_severity,omitempty"`
+	// Timeouts end commands running longer than a duration, by action or
+	// "*", e.g. exec: 30m
+	Timeouts map[string]string `yaml:"timeouts,omitempty"`
+	// WaitForDeletes makes deletes wait until their objects are gone, up to
+	// this long (e.g. 5m), showing what is left as they go
+	WaitForDeletes string `yaml:"wait_for_deletes,omitempty"`
+	// WatchRollouts follows the rollouts a confirmed rollout restart or
+	// apply starts, up to this long (e.g. 5m), and offers to undo one that
+	// fails
+	WatchRollouts string `yaml:"watch_rollouts,omitempty"`
+	// Unreachable decides gated commands when the API server can't be
+	// reached: warn (default) lets them through, block fails closed
+	Unreachable string `yaml:"unreachable,omitempty"`
+	// ManifestHosts, when set, are the only hosts (patterns allowed) remote
+	// -f URLs and -k repositories may come from
+	ManifestHosts []string `yaml:"manifest_hosts,omitempty"`
+	// AllowedNamespaces, when set, are the only namespaces (patterns
+	// allowed) mutating commands may target
+	AllowedNamespaces []string `yaml:"allowed_namespaces,omitempty"`
+	// AllowedRegistries, when set, are the only registries (patterns
+	// allowed, e.g. ghcr.io/acme/*) applied manifests may run images from
+	AllowedRegistries []string `yaml:"allowed_registries,omitempty"`
+	// RequireDigests makes applied manifests pin their images by digest
+	RequireDigests bool `yaml:"require_digests,omitempty"`
+	// UntrustedImages decides applies breaking allowed_registries or
+	// require_digests: warn (default) or block
+	UntrustedImages string `yaml:"untrusted_images,omitempty"`
+	// ExpiresAt makes the entry temporary; it is ignored from then on
+	ExpiresAt time.Time `yaml:"expires_at,omitempty"`
 }
 
 // TierConfig represents rules for a tier of clusters
 type TierConfig struct {
-	Patterns            []string `yaml:"patterns"`
-	RequireConfirmation []string `yaml:"require_confirmation"`
-	BlockedActions      []string `yaml:"blocked_actions"`
+	// Inherits names a parent tier whose rules this tier extends. Own
+	// require_confirmation and blocked_actions are added to the parent's.
+	Inherits             string     `yaml:"inherits,omitempty"`
+	RemoveConfirmation   []string   `yaml:"remove_confirmation,omitempty"`    // Parent confirmations to drop
+	RemoveBlockedActions []string   `yaml:"remove_blocked_actions,omitempty"` // Parent blocked actions to drop
+	Patterns             []string   `yaml:"patterns"`
+	Servers              []string   `yaml:"servers,omitempty"` // Patterns for the cluster's API server URL
+	RequireConfirmation  []string   `yaml:"require_confirmation"`
+	BlockedActions       ActionList `yaml:"blocked_actions"`
+	AllowedActions       []string   `yaml:"allowed_actions,omitempty"` // Exceptions to "*" rules and default
+	Default              string     `yaml:"default,omitempty"`         // Verdict for unlisted actions: allow, confirm or deny
+	Banner               bool       `yaml:"banner,omitempty"`          // Print a context banner before every command
+	// Block destructive commands that rely on the implicit current-context
+	RequireExplicitContext bool `yaml:"require_explicit_context,omitempty"`
+	// Block mutating commands on namespaced resources without -n
+	RequireExplicitNamespace bool `yaml:"require_explicit_namespace,omitempty"`
+	// Block deletes without a name, selector or -f, and --all without -n
+	BlockUnqualifiedDeletes bool `yaml:"block_unqualified_deletes,omitempty"`
+	// Priority orders tiers whose patterns overlap; higher wins
+	Priority int                      `yaml:"priority,omitempty"`
+	Messages map[string]ActionMessage `yaml:"messages,omitempty"`
+	// ApprovalCommand runs before gated actions; they proceed only if it exits 0
+	ApprovalCommand string `yaml:"approval_command,omitempty"`
+	// ConfirmationPhrase must be typed exactly to confirm, instead of y
+	ConfirmationPhrase string `yaml:"confirmation_phrase,omitempty"`
+	// AddFlags are appended to commands by action unless already set, e.g.
+	// delete: ["--wait=true", "--timeout=120s"]
+	AddFlags map[string][]string `yaml:"add_flags,omitempty"`
+	// RequireTicket makes destructive actions need a valid --kctl-ticket
+	RequireTicket bool `yaml:"require_ticket,omitempty"`
+	// RequireOnCall makes destructive actions by engineers who aren't on call
+	// need approval_command
+	RequireOnCall bool `yaml:"require_oncall,omitempty"`
+	// RequireMFA asks for a TOTP code (see 'kctl mfa enroll') before
+	// critical actions
+	RequireMFA bool `yaml:"require_mfa,omitempty"`
+	// HealthCheck shows a summary of node readiness, pending pods and
+	// recent warning events before a drain or rollout restart
+	HealthCheck bool `yaml:"health_check,omitempty"`
+	// CheckQuota warns when applied or created objects won't fit their
+	// namespace's ResourceQuotas and LimitRanges
+	CheckQuota bool `yaml:"check_quota,omitempty"`
+	// MaxAffected escalates mutating commands whose server-side dry run
+	// touches more objects; OverMaxAffected is block (default) or break_glass
+	MaxAffected     int    `yaml:"max_affected,omitempty"`
+	OverMaxAffected string `yaml:"over_max_affected,omitempty"`
+	// RetypeChildren makes deleting an object that owns at least this many
+	// others (a Deployment's ReplicaSets, a Job's Pods) need its name typed
+	// to confirm
+	RetypeChildren int `yaml:"retype_children,omitempty"`
+	// ScaleUpFactor makes scales to more than this many times the current
+	// replicas check the cluster has room for the new pods
+	ScaleUpFactor float64 `yaml:"scale_up_factor,omitempty"`
+	// ConfirmSeverity and BlockSeverity gate commands by severity (see
+	// SeverityConfig): "high" for exactly high, "medium+" for medium or above
+	ConfirmSeverity string `yaml:"confirm_severity,omitempty"`
+	BlockSeverity   string `yaml:"block_severity,omitempty"`
+	// Timeouts end commands running longer than a duration, by action or
+	// "*", e.g. exec: 30m
+	Timeouts map[string]string `yaml:"timeouts,omitempty"`
+	// WaitForDeletes makes deletes wait until their objects are gone, up to
+	// this long (e.g. 5m), showing what is left as they go
+	WaitForDeletes string `yaml:"wait_for_deletes,omitempty"`
+	// WatchRollouts follows the rollouts a confirmed rollout restart or
+	// apply starts, up to this long (e.g. 5m), and offers to undo one that
+	// fails
+	WatchRollouts string `yaml:"watch_rollouts,omitempty"`
+	// Unreachable decides gated commands when the API server can't be
+	// reached: warn (default) lets them through, block fails closed
+	Unreachable string `yaml:"unreachable,omitempty"`
+	// ManifestHosts, when set, are the only hosts (patterns allowed) remote
+	// -f URLs and -k repositories may come from
+	ManifestHosts []string `yaml:"manifest_hosts,omitempty"`
+	// AllowedNamespaces, when set, are the only namespaces (patterns
+	// allowed) mutating commands may target
+	AllowedNamespaces []string `yaml:"allowed_namespaces,omitempty"`
+	// AllowedRegistries, when set, are the only registries (patterns
+	// allowed, e.g. ghcr.io/acme/*) applied manifests may run images from
+	AllowedRegistries []string `yaml:"allowed_registries,omitempty"`
+	// RequireDigests makes applied manifests pin their images by digest
+	RequireDigests bool `yaml:"require_digests,omitempty"`
+	// UntrustedImages decides applies breaking allowed_registries or
+	// require_digests: warn (default) or block
+	UntrustedImages string `yaml:"untrusted_images,omitempty"`
 }
 
 // ResolvedRules represents the final resolved rules for a cluster
 type ResolvedRules struct {
-	Tier                string
-	RequireConfirmation []string
-	BlockedActions      []string
+	Tier                   string                   `yaml:"tier"`
+	RequireConfirmation    []string                 `yaml:"require_confirmation"`
+	BlockedActions         []string                 `yaml:"blocked_actions"`
+	AllowedActions         []string                 `yaml:"allowed_actions,omitempty"`
+	Default                string                   `yaml:"default,omitempty"`
+	Banner                 bool                     `yaml:"banner"`
+	Messages               map[string]ActionMessage `yaml:"messages,omitempty"`
+	RequireExplicitContext bool                     `yaml:"require_explicit_context"`
+	// RequireExplicitNamespace and BlockUnqualifiedDeletes guard against
+	// commands that silently use the context's namespace or hit everything
+	RequireExplicitNamespace bool                `yaml:"require_explicit_namespace,omitempty"`
+	BlockUnqualifiedDeletes  bool                `yaml:"block_unqualified_deletes,omitempty"`
+	ApprovalCommand          string              `yaml:"approval_command,omitempty"`
+	ConfirmationPhrase       string              `yaml:"confirmation_phrase,omitempty"`
+	AddFlags                 map[string][]string `yaml:"add_flags,omitempty"`
+	RequireTicket            bool                `yaml:"require_ticket,omitempty"`
+	RequireOnCall            bool                `yaml:"require_oncall,omitempty"`
+	RequireMFA               bool                `yaml:"require_mfa,omitempty"`
+	HealthCheck              bool                `yaml:"health_check,omitempty"`
+	CheckQuota               bool                `yaml:"check_quota,omitempty"`
+	MaxAffected              int                 `yaml:"max_affected,omitempty"`
+	OverMaxAffected          string              `yaml:"over_max_affected,omitempty"`
+	RetypeChildren           int                 `yaml:"retype_children,omitempty"`
+	ScaleUpFactor            float64             `yaml:"scale_up_factor,omitempty"`
+	ConfirmSeverity          string              `yaml:"confirm_severity,omitempty"`
+	BlockSeverity            string              `yaml:"block_severity,omitempty"`
+	Timeouts                 map[string]string   `yaml:"timeouts,omitempty"`
+	WaitForDeletes           string              `yaml:"wait_for_deletes,omitempty"`
+	WatchRollouts            string              `yaml:"watch_rollouts,omitempty"`
+	Unreachable              string              `yaml:"unreachable,omitempty"`
+	ManifestHosts            []string            `yaml:"manifest_hosts,omitempty"`
+	AllowedNamespaces        []string            `yaml:"allowed_namespaces,omitempty"`
+	AllowedRegistries        []string            `yaml:"allowed_registries,omitempty"`
+	RequireDigests           bool                `yaml:"require_digests,omitempty"`
+	UntrustedImages          string              `yaml:"untrusted_images,omitempty"`
+	// Maintenance names the open maintenance window covering the cluster
+	Maintenance string `yaml:"maintenance,omitempty"`
+	// Freeze names the open freeze window covering the cluster
+	Freeze string `yaml:"freeze,omitempty"`
 }
 
-// ConfigPath returns the path to the config file
+// ConfigPath returns the path to the config file: config.yaml in ConfigDir,
+// or the active profile's file under profiles/
 func ConfigPath() string {
+	dir := ConfigDir()
+	if dir == "" {
+		return ""
+	}
+	if profile := Profile(); profile != "" {
+		return ProfilePath(profile)
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+// ConfigDir returns the directory holding the config files
+func ConfigDir() string {
 	// Check XDG_CONFIG_HOME first
 	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-		return filepath.Join(xdgConfig, "kubectl-enhanced", "config.yaml")
+		return filepath.Join(xdgConfig, "kubectl-enhanced")
 	}
 
 	// Fall back to ~/.config
@@ -54,7 +525,35 @@ func ConfigPath() string {
 	if err != nil {
 		return ""
 	}
-	return filepath.Join(home, ".config", "kubectl-enhanced", "config.yaml")
+	return filepath.Join(home, ".config", "kubectl-enhanced")
+}
+
+// DataDir returns the directory used for persistent data such as history
+func DataDir() string {
+	// Check XDG_DATA_HOME first
+	if xdgData := os.Getenv("XDG_DATA_HOME"); xdgData != "" {
+		return filepath.Join(xdgData, "kubectl-enhanced")
+	}
+
+	// Fall back to ~/.local/share
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "kubectl-enhanced")
+}
+
+// CacheDir returns the directory for cached lookups
+func CacheDir() string {
+	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
+		return filepath.Join(xdgCache, "kubectl-enhanced")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "kubectl-enhanced")
 }
 
 // Load loads the configuration from the default config path
@@ -64,6 +563,176 @@ func Load() (*Config, error) {
 
 // LoadFromPath loads configuration from a specific path
 func LoadFromPath(path string) (*Config, error) {
+	cfg, err := decodeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadWithBase loads the config at path layered over the config at
+// basePath (see Merge). Validation happens after merging, so the local file
+// may refer to tiers defined only in the base.
+func LoadWithBase(basePath, path string) (*Config, error) {
+	base, err := decodeFile(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", basePath, err)
+	}
+	local, err := decodeFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Merge(base, local)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadSource reads only the source section of the config at path
+func LoadSource(path string) (SourceConfig, error) {
+	cfg, err := decodeFile(path)
+	if err != nil {
+		return SourceConfig{}, err
+	}
+	return cfg.Source, nil
+}
+
+// Validate checks rules that can't be expressed in the YAML structure
+func (c *Config) Validate() error {
+	if err := c.checkInheritance(); err != nil {
+		return err
+	}
+	if err := c.checkDefaults(); err != nil {
+		return err
+	}
+	if err := c.checkMaintenance(); err != nil {
+		return err
+	}
+	if err := c.checkNamespaces(); err != nil {
+		return err
+	}
+	if err := c.checkSeverity(); err != nil {
+		return err
+	}
+	if err := c.checkKubectlBinaries(); err != nil {
+		return err
+	}
+	if err := c.checkKubectlPin(); err != nil {
+		return err
+	}
+	if err := c.checkDeleteAll(); err != nil {
+		return err
+	}
+	if err := c.checkRetry(); err != nil {
+		return err
+	}
+	if err := c.checkTimeouts(); err != nil {
+		return err
+	}
+	if err := c.checkOwnership(); err != nil {
+		return err
+	}
+	if err := c.checkMacros(); err != nil {
+		return err
+	}
+	if err := c.checkAliases(); err != nil {
+		return err
+	}
+	if c.Tickets.Pattern != "" {
+		if _, err := regexp.Compile(c.Tickets.Pattern); err != nil {
+			return fmt.Errorf("tickets: invalid pattern: %w", err)
+		}
+	}
+	return c.checkPatterns()
+}
+
+// checkDefaults reports unknown values of the default, over_max_affected,
+// unreachable and untrusted_images settings, and scale_up_factor values
+// below 1
+func (c *Config) checkDefaults() error {
+	valid := func(v string) bool {
+		return v == "" || v == DefaultAllow || v == DefaultConfirm || v == DefaultDeny
+	}
+	for name, rules := range c.Clusters {
+		if !valid(rules.Default) {
+			return fmt.Errorf("cluster '%s': default must be allow, confirm or deny, got %q", name, rules.Default)
+		}
+		if !validOverMax(rules.OverMaxAffected) {
+			return fmt.Errorf("cluster '%s': over_max_affected must be block or break_glass, got %q", name, rules.OverMaxAffected)
+		}
+		if !validUnreachable(rules.Unreachable) {
+			return fmt.Errorf("cluster '%s': unreachable must be warn or block, got %q", name, rules.Unreachable)
+		}
+		if !validUntrustedImages(rules.UntrustedImages) {
+			return fmt.Errorf("cluster '%s': untrusted_images must be warn or block, got %q", name, rules.UntrustedImages)
+		}
+		if rules.ScaleUpFactor != 0 && rules.ScaleUpFactor < 1 {
+			return fmt.Errorf("cluster '%s': scale_up_factor must be at least 1, got %v", name, rules.ScaleUpFactor)
+		}
+	}
+	for name, tier := range c.Tiers {
+		if !valid(tier.Default) {
+			return fmt.Errorf("tier '%s': default must be allow, confirm or deny, got %q", name, tier.Default)
+		}
+		if !validOverMax(tier.OverMaxAffected) {
+			return fmt.Errorf("tier '%s': over_max_affected must be block or break_glass, got %q", name, tier.OverMaxAffected)
+		}
+		if !validUnreachable(tier.Unreachable) {
+			return fmt.Errorf("tier '%s': unreachable must be warn or block, got %q", name, tier.Unreachable)
+		}
+		if !validUntrustedImages(tier.UntrustedImages) {
+			return fmt.Errorf("tier '%s': untrusted_images must be warn or block, got %q", name, tier.UntrustedImages)
+		}
+		if tier.ScaleUpFactor != 0 && tier.ScaleUpFactor < 1 {
+			return fmt.Errorf("tier '%s': scale_up_factor must be at least 1, got %v", name, tier.ScaleUpFactor)
+		}
+	}
+	return nil
+}
+
+func validOverMax(v string) bool {
+	return v == "" || v == OverMaxBlock || v == OverMaxBreakGlass
+}
+
+func validUnreachable(v string) bool {
+	return v == "" || v == UnreachableWarn || v == UnreachableBlock
+}
+
+func validUntrustedImages(v string) bool {
+	return v == "" || v == UntrustedImagesWarn || v == UntrustedImagesBlock
+}
+
+// CheckKnownFields reports keys in data that don't correspond to any
+// config setting, which are otherwise silently ignored. Syntax and type
+// errors are left to loading.
+func CheckKnownFields(data []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var cfg Config
+	err := decoder.Decode(&cfg)
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	for _, msg := range typeErr.Errors {
+		if strings.Contains(msg, "not found in type") {
+			unknown = append(unknown, msg)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown keys:\n  %s", strings.Join(unknown, "\n  "))
+}
+
+func decodeFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -73,7 +742,6 @@ func LoadFromPath(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
-
 	return &cfg, nil
 }
 
@@ -82,24 +750,26 @@ func Default() *Config {
 	return &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      ActionList{},
 		},
 		Clusters: make(map[string]ClusterRules),
 		Tiers: map[string]TierConfig{
 			"production": {
 				Patterns:            []string{"*-prod", "*-production", "prod-*", "production-*"},
 				RequireConfirmation: []string{"delete", "drain"},
-				BlockedActions:      []string{},
+				BlockedActions:      ActionList{},
+				Banner:              true,
+				RetypeChildren:      20,
 			},
 			"staging": {
 				Patterns:            []string{"*-staging", "*-stg", "staging-*", "stg-*"},
 				RequireConfirmation: []string{"delete"},
-				BlockedActions:      []string{},
+				BlockedActions:      ActionList{},
 			},
 			"development": {
 				Patterns:            []string{"*-dev", "*-development", "dev-*", "development-*", "local*", "minikube", "docker-desktop", "kind-*"},
 				RequireConfirmation: []string{},
-				BlockedActions:      []string{},
+				BlockedActions:      ActionList{},
 			},
 		},
 	}
@@ -107,37 +777,56 @@ func Default() *Config {
 
 // GetClusterRules returns the resolved rules for a given cluster context
 func (c *Config) GetClusterRules(context string) ResolvedRules {
-	// 1. Check for exact cluster match
-	if rules, ok := c.Clusters[context]; ok {
-		return ResolvedRules{
-			Tier:                rules.Tier,
-			RequireConfirmation: rules.RequireConfirmation,
-			BlockedActions:      rules.BlockedActions,
+	rules := c.matchRules(context)
+	rules.Messages = mergeByAction(c.Defaults.Messages, rules.Messages)
+	rules.Maintenance = c.activeMaintenance(context)
+	rules.Freeze = c.ActiveFreeze(context)
+	return rules
+}
+
+// GetTierRules returns the resolved rules of a configured tier, as a
+// cluster matching it would get them outside maintenance windows
+func (c *Config) GetTierRules(name string) (ResolvedRules, bool) {
+	if _, ok := c.Tiers[name]; !ok {
+		return ResolvedRules{}, false
+	}
+	rules := c.tierRules(name)
+	rules.Messages = mergeByAction(c.Defaults.Messages, rules.Messages)
+	return rules, true
+}
+
+// MessageFor returns the guidance for an action decided by rule (the entry
+// that matched, as returned by rbac.Resolve): a message for the action
+// itself wins over one for the rule, then over "*"
+func (r ResolvedRules) MessageFor(action, rule string) ActionMessage {
+	for _, key := range []string{action, rule, "*"} {
+		if msg, ok := r.Messages[key]; ok {
+			return msg
 		}
 	}
+	return ActionMessage{}
+}
+
+// matchRules resolves the rules for context without global messages
+func (c *Config) matchRules(context string) ResolvedRules {
+	// 0. A tier declared by the cluster itself can't be renamed around
+	if tierName := c.declaredTier(context); tierName != "" {
+		return c.tierRules(tierName)
+	}
+
+	// 1. Check for exact cluster match
+	if rules, ok := c.Clusters[context]; ok && !rules.Expired() {
+		return clusterRules(rules)
+	}
 
 	// 2. Check for glob pattern match in clusters
-	for pattern, rules := range c.Clusters {
-		if matchGlob(pattern, context) {
-			return ResolvedRules{
-				Tier:                rules.Tier,
-				RequireConfirmation: rules.RequireConfirmation,
-				BlockedActions:      rules.BlockedActions,
-			}
-		}
+	if pattern, ok := c.matchClusterPattern(context); ok {
+		return clusterRules(c.Clusters[pattern])
 	}
 
-	// 3. Check tier patterns
-	for tierName, tier := range c.Tiers {
-		for _, pattern := range tier.Patterns {
-			if matchGlob(pattern, context) {
-				return ResolvedRules{
-					Tier:                tierName,
-					RequireConfirmation: tier.RequireConfirmation,
-					BlockedActions:      tier.BlockedActions,
-				}
-			}
-		}
+	// 3. Check tier server patterns, then tier context patterns
+	if tierName, ok := c.matchTier(context); ok {
+		return c.tierRules(tierName)
 	}
 
 	// 4. Return defaults
@@ -150,12 +839,111 @@ func (c *Config) GetClusterRules(context string) ResolvedRules {
 	return ResolvedRules{
 		Tier:                "default",
 		RequireConfirmation: confirmActions,
-		BlockedActions:      c.Defaults.BlockedActions,
+		BlockedActions:      c.Defaults.BlockedActions.Active(),
+	}
+}
+
+// Expired reports whether a temporary cluster entry has run out
+func (r ClusterRules) Expired() bool {
+	return !r.ExpiresAt.IsZero() && !now().Before(r.ExpiresAt)
+}
+
+func clusterRules(rules ClusterRules) ResolvedRules {
+	return ResolvedRules{
+		Tier:                     rules.Tier,
+		RequireConfirmation:      rules.RequireConfirmation,
+		BlockedActions:           rules.BlockedActions.Active(),
+		AllowedActions:           rules.AllowedActions,
+		Default:                  rules.Default,
+		Banner:                   rules.Banner,
+		RequireExplicitContext:   rules.RequireExplicitContext,
+		RequireExplicitNamespace: rules.RequireExplicitNamespace,
+		BlockUnqualifiedDeletes:  rules.BlockUnqualifiedDeletes,
+		Messages:                 rules.Messages,
+		ApprovalCommand:          rules.ApprovalCommand,
+		ConfirmationPhrase:       rules.ConfirmationPhrase,
+		AddFlags:                 rules.AddFlags,
+		RequireTicket:            rules.RequireTicket,
+		RequireOnCall:            rules.RequireOnCall,
+		RequireMFA:               rules.RequireMFA,
+		HealthCheck:              rules.HealthCheck,
+		CheckQuota:               rules.CheckQuota,
+		MaxAffected:              rules.MaxAffected,
+		OverMaxAffected:          rules.OverMaxAffected,
+		RetypeChildren:           rules.RetypeChildren,
+		ScaleUpFactor:            rules.ScaleUpFactor,
+		ConfirmSeverity:          rules.ConfirmSeverity,
+		BlockSeverity:            rules.BlockSeverity,
+		Timeouts:                 rules.Timeouts,
+		WaitForDeletes:           rules.WaitForDeletes,
+		WatchRollouts:            rules.WatchRollouts,
+		Unreachable:              rules.Unreachable,
+		ManifestHosts:            rules.ManifestHosts,
+		AllowedNamespaces:        rules.AllowedNamespaces,
+		AllowedRegistries:        rules.AllowedRegistries,
+		RequireDigests:           rules.RequireDigests,
+		UntrustedImages:          rules.UntrustedImages,
+	}
+}
+
+func (c *Config) tierRules(name string) ResolvedRules {
+	tier := c.ResolveTier(name)
+	return ResolvedRules{
+		Tier:                     name,
+		RequireConfirmation:      tier.RequireConfirmation,
+		BlockedActions:           tier.BlockedActions.Active(),
+		AllowedActions:           tier.AllowedActions,
+		Default:                  tier.Default,
+		Banner:                   tier.Banner,
+		RequireExplicitContext:   tier.RequireExplicitContext,
+		RequireExplicitNamespace: tier.RequireExplicitNamespace,
+		BlockUnqualifiedDeletes:  tier.BlockUnqualifiedDeletes,
+		Messages:                 tier.Messages,
+		ApprovalCommand:          tier.ApprovalCommand,
+		ConfirmationPhrase:       tier.ConfirmationPhrase,
+		AddFlags:                 tier.AddFlags,
+		RequireTicket:            tier.RequireTicket,
+		RequireOnCall:            tier.RequireOnCall,
+		RequireMFA:               tier.RequireMFA,
+		HealthCheck:              tier.HealthCheck,
+		CheckQuota:               tier.CheckQuota,
+		MaxAffected:              tier.MaxAffected,
+		OverMaxAffected:          tier.OverMaxAffected,
+		RetypeChildren:           tier.RetypeChildren,
+		ScaleUpFactor:            tier.ScaleUpFactor,
+		ConfirmSeverity:          tier.ConfirmSeverity,
+		BlockSeverity:            tier.BlockSeverity,
+		Timeouts:                 tier.Timeouts,
+		WaitForDeletes:           tier.WaitForDeletes,
+		WatchRollouts:            tier.WatchRollouts,
+		Unreachable:              tier.Unreachable,
+		ManifestHosts:            tier.ManifestHosts,
+		AllowedNamespaces:        tier.AllowedNamespaces,
+		AllowedRegistries:        tier.AllowedRegistries,
+		RequireDigests:           tier.RequireDigests,
+		UntrustedImages:          tier.UntrustedImages,
+	}
+}
+
+// IsProduction reports whether a resolved tier should be treated as
+// production: the production tier itself or any tier inheriting from it
+func (c *Config) IsProduction(tier string) bool {
+	for _, name := range c.tierChain(tier) {
+		if name == "production" {
+			return true
+		}
 	}
+	return false
 }
 
-// matchGlob checks if a string matches a glob pattern
+// matchGlob checks if a string matches a glob pattern. Patterns prefixed
+// with "re:" are RE2 regular expressions instead.
 func matchGlob(pattern, str string) bool {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		re, err := compileRegex(expr)
+		return err == nil && re.MatchString(str)
+	}
+
 	// Try to compile and match with gobwas/glob for advanced patterns
 	g, err := glob.Compile(pattern)
 	if err != nil {
@@ -165,4 +953,3 @@ func matchGlob(pattern, str string) bool {
 	}
 	return g.Match(str)
 }
-
@@ -1,27 +1,32 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestGetClusterRules_ExactMatch(t *testing.T) {
 	cfg := &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      Actions(),
 		},
 		Clusters: map[string]ClusterRules{
 			"prod-cluster": {
 				Tier:                "production",
 				RequireConfirmation: []string{"delete", "drain"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 			"staging-cluster": {
 				Tier:                "staging",
 				RequireConfirmation: []string{"delete"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 		},
 		Tiers: map[string]TierConfig{},
@@ -71,18 +76,18 @@ func TestGetClusterRules_PatternMatch(t *testing.T) {
 	cfg := &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      Actions(),
 		},
 		Clusters: map[string]ClusterRules{
 			"prod-*": {
 				Tier:                "production",
 				RequireConfirmation: []string{"delete", "drain"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 			"*-staging": {
 				Tier:                "staging",
 				RequireConfirmation: []string{"delete"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 		},
 		Tiers: map[string]TierConfig{},
@@ -120,28 +125,53 @@ func TestGetClusterRules_PatternMatch(t *testing.T) {
 	}
 }
 
+func TestClusterEntry(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"prod-*":      {Tier: "production"},
+			"prod-eu":     {Tier: "production"},
+			"old-cluster": {Tier: "staging", ExpiresAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	tests := []struct {
+		context string
+		want    string
+		ok      bool
+	}{
+		{"prod-eu", "prod-eu", true},
+		{"prod-us", "prod-*", true},
+		{"old-cluster", "", false},
+		{"dev", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := cfg.ClusterEntry(tt.context); got != tt.want || ok != tt.ok {
+			t.Errorf("ClusterEntry(%q) = %q, %v, want %q, %v", tt.context, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
 func TestGetClusterRules_TierPatterns(t *testing.T) {
 	cfg := &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      Actions(),
 		},
 		Clusters: map[string]ClusterRules{},
 		Tiers: map[string]TierConfig{
 			"production": {
 				Patterns:            []string{"*-prod", "*-production", "prod-*"},
 				RequireConfirmation: []string{"delete", "drain"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 			"staging": {
 				Patterns:            []string{"*-staging", "*-stg", "staging-*"},
 				RequireConfirmation: []string{"delete"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 			"development": {
 				Patterns:            []string{"*-dev", "dev-*", "minikube", "docker-desktop", "kind-*"},
 				RequireConfirmation: []string{},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 		},
 	}
@@ -215,21 +245,21 @@ func TestGetClusterRules_Priority(t *testing.T) {
 	cfg := &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      Actions(),
 		},
 		Clusters: map[string]ClusterRules{
 			// Exact match should take priority
 			"special-prod": {
 				Tier:                "special",
 				RequireConfirmation: []string{"scale"},
-				BlockedActions:      []string{"delete"},
+				BlockedActions:      Actions("delete"),
 			},
 		},
 		Tiers: map[string]TierConfig{
 			"production": {
 				Patterns:            []string{"*-prod"},
 				RequireConfirmation: []string{"delete", "drain"},
-				BlockedActions:      []string{},
+				BlockedActions:      Actions(),
 			},
 		},
 	}
@@ -254,7 +284,7 @@ func TestGetClusterRules_DefaultRequireConfirmation(t *testing.T) {
 	cfg := &Config{
 		Defaults: DefaultsConfig{
 			RequireConfirmation: true, // Global confirmation enabled
-			BlockedActions:      []string{},
+			BlockedActions:      Actions(),
 		},
 		Clusters: map[string]ClusterRules{},
 		Tiers:    map[string]TierConfig{},
@@ -353,6 +383,13 @@ func TestMatchGlob(t *testing.T) {
 		// Case sensitivity
 		{"*-PROD", "cluster-prod", false}, // glob is case-sensitive
 		{"*-prod", "cluster-PROD", false},
+
+		// Regular expressions
+		{"re:^arn:aws:eks:.*:cluster/prod-.*$", "arn:aws:eks:us-east-1:123:cluster/prod-api", true},
+		{"re:^arn:aws:eks:.*:cluster/prod-.*$", "arn:aws:eks:us-east-1:123:cluster/dev-api", false},
+		{"re:^gke_[^_]+_europe-", "gke_proj_europe-west1_main", true},
+		{"re:(?i)-PROD$", "cluster-prod", true},
+		{"re:[unclosed", "[unclosed", false}, // invalid regex never matches
 	}
 
 	for _, tt := range tests {
@@ -408,7 +445,7 @@ tiers:
 		t.Error("Expected Defaults.RequireConfirmation to be true")
 	}
 
-	if len(cfg.Defaults.BlockedActions) != 1 || cfg.Defaults.BlockedActions[0] != "exec" {
+	if len(cfg.Defaults.BlockedActions) != 1 || cfg.Defaults.BlockedActions[0].Action != "exec" {
 		t.Errorf("Expected Defaults.BlockedActions = [exec], got %v", cfg.Defaults.BlockedActions)
 	}
 
@@ -515,3 +552,504 @@ func TestGetClusterRules_RealWorldContextNames(t *testing.T) {
 	}
 }
 
+func TestDataDir(t *testing.T) {
+	originalXDG := os.Getenv("XDG_DATA_HOME")
+	defer os.Setenv("XDG_DATA_HOME", originalXDG)
+
+	os.Setenv("XDG_DATA_HOME", "/custom/data")
+	if dir := DataDir(); dir != "/custom/data/kubectl-enhanced" {
+		t.Errorf("DataDir() with XDG_DATA_HOME = %q, want %q", dir, "/custom/data/kubectl-enhanced")
+	}
+
+	os.Unsetenv("XDG_DATA_HOME")
+	home, _ := os.UserHomeDir()
+	expected := filepath.Join(home, ".local", "share", "kubectl-enhanced")
+	if dir := DataDir(); dir != expected {
+		t.Errorf("DataDir() without XDG_DATA_HOME = %q, want %q", dir, expected)
+	}
+}
+
+func TestGetClusterRules_Banner(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"quiet-prod": {Tier: "production"},
+			"loud-dev":   {Tier: "development", Banner: true},
+		},
+		Tiers: map[string]TierConfig{
+			"production": {Patterns: []string{"*-prod"}, Banner: true},
+		},
+	}
+
+	tests := []struct {
+		context  string
+		expected bool
+	}{
+		{"app-prod", true},    // tier banner
+		{"quiet-prod", false}, // explicit cluster entry overrides tier
+		{"loud-dev", true},    // explicit cluster banner
+		{"unknown", false},    // defaults never show a banner
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			if got := cfg.GetClusterRules(tt.context).Banner; got != tt.expected {
+				t.Errorf("GetClusterRules(%q).Banner = %v, want %v", tt.context, got, tt.expected)
+			}
+		})
+	}
+
+	if !Default().Tiers["production"].Banner {
+		t.Error("Default production tier should enable the banner")
+	}
+}
+
+func TestGetClusterRules_TierInheritance(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"delete", "drain", "scale"},
+				BlockedActions:      Actions(),
+				Banner:              true,
+			},
+			"prod-regulated": {
+				Inherits:       "production",
+				Patterns:       []string{"*-pci"},
+				BlockedActions: Actions("exec"),
+			},
+			"prod-sandbox": {
+				Inherits:           "production",
+				Patterns:           []string{"*-sandbox"},
+				RemoveConfirmation: []string{"scale"},
+			},
+			"prod-sandbox-eu": {
+				Inherits:            "prod-sandbox",
+				Patterns:            []string{"eu-*"},
+				RequireConfirmation: []string{"exec"},
+			},
+		},
+	}
+
+	tests := []struct {
+		context         string
+		expectedConfirm []string
+		expectedBlocked []string
+	}{
+		{"app-pci", []string{"delete", "drain", "scale"}, []string{"exec"}},
+		{"app-sandbox", []string{"delete", "drain"}, []string{}},
+		{"eu-app", []string{"delete", "drain", "exec"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			rules := cfg.GetClusterRules(tt.context)
+			if !reflect.DeepEqual(rules.RequireConfirmation, tt.expectedConfirm) {
+				t.Errorf("RequireConfirmation = %v, want %v", rules.RequireConfirmation, tt.expectedConfirm)
+			}
+			if !reflect.DeepEqual(rules.BlockedActions, tt.expectedBlocked) {
+				t.Errorf("BlockedActions = %v, want %v", rules.BlockedActions, tt.expectedBlocked)
+			}
+			if !rules.Banner {
+				t.Error("Banner should be inherited from production")
+			}
+			if !cfg.IsProduction(rules.Tier) {
+				t.Errorf("IsProduction(%q) = false, want true", rules.Tier)
+			}
+		})
+	}
+
+	if cfg.IsProduction("staging") {
+		t.Error("IsProduction(staging) = true, want false")
+	}
+}
+
+func TestLoadFromPath_InheritanceErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown parent": "tiers:\n  a:\n    inherits: missing\n",
+		"cycle":          "tiers:\n  a:\n    inherits: b\n  b:\n    inherits: a\n",
+		"self":           "tiers:\n  a:\n    inherits: a\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			if _, err := LoadFromPath(configPath); err == nil {
+				t.Error("Expected error for broken tier inheritance")
+			}
+		})
+	}
+}
+
+func TestGetClusterRules_PatternPrecedence(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"*-prod":        {Tier: "generic"},
+			"payments-*":    {Tier: "payments"},
+			"payments-prod": {Tier: "exact"},
+			"eu-*":          {Tier: "eu", Priority: 10},
+		},
+		Tiers: map[string]TierConfig{
+			"production": {Patterns: []string{"*-prod", "prod-*", "!prod-sandbox-*"}},
+			"sandbox":    {Patterns: []string{"*sandbox*"}},
+			"critical":   {Patterns: []string{"*"}, Priority: 5},
+		},
+	}
+
+	tests := []struct {
+		context  string
+		expected string
+	}{
+		{"payments-prod", "exact"},            // exact match beats every glob
+		{"payments-staging-prod", "payments"}, // more literal characters than *-prod
+		{"eu-app-prod", "eu"},                 // explicit priority beats specificity
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			if got := cfg.GetClusterRules(tt.context).Tier; got != tt.expected {
+				t.Errorf("GetClusterRules(%q).Tier = %q, want %q", tt.context, got, tt.expected)
+			}
+		})
+	}
+
+	cfg.Clusters = nil
+	tierTests := []struct {
+		context  string
+		expected string
+	}{
+		{"prod-api", "critical"}, // priority 5 beats production
+		{"prod-sandbox-1", "critical"},
+	}
+	for _, tt := range tierTests {
+		if got := cfg.GetClusterRules(tt.context).Tier; got != tt.expected {
+			t.Errorf("GetClusterRules(%q).Tier = %q, want %q", tt.context, got, tt.expected)
+		}
+	}
+
+	delete(cfg.Tiers, "critical")
+	if got := cfg.GetClusterRules("prod-api").Tier; got != "production" {
+		t.Errorf("GetClusterRules(prod-api).Tier = %q, want production", got)
+	}
+	// Negated pattern excludes the production tier; sandbox matches instead
+	if got := cfg.GetClusterRules("prod-sandbox-1").Tier; got != "sandbox" {
+		t.Errorf("GetClusterRules(prod-sandbox-1).Tier = %q, want sandbox", got)
+	}
+}
+
+func TestGetClusterRules_Deterministic(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"alpha": {Patterns: []string{"app-*"}},
+			"beta":  {Patterns: []string{"*-one"}},
+			"gamma": {Patterns: []string{"app-*"}},
+		},
+	}
+	// alpha and gamma tie on priority and specificity; name breaks the tie
+	for i := 0; i < 50; i++ {
+		if got := cfg.GetClusterRules("app-one").Tier; got != "alpha" {
+			t.Fatalf("GetClusterRules(app-one).Tier = %q on iteration %d, want alpha", got, i)
+		}
+	}
+}
+
+func TestLoadFromPath_InvalidRegex(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "tiers:\n  production:\n    patterns: [\"re:prod-(\"]\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := LoadFromPath(configPath); err == nil {
+		t.Error("Expected error for invalid regex pattern")
+	}
+}
+
+func TestGetClusterRules_ServerPatterns(t *testing.T) {
+	servers := map[string]string{
+		"renamed-dev": "https://api.prod.internal:6443",
+		"real-dev":    "https://api.dev.internal:6443",
+	}
+	lookups := 0
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":  {Servers: []string{"https://*.prod.internal:6443"}},
+			"development": {Patterns: []string{"*-dev"}},
+		},
+		ServerLookup: func(context string) (string, error) {
+			lookups++
+			if server, ok := servers[context]; ok {
+				return server, nil
+			}
+			return "", os.ErrNotExist
+		},
+	}
+
+	tests := []struct {
+		context  string
+		expected string
+	}{
+		{"renamed-dev", "production"}, // server match beats the context name
+		{"real-dev", "development"},
+		{"unknown-dev", "development"}, // lookup failure falls back to names
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			if got := cfg.GetClusterRules(tt.context).Tier; got != tt.expected {
+				t.Errorf("GetClusterRules(%q).Tier = %q, want %q", tt.context, got, tt.expected)
+			}
+		})
+	}
+
+	cfg.GetClusterRules("renamed-dev")
+	if lookups != 3 {
+		t.Errorf("ServerLookup called %d times, want 3 (results cached per context)", lookups)
+	}
+
+	// Without server patterns the lookup is never needed
+	lookups = 0
+	plain := &Config{Tiers: map[string]TierConfig{"development": {Patterns: []string{"*-dev"}}}, ServerLookup: cfg.ServerLookup}
+	plain.GetClusterRules("real-dev")
+	if lookups != 0 {
+		t.Errorf("ServerLookup called %d times without server patterns", lookups)
+	}
+}
+
+// TestGetClusterRules_Concurrent runs lookups from several goroutines, as
+// kctl serve and kctl proxy do; run it with -race
+func TestGetClusterRules_Concurrent(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production": {Servers: []string{"https://*.prod.internal:6443"}},
+		},
+		ServerLookup: func(context string) (string, error) { return "https://api.prod.internal:6443", nil },
+		TierLookup:   func(context string) (string, error) { return "", nil },
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			context := fmt.Sprintf("app-%d", i%5)
+			if got := cfg.GetClusterRules(context).Tier; got != "production" {
+				t.Errorf("GetClusterRules(%q).Tier = %q, want production", context, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestGetClusterRules_DeclaredTier(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"renamed-dev": {Tier: "development"},
+		},
+		Tiers: map[string]TierConfig{
+			"production":  {Patterns: []string{"*-prod"}, BlockedActions: Actions("delete")},
+			"development": {Patterns: []string{"*-dev"}},
+		},
+		TierLookup: func(context string) (string, error) {
+			switch context {
+			case "renamed-dev":
+				return "production", nil
+			case "odd-dev":
+				return "no-such-tier", nil
+			}
+			return "", nil
+		},
+	}
+
+	tests := []struct {
+		context  string
+		expected string
+	}{
+		{"renamed-dev", "production"}, // declaration beats explicit name entries
+		{"odd-dev", "development"},    // unknown declared tiers are ignored
+		{"plain-dev", "development"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			if got := cfg.GetClusterRules(tt.context).Tier; got != tt.expected {
+				t.Errorf("GetClusterRules(%q).Tier = %q, want %q", tt.context, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadFromPath_InvalidDefault(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("tiers:\n  locked:\n    default: block\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	if _, err := LoadFromPath(configPath); err == nil {
+		t.Error("Expected error for unknown default value")
+	}
+}
+
+func TestGetClusterRules_Messages(t *testing.T) {
+	cfg := &Config{
+		Defaults: DefaultsConfig{
+			Messages: map[string]ActionMessage{
+				"*":      {DocsURL: "https://wiki.example.com/kctl"},
+				"delete": {Message: "global delete message"},
+			},
+		},
+		Tiers: map[string]TierConfig{
+			"production": {
+				Patterns: []string{"*-prod"},
+				Messages: map[string]ActionMessage{
+					"delete": {Message: "Deletes on prod require a change ticket", DocsURL: "https://wiki.example.com/change"},
+					"drain":  {Message: "Drains need a maintenance window"},
+				},
+			},
+			"prod-eu": {
+				Inherits: "production",
+				Patterns: []string{"eu-*"},
+				Messages: map[string]ActionMessage{"drain": {Message: "EU drains need DPO sign-off"}},
+			},
+		},
+	}
+
+	rules := cfg.GetClusterRules("app-prod")
+	if got := rules.MessageFor("delete", "delete"); got.Message != "Deletes on prod require a change ticket" {
+		t.Errorf("delete message = %+v, want tier message", got)
+	}
+	// cordon is decided by the drain rule
+	if got := rules.MessageFor("cordon", "drain"); got.Message != "Drains need a maintenance window" {
+		t.Errorf("cordon message = %+v, want drain message", got)
+	}
+	if got := rules.MessageFor("exec", "*"); got.DocsURL != "https://wiki.example.com/kctl" {
+		t.Errorf("exec message = %+v, want global fallback", got)
+	}
+
+	eu := cfg.GetClusterRules("eu-app")
+	if got := eu.MessageFor("drain", "drain"); got.Message != "EU drains need DPO sign-off" {
+		t.Errorf("inherited drain message = %+v, want override", got)
+	}
+	if got := eu.MessageFor("delete", "delete"); got.DocsURL != "https://wiki.example.com/change" {
+		t.Errorf("inherited delete message = %+v, want parent message", got)
+	}
+
+	if got := cfg.GetClusterRules("dev").MessageFor("delete", "delete"); got.Message != "global delete message" {
+		t.Errorf("default tier message = %+v, want global message", got)
+	}
+}
+
+func TestApprovalCommand(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"payments-prod": {Tier: "production", ApprovalCommand: "payments-approve"},
+		},
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, ApprovalCommand: "approve"},
+			"prod-regulated": {Inherits: "production", Patterns: []string{"*-reg"}},
+		},
+	}
+
+	for context, want := range map[string]string{
+		"app-prod":      "approve",
+		"app-reg":       "approve",
+		"payments-prod": "payments-approve",
+		"dev":           "",
+	} {
+		if got := cfg.GetClusterRules(context).ApprovalCommand; got != want {
+			t.Errorf("ApprovalCommand for %s = %q, want %q", context, got, want)
+		}
+	}
+}
+
+func TestRequireTicket(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, RequireTicket: true},
+			"prod-regulated": {Inherits: "production", Patterns: []string{"*-reg"}},
+		},
+		Tickets: TicketConfig{Pattern: "^CHG-("},
+	}
+	if !cfg.GetClusterRules("app-reg").RequireTicket {
+		t.Error("RequireTicket not inherited from production")
+	}
+	if cfg.GetClusterRules("app-dev").RequireTicket {
+		t.Error("RequireTicket set for an unmatched context")
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tickets") {
+		t.Errorf("Validate = %v, want invalid tickets pattern", err)
+	}
+}
+
+func TestConfirmationPhrase(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, ConfirmationPhrase: "yes-production"},
+			"prod-regulated": {Inherits: "production", Patterns: []string{"*-reg"}},
+			"prod-payments":  {Inherits: "production", Patterns: []string{"pay-*"}, ConfirmationPhrase: "yes-payments"},
+		},
+	}
+	for context, want := range map[string]string{
+		"app-prod": "yes-production",
+		"app-reg":  "yes-production",
+		"pay-eu":   "yes-payments",
+		"dev":      "",
+	} {
+		if got := cfg.GetClusterRules(context).ConfirmationPhrase; got != want {
+			t.Errorf("ConfirmationPhrase for %s = %q, want %q", context, got, want)
+		}
+	}
+}
+
+func TestMaxAffected(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, MaxAffected: 10},
+			"prod-regulated": {Inherits: "production", Patterns: []string{"*-reg"}, OverMaxAffected: OverMaxBreakGlass},
+		},
+	}
+	rules := cfg.GetClusterRules("app-reg")
+	if rules.MaxAffected != 10 || rules.OverMaxAffected != OverMaxBreakGlass {
+		t.Errorf("app-reg: MaxAffected = %d, OverMaxAffected = %q; want 10, break_glass", rules.MaxAffected, rules.OverMaxAffected)
+	}
+	if got := cfg.GetClusterRules("dev").MaxAffected; got != 0 {
+		t.Errorf("dev: MaxAffected = %d, want 0", got)
+	}
+
+	cfg.Tiers["production"] = TierConfig{Patterns: []string{"*-prod"}, OverMaxAffected: "ask"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "over_max_affected") {
+		t.Errorf("Validate() error = %v, want an over_max_affected error", err)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, Unreachable: UnreachableBlock},
+			"prod-regulated": {Inherits: "production", Patterns: []string{"*-reg"}},
+		},
+	}
+	if got := cfg.GetClusterRules("app-reg").Unreachable; got != UnreachableBlock {
+		t.Errorf("app-reg: Unreachable = %q, want block (inherited)", got)
+	}
+	if got := cfg.GetClusterRules("dev").Unreachable; got != "" {
+		t.Errorf("dev: Unreachable = %q, want unset", got)
+	}
+
+	cfg.Tiers["production"] = TierConfig{Patterns: []string{"*-prod"}, Unreachable: "fail"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Validate() error = %v, want an unreachable error", err)
+	}
+}
+
+func TestGetTierRules(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production":     {Patterns: []string{"*-prod"}, BlockedActions: Actions("delete")},
+			"prod-regulated": {Inherits: "production", BlockedActions: Actions("exec")},
+		},
+	}
+	rules, ok := cfg.GetTierRules("prod-regulated")
+	if !ok || rules.Tier != "prod-regulated" || !reflect.DeepEqual(rules.BlockedActions, []string{"delete", "exec"}) {
+		t.Errorf("GetTierRules(prod-regulated) = %+v, %v", rules, ok)
+	}
+	if _, ok := cfg.GetTierRules("staging"); ok {
+		t.Error("GetTierRules(staging) should report an unknown tier")
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import "fmt"
+
+// Values for defaults.delete_all_namespaces: how a delete combining --all
+// with --all-namespaces, which empties every namespace, is handled on every
+// tier
+const (
+	// DeleteAllBreakGlass, the default, needs a confirmation --yes can't
+	// skip and the tier's approval_command; without one it is blocked
+	DeleteAllBreakGlass = "break_glass"
+	DeleteAllBlock      = "block"
+	// DeleteAllRules leaves it to the tier's rules
+	DeleteAllRules = "rules"
+)
+
+// DeleteAllNamespacesMode returns how deletes of everything in every
+// namespace are handled
+func (d DefaultsConfig) DeleteAllNamespacesMode() string {
+	if d.DeleteAllNamespaces == "" {
+		return DeleteAllBreakGlass
+	}
+	return d.DeleteAllNamespaces
+}
+
+// mergeDeleteAll returns the stricter of two delete_all_namespaces settings;
+// a layer that doesn't set it leaves the other's
+func mergeDeleteAll(base, local string) string {
+	rank := map[string]int{DeleteAllRules: 0, DeleteAllBreakGlass: 1, DeleteAllBlock: 2}
+	if base == "" || local != "" && rank[local] > rank[base] {
+		return local
+	}
+	return base
+}
+
+// checkDeleteAll reports an unknown delete_all_namespaces value
+func (c *Config) checkDeleteAll() error {
+	switch c.Defaults.DeleteAllNamespaces {
+	case "", DeleteAllBreakGlass, DeleteAllBlock, DeleteAllRules:
+		return nil
+	}
+	return fmt.Errorf("defaults.delete_all_namespaces must be break_glass, block or rules, got %q", c.Defaults.DeleteAllNamespaces)
+}
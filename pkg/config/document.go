@@ -0,0 +1,317 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file held as a YAML node tree, so individual values
+// can be read and changed without losing comments or key ordering
+type Document struct {
+	root yaml.Node
+}
+
+// NewDocument returns an empty document with a top-level mapping
+func NewDocument() *Document {
+	d := &Document{}
+	d.root.Kind = yaml.DocumentNode
+	d.root.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	return d
+}
+
+// ParseDocument parses YAML data into a document. Empty input yields an
+// empty document.
+func ParseDocument(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := yaml.Unmarshal(data, &d.root); err != nil {
+		return nil, err
+	}
+	if d.root.Kind == 0 || len(d.root.Content) == 0 {
+		return NewDocument(), nil
+	}
+	if d.root.Kind != yaml.DocumentNode || d.root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config root must be a mapping")
+	}
+	return d, nil
+}
+
+// LoadDocument reads the document at path
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDocument(data)
+}
+
+// Config decodes the document into a Config
+func (d *Document) Config() (*Config, error) {
+	var cfg Config
+	if err := d.root.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SetHeader sets the comment block at the top of the document
+func (d *Document) SetHeader(comment string) {
+	d.root.HeadComment = comment
+}
+
+// Get returns the node at path, or nil if it doesn't exist.
+// Path segments are mapping keys or, for sequences, numeric indexes.
+func (d *Document) Get(path []string) *yaml.Node {
+	node := d.root.Content[0]
+	for _, key := range path {
+		node = child(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Set stores value at path, creating intermediate mappings as needed.
+// Comments attached to an existing key or value are kept.
+func (d *Document) Set(path []string, value interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty path")
+	}
+	parent, err := d.ensureParent(path)
+	if err != nil {
+		return err
+	}
+
+	encoded := &yaml.Node{}
+	if err := encoded.Encode(value); err != nil {
+		return err
+	}
+
+	key := path[len(path)-1]
+	existing := child(parent, key)
+	if existing == nil {
+		if parent.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: index out of range", strings.Join(path, "."))
+		}
+		parent.Content = append(parent.Content, scalarNode(key), encoded)
+		parent.Style = 0 // an empty "{}" becomes a block mapping
+		return nil
+	}
+
+	existing.Kind = encoded.Kind
+	existing.Tag = encoded.Tag
+	existing.Value = encoded.Value
+	existing.Content = encoded.Content
+	existing.Style = encoded.Style
+	return nil
+}
+
+// Delete removes the key or sequence item at path. Returns false if it
+// didn't exist.
+func (d *Document) Delete(path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	parent := d.Get(path[:len(path)-1])
+	if parent == nil {
+		return false
+	}
+	key := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == key {
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+				return true
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(parent.Content) {
+			parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetComment sets the head comment of the key at path
+func (d *Document) SetComment(path []string, comment string) {
+	if len(path) == 0 {
+		return
+	}
+	parent := d.Get(path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			parent.Content[i].HeadComment = comment
+			return
+		}
+	}
+}
+
+// AppendUnique appends string values missing from the sequence at path,
+// creating the sequence if needed, and returns the values it added
+func (d *Document) AppendUnique(path []string, values []string) ([]string, error) {
+	seq := d.Get(path)
+	if seq == nil {
+		if err := d.Set(path, []string{}); err != nil {
+			return nil, err
+		}
+		seq = d.Get(path)
+	}
+	if seq.Kind == yaml.ScalarNode && seq.Tag == "!!null" {
+		seq.Kind, seq.Tag, seq.Value = yaml.SequenceNode, "!!seq", ""
+	}
+	if seq.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+	}
+
+	present := make(map[string]bool, len(seq.Content))
+	for _, item := range seq.Content {
+		present[item.Value] = true
+	}
+	var added []string
+	for _, v := range values {
+		if present[v] {
+			continue
+		}
+		present[v] = true
+		seq.Content = append(seq.Content, scalarNode(v))
+		added = append(added, v)
+	}
+	// An empty flow sequence ("[]") reads badly once it has items
+	if len(added) > 0 && len(seq.Content) == len(added) {
+		seq.Style = 0
+	}
+	return added, nil
+}
+
+// Bytes serializes the document with two-space indentation and a blank
+// line between top-level sections
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return separateSections(buf.Bytes()), nil
+}
+
+// Save writes the document to path atomically, keeping the existing file
+// mode and creating the directory if needed
+func (d *Document) Save(path string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, ".config-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ensureParent returns the node that should contain the last path element,
+// creating missing mappings along the way
+func (d *Document) ensureParent(path []string) (*yaml.Node, error) {
+	node := d.root.Content[0]
+	for i, key := range path[:len(path)-1] {
+		next := child(node, key)
+		if next == nil {
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s is not a mapping", strings.Join(path[:i], "."))
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, scalarNode(key), next)
+			node.Style = 0
+		} else if next.Kind == yaml.ScalarNode && next.Tag == "!!null" {
+			// "clusters:" followed only by comments decodes as null
+			next.Kind, next.Tag, next.Value = yaml.MappingNode, "!!map", ""
+		}
+		node = next
+	}
+	return node, nil
+}
+
+// child returns the value under key in a mapping or the item at a numeric
+// index in a sequence
+func child(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// separateSections inserts a blank line before each top-level key (and its
+// comment block), which yaml.v3 drops when re-encoding
+func separateSections(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines)+8)
+	seenKey := false
+	for i, line := range lines {
+		topLevel := line != "" && line[0] != ' ' && line[0] != '#' && line[0] != '-'
+		if topLevel {
+			// Walk back over the key's head comment block
+			start := len(out)
+			for start > 0 && strings.HasPrefix(out[start-1], "#") {
+				start--
+			}
+			if seenKey && start > 0 && out[start-1] != "" {
+				out = append(out[:start], append([]string{""}, out[start:]...)...)
+			}
+			seenKey = true
+		}
+		if i == len(lines)-1 && line == "" {
+			break
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
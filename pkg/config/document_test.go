@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const documentBase = `# top comment
+defaults:
+  blocked_actions: [] # none yet
+
+clusters:
+  # payments cluster
+  payments-prod:
+    tier: production
+`
+
+func TestDocument_SetPreservesComments(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentBase))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	if err := doc.Set([]string{"clusters", "payments-prod", "tier"}, "staging"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := doc.Set([]string{"tiers", "production", "banner"}, true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{"# top comment", "# none yet", "# payments cluster", "tier: staging", "banner: true", "\n\ntiers:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q:\n%s", want, out)
+		}
+	}
+
+	cfg, err := doc.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if cfg.Clusters["payments-prod"].Tier != "staging" || !cfg.Tiers["production"].Banner {
+		t.Errorf("Decoded config does not reflect changes: %+v", cfg)
+	}
+}
+
+func TestDocument_GetDelete(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentBase))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	if node := doc.Get([]string{"clusters", "payments-prod", "tier"}); node == nil || node.Value != "production" {
+		t.Errorf("Get returned %v, want production", node)
+	}
+	if doc.Get([]string{"clusters", "missing"}) != nil {
+		t.Error("Get of missing key should return nil")
+	}
+	if !doc.Delete([]string{"clusters", "payments-prod"}) {
+		t.Error("Delete of existing key should return true")
+	}
+	if doc.Delete([]string{"clusters", "payments-prod"}) {
+		t.Error("Second Delete should return false")
+	}
+}
+
+func TestDocument_AppendUnique(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentBase))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	added, err := doc.AppendUnique([]string{"defaults", "blocked_actions"}, []string{"delete", "delete", "drain"})
+	if err != nil {
+		t.Fatalf("AppendUnique failed: %v", err)
+	}
+	if len(added) != 2 {
+		t.Errorf("AppendUnique added %v, want [delete drain]", added)
+	}
+	added, _ = doc.AppendUnique([]string{"defaults", "blocked_actions"}, []string{"drain"})
+	if len(added) != 0 {
+		t.Errorf("AppendUnique re-added %v", added)
+	}
+
+	if _, err := doc.AppendUnique([]string{"clusters", "payments-prod", "tier"}, []string{"x"}); err == nil {
+		t.Error("AppendUnique on a scalar should fail")
+	}
+}
+
+func TestDocument_SaveQuotesGlobKeys(t *testing.T) {
+	doc := NewDocument()
+	if err := doc.Set([]string{"clusters", "*-prod"}, ClusterRules{Tier: "production"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	if err := doc.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	cfg, err := LoadFromPath(path)
+	if err != nil {
+		data, _ := os.ReadFile(path)
+		t.Fatalf("Saved config does not load: %v\n%s", err, data)
+	}
+	if cfg.Clusters["*-prod"].Tier != "production" {
+		t.Errorf("Glob cluster key lost: %+v", cfg.Clusters)
+	}
+}
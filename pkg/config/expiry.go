@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// now is replaced in tests
+var now = time.Now
+
+// ActionList is a list of action names. An entry can also be temporary:
+//
+//	blocked_actions:
+//	  - delete
+//	  - action: drain
+//	    expires_at: 2026-05-01T18:00:00Z
+//
+// Entries already expired are dropped when the config is loaded; the rest
+// are checked each time the rules are resolved (see Active), so they stop
+// applying on time in a long-running shell, server or proxy too.
+type ActionList []ActionEntry
+
+// ActionEntry is an entry of an ActionList
+type ActionEntry struct {
+	Action    string
+	ExpiresAt time.Time // Zero for a permanent entry
+}
+
+// Actions returns a list of permanent entries for names
+func Actions(names ...string) ActionList {
+	list := make(ActionList, len(names))
+	for i, name := range names {
+		list[i] = ActionEntry{Action: name}
+	}
+	return list
+}
+
+// Expired reports whether a temporary entry has run out
+func (e ActionEntry) Expired() bool {
+	return !e.ExpiresAt.IsZero() && !now().Before(e.ExpiresAt)
+}
+
+// Active returns the actions of the entries that haven't expired, each once
+func (l ActionList) Active() []string {
+	active := []string{}
+	for _, e := range l {
+		if !e.Expired() && !contains(active, e.Action) {
+			active = append(active, e.Action)
+		}
+	}
+	return active
+}
+
+// Names returns the actions of every entry, expired or not
+func (l ActionList) Names() []string {
+	names := make([]string, len(l))
+	for i, e := range l {
+		names[i] = e.Action
+	}
+	return names
+}
+
+// appendEntries adds the entries of values that list lacks
+func appendEntries(list, values ActionList) ActionList {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// removeEntries drops the entries for the actions in names
+func removeEntries(list ActionList, names []string) ActionList {
+	if len(names) == 0 {
+		return list
+	}
+	kept := ActionList{}
+	for _, e := range list {
+		if !contains(names, e.Action) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// MarshalYAML writes permanent entries as plain action names
+func (l ActionList) MarshalYAML() (any, error) {
+	items := make([]any, len(l))
+	for i, e := range l {
+		if e.ExpiresAt.IsZero() {
+			items[i] = e.Action
+			continue
+		}
+		items[i] = struct {
+			Action    string    `yaml:"action"`
+			ExpiresAt time.Time `yaml:"expires_at"`
+		}{e.Action, e.ExpiresAt}
+	}
+	return items, nil
+}
+
+// UnmarshalYAML accepts plain action names and {action, expires_at} entries
+func (l *ActionList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		*l = nil
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a list of actions", node.Line)
+	}
+
+	list := ActionList{}
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			list = append(list, ActionEntry{Action: item.Value})
+		case yaml.MappingNode:
+			var entry struct {
+				Action    string    `yaml:"action"`
+				ExpiresAt time.Time `yaml:"expires_at"`
+			}
+			if err := item.Decode(&entry); err != nil {
+				return err
+			}
+			if entry.Action == "" {
+				return fmt.Errorf("line %d: action is required", item.Line)
+			}
+			e := ActionEntry{Action: entry.Action, ExpiresAt: entry.ExpiresAt}
+			if e.Expired() {
+				continue
+			}
+			list = append(list, e)
+		default:
+			return fmt.Errorf("line %d: expected an action name", item.Line)
+		}
+	}
+	*l = list
+	return nil
+}
+
+// Expiration is a temporary entry found in a config document
+type Expiration struct {
+	Path      string
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the entry is no longer in effect
+func (e Expiration) Expired() bool {
+	return !now().Before(e.ExpiresAt)
+}
+
+// Expirations lists every entry in the document carrying expires_at,
+// in document order
+func (d *Document) Expirations() ([]Expiration, error) {
+	var found []Expiration
+	var walk func(node *yaml.Node, path []string) error
+	walk = func(node *yaml.Node, path []string) error {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Value == "expires_at" {
+					var t time.Time
+					if err := value.Decode(&t); err != nil {
+						return fmt.Errorf("%s: invalid expires_at %q (use RFC 3339, e.g. 2026-05-01T18:00:00Z)", strings.Join(path, "."), value.Value)
+					}
+					label := strings.Join(path, ".")
+					if action := child(node, "action"); action != nil {
+						label += " (" + action.Value + ")"
+					}
+					found = append(found, Expiration{Path: label, ExpiresAt: t})
+					continue
+				}
+				if err := walk(value, append(path, key.Value)); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				if err := walk(item, append(path, fmt.Sprint(i))); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(d.root.Content[0], nil); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
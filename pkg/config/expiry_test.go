@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func withNow(t *testing.T, at time.Time) {
+	t.Helper()
+	previous := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = previous })
+}
+
+const expiryConfig = `clusters:
+  incident-prod:
+    tier: production
+    blocked_actions: [delete]
+    expires_at: 2026-03-01T00:00:00Z
+tiers:
+  production:
+    patterns: ["*-prod"]
+    blocked_actions:
+      - exec
+      - action: delete
+        expires_at: 2026-03-01T00:00:00Z
+      - action: drain
+        expires_at: 2026-01-01T00:00:00Z
+`
+
+func TestExpiry(t *testing.T) {
+	withNow(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(expiryConfig), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	if got := cfg.Tiers["production"].BlockedActions.Names(); !reflect.DeepEqual(got, []string{"exec", "delete"}) {
+		t.Errorf("BlockedActions = %v, want [exec delete] (drain expired)", got)
+	}
+	if got := cfg.GetClusterRules("incident-prod").BlockedActions; !reflect.DeepEqual(got, []string{"delete"}) {
+		t.Errorf("incident-prod BlockedActions = %v, want the temporary cluster entry", got)
+	}
+
+	withNow(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	if got := cfg.GetClusterRules("incident-prod").Tier; got != "production" {
+		t.Errorf("incident-prod Tier = %q, want production from the tier pattern", got)
+	}
+	if got := cfg.GetClusterRules("incident-prod").BlockedActions; !reflect.DeepEqual(got, []string{"exec"}) {
+		t.Errorf("BlockedActions = %v, want [exec] (cluster entry and delete expired since loading)", got)
+	}
+}
+
+func TestActionList_Active(t *testing.T) {
+	withNow(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	list := ActionList{
+		{Action: "exec"},
+		{Action: "delete", ExpiresAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Action: "exec", ExpiresAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if got := list.Active(); !reflect.DeepEqual(got, []string{"exec", "delete"}) {
+		t.Errorf("Active = %v, want [exec delete]", got)
+	}
+	withNow(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if got := list.Active(); !reflect.DeepEqual(got, []string{"exec"}) {
+		t.Errorf("Active at expiry = %v, want [exec]", got)
+	}
+
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back ActionList
+	withNow(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err := yaml.Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back, list) {
+		t.Errorf("round trip = %+v, %v; want %+v\n%s", back, err, list, data)
+	}
+}
+
+func TestDocument_Expirations(t *testing.T) {
+	withNow(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	doc, err := ParseDocument([]byte(expiryConfig))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	expirations, err := doc.Expirations()
+	if err != nil {
+		t.Fatalf("Expirations failed: %v", err)
+	}
+
+	var expired []string
+	for _, e := range expirations {
+		if e.Expired() {
+			expired = append(expired, e.Path)
+		}
+	}
+	if len(expirations) != 3 || !reflect.DeepEqual(expired, []string{"tiers.production.blocked_actions.2 (drain)"}) {
+		t.Errorf("Expirations = %+v, expired %v", expirations, expired)
+	}
+
+	bad, _ := ParseDocument([]byte("clusters:\n  x:\n    expires_at: tomorrow\n"))
+	if _, err := bad.Expirations(); err == nil {
+		t.Error("Expected error for an invalid expires_at")
+	}
+}
+
+func TestCheckKnownFields(t *testing.T) {
+	if err := CheckKnownFields([]byte(expiryConfig)); err != nil {
+		t.Errorf("CheckKnownFields rejected a valid config: %v", err)
+	}
+	if err := CheckKnownFields([]byte("tiers:\n  production:\n    blocked_action: [delete]\n")); err == nil {
+		t.Error("Expected error for a misspelled key")
+	}
+}
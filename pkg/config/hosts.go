@@ -0,0 +1,18 @@
+package config
+
+import "strings"
+
+// AllowsManifestHost reports whether remote manifests may be read from host
+// under these rules: any host when ManifestHosts is empty, otherwise one
+// matching an entry
+func (r ResolvedRules) AllowsManifestHost(host string) bool {
+	if len(r.ManifestHosts) == 0 {
+		return true
+	}
+	for _, pattern := range r.ManifestHosts {
+		if matchGlob(strings.ToLower(pattern), strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
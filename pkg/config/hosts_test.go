@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestAllowsManifestHost(t *testing.T) {
+	if !(ResolvedRules{}).AllowsManifestHost("anything.test") {
+		t.Error("rules without manifest_hosts should allow any host")
+	}
+
+	rules := ResolvedRules{ManifestHosts: []string{"github.com", "*.example.com"}}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"github.com", true},
+		{"GitHub.com", true},
+		{"raw.example.com", true},
+		{"example.com", false},
+		{"gitlab.com", false},
+	}
+	for _, tt := range tests {
+		if got := rules.AllowsManifestHost(tt.host); got != tt.want {
+			t.Errorf("AllowsManifestHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
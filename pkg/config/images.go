@@ -0,0 +1,28 @@
+package config
+
+import "strings"
+
+// AllowsRegistry reports whether images named name, a registry and
+// repository such as "ghcr.io/acme/api", may run under these rules: any
+// when AllowedRegistries is empty, otherwise those whose registry or full
+// name matches an entry
+func (r ResolvedRules) AllowsRegistry(name string) bool {
+	if len(r.AllowedRegistries) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	registry, _, _ := strings.Cut(name, "/")
+	for _, pattern := range r.AllowedRegistries {
+		pattern = strings.ToLower(pattern)
+		if matchGlob(pattern, registry) || matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChecksImages reports whether the rules restrict the images applied
+// manifests may run
+func (r ResolvedRules) ChecksImages() bool {
+	return len(r.AllowedRegistries) > 0 || r.RequireDigests
+}
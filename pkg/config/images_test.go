@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestAllowsRegistry(t *testing.T) {
+	if !(ResolvedRules{}).AllowsRegistry("docker.io/library/nginx") {
+		t.Error("rules without allowed_registries should allow any registry")
+	}
+
+	rules := ResolvedRules{AllowedRegistries: []string{"registry.example.com", "*.dkr.ecr.*.amazonaws.com", "ghcr.io/acme/*"}}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"registry.example.com/team/app", true},
+		{"Registry.Example.com/team/app", true},
+		{"123.dkr.ecr.eu-west-1.amazonaws.com/api", true},
+		{"ghcr.io/acme/api", true},
+		{"ghcr.io/other/api", false},
+		{"docker.io/library/nginx", false},
+	}
+	for _, tt := range tests {
+		if got := rules.AllowsRegistry(tt.name); got != tt.want {
+			t.Errorf("AllowsRegistry(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
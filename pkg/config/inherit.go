@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolveTier returns the rules of the named tier with everything it
+// inherits applied. Parent lists come first, the tier's own additions
+// follow and its remove_* entries are taken out. Boolean switches such as
+// banner are on if any tier in the chain turns them on; default,
+// approval_command, max_affected, the severity thresholds and each message
+// come from the nearest tier that sets them. Patterns are never inherited.
+func (c *Config) ResolveTier(name string) TierConfig {
+	chain := c.tierChain(name)
+	if len(chain) == 0 {
+		return TierConfig{}
+	}
+
+	resolved := TierConfig{
+		Patterns:            c.Tiers[name].Patterns,
+		RequireConfirmation: []string{},
+		BlockedActions:      ActionList{},
+	}
+	// Apply from the root ancestor down to the tier itself
+	for i := len(chain) - 1; i >= 0; i-- {
+		tier := c.Tiers[chain[i]]
+		resolved.RequireConfirmation = removeAll(appendMissing(resolved.RequireConfirmation, tier.RequireConfirmation), tier.RemoveConfirmation)
+		resolved.BlockedActions = removeEntries(appendEntries(resolved.BlockedActions, tier.BlockedActions), tier.RemoveBlockedActions)
+		resolved.AllowedActions = appendMissing(resolved.AllowedActions, tier.AllowedActions)
+		resolved.ManifestHosts = appendMissing(resolved.ManifestHosts, tier.ManifestHosts)
+		resolved.AllowedNamespaces = appendMissing(resolved.AllowedNamespaces, tier.AllowedNamespaces)
+		resolved.AllowedRegistries = appendMissing(resolved.AllowedRegistries, tier.AllowedRegistries)
+		if tier.Default != "" {
+			resolved.Default = tier.Default
+		}
+		if tier.ApprovalCommand != "" {
+			resolved.ApprovalCommand = tier.ApprovalCommand
+		}
+		if tier.ConfirmationPhrase != "" {
+			resolved.ConfirmationPhrase = tier.ConfirmationPhrase
+		}
+		if tier.MaxAffected != 0 {
+			resolved.MaxAffected = tier.MaxAffected
+		}
+		if tier.OverMaxAffected != "" {
+			resolved.OverMaxAffected = tier.OverMaxAffected
+		}
+		if tier.RetypeChildren != 0 {
+			resolved.RetypeChildren = tier.RetypeChildren
+		}
+		if tier.ScaleUpFactor != 0 {
+			resolved.ScaleUpFactor = tier.ScaleUpFactor
+		}
+		if tier.ConfirmSeverity != "" {
+			resolved.ConfirmSeverity = tier.ConfirmSeverity
+		}
+		if tier.BlockSeverity != "" {
+			resolved.BlockSeverity = tier.BlockSeverity
+		}
+		if tier.Unreachable != "" {
+			resolved.Unreachable = tier.Unreachable
+		}
+		if tier.WaitForDeletes != "" {
+			resolved.WaitForDeletes = tier.WaitForDeletes
+		}
+		if tier.WatchRollouts != "" {
+			resolved.WatchRollouts = tier.WatchRollouts
+		}
+		if tier.UntrustedImages != "" {
+			resolved.UntrustedImages = tier.UntrustedImages
+		}
+		resolved.Messages = mergeByAction(resolved.Messages, tier.Messages)
+		resolved.AddFlags = mergeByAction(resolved.AddFlags, tier.AddFlags)
+		resolved.Timeouts = mergeByAction(resolved.Timeouts, tier.Timeouts)
+		resolved.Banner = resolved.Banner || tier.Banner
+		resolved.RequireExplicitContext = resolved.RequireExplicitContext || tier.RequireExplicitContext
+		resolved.RequireExplicitNamespace = resolved.RequireExplicitNamespace || tier.RequireExplicitNamespace
+		resolved.BlockUnqualifiedDeletes = resolved.BlockUnqualifiedDeletes || tier.BlockUnqualifiedDeletes
+		resolved.RequireTicket = resolved.RequireTicket || tier.RequireTicket
+		resolved.RequireOnCall = resolved.RequireOnCall || tier.RequireOnCall
+		resolved.RequireMFA = resolved.RequireMFA || tier.RequireMFA
+		resolved.HealthCheck = resolved.HealthCheck || tier.HealthCheck
+		resolved.CheckQuota = resolved.CheckQuota || tier.CheckQuota
+		resolved.RequireDigests = resolved.RequireDigests || tier.RequireDigests
+	}
+	if len(chain) == 1 {
+		// Nothing inherited: keep the tier exactly as written
+		tier := c.Tiers[name]
+		resolved.RequireConfirmation = tier.RequireConfirmation
+		resolved.BlockedActions = tier.BlockedActions
+		resolved.AllowedActions = tier.AllowedActions
+	}
+	return resolved
+}
+
+// tierChain returns name followed by its ancestors. It stops at a missing
+// parent or a cycle; checkInheritance reports those at load time.
+func (c *Config) tierChain(name string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for name != "" && !seen[name] {
+		tier, ok := c.Tiers[name]
+		if !ok {
+			break
+		}
+		seen[name] = true
+		chain = append(chain, name)
+		name = tier.Inherits
+	}
+	return chain
+}
+
+// checkInheritance reports tiers inheriting from unknown tiers and
+// inheritance cycles
+func (c *Config) checkInheritance() error {
+	for _, name := range sortedTierNames(c.Tiers) {
+		seen := map[string]bool{name: true}
+		path := []string{name}
+		for parent := c.Tiers[name].Inherits; parent != ""; parent = c.Tiers[parent].Inherits {
+			if _, ok := c.Tiers[parent]; !ok {
+				return fmt.Errorf("tier '%s' inherits from unknown tier '%s'", path[len(path)-1], parent)
+			}
+			path = append(path, parent)
+			if seen[parent] {
+				return fmt.Errorf("tier inheritance cycle: %s", strings.Join(path, " -> "))
+			}
+			seen[parent] = true
+		}
+	}
+	return nil
+}
+
+// mergeByAction returns base with entries from over replacing those with
+// the same action
+func mergeByAction[V any](base, over map[string]V) map[string]V {
+	if len(base) == 0 {
+		return over
+	}
+	if len(over) == 0 {
+		return base
+	}
+	merged := make(map[string]V, len(base)+len(over))
+	for action, msg := range base {
+		merged[action] = msg
+	}
+	for action, msg := range over {
+		merged[action] = msg
+	}
+	return merged
+}
+
+func appendMissing(list, values []string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func removeAll(list, values []string) []string {
+	if len(values) == 0 {
+		return list
+	}
+	kept := []string{}
+	for _, v := range list {
+		if !contains(values, v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedTierNames(tiers map[string]TierConfig) []string {
+	names := make([]string, 0, len(tiers))
+	for name := range tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// KubectlBinary returns the kubectl configured for context in
+// KubectlBinaries: a path or a version. An entry naming context exactly
+// wins; otherwise the best matching pattern, as for cluster keys.
+func (c *Config) KubectlBinary(context string) (string, bool) {
+	if spec, ok := c.KubectlBinaries[context]; ok {
+		return spec, true
+	}
+	var matches []patternMatch
+	for pattern := range c.KubectlBinaries {
+		if matchGlob(pattern, context) {
+			matches = append(matches, patternMatch{pattern, pattern, 0})
+		}
+	}
+	pattern, ok := bestMatch(matches)
+	if !ok {
+		return "", false
+	}
+	return c.KubectlBinaries[pattern], true
+}
+
+// checkKubectlBinaries reports empty entries and invalid regular
+// expressions in KubectlBinaries
+func (c *Config) checkKubectlBinaries() error {
+	patterns := make([]string, 0, len(c.KubectlBinaries))
+	for pattern := range c.KubectlBinaries {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if c.KubectlBinaries[pattern] == "" {
+			return fmt.Errorf("kubectl_binaries.%s: name a path or a version", pattern)
+		}
+		if err := checkPattern(pattern); err != nil {
+			return fmt.Errorf("kubectl_binaries: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkKubectlPin reports a relative pinned path or a malformed checksum
+func (c *Config) checkKubectlPin() error {
+	if path := c.KubectlPin.Path; path != "" && !filepath.IsAbs(path) {
+		return fmt.Errorf("kubectl_pin.path must be absolute, got %q", path)
+	}
+	if sum := c.KubectlPin.SHA256; sum != "" {
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != 32 {
+			return fmt.Errorf("kubectl_pin.sha256 must be 64 hex digits")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKubectlBinary(t *testing.T) {
+	cfg := &Config{KubectlBinaries: map[string]string{
+		"legacy-*":      "1.24",
+		"legacy-prod-*": "/opt/kubectl-1.25/kubectl",
+		"legacy-prod-1": "1.26",
+	}}
+	tests := []struct {
+		context, want string
+	}{
+		{"legacy-dev", "1.24"},
+		{"legacy-prod-2", "/opt/kubectl-1.25/kubectl"},
+		{"legacy-prod-1", "1.26"},
+		{"app-prod", ""},
+	}
+	for _, tt := range tests {
+		if got, ok := cfg.KubectlBinary(tt.context); got != tt.want || ok != (tt.want != "") {
+			t.Errorf("KubectlBinary(%q) = %q, %v; want %q", tt.context, got, ok, tt.want)
+		}
+	}
+
+	cfg.KubectlBinaries["app-*"] = ""
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted an empty kubectl_binaries entry")
+	}
+}
+
+func TestCheckKubectlPin(t *testing.T) {
+	tests := []struct {
+		pin   KubectlPinConfig
+		valid bool
+	}{
+		{KubectlPinConfig{Path: "/usr/local/bin/kubectl"}, true},
+		{KubectlPinConfig{SHA256: strings.Repeat("ab", 32)}, true},
+		{KubectlPinConfig{Path: "bin/kubectl"}, false},
+		{KubectlPinConfig{SHA256: "abc"}, false},
+	}
+	for _, tt := range tests {
+		cfg := &Config{KubectlPin: tt.pin}
+		if err := cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.pin, err, tt.valid)
+		}
+	}
+}
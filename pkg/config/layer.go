@@ -0,0 +1,260 @@
+package config
+
+// Merge layers local over base and returns the result. Clusters, tiers,
+// maintenance windows, suggestions, namespace assignments, severity
+// entries, kubectl binaries, macros and aliases from local replace base
+// entries with the same name; freeze windows are combined, and base's win
+// over local ones with the same name. Global defaults only ever get
+// stricter: confirmation is required if either layer requires it, blocked
+// actions are combined, namespace re-typing stays on if either layer turns
+// it on and the stricter delete_all_namespaces wins. Auditing, verification,
+// leases and ownership checks are on if either layer turns them on. Audit,
+// verify, lease, retry, ownership, ticket, on-call, kubectl_pin, update and
+// ui settings are merged one by one; how base validates tickets, its
+// on-call schedule, its kubectl pin and its release signing key can't be
+// dropped or replaced, and read-only tiers are combined. Other sections
+// come from local when set there, otherwise from base.
+func Merge(base, local *Config) *Config {
+	merged := &Config{
+		Defaults: DefaultsConfig{
+			RequireConfirmation: base.Defaults.RequireConfirmation || local.Defaults.RequireConfirmation,
+			BlockedActions:      appendEntries(appendEntries(ActionList{}, base.Defaults.BlockedActions), local.Defaults.BlockedActions),
+			Messages:            mergeByAction(base.Defaults.Messages, local.Defaults.Messages),
+			// Either layer can turn it off unless the other turns it on
+			RetypeNamespaceDeletes: mergeSwitch(base.Defaults.RetypeNamespaceDeletes, local.Defaults.RetypeNamespaceDeletes),
+			BlockUnclassifiedProd:  base.Defaults.BlockUnclassifiedProd || local.Defaults.BlockUnclassifiedProd,
+			WatchKubeconfig:        base.Defaults.WatchKubeconfig || local.Defaults.WatchKubeconfig,
+			DeleteAllNamespaces:    mergeDeleteAll(base.Defaults.DeleteAllNamespaces, local.Defaults.DeleteAllNamespaces),
+		},
+		Clusters:           make(map[string]ClusterRules),
+		Tiers:              make(map[string]TierConfig),
+		MaintenanceWindows: make(map[string]MaintenanceWindow),
+		FreezeWindows:      make(map[string]MaintenanceWindow),
+		Suggestions:        make(map[string][]string),
+		Namespaces:         make(map[string][]string),
+		KubectlBinaries:    make(map[string]string),
+		Macros:             make(map[string]Macro),
+		Aliases:            make(map[string]string),
+		Output:             local.Output,
+		History:            local.History,
+		ClusterMeta:        local.ClusterMeta,
+		Source:             local.Source,
+		Audit:              mergeAudit(base.Audit, local.Audit),
+		Verify:             mergeVerify(base.Verify, local.Verify),
+		Tickets:            mergeTickets(base.Tickets, local.Tickets),
+		OnCall:             mergeOnCall(base.OnCall, local.OnCall),
+		Lease:              mergeLease(base.Lease, local.Lease),
+		Update:             mergeUpdate(base.Update, local.Update),
+		Telemetry:          local.Telemetry,
+		KubectlPin:         mergeKubectlPin(base.KubectlPin, local.KubectlPin),
+		Retry:              mergeRetry(base.Retry, local.Retry),
+		UI:                 mergeUI(base.UI, local.UI),
+		Ownership:          mergeOwnership(base.Ownership, local.Ownership),
+		Severity: SeverityConfig{
+			Actions: mergeByAction(base.Severity.Actions, local.Severity.Actions),
+			Kinds:   mergeByAction(base.Severity.Kinds, local.Severity.Kinds),
+		},
+	}
+
+	for _, layer := range []*Config{base, local} {
+		for name, rules := range layer.Clusters {
+			merged.Clusters[name] = rules
+		}
+		for name, tier := range layer.Tiers {
+			merged.Tiers[name] = tier
+		}
+		for name, window := range layer.MaintenanceWindows {
+			merged.MaintenanceWindows[name] = window
+		}
+		for name, window := range layer.FreezeWindows {
+			if _, shared := base.FreezeWindows[name]; !shared || layer == base {
+				merged.FreezeWindows[name] = window
+			}
+		}
+		for key, list := range layer.Suggestions {
+			merged.Suggestions[key] = list
+		}
+		for tier, patterns := range layer.Namespaces {
+			merged.Namespaces[tier] = patterns
+		}
+		for pattern, spec := range layer.KubectlBinaries {
+			merged.KubectlBinaries[pattern] = spec
+		}
+		for name, macro := range layer.Macros {
+			merged.Macros[name] = macro
+		}
+		for name, expansion := range layer.Aliases {
+			merged.Aliases[name] = expansion
+		}
+	}
+
+	if merged.Output == (OutputConfig{}) {
+		merged.Output = base.Output
+	}
+	if merged.History == (HistoryConfig{}) {
+		merged.History = base.History
+	}
+	if merged.ClusterMeta == (ClusterMetaConfig{}) {
+		merged.ClusterMeta = base.ClusterMeta
+	}
+	if merged.Telemetry == (TelemetryConfig{}) {
+		merged.Telemetry = base.Telemetry
+	}
+	return merged
+}
+
+// mergeSwitch combines an optional setting of two layers: true if either
+// sets it, false if one sets it false, otherwise unset
+func mergeSwitch(base, local *bool) *bool {
+	if base != nil && *base || local == nil {
+		return base
+	}
+	if *local || base == nil {
+		return local
+	}
+	return base
+}
+
+// mergeUpdate takes each update setting from local when set there, else
+// from base. A public key base sets stays, so a local file can't turn off
+// or redirect signature checks.
+func mergeUpdate(base, local UpdateConfig) UpdateConfig {
+	merged := local
+	merged.Check = base.Check || local.Check
+	if merged.Repository == "" {
+		merged.Repository = base.Repository
+	}
+	if merged.APIURL == "" {
+		merged.APIURL = base.APIURL
+	}
+	if base.PublicKey != "" {
+		merged.PublicKey = base.PublicKey
+	}
+	return merged
+}
+
+// mergeTickets takes each ticket setting from local when set there, else
+// from base. What makes a ticket valid (its pattern, the API and the
+// approved states) comes from base when base sets it; local only supplies
+// what base leaves out, like the token.
+func mergeTickets(base, local TicketConfig) TicketConfig {
+	merged := base
+	if base.Pattern == "" {
+		merged.Pattern = local.Pattern
+	}
+	if base.URL == "" {
+		merged.URL = local.URL
+	}
+	if base.StatusField == "" {
+		merged.StatusField = local.StatusField
+	}
+	if len(base.ApprovedStates) == 0 {
+		merged.ApprovedStates = local.ApprovedStates
+	}
+	if local.TokenEnv != "" {
+		merged.TokenEnv = local.TokenEnv
+	}
+	if local.TokenSecret != "" {
+		merged.TokenSecret = local.TokenSecret
+	}
+	if local.AuthScheme != "" {
+		merged.AuthScheme = local.AuthScheme
+	}
+	if local.Timeout != "" {
+		merged.Timeout = local.Timeout
+	}
+	return merged
+}
+
+// mergeOnCall takes each on-call setting from local when set there, else
+// from base. The schedule and API of base stay, so a local file can't name
+// a schedule its user is on call in.
+func mergeOnCall(base, local OnCallConfig) OnCallConfig {
+	merged := local
+	if base.ScheduleID != "" {
+		merged.ScheduleID = base.ScheduleID
+	}
+	if base.APIURL != "" {
+		merged.APIURL = base.APIURL
+	}
+	if merged.TokenEnv == "" {
+		merged.TokenEnv = base.TokenEnv
+	}
+	if merged.TokenSecret == "" {
+		merged.TokenSecret = base.TokenSecret
+	}
+	if merged.Email == "" {
+		merged.Email = base.Email
+	}
+	if merged.Timeout == "" {
+		merged.Timeout = base.Timeout
+	}
+	return merged
+}
+
+// mergeKubectlPin takes the pinned path and checksum from base when set
+// there, else from local, so a local pin can only add to the shared one
+func mergeKubectlPin(base, local KubectlPinConfig) KubectlPinConfig {
+	merged := base
+	if merged.Path == "" {
+		merged.Path = local.Path
+	}
+	if merged.SHA256 == "" {
+		merged.SHA256 = local.SHA256
+	}
+	return merged
+}
+
+// mergeAudit takes each audit setting from local when set there, else from
+// base. Auditing and output capture stay on if either layer turns them on,
+// so a local file can't stop the shared audit log.
+func mergeAudit(base, local AuditConfig) AuditConfig {
+	merged := local
+	merged.Enabled = base.Enabled || local.Enabled
+	merged.CaptureOutput = base.CaptureOutput || local.CaptureOutput
+	merged.CompressOutput = base.CompressOutput || local.CompressOutput
+	if merged.Path == "" {
+		merged.Path = base.Path
+	}
+	if merged.MaxOutputBytes == 0 {
+		merged.MaxOutputBytes = base.MaxOutputBytes
+	}
+	if merged.MaxAge == "" {
+		merged.MaxAge = base.MaxAge
+	}
+	if merged.MaxSize == "" {
+		merged.MaxSize = base.MaxSize
+	}
+	return merged
+}
+
+// mergeVerify takes the verify timeout from local when set there, else from
+// base; verification stays on if either layer turns it on
+func mergeVerify(base, local VerifyConfig) VerifyConfig {
+	merged := local
+	merged.Enabled = base.Enabled || local.Enabled
+	if merged.Timeout == "" {
+		merged.Timeout = base.Timeout
+	}
+	return merged
+}
+
+// mergeLease takes each lease setting from local when set there, else from
+// base; leases stay on if either layer turns them on
+func mergeLease(base, local LeaseConfig) LeaseConfig {
+	merged := local
+	merged.Enabled = base.Enabled || local.Enabled
+	if len(merged.Actions) == 0 {
+		merged.Actions = base.Actions
+	}
+	if merged.Namespace == "" {
+		merged.Namespace = base.Namespace
+	}
+	if merged.Name == "" {
+		merged.Name = base.Name
+	}
+	if merged.Duration == "" {
+		merged.Duration = base.Duration
+	}
+	return merged
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := &Config{
+		Defaults: DefaultsConfig{RequireConfirmation: true, BlockedActions: Actions("delete")},
+		Clusters: map[string]ClusterRules{
+			"shared-prod": {Tier: "production"},
+			"both":        {Tier: "production"},
+		},
+		Tiers: map[string]TierConfig{
+			"production": {Patterns: []string{"*-prod"}},
+		},
+		History:   HistoryConfig{Disabled: true},
+		Telemetry: TelemetryConfig{Endpoint: "https://shared.example.com"},
+	}
+	local := &Config{
+		Defaults: DefaultsConfig{BlockedActions: Actions("drain", "delete")},
+		Clusters: map[string]ClusterRules{
+			"both":     {Tier: "development"},
+			"my-local": {Tier: "development"},
+		},
+		Tiers: map[string]TierConfig{
+			"development": {Patterns: []string{"*-dev"}},
+		},
+		Telemetry: TelemetryConfig{Endpoint: "https://local.example.com"},
+	}
+
+	merged := Merge(base, local)
+
+	if !merged.Defaults.RequireConfirmation {
+		t.Error("RequireConfirmation from base should be kept")
+	}
+	if !reflect.DeepEqual(merged.Defaults.BlockedActions, Actions("delete", "drain")) {
+		t.Errorf("BlockedActions = %v, want [delete drain]", merged.Defaults.BlockedActions)
+	}
+	if len(merged.Clusters) != 3 || merged.Clusters["both"].Tier != "development" {
+		t.Errorf("Clusters = %v, want 3 entries with local 'both'", merged.Clusters)
+	}
+	if len(merged.Tiers) != 2 {
+		t.Errorf("Tiers = %v, want production and development", merged.Tiers)
+	}
+	if !merged.History.Disabled {
+		t.Error("History settings should come from base when local leaves them unset")
+	}
+	if merged.Telemetry.Endpoint != "https://local.example.com" {
+		t.Errorf("Telemetry.Endpoint = %q, want local's", merged.Telemetry.Endpoint)
+	}
+}
+
+func TestMerge_RetypeNamespaceDeletes(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		base, local *bool
+		want        bool
+	}{
+		{nil, nil, true},
+		{nil, &off, false},
+		{&off, nil, false},
+		{&on, &off, true},
+		{&off, &on, true},
+	}
+	for _, tt := range tests {
+		merged := Merge(&Config{Defaults: DefaultsConfig{RetypeNamespaceDeletes: tt.base}},
+			&Config{Defaults: DefaultsConfig{RetypeNamespaceDeletes: tt.local}})
+		if got := merged.Defaults.RetypesNamespaceDeletes(); got != tt.want {
+			t.Errorf("base %v, local %v: RetypesNamespaceDeletes = %v, want %v", tt.base, tt.local, got, tt.want)
+		}
+	}
+}
+
+func TestLoadWithBase(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	localPath := filepath.Join(dir, "local.yaml")
+	os.WriteFile(basePath, []byte("tiers:\n  production:\n    patterns: [\"*-prod\"]\n    blocked_actions: [delete]\n"), 0644)
+	// The local tier inherits a tier that only exists in the base
+	os.WriteFile(localPath, []byte("tiers:\n  prod-sandbox:\n    inherits: production\n    patterns: [\"*-sbx\"]\n"), 0644)
+
+	cfg, err := LoadWithBase(basePath, localPath)
+	if err != nil {
+		t.Fatalf("LoadWithBase failed: %v", err)
+	}
+	if got := cfg.GetClusterRules("app-sbx").BlockedActions; !reflect.DeepEqual(got, []string{"delete"}) {
+		t.Errorf("BlockedActions = %v, want [delete]", got)
+	}
+
+	if _, err := LoadFromPath(localPath); err == nil {
+		t.Error("Local file alone should fail validation")
+	}
+}
+
+func TestMerge_DeleteAllNamespaces(t *testing.T) {
+	tests := []struct{ base, local, want string }{
+		{"", "", DeleteAllBreakGlass},
+		{"", DeleteAllRules, DeleteAllRules},
+		{DeleteAllBlock, DeleteAllRules, DeleteAllBlock},
+		{DeleteAllRules, DeleteAllBreakGlass, DeleteAllBreakGlass},
+	}
+	for _, tt := range tests {
+		merged := Merge(&Config{Defaults: DefaultsConfig{DeleteAllNamespaces: tt.base}},
+			&Config{Defaults: DefaultsConfig{DeleteAllNamespaces: tt.local}})
+		if got := merged.Defaults.DeleteAllNamespacesMode(); got != tt.want {
+			t.Errorf("base %q, local %q: DeleteAllNamespacesMode = %q, want %q", tt.base, tt.local, got, tt.want)
+		}
+	}
+}
+
+func TestMerge_FreezeWindows(t *testing.T) {
+	shared := MaintenanceWindow{Clusters: []string{"*-prod"}, Days: []string{"fri"}, From: "12:00", To: "23:59"}
+	base := &Config{FreezeWindows: map[string]MaintenanceWindow{"friday": shared}}
+	local := &Config{FreezeWindows: map[string]MaintenanceWindow{
+		"friday":   {Clusters: []string{"nothing"}, Days: []string{"fri"}, From: "12:00", To: "12:01"},
+		"year-end": {Clusters: []string{"*"}, From: "00:00", To: "23:59"},
+	}}
+	merged := Merge(base, local)
+	if len(merged.FreezeWindows) != 2 || !reflect.DeepEqual(merged.FreezeWindows["friday"], shared) {
+		t.Errorf("FreezeWindows = %+v, want both, with the shared friday window", merged.FreezeWindows)
+	}
+}
+
+func TestMerge_Update(t *testing.T) {
+	base := &Config{Update: UpdateConfig{Repository: "acme/kctl", PublicKey: "c2hhcmVk"}}
+	tests := []struct {
+		name  string
+		local UpdateConfig
+		want  UpdateConfig
+	}{
+		{"local turns on checks", UpdateConfig{Check: true}, UpdateConfig{Check: true, Repository: "acme/kctl", PublicKey: "c2hhcmVk"}},
+		{"local can't replace the key", UpdateConfig{Repository: "fork/kctl", PublicKey: "bG9jYWw="}, UpdateConfig{Repository: "fork/kctl", PublicKey: "c2hhcmVk"}},
+	}
+	for _, tt := range tests {
+		if got := Merge(base, &Config{Update: tt.local}).Update; got != tt.want {
+			t.Errorf("%s: Update = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMerge_Tickets(t *testing.T) {
+	base := &Config{Tickets: TicketConfig{
+		Pattern:        "^CHG-[0-9]+$",
+		URL:            "https://jira.example.com/rest/api/2/issue/{ticket}",
+		ApprovedStates: []string{"Approved"},
+	}}
+	local := &Config{Tickets: TicketConfig{
+		Pattern:  ".*",
+		TokenEnv: "JIRA_TOKEN",
+		Timeout:  "5s",
+	}}
+	want := TicketConfig{
+		Pattern:        "^CHG-[0-9]+$",
+		URL:            "https://jira.example.com/rest/api/2/issue/{ticket}",
+		ApprovedStates: []string{"Approved"},
+		TokenEnv:       "JIRA_TOKEN",
+		Timeout:        "5s",
+	}
+	if got := Merge(base, local).Tickets; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tickets = %+v, want %+v", got, want)
+	}
+	if got := Merge(&Config{}, local).Tickets; !reflect.DeepEqual(got, local.Tickets) {
+		t.Errorf("Tickets = %+v, want local's %+v", got, local.Tickets)
+	}
+}
+
+func TestMerge_OnCall(t *testing.T) {
+	base := &Config{OnCall: OnCallConfig{ScheduleID: "PABC123", TokenSecret: "pagerduty"}}
+	local := &Config{OnCall: OnCallConfig{ScheduleID: "PMINE", Email: "you@example.com"}}
+	want := OnCallConfig{ScheduleID: "PABC123", TokenSecret: "pagerduty", Email: "you@example.com"}
+	if got := Merge(base, local).OnCall; got != want {
+		t.Errorf("OnCall = %+v, want %+v", got, want)
+	}
+}
+
+func TestMerge_KubectlPin(t *testing.T) {
+	base := &Config{KubectlPin: KubectlPinConfig{SHA256: "ab12"}}
+	local := &Config{KubectlPin: KubectlPinConfig{Path: "/usr/local/bin/kubectl", SHA256: "cd34"}}
+	want := KubectlPinConfig{Path: "/usr/local/bin/kubectl", SHA256: "ab12"}
+	if got := Merge(base, local).KubectlPin; got != want {
+		t.Errorf("KubectlPin = %+v, want %+v", got, want)
+	}
+}
+
+func TestMerge_Audit(t *testing.T) {
+	base := &Config{Audit: AuditConfig{Enabled: true, Path: "/var/log/kctl/audit.jsonl"}}
+	local := &Config{Audit: AuditConfig{MaxAge: "30d"}}
+	want := AuditConfig{Enabled: true, Path: "/var/log/kctl/audit.jsonl", MaxAge: "30d"}
+	if got := Merge(base, local).Audit; got != want {
+		t.Errorf("Audit = %+v, want %+v", got, want)
+	}
+}
+
+func TestMerge_Sections(t *testing.T) {
+	base := &Config{
+		Verify: VerifyConfig{Enabled: true},
+		Lease:  LeaseConfig{Enabled: true, Actions: []string{"drain"}, Namespace: "ops"},
+		Retry:  RetryConfig{Attempts: 5, Backoff: "1s"},
+	}
+	local := &Config{
+		Verify: VerifyConfig{Timeout: "1m"},
+		Lease:  LeaseConfig{Duration: "5m"},
+		Retry:  RetryConfig{MaxBackoff: "10s"},
+	}
+	merged := Merge(base, local)
+	if want := (VerifyConfig{Enabled: true, Timeout: "1m"}); merged.Verify != want {
+		t.Errorf("Verify = %+v, want %+v", merged.Verify, want)
+	}
+	if want := (LeaseConfig{Enabled: true, Actions: []string{"drain"}, Namespace: "ops", Duration: "5m"}); !reflect.DeepEqual(merged.Lease, want) {
+		t.Errorf("Lease = %+v, want %+v", merged.Lease, want)
+	}
+	if want := (RetryConfig{Attempts: 5, Backoff: "1s", MaxBackoff: "10s"}); merged.Retry != want {
+		t.Errorf("Retry = %+v, want %+v", merged.Retry, want)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// macroParam matches a {name} parameter in a macro's args
+var macroParam = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+// Macro is a kubectl command with parameters, run with 'kctl run NAME
+// param=value...'
+type Macro struct {
+	Description string `yaml:"description,omitempty"`
+	// Args are the kubectl arguments; {name} is replaced with the value of
+	// parameter name
+	Args []string `yaml:"args"`
+	// Defaults are the values of parameters that may be left out
+	Defaults map[string]string `yaml:"defaults,omitempty"`
+	// Allowed limits parameters to the values listed
+	Allowed map[string][]string `yaml:"allowed,omitempty"`
+}
+
+// Params returns the names of the macro's parameters, sorted
+func (m Macro) Params() []string {
+	seen := make(map[string]bool)
+	var params []string
+	for _, arg := range m.Args {
+		for _, match := range macroParam.FindAllStringSubmatch(arg, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				params = append(params, match[1])
+			}
+		}
+	}
+	sort.Strings(params)
+	return params
+}
+
+// Expand returns the macro's args with its parameters replaced by values,
+// or by their defaults when left out. Unknown parameters, missing ones and
+// values Allowed doesn't list are errors.
+func (m Macro) Expand(values map[string]string) ([]string, error) {
+	params := m.Params()
+	for _, name := range sortedKeys(values) {
+		if !contains(params, name) {
+			return nil, fmt.Errorf("unknown parameter '%s' (takes %s)", name, describeParams(params))
+		}
+	}
+	resolved := make(map[string]string, len(params))
+	for _, name := range params {
+		value, ok := values[name]
+		if !ok {
+			if value, ok = m.Defaults[name]; !ok {
+				return nil, fmt.Errorf("missing parameter '%s'; pass it as %s=<value>", name, name)
+			}
+		}
+		if allowed := m.Allowed[name]; len(allowed) > 0 && !contains(allowed, value) {
+			return nil, fmt.Errorf("parameter '%s' must be one of %s, got '%s'", name, strings.Join(allowed, ", "), value)
+		}
+		resolved[name] = value
+	}
+
+	args := make([]string, len(m.Args))
+	for i, arg := range m.Args {
+		args[i] = macroParam.ReplaceAllStringFunc(arg, func(match string) string {
+			return resolved[match[1:len(match)-1]]
+		})
+	}
+	return args, nil
+}
+
+// describeParams lists parameter names for an error message
+func describeParams(params []string) string {
+	if len(params) == 0 {
+		return "no parameters"
+	}
+	return strings.Join(params, ", ")
+}
+
+// checkMacros reports macros without args, and defaults or allowed values
+// for parameters a macro doesn't have
+func (c *Config) checkMacros() error {
+	for _, name := range sortedKeys(c.Macros) {
+		m := c.Macros[name]
+		if len(m.Args) == 0 {
+			return fmt.Errorf("macro '%s': args is required", name)
+		}
+		params := m.Params()
+		for _, param := range sortedKeys(m.Defaults) {
+			if !contains(params, param) {
+				return fmt.Errorf("macro '%s': default for unknown parameter '%s'", name, param)
+			}
+			if allowed := m.Allowed[param]; len(allowed) > 0 && !contains(allowed, m.Defaults[param]) {
+				return fmt.Errorf("macro '%s': default of '%s' isn't one of its allowed values", name, param)
+			}
+		}
+		for _, param := range sortedKeys(m.Allowed) {
+			if !contains(params, param) {
+				return fmt.Errorf("macro '%s': allowed values for unknown parameter '%s'", name, param)
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMacroExpand(t *testing.T) {
+	m := Macro{
+		Args:     []string{"--context", "app-{env}", "rollout", "restart", "deployment/{app}", "-n", "{app}"},
+		Defaults: map[string]string{"app": "api"},
+		Allowed:  map[string][]string{"env": {"staging", "prod"}},
+	}
+	if got := m.Params(); !reflect.DeepEqual(got, []string{"app", "env"}) {
+		t.Errorf("Params = %v", got)
+	}
+
+	got, err := m.Expand(map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--context", "app-prod", "rollout", "restart", "deployment/api", "-n", "api"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand = %v, want %v", got, want)
+	}
+
+	errors := []struct {
+		values map[string]string
+		want   string
+	}{
+		{map[string]string{}, "missing parameter 'env'"},
+		{map[string]string{"env": "dev"}, "must be one of staging, prod"},
+		{map[string]string{"env": "prod", "replicas": "3"}, "unknown parameter 'replicas'"},
+	}
+	for _, tt := range errors {
+		if _, err := m.Expand(tt.values); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expand(%v) error = %v, want %q", tt.values, err, tt.want)
+		}
+	}
+}
+
+func TestCheckMacros(t *testing.T) {
+	tests := []struct {
+		macro Macro
+		want  string
+	}{
+		{Macro{Args: []string{"get", "pods", "-n", "{ns}"}, Defaults: map[string]string{"ns": "default"}}, ""},
+		{Macro{}, "args is required"},
+		{Macro{Args: []string{"get", "pods"}, Defaults: map[string]string{"ns": "x"}}, "unknown parameter 'ns'"},
+		{Macro{Args: []string{"get", "pods"}, Allowed: map[string][]string{"ns": {"x"}}}, "unknown parameter 'ns'"},
+		{Macro{Args: []string{"-n", "{ns}"}, Defaults: map[string]string{"ns": "x"}, Allowed: map[string][]string{"ns": {"y"}}}, "isn't one of its allowed values"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Macros: map[string]Macro{"m": tt.macro}}
+		err := cfg.checkMacros()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("checkMacros(%+v) = %v, want %q", tt.macro, err, tt.want)
+		}
+	}
+}
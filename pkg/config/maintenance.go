@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow downgrades blocked actions to confirmation on matching
+// clusters while it is open. A window is either one-off (start and end) or
+// weekly (days, from and to).
+type MaintenanceWindow struct {
+	Clusters []string  `yaml:"clusters"` // Context patterns, as in tier patterns
+	Start    time.Time `yaml:"start,omitempty"`
+	End      time.Time `yaml:"end,omitempty"`
+	Days     []string  `yaml:"days,omitempty"`     // mon, tue, ... Default: every day
+	From     string    `yaml:"from,omitempty"`     // Daily start as HH:MM
+	To       string    `yaml:"to,omitempty"`       // Daily end as HH:MM; before from spans midnight
+	Timezone string    `yaml:"timezone,omitempty"` // IANA zone for from and to. Default: local time
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Open reports whether the window is open at t
+func (w MaintenanceWindow) Open(t time.Time) bool {
+	if !w.Start.IsZero() {
+		return !t.Before(w.Start) && t.Before(w.End)
+	}
+
+	from, errFrom := parseClock(w.From)
+	to, errTo := parseClock(w.To)
+	loc, errLoc := w.location()
+	if errFrom != nil || errTo != nil || errLoc != nil {
+		return false
+	}
+	t = t.In(loc)
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if from <= to {
+		return clock >= from && clock < to && w.onDay(t.Weekday())
+	}
+	// Spans midnight: the window belongs to the day it started on
+	if clock >= from {
+		return w.onDay(t.Weekday())
+	}
+	return clock < to && w.onDay((t.Weekday()+6)%7)
+}
+
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// parseClock parses HH:MM into the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// activeMaintenance returns the name of the open maintenance window covering
+// context, or ""
+func (c *Config) activeMaintenance(context string) string {
+	return openWindow(c.MaintenanceWindows, context)
+}
+
+// ActiveFreeze returns the name of the open freeze window covering context,
+// or ""
+func (c *Config) ActiveFreeze(context string) string {
+	return openWindow(c.FreezeWindows, context)
+}
+
+// openWindow returns the name of the open window covering context, or "".
+// Overlapping windows are chosen by name so the result is deterministic.
+func openWindow(windows map[string]MaintenanceWindow, context string) string {
+	names := make([]string, 0, len(windows))
+	for name := range windows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := now()
+	for _, name := range names {
+		w := windows[name]
+		if _, ok := matchPatterns(w.Clusters, context); ok && w.Open(t) {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkMaintenance reports maintenance and freeze windows that could never
+// open or are ambiguous
+func (c *Config) checkMaintenance() error {
+	for name, w := range c.MaintenanceWindows {
+		if err := w.check(); err != nil {
+			return fmt.Errorf("maintenance window '%s': %w", name, err)
+		}
+	}
+	for name, w := range c.FreezeWindows {
+		if err := w.check(); err != nil {
+			return fmt.Errorf("freeze window '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (w MaintenanceWindow) check() error {
+	if len(w.Clusters) == 0 {
+		return fmt.Errorf("clusters is required")
+	}
+	for _, pattern := range w.Clusters {
+		if err := checkPattern(strings.TrimPrefix(pattern, "!")); err != nil {
+			return err
+		}
+	}
+
+	oneOff := !w.Start.IsZero() || !w.End.IsZero()
+	weekly := w.From != "" || w.To != "" || len(w.Days) > 0
+	switch {
+	case oneOff && weekly:
+		return fmt.Errorf("use either start/end or days/from/to, not both")
+	case oneOff:
+		if w.Start.IsZero() || w.End.IsZero() || !w.End.After(w.Start) {
+			return fmt.Errorf("end must be after start")
+		}
+	case weekly:
+		if _, err := parseClock(w.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		if _, err := parseClock(w.To); err != nil {
+			return fmt.Errorf("to: %w", err)
+		}
+		for _, d := range w.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("unknown day %q (use mon, tue, ...)", d)
+			}
+		}
+		if _, err := w.location(); err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+	default:
+		return fmt.Errorf("set start and end, or from and to")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindow_Open(t *testing.T) {
+	utc := func(day, hour, minute int) time.Time {
+		// March 2026 starts on a Sunday
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		at     time.Time
+		open   bool
+	}{
+		{"one-off inside", MaintenanceWindow{Start: utc(1, 2, 0), End: utc(1, 6, 0)}, utc(1, 3, 0), true},
+		{"one-off end is exclusive", MaintenanceWindow{Start: utc(1, 2, 0), End: utc(1, 6, 0)}, utc(1, 6, 0), false},
+		{"weekly on day", MaintenanceWindow{Days: []string{"sun"}, From: "02:00", To: "06:00", Timezone: "UTC"}, utc(8, 2, 30), true},
+		{"weekly other day", MaintenanceWindow{Days: []string{"sun"}, From: "02:00", To: "06:00", Timezone: "UTC"}, utc(9, 2, 30), false},
+		{"daily", MaintenanceWindow{From: "02:00", To: "06:00", Timezone: "UTC"}, utc(4, 5, 59), true},
+		{"spans midnight, before", MaintenanceWindow{Days: []string{"sat"}, From: "22:00", To: "02:00", Timezone: "UTC"}, utc(7, 23, 0), true},
+		{"spans midnight, after", MaintenanceWindow{Days: []string{"sat"}, From: "22:00", To: "02:00", Timezone: "UTC"}, utc(8, 1, 0), true},
+		{"spans midnight, wrong day", MaintenanceWindow{Days: []string{"sat"}, From: "22:00", To: "02:00", Timezone: "UTC"}, utc(7, 1, 0), false},
+		{"timezone", MaintenanceWindow{From: "02:00", To: "03:00", Timezone: "Europe/Berlin"}, utc(4, 1, 30), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Open(tt.at); got != tt.open {
+				t.Errorf("Open(%s) = %v, want %v", tt.at, got, tt.open)
+			}
+		})
+	}
+}
+
+func TestGetClusterRules_Maintenance(t *testing.T) {
+	withNow(t, time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC))
+	cfg := &Config{
+		MaintenanceWindows: map[string]MaintenanceWindow{
+			"b-patching": {Clusters: []string{"*-prod"}, From: "02:00", To: "04:00", Timezone: "UTC"},
+			"a-upgrade":  {Clusters: []string{"app-*", "!app-legacy"}, From: "00:00", To: "23:59", Timezone: "UTC"},
+		},
+	}
+
+	for context, want := range map[string]string{
+		"app-prod":   "a-upgrade",
+		"app-legacy": "",
+		"db-prod":    "b-patching",
+		"dev":        "",
+	} {
+		if got := cfg.GetClusterRules(context).Maintenance; got != want {
+			t.Errorf("Maintenance for %s = %q, want %q", context, got, want)
+		}
+	}
+}
+
+func TestValidate_Maintenance(t *testing.T) {
+	start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window MaintenanceWindow
+		want   string
+	}{
+		{MaintenanceWindow{From: "02:00", To: "04:00"}, "clusters is required"},
+		{MaintenanceWindow{Clusters: []string{"*"}}, "set start and end"},
+		{MaintenanceWindow{Clusters: []string{"*"}, Start: start, End: start}, "end must be after start"},
+		{MaintenanceWindow{Clusters: []string{"*"}, Start: start, From: "02:00"}, "not both"},
+		{MaintenanceWindow{Clusters: []string{"*"}, From: "2am", To: "04:00"}, "from"},
+		{MaintenanceWindow{Clusters: []string{"*"}, Days: []string{"sunday"}, From: "02:00", To: "04:00"}, "unknown day"},
+		{MaintenanceWindow{Clusters: []string{"*"}, From: "02:00", To: "04:00", Timezone: "Mars/Base"}, "timezone"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{MaintenanceWindows: map[string]MaintenanceWindow{"w": tt.window}}
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%+v) = %v, want error containing %q", tt.window, err, tt.want)
+		}
+	}
+}
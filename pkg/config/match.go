@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// regexPrefix marks a pattern as an RE2 regular expression
+const regexPrefix = "re:"
+
+var regexCache sync.Map // expression -> *regexp.Regexp
+
+// lookupMu guards the servers and tiers configs cache from their lookups,
+// which servers deciding requests concurrently share. It isn't held while
+// a lookup runs.
+var lookupMu sync.Mutex
+
+// compileRegex compiles expr once per process
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, re)
+	return re, nil
+}
+
+// checkPatterns reports invalid regular expressions in cluster keys and
+// tier patterns
+func (c *Config) checkPatterns() error {
+	for key := range c.Clusters {
+		if err := checkPattern(key); err != nil {
+			return fmt.Errorf("cluster '%s': %w", key, err)
+		}
+	}
+	for _, name := range sortedTierNames(c.Tiers) {
+		tier := c.Tiers[name]
+		for _, pattern := range append(append([]string{}, tier.Patterns...), tier.Servers...) {
+			if err := checkPattern(strings.TrimPrefix(pattern, "!")); err != nil {
+				return fmt.Errorf("tier '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkPattern(pattern string) error {
+	expr, ok := strings.CutPrefix(pattern, regexPrefix)
+	if !ok {
+		return nil
+	}
+	if _, err := compileRegex(expr); err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// When several glob entries match a context, the winner is chosen by:
+//  1. higher priority
+//  2. more specific pattern (more literal characters, for globs and regexes)
+//  3. name, alphabetically
+// so resolution never depends on map iteration order.
+
+type patternMatch struct {
+	name     string // cluster key or tier name
+	pattern  string
+	priority int
+}
+
+// ClusterEntry returns the name of the clusters entry context gets its
+// rules from: its own name or the best pattern matching it. ok is false
+// when the cluster declares its tier or no unexpired entry matches.
+func (c *Config) ClusterEntry(context string) (name string, ok bool) {
+	if c.declaredTier(context) != "" {
+		return "", false
+	}
+	if rules, ok := c.Clusters[context]; ok && !rules.Expired() {
+		return context, true
+	}
+	return c.matchClusterPattern(context)
+}
+
+// matchClusterPattern returns the key of the best glob entry in clusters
+// that matches context
+func (c *Config) matchClusterPattern(context string) (string, bool) {
+	var matches []patternMatch
+	for pattern, rules := range c.Clusters {
+		if !rules.Expired() && matchGlob(pattern, context) {
+			matches = append(matches, patternMatch{pattern, pattern, rules.Priority})
+		}
+	}
+	return bestMatch(matches)
+}
+
+// matchTier returns the best tier for context. Server URL patterns are
+// checked first since the server can't be renamed the way a context can;
+// then context name patterns. A tier is skipped if any of its negated
+// ("!pattern") patterns matches.
+func (c *Config) matchTier(context string) (string, bool) {
+	if server := c.serverFor(context); server != "" {
+		var matches []patternMatch
+		for name, tier := range c.Tiers {
+			if pattern, ok := matchPatterns(tier.Servers, server); ok {
+				matches = append(matches, patternMatch{name, pattern, tier.Priority})
+			}
+		}
+		if name, ok := bestMatch(matches); ok {
+			return name, true
+		}
+	}
+
+	var matches []patternMatch
+	for name, tier := range c.Tiers {
+		if pattern, ok := matchPatterns(tier.Patterns, context); ok {
+			matches = append(matches, patternMatch{name, pattern, tier.Priority})
+		}
+	}
+	return bestMatch(matches)
+}
+
+// serverFor looks up and caches the API server URL of context. It returns
+// "" when no tier uses server patterns or the lookup fails.
+func (c *Config) serverFor(context string) string {
+	if c.ServerLookup == nil || !c.usesServerPatterns() {
+		return ""
+	}
+	lookupMu.Lock()
+	server, ok := c.servers[context]
+	lookupMu.Unlock()
+	if ok {
+		return server
+	}
+	server, err := c.ServerLookup(context)
+	if err != nil {
+		server = ""
+	}
+	lookupMu.Lock()
+	if c.servers == nil {
+		c.servers = make(map[string]string)
+	}
+	c.servers[context] = server
+	lookupMu.Unlock()
+	return server
+}
+
+// declaredTier returns the tier the cluster behind context declares for
+// itself, if it names a configured tier
+func (c *Config) declaredTier(context string) string {
+	if c.TierLookup == nil {
+		return ""
+	}
+	lookupMu.Lock()
+	tier, ok := c.declared[context]
+	lookupMu.Unlock()
+	if !ok {
+		tier, _ = c.TierLookup(context)
+		lookupMu.Lock()
+		if c.declared == nil {
+			c.declared = make(map[string]string)
+		}
+		c.declared[context] = tier
+		lookupMu.Unlock()
+	}
+	if _, known := c.Tiers[tier]; !known {
+		return ""
+	}
+	return tier
+}
+
+func (c *Config) usesServerPatterns() bool {
+	for _, tier := range c.Tiers {
+		if len(tier.Servers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPatterns returns the most specific positive pattern matching
+// context, unless a negated pattern excludes it
+func matchPatterns(patterns []string, context string) (string, bool) {
+	best := ""
+	matched := false
+	for _, pattern := range patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchGlob(negated, context) {
+				return "", false
+			}
+			continue
+		}
+		if matchGlob(pattern, context) && (!matched || specificity(pattern) > specificity(best)) {
+			best, matched = pattern, true
+		}
+	}
+	return best, matched
+}
+
+func bestMatch(matches []patternMatch) (string, bool) {
+	if len(matches) == 0 {
+		return "", false
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		if sa, sb := specificity(a.pattern), specificity(b.pattern); sa != sb {
+			return sa > sb
+		}
+		return a.name < b.name
+	})
+	return matches[0].name, true
+}
+
+// specificity counts the literal characters in a glob or regex pattern
+func specificity(pattern string) int {
+	special := "*?[]{}!"
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		pattern, special = expr, `.*+?()[]{}|^$\`
+	}
+	n := 0
+	for _, r := range pattern {
+		if !strings.ContainsRune(special, r) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NamespaceTier returns the tier namespace is assigned in Namespaces. When
+// several tiers match, the winner is chosen like tier patterns for contexts.
+func (c *Config) NamespaceTier(namespace string) (string, bool) {
+	var matches []patternMatch
+	for name, patterns := range c.Namespaces {
+		if pattern, ok := matchPatterns(patterns, namespace); ok {
+			matches = append(matches, patternMatch{name, pattern, c.Tiers[name].Priority})
+		}
+	}
+	return bestMatch(matches)
+}
+
+// NamespaceTiers returns the tiers namespaces are assigned to, sorted
+func (c *Config) NamespaceTiers() []string {
+	names := make([]string, 0, len(c.Namespaces))
+	for name := range c.Namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetNamespaceRules returns the rules of the tier assigned to namespace,
+// as applied on the cluster behind context: maintenance and freeze windows
+// are the cluster's. ok is false when no namespace pattern matches.
+func (c *Config) GetNamespaceRules(context, namespace string) (ResolvedRules, bool) {
+	tier, ok := c.NamespaceTier(namespace)
+	if !ok {
+		return ResolvedRules{}, false
+	}
+	rules, ok := c.GetTierRules(tier)
+	if !ok {
+		return ResolvedRules{}, false
+	}
+	rules.Maintenance = c.activeMaintenance(context)
+	rules.Freeze = c.ActiveFreeze(context)
+	return rules, true
+}
+
+// AllowsNamespace reports whether mutating commands may target namespace
+// under these rules: any namespace when AllowedNamespaces is empty,
+// otherwise one matching its patterns
+func (r ResolvedRules) AllowsNamespace(namespace string) bool {
+	if len(r.AllowedNamespaces) == 0 {
+		return true
+	}
+	_, ok := matchPatterns(r.AllowedNamespaces, namespace)
+	return ok
+}
+
+// checkNamespaces reports namespace patterns naming unknown tiers or
+// holding invalid regular expressions, in namespaces and in
+// allowed_namespaces
+func (c *Config) checkNamespaces() error {
+	for _, name := range c.NamespaceTiers() {
+		if _, ok := c.Tiers[name]; !ok {
+			return fmt.Errorf("namespaces: unknown tier '%s'", name)
+		}
+		for _, pattern := range c.Namespaces[name] {
+			if err := checkPattern(strings.TrimPrefix(pattern, "!")); err != nil {
+				return fmt.Errorf("namespaces: tier '%s': %w", name, err)
+			}
+		}
+	}
+	for name, rules := range c.Clusters {
+		for _, pattern := range rules.AllowedNamespaces {
+			if err := checkPattern(strings.TrimPrefix(pattern, "!")); err != nil {
+				return fmt.Errorf("cluster '%s': allowed_namespaces: %w", name, err)
+			}
+		}
+	}
+	for name, tier := range c.Tiers {
+		for _, pattern := range tier.AllowedNamespaces {
+			if err := checkPattern(strings.TrimPrefix(pattern, "!")); err != nil {
+				return fmt.Errorf("tier '%s': allowed_namespaces: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
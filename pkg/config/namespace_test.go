@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamespaceTier(t *testing.T) {
+	cfg := &Config{
+		Tiers: map[string]TierConfig{
+			"production": {RequireConfirmation: []string{"delete"}},
+			"staging":    {Priority: 10},
+		},
+		Namespaces: map[string][]string{
+			"production": {"payments", "prod-*", "!prod-sandbox"},
+			"staging":    {"prod-preview-*"},
+		},
+	}
+
+	tests := []struct {
+		namespace string
+		tier      string
+		ok        bool
+	}{
+		{"payments", "production", true},
+		{"prod-api", "production", true},
+		{"prod-sandbox", "", false},
+		{"prod-preview-1", "staging", true},
+		{"default", "", false},
+	}
+	for _, tt := range tests {
+		tier, ok := cfg.NamespaceTier(tt.namespace)
+		if tier != tt.tier || ok != tt.ok {
+			t.Errorf("NamespaceTier(%q) = %q, %v, want %q, %v", tt.namespace, tier, ok, tt.tier, tt.ok)
+		}
+	}
+
+	rules, ok := cfg.GetNamespaceRules("dev", "payments")
+	if !ok || rules.Tier != "production" || len(rules.RequireConfirmation) != 1 {
+		t.Errorf("GetNamespaceRules(payments) = %+v, %v", rules, ok)
+	}
+}
+
+func TestValidate_Namespaces(t *testing.T) {
+	tests := []struct {
+		namespaces map[string][]string
+		want       string
+	}{
+		{map[string][]string{"prod": {"payments"}}, "unknown tier 'prod'"},
+		{map[string][]string{"production": {"re:(payments"}}, "invalid regex"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Tiers: map[string]TierConfig{"production": {}}, Namespaces: tt.namespaces}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%v) = %v, want error containing %q", tt.namespaces, err, tt.want)
+		}
+	}
+}
+
+func TestAllowsNamespace(t *testing.T) {
+	cfg := &Config{
+		Clusters: map[string]ClusterRules{
+			"shared-prod": {Tier: "production", AllowedNamespaces: []string{"team-a", "team-a-*", "!team-a-infra"}},
+		},
+		Tiers: map[string]TierConfig{"production": {Patterns: []string{"*-prod"}}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	rules := cfg.GetClusterRules("shared-prod")
+	for namespace, want := range map[string]bool{"team-a": true, "team-a-jobs": true, "team-a-infra": false, "team-b": false} {
+		if got := rules.AllowsNamespace(namespace); got != want {
+			t.Errorf("AllowsNamespace(%q) = %v, want %v", namespace, got, want)
+		}
+	}
+	if !cfg.GetClusterRules("app-prod").AllowsNamespace("team-b") {
+		t.Error("rules without allowed_namespaces refused a namespace")
+	}
+
+	cfg.Clusters["shared-prod"] = ClusterRules{AllowedNamespaces: []string{"re:team-("}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "allowed_namespaces") {
+		t.Errorf("Validate = %v, want an allowed_namespaces error", err)
+	}
+}
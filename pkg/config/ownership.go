@@ -0,0 +1,48 @@
+package config
+
+import "fmt"
+
+// DefaultOwnerKeys are the labels and annotations naming the team that owns
+// an object, in the order they are looked up
+var DefaultOwnerKeys = []string{"app.kubernetes.io/managed-by", "owner"}
+
+// OwnershipConfig makes changes to objects owned by another team need
+// confirmation
+type OwnershipConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Team is yours, as the owner labels and annotations name it
+	Team string `yaml:"team,omitempty"`
+	// Keys are the labels and annotations naming an object's owning team;
+	// the first one an object has is used. Default: DefaultOwnerKeys
+	Keys []string `yaml:"keys,omitempty"`
+}
+
+// OwnerKeys returns the labels and annotations naming an object's team
+func (o OwnershipConfig) OwnerKeys() []string {
+	if len(o.Keys) == 0 {
+		return DefaultOwnerKeys
+	}
+	return o.Keys
+}
+
+// mergeOwnership turns ownership checks on if either layer does; a team
+// or keys set locally replace the base's
+func mergeOwnership(base, local OwnershipConfig) OwnershipConfig {
+	merged := local
+	merged.Enabled = base.Enabled || local.Enabled
+	if merged.Team == "" {
+		merged.Team = base.Team
+	}
+	if len(merged.Keys) == 0 {
+		merged.Keys = base.Keys
+	}
+	return merged
+}
+
+// checkOwnership reports ownership checks turned on without a team
+func (c *Config) checkOwnership() error {
+	if c.Ownership.Enabled && c.Ownership.Team == "" {
+		return fmt.Errorf("ownership: team is required when enabled")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge_Ownership(t *testing.T) {
+	base := &Config{Ownership: OwnershipConfig{Enabled: true, Keys: []string{"team.example.com/owner"}}}
+	local := &Config{Ownership: OwnershipConfig{Team: "payments"}}
+	got := Merge(base, local).Ownership
+	want := OwnershipConfig{Enabled: true, Team: "payments", Keys: []string{"team.example.com/owner"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+	if keys := (OwnershipConfig{}).OwnerKeys(); !reflect.DeepEqual(keys, DefaultOwnerKeys) {
+		t.Errorf("OwnerKeys = %v, want the defaults", keys)
+	}
+
+	if err := (&Config{Ownership: OwnershipConfig{Enabled: true}}).Validate(); err == nil {
+		t.Error("Validate accepted ownership checks without a team")
+	}
+}
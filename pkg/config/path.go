@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePath splits a dotted config path such as "tiers.production.banner"
+// into its segments. Segments containing dots can be double-quoted:
+// clusters."api.example.com".tier
+func ParsePath(s string) ([]string, error) {
+	var path []string
+	var current strings.Builder
+	quoted := false
+	wasQuoted := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			wasQuoted = true
+		case c == '.' && !quoted:
+			if current.Len() == 0 && !wasQuoted {
+				return nil, fmt.Errorf("invalid path %q: empty segment", s)
+			}
+			path = append(path, current.String())
+			current.Reset()
+			wasQuoted = false
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid path %q: unterminated quote", s)
+	}
+	if current.Len() == 0 && !wasQuoted {
+		return nil, fmt.Errorf("invalid path %q: empty segment", s)
+	}
+	return append(path, current.String()), nil
+}
+
+// FieldType returns the Go type of the config setting at path, following
+// yaml tags through structs and map values. Unknown keys are an error so
+// typos don't silently end up in the file.
+func FieldType(path []string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for i, key := range path {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByTag(t, key)
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", strings.Join(path[:i+1], "."))
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice:
+			if _, err := strconv.Atoi(key); err != nil {
+				return nil, fmt.Errorf("%s is a list; use a numeric index", strings.Join(path[:i], "."))
+			}
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("%s is not a section", strings.Join(path[:i], "."))
+		}
+	}
+	return t, nil
+}
+
+// ParseValue converts a command-line value into the type expected at path.
+// Lists are given comma-separated ("delete,drain"); an empty string clears
+// a list.
+func ParseValue(path []string, raw string) (interface{}, error) {
+	t, err := FieldType(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.Join(path, ".")
+
+	if t == reflect.TypeOf(time.Time{}) {
+		v, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects an RFC 3339 time such as 2026-03-01T18:00:00Z, got %q", name, raw)
+		}
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false, got %q", name, raw)
+		}
+		return v, nil
+	case reflect.Int, reflect.Int64:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a number, got %q", name, raw)
+		}
+		return v, nil
+	case reflect.Slice:
+		// Action lists are given as plain names, leaving temporary
+		// entries to the config file
+		if t.Elem().Kind() != reflect.String && t != reflect.TypeOf(ActionList{}) {
+			break
+		}
+		values := []string{}
+		for _, v := range strings.Split(strings.Trim(raw, "[]"), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("%s is a section; set its fields individually", name)
+}
+
+func fieldByTag(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
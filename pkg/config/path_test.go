@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"tiers.production.banner", []string{"tiers", "production", "banner"}, false},
+		{`clusters."api.example.com".tier`, []string{"clusters", "api.example.com", "tier"}, false},
+		{"clusters.arn:aws:eks:us-east-1:1:cluster/payments", []string{"clusters", "arn:aws:eks:us-east-1:1:cluster/payments"}, false},
+		{"tiers..banner", nil, true},
+		{`clusters."open`, nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePath(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePath(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePath(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		path    []string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{[]string{"tiers", "production", "blocked_actions"}, "delete, drain", []string{"delete", "drain"}, false},
+		{[]string{"tiers", "production", "blocked_actions"}, "", []string{}, false},
+		{[]string{"clusters", "my-prod", "tier"}, "production", "production", false},
+		{[]string{"defaults", "require_confirmation"}, "true", true, false},
+		{[]string{"defaults", "require_confirmation"}, "maybe", nil, true},
+		{[]string{"tiers", "production", "bannr"}, "true", nil, true},
+		{[]string{"tiers", "production"}, "x", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseValue(tt.path, tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseValue(%v, %q) error = %v, wantErr %v", tt.path, tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%v, %q) = %#v, want %#v", tt.path, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// activeProfile is the profile whose config file ConfigPath returns
+var activeProfile string
+
+// validProfile keeps profile names usable as file names
+var validProfile = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Meta holds settings about the config files themselves, kept in meta.yaml
+// next to them
+type Meta struct {
+	// Profile is used when neither --kctl-profile nor KCTL_PROFILE names one
+	Profile string `yaml:"profile,omitempty"`
+}
+
+// SetProfile makes ConfigPath return the config file of profile; "" selects
+// the default config.yaml
+func SetProfile(profile string) error {
+	if err := CheckProfileName(profile); err != nil {
+		return err
+	}
+	activeProfile = profile
+	return nil
+}
+
+// Profile returns the active profile, or "" for the default config
+func Profile() string {
+	return activeProfile
+}
+
+// CheckProfileName rejects profile names that aren't plain file names
+func CheckProfileName(profile string) error {
+	if profile != "" && !validProfile.MatchString(profile) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", profile)
+	}
+	return nil
+}
+
+// ProfilePath returns the config file of profile
+func ProfilePath(profile string) string {
+	return filepath.Join(ConfigDir(), "profiles", profile+".yaml")
+}
+
+// Profiles returns the names of the profiles that have a config file,
+// sorted
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(ConfigDir(), "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var profiles []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if ok && !e.IsDir() && validProfile.MatchString(name) {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// MetaPath returns the path of meta.yaml
+func MetaPath() string {
+	return filepath.Join(ConfigDir(), "meta.yaml")
+}
+
+// LoadMeta reads meta.yaml; without one, every setting is unset
+func LoadMeta() (Meta, error) {
+	var meta Meta
+	data, err := os.ReadFile(MetaPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, nil
+		}
+		return meta, err
+	}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return Meta{}, fmt.Errorf("%s: %w", MetaPath(), err)
+	}
+	return meta, CheckProfileName(meta.Profile)
+}
+
+// SaveMeta writes meta.yaml
+func SaveMeta(meta Meta) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ConfigDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(MetaPath(), data, 0644)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Cleanup(func() { activeProfile = "" })
+
+	if got := ConfigPath(); got != filepath.Join(dir, "kubectl-enhanced", "config.yaml") {
+		t.Errorf("ConfigPath without a profile = %s", got)
+	}
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConfigPath(); got != filepath.Join(dir, "kubectl-enhanced", "profiles", "work.yaml") {
+		t.Errorf("ConfigPath for profile work = %s", got)
+	}
+	for _, bad := range []string{"../work", "a/b", ".hidden"} {
+		if err := SetProfile(bad); err == nil {
+			t.Errorf("SetProfile(%q) accepted an invalid name", bad)
+		}
+	}
+	if Profile() != "work" {
+		t.Errorf("an invalid name changed the active profile to %q", Profile())
+	}
+
+	profiles := filepath.Join(dir, "kubectl-enhanced", "profiles")
+	os.MkdirAll(profiles, 0755)
+	for _, name := range []string{"work.yaml", "personal.yaml", "notes.txt"} {
+		os.WriteFile(filepath.Join(profiles, name), []byte("defaults: {}\n"), 0644)
+	}
+	if got, err := Profiles(); err != nil || !reflect.DeepEqual(got, []string{"personal", "work"}) {
+		t.Errorf("Profiles = %v, %v", got, err)
+	}
+
+	if meta, err := LoadMeta(); err != nil || meta.Profile != "" {
+		t.Errorf("LoadMeta without a file = %+v, %v", meta, err)
+	}
+	if err := SaveMeta(Meta{Profile: "personal"}); err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := LoadMeta(); err != nil || meta.Profile != "personal" {
+		t.Errorf("LoadMeta = %+v, %v; want profile personal", meta, err)
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry defaults: three attempts, waiting 500ms, then 1s
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = 500 * time.Millisecond
+	DefaultRetryMaxBackoff = 5 * time.Second
+)
+
+// RetryConfig controls retrying kubectl lookups (the current context,
+// dry runs, permission and identity checks) and read-only commands such as
+// get and describe when they fail to reach the API server, e.g. over a
+// flaky VPN
+type RetryConfig struct {
+	// Attempts is the most times a command runs. Default: 3; 1 disables
+	// retries
+	Attempts int `yaml:"attempts,omitempty"`
+	// Backoff is the wait before the first retry, doubling for each later
+	// one. Default: 500ms
+	Backoff string `yaml:"backoff,omitempty"`
+	// MaxBackoff caps the wait between retries. Default: 5s
+	MaxBackoff string `yaml:"max_backoff,omitempty"`
+	// ReachableAttempts is the most times kctl asks whether the API server
+	// answers before a gated command runs. Default: Attempts
+	ReachableAttempts int `yaml:"reachable_attempts,omitempty"`
+}
+
+// Policy returns the attempts and waits to use, with defaults for unset
+// settings
+func (r RetryConfig) Policy() (attempts int, backoff, maxBackoff time.Duration) {
+	attempts, backoff, maxBackoff = r.Attempts, DefaultRetryBackoff, DefaultRetryMaxBackoff
+	if attempts == 0 {
+		attempts = DefaultRetryAttempts
+	}
+	if d, err := time.ParseDuration(r.Backoff); err == nil {
+		backoff = d
+	}
+	if d, err := time.ParseDuration(r.MaxBackoff); err == nil {
+		maxBackoff = d
+	}
+	return attempts, backoff, maxBackoff
+}
+
+// ReachableTries returns the attempts of the check that the API server
+// answers, those of other lookups unless ReachableAttempts is set
+func (r RetryConfig) ReachableTries() int {
+	if r.ReachableAttempts > 0 {
+		return r.ReachableAttempts
+	}
+	attempts, _, _ := r.Policy()
+	return attempts
+}
+
+// mergeRetry takes each retry setting from local when set there, else from
+// base
+func mergeRetry(base, local RetryConfig) RetryConfig {
+	merged := local
+	if merged.Attempts == 0 {
+		merged.Attempts = base.Attempts
+	}
+	if merged.Backoff == "" {
+		merged.Backoff = base.Backoff
+	}
+	if merged.MaxBackoff == "" {
+		merged.MaxBackoff = base.MaxBackoff
+	}
+	if merged.ReachableAttempts == 0 {
+		merged.ReachableAttempts = base.ReachableAttempts
+	}
+	return merged
+}
+
+// checkRetry reports negative attempts and invalid waits
+func (c *Config) checkRetry() error {
+	if c.Retry.Attempts < 0 {
+		return fmt.Errorf("retry.attempts must be at least 1, got %d", c.Retry.Attempts)
+	}
+	if c.Retry.ReachableAttempts < 0 {
+		return fmt.Errorf("retry.reachable_attempts must be at least 1, got %d", c.Retry.ReachableAttempts)
+	}
+	for name, value := range map[string]string{"backoff": c.Retry.Backoff, "max_backoff": c.Retry.MaxBackoff} {
+		if d, err := time.ParseDuration(value); value != "" && (err != nil || d < 0) {
+			return fmt.Errorf("retry.%s: %q is not a duration such as 500ms", name, value)
+		}
+	}
+	return nil
+}
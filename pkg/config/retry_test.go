@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	attempts, backoff, maxBackoff := RetryConfig{}.Policy()
+	if attempts != DefaultRetryAttempts || backoff != DefaultRetryBackoff || maxBackoff != DefaultRetryMaxBackoff {
+		t.Errorf("default Policy = %d, %v, %v", attempts, backoff, maxBackoff)
+	}
+	attempts, backoff, maxBackoff = RetryConfig{Attempts: 1, Backoff: "2s", MaxBackoff: "10s"}.Policy()
+	if attempts != 1 || backoff != 2*time.Second || maxBackoff != 10*time.Second {
+		t.Errorf("Policy = %d, %v, %v", attempts, backoff, maxBackoff)
+	}
+
+	if got := (RetryConfig{Attempts: 5}).ReachableTries(); got != 5 {
+		t.Errorf("ReachableTries = %d, want the attempts of other lookups", got)
+	}
+	if got := (RetryConfig{Attempts: 5, ReachableAttempts: 1}).ReachableTries(); got != 1 {
+		t.Errorf("ReachableTries = %d, want reachable_attempts", got)
+	}
+
+	for _, bad := range []RetryConfig{{Attempts: -1}, {Backoff: "soon"}, {MaxBackoff: "-1s"}, {ReachableAttempts: -1}} {
+		cfg := &Config{Retry: bad}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate accepted retry %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SeverityLevels are the command severities, from least to most severe
+var SeverityLevels = []string{"none", "low", "medium", "high", "critical"}
+
+// SeverityConfig adjusts how severe commands are judged to be
+type SeverityConfig struct {
+	// Actions replace the built-in severity of actions, e.g. exec: high
+	Actions map[string]string `yaml:"actions,omitempty"`
+	// Kinds raise the severity of any command on a kind (singular, e.g.
+	// secret) by a number of levels, up to critical
+	Kinds map[string]int `yaml:"kinds,omitempty"`
+}
+
+// UnmarshalYAML rejects keys other than actions and kinds, so a table
+// written with actions directly under severity isn't silently ignored
+func (s *SeverityConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Value != "actions" && key.Value != "kinds" {
+				return fmt.Errorf("line %d: unknown severity key %q (use actions or kinds)", key.Line, key.Value)
+			}
+		}
+	}
+	type plain SeverityConfig
+	return node.Decode((*plain)(s))
+}
+
+// severityActions are what severity.actions can name: kubectl's own
+// commands, node-access and the shell plugins kctl knows (rbac.ShellPlugins)
+var severityActions = map[string]bool{
+	"annotate": true, "api-resources": true, "api-versions": true, "apply": true,
+	"attach": true, "auth": true, "autoscale": true, "certificate": true,
+	"cluster-info": true, "completion": true, "config": true, "cordon": true,
+	"cp": true, "create": true, "debug": true, "delete": true, "describe": true,
+	"diff": true, "drain": true, "edit": true, "events": true, "exec": true,
+	"explain": true, "expose": true, "get": true, "kustomize": true, "label": true,
+	"logs": true, "patch": true, "plugin": true, "port-forward": true, "proxy": true,
+	"replace": true, "rollout": true, "run": true, "scale": true, "set": true,
+	"taint": true, "top": true, "uncordon": true, "version": true, "wait": true,
+	"node-access": true, "node-shell": true, "ssh-jump": true, "nsenter": true, "exec-as": true,
+}
+
+// severityKind matches a kind as commands are matched against it:
+// lowercase and singular, e.g. secret
+var severityKind = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*$`)
+
+// SeverityRank returns the position of level in SeverityLevels, or -1 for
+// an unknown level
+func SeverityRank(level string) int {
+	for i, l := range SeverityLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// MatchesSeverity reports whether severity meets threshold: "high" matches
+// high only, "medium+" medium and above. An empty threshold matches nothing.
+func MatchesSeverity(threshold, severity string) bool {
+	if threshold == "" {
+		return false
+	}
+	level, orAbove := strings.CutSuffix(threshold, "+")
+	if orAbove {
+		return SeverityRank(severity) >= SeverityRank(level)
+	}
+	return severity == level
+}
+
+// checkSeverity reports unknown actions, kinds and severity levels in the
+// severity table and unknown levels in the rules' thresholds
+func (c *Config) checkSeverity() error {
+	validThreshold := func(t string) bool {
+		return t == "" || SeverityRank(strings.TrimSuffix(t, "+")) >= 0
+	}
+	for _, action := range sortedStrings(c.Severity.Actions) {
+		if !severityActions[action] {
+			return fmt.Errorf("severity.actions: unknown action %q", action)
+		}
+		if level := c.Severity.Actions[action]; SeverityRank(level) < 0 {
+			return fmt.Errorf("severity.actions.%s: unknown severity %q (use %s)", action, level, strings.Join(SeverityLevels, ", "))
+		}
+	}
+	for kind := range c.Severity.Kinds {
+		if !severityKind.MatchString(kind) {
+			return fmt.Errorf("severity.kinds: %q is not a kind (use the lowercase singular, e.g. secret)", kind)
+		}
+	}
+	for name, rules := range c.Clusters {
+		for _, t := range []string{rules.ConfirmSeverity, rules.BlockSeverity} {
+			if !validThreshold(t) {
+				return fmt.Errorf("cluster '%s': unknown severity %q", name, t)
+			}
+		}
+	}
+	for name, tier := range c.Tiers {
+		for _, t := range []string{tier.ConfirmSeverity, tier.BlockSeverity} {
+			if !validThreshold(t) {
+				return fmt.Errorf("tier '%s': unknown severity %q", name, t)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedStrings(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
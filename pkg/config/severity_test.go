@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMatchesSeverity(t *testing.T) {
+	tests := []struct {
+		threshold, severity string
+		want                bool
+	}{
+		{"medium+", "medium", true},
+		{"medium+", "critical", true},
+		{"medium+", "low", false},
+		{"high", "high", true},
+		{"high", "critical", false},
+		{"", "critical", false},
+	}
+	for _, tt := range tests {
+		if got := MatchesSeverity(tt.threshold, tt.severity); got != tt.want {
+			t.Errorf("MatchesSeverity(%q, %q) = %v, want %v", tt.threshold, tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_Severity(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Severity: SeverityConfig{Actions: map[string]string{"exec": "severe"}}}, "severity.actions.exec"},
+		{Config{Severity: SeverityConfig{Actions: map[string]string{"exce": "high"}}}, `unknown action "exce"`},
+		{Config{Severity: SeverityConfig{Kinds: map[string]int{"Secret": 1}}}, `"Secret" is not a kind`},
+		{Config{Tiers: map[string]TierConfig{"production": {ConfirmSeverity: "medium++"}}}, "tier 'production'"},
+		{Config{Clusters: map[string]ClusterRules{"prod": {BlockSeverity: "extreme"}}}, "cluster 'prod'"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate() = %v, want error containing %q", err, tt.want)
+		}
+	}
+
+	cfg := Config{Tiers: map[string]TierConfig{"production": {ConfirmSeverity: "medium+", BlockSeverity: "critical"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v for valid thresholds", err)
+	}
+}
+
+func TestSeverityConfig_UnknownKeys(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte("severity:\n  exec: high\n"), &cfg)
+	if err == nil || !strings.Contains(err.Error(), `unknown severity key "exec"`) {
+		t.Errorf("Unmarshal = %v, want the unknown key reported", err)
+	}
+	if err := yaml.Unmarshal([]byte("severity:\n  actions:\n    exec: high\n  kinds:\n    secret: 1\n"), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Severity.Actions["exec"] != "high" || cfg.Severity.Kinds["secret"] != 1 {
+		t.Errorf("Severity = %+v", cfg.Severity)
+	}
+}
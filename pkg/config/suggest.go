@@ -0,0 +1,49 @@
+package config
+
+import "strings"
+
+// DefaultSuggestions are the safer alternatives offered when an action is
+// blocked. Keys are "action kind" or just "action"; {name} is replaced with
+// the resource name from the blocked command.
+var DefaultSuggestions = map[string][]string{
+	"delete deployment":  {"scale deployment {name} --replicas=0", "rollout undo deployment {name}"},
+	"delete statefulset": {"scale statefulset {name} --replicas=0"},
+	"delete daemonset":   {"rollout undo daemonset {name}"},
+	"delete pod":         {"rollout restart deployment <owner>", "logs {name}"},
+	"delete job":         {"patch job {name} -p '{\"spec\":{\"suspend\":true}}'"},
+	"delete cronjob":     {"patch cronjob {name} -p '{\"spec\":{\"suspend\":true}}'"},
+	"drain":              {"cordon {name}"},
+	"edit":               {"diff -f <file>", "apply -f <file> from version control"},
+	"exec":               {"logs {name}", "debug {name} -it --image=busybox"},
+}
+
+// SuggestionsFor returns the alternatives to suggest when action on kind
+// (singular, e.g. "deployment"; may be empty) is blocked. The config's
+// suggestions replace the defaults key by key; an empty list turns a
+// default off.
+func (c *Config) SuggestionsFor(action, kind, name string) []string {
+	keys := []string{action}
+	if kind != "" {
+		keys = []string{action + " " + kind, action}
+	}
+
+	var found []string
+	for _, key := range keys {
+		if list, ok := c.Suggestions[key]; ok {
+			found = list
+			break
+		}
+		if list, ok := DefaultSuggestions[key]; ok {
+			found = list
+			break
+		}
+	}
+	if name == "" {
+		name = "<name>"
+	}
+	suggestions := make([]string, 0, len(found))
+	for _, s := range found {
+		suggestions = append(suggestions, strings.ReplaceAll(s, "{name}", name))
+	}
+	return suggestions
+}
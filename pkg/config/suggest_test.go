@@ -0,0 +1,30 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestionsFor(t *testing.T) {
+	cfg := &Config{Suggestions: map[string][]string{
+		"delete service": {"annotate service {name} kctl.io/retired=true"},
+		"drain":          {},
+	}}
+
+	tests := []struct {
+		action, kind, name string
+		want               []string
+	}{
+		{"delete", "deployment", "web", []string{"scale deployment web --replicas=0", "rollout undo deployment web"}},
+		{"delete", "deployment", "", []string{"scale deployment <name> --replicas=0", "rollout undo deployment <name>"}},
+		{"delete", "service", "api", []string{"annotate service api kctl.io/retired=true"}},
+		{"delete", "configmap", "x", []string{}},
+		{"drain", "", "node-1", []string{}},
+		{"exec", "", "web-1", []string{"logs web-1", "debug web-1 -it --image=busybox"}},
+	}
+	for _, tt := range tests {
+		if got := cfg.SuggestionsFor(tt.action, tt.kind, tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SuggestionsFor(%s, %s, %s) = %q, want %q", tt.action, tt.kind, tt.name, got, tt.want)
+		}
+	}
+}
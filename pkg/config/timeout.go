@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutFor returns how long a command of action may run under these
+// rules: its own entry in Timeouts, else the "*" entry. Zero means no limit.
+func (r ResolvedRules) TimeoutFor(action string) time.Duration {
+	for _, key := range []string{action, "*"} {
+		if value, ok := r.Timeouts[key]; ok {
+			d, _ := time.ParseDuration(value)
+			return d
+		}
+	}
+	return 0
+}
+
+// DeleteWait returns how long deletes wait for their objects to be gone;
+// zero when they don't
+func (r ResolvedRules) DeleteWait() time.Duration {
+	d, _ := time.ParseDuration(r.WaitForDeletes)
+	return d
+}
+
+// RolloutWait returns how long rollouts started by confirmed commands are
+// watched; zero when they aren't
+func (r ResolvedRules) RolloutWait() time.Duration {
+	d, _ := time.ParseDuration(r.WatchRollouts)
+	return d
+}
+
+// checkTimeouts reports timeouts, delete waits and rollout watches that
+// aren't positive durations
+func (c *Config) checkTimeouts() error {
+	check := func(timeouts map[string]string, wait, watch string) error {
+		for _, action := range sortedStrings(timeouts) {
+			if d, err := time.ParseDuration(timeouts[action]); err != nil || d <= 0 {
+				return fmt.Errorf("timeouts.%s: %q is not a duration such as 30m", action, timeouts[action])
+			}
+		}
+		if d, err := time.ParseDuration(wait); wait != "" && (err != nil || d <= 0) {
+			return fmt.Errorf("wait_for_deletes: %q is not a duration such as 5m", wait)
+		}
+		if d, err := time.ParseDuration(watch); watch != "" && (err != nil || d <= 0) {
+			return fmt.Errorf("watch_rollouts: %q is not a duration such as 5m", watch)
+		}
+		return nil
+	}
+	for name, rules := range c.Clusters {
+		if err := check(rules.Timeouts, rules.WaitForDeletes, rules.WatchRollouts); err != nil {
+			return fmt.Errorf("cluster '%s': %w", name, err)
+		}
+	}
+	for name, tier := range c.Tiers {
+		if err := check(tier.Timeouts, tier.WaitForDeletes, tier.WatchRollouts); err != nil {
+			return fmt.Errorf("tier '%s': %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutFor(t *testing.T) {
+	cfg := &Config{Tiers: map[string]TierConfig{
+		"production": {
+			Patterns: []string{"*-prod"},
+			Timeouts: map[string]string{"exec": "30m", "*": "2h"},
+		},
+		"payments": {
+			Inherits: "production",
+			Patterns: []string{"payments-*"},
+			Timeouts: map[string]string{"apply": "10m"},
+		},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		context, action string
+		want            time.Duration
+	}{
+		{"app-prod", "exec", 30 * time.Minute},
+		{"app-prod", "get", 2 * time.Hour},
+		{"payments-eu", "apply", 10 * time.Minute},
+		{"payments-eu", "exec", 30 * time.Minute}, // inherited
+		{"kind-dev", "exec", 0},
+	}
+	for _, tt := range tests {
+		if got := cfg.GetClusterRules(tt.context).TimeoutFor(tt.action); got != tt.want {
+			t.Errorf("TimeoutFor(%s on %s) = %v, want %v", tt.action, tt.context, got, tt.want)
+		}
+	}
+
+	cfg.Tiers["staging"] = TierConfig{Timeouts: map[string]string{"exec": "forever"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted an invalid timeout")
+	}
+}
+
+func TestDeleteWait(t *testing.T) {
+	cfg := &Config{Tiers: map[string]TierConfig{
+		"production": {Patterns: []string{"*-prod"}, WaitForDeletes: "5m"},
+		"payments":   {Inherits: "production", Patterns: []string{"payments-*"}},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for context, want := range map[string]time.Duration{"app-prod": 5 * time.Minute, "payments-eu": 5 * time.Minute, "kind-dev": 0} {
+		if got := cfg.GetClusterRules(context).DeleteWait(); got != want {
+			t.Errorf("DeleteWait on %s = %v, want %v", context, got, want)
+		}
+	}
+
+	cfg.Tiers["staging"] = TierConfig{WaitForDeletes: "yes"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted an invalid wait_for_deletes")
+	}
+}
+
+func TestRolloutWait(t *testing.T) {
+	cfg := &Config{Tiers: map[string]TierConfig{
+		"production": {Patterns: []string{"*-prod"}, WatchRollouts: "10m"},
+		"payments":   {Inherits: "production", Patterns: []string{"payments-*"}, WatchRollouts: "2m"},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for context, want := range map[string]time.Duration{"app-prod": 10 * time.Minute, "payments-eu": 2 * time.Minute, "kind-dev": 0} {
+		if got := cfg.GetClusterRules(context).RolloutWait(); got != want {
+			t.Errorf("RolloutWait on %s = %v, want %v", context, got, want)
+		}
+	}
+
+	cfg.Tiers["staging"] = TierConfig{WatchRollouts: "-1m"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted an invalid watch_rollouts")
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import "slices"
+
+// DefaultUICommand is the tool 'kctl ui' launches
+const DefaultUICommand = "k9s"
+
+// UIConfig configures the terminal UI 'kctl ui' launches
+type UIConfig struct {
+	// Command is the tool to run. Default: k9s
+	Command string `yaml:"command,omitempty"`
+	// Args are passed to every session
+	Args []string `yaml:"args,omitempty"`
+	// ContextFlag selects the context. Default: --context
+	ContextFlag string `yaml:"context_flag,omitempty"`
+	// ReadOnlyFlags make a session read-only. Default: --readonly for k9s;
+	// other tools must set them to be launched on read-only tiers
+	ReadOnlyFlags []string `yaml:"readonly_flags,omitempty"`
+	// ReadOnlyTiers get read-only sessions. Default: production and the
+	// tiers inheriting from it
+	ReadOnlyTiers []string `yaml:"readonly_tiers,omitempty"`
+}
+
+// writeFlags are k9s flags that undo --readonly; read-only sessions drop
+// them from ui.args
+var writeFlags = []string{"--write", "--write=true"}
+
+// UIArgs returns the command line of a session on context: the command
+// followed by its arguments. ok is false for a read-only session of a tool
+// with no read-only flags known.
+func (u UIConfig) UIArgs(context string, readOnly bool) (argv []string, ok bool) {
+	command, contextFlag, readOnlyFlags := u.Command, u.ContextFlag, u.ReadOnlyFlags
+	if command == "" {
+		command = DefaultUICommand
+	}
+	if contextFlag == "" {
+		contextFlag = "--context"
+	}
+	if len(readOnlyFlags) == 0 && command == DefaultUICommand {
+		readOnlyFlags = []string{"--readonly"}
+	}
+	if readOnly && len(readOnlyFlags) == 0 {
+		return nil, false
+	}
+
+	argv = []string{command, contextFlag, context}
+	for _, arg := range u.Args {
+		if readOnly && command == DefaultUICommand && slices.Contains(writeFlags, arg) {
+			continue
+		}
+		argv = append(argv, arg)
+	}
+	if readOnly {
+		argv = append(argv, readOnlyFlags...)
+	}
+	return argv, true
+}
+
+// mergeUI takes each ui setting from local when set there, else from base.
+// Read-only tiers are combined, so a local file can't make a session
+// read-write that the shared one makes read-only.
+func mergeUI(base, local UIConfig) UIConfig {
+	merged := local
+	if merged.Command == "" {
+		merged.Command = base.Command
+	}
+	if merged.Args == nil {
+		merged.Args = base.Args
+	}
+	if merged.ContextFlag == "" {
+		merged.ContextFlag = base.ContextFlag
+	}
+	if merged.ReadOnlyFlags == nil {
+		merged.ReadOnlyFlags = base.ReadOnlyFlags
+	}
+	if len(local.ReadOnlyTiers) > 0 {
+		shared := base.ReadOnlyTiers
+		if len(shared) == 0 {
+			shared = []string{"production"}
+		}
+		merged.ReadOnlyTiers = appendMissing(appendMissing([]string{}, shared), local.ReadOnlyTiers)
+	} else {
+		merged.ReadOnlyTiers = base.ReadOnlyTiers
+	}
+	return merged
+}
+
+// UIReadOnly reports whether 'kctl ui' sessions on clusters of tier are
+// read-only
+func (c *Config) UIReadOnly(tier string) bool {
+	readOnly := c.UI.ReadOnlyTiers
+	if len(readOnly) == 0 {
+		readOnly = []string{"production"}
+	}
+	// A cluster entry can name a tier that has no tier section
+	for _, name := range append([]string{tier}, c.tierChain(tier)...) {
+		if slices.Contains(readOnly, name) {
+			return true
+		}
+	}
+	return false
+}
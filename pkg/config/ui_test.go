@@ -0,0 +1,67 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUIArgs(t *testing.T) {
+	argv, ok := UIConfig{}.UIArgs("app-prod", true)
+	if want := []string{"k9s", "--context", "app-prod", "--readonly"}; !ok || !reflect.DeepEqual(argv, want) {
+		t.Errorf("default read-only UIArgs = %v, %v, want %v", argv, ok, want)
+	}
+	argv, _ = UIConfig{Args: []string{"--headless"}}.UIArgs("kind-dev", false)
+	if want := []string{"k9s", "--context", "kind-dev", "--headless"}; !reflect.DeepEqual(argv, want) {
+		t.Errorf("read-write UIArgs = %v, want %v", argv, want)
+	}
+
+	argv, _ = UIConfig{Args: []string{"--write", "--headless"}}.UIArgs("app-prod", true)
+	if want := []string{"k9s", "--context", "app-prod", "--headless", "--readonly"}; !reflect.DeepEqual(argv, want) {
+		t.Errorf("read-only UIArgs = %v, want %v (without --write)", argv, want)
+	}
+
+	other := UIConfig{Command: "kdash", ContextFlag: "--ctx"}
+	if _, ok := other.UIArgs("app-prod", true); ok {
+		t.Error("a tool without read-only flags was launched read-only")
+	}
+	other.ReadOnlyFlags = []string{"--view-only"}
+	argv, ok = other.UIArgs("app-prod", true)
+	if want := []string{"kdash", "--ctx", "app-prod", "--view-only"}; !ok || !reflect.DeepEqual(argv, want) {
+		t.Errorf("UIArgs = %v, %v, want %v", argv, ok, want)
+	}
+}
+
+func TestUIReadOnly(t *testing.T) {
+	cfg := &Config{Tiers: map[string]TierConfig{
+		"production": {Patterns: []string{"*-prod"}},
+		"prod-eu":    {Inherits: "production"},
+		"staging":    {Patterns: []string{"*-stg"}},
+	}}
+	for tier, want := range map[string]bool{"production": true, "prod-eu": true, "staging": false, "default": false} {
+		if got := cfg.UIReadOnly(tier); got != want {
+			t.Errorf("UIReadOnly(%q) = %v, want %v", tier, got, want)
+		}
+	}
+
+	cfg.UI.ReadOnlyTiers = []string{"staging"}
+	if !cfg.UIReadOnly("staging") || cfg.UIReadOnly("production") {
+		t.Error("readonly_tiers should replace the default")
+	}
+}
+
+func TestMerge_UI(t *testing.T) {
+	base := &Config{UI: UIConfig{Args: []string{"--headless"}}}
+	merged := Merge(base, &Config{UI: UIConfig{ReadOnlyTiers: []string{"staging"}}})
+	if want := []string{"--headless"}; !reflect.DeepEqual(merged.UI.Args, want) {
+		t.Errorf("Args = %v, want %v", merged.UI.Args, want)
+	}
+	if !merged.UIReadOnly("staging") || !merged.UIReadOnly("production") {
+		t.Errorf("ReadOnlyTiers = %v, want the shared default and staging", merged.UI.ReadOnlyTiers)
+	}
+
+	base.UI.ReadOnlyTiers = []string{"production", "staging"}
+	merged = Merge(base, &Config{UI: UIConfig{Command: "k9s", ReadOnlyTiers: []string{"qa"}}})
+	if want := []string{"production", "staging", "qa"}; !reflect.DeepEqual(merged.UI.ReadOnlyTiers, want) {
+		t.Errorf("ReadOnlyTiers = %v, want %v", merged.UI.ReadOnlyTiers, want)
+	}
+}
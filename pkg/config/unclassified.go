@@ -0,0 +1,15 @@
+package config
+
+import "strings"
+
+// Unclassified reports whether no cluster entry, tier or in-cluster
+// declaration covers context, leaving it with the defaults
+func (c *Config) Unclassified(context string) bool {
+	return c.matchRules(context).Tier == "default"
+}
+
+// LooksProduction reports whether a context's name suggests a production
+// cluster
+func LooksProduction(context string) bool {
+	return strings.Contains(strings.ToLower(context), "prod")
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchInterval is how often Run syncs, or looks at the config files
+// when they can't be watched
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher keeps the rules of a long-running command current: when one of
+// the files the config was read from changes, it loads the config again.
+// A config that fails to load leaves the previous one in place.
+type Watcher struct {
+	// Load reads the config
+	Load func() (*Config, error)
+	// Files returns the files the config is read from; a file appearing,
+	// disappearing or being modified counts as a change
+	Files func() []string
+	// Sync, when set, runs before each check, e.g. to pull a shared policy
+	// repository so its changes show up in Files
+	Sync func()
+
+	mu     sync.RWMutex
+	cfg    *Config
+	stamps map[string]fileStamp
+}
+
+// fileStamp is what Watcher compares to notice a change
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// NewWatcher returns a Watcher serving cfg, the config just read from files
+func NewWatcher(cfg *Config, load func() (*Config, error), files func() []string) *Watcher {
+	w := &Watcher{Load: load, Files: files, cfg: cfg}
+	w.stamps = w.stat()
+	return w
+}
+
+// Config returns the current config
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cfg
+}
+
+// Check loads the config again if its files changed since the last check.
+// It reports whether the config was replaced; on a load error the previous
+// config stays and the error is returned, and the same files aren't
+// retried until they change again.
+func (w *Watcher) Check() (bool, error) {
+	if w.Sync != nil {
+		w.Sync()
+	}
+	stamps := w.stat()
+	w.mu.RLock()
+	changed := !sameStamps(stamps, w.stamps)
+	w.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cfg, err := w.Load()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stamps = stamps
+	if err != nil {
+		return false, err
+	}
+	w.cfg = cfg
+	return true, nil
+}
+
+// settleDelay is how long Run waits after a file event before checking,
+// so an editor's write, rename and chmod make one reload
+const settleDelay = 100 * time.Millisecond
+
+// Run watches the directories of the config files until stop is closed,
+// checking once events on the files settle, and calls report with the
+// outcome of each check that found a change. With Sync set it also checks
+// every interval, as a remote policy changes without file events; when the
+// files can't be watched it falls back to checking every interval.
+func (w *Watcher) Run(interval time.Duration, stop <-chan struct{}, report func(reloaded bool, err error)) {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	fsw, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer fsw.Close()
+		w.watchDirs(fsw)
+		events, errs = fsw.Events, fsw.Errors
+	}
+	check := func() {
+		if reloaded, err := w.Check(); reloaded || err != nil {
+			report(reloaded, err)
+		}
+		// The files may have moved, e.g. to a newly synced policy
+		if fsw != nil {
+			w.watchDirs(fsw)
+		}
+	}
+	var tick <-chan time.Time
+	if err != nil || w.Sync != nil {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	settle := time.NewTimer(settleDelay)
+	settle.Stop()
+	defer settle.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick:
+			check()
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if w.watches(event.Name) {
+				settle.Reset(settleDelay)
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			// Events may have been dropped; look at the files directly
+			check()
+		case <-settle.C:
+			check()
+		}
+	}
+}
+
+// watchDirs watches the directories of the config files. Directories are
+// watched rather than files, so a file replaced by a rename, as editors
+// save, or created later is still seen.
+func (w *Watcher) watchDirs(fsw *fsnotify.Watcher) {
+	for _, path := range w.Files() {
+		// A directory that doesn't exist yet can't be watched; it is
+		// added at a later check
+		_ = fsw.Add(filepath.Dir(path))
+	}
+}
+
+// watches reports whether name is one of the config files
+func (w *Watcher) watches(name string) bool {
+	for _, path := range w.Files() {
+		if filepath.Clean(path) == filepath.Clean(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) stat() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, path := range w.Files() {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+		} else {
+			stamps[path] = fileStamp{}
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size || other.exists != stamp.exists {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// Set the time explicitly; file systems may not tell apart two
+		// writes within the same tick
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("defaults:\n  require_confirmation: true\n", start)
+
+	load := func() (*Config, error) { return LoadFromPath(path) }
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(cfg, load, func() []string { return []string{path} })
+
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		t.Errorf("Check without a change = %v, %v", reloaded, err)
+	}
+
+	write("defaults:\n  require_confirmation: false\n", start.Add(time.Minute))
+	if reloaded, err := w.Check(); !reloaded || err != nil {
+		t.Fatalf("Check after a change = %v, %v", reloaded, err)
+	}
+	if w.Config().Defaults.RequireConfirmation {
+		t.Error("the reloaded config still has the old rules")
+	}
+
+	// A broken config keeps the last good one, and isn't retried until it
+	// changes again
+	write("defaults: [", start.Add(2*time.Minute))
+	if reloaded, err := w.Check(); reloaded || err == nil {
+		t.Errorf("Check of a broken config = %v, %v; want an error", reloaded, err)
+	}
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		t.Errorf("second Check of the broken config = %v, %v; want no change", reloaded, err)
+	}
+	if w.Config() == nil || w.Config().Defaults.RequireConfirmation {
+		t.Error("a broken config replaced the last good one")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err == nil {
+		t.Error("Check after the file was removed should try to load it")
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("defaults:\n  require_confirmation: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	load := func() (*Config, error) { return LoadFromPath(path) }
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(cfg, load, func() []string { return []string{path} })
+
+	reloads := make(chan error, 10)
+	stop := make(chan struct{})
+	defer close(stop)
+	// Without Sync nothing is polled; only file events lead to a check
+	go w.Run(time.Hour, stop, func(reloaded bool, err error) { reloads <- err })
+	time.Sleep(100 * time.Millisecond)
+
+	// Saved the way editors do: written elsewhere and renamed over it
+	tmp := filepath.Join(dir, ".config.yaml.swp")
+	if err := os.WriteFile(tmp, []byte("defaults:\n  require_confirmation: false\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		if err != nil {
+			t.Fatalf("reload failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change wasn't noticed")
+	}
+	if w.Config().Defaults.RequireConfirmation {
+		t.Error("the reloaded config still has the old rules")
+	}
+}
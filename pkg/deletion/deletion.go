@@ -0,0 +1,220 @@
+// Package deletion finds what a delete would wait on or take with it: the
+// finalizers that hold its targets, the owners that may recreate them and
+// the objects they own, which are deleted too. While it runs, Remaining
+// tells what is left.
+package deletion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// childResources are the resources the built-in controllers create for
+// each kind, which a cascading delete removes with it
+var childResources = map[string]string{
+	"Deployment":  "replicasets",
+	"ReplicaSet":  "pods",
+	"StatefulSet": "pods",
+	"DaemonSet":   "pods",
+	"Job":         "pods",
+	"CronJob":     "jobs",
+}
+
+// cascadeFinalizers are added by the API server for the delete's own
+// --cascade mode; they don't hold an object the way a controller's do
+var cascadeFinalizers = map[string]bool{"orphan": true, "foregroundDeletion": true}
+
+// Target is an object a delete removes
+type Target struct {
+	Kind      string
+	Name      string
+	Namespace string
+	UID       string
+	// Finalizers must be removed by their controllers before the object
+	// is gone
+	Finalizers []string
+	// Owners are the objects that own this one, as Kind/name
+	Owners []string
+	// Children is how many objects of ChildResource it owns
+	Children      int
+	ChildResource string
+}
+
+// String names the target, e.g. "Deployment shop/web"
+func (t Target) String() string {
+	if t.Namespace == "" {
+		return t.Kind + " " + t.Name
+	}
+	return fmt.Sprintf("%s %s/%s", t.Kind, t.Namespace, t.Name)
+}
+
+// object is the part of an object Inspect reads
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name            string   `json:"name"`
+		Namespace       string   `json:"namespace"`
+		UID             string   `json:"uid"`
+		Finalizers      []string `json:"finalizers"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Items []object `json:"items"`
+}
+
+// target returns o as a Target with the finalizers that hold it
+func (o object) target() Target {
+	t := Target{Kind: o.Kind, Name: o.Metadata.Name, Namespace: o.Metadata.Namespace, UID: o.Metadata.UID}
+	for _, f := range o.Metadata.Finalizers {
+		if !cascadeFinalizers[f] {
+			t.Finalizers = append(t.Finalizers, f)
+		}
+	}
+	return t
+}
+
+// Inspect finds the objects the delete in args removes on context, reading
+// them with 'kubectl get', and for those the built-in controllers own
+// objects for, counts them. Children aren't counted for --cascade=orphan,
+// which leaves them behind.
+func Inspect(context string, args []string) ([]Target, error) {
+	stdout, stderr, exitCode := kubectl.Run(preview.GetArgs(context, args, "json"))
+	if exitCode != 0 {
+		return nil, fmt.Errorf("reading what the delete removes failed: %s", strings.TrimSpace(stderr))
+	}
+	objects, err := parseObjects(stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, o := range objects {
+		t := o.target()
+		for _, ref := range o.Metadata.OwnerReferences {
+			t.Owners = append(t.Owners, ref.Kind+"/"+ref.Name)
+		}
+		targets = append(targets, t)
+	}
+
+	if cascade, _ := rbac.FlagValue(args, "--cascade"); cascade != "orphan" && cascade != "false" {
+		countChildren(context, targets)
+	}
+	return targets, nil
+}
+
+// Owning returns the targets owning at least limit objects; none when
+// limit isn't positive
+func Owning(targets []Target, limit int) []Target {
+	if limit <= 0 {
+		return nil
+	}
+	var owning []Target
+	for _, t := range targets {
+		if t.Children >= limit {
+			owning = append(owning, t)
+		}
+	}
+	return owning
+}
+
+// countChildren sets the children of targets whose kind has childResources,
+// listing each resource once per namespace
+func countChildren(context string, targets []Target) {
+	type list struct{ resource, namespace string }
+	counts := make(map[list]map[string]int)
+	for i, t := range targets {
+		resource, ok := childResources[t.Kind]
+		if !ok || t.UID == "" {
+			continue
+		}
+		l := list{resource, t.Namespace}
+		if _, listed := counts[l]; !listed {
+			counts[l] = ownerUIDs(context, resource, t.Namespace)
+		}
+		targets[i].Children = counts[l][t.UID]
+		targets[i].ChildResource = resource
+	}
+}
+
+// ownerUIDs counts the objects of resource in namespace by the UID of each
+// of their owners. A failed listing counts nothing.
+func ownerUIDs(context, resource, namespace string) map[string]int {
+	args := []string{"--context", context, "--request-timeout=10s", "get", resource,
+		"-o", `jsonpath={range .items[*]}{range .metadata.ownerReferences[*]}{.uid}{"\n"}{end}{end}`}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	stdout, _, exitCode := kubectl.Run(args)
+	counts := make(map[string]int)
+	if exitCode != 0 {
+		return counts
+	}
+	for _, uid := range strings.Fields(stdout) {
+		counts[uid]++
+	}
+	return counts
+}
+
+// parseObjects reads the objects kubectl prints: one object, several
+// objects one after another, or a List
+func parseObjects(data string) ([]object, error) {
+	var objects []object
+	decoder := json.NewDecoder(strings.NewReader(data))
+	for {
+		var o object
+		err := decoder.Decode(&o)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading the objects: %w", err)
+		}
+		if strings.HasSuffix(o.Kind, "List") {
+			objects = append(objects, o.Items...)
+		} else if o.Kind != "" {
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+// Remaining returns the targets that still exist on context, with their
+// current finalizers, fetching each namespace's targets together
+func Remaining(context string, targets []Target) ([]Target, error) {
+	var namespaces []string
+	byNamespace := make(map[string][]string)
+	for _, t := range targets {
+		if _, seen := byNamespace[t.Namespace]; !seen {
+			namespaces = append(namespaces, t.Namespace)
+		}
+		byNamespace[t.Namespace] = append(byNamespace[t.Namespace], strings.ToLower(t.Kind)+"/"+t.Name)
+	}
+
+	var remaining []Target
+	for _, namespace := range namespaces {
+		args := append([]string{"--context", context, "--request-timeout=10s", "get"}, byNamespace[namespace]...)
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		}
+		stdout, stderr, exitCode := kubectl.Run(append(args, "--ignore-not-found", "-o", "json"))
+		if exitCode != 0 {
+			return nil, fmt.Errorf("checking what is left failed: %s", strings.TrimSpace(stderr))
+		}
+		objects, err := parseObjects(stdout)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objects {
+			remaining = append(remaining, o.target())
+		}
+	}
+	return remaining, nil
+}
@@ -0,0 +1,94 @@
+package deletion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestInspect(t *testing.T) {
+	var listed []string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.Contains(command, "--dry-run=server"):
+			// kubectl delete prints only names, whatever -o asks for
+			return "deployment.apps \"web\" deleted (server dry run)\n", "", 0
+		case strings.Contains(command, "app-prod get -f app.yaml --ignore-not-found -o json"):
+			return `{"kind":"Deployment","metadata":{"name":"web","namespace":"shop","uid":"d1"}}
+{"kind":"Pod","metadata":{"name":"web-1","namespace":"shop","uid":"p1",
+  "finalizers":["foregroundDeletion","example.com/cleanup"],
+  "ownerReferences":[{"kind":"ReplicaSet","name":"web-5d8f","uid":"r1"}]}}
+{"kind":"List","items":[{"kind":"Deployment","metadata":{"name":"api","namespace":"shop","uid":"d2"}}]}`, "", 0
+		case strings.Contains(command, "get replicasets"):
+			listed = append(listed, command)
+			return "d1\nd1\nd1\nd2\nother\n", "", 0
+		}
+		t.Errorf("unexpected kubectl %s", command)
+		return "", "", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	targets, err := Inspect("app-prod", []string{"delete", "-f", "app.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{Kind: "Deployment", Name: "web", Namespace: "shop", UID: "d1", Children: 3, ChildResource: "replicasets"},
+		{Kind: "Pod", Name: "web-1", Namespace: "shop", UID: "p1", Finalizers: []string{"example.com/cleanup"}, Owners: []string{"ReplicaSet/web-5d8f"}},
+		{Kind: "Deployment", Name: "api", Namespace: "shop", UID: "d2", Children: 1, ChildResource: "replicasets"},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("Inspect = %+v, want %+v", targets, want)
+	}
+	if len(listed) != 1 || !strings.Contains(listed[0], "-n shop") {
+		t.Errorf("listed %v, want replicasets in shop once", listed)
+	}
+	if got := Owning(targets, 3); len(got) != 1 || got[0].Name != "web" {
+		t.Errorf("Owning(3) = %v, want web", got)
+	}
+	if got := Owning(targets, 0); got != nil {
+		t.Errorf("Owning(0) = %v, want nil", got)
+	}
+
+	// Orphaned children stay, so they aren't counted
+	listed = nil
+	targets, err = Inspect("app-prod", []string{"delete", "-f", "app.yaml", "--cascade=orphan"})
+	if err != nil || targets[0].Children != 0 || listed != nil {
+		t.Errorf("Inspect with --cascade=orphan = %+v, %v after listing %v", targets, err, listed)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	var commands []string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		command := strings.Join(args, " ")
+		commands = append(commands, command)
+		if strings.Contains(command, "-n shop") {
+			return `{"kind":"List","items":[{"kind":"Pod","metadata":{"name":"web-1","namespace":"shop","finalizers":["example.com/cleanup"]}}]}`, "", 0
+		}
+		return "", "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	targets := []Target{
+		{Kind: "Pod", Name: "web-1", Namespace: "shop"},
+		{Kind: "Pod", Name: "web-2", Namespace: "shop"},
+		{Kind: "PersistentVolume", Name: "pv-1"},
+	}
+	remaining, err := Remaining("app-prod", targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{{Kind: "Pod", Name: "web-1", Namespace: "shop", Finalizers: []string{"example.com/cleanup"}}}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("Remaining = %+v, want %+v", remaining, want)
+	}
+	if len(commands) != 2 || !strings.Contains(commands[0], "get pod/web-1 pod/web-2 -n shop --ignore-not-found") {
+		t.Errorf("ran %v, want one get per namespace", commands)
+	}
+}
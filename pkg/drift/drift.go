@@ -0,0 +1,74 @@
+// Package drift notices kubeconfig contexts that no cluster entry or tier
+// covers, which would otherwise quietly get the default rules
+package drift
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// CheckInterval is how often the kubeconfig's contexts are listed again
+const CheckInterval = time.Hour
+
+// listContexts is replaced in tests
+var listContexts = kubectl.GetAllContexts
+
+// StatePath is where the last check and the contexts already warned about
+// are recorded
+func StatePath() string {
+	return filepath.Join(config.DataDir(), "drift.json")
+}
+
+// state is the record kept at StatePath
+type state struct {
+	CheckedAt time.Time            `json:"checked_at"`
+	Warned    map[string]time.Time `json:"warned,omitempty"`
+}
+
+// Unwarned returns the kubeconfig contexts cfg leaves unclassified that
+// haven't been reported before, and records them as reported. The contexts
+// are listed at most once per CheckInterval. Tiers clusters declare for
+// themselves aren't looked up, as that would ask every cluster.
+func Unwarned(cfg *config.Config, path string, now time.Time) []string {
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return nil
+	}
+	defer unlock()
+
+	var s state
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &s)
+	}
+	if now.Sub(s.CheckedAt) < CheckInterval {
+		return nil
+	}
+	s.CheckedAt = now
+	if s.Warned == nil {
+		s.Warned = make(map[string]time.Time)
+	}
+
+	var found []string
+	if contexts, err := listContexts(); err == nil {
+		probe := *cfg
+		probe.TierLookup = nil
+		for _, context := range contexts {
+			if _, warned := s.Warned[context]; !warned && probe.Unclassified(context) {
+				found = append(found, context)
+				s.Warned[context] = now
+			}
+		}
+	}
+	if data, err := json.Marshal(s); err == nil {
+		_ = statefile.WriteFile(path, data, 0600)
+	}
+	sort.Strings(found)
+	return found
+}
@@ -0,0 +1,91 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestUnwarned(t *testing.T) {
+	contexts := []string{"app-prod", "payments", "kind-dev", "billing"}
+	calls := 0
+	previous := listContexts
+	listContexts = func() ([]string, error) {
+		calls++
+		return contexts, nil
+	}
+	t.Cleanup(func() { listContexts = previous })
+
+	cfg := config.Default()
+	cfg.TierLookup = func(string) (string, error) {
+		t.Error("Unwarned asked a cluster for its tier")
+		return "", nil
+	}
+	path := filepath.Join(t.TempDir(), "drift.json")
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if got, want := Unwarned(cfg, path, start), []string{"billing", "payments"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unwarned = %v, want %v", got, want)
+	}
+	// Within CheckInterval the kubeconfig isn't read again
+	if got := Unwarned(cfg, path, start.Add(time.Minute)); got != nil || calls != 1 {
+		t.Errorf("Unwarned within the interval = %v after %d listings", got, calls)
+	}
+
+	// Later, only contexts not warned about before are reported
+	contexts = append(contexts, "new-cluster")
+	if got, want := Unwarned(cfg, path, start.Add(CheckInterval)), []string{"new-cluster"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unwarned later = %v, want %v", got, want)
+	}
+}
+
+func TestKubeconfigChanges(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "config")
+	statePath := filepath.Join(dir, "kubeconfig.json")
+	view := `{"clusters":[{"name":"staging","cluster":{"server":"https://staging:6443"}}],
+		"contexts":[{"name":"staging","context":{"cluster":"staging","user":"dev"}}],
+		"users":[{"name":"dev"}]}`
+	views := 0
+	previous := viewKubeconfig
+	viewKubeconfig = func() (string, error) {
+		views++
+		return view, nil
+	}
+	t.Cleanup(func() { viewKubeconfig = previous })
+	write := func(content string) {
+		if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first run only records the kubeconfig
+	write("v1")
+	if got := KubeconfigChanges([]string{kubeconfig}, statePath); got != nil {
+		t.Errorf("first KubeconfigChanges = %v, want nil", got)
+	}
+	// An unchanged file isn't read again
+	if got := KubeconfigChanges([]string{kubeconfig}, statePath); got != nil || views != 1 {
+		t.Errorf("unchanged KubeconfigChanges = %v after %d views", got, views)
+	}
+
+	write("v2")
+	view = `{"clusters":[{"name":"staging","cluster":{"server":"https://prod:6443"}},{"name":"new","cluster":{"server":"https://new:6443"}}],
+		"contexts":[{"name":"staging","context":{"cluster":"staging"}},{"name":"new","context":{"cluster":"new"}}],
+		"users":[{"name":"dev"},{"name":"admin"}]}`
+	want := []Change{
+		{Kind: NewContext, Name: "new", New: "https://new:6443"},
+		{Kind: ServerChanged, Name: "staging", Old: "https://staging:6443", New: "https://prod:6443"},
+		{Kind: NewUser, Name: "admin"},
+	}
+	if got := KubeconfigChanges([]string{kubeconfig}, statePath); !reflect.DeepEqual(got, want) {
+		t.Errorf("KubeconfigChanges = %v, want %v", got, want)
+	}
+	if got := KubeconfigChanges([]string{kubeconfig}, statePath); got != nil {
+		t.Errorf("KubeconfigChanges after reporting = %v, want nil", got)
+	}
+}
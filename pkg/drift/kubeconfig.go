@@ -0,0 +1,184 @@
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// viewKubeconfig is replaced in tests
+var viewKubeconfig = func() (string, error) {
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput([]string{"config", "view", "-o", "json"})
+	if exitCode != 0 {
+		return "", &kubectl.ContextError{Message: strings.TrimSpace(stderr)}
+	}
+	return stdout, nil
+}
+
+// KubeconfigStatePath is where the kubeconfig's hash and the contexts, users
+// and servers it last held are recorded
+func KubeconfigStatePath() string {
+	return filepath.Join(config.DataDir(), "kubeconfig.json")
+}
+
+// Change kinds
+const (
+	NewContext    = "context"
+	NewUser       = "user"
+	ServerChanged = "server"
+)
+
+// Change is a kubeconfig change worth a warning
+type Change struct {
+	Kind string
+	// Name is the context or user
+	Name string
+	// Old and New are a context's server URLs, for a ServerChanged
+	Old, New string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case NewContext:
+		return fmt.Sprintf("Context '%s' was added to the kubeconfig (server %s)", c.Name, c.New)
+	case NewUser:
+		return fmt.Sprintf("User '%s' was added to the kubeconfig", c.Name)
+	}
+	return fmt.Sprintf("Context '%s' now points at %s instead of %s; commands for it go to a different cluster", c.Name, c.New, c.Old)
+}
+
+// kubeconfigState is the record kept at KubeconfigStatePath
+type kubeconfigState struct {
+	Hash string `json:"hash"`
+	// Servers maps each context to its cluster's server URL
+	Servers map[string]string `json:"servers"`
+	Users   []string          `json:"users"`
+}
+
+// KubeconfigChanges hashes the kubeconfig files at paths and, when they
+// changed since the last call, returns the contexts and users added and the
+// contexts whose server URL changed. The first call only records what the
+// kubeconfig holds.
+func KubeconfigChanges(paths []string, statePath string) []Change {
+	unlock, err := statefile.Lock(statePath)
+	if err != nil {
+		return nil
+	}
+	defer unlock()
+
+	var previous kubeconfigState
+	if data, err := os.ReadFile(statePath); err == nil {
+		_ = json.Unmarshal(data, &previous)
+	}
+	hash := hashFiles(paths)
+	if hash == previous.Hash {
+		return nil
+	}
+	current, err := readKubeconfig()
+	if err != nil {
+		return nil
+	}
+	current.Hash = hash
+	if data, err := json.Marshal(current); err == nil {
+		_ = statefile.WriteFile(statePath, data, 0600)
+	}
+	if previous.Hash == "" {
+		return nil
+	}
+	return compare(previous, current)
+}
+
+// compare returns what changed from previous to current, contexts first
+func compare(previous, current kubeconfigState) []Change {
+	var changes []Change
+	contexts := make([]string, 0, len(current.Servers))
+	for context := range current.Servers {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+	for _, context := range contexts {
+		server := current.Servers[context]
+		old, known := previous.Servers[context]
+		switch {
+		case !known:
+			changes = append(changes, Change{Kind: NewContext, Name: context, New: server})
+		case old != server:
+			changes = append(changes, Change{Kind: ServerChanged, Name: context, Old: old, New: server})
+		}
+	}
+
+	known := make(map[string]bool, len(previous.Users))
+	for _, user := range previous.Users {
+		known[user] = true
+	}
+	for _, user := range current.Users {
+		if !known[user] {
+			changes = append(changes, Change{Kind: NewUser, Name: user})
+		}
+	}
+	return changes
+}
+
+// hashFiles hashes the names and contents of paths; a missing file counts
+// as empty
+func hashFiles(paths []string) string {
+	h := sha256.New()
+	for _, path := range paths {
+		data, _ := os.ReadFile(path)
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readKubeconfig reads the contexts, their servers and the users from the
+// kubeconfig as kubectl merges it
+func readKubeconfig() (kubeconfigState, error) {
+	stdout, err := viewKubeconfig()
+	if err != nil {
+		return kubeconfigState{}, err
+	}
+	var view struct {
+		Clusters []struct {
+			Name    string `json:"name"`
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+		Contexts []struct {
+			Name    string `json:"name"`
+			Context struct {
+				Cluster string `json:"cluster"`
+			} `json:"context"`
+		} `json:"contexts"`
+		Users []struct {
+			Name string `json:"name"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &view); err != nil {
+		return kubeconfigState{}, err
+	}
+
+	servers := make(map[string]string, len(view.Clusters))
+	for _, cluster := range view.Clusters {
+		servers[cluster.Name] = cluster.Cluster.Server
+	}
+	s := kubeconfigState{Servers: make(map[string]string, len(view.Contexts))}
+	for _, context := range view.Contexts {
+		s.Servers[context.Name] = servers[context.Context.Cluster]
+	}
+	for _, user := range view.Users {
+		s.Users = append(s.Users, user.Name)
+	}
+	sort.Strings(s.Users)
+	return s, nil
+}
@@ -0,0 +1,207 @@
+// Package editdiff records what 'kubectl edit' changed: the edited objects
+// are fetched before and after the edit and compared as a JSON patch. It
+// also previews what a scale or patch will change before it runs.
+package editdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// ignored are fields the API server or controllers change on their own,
+// which would bury the edit in noise
+var ignored = map[string]bool{
+	"/metadata/resourceVersion": true,
+	"/metadata/generation":      true,
+	"/metadata/managedFields":   true,
+	"/status":                   true,
+}
+
+// Snapshot is the state of the objects a command targets
+type Snapshot map[key]map[string]any
+
+// key identifies an object in a Snapshot
+type key struct {
+	object    string // kind/name
+	namespace string
+}
+
+// Supported reports whether args is a 'kubectl edit' whose objects can be
+// fetched
+func Supported(args []string) bool {
+	positional := rbac.Positional(args)
+	return len(positional) > 0 && positional[0] == "edit" &&
+		(len(positional) > 1 || rbac.HasFlag(args, "-f", "--filename"))
+}
+
+// Take fetches the objects the edit in args targets on context
+func Take(context string, args []string) (Snapshot, error) {
+	stdout, stderr, exitCode := kubectl.Run(getArgs(context, args))
+	if exitCode != 0 {
+		return nil, fmt.Errorf("fetching the objects failed: %s", strings.TrimSpace(stderr))
+	}
+	return parseSnapshot(stdout)
+}
+
+// parseSnapshot reads kubectl's JSON output of one object or a list
+func parseSnapshot(stdout string) (Snapshot, error) {
+	var fetched map[string]any
+	if err := json.Unmarshal([]byte(stdout), &fetched); err != nil {
+		return nil, fmt.Errorf("unexpected kubectl output: %w", err)
+	}
+
+	objects := []any{fetched}
+	if items, ok := fetched["items"].([]any); ok {
+		objects = items
+	}
+	snapshot := Snapshot{}
+	for _, o := range objects {
+		if obj, ok := o.(map[string]any); ok {
+			snapshot[keyOf(obj)] = obj
+		}
+	}
+	return snapshot, nil
+}
+
+// Diff returns the changes from before to after, for objects that changed
+func Diff(before, after Snapshot) ([]audit.Change, error) {
+	var changes []audit.Change
+	for k, old := range before {
+		obj, ok := after[k]
+		if !ok {
+			continue // deleted meanwhile; not the edit's doing
+		}
+		var patch []audit.PatchOp
+		if err := diff("", old, obj, &patch); err != nil {
+			return nil, err
+		}
+		if len(patch) > 0 {
+			changes = append(changes, audit.Change{Object: k.object, Namespace: k.namespace, Patch: patch})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Object < changes[j].Object
+	})
+	return changes, nil
+}
+
+// diff appends the operations turning old into new at path to patch.
+// Objects are compared field by field; lists only element by element when
+// their length is unchanged, otherwise they are replaced whole.
+func diff(path string, old, new any, patch *[]audit.PatchOp) error {
+	if ignored[path] || reflect.DeepEqual(old, new) {
+		return nil
+	}
+
+	switch o := old.(type) {
+	case map[string]any:
+		n, ok := new.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, field := range sortedKeys(o) {
+			child := path + "/" + escape(field)
+			if value, ok := n[field]; ok {
+				if err := diff(child, o[field], value, patch); err != nil {
+					return err
+				}
+			} else if !ignored[child] {
+				*patch = append(*patch, audit.PatchOp{Op: "remove", Path: child})
+			}
+		}
+		for _, field := range sortedKeys(n) {
+			child := path + "/" + escape(field)
+			if _, ok := o[field]; !ok && !ignored[child] {
+				if err := add(patch, "add", child, n[field]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case []any:
+		n, ok := new.([]any)
+		if !ok || len(n) != len(o) {
+			break
+		}
+		for i := range o {
+			if err := diff(path+"/"+strconv.Itoa(i), o[i], n[i], patch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return add(patch, "replace", path, new)
+}
+
+func add(patch *[]audit.PatchOp, op, path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	*patch = append(*patch, audit.PatchOp{Op: op, Path: path, Value: data})
+	return nil
+}
+
+// getArgs turns an edit into a 'kubectl get' of the same objects as JSON
+func getArgs(context string, args []string) []string {
+	out := []string{"--context", context, "--request-timeout=10s", "get"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		switch arg {
+		case "-n", "--namespace", "-f", "--filename", "-l", "--selector", "--kubeconfig":
+			if i+1 < len(args) {
+				out = append(out, arg, args[i+1])
+				i++
+			}
+			continue
+		case "-R", "--recursive":
+			out = append(out, arg)
+			continue
+		}
+		for _, flag := range []string{"--namespace=", "--filename=", "--selector=", "--kubeconfig="} {
+			if strings.HasPrefix(arg, flag) {
+				out = append(out, arg)
+			}
+		}
+	}
+	if positional := rbac.Positional(args); len(positional) > 1 {
+		out = append(out, positional[1:]...)
+	}
+	return append(out, "-o", "json")
+}
+
+func keyOf(obj map[string]any) key {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	return key{object: strings.ToLower(kind) + "/" + name, namespace: namespace}
+}
+
+// escape encodes a field name for a JSON pointer (RFC 6901)
+func escape(field string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(field, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package editdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestSupported(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"edit", "deploy/web"}, true},
+		{[]string{"-n", "web", "edit", "deployment", "web"}, true},
+		{[]string{"edit", "-f", "app.yaml"}, true},
+		{[]string{"edit"}, false},
+		{[]string{"get", "pods"}, false},
+	}
+	for _, tt := range tests {
+		if got := Supported(tt.args); got != tt.want {
+			t.Errorf("Supported(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			[]string{"edit", "deploy/web", "-n", "shop", "-o", "yaml", "--save-config"},
+			"--context prod --request-timeout=10s get -n shop deploy/web -o json",
+		},
+		{
+			[]string{"--namespace=shop", "edit", "-f", "app.yaml", "--context", "other"},
+			"--context prod --request-timeout=10s get --namespace=shop -f app.yaml -o json",
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(getArgs("prod", tt.args), " "); got != tt.want {
+			t.Errorf("getArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTakeDiff(t *testing.T) {
+	before := `{"kind": "Deployment", "metadata": {"name": "web", "namespace": "shop", "resourceVersion": "1",
+		"labels": {"app": "web", "app.kubernetes.io/tier": "front"}},
+		"spec": {"replicas": 2, "template": {"spec": {"containers": [{"name": "web", "image": "web:1"}]}}},
+		"status": {"readyReplicas": 2}}`
+	after := `{"kind": "Deployment", "metadata": {"name": "web", "namespace": "shop", "resourceVersion": "2",
+		"labels": {"app": "web", "team": "shop"}},
+		"spec": {"replicas": 3, "paused": false, "template": {"spec": {"containers": [{"name": "web", "image": "web:2"}]}}},
+		"status": {"readyReplicas": 1}}`
+
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+	fetched := before
+	kubectl.Run = func(args []string) (string, string, int) { return fetched, "", 0 }
+
+	old, err := Take("prod", []string{"edit", "deploy/web", "-n", "shop"})
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	fetched = after
+	changed, err := Take("prod", []string{"edit", "deploy/web", "-n", "shop"})
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+
+	changes, err := Diff(old, changed)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Object != "deployment/web" || changes[0].Namespace != "shop" {
+		t.Fatalf("Diff = %+v", changes)
+	}
+	var got []string
+	for _, op := range changes[0].Patch {
+		got = append(got, op.Op+" "+op.Path+" "+string(op.Value))
+	}
+	want := []string{
+		"remove /metadata/labels/app.kubernetes.io~1tier ",
+		`add /metadata/labels/team "shop"`,
+		"replace /spec/replicas 3",
+		`replace /spec/template/spec/containers/0/image "web:2"`,
+		"add /spec/paused false",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("patch =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Unchanged objects, and lists, produce nothing
+	fetched = `{"kind": "List", "items": [` + after + `]}`
+	same, err := Take("prod", []string{"edit", "deploy/web", "-n", "shop"})
+	if err != nil {
+		t.Fatalf("Take of a list failed: %v", err)
+	}
+	if changes, err := Diff(changed, same); err != nil || len(changes) != 0 {
+		t.Errorf("Diff of unchanged objects = %+v, %v", changes, err)
+	}
+}
+
+func TestTake_Failure(t *testing.T) {
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+	kubectl.Run = func(args []string) (string, string, int) { return "", "NotFound\n", 1 }
+
+	if _, err := Take("prod", []string{"edit", "deploy/gone"}); err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Errorf("Take error = %v, want the kubectl error", err)
+	}
+}
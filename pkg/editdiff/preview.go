@@ -0,0 +1,148 @@
+package editdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+)
+
+// FieldChange is one field a command would change, with its values as
+// JSON; Before is empty for a field the command adds and After for one it
+// removes
+type FieldChange struct {
+	Object    string // kind/name
+	Namespace string
+	Path      string // JSON pointer, e.g. /spec/replicas
+	Before    string
+	After     string
+}
+
+// Previewable reports whether args is a scale or patch whose changes
+// Preview can show
+func Previewable(args []string) bool {
+	positional := rbac.Positional(args)
+	if len(positional) == 0 || len(positional) < 2 && !rbac.HasFlag(args, "-f", "--filename") {
+		return false
+	}
+	switch positional[0] {
+	case "scale":
+		_, ok := replicas(args)
+		return ok
+	case "patch":
+		return true
+	}
+	return false
+}
+
+// Preview fetches the objects the scale or patch in args targets on context
+// and returns the fields it would change: the replicas a scale sets, or
+// what a server-side dry run of a patch reports. The values of a Secret's
+// data are redacted.
+func Preview(context string, args []string) ([]FieldChange, error) {
+	before, err := Take(context, args)
+	if err != nil {
+		return nil, err
+	}
+
+	after := Snapshot{}
+	if n, ok := replicas(args); ok && rbac.Positional(args)[0] == "scale" {
+		for k, obj := range before {
+			scaled := make(map[string]any, len(obj))
+			for field, value := range obj {
+				scaled[field] = value
+			}
+			spec := map[string]any{}
+			if old, ok := obj["spec"].(map[string]any); ok {
+				for field, value := range old {
+					spec[field] = value
+				}
+			}
+			spec["replicas"] = float64(n)
+			scaled["spec"] = spec
+			after[k] = scaled
+		}
+	} else {
+		stdout, stderr, exitCode := kubectl.Run(preview.DryRunArgs(context, args, "json"))
+		if exitCode != 0 {
+			return nil, fmt.Errorf("dry run failed: %s", strings.TrimSpace(stderr))
+		}
+		if after, err = parseSnapshot(stdout); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+	var fields []FieldChange
+	for _, change := range changes {
+		obj := before[key{object: change.Object, namespace: change.Namespace}]
+		for _, op := range change.Patch {
+			field := FieldChange{Object: change.Object, Namespace: change.Namespace, Path: op.Path}
+			if old, ok := valueAt(obj, op.Path); ok {
+				if data, err := json.Marshal(old); err == nil {
+					field.Before = string(data)
+				}
+			}
+			if op.Op != "remove" {
+				field.After = string(op.Value)
+			}
+			if kind, _, _ := strings.Cut(change.Object, "/"); redact.Field(kind, op.Path) {
+				field.Before, field.After = hidden(field.Before), hidden(field.After)
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// hidden stands in for a secret field's JSON value, keeping it empty when
+// the field is unset
+func hidden(value string) string {
+	if value == "" {
+		return ""
+	}
+	return `"` + redact.Placeholder + `"`
+}
+
+// replicas returns the --replicas a scale sets
+func replicas(args []string) (int, bool) {
+	value, ok := rbac.FlagValue(args, "--replicas")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n >= 0
+}
+
+// valueAt returns the value at a JSON pointer in obj
+func valueAt(obj any, pointer string) (any, bool) {
+	value := obj
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := value.(type) {
+		case map[string]any:
+			child, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,100 @@
+package editdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestPreviewable(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"scale", "deploy/web", "--replicas=5"}, true},
+		{[]string{"-n", "shop", "scale", "deployment", "web", "--replicas", "0"}, true},
+		{[]string{"scale", "deploy/web"}, false},
+		{[]string{"patch", "deploy/web", "-p", `{"spec":{"paused":true}}`}, true},
+		{[]string{"patch", "-f", "web.yaml", "-p", `{}`}, true},
+		{[]string{"patch"}, false},
+		{[]string{"delete", "deploy/web"}, false},
+	}
+	for _, tt := range tests {
+		if got := Previewable(tt.args); got != tt.want {
+			t.Errorf("Previewable(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	current := `{"kind": "Deployment", "metadata": {"name": "web", "namespace": "shop", "resourceVersion": "7"},
+		"spec": {"replicas": 3, "template": {"spec": {"containers": [{"name": "web", "image": "web:1"}]}}}}`
+	patched := `{"kind": "Deployment", "metadata": {"name": "web", "namespace": "shop", "resourceVersion": "8"},
+		"spec": {"replicas": 3, "paused": true, "template": {"spec": {"containers": [{"name": "web", "image": "web:2"}]}}}}`
+
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+	var calls []string
+	kubectl.Run = func(args []string) (string, string, int) {
+		calls = append(calls, strings.Join(args, " "))
+		if strings.Contains(strings.Join(args, " "), "--dry-run=server") {
+			return patched, "", 0
+		}
+		return current, "", 0
+	}
+
+	describe := func(fields []FieldChange) string {
+		var lines []string
+		for _, f := range fields {
+			lines = append(lines, fmt.Sprintf("%s %s: %s -> %s", f.Object, f.Path, f.Before, f.After))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	fields, err := Preview("prod", []string{"scale", "deploy/web", "-n", "shop", "--replicas=5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := describe(fields), "deployment/web /spec/replicas: 3 -> 5"; got != want {
+		t.Errorf("scale preview = %q, want %q", got, want)
+	}
+	if len(calls) != 1 {
+		t.Errorf("scale preview ran %v, want only the get", calls)
+	}
+
+	fields, err = Preview("prod", []string{"patch", "deploy/web", "-n", "shop", "-p", `{"spec":{"paused":true}}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `deployment/web /spec/template/spec/containers/0/image: "web:1" -> "web:2"` + "\n" +
+		"deployment/web /spec/paused:  -> true"
+	if got := describe(fields); got != want {
+		t.Errorf("patch preview =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPreview_Secret(t *testing.T) {
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+	kubectl.Run = func(args []string) (string, string, int) {
+		if strings.Contains(strings.Join(args, " "), "--dry-run=server") {
+			return `{"kind": "Secret", "metadata": {"name": "db", "labels": {"app": "db"}}, "data": {"password": "bmV3", "user": "YWRtaW4="}}`, "", 0
+		}
+		return `{"kind": "Secret", "metadata": {"name": "db"}, "data": {"user": "YWRtaW4="}}`, "", 0
+	}
+
+	fields, err := Preview("prod", []string{"patch", "secret/db", "-p", `{"data":{"password":"bmV3"}}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldChange{
+		{Object: "secret/db", Path: "/data/password", After: `"REDACTED"`},
+		{Object: "secret/db", Path: "/metadata/labels", After: `{"app":"db"}`},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Preview = %+v, want %+v", fields, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Package eviction plans a drain without touching the node: it decides, for
+// each pod on the node, what drain would do with it, and asks the API
+// server with a dry-run eviction whether the pod's disruption budgets let it
+// go
+package eviction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// runWithInput is replaced in tests
+var runWithInput = kubectl.ExecuteWithInput
+
+// Results of planning a pod's eviction
+const (
+	// Evicts: the eviction would be accepted
+	Evicts = "evicts"
+	// Blocked: a disruption budget or a missing drain flag stops the drain
+	Blocked = "blocked"
+	// Skipped: drain leaves the pod in place
+	Skipped = "skipped"
+	// Failed: the dry run failed for another reason
+	Failed = "failed"
+)
+
+// mirrorAnnotation marks the API server's copy of a static pod
+const mirrorAnnotation = "kubernetes.io/config.mirror"
+
+// Pod is the plan for one pod on the node
+type Pod struct {
+	Namespace string
+	Name      string
+	Result    string
+	Reason    string
+}
+
+// pod is the part of a pod Plan reads
+type pod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Annotations     map[string]string `json:"annotations"`
+		OwnerReferences []struct {
+			Kind       string `json:"kind"`
+			Controller bool   `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		Volumes []struct {
+			EmptyDir *struct{} `json:"emptyDir"`
+		} `json:"volumes"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// Plan lists the pods on node on context and decides each the way the
+// drain in args would: DaemonSet and static pods are skipped or stop the
+// drain, as are unmanaged pods and pods with emptyDir data without the
+// flags allowing them, and the rest are evicted with dryRun=All
+func Plan(context, node string, args []string) ([]Pod, error) {
+	list := []string{"--context", context, "--request-timeout=10s", "get", "pods", "-A",
+		"--field-selector", "spec.nodeName=" + node}
+	if selector, ok := rbac.FlagValue(args, "--pod-selector"); ok {
+		list = append(list, "-l", selector)
+	}
+	var pods struct {
+		Items []pod `json:"items"`
+	}
+	if err := kubectl.GetJSON(list, &pods); err != nil {
+		return nil, fmt.Errorf("listing the pods on %s failed: %w", node, err)
+	}
+
+	var plan []Pod
+	for _, p := range pods.Items {
+		planned := Pod{Namespace: p.Metadata.Namespace, Name: p.Metadata.Name}
+		planned.Result, planned.Reason = decide(p, args)
+		if planned.Result == "" {
+			planned.Result, planned.Reason = evict(context, p)
+		}
+		plan = append(plan, planned)
+	}
+	return plan, nil
+}
+
+// decide returns what drain does with p before any eviction, or no result
+// when p is evicted
+func decide(p pod, args []string) (result, reason string) {
+	if _, mirror := p.Metadata.Annotations[mirrorAnnotation]; mirror {
+		return Skipped, "static pod"
+	}
+	controller := ""
+	for _, ref := range p.Metadata.OwnerReferences {
+		if ref.Controller {
+			controller = ref.Kind
+		}
+	}
+	if controller == "DaemonSet" {
+		if rbac.HasFlag(args, "--ignore-daemonsets") {
+			return Skipped, "DaemonSet pod"
+		}
+		return Blocked, "DaemonSet pod; drain needs --ignore-daemonsets"
+	}
+	if p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed" {
+		return "", ""
+	}
+	if controller == "" && !rbac.HasFlag(args, "--force") {
+		return Blocked, "no controller recreates it; drain needs --force"
+	}
+	for _, v := range p.Spec.Volumes {
+		if v.EmptyDir != nil && !rbac.HasFlag(args, "--delete-emptydir-data", "--delete-local-data") {
+			return Blocked, "emptyDir data would be lost; drain needs --delete-emptydir-data"
+		}
+	}
+	return "", ""
+}
+
+// evict asks the API server to evict p with dryRun=All
+func evict(context string, p pod) (result, reason string) {
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "policy/v1",
+		"kind":       "Eviction",
+		"metadata":   map[string]string{"name": p.Metadata.Name, "namespace": p.Metadata.Namespace},
+	})
+	if err != nil {
+		return Failed, err.Error()
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/eviction?dryRun=All", p.Metadata.Namespace, p.Metadata.Name)
+	_, stderr, exitCode := runWithInput([]string{"--context", context, "--request-timeout=10s", "create", "--raw", path, "-f", "-"}, body)
+	if exitCode == 0 {
+		return Evicts, ""
+	}
+	message := strings.TrimSpace(stderr)
+	if strings.Contains(message, "disruption budget") || strings.Contains(message, "TooManyRequests") {
+		return Blocked, "PodDisruptionBudget allows no more disruptions"
+	}
+	return Failed, message
+}
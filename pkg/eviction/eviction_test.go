@@ -0,0 +1,66 @@
+package eviction
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestPlan(t *testing.T) {
+	previous, previousInput := kubectl.Run, runWithInput
+	kubectl.Run = func(args []string) (string, string, int) {
+		if !strings.Contains(strings.Join(args, " "), "get pods -A --field-selector spec.nodeName=node-1") {
+			t.Errorf("unexpected kubectl %v", args)
+		}
+		return `{"items":[
+  {"metadata":{"name":"web-1","namespace":"shop","ownerReferences":[{"kind":"ReplicaSet","controller":true}]}},
+  {"metadata":{"name":"db-0","namespace":"shop","ownerReferences":[{"kind":"StatefulSet","controller":true}]}},
+  {"metadata":{"name":"fluentd-x","namespace":"logging","ownerReferences":[{"kind":"DaemonSet","controller":true}]}},
+  {"metadata":{"name":"etcd-node-1","namespace":"kube-system","annotations":{"kubernetes.io/config.mirror":"abc"}}},
+  {"metadata":{"name":"debug","namespace":"shop"}},
+  {"metadata":{"name":"cache-1","namespace":"shop","ownerReferences":[{"kind":"ReplicaSet","controller":true}]},"spec":{"volumes":[{"emptyDir":{}}]}}
+]}`, "", 0
+	}
+	var evicted []string
+	runWithInput = func(args []string, input []byte) (string, string, int) {
+		path := args[len(args)-3]
+		evicted = append(evicted, path)
+		if !strings.Contains(string(input), `"kind":"Eviction"`) {
+			t.Errorf("eviction body %s", input)
+		}
+		if strings.Contains(path, "db-0") {
+			return "", "Error from server (TooManyRequests): Cannot evict pod as it would violate the pod's disruption budget.", 1
+		}
+		return "", "", 0
+	}
+	t.Cleanup(func() { kubectl.Run, runWithInput = previous, previousInput })
+
+	plan, err := Plan("app-prod", "node-1", []string{"drain", "node-1", "--ignore-daemonsets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pod{
+		{Namespace: "shop", Name: "web-1", Result: Evicts},
+		{Namespace: "shop", Name: "db-0", Result: Blocked, Reason: "PodDisruptionBudget allows no more disruptions"},
+		{Namespace: "logging", Name: "fluentd-x", Result: Skipped, Reason: "DaemonSet pod"},
+		{Namespace: "kube-system", Name: "etcd-node-1", Result: Skipped, Reason: "static pod"},
+		{Namespace: "shop", Name: "debug", Result: Blocked, Reason: "no controller recreates it; drain needs --force"},
+		{Namespace: "shop", Name: "cache-1", Result: Blocked, Reason: "emptyDir data would be lost; drain needs --delete-emptydir-data"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("Plan = %+v\nwant %+v", plan, want)
+	}
+	wantEvicted := []string{"/api/v1/namespaces/shop/pods/web-1/eviction?dryRun=All", "/api/v1/namespaces/shop/pods/db-0/eviction?dryRun=All"}
+	if !reflect.DeepEqual(evicted, wantEvicted) {
+		t.Errorf("evicted %v, want %v", evicted, wantEvicted)
+	}
+
+	// The flags drain needs let those pods go
+	evicted = nil
+	plan, _ = Plan("app-prod", "node-1", []string{"drain", "node-1", "--force", "--delete-emptydir-data"})
+	if plan[2].Result != Blocked || plan[4].Result != Evicts || plan[5].Result != Evicts {
+		t.Errorf("Plan with --force --delete-emptydir-data = %+v", plan)
+	}
+}
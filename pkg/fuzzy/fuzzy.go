@@ -0,0 +1,79 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Match kinds, from strongest to weakest
+const (
+	matchNone = iota
+	matchSubsequence
+	matchSubstring
+	matchPrefix
+	matchExact
+)
+
+// Find returns the candidates matching query, best matches first.
+// An exact match is returned on its own. Otherwise candidates are ranked
+// prefix > substring > subsequence (case-insensitive), then by length.
+func Find(query string, candidates []string) []string {
+	type scored struct {
+		value string
+		kind  int
+	}
+
+	var matches []scored
+	for _, c := range candidates {
+		if c == query {
+			return []string{c}
+		}
+		if kind := classify(strings.ToLower(query), strings.ToLower(c)); kind != matchNone {
+			matches = append(matches, scored{c, kind})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].kind != matches[j].kind {
+			return matches[i].kind > matches[j].kind
+		}
+		return len(matches[i].value) < len(matches[j].value)
+	})
+
+	// Only keep the strongest kind of match so "prod" doesn't compete
+	// with every name that merely contains p, r, o, d in order
+	var result []string
+	for _, m := range matches {
+		if m.kind != matches[0].kind {
+			break
+		}
+		result = append(result, m.value)
+	}
+	return result
+}
+
+func classify(query, candidate string) int {
+	switch {
+	case query == candidate:
+		return matchExact
+	case strings.HasPrefix(candidate, query):
+		return matchPrefix
+	case strings.Contains(candidate, query):
+		return matchSubstring
+	case isSubsequence(query, candidate):
+		return matchSubsequence
+	default:
+		return matchNone
+	}
+}
+
+func isSubsequence(query, candidate string) bool {
+	qi := 0
+	q := []rune(query)
+	for _, r := range candidate {
+		if qi < len(q) && r == q[qi] {
+			qi++
+		}
+	}
+	return qi == len(q)
+}
@@ -0,0 +1,33 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	candidates := []string{"app-prod", "app-production", "kind-dev", "staging-eu", "Payments-Prod"}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"exact match wins", "app-prod", []string{"app-prod"}},
+		{"prefix beats substring", "app", []string{"app-prod", "app-production"}},
+		{"substring", "eu", []string{"staging-eu"}},
+		{"case insensitive", "payments", []string{"Payments-Prod"}},
+		{"subsequence", "kdv", []string{"kind-dev"}},
+		{"substring ranked by length", "prod", []string{"app-prod", "Payments-Prod", "app-production"}},
+		{"no match", "zzz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Find(tt.query, candidates)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Find(%q) = %v, want %v", tt.query, result, tt.expected)
+			}
+		})
+	}
+}
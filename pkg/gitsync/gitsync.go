@@ -0,0 +1,218 @@
+// Package gitsync keeps a local copy of a shared policy repository that is
+// used as the base config layer
+package gitsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+)
+
+// DefaultPath is the config file used when the source doesn't name one
+const DefaultPath = "config.yaml"
+
+// DefaultInterval is how often the repository is pulled automatically
+const DefaultInterval = time.Hour
+
+// syncedMarker is touched after every successful sync
+const syncedMarker = ".kctl-synced"
+
+// retryInterval keeps an unreachable repository from being tried on every
+// command; automatic syncs wait this long after any attempt
+const retryInterval = 5 * time.Minute
+
+// Dir returns where the source is checked out. Each URL and ref gets its
+// own directory so changing either never mixes checkouts.
+func Dir(src config.SourceConfig) string {
+	sum := sha256.Sum256([]byte(src.URL + "\x00" + src.Ref))
+	return filepath.Join(config.DataDir(), "policy", hex.EncodeToString(sum[:8]))
+}
+
+// ConfigFile returns the path of the synced config file
+func ConfigFile(src config.SourceConfig) (string, error) {
+	path := src.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("source path %q must be relative to the repository", src.Path)
+	}
+	return filepath.Join(Dir(src), clean), nil
+}
+
+// Validate checks that src can be synced
+func Validate(src config.SourceConfig) error {
+	if src.Type != "git" {
+		return fmt.Errorf("unsupported source type %q (only \"git\" is supported)", src.Type)
+	}
+	if src.URL == "" {
+		return fmt.Errorf("source.url is required")
+	}
+	if _, err := ConfigFile(src); err != nil {
+		return err
+	}
+	if _, err := interval(src); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LastSync returns when src was last synced successfully
+func LastSync(src config.SourceConfig) (time.Time, bool) {
+	info, err := os.Stat(filepath.Join(Dir(src), syncedMarker))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// Due reports whether src should be pulled automatically now
+func Due(src config.SourceConfig) bool {
+	every, err := interval(src)
+	if err != nil {
+		return false
+	}
+	if info, err := os.Stat(attemptMarker(src)); err == nil && time.Since(info.ModTime()) < retryInterval {
+		return false
+	}
+	last, ok := LastSync(src)
+	if !ok {
+		return true
+	}
+	return every > 0 && time.Since(last) >= every
+}
+
+// Sync clones the repository or updates the existing checkout to the
+// latest commit of the configured ref
+func Sync(src config.SourceConfig, timeout time.Duration) error {
+	if err := Validate(src); err != nil {
+		return err
+	}
+
+	env, err := authEnv(src)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	dir := Dir(src)
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return err
+	}
+	_ = os.WriteFile(attemptMarker(src), nil, 0600)
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		// Clone next to the final location so a failed clone leaves no checkout
+		tmp, err := os.MkdirTemp(filepath.Dir(dir), ".clone-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if src.Ref != "" {
+			args = append(args, "--branch", src.Ref)
+		}
+		if err := git(ctx, "", env, append(args, "--", src.URL, tmp)...); err != nil {
+			return err
+		}
+		os.RemoveAll(dir)
+		if err := os.Rename(tmp, dir); err != nil {
+			return err
+		}
+	} else {
+		ref := src.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if err := git(ctx, dir, env, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+			return err
+		}
+		if err := git(ctx, dir, env, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(filepath.Join(dir, syncedMarker), nil, 0600)
+}
+
+// Revision returns the commit currently checked out for src
+func Revision(src config.SourceConfig) (string, error) {
+	cmd := exec.Command("git", "-C", Dir(src), "rev-parse", "--short", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// attemptMarker is touched whenever a sync starts
+func attemptMarker(src config.SourceConfig) string {
+	return Dir(src) + ".attempt"
+}
+
+func interval(src config.SourceConfig) (time.Duration, error) {
+	switch src.SyncInterval {
+	case "":
+		return DefaultInterval, nil
+	case "0":
+		return 0, nil
+	}
+	d, err := time.ParseDuration(src.SyncInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid source.sync_interval %q: %w", src.SyncInterval, err)
+	}
+	return d, nil
+}
+
+// authEnv returns environment variables that make git send the source's
+// token from the keyring, if it has one. The header goes through the
+// environment so the token never appears in a process listing.
+func authEnv(src config.SourceConfig) ([]string, error) {
+	token, err := secrets.Token("", src.TokenSecret)
+	if err != nil || token == "" {
+		return nil, err
+	}
+	// GitHub and GitLab both accept a token as the password of any user
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + basic,
+	}, nil
+}
+
+// git runs a git command without ever prompting for credentials
+func git(ctx context.Context, dir string, env []string, args ...string) error {
+	name := args[0]
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("git %s timed out", name)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("git %s failed: %s", name, msg)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+// newRepo creates a local repository with config.yaml committed
+func newRepo(t *testing.T, content string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(repo, "config.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "config.yaml")
+	run("commit", "--quiet", "-m", "policy")
+	return repo
+}
+
+func TestSync(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	repo := newRepo(t, "tiers:\n  production:\n    patterns: [\"*-prod\"]\n")
+	src := config.SourceConfig{Type: "git", URL: "file://" + repo, Ref: "main"}
+
+	if !Due(src) {
+		t.Error("Due should be true before the first sync")
+	}
+	if err := Sync(src, time.Minute); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	file, _ := ConfigFile(src)
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("Synced config missing: %v", err)
+	}
+	if _, ok := LastSync(src); !ok {
+		t.Error("LastSync should report the sync")
+	}
+	if Due(src) {
+		t.Error("Due should be false right after a sync")
+	}
+
+	// Update the repository and sync again
+	os.WriteFile(filepath.Join(repo, "config.yaml"), []byte("tiers: {}\n"), 0644)
+	cmd := exec.Command("git", "-C", repo, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-am", "update")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit: %v\n%s", err, out)
+	}
+	if err := Sync(src, time.Minute); err != nil {
+		t.Fatalf("second Sync failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "tiers: {}\n" {
+		t.Errorf("Synced config = %q, want updated content", data)
+	}
+}
+
+func TestSync_Unreachable(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	src := config.SourceConfig{Type: "git", URL: "file:///nonexistent/repo"}
+
+	if err := Sync(src, time.Minute); err == nil {
+		t.Fatal("Sync of a missing repository should fail")
+	}
+	if _, err := os.Stat(Dir(src)); !os.IsNotExist(err) {
+		t.Error("Failed clone should not leave a checkout behind")
+	}
+	if Due(src) {
+		t.Error("Due should back off after a failed attempt")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		src     config.SourceConfig
+		wantErr bool
+	}{
+		{config.SourceConfig{Type: "git", URL: "https://example.com/policy.git"}, false},
+		{config.SourceConfig{Type: "svn", URL: "https://example.com/policy"}, true},
+		{config.SourceConfig{Type: "git"}, true},
+		{config.SourceConfig{Type: "git", URL: "x", Path: "../escape.yaml"}, true},
+		{config.SourceConfig{Type: "git", URL: "x", SyncInterval: "soon"}, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.src); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package health summarizes how a cluster is doing before a disruptive
+// command: which nodes aren't ready, how many pods are pending and which
+// warning events were recorded recently
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+// EventWindow is how far back warning events count as recent
+const EventWindow = 15 * time.Minute
+
+// maxReasons is how many warning reasons a summary names
+const maxReasons = 3
+
+// Summary is a cluster's health
+type Summary struct {
+	Nodes    int
+	NotReady []string
+	Pending  int
+	// Warnings counts the warning events of the last EventWindow by reason
+	Warnings map[string]int
+}
+
+// Degraded reports whether anything in s is worth a second look
+func (s Summary) Degraded() bool {
+	return len(s.NotReady) > 0 || s.Pending > 0 || len(s.Warnings) > 0
+}
+
+// Lines describes s in a few short lines
+func (s Summary) Lines() []string {
+	nodes := fmt.Sprintf("Nodes: %d of %d ready", s.Nodes-len(s.NotReady), s.Nodes)
+	if len(s.NotReady) > 0 {
+		nodes += fmt.Sprintf(" (not ready: %s)", strings.Join(s.NotReady, ", "))
+	}
+	lines := []string{nodes, fmt.Sprintf("Pending pods: %d", s.Pending)}
+
+	window := fmt.Sprintf("%dm", int(EventWindow.Minutes()))
+	reasons := make([]string, 0, len(s.Warnings))
+	total := 0
+	for reason, count := range s.Warnings {
+		reasons = append(reasons, reason)
+		total += count
+	}
+	if total == 0 {
+		return append(lines, fmt.Sprintf("Warning events in the last %s: none", window))
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if s.Warnings[reasons[i]] != s.Warnings[reasons[j]] {
+			return s.Warnings[reasons[i]] > s.Warnings[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	var top []string
+	for i, reason := range reasons {
+		if i == maxReasons {
+			top = append(top, "...")
+			break
+		}
+		top = append(top, fmt.Sprintf("%s ×%d", reason, s.Warnings[reason]))
+	}
+	return append(lines, fmt.Sprintf("Warning events in the last %s: %d (%s)", window, total, strings.Join(top, ", ")))
+}
+
+// Check summarizes the health of the cluster of context as of now
+func Check(context string, now time.Time) (Summary, error) {
+	var s Summary
+	var nodes struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := get(context, &nodes, "nodes"); err != nil {
+		return s, err
+	}
+	s.Nodes = len(nodes.Items)
+	for _, node := range nodes.Items {
+		ready := false
+		for _, c := range node.Status.Conditions {
+			if c.Type == "Ready" {
+				ready = c.Status == "True"
+			}
+		}
+		if !ready {
+			s.NotReady = append(s.NotReady, node.Metadata.Name)
+		}
+	}
+
+	var pods struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := get(context, &pods, "pods", "-A", "--field-selector=status.phase=Pending"); err != nil {
+		return s, err
+	}
+	s.Pending = len(pods.Items)
+
+	var events struct {
+		Items []struct {
+			Reason        string    `json:"reason"`
+			LastTimestamp time.Time `json:"lastTimestamp"`
+			EventTime     time.Time `json:"eventTime"`
+			Series        struct {
+				LastObservedTime time.Time `json:"lastObservedTime"`
+			} `json:"series"`
+		} `json:"items"`
+	}
+	if err := get(context, &events, "events", "-A", "--field-selector=type=Warning"); err != nil {
+		return s, err
+	}
+	s.Warnings = make(map[string]int)
+	for _, e := range events.Items {
+		last := e.LastTimestamp
+		for _, t := range []time.Time{e.EventTime, e.Series.LastObservedTime} {
+			if t.After(last) {
+				last = t
+			}
+		}
+		if now.Sub(last) <= EventWindow {
+			s.Warnings[e.Reason]++
+		}
+	}
+	return s, nil
+}
+
+// get lists resource on context as JSON into v
+func get(context string, v any, resource string, flags ...string) error {
+	args := append([]string{"--context", context, "--request-timeout=10s", "get", resource}, flags...)
+	if err := kubectl.GetJSON(args, v); err != nil {
+		return fmt.Errorf("listing %s failed: %w", resource, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestCheck(t *testing.T) {
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		switch command := strings.Join(args, " "); {
+		case strings.Contains(command, "get nodes"):
+			return `{"items":[
+  {"metadata":{"name":"node-1"},"status":{"conditions":[{"type":"Ready","status":"True"}]}},
+  {"metadata":{"name":"node-2"},"status":{"conditions":[{"type":"MemoryPressure","status":"False"},{"type":"Ready","status":"Unknown"}]}}
+]}`, "", 0
+		case strings.Contains(command, "get pods -A --field-selector=status.phase=Pending"):
+			return `{"items":[{},{}]}`, "", 0
+		case strings.Contains(command, "get events -A --field-selector=type=Warning"):
+			return `{"items":[
+  {"reason":"BackOff","lastTimestamp":"2026-03-01T11:58:00Z","eventTime":null},
+  {"reason":"BackOff","lastTimestamp":"2026-03-01T11:50:00Z"},
+  {"reason":"FailedScheduling","lastTimestamp":null,"eventTime":"2026-03-01T11:59:30.000000Z"},
+  {"reason":"FailedMount","lastTimestamp":"2026-03-01T10:00:00Z"}
+]}`, "", 0
+		}
+		t.Errorf("unexpected kubectl %v", args)
+		return "", "", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	s, err := Check("app-prod", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Summary{Nodes: 2, NotReady: []string{"node-2"}, Pending: 2, Warnings: map[string]int{"BackOff": 2, "FailedScheduling": 1}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Check = %+v, want %+v", s, want)
+	}
+	if !s.Degraded() {
+		t.Error("Degraded = false, want true")
+	}
+	lines := []string{
+		"Nodes: 1 of 2 ready (not ready: node-2)",
+		"Pending pods: 2",
+		"Warning events in the last 15m: 3 (BackOff ×2, FailedScheduling ×1)",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, lines) {
+		t.Errorf("Lines = %q, want %q", got, lines)
+	}
+	if (Summary{Nodes: 3, Warnings: map[string]int{}}).Degraded() {
+		t.Error("a healthy cluster is degraded")
+	}
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// MaxEntries is the number of entries kept when the history file is trimmed
+const MaxEntries = 1000
+
+// trimThreshold is the file size above which the history is trimmed
+const trimThreshold = 512 * 1024
+
+// Entry is a single executed command
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Context  string    `json:"context"`
+	Args     []string  `json:"args"`
+	ExitCode int       `json:"exit_code"`
+}
+
+// Path returns the default history file location
+func Path() string {
+	return filepath.Join(config.DataDir(), "history.jsonl")
+}
+
+// Append adds an entry to the history file at path, trimming old
+// entries once the file grows large
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := statefile.AppendLocked(path, append(line, '\n')); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > trimThreshold {
+		return trim(path, MaxEntries)
+	}
+	return nil
+}
+
+// Load reads all entries from the history file at path, oldest first.
+// A missing file yields an empty history.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip corrupt lines rather than losing the whole history
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// trim rewrites the history file keeping only the newest max entries. The
+// caller holds the file's lock.
+func trim(path string, max int) error {
+	entries, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) <= max {
+		return nil
+	}
+	entries = entries[len(entries)-max:]
+
+	var out bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		out.Write(append(line, '\n'))
+	}
+	return statefile.WriteFile(path, out.Bytes(), 0600)
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load on missing file failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected empty history, got %d entries", len(entries))
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, args := range [][]string{{"get", "pods"}, {"delete", "pod", "foo"}} {
+		err := Append(path, Entry{Time: now, Context: "app-prod", Args: args, ExitCode: i})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].Args[0] != "delete" || entries[1].ExitCode != 1 || entries[1].Context != "app-prod" {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+	if !entries[0].Time.Equal(now) {
+		t.Errorf("Expected time %v, got %v", now, entries[0].Time)
+	}
+}
+
+func TestLoad_SkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := `{"context":"a","args":["get","pods"]}
+not json
+{"context":"b","args":["get","svc"]}
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write history: %v", err)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Context != "b" {
+		t.Errorf("Expected 2 valid entries, got %+v", entries)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for i := 0; i < 10; i++ {
+		if err := Append(path, Entry{Context: "ctx", Args: []string{"get", "pods"}, ExitCode: i}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if err := trim(path, 3); err != nil {
+		t.Fatalf("trim failed: %v", err)
+	}
+
+	entries, _ := Load(path)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries after trim, got %d", len(entries))
+	}
+	if entries[0].ExitCode != 7 || entries[2].ExitCode != 9 {
+		t.Errorf("Expected newest entries to be kept, got %+v", entries)
+	}
+}
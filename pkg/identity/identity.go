@@ -0,0 +1,163 @@
+// Package identity resolves who a context authenticates to the cluster as,
+// so audit entries name the cluster user rather than the local login
+package identity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Sources of an identity, from most to least authoritative
+const (
+	SourceWhoami     = "whoami"     // the API server's SelfSubjectReview
+	SourceToken      = "token"      // claims of the kubeconfig's OIDC or bearer token
+	SourceKubeconfig = "kubeconfig" // the kubeconfig user entry's name
+)
+
+// CacheTTL is how long a resolved identity is reused
+const CacheTTL = time.Hour
+
+// retryInterval is how long a fallback identity is reused before the
+// cluster is asked again
+const retryInterval = 5 * time.Minute
+
+// Identity is the user a context acts as
+type Identity struct {
+	Username  string    `json:"username"`
+	Groups    []string  `json:"groups,omitempty"`
+	Source    string    `json:"source"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// now is replaced in tests
+var now = time.Now
+
+// CachePath is where resolved identities are kept, keyed by context and
+// kubeconfig (see cacheKey)
+func CachePath() string {
+	return filepath.Join(config.CacheDir(), "identity.json")
+}
+
+// cacheKey is context's key in the cache. The same context name may be
+// another cluster, or another user, under another $KUBECONFIG.
+func cacheKey(context string) string {
+	return context + " " + strings.Join(kubectl.KubeconfigPaths(), string(filepath.ListSeparator))
+}
+
+// Resolve returns who context acts as: the username reported by 'kubectl
+// auth whoami', else the claims of the kubeconfig's token, else the
+// kubeconfig user name. The zero Identity means nothing could be found.
+func Resolve(context string) Identity {
+	path, key := CachePath(), cacheKey(context)
+	if cached, ok := statefile.ReadCache[Identity](path)[key]; ok && now().Before(cached.ExpiresAt) {
+		return cached
+	}
+
+	id, ok := whoami(context)
+	if ok {
+		id.ExpiresAt = now().Add(CacheTTL)
+	} else {
+		id = fromKubeconfig(context)
+		id.ExpiresAt = now().Add(retryInterval)
+	}
+	if id.Username != "" {
+		statefile.UpdateCache(path, func(cache map[string]Identity) { cache[key] = id })
+	}
+	return id
+}
+
+// ResolveOffline is Resolve without contacting the cluster: a cached
+// identity, else what the kubeconfig says
+func ResolveOffline(context string) Identity {
+	if cached, ok := statefile.ReadCache[Identity](CachePath())[cacheKey(context)]; ok && now().Before(cached.ExpiresAt) {
+		return cached
+	}
+	return fromKubeconfig(context)
+}
+
+// whoami asks the API server, which knows the user behind any auth method,
+// exec plugins included. It needs Kubernetes 1.27 or later.
+func whoami(context string) (Identity, bool) {
+	var review struct {
+		Status struct {
+			UserInfo struct {
+				Username string   `json:"username"`
+				Groups   []string `json:"groups"`
+			} `json:"userInfo"`
+		} `json:"status"`
+	}
+	err := kubectl.GetJSON([]string{"--context", context, "--request-timeout=5s", "auth", "whoami"}, &review)
+	if err != nil || review.Status.UserInfo.Username == "" {
+		return Identity{}, false
+	}
+	return Identity{
+		Username: review.Status.UserInfo.Username,
+		Groups:   review.Status.UserInfo.Groups,
+		Source:   SourceWhoami,
+	}, true
+}
+
+// fromKubeconfig reads the context's user entry without contacting the cluster
+func fromKubeconfig(context string) Identity {
+	stdout, _, exitCode := kubectl.Run([]string{"config", "view", "--minify", "--raw", "--context", context, "-o", "json"})
+	if exitCode != 0 {
+		return Identity{}
+	}
+	var kubeconfig struct {
+		Users []struct {
+			Name string `json:"name"`
+			User struct {
+				Token        string `json:"token"`
+				AuthProvider struct {
+					Config map[string]string `json:"config"`
+				} `json:"auth-provider"`
+			} `json:"user"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &kubeconfig); err != nil || len(kubeconfig.Users) == 0 {
+		return Identity{}
+	}
+	user := kubeconfig.Users[0]
+	for _, token := range []string{user.User.AuthProvider.Config["id-token"], user.User.Token} {
+		if name, groups := claims(token); name != "" {
+			return Identity{Username: name, Groups: groups, Source: SourceToken}
+		}
+	}
+	return Identity{Username: user.Name, Source: SourceKubeconfig}
+}
+
+// claims returns the user named by a JWT's email, preferred_username or sub
+// claim, and its groups. The signature isn't checked: the name is only for
+// attribution, and the API server decides what the token may do.
+func claims(token string) (string, []string) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", nil
+	}
+	var c struct {
+		Email             string   `json:"email"`
+		PreferredUsername string   `json:"preferred_username"`
+		Subject           string   `json:"sub"`
+		Groups            []string `json:"groups"`
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", nil
+	}
+	for _, name := range []string{c.Email, c.PreferredUsername, c.Subject} {
+		if name != "" {
+			return name, c.Groups
+		}
+	}
+	return "", nil
+}
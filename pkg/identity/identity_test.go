@@ -0,0 +1,97 @@
+package identity
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+// fakeKubectl answers whoami and config view, counting whoami calls
+type fakeKubectl struct {
+	whoami     string
+	kubeconfig string
+	calls      int
+}
+
+func (f *fakeKubectl) run(args []string) (string, string, int) {
+	if strings.Contains(strings.Join(args, " "), "auth whoami") {
+		f.calls++
+		if f.whoami == "" {
+			return "", "error: the server doesn't have a resource type \"selfsubjectreviews\"", 1
+		}
+		return f.whoami, "", 0
+	}
+	return f.kubeconfig, "", 0
+}
+
+func setup(t *testing.T, f *fakeKubectl) *time.Time {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldRun, oldNow := kubectl.Run, now
+	kubectl.Run = f.run
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { kubectl.Run, now = oldRun, oldNow })
+	return &clock
+}
+
+func jwt(payload string) string {
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestResolve_Whoami(t *testing.T) {
+	f := &fakeKubectl{whoami: `{"status":{"userInfo":{"username":"alice@example.com","groups":["sre","system:authenticated"]}}}`}
+	clock := setup(t, f)
+
+	id := Resolve("prod")
+	if id.Username != "alice@example.com" || id.Source != SourceWhoami || len(id.Groups) != 2 {
+		t.Fatalf("Resolve = %+v", id)
+	}
+	Resolve("prod")
+	if f.calls != 1 {
+		t.Errorf("whoami called %d times, want 1 (cached)", f.calls)
+	}
+	*clock = clock.Add(CacheTTL + time.Minute)
+	Resolve("prod")
+	if f.calls != 2 {
+		t.Errorf("whoami called %d times after expiry, want 2", f.calls)
+	}
+}
+
+func TestResolve_KeyedByKubeconfig(t *testing.T) {
+	f := &fakeKubectl{whoami: `{"status":{"userInfo":{"username":"alice@example.com"}}}`}
+	setup(t, f)
+	t.Setenv("KUBECONFIG", "/tmp/work.yaml")
+	Resolve("prod")
+
+	t.Setenv("KUBECONFIG", "/tmp/lab.yaml")
+	f.whoami = `{"status":{"userInfo":{"username":"lab-admin"}}}`
+	if id := Resolve("prod"); id.Username != "lab-admin" {
+		t.Errorf("Resolve under another KUBECONFIG = %q, want lab-admin rather than the cached identity", id.Username)
+	}
+	if f.calls != 2 {
+		t.Errorf("whoami called %d times, want once per kubeconfig", f.calls)
+	}
+}
+
+func TestResolve_Kubeconfig(t *testing.T) {
+	tests := []struct {
+		name, user, want, source string
+	}{
+		{"oidc", `{"name":"oidc","user":{"auth-provider":{"config":{"id-token":"` + jwt(`{"sub":"123","email":"bob@example.com"}`) + `"}}}}`, "bob@example.com", SourceToken},
+		{"token", `{"name":"sa","user":{"token":"` + jwt(`{"sub":"system:serviceaccount:ci:deployer"}`) + `"}}`, "system:serviceaccount:ci:deployer", SourceToken},
+		{"opaque token", `{"name":"admin","user":{"token":"abc123"}}`, "admin", SourceKubeconfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t, &fakeKubectl{kubeconfig: `{"users":[` + tt.user + `]}`})
+			id := Resolve("prod")
+			if id.Username != tt.want || id.Source != tt.source {
+				t.Errorf("Resolve = %+v, want %s from %s", id, tt.want, tt.source)
+			}
+		})
+	}
+}
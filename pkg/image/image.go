@@ -0,0 +1,52 @@
+// Package image reads container image references the way the container
+// runtime resolves them, e.g. "nginx" as docker.io/library/nginx:latest
+package image
+
+import "strings"
+
+// DefaultRegistry is the registry of images that don't name one
+const DefaultRegistry = "docker.io"
+
+// Ref is a parsed image reference
+type Ref struct {
+	Registry   string
+	Repository string
+	// Tag is "latest" when the reference has neither tag nor digest
+	Tag    string
+	Digest string
+}
+
+// Parse reads an image reference such as "ghcr.io/acme/api:v2" or
+// "nginx@sha256:...". The first path component is the registry when it
+// has a dot or port, or is localhost; otherwise the image is on
+// DefaultRegistry, where single names are under library/.
+func Parse(s string) Ref {
+	var r Ref
+	s, r.Digest, _ = strings.Cut(s, "@")
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		s, r.Tag = s[:i], s[i+1:]
+	}
+	if first, rest, found := strings.Cut(s, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		r.Registry, r.Repository = strings.ToLower(first), rest
+	} else {
+		r.Registry, r.Repository = DefaultRegistry, s
+		if !found {
+			r.Repository = "library/" + s
+		}
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r
+}
+
+// Name returns the registry and repository, e.g. "ghcr.io/acme/api"
+func (r Ref) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// Pinned reports whether the reference names a digest, so it always pulls
+// the same image
+func (r Ref) Pinned() bool {
+	return r.Digest != ""
+}
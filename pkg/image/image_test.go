@@ -0,0 +1,26 @@
+package image
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Ref
+	}{
+		{"nginx", Ref{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.25", Ref{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"}},
+		{"bitnami/redis:7", Ref{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7"}},
+		{"ghcr.io/acme/api:v2", Ref{Registry: "ghcr.io", Repository: "acme/api", Tag: "v2"}},
+		{"Registry.Example.com:5000/team/app", Ref{Registry: "registry.example.com:5000", Repository: "team/app", Tag: "latest"}},
+		{"localhost/app@sha256:abc", Ref{Registry: "localhost", Repository: "app", Digest: "sha256:abc"}},
+		{"ghcr.io/acme/api:v2@sha256:abc", Ref{Registry: "ghcr.io", Repository: "acme/api", Tag: "v2", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.image); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+	if got := Parse("ghcr.io/acme/api:v2").Name(); got != "ghcr.io/acme/api" {
+		t.Errorf("Name = %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package init
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// configureFeatures offers the subsystems that stay off until their config
+// section exists: the audit log, verifying results, operation leases and a
+// change freeze. Each is written with defaults that suit most teams; none
+// relaxes the tier rules.
+func configureFeatures(cfg *config.Config, opts *Options) {
+	fmt.Println()
+	output.PrintInfo("Configuring optional features")
+	output.PrintSublog("These are off until enabled here or in the config file.")
+	fmt.Println()
+
+	if promptYesNo("Keep an audit log of guarded commands?", true) {
+		cfg.Audit = config.AuditConfig{
+			Enabled: true,
+			MaxAge:  promptWithDefault("  Keep entries for", "90d"),
+		}
+		cfg.Audit.CaptureOutput = promptYesNo("  Record kubectl's output of confirmed destructive commands?", false)
+	}
+
+	if promptYesNo("Check that confirmed deletes, scales and drains took effect?", false) {
+		cfg.Verify = config.VerifyConfig{Enabled: true}
+	}
+
+	if promptYesNo("Take a per-cluster Lease for drains and deletes, so two people can't run them at once?", false) {
+		cfg.Lease = config.LeaseConfig{Enabled: true, Actions: []string{"drain", "delete"}}
+	}
+
+	output.PrintSublog("Freeze windows block every change to matching clusters while open.")
+	if promptYesNo("Add a weekly change freeze?", false) {
+		patterns := parseCommaSeparated(promptWithDefault("  Cluster patterns (comma-separated)", strings.Join(opts.ProdPatterns, ",")))
+		window := config.MaintenanceWindow{
+			Clusters: patterns,
+			Days:     parseCommaSeparated(promptWithDefault("  Days (comma-separated)", "fri")),
+			From:     promptWithDefault("  From (HH:MM)", "15:00"),
+			To:       promptWithDefault("  To (HH:MM)", "23:59"),
+		}
+		probe := &config.Config{FreezeWindows: map[string]config.MaintenanceWindow{"weekly": window}}
+		if err := probe.Validate(); err != nil {
+			output.PrintWarning(fmt.Sprintf("Skipping the freeze window: %v", err))
+		} else {
+			cfg.FreezeWindows = probe.FreezeWindows
+		}
+	}
+}
+
+// setFeatures writes the sections configureFeatures enabled, with a comment
+// saying what each does
+func setFeatures(doc *config.Document, cfg *config.Config) error {
+	sections := []struct {
+		key     string
+		value   any
+		set     bool
+		comment string
+	}{
+		{"audit", cfg.Audit, cfg.Audit.Enabled, "Record every guarded command, including blocked ones; see 'kctl audit'"},
+		{"verify", cfg.Verify, cfg.Verify.Enabled, "Check that confirmed destructive commands took effect"},
+		{"lease", cfg.Lease, cfg.Lease.Enabled, "Take a per-cluster Lease before these actions, so they don't overlap"},
+		{"freeze_windows", cfg.FreezeWindows, len(cfg.FreezeWindows) > 0, "Every change to matching clusters is blocked while a window is open"},
+	}
+	for _, s := range sections {
+		if !s.set {
+			continue
+		}
+		if err := doc.Set([]string{s.key}, s.value); err != nil {
+			return err
+		}
+		doc.SetComment([]string{s.key}, s.comment)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package init
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestBuildConfigDocument_Features(t *testing.T) {
+	cfg := buildConfigFromOptions(DefaultOptions())
+	doc, err := buildConfigDocument(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := doc.Bytes()
+	for _, key := range []string{"audit:", "verify:", "lease:", "freeze_windows:", "maintenance_windows:"} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("config without features has %s\n%s", key, data)
+		}
+	}
+
+	cfg.Audit = config.AuditConfig{Enabled: true, MaxAge: "90d"}
+	cfg.Lease = config.LeaseConfig{Enabled: true, Actions: []string{"drain", "delete"}}
+	cfg.FreezeWindows = map[string]config.MaintenanceWindow{
+		"weekly": {Clusters: []string{"*-prod"}, Days: []string{"fri"}, From: "15:00", To: "23:59"},
+	}
+	if doc, err = buildConfigDocument(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = doc.Bytes()
+	parsed, err := doc.Config()
+	if err != nil {
+		t.Fatalf("generated config doesn't parse: %v\n%s", err, data)
+	}
+	if err := parsed.Validate(); err != nil {
+		t.Errorf("generated config is invalid: %v\n%s", err, data)
+	}
+	if !parsed.Audit.Enabled || parsed.Audit.MaxAge != "90d" || !parsed.Lease.Enabled || parsed.Verify.Enabled ||
+		len(parsed.MaintenanceWindows) > 0 {
+		t.Errorf("features not written as configured:\n%s", data)
+	}
+	if w := parsed.FreezeWindows["weekly"]; w.From != "15:00" || len(w.Days) != 1 {
+		t.Errorf("freeze window = %+v", w)
+	}
+	if !strings.Contains(string(data), "# Record every guarded command") {
+		t.Errorf("audit section has no comment:\n%s", data)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 
@@ -18,6 +17,7 @@ type Options struct {
 	// Non-interactive mode options
 	NonInteractive  bool
 	Force           bool     // Overwrite existing config
+	Merge           bool     // Add to an existing config instead of replacing it
 	ProdPatterns    []string // Production cluster patterns
 	StagingPatterns []string // Staging cluster patterns
 	DevPatterns     []string // Development cluster patterns
@@ -25,6 +25,9 @@ type Options struct {
 	StagingActions  []string // Actions requiring confirmation on staging
 	BlockedActions  []string // Globally blocked actions
 	OutputPath      string   // Custom output path
+	// FromKubeconfig writes an explicit cluster entry for each kubeconfig
+	// context, classified by patterns and heuristics; it implies NonInteractive
+	FromKubeconfig bool
 }
 
 // DefaultOptions returns default initialization options
@@ -53,10 +56,22 @@ func Run(opts *Options) error {
 		outputPath = config.ConfigPath()
 	}
 
+	if opts.Merge && opts.Force {
+		return fmt.Errorf("--merge and --force cannot be used together")
+	}
+
+	// Merge into an existing config when requested
+	if opts.Merge {
+		if _, err := os.Stat(outputPath); err == nil {
+			return runMerge(opts, outputPath)
+		}
+		output.PrintSublog(fmt.Sprintf("No config at %s yet; creating a new one", outputPath))
+	}
+
 	// Check if config already exists
 	if _, err := os.Stat(outputPath); err == nil && !opts.Force {
 		if opts.NonInteractive {
-			return fmt.Errorf("config file already exists at %s (use --force to overwrite)", outputPath)
+			return fmt.Errorf("config file already exists at %s (use --merge to add to it or --force to overwrite)", outputPath)
 		}
 		output.PrintWarning(fmt.Sprintf("Config file already exists at %s", outputPath))
 		if !promptYesNo("Do you want to overwrite it?", false) {
@@ -69,12 +84,12 @@ func Run(opts *Options) error {
 	var err error
 
 	if opts.NonInteractive {
-		cfg = buildConfigFromOptions(opts)
+		cfg, err = buildNonInteractive(opts)
 	} else {
 		cfg, err = runInteractiveInit(opts)
-		if err != nil {
-			return err
-		}
+	}
+	if err != nil {
+		return err
 	}
 
 	// Write config to file
@@ -98,7 +113,7 @@ func runInteractiveInit(opts *Options) (*config.Config, error) {
 	cfg := &config.Config{
 		Defaults: config.DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      []string{},
+			BlockedActions:      config.ActionList{},
 		},
 		Clusters: make(map[string]config.ClusterRules),
 		Tiers:    make(map[string]config.TierConfig),
@@ -112,16 +127,26 @@ func runInteractiveInit(opts *Options) (*config.Config, error) {
 	}
 
 	if len(contexts) > 0 {
+		// Suggest a tier for each context based on the default patterns
+		patternCfg := buildConfigFromOptions(opts)
+		suggestions := make(map[string]Suggestion, len(contexts))
+
 		fmt.Println()
 		output.PrintInfo("Detected kubectl contexts:")
 		for i, ctx := range contexts {
-			fmt.Printf("  %d. %s\n", i+1, ctx)
+			suggestion := SuggestTier(patternCfg, ctx)
+			suggestions[ctx] = suggestion
+			marker := ""
+			if suggestion.Unmatched {
+				marker = " ⚠️"
+			}
+			fmt.Printf("  %d. %s → %s (%s)%s\n", i+1, ctx, suggestion.Tier, suggestion.Reason, marker)
 		}
 		fmt.Println()
 
 		// Ask if user wants to configure specific clusters
 		if promptYesNo("Would you like to configure rules for specific clusters?", true) {
-			cfg.Clusters = configureSpecificClusters(contexts)
+			cfg.Clusters = configureSpecificClusters(contexts, suggestions)
 		}
 	}
 
@@ -159,25 +184,36 @@ func runInteractiveInit(opts *Options) (*config.Config, error) {
 	fmt.Println()
 	output.PrintSublog("You can block certain actions entirely (they will always be denied).")
 	if promptYesNo("Would you like to block any actions globally?", false) {
-		cfg.Defaults.BlockedActions = selectActions("Select actions to block globally", []string{})
+		cfg.Defaults.BlockedActions = config.Actions(selectActions("Select actions to block globally", []string{})...)
 	}
 
+	// Step 4: Offer optional features
+	configureFeatures(cfg, opts)
+
 	return cfg, nil
 }
 
-// configureSpecificClusters lets user configure rules for specific clusters
-func configureSpecificClusters(contexts []string) map[string]config.ClusterRules {
+// configureSpecificClusters lets user configure rules for specific clusters,
+// offering each context's suggested tier as the default answer
+func configureSpecificClusters(contexts []string, suggestions map[string]Suggestion) map[string]config.ClusterRules {
 	clusters := make(map[string]config.ClusterRules)
-	
+
 	fmt.Println()
 	output.PrintSublog("For each cluster, you can set its tier and specific rules.")
-	output.PrintSublog("Press Enter to skip a cluster.")
+	output.PrintSublog("Press Enter to accept the suggested tier, or type 'skip'.")
 	fmt.Println()
 
 	for _, ctx := range contexts {
+		suggested := "skip"
+		if s, ok := suggestions[ctx]; ok {
+			suggested = s.Tier
+			if s.Unmatched && s.Tier != "skip" {
+				output.PrintSublog(fmt.Sprintf("'%s' matches no pattern; suggestion based on %s", ctx, s.Reason))
+			}
+		}
 		fmt.Printf("Configure cluster '%s'? ", ctx)
-		tier := promptWithDefault("tier (production/staging/development/skip)", "skip")
-		
+		tier := promptWithDefault("tier (production/staging/development/skip)", suggested)
+
 		if tier == "skip" || tier == "" {
 			continue
 		}
@@ -199,7 +235,8 @@ func configureSpecificClusters(contexts []string) map[string]config.ClusterRules
 		clusters[ctx] = config.ClusterRules{
 			Tier:                tier,
 			RequireConfirmation: actions,
-			BlockedActions:      []string{},
+			BlockedActions:      config.ActionList{},
+			Banner:              tier == "production",
 		}
 		fmt.Println()
 	}
@@ -230,13 +267,14 @@ func configureTier(tierName string, defaultPatterns, defaultActions []string) co
 	return config.TierConfig{
 		Patterns:            patterns,
 		RequireConfirmation: actions,
-		BlockedActions:      []string{},
+		BlockedActions:      config.ActionList{},
+		Banner:              tierName == "production",
 	}
 }
 
 // selectActions presents a multi-select for actions
 func selectActions(prompt string, defaults []string) []string {
-	allActions := []string{"delete", "drain", "scale", "edit", "apply", "exec", "rollout"}
+	allActions := []string{"delete", "drain", "scale", "edit", "apply", "exec", "rollout", "node-access"}
 	
 	fmt.Println(prompt + ":")
 	for i, action := range allActions {
@@ -301,7 +339,7 @@ func buildConfigFromOptions(opts *Options) *config.Config {
 	cfg := &config.Config{
 		Defaults: config.DefaultsConfig{
 			RequireConfirmation: false,
-			BlockedActions:      opts.BlockedActions,
+			BlockedActions:      config.Actions(opts.BlockedActions...),
 		},
 		Clusters: make(map[string]config.ClusterRules),
 		Tiers:    make(map[string]config.TierConfig),
@@ -311,7 +349,8 @@ func buildConfigFromOptions(opts *Options) *config.Config {
 		cfg.Tiers["production"] = config.TierConfig{
 			Patterns:            opts.ProdPatterns,
 			RequireConfirmation: opts.ProdActions,
-			BlockedActions:      []string{},
+			BlockedActions:      config.ActionList{},
+			Banner:              true,
 		}
 	}
 
@@ -319,7 +358,7 @@ func buildConfigFromOptions(opts *Options) *config.Config {
 		cfg.Tiers["staging"] = config.TierConfig{
 			Patterns:            opts.StagingPatterns,
 			RequireConfirmation: opts.StagingActions,
-			BlockedActions:      []string{},
+			BlockedActions:      config.ActionList{},
 		}
 	}
 
@@ -327,127 +366,98 @@ func buildConfigFromOptions(opts *Options) *config.Config {
 		cfg.Tiers["development"] = config.TierConfig{
 			Patterns:            opts.DevPatterns,
 			RequireConfirmation: []string{},
-			BlockedActions:      []string{},
+			BlockedActions:      config.ActionList{},
 		}
 	}
 
 	return cfg
 }
 
+// buildNonInteractive builds the config from opts alone, classifying the
+// kubeconfig's contexts when asked to
+func buildNonInteractive(opts *Options) (*config.Config, error) {
+	if !opts.FromKubeconfig {
+		return buildConfigFromOptions(opts), nil
+	}
+	cfg, report, err := buildConfigFromKubeconfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	printClassification(report)
+	return cfg, nil
+}
+
 // writeConfig writes the config to a YAML file
 func writeConfig(cfg *config.Config, path string) error {
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	doc, err := buildConfigDocument(cfg)
+	if err != nil {
 		return err
 	}
+	return doc.Save(path)
+}
 
-	// Generate YAML with comments
-	content := generateConfigYAML(cfg)
+// buildConfigDocument builds a commented YAML document for a new config
+func buildConfigDocument(cfg *config.Config) (*config.Document, error) {
+	doc := config.NewDocument()
+	doc.SetHeader(`kubectl-enhanced-cli Configuration
+Generated by 'kctl init'
 
-	return os.WriteFile(path, []byte(content), 0644)
-}
+This file controls RBAC-like protections for kubectl commands.
+Edit this file to customize behavior per cluster or tier.`)
 
-// generateConfigYAML generates a well-commented YAML config
-func generateConfigYAML(cfg *config.Config) string {
-	var sb strings.Builder
-
-	sb.WriteString("# kubectl-enhanced-cli Configuration\n")
-	sb.WriteString("# Generated by 'kctl init'\n")
-	sb.WriteString("#\n")
-	sb.WriteString("# This file controls RBAC-like protections for kubectl commands.\n")
-	sb.WriteString("# Edit this file to customize behavior per cluster or tier.\n\n")
-
-	sb.WriteString("# Global defaults applied to all clusters unless overridden\n")
-	sb.WriteString("defaults:\n")
-	sb.WriteString(fmt.Sprintf("  require_confirmation: %v\n", cfg.Defaults.RequireConfirmation))
-	if len(cfg.Defaults.BlockedActions) > 0 {
-		sb.WriteString("  blocked_actions:\n")
-		for _, action := range cfg.Defaults.BlockedActions {
-			sb.WriteString(fmt.Sprintf("    - %s\n", action))
-		}
-	} else {
-		sb.WriteString("  blocked_actions: []\n")
-	}
-
-	sb.WriteString("\n# Explicit cluster rules (highest priority)\n")
-	sb.WriteString("# Use exact context names or glob patterns\n")
-	sb.WriteString("clusters:\n")
-	if len(cfg.Clusters) == 0 {
-		sb.WriteString("  # Example:\n")
-		sb.WriteString("  # my-prod-cluster:\n")
-		sb.WriteString("  #   tier: production\n")
-		sb.WriteString("  #   require_confirmation: [delete, drain]\n")
-		sb.WriteString("  #   blocked_actions: []\n")
-	} else {
-		// Sort cluster names for consistent output
-		clusterNames := make([]string, 0, len(cfg.Clusters))
-		for name := range cfg.Clusters {
-			clusterNames = append(clusterNames, name)
-		}
-		sort.Strings(clusterNames)
-
-		for _, name := range clusterNames {
-			rules := cfg.Clusters[name]
-			sb.WriteString(fmt.Sprintf("  %s:\n", name))
-			sb.WriteString(fmt.Sprintf("    tier: %s\n", rules.Tier))
-			writeYAMLStringArray(&sb, "    require_confirmation", rules.RequireConfirmation)
-			writeYAMLStringArray(&sb, "    blocked_actions", rules.BlockedActions)
-		}
+	defaults := cfg.Defaults
+	if defaults.BlockedActions == nil {
+		defaults.BlockedActions = config.ActionList{}
+	}
+	if err := doc.Set([]string{"defaults"}, defaults); err != nil {
+		return nil, err
 	}
+	doc.SetComment([]string{"defaults"}, "Global defaults applied to all clusters unless overridden")
 
-	sb.WriteString("\n# Tier-based rules (fallback when no explicit cluster match)\n")
-	sb.WriteString("# Clusters are matched against tier patterns\n")
-	sb.WriteString("tiers:\n")
+	clusters := cfg.Clusters
+	if clusters == nil {
+		clusters = map[string]config.ClusterRules{}
+	}
+	if err := doc.Set([]string{"clusters"}, clusters); err != nil {
+		return nil, err
+	}
+	clustersComment := "Explicit cluster rules (highest priority)\nUse exact context names or glob patterns"
+	if len(clusters) == 0 {
+		clustersComment += `
+Example:
+  my-prod-cluster:
+    tier: production
+    require_confirmation: [delete, drain]
+    blocked_actions: []`
+	}
+	doc.SetComment([]string{"clusters"}, clustersComment)
 
-	// Write tiers in a consistent order
+	// Write tiers in a consistent order: standard tiers first, then the rest
+	if err := doc.Set([]string{"tiers"}, map[string]config.TierConfig{}); err != nil {
+		return nil, err
+	}
+	doc.SetComment([]string{"tiers"}, "Tier-based rules (fallback when no explicit cluster match)\nClusters are matched against tier patterns")
 	tierOrder := []string{"production", "staging", "development"}
-	for _, tierName := range tierOrder {
-		if tier, ok := cfg.Tiers[tierName]; ok {
-			sb.WriteString(fmt.Sprintf("  %s:\n", tierName))
-			sb.WriteString("    patterns:\n")
-			for _, pattern := range tier.Patterns {
-				sb.WriteString(fmt.Sprintf("      - \"%s\"\n", pattern))
-			}
-			writeYAMLStringArray(&sb, "    require_confirmation", tier.RequireConfirmation)
-			writeYAMLStringArray(&sb, "    blocked_actions", tier.BlockedActions)
-			sb.WriteString("\n")
+	var extra []string
+	for name := range cfg.Tiers {
+		if name != "production" && name != "staging" && name != "development" {
+			extra = append(extra, name)
 		}
 	}
-
-	// Write any additional tiers not in the standard order
-	for tierName, tier := range cfg.Tiers {
-		found := false
-		for _, t := range tierOrder {
-			if t == tierName {
-				found = true
-				break
-			}
-		}
-		if !found {
-			sb.WriteString(fmt.Sprintf("  %s:\n", tierName))
-			sb.WriteString("    patterns:\n")
-			for _, pattern := range tier.Patterns {
-				sb.WriteString(fmt.Sprintf("      - \"%s\"\n", pattern))
+	sort.Strings(extra)
+	for _, name := range append(tierOrder, extra...) {
+		if tier, ok := cfg.Tiers[name]; ok {
+			if err := doc.Set([]string{"tiers", name}, tier); err != nil {
+				return nil, err
 			}
-			writeYAMLStringArray(&sb, "    require_confirmation", tier.RequireConfirmation)
-			writeYAMLStringArray(&sb, "    blocked_actions", tier.BlockedActions)
-			sb.WriteString("\n")
 		}
 	}
 
-	return sb.String()
-}
-
-func writeYAMLStringArray(sb *strings.Builder, key string, values []string) {
-	if len(values) == 0 {
-		sb.WriteString(fmt.Sprintf("%s: []\n", key))
-	} else {
-		sb.WriteString(fmt.Sprintf("%s:\n", key))
-		for _, v := range values {
-			sb.WriteString(fmt.Sprintf("      - %s\n", v))
-		}
+	if err := setFeatures(doc, cfg); err != nil {
+		return nil, err
 	}
+
+	return doc, nil
 }
 
 // Prompt helpers
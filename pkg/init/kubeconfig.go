@@ -0,0 +1,135 @@
+package init
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// Classification is the tier chosen for one kubeconfig context
+type Classification struct {
+	Context  string
+	Server   string
+	Provider string
+	Suggestion
+}
+
+// getContexts and getServer read the kubeconfig; replaced in tests
+var (
+	getContexts = kubectl.GetAllContexts
+	getServer   = kubectl.GetServer
+)
+
+// buildConfigFromKubeconfig is buildConfigFromOptions with an explicit
+// cluster entry for every context that could be classified, so a policy
+// review sees each cluster's tier rather than patterns alone
+func buildConfigFromKubeconfig(opts *Options) (*config.Config, []Classification, error) {
+	cfg := buildConfigFromOptions(opts)
+	report, err := classifyContexts(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range report {
+		if c.Tier != "skip" {
+			cfg.Clusters[c.Context] = clusterEntry(c.Tier, opts)
+		}
+	}
+	return cfg, report, nil
+}
+
+// mergeFromKubeconfig returns, for merging into existing, an entry for each
+// kubeconfig context existing has no cluster entry for, classified under
+// existing's tiers, and the tiers those entries need that existing lacks.
+// Entries existing already has are left alone, as are its tiers.
+func mergeFromKubeconfig(opts *Options, existing *config.Config) (*config.Config, []Classification, error) {
+	report, err := classifyContexts(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := buildConfigFromOptions(opts)
+	delta := &config.Config{
+		Clusters: make(map[string]config.ClusterRules),
+		Tiers:    make(map[string]config.TierConfig),
+	}
+	missing := report[:0]
+	for _, c := range report {
+		if _, ok := existing.Clusters[c.Context]; ok {
+			continue
+		}
+		missing = append(missing, c)
+		if c.Tier == "skip" {
+			continue
+		}
+		delta.Clusters[c.Context] = clusterEntry(c.Tier, opts)
+		if _, ok := existing.Tiers[c.Tier]; !ok {
+			delta.Tiers[c.Tier] = template.Tiers[c.Tier]
+		}
+	}
+	return delta, missing, nil
+}
+
+// classifyContexts suggests a tier under cfg for each kubeconfig context
+func classifyContexts(cfg *config.Config) ([]Classification, error) {
+	contexts, err := getContexts()
+	if err != nil {
+		return nil, fmt.Errorf("could not read kubectl contexts: %w", err)
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("the kubeconfig has no contexts")
+	}
+
+	report := make([]Classification, 0, len(contexts))
+	for _, ctx := range contexts {
+		server, _ := getServer(ctx)
+		report = append(report, Classification{
+			Context:    ctx,
+			Server:     server,
+			Provider:   Provider(ctx, server),
+			Suggestion: SuggestTierForServer(cfg, ctx, server),
+		})
+	}
+	return report, nil
+}
+
+// clusterEntry returns explicit rules for a cluster of tier, matching the
+// rules the tier is given
+func clusterEntry(tier string, opts *Options) config.ClusterRules {
+	actions := []string{}
+	switch tier {
+	case "production":
+		actions = opts.ProdActions
+	case "staging":
+		actions = opts.StagingActions
+	}
+	return config.ClusterRules{
+		Tier:                tier,
+		RequireConfirmation: actions,
+		BlockedActions:      config.ActionList{},
+		Banner:              tier == "production",
+	}
+}
+
+// printClassification reports the tier chosen for each context and why
+func printClassification(report []Classification) {
+	fmt.Println()
+	output.PrintInfo("Classified kubectl contexts:")
+	var skipped int
+	for _, c := range report {
+		marker := ""
+		if c.Tier == "skip" {
+			marker = " ⚠️  (no entry written)"
+			skipped++
+		}
+		provider := ""
+		if c.Provider != "" {
+			provider = c.Provider + ", "
+		}
+		fmt.Printf("  %s → %s (%s%s)%s\n", c.Context, c.Tier, provider, c.Reason, marker)
+	}
+	if skipped > 0 {
+		output.PrintSublog(fmt.Sprintf("%d context(s) could not be classified; add them under clusters by hand", skipped))
+	}
+	fmt.Println()
+}
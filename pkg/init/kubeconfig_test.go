@@ -0,0 +1,154 @@
+package init
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestSuggestTierForServer(t *testing.T) {
+	cfg := buildConfigFromOptions(DefaultOptions())
+
+	tests := []struct {
+		name    string
+		context string
+		server  string
+		tier    string
+	}{
+		{"pattern wins over server", "app-prod", "https://127.0.0.1:6443", "production"},
+		{"local server", "workbench", "https://127.0.0.1:52814", "development"},
+		{"docker desktop host", "desktop", "https://kubernetes.docker.internal:6443", "development"},
+		{"tier in server host", "payments", "https://api.stg.example.com", "staging"},
+		{"AKS host", "payments", "https://payments-prd-1a2b3c.hcp.westeurope.azmk8s.io:443", "production"},
+		{"nothing to go on", "payments", "https://ABCDEF.gr7.us-east-1.eks.amazonaws.com", "skip"},
+		{"no server", "payments", "", "skip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := SuggestTierForServer(cfg, tt.context, tt.server); s.Tier != tt.tier {
+				t.Errorf("SuggestTierForServer(%q, %q).Tier = %q, want %q (%s)", tt.context, tt.server, s.Tier, tt.tier, s.Reason)
+			}
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	tests := []struct{ context, server, want string }{
+		{"gke_project_us-central1_api", "https://34.1.2.3", "GKE"},
+		{"arn:aws:eks:us-east-1:123:cluster/api", "", "EKS"},
+		{"api", "https://ABCDEF.gr7.us-east-1.eks.amazonaws.com", "EKS"},
+		{"api", "https://api-1a2b.hcp.westeurope.azmk8s.io:443", "AKS"},
+		{"api", "https://10.0.0.1:6443", ""},
+	}
+	for _, tt := range tests {
+		if got := Provider(tt.context, tt.server); got != tt.want {
+			t.Errorf("Provider(%q, %q) = %q, want %q", tt.context, tt.server, got, tt.want)
+		}
+	}
+}
+
+// fakeKubeconfig makes the kubeconfig hold the contexts of servers
+func fakeKubeconfig(t *testing.T, servers map[string]string) {
+	t.Helper()
+	var contexts []string
+	for ctx := range servers {
+		contexts = append(contexts, ctx)
+	}
+	sort.Strings(contexts)
+	previousContexts, previousServer := getContexts, getServer
+	getContexts = func() ([]string, error) { return contexts, nil }
+	getServer = func(context string) (string, error) {
+		if s, ok := servers[context]; ok {
+			return s, nil
+		}
+		return "", fmt.Errorf("no server")
+	}
+	t.Cleanup(func() { getContexts, getServer = previousContexts, previousServer })
+}
+
+func TestBuildConfigFromKubeconfig(t *testing.T) {
+	fakeKubeconfig(t, map[string]string{
+		"app-prod":  "https://10.0.0.1:6443",
+		"kind-test": "https://127.0.0.1:40000",
+		"payments":  "https://api.staging.example.com",
+		"mystery":   "https://10.0.0.9:6443",
+	})
+
+	opts := DefaultOptions()
+	cfg, report, err := buildConfigFromKubeconfig(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 4 {
+		t.Fatalf("report has %d entries, want 4", len(report))
+	}
+
+	want := map[string]string{"app-prod": "production", "kind-test": "development", "payments": "staging"}
+	for ctx, tier := range want {
+		entry, ok := cfg.Clusters[ctx]
+		if !ok || entry.Tier != tier {
+			t.Errorf("cluster %s = %+v, want tier %s", ctx, entry, tier)
+		}
+	}
+	if _, ok := cfg.Clusters["mystery"]; ok {
+		t.Error("unclassified context got a cluster entry")
+	}
+	if got := cfg.Clusters["app-prod"]; !got.Banner || len(got.RequireConfirmation) != len(opts.ProdActions) {
+		t.Errorf("production entry = %+v, want the production tier's rules", got)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("generated config is invalid: %v", err)
+	}
+}
+
+func TestMergeFromKubeconfig(t *testing.T) {
+	fakeKubeconfig(t, map[string]string{
+		"legacy-prod": "https://10.0.0.1:6443",
+		"app-prod":    "https://10.0.0.2:6443",
+		"kind-test":   "https://127.0.0.1:40000",
+	})
+	doc, err := config.ParseDocument([]byte(mergeBase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing, err := doc.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delta, report, err := mergeFromKubeconfig(DefaultOptions(), existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 {
+		t.Errorf("report = %+v, want only the contexts without an entry", report)
+	}
+	if _, ok := delta.Clusters["legacy-prod"]; ok {
+		t.Error("an existing cluster entry was merged again")
+	}
+	if delta.Clusters["app-prod"].Tier != "production" || delta.Clusters["kind-test"].Tier != "development" {
+		t.Errorf("clusters = %+v, want entries for app-prod and kind-test", delta.Clusters)
+	}
+	if _, ok := delta.Tiers["production"]; ok {
+		t.Error("the existing production tier would be extended")
+	}
+	if _, ok := delta.Tiers["development"]; !ok {
+		t.Error("the development tier the new entry needs is missing")
+	}
+
+	if _, err := mergeIntoDocument(doc, delta); err != nil {
+		t.Fatal(err)
+	}
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := string(data)
+	if !strings.Contains(merged, "# Legacy cluster, see ticket OPS-1\n  legacy-prod:\n    tier: production\n    require_confirmation: [delete]\n") ||
+		!strings.Contains(merged, "  production:\n    patterns:\n      - \"*-prod\"\n    require_confirmation:\n      - delete\n    blocked_actions: []\n") {
+		t.Errorf("existing entries changed:\n%s", merged)
+	}
+}
@@ -0,0 +1,201 @@
+package init
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// runMerge adds new clusters, tiers and actions to an existing config file
+// without touching unrelated entries or comments
+func runMerge(opts *Options, path string) error {
+	doc, err := config.LoadDocument(path)
+	if err != nil {
+		return fmt.Errorf("cannot merge into invalid config: %w", err)
+	}
+	existing, err := doc.Config()
+	if err != nil {
+		return fmt.Errorf("cannot merge into invalid config: %w", err)
+	}
+
+	var delta *config.Config
+	switch {
+	case opts.FromKubeconfig:
+		var report []Classification
+		if delta, report, err = mergeFromKubeconfig(opts, existing); err != nil {
+			return err
+		}
+		if len(report) > 0 {
+			printClassification(report)
+		}
+	case opts.NonInteractive:
+		if delta, err = buildNonInteractive(opts); err != nil {
+			return err
+		}
+	default:
+		delta = runInteractiveMerge(opts, existing)
+	}
+
+	changes, err := mergeIntoDocument(doc, delta)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		output.PrintSublog("Nothing new to add; config left unchanged")
+		return nil
+	}
+
+	if err := doc.Save(path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	output.PrintSuccess(fmt.Sprintf("Merged %d change(s) into %s", len(changes), path))
+	for _, change := range changes {
+		output.PrintSublog(change)
+	}
+	return nil
+}
+
+// runInteractiveMerge asks only about things the existing config lacks and
+// returns them as a config to be merged
+func runInteractiveMerge(opts *Options, existing *config.Config) *config.Config {
+	delta := &config.Config{
+		Clusters: make(map[string]config.ClusterRules),
+		Tiers:    make(map[string]config.TierConfig),
+	}
+
+	fmt.Println()
+	output.PrintInfo("kubectl-enhanced-cli Configuration Wizard (merge mode)")
+	output.PrintSublog("Existing entries are kept; you will only be asked about additions.")
+	fmt.Println()
+
+	// New clusters: contexts without an explicit entry
+	contexts, err := kubectl.GetAllContexts()
+	if err != nil {
+		output.PrintWarning("Could not fetch kubectl contexts.")
+	}
+	var newContexts []string
+	suggestions := make(map[string]Suggestion)
+	for _, ctx := range contexts {
+		if _, ok := existing.Clusters[ctx]; ok {
+			continue
+		}
+		newContexts = append(newContexts, ctx)
+		suggestions[ctx] = SuggestTier(existing, ctx)
+	}
+
+	if len(newContexts) > 0 {
+		output.PrintInfo("Contexts without an explicit cluster entry:")
+		for i, ctx := range newContexts {
+			s := suggestions[ctx]
+			fmt.Printf("  %d. %s → %s (%s)\n", i+1, ctx, s.Tier, s.Reason)
+		}
+		fmt.Println()
+		if promptYesNo("Would you like to add rules for any of these clusters?", true) {
+			delta.Clusters = configureSpecificClusters(newContexts, suggestions)
+		}
+	}
+
+	// Tiers: add missing ones, extend existing ones
+	defaults := map[string]struct{ patterns, actions []string }{
+		"production":  {opts.ProdPatterns, opts.ProdActions},
+		"staging":     {opts.StagingPatterns, opts.StagingActions},
+		"development": {opts.DevPatterns, []string{}},
+	}
+	for _, tierName := range []string{"production", "staging", "development"} {
+		if tier, ok := existing.Tiers[tierName]; ok {
+			if promptYesNo(fmt.Sprintf("Add actions requiring confirmation to the %s tier?", tierName), false) {
+				selected := selectActions("  Select actions requiring confirmation", tier.RequireConfirmation)
+				delta.Tiers[tierName] = config.TierConfig{RequireConfirmation: selected}
+			}
+			continue
+		}
+		if promptYesNo(fmt.Sprintf("The %s tier is not configured. Add it?", tierName), true) {
+			d := defaults[tierName]
+			delta.Tiers[tierName] = configureTier(tierName, d.patterns, d.actions)
+		}
+	}
+
+	// Global blocked actions
+	fmt.Println()
+	if promptYesNo("Would you like to block additional actions globally?", false) {
+		delta.Defaults.BlockedActions = config.Actions(selectActions("Select actions to block globally", existing.Defaults.BlockedActions.Active())...)
+	}
+
+	return delta
+}
+
+// mergeIntoDocument adds entries from delta that are missing in doc.
+// Existing scalar values are never changed; lists only gain items.
+// Returns a description of each change made.
+func mergeIntoDocument(doc *config.Document, delta *config.Config) ([]string, error) {
+	var changes []string
+
+	if len(delta.Defaults.BlockedActions) > 0 {
+		added, err := doc.AppendUnique([]string{"defaults", "blocked_actions"}, delta.Defaults.BlockedActions.Names())
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range added {
+			changes = append(changes, fmt.Sprintf("defaults: blocked action '%s'", a))
+		}
+	}
+
+	for _, name := range sortedKeys(delta.Clusters) {
+		if doc.Get([]string{"clusters", name}) != nil {
+			continue
+		}
+		if err := doc.Set([]string{"clusters", name}, delta.Clusters[name]); err != nil {
+			return nil, err
+		}
+		changes = append(changes, fmt.Sprintf("clusters: added '%s' (%s)", name, delta.Clusters[name].Tier))
+	}
+
+	for _, name := range sortedKeys(delta.Tiers) {
+		tier := delta.Tiers[name]
+		if doc.Get([]string{"tiers", name}) == nil {
+			if err := doc.Set([]string{"tiers", name}, tier); err != nil {
+				return nil, err
+			}
+			changes = append(changes, fmt.Sprintf("tiers: added '%s'", name))
+			continue
+		}
+
+		lists := []struct {
+			key    string
+			values []string
+			label  string
+		}{
+			{"patterns", tier.Patterns, "pattern"},
+			{"require_confirmation", tier.RequireConfirmation, "confirmation for"},
+			{"blocked_actions", tier.BlockedActions.Names(), "blocked action"},
+		}
+		for _, list := range lists {
+			if len(list.values) == 0 {
+				continue
+			}
+			added, err := doc.AppendUnique([]string{"tiers", name, list.key}, list.values)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range added {
+				changes = append(changes, fmt.Sprintf("tiers.%s: %s '%s'", name, list.label, v))
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
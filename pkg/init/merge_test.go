@@ -0,0 +1,113 @@
+package init
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+const mergeBase = `# Team policy - do not remove
+defaults:
+  require_confirmation: false
+  blocked_actions: []
+
+clusters:
+  # Legacy cluster, see ticket OPS-1
+  legacy-prod:
+    tier: production
+    require_confirmation: [delete]
+    blocked_actions: []
+
+tiers:
+  production:
+    patterns:
+      - "*-prod"
+    require_confirmation:
+      - delete
+    blocked_actions: []
+`
+
+func TestMergeIntoDocument(t *testing.T) {
+	doc, err := config.ParseDocument([]byte(mergeBase))
+	if err != nil {
+		t.Fatalf("Failed to parse base: %v", err)
+	}
+
+	delta := &config.Config{
+		Defaults: config.DefaultsConfig{BlockedActions: config.Actions("exec")},
+		Clusters: map[string]config.ClusterRules{
+			"legacy-prod": {Tier: "development"}, // must not overwrite
+			"new-stg":     {Tier: "staging", RequireConfirmation: []string{"delete"}},
+		},
+		Tiers: map[string]config.TierConfig{
+			"production": {Patterns: []string{"*-prod", "prod-*"}, RequireConfirmation: []string{"delete", "drain"}},
+			"staging":    {Patterns: []string{"*-stg"}, RequireConfirmation: []string{"delete"}},
+		},
+	}
+
+	changes, err := mergeIntoDocument(doc, delta)
+	if err != nil {
+		t.Fatalf("mergeIntoDocument failed: %v", err)
+	}
+	if len(changes) != 5 {
+		t.Errorf("Expected 5 changes, got %d: %v", len(changes), changes)
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	text := string(data)
+
+	for _, comment := range []string{"# Team policy - do not remove", "# Legacy cluster, see ticket OPS-1"} {
+		if !strings.Contains(text, comment) {
+			t.Errorf("Merged document lost comment %q:\n%s", comment, text)
+		}
+	}
+
+	var cfg config.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Merged document is not valid config: %v\n%s", err, text)
+	}
+	if cfg.Clusters["legacy-prod"].Tier != "production" {
+		t.Errorf("Existing cluster was modified: %+v", cfg.Clusters["legacy-prod"])
+	}
+	if cfg.Clusters["new-stg"].Tier != "staging" {
+		t.Errorf("New cluster not added: %+v", cfg.Clusters)
+	}
+	if got := cfg.Tiers["production"].Patterns; len(got) != 2 || got[1] != "prod-*" {
+		t.Errorf("Expected production patterns extended, got %v", got)
+	}
+	if got := cfg.Tiers["production"].RequireConfirmation; len(got) != 2 || got[1] != "drain" {
+		t.Errorf("Expected drain added to production, got %v", got)
+	}
+	if _, ok := cfg.Tiers["staging"]; !ok {
+		t.Error("Expected staging tier to be added")
+	}
+	if got := cfg.Defaults.BlockedActions; len(got) != 1 || got[0].Action != "exec" {
+		t.Errorf("Expected defaults.blocked_actions [exec], got %v", got)
+	}
+}
+
+func TestMergeIntoDocument_NoChanges(t *testing.T) {
+	doc, err := config.ParseDocument([]byte(mergeBase))
+	if err != nil {
+		t.Fatalf("Failed to parse base: %v", err)
+	}
+
+	delta := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {Patterns: []string{"*-prod"}, RequireConfirmation: []string{"delete"}},
+		},
+	}
+	changes, err := mergeIntoDocument(doc, delta)
+	if err != nil {
+		t.Fatalf("mergeIntoDocument failed: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
@@ -0,0 +1,162 @@
+package init
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+// Suggestion is the tier proposed for a detected context
+type Suggestion struct {
+	Tier   string // Suggested tier, or "skip" when nothing matched
+	Reason string // Human-readable explanation shown in the wizard
+	// Unmatched is true when no configured pattern matched the context
+	// and the suggestion comes from naming heuristics only
+	Unmatched bool
+}
+
+// tierKeywords maps name tokens to the tier they usually indicate
+var tierKeywords = map[string]string{
+	"prod":        "production",
+	"prd":         "production",
+	"production":  "production",
+	"live":        "production",
+	"staging":     "staging",
+	"stage":       "staging",
+	"stg":         "staging",
+	"uat":         "staging",
+	"preprod":     "staging",
+	"qa":          "staging",
+	"dev":         "development",
+	"development": "development",
+	"test":        "development",
+	"sandbox":     "development",
+	"local":       "development",
+	"kind":        "development",
+	"minikube":    "development",
+}
+
+// SuggestTier proposes a tier for a context using the configured patterns
+// first, then the cluster name embedded in provider-style context names
+// (EKS ARNs, GKE contexts, user@cluster), then keyword heuristics.
+func SuggestTier(cfg *config.Config, context string) Suggestion {
+	if tier := cfg.GetClusterRules(context).Tier; tier != "default" {
+		return Suggestion{Tier: tier, Reason: fmt.Sprintf("matches %s patterns", tier)}
+	}
+
+	name := clusterNameFromContext(context)
+	if name != context {
+		if tier := cfg.GetClusterRules(name).Tier; tier != "default" {
+			return Suggestion{
+				Tier:      tier,
+				Reason:    fmt.Sprintf("cluster name '%s' matches %s patterns", name, tier),
+				Unmatched: true,
+			}
+		}
+	}
+
+	for _, token := range nameTokens(name) {
+		if tier, ok := tierKeywords[token]; ok {
+			return Suggestion{
+				Tier:      tier,
+				Reason:    fmt.Sprintf("no pattern matches; name contains '%s'", token),
+				Unmatched: true,
+			}
+		}
+	}
+
+	return Suggestion{Tier: "skip", Reason: "no pattern matches", Unmatched: true}
+}
+
+// localHosts are API server hosts of clusters running on the workstation
+var localHosts = map[string]bool{
+	"localhost":                  true,
+	"host.docker.internal":       true,
+	"kubernetes.docker.internal": true,
+}
+
+// SuggestTierForServer is SuggestTier that, when nothing in the context's
+// name suggests a tier, also looks at its API server URL: a server on the
+// workstation is development, and the host may name the tier, as in
+// api.prod.example.com or payments-prd-1a2b.hcp.westeurope.azmk8s.io
+func SuggestTierForServer(cfg *config.Config, context, server string) Suggestion {
+	suggestion := SuggestTier(cfg, context)
+	if suggestion.Tier != "skip" || server == "" {
+		return suggestion
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Hostname() == "" {
+		return suggestion
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); localHosts[host] || ip != nil && ip.IsLoopback() {
+		return Suggestion{Tier: "development", Reason: fmt.Sprintf("server %s is local", host), Unmatched: true}
+	}
+	for _, token := range nameTokens(host) {
+		if tier, ok := tierKeywords[token]; ok {
+			return Suggestion{
+				Tier:      tier,
+				Reason:    fmt.Sprintf("server host %s contains '%s'", host, token),
+				Unmatched: true,
+			}
+		}
+	}
+	return suggestion
+}
+
+// Provider names the managed Kubernetes service a context's cluster runs
+// on, from its provider-generated name or API server URL, or "" when it
+// isn't recognised
+func Provider(context, server string) string {
+	var host string
+	if u, err := url.Parse(server); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	switch {
+	case strings.HasPrefix(context, "gke_"):
+		return "GKE"
+	case strings.HasPrefix(context, "arn:aws:eks:"):
+		return "EKS"
+	case strings.HasSuffix(host, ".eks.amazonaws.com"):
+		return "EKS"
+	case strings.HasSuffix(host, ".azmk8s.io"):
+		return "AKS"
+	case strings.HasSuffix(host, ".k8s.ondigitalocean.com"):
+		return "DOKS"
+	case strings.HasSuffix(host, ".linodelke.net"):
+		return "LKE"
+	}
+	return ""
+}
+
+// clusterNameFromContext extracts the cluster name from provider-generated
+// context names, returning the context unchanged when no format applies
+func clusterNameFromContext(context string) string {
+	switch {
+	case strings.HasPrefix(context, "arn:aws:eks:"):
+		// arn:aws:eks:<region>:<account>:cluster/<name>
+		if i := strings.LastIndex(context, "cluster/"); i >= 0 {
+			return context[i+len("cluster/"):]
+		}
+	case strings.HasPrefix(context, "gke_"):
+		// gke_<project>_<location>_<name>
+		parts := strings.SplitN(context, "_", 4)
+		if len(parts) == 4 {
+			return parts[3]
+		}
+	case strings.Contains(context, "@"):
+		// <user>@<cluster>
+		return context[strings.LastIndex(context, "@")+1:]
+	}
+	return context
+}
+
+// nameTokens splits a name into lowercase alphanumeric tokens
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
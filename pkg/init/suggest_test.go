@@ -0,0 +1,53 @@
+package init
+
+import (
+	"testing"
+)
+
+func TestSuggestTier(t *testing.T) {
+	cfg := buildConfigFromOptions(DefaultOptions())
+
+	tests := []struct {
+		name      string
+		context   string
+		tier      string
+		unmatched bool
+	}{
+		{"pattern match", "app-prod", "production", false},
+		{"dev pattern", "kind-test", "development", false},
+		{"EKS ARN with prod cluster name", "arn:aws:eks:us-east-1:123456:cluster/prod-payments", "production", true},
+		{"GKE context with staging name", "gke_my-project_us-central1_staging-api", "staging", true},
+		{"GKE keyword heuristic", "gke_my-project_europe-west1_payments-production-2", "production", true},
+		{"user at cluster", "admin@stg-eu", "staging", true},
+		{"preprod is not prod", "gke_p_z_preprod", "staging", true},
+		{"unknown name", "gke_my-project_us-central1_payments", "skip", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SuggestTier(cfg, tt.context)
+			if s.Tier != tt.tier {
+				t.Errorf("SuggestTier(%q).Tier = %q, want %q (%s)", tt.context, s.Tier, tt.tier, s.Reason)
+			}
+			if s.Unmatched != tt.unmatched {
+				t.Errorf("SuggestTier(%q).Unmatched = %v, want %v", tt.context, s.Unmatched, tt.unmatched)
+			}
+		})
+	}
+}
+
+func TestClusterNameFromContext(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:eks:us-east-1:123456:cluster/my-app": "my-app",
+		"gke_project_us-central1-a_web":               "web",
+		"gke_bad":                                     "gke_bad",
+		"kubernetes-admin@dds-prod":                   "dds-prod",
+		"minikube":                                    "minikube",
+	}
+
+	for context, expected := range tests {
+		if name := clusterNameFromContext(context); name != expected {
+			t.Errorf("clusterNameFromContext(%q) = %q, want %q", context, name, expected)
+		}
+	}
+}
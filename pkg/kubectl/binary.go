@@ -0,0 +1,186 @@
+package kubectl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Binary is the kubectl executable every command runs. kctl points it at
+// the binary configured for a context before running commands there.
+var Binary = "kubectl"
+
+// ResolveBinary finds the kubectl named by spec: a path to an executable
+// ("~/" is the home directory), or a version such as "1.28" or "v1.28",
+// found in PATH as kubectl1.28, kubectl-1.28 or kubectl-v1.28
+func ResolveBinary(spec string) (string, error) {
+	if strings.ContainsRune(spec, '/') || strings.ContainsRune(spec, filepath.Separator) {
+		if rest, ok := strings.CutPrefix(spec, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			spec = filepath.Join(home, rest)
+		}
+		info, err := os.Stat(spec)
+		if err != nil {
+			return "", fmt.Errorf("kubectl %s: %w", spec, err)
+		}
+		if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+			return "", fmt.Errorf("kubectl %s is not an executable file", spec)
+		}
+		return spec, nil
+	}
+
+	version := strings.TrimPrefix(spec, "v")
+	candidates := []string{"kubectl" + version, "kubectl-" + version, "kubectl-v" + version}
+	for _, name := range candidates {
+		if path, err := lookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no kubectl %s in PATH (looked for %s)", spec, strings.Join(candidates, ", "))
+}
+
+// VerifyBinary resolves name in PATH and checks the result against a pinned
+// absolute path and a hex SHA-256 of its contents; either may be empty. It
+// returns the resolved path, which should then be run instead of name so
+// PATH isn't consulted again.
+func VerifyBinary(name, wantPath, wantSHA256 string) (string, error) {
+	path, err := lookPath(name)
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if wantPath != "" && filepath.Clean(path) != filepath.Clean(wantPath) {
+		resolved, err1 := filepath.EvalSymlinks(path)
+		want, err2 := filepath.EvalSymlinks(wantPath)
+		if err1 != nil || err2 != nil || resolved != want {
+			return "", fmt.Errorf("%s in PATH is %s, not the pinned %s; refusing to run it", name, path, wantPath)
+		}
+	}
+	if wantSHA256 != "" {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(sum, wantSHA256) {
+			return "", fmt.Errorf("%s (%s) has SHA-256 %s, not the pinned %s; refusing to run it", name, path, sum, strings.ToLower(wantSHA256))
+		}
+	}
+	return path, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Versions returns the gitVersion of Binary and of the API server behind
+// context, e.g. "v1.28.3"
+func Versions(context string) (client, server string, err error) {
+	stdout, stderr, exitCode := ExecuteWithOutput([]string{"--context", context, "--request-timeout=5s", "version", "-o", "json"})
+	var versions struct {
+		Client struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+		Server *struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	// kubectl version prints the client version and exits non-zero when
+	// the server can't be reached
+	if err := json.Unmarshal([]byte(stdout), &versions); err != nil || versions.Server == nil {
+		if exitCode != 0 {
+			return versions.Client.GitVersion, "", &ContextError{Message: strings.TrimSpace(stderr)}
+		}
+		return versions.Client.GitVersion, "", fmt.Errorf("kubectl version reported no server version")
+	}
+	return versions.Client.GitVersion, versions.Server.GitVersion, nil
+}
+
+// MinorSkew returns how many minor versions client is ahead of (positive)
+// or behind (negative) server. ok is false when either version can't be
+// read or their major versions differ.
+func MinorSkew(client, server string) (skew int, ok bool) {
+	cMajor, cMinor, okClient := majorMinor(client)
+	sMajor, sMinor, okServer := majorMinor(server)
+	if !okClient || !okServer || cMajor != sMajor {
+		return 0, false
+	}
+	return cMinor - sMinor, true
+}
+
+// majorMinor reads "v1.28.3", "1.28" or "v1.27.4-eks-8ccc7ba"
+func majorMinor(version string) (major, minor int, ok bool) {
+	fields := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	digits := strings.TrimRightFunc(fields[1], func(r rune) bool { return r < '0' || r > '9' })
+	minor, err = strconv.Atoi(digits)
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// SkewTTL is how long a context's version check is reused
+const SkewTTL = time.Hour
+
+// skewEntry is a cached version check
+type skewEntry struct {
+	Client    string    `json:"client,omitempty"`
+	Server    string    `json:"server,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CachedVersions is Versions for Binary on context, reusing a check recorded
+// in the JSON file at cachePath for SkewTTL. Failed checks are recorded too,
+// so an unreachable cluster isn't asked before every command.
+func CachedVersions(context, cachePath string, now time.Time) (client, server string) {
+	cache := make(map[string]skewEntry)
+	if data, err := os.ReadFile(cachePath); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	// The same context name may be another cluster under another $KUBECONFIG
+	key := context + " " + Binary + " " + strings.Join(KubeconfigPaths(), string(filepath.ListSeparator))
+	if e, ok := cache[key]; ok && now.Sub(e.CheckedAt) < SkewTTL {
+		return e.Client, e.Server
+	}
+
+	client, server, _ = Versions(context)
+	cache[key] = skewEntry{Client: client, Server: server, CheckedAt: now}
+	for k, e := range cache {
+		if now.Sub(e.CheckedAt) >= SkewTTL {
+			delete(cache, k)
+		}
+	}
+	if data, err := json.Marshal(cache); err == nil {
+		_ = statefile.WriteFile(cachePath, data, 0600)
+	}
+	return client, server
+}
@@ -0,0 +1,100 @@
+package kubectl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveBinary(t *testing.T) {
+	previous := lookPath
+	lookPath = func(file string) (string, error) {
+		if file == "kubectl-1.27" {
+			return "/usr/local/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { lookPath = previous })
+
+	for _, spec := range []string{"1.27", "v1.27"} {
+		if got, err := ResolveBinary(spec); err != nil || got != "/usr/local/bin/kubectl-1.27" {
+			t.Errorf("ResolveBinary(%q) = %q, %v", spec, got, err)
+		}
+	}
+	if _, err := ResolveBinary("1.30"); err == nil || !strings.Contains(err.Error(), "kubectl1.30") {
+		t.Errorf("ResolveBinary of a missing version = %v, want the names looked for", err)
+	}
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "kubectl")
+	os.WriteFile(exe, []byte("#!/bin/sh\n"), 0755)
+	notExe := filepath.Join(dir, "notes")
+	os.WriteFile(notExe, nil, 0644)
+	if got, err := ResolveBinary(exe); err != nil || got != exe {
+		t.Errorf("ResolveBinary(%q) = %q, %v", exe, got, err)
+	}
+	for _, bad := range []string{notExe, dir, filepath.Join(dir, "missing")} {
+		if _, err := ResolveBinary(bad); err == nil {
+			t.Errorf("ResolveBinary(%q) accepted it", bad)
+		}
+	}
+}
+
+func TestMinorSkew(t *testing.T) {
+	tests := []struct {
+		client, server string
+		skew           int
+		ok             bool
+	}{
+		{"v1.28.3", "v1.28.0", 0, true},
+		{"v1.30.1", "v1.27.4-eks-8ccc7ba", 3, true},
+		{"v1.26.0", "v1.28.2+k3s1", -2, true},
+		{"v1.28.0", "v1.29+", -1, true},
+		{"v1.28.0", "", 0, false},
+		{"v2.0.0", "v1.28.0", 0, false},
+	}
+	for _, tt := range tests {
+		skew, ok := MinorSkew(tt.client, tt.server)
+		if skew != tt.skew || ok != tt.ok {
+			t.Errorf("MinorSkew(%q, %q) = %d, %v; want %d, %v", tt.client, tt.server, skew, ok, tt.skew, tt.ok)
+		}
+	}
+}
+
+func TestVerifyBinary(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "kubectl")
+	os.WriteFile(real, []byte("real kubectl"), 0755)
+	link := filepath.Join(dir, "bin", "kubectl")
+	os.MkdirAll(filepath.Dir(link), 0755)
+	os.Symlink(real, link)
+	sum, err := fileSHA256(real)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := real
+	previous := lookPath
+	lookPath = func(file string) (string, error) { return found, nil }
+	t.Cleanup(func() { lookPath = previous })
+
+	if got, err := VerifyBinary("kubectl", real, strings.ToUpper(sum)); err != nil || got != real {
+		t.Errorf("VerifyBinary of the pinned binary = %q, %v", got, err)
+	}
+	if _, err := VerifyBinary("kubectl", link, ""); err != nil {
+		t.Errorf("VerifyBinary with the pin a symlink to it = %v", err)
+	}
+
+	fake := filepath.Join(dir, "evil", "kubectl")
+	os.MkdirAll(filepath.Dir(fake), 0755)
+	os.WriteFile(fake, []byte("evil kubectl"), 0755)
+	found = fake
+	if _, err := VerifyBinary("kubectl", real, ""); err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("VerifyBinary of another path = %v, want it refused", err)
+	}
+	if _, err := VerifyBinary("kubectl", "", sum); err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Errorf("VerifyBinary of other contents = %v, want it refused", err)
+	}
+}
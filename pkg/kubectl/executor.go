@@ -2,28 +2,53 @@ package kubectl
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
 )
 
+// Input, when set, is what kubectl commands read on stdin instead of kctl's
+// own: a manifest piped to "-f -" that kctl has already read to show it
+// before confirming
+var Input []byte
+
+// stdin returns the stdin for a kubectl command
+func stdin() io.Reader {
+	if Input != nil {
+		return bytes.NewReader(Input)
+	}
+	return os.Stdin
+}
+
+// WrappedEnv is set in the environment of the kubectl commands kctl runs,
+// which have been checked against the rules already, to a nonce registered
+// by MarkWrapped. 'kctl exec-credential' uses it to tell them from raw
+// kubectl (see Wrapped).
+const WrappedEnv = "KCTL_WRAPPED"
+
+// GracePeriod is how long kubectl has to exit after it is interrupted for
+// running past its deadline before it is killed
+const GracePeriod = 10 * time.Second
+
 // GetCurrentContext returns the current kubectl context name
 func GetCurrentContext() (string, error) {
-	cmd := exec.Command("kubectl", "config", "current-context")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
+	stdout, stderr, exitCode := ExecuteWithOutput([]string{"config", "current-context"})
+	if exitCode != 0 {
 		// Return stderr if available for better error messages
-		if stderr.Len() > 0 {
-			return "", &ContextError{Message: strings.TrimSpace(stderr.String())}
+		if stderr != "" {
+			return "", &ContextError{Message: strings.TrimSpace(stderr)}
 		}
-		return "", err
+		return "", &ContextError{Message: "failed to get the current context"}
 	}
 
-	return strings.TrimSpace(stdout.String()), nil
+	return strings.TrimSpace(stdout), nil
 }
 
 // ContextError represents an error getting the kubectl context
@@ -35,29 +60,120 @@ func (e *ContextError) Error() string {
 	return e.Message
 }
 
-// Execute runs kubectl with the given arguments and returns the exit code
+// Execute runs kubectl with the given arguments and returns the exit code.
+// kubectl writes to the terminal itself, as interactive commands such as
+// exec and edit need, so its errors get no hints; see ExecuteTo.
 func Execute(args []string) int {
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	return ExecuteTo(args, os.Stdout, os.Stderr)
+}
+
+// ExecuteTo runs kubectl with its output sent to stdout and stderr, reading
+// from the real stdin (or Input), and returns the exit code. A failure Hint
+// has advice for is followed by it on stderr, unless stderr is a file such
+// as the terminal, which kubectl then writes to directly.
+func ExecuteTo(args []string, stdout, stderr io.Writer) int {
+	tail := &stderrTail{}
+	cmd := exec.Command(Binary, args...)
+	cmd.Stdin = stdin()
+	cmd.Stdout = stdout
+	cmd.Stderr = teeStderr(stderr, tail)
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		exitCode = 1 // Non-exit error (e.g., kubectl not found)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	printHint(stderr, args, tail, exitCode)
+	return exitCode
+}
+
+// ExecuteContext is ExecuteTo for a command that must end when ctx does:
+// kubectl is interrupted, as with Ctrl-C, and killed if it is still
+// running GracePeriod later
+func ExecuteContext(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	tail := &stderrTail{}
+	cmd := exec.CommandContext(ctx, Binary, args...)
+	cmd.Stdin = stdin()
+	cmd.Stdout = stdout
+	cmd.Stderr = teeStderr(stderr, tail)
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = GracePeriod
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		exitCode = 1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	if ctx.Err() == nil {
+		printHint(stderr, args, tail, exitCode)
+	}
+	return exitCode
+}
+
+// ExecuteWithOutput runs kubectl and captures the output. It is for
+// commands that are safe to repeat, which are retried per Retry when they
+// can't reach the API server. A failure Hint has advice for ends stderr
+// with it.
+func ExecuteWithOutput(args []string) (string, string, int) {
+	var stdout, stderr string
+	exitCode := Retry.Run(func() (string, int) {
+		var exitCode int
+		stdout, stderr, exitCode = executeWithOutput(args)
+		return stderr, exitCode
+	})
+	return stdout, withHint(args, stderr, exitCode), exitCode
+}
+
+// Run is how the packages that query the cluster run kubectl:
+// ExecuteWithOutput, which tests replace to fake kubectl
+var Run = ExecuteWithOutput
+
+// GetJSON runs kubectl with args and -o json through Run and decodes its
+// output into v. When kubectl fails, the error is what it printed.
+func GetJSON(args []string, v any) error {
+	stdout, stderr, exitCode := Run(append(args[:len(args):len(args)], "-o", "json"))
+	if exitCode != 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+	return json.Unmarshal([]byte(stdout), v)
+}
+
+func executeWithOutput(args []string) (string, string, int) {
+	cmd := exec.Command(Binary, args...)
+	var stdout, stderr bytes.Buffer
+	if Input != nil {
+		cmd.Stdin = bytes.NewReader(Input)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
 	err := cmd.Run()
+	exitCode := 0
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return exitErr.ExitCode()
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = 1
 		}
-		// Non-exit error (e.g., kubectl not found)
-		return 1
 	}
 
-	return 0
+	return stdout.String(), stderr.String(), exitCode
 }
 
-// ExecuteWithOutput runs kubectl and captures the output
-func ExecuteWithOutput(args []string) (string, string, int) {
-	cmd := exec.Command("kubectl", args...)
+// ExecuteWithInput runs kubectl with stdin set to input and captures the output
+func ExecuteWithInput(args []string, input []byte) (string, string, int) {
+	cmd := exec.Command(Binary, args...)
 	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -87,27 +203,68 @@ func GetClusterInfo() (server string, err error) {
 	return strings.TrimSpace(stdout), nil
 }
 
-// GetNamespace returns the namespace from args or the default namespace
-func GetNamespace(args []string) string {
-	// Check if namespace is specified in args
+// GetServer returns the API server URL of the cluster used by context
+func GetServer(context string) (string, error) {
+	stdout, _, exitCode := ExecuteWithOutput([]string{
+		"config", "view", "--minify", "--context", context, "-o", "jsonpath={.clusters[0].cluster.server}",
+	})
+
+	if exitCode != 0 {
+		return "", &ContextError{Message: "failed to get server for context " + context}
+	}
+
+	return strings.TrimSpace(stdout), nil
+}
+
+// Reachable returns an error when the API server behind context can't be
+// reached, after up to attempts tries with the backoff of Retry. An error
+// response from the server, such as Forbidden, still shows it is there.
+func Reachable(context string, attempts int) error {
+	policy := Retry
+	policy.Attempts = attempts
+	var stderr string
+	exitCode := policy.Run(func() (string, int) {
+		var exitCode int
+		_, stderr, exitCode = executeWithOutput([]string{"--context", context, "--request-timeout=5s", "get", "--raw", "/version"})
+		return stderr, exitCode
+	})
+	if exitCode != 0 {
+		if reason := TransientError(stderr); reason != "" {
+			return &ContextError{Message: reason}
+		}
+	}
+	return nil
+}
+
+// GetContextFromArgs returns the context selected with --context in args, if any
+func GetContextFromArgs(args []string) (string, bool) {
 	for i, arg := range args {
-		if arg == "-n" || arg == "--namespace" {
-			if i+1 < len(args) {
-				return args[i+1]
-			}
+		if arg == "--" {
+			break
 		}
-		if strings.HasPrefix(arg, "-n=") {
-			return strings.TrimPrefix(arg, "-n=")
+		if arg == "--context" && i+1 < len(args) {
+			return args[i+1], true
 		}
-		if strings.HasPrefix(arg, "--namespace=") {
-			return strings.TrimPrefix(arg, "--namespace=")
+		if strings.HasPrefix(arg, "--context=") {
+			return strings.TrimPrefix(arg, "--context="), true
 		}
 	}
+	return "", false
+}
 
-	// Get default namespace from context
-	stdout, _, exitCode := ExecuteWithOutput([]string{
-		"config", "view", "--minify", "-o", "jsonpath={.contexts[0].context.namespace}",
-	})
+// GetNamespace returns the namespace from args or the default namespace
+func GetNamespace(args []string) string {
+	// Check if namespace is specified in args, in any of kubectl's forms
+	if namespace, ok := rbac.Namespace(args); ok {
+		return namespace
+	}
+
+	// Get default namespace from the context args select, or the current one
+	viewArgs := []string{"config", "view", "--minify", "-o", "jsonpath={.contexts[0].context.namespace}"}
+	if context, ok := GetContextFromArgs(args); ok {
+		viewArgs = append(viewArgs, "--context", context)
+	}
+	stdout, _, exitCode := ExecuteWithOutput(viewArgs)
 
 	if exitCode == 0 && strings.TrimSpace(stdout) != "" {
 		return strings.TrimSpace(stdout)
@@ -116,9 +273,24 @@ func GetNamespace(args []string) string {
 	return "default"
 }
 
+// GetContextNamespace returns the default namespace of context, or
+// "default" when it sets none
+func GetContextNamespace(context string) (string, error) {
+	stdout, _, exitCode := ExecuteWithOutput([]string{
+		"config", "view", "--minify", "--context", context, "-o", "jsonpath={.contexts[0].context.namespace}",
+	})
+	if exitCode != 0 {
+		return "", &ContextError{Message: "failed to get namespace for context " + context}
+	}
+	if namespace := strings.TrimSpace(stdout); namespace != "" {
+		return namespace, nil
+	}
+	return "default", nil
+}
+
 // CheckKubectlAvailable checks if kubectl is available in PATH
 func CheckKubectlAvailable() bool {
-	_, err := exec.LookPath("kubectl")
+	_, err := exec.LookPath(Binary)
 	return err == nil
 }
 
@@ -144,3 +316,33 @@ func GetAllContexts() ([]string, error) {
 	return contexts, nil
 }
 
+// UseContext switches the current kubectl context
+func UseContext(name string) error {
+	_, stderr, exitCode := ExecuteWithOutput([]string{"config", "use-context", name})
+	if exitCode != 0 {
+		return &ContextError{Message: strings.TrimSpace(stderr)}
+	}
+	return nil
+}
+
+// SetNamespace sets the default namespace of the current context
+func SetNamespace(namespace string) error {
+	_, stderr, exitCode := ExecuteWithOutput([]string{
+		"config", "set-context", "--current", "--namespace=" + namespace,
+	})
+	if exitCode != 0 {
+		return &ContextError{Message: strings.TrimSpace(stderr)}
+	}
+	return nil
+}
+
+// GetNamespaces returns the names of all namespaces in the current cluster
+func GetNamespaces() ([]string, error) {
+	stdout, stderr, exitCode := ExecuteWithOutput([]string{
+		"get", "namespaces", "-o", "jsonpath={.items[*].metadata.name}",
+	})
+	if exitCode != 0 {
+		return nil, &ContextError{Message: strings.TrimSpace(stderr)}
+	}
+	return strings.Fields(stdout), nil
+}
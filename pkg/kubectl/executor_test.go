@@ -0,0 +1,131 @@
+package kubectl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteContext(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "kubectl")
+	os.WriteFile(fake, []byte("#!/bin/sh\ntrap 'exit 130' INT\nwhile :; do sleep 0.05; done\n"), 0755)
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary = previous })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	exitCode := ExecuteContext(ctx, []string{"exec", "-it", "pod"}, io.Discard, io.Discard)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("ExecuteContext ran for %s past its deadline", elapsed)
+	}
+	// The interrupt, not a kill, ended it
+	if exitCode != 130 {
+		t.Errorf("ExecuteContext of an interrupted command = %d, want 130", exitCode)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "kubectl")
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary = previous })
+
+	os.WriteFile(fake, []byte("#!/bin/sh\necho 'dial tcp 10.0.0.1:6443: connect: connection refused' >&2\nexit 1\n"), 0755)
+	if err := Reachable("app-prod", 1); err == nil {
+		t.Error("Reachable with the connection refused = nil")
+	}
+	os.WriteFile(fake, []byte("#!/bin/sh\necho 'Error from server (Forbidden): forbidden' >&2\nexit 1\n"), 0755)
+	if err := Reachable("app-prod", 1); err != nil {
+		t.Errorf("Reachable with an error from the server = %v", err)
+	}
+	calls := filepath.Join(dir, "calls")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho x >> "+calls+"\necho 'connect: connection refused' >&2\nexit 1\n"), 0755)
+	oldSleep := sleep
+	sleep = func(time.Duration) {}
+	t.Cleanup(func() { sleep = oldSleep })
+	Reachable("app-prod", 3)
+	if data, _ := os.ReadFile(calls); strings.Count(string(data), "x") != 3 {
+		t.Errorf("Reachable(_, 3) tried %d times, want 3", strings.Count(string(data), "x"))
+	}
+	if Retry.Attempts != 0 {
+		t.Errorf("Reachable changed Retry.Attempts to %d", Retry.Attempts)
+	}
+}
+
+func TestInput(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "kubectl")
+	os.WriteFile(fake, []byte("#!/bin/sh\ncat\n"), 0755)
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary, Input = previous, nil })
+
+	Input = []byte("kind: ConfigMap\n")
+	// Every command reads all of it, so a retried one does too
+	for i := 0; i < 2; i++ {
+		var stdout strings.Builder
+		if exitCode := ExecuteTo([]string{"apply", "-f", "-"}, &stdout, io.Discard); exitCode != 0 || stdout.String() != string(Input) {
+			t.Errorf("ExecuteTo read %q (exit %d), want %q", stdout.String(), exitCode, Input)
+		}
+	}
+	if stdout, _, _ := ExecuteWithOutput([]string{"apply", "-f", "-", "--dry-run=server"}); stdout != string(Input) {
+		t.Errorf("ExecuteWithOutput read %q, want %q", stdout, Input)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	var ran []string
+	previous := Run
+	Run = func(args []string) (string, string, int) {
+		ran = args
+		if args[len(args)-3] == "missing" {
+			return "", "Error from server (NotFound): pods \"missing\" not found\n", 1
+		}
+		return `{"metadata":{"name":"web"}}`, "", 0
+	}
+	t.Cleanup(func() { Run = previous })
+
+	args := []string{"get", "pod", "web"}
+	var pod struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := GetJSON(args, &pod); err != nil || pod.Metadata.Name != "web" {
+		t.Fatalf("GetJSON = %+v, %v", pod, err)
+	}
+	if got := strings.Join(ran, " "); got != "get pod web -o json" {
+		t.Errorf("ran kubectl %s", got)
+	}
+	if len(args) != 3 {
+		t.Errorf("GetJSON changed its args: %v", args)
+	}
+	if err := GetJSON([]string{"get", "pod", "missing"}, &pod); err == nil || err.Error() != `Error from server (NotFound): pods "missing" not found` {
+		t.Errorf("GetJSON error = %v, want kubectl's", err)
+	}
+}
+
+func TestGetNamespace(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"delete", "pod", "web", "-n", "prod"}, "prod"},
+		{[]string{"delete", "pod", "web", "-n=prod"}, "prod"},
+		{[]string{"delete", "pod", "web", "-nprod"}, "prod"},
+		{[]string{"delete", "pod", "web", "--namespace=prod"}, "prod"},
+	}
+	for _, tt := range tests {
+		if got := GetNamespace(tt.args); got != tt.want {
+			t.Errorf("GetNamespace(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
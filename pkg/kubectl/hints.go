@@ -0,0 +1,174 @@
+package kubectl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Failure classes Classify tells apart
+const (
+	FailureUnauthorized    = "unauthorized"
+	FailureForbidden       = "forbidden"
+	FailureUnreachable     = "unreachable"
+	FailureContextNotFound = "context_not_found"
+)
+
+// HintPrefix starts the lines hints are added to kubectl's errors as
+const HintPrefix = "hint: "
+
+// notPermitted matches RBAC denials. Other errors the server reports as
+// Forbidden, like an exceeded quota, a PodSecurity violation or a
+// terminating namespace, don't name the user.
+var notPermitted = regexp.MustCompile(`User "[^"]*" cannot `)
+
+// missingContext matches kubectl's errors for a context the kubeconfig
+// doesn't have, capturing its name
+var missingContext = regexp.MustCompile(`context "([^"]*)" does not exist|no context exists with the name: "([^"]*)"`)
+
+// loginCommands refresh the credentials of common exec credential
+// plugins, by the plugin's command
+var loginCommands = map[string]string{
+	"aws":                    "aws sso login",
+	"aws-iam-authenticator":  "aws sso login",
+	"gke-gcloud-auth-plugin": "gcloud auth login",
+	"gcloud":                 "gcloud auth login",
+}
+
+// Classify returns the class of the failure kubectl's stderr reports, or
+// "" for failures it has no advice for
+func Classify(stderr string) string {
+	lower := strings.ToLower(stderr)
+	switch {
+	case missingContext.MatchString(stderr):
+		return FailureContextNotFound
+	case strings.Contains(lower, "(unauthorized)") || strings.Contains(lower, "you must be logged in to the server"):
+		return FailureUnauthorized
+	case notPermitted.MatchString(stderr):
+		return FailureForbidden
+	case TransientError(stderr) != "":
+		return FailureUnreachable
+	}
+	return ""
+}
+
+// Hint returns advice on the failure kubectl's stderr reports for args, or
+// "" when there is none. Looking up the context doesn't go through the
+// retries and hints of ExecuteWithOutput.
+func Hint(args []string, stderr string) string {
+	return hint(args, stderr, true)
+}
+
+// hint is Hint; without probe, it runs no kubectl commands to look up the
+// current context and login command, and gives more general advice
+func hint(args []string, stderr string, probe bool) string {
+	class := Classify(stderr)
+	if class == "" {
+		return ""
+	}
+	context, ok := GetContextFromArgs(args)
+	if !ok && probe && class != FailureContextNotFound {
+		stdout, _, exitCode := executeWithOutput([]string{"config", "current-context"})
+		if exitCode == 0 {
+			context = strings.TrimSpace(stdout)
+		}
+	}
+	where := fmt.Sprintf("context %q", context)
+	if context == "" {
+		where = "the current context"
+	}
+
+	switch class {
+	case FailureContextNotFound:
+		m := missingContext.FindStringSubmatch(stderr)
+		return fmt.Sprintf("your kubeconfig has no context %q; list the contexts with 'kctl ctx'", m[1]+m[2])
+	case FailureUnauthorized:
+		login := "your cluster's login command"
+		if probe {
+			if command := loginCommand(context); command != "" {
+				login = "'" + command + "'"
+			}
+		}
+		return fmt.Sprintf("the credentials of %s were rejected and may have expired; log in again with %s and retry", where, login)
+	case FailureForbidden:
+		return fmt.Sprintf("you are logged in to %s but not allowed to do this; see what you may do with 'kubectl auth can-i --list' or ask for access", where)
+	default:
+		return fmt.Sprintf("the API server of %s can't be reached; check your VPN and that the cluster is up ('kubectl cluster-info')", where)
+	}
+}
+
+// loginCommand returns the command that refreshes the credentials of
+// context's exec plugin, or "" when it isn't a plugin kctl knows
+func loginCommand(context string) string {
+	args := []string{"config", "view", "--minify", "-o", "jsonpath={.users[0].user.exec.command} {.users[0].user.exec.args}"}
+	if context != "" {
+		args = append(args, "--context", context)
+	}
+	stdout, _, exitCode := executeWithOutput(args)
+	fields := strings.Fields(stdout)
+	if exitCode != 0 || len(fields) == 0 {
+		return ""
+	}
+	if strings.Contains(stdout, "oidc-login") {
+		return "kubectl oidc-login clean"
+	}
+	return loginCommands[filepath.Base(fields[0])]
+}
+
+// withHint returns kubectl's stderr with the hint for its failure added.
+// Most commands captured this way are kctl's own lookups, so finding the
+// hint runs no further kubectl commands.
+func withHint(args []string, stderr string, exitCode int) string {
+	if exitCode == 0 {
+		return stderr
+	}
+	advice := hint(args, stderr, false)
+	if advice == "" {
+		return stderr
+	}
+	if stderr != "" && !strings.HasSuffix(stderr, "\n") {
+		stderr += "\n"
+	}
+	return stderr + HintPrefix + advice + "\n"
+}
+
+// stderrTail keeps the end of what kubectl writes to stderr, enough to
+// classify its failure
+type stderrTail struct {
+	buf []byte
+}
+
+// maxStderrTail is how much of kubectl's stderr is kept
+const maxStderrTail = 8 << 10
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxStderrTail {
+		t.buf = t.buf[len(t.buf)-maxStderrTail:]
+	}
+	return len(p), nil
+}
+
+// teeStderr returns the stderr to run kubectl with: stderr with a copy
+// kept in tail, or stderr itself when it is a file. kubectl then writes to
+// it directly, which interactive commands need of the terminal.
+func teeStderr(stderr io.Writer, tail *stderrTail) io.Writer {
+	if _, ok := stderr.(*os.File); ok {
+		return stderr
+	}
+	return io.MultiWriter(stderr, tail)
+}
+
+// printHint writes the hint for a failed command to stderr, after what
+// kubectl wrote there
+func printHint(stderr io.Writer, args []string, tail *stderrTail, exitCode int) {
+	if exitCode == 0 {
+		return
+	}
+	if hint := Hint(args, string(tail.buf)); hint != "" {
+		fmt.Fprintln(stderr, HintPrefix+hint)
+	}
+}
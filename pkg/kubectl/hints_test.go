@@ -0,0 +1,107 @@
+package kubectl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   string
+	}{
+		{"error: You must be logged in to the server (Unauthorized)", FailureUnauthorized},
+		{`Error from server (Forbidden): pods is forbidden: User "dev" cannot list resource "pods"`, FailureForbidden},
+		{"The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?\ndial tcp 10.0.0.1:6443: connect: connection refused", FailureUnreachable},
+		{`error: context "prd" does not exist`, FailureContextNotFound},
+		{`error: no context exists with the name: "prd"`, FailureContextNotFound},
+		{`Error from server (NotFound): pods "web" not found`, ""},
+		{`Error from server (Forbidden): pods "web" is forbidden: exceeded quota: compute, requested: cpu=2, used: cpu=8, limited: cpu=8`, ""},
+		{`Error from server (Forbidden): pods "web" is forbidden: violates PodSecurity "restricted:latest": privileged`, ""},
+		{`Error from server (Forbidden): pods "web" is forbidden: unable to create new content in namespace old because it is being terminated`, ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.stderr); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestHint(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "kubectl")
+	os.WriteFile(fake, []byte(`#!/bin/sh
+case "$*" in
+  "config current-context") echo app-prod;;
+  *exec.command*app-eks*) echo 'aws ["eks","get-token"]';;
+  *exec.command*) echo '';;
+esac
+`), 0755)
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary = previous })
+
+	tests := []struct {
+		args   []string
+		stderr string
+		want   string
+	}{
+		{[]string{"get", "pods", "--context", "app-eks"}, "error: You must be logged in to the server (Unauthorized)", `context "app-eks" were rejected and may have expired; log in again with 'aws sso login'`},
+		{[]string{"get", "pods"}, "error: You must be logged in to the server (Unauthorized)", `context "app-prod" were rejected and may have expired; log in again with your cluster's login command`},
+		{[]string{"get", "pods"}, "dial tcp: lookup api.internal: no such host", `context "app-prod" can't be reached; check your VPN`},
+		{[]string{"delete", "pod", "x"}, `Error from server (Forbidden): pods "x" is forbidden: User "dev" cannot delete resource "pods"`, `logged in to context "app-prod" but not allowed`},
+		{[]string{"get", "pods", "--context", "prd"}, `error: context "prd" does not exist`, `no context "prd"; list the contexts with 'kctl ctx'`},
+		{[]string{"get", "pods"}, "error: the server doesn't have a resource type \"pdos\"", ""},
+	}
+	for _, tt := range tests {
+		got := Hint(tt.args, tt.stderr)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("Hint(%q, %q) = %q, want it to contain %q", tt.args, tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteHints(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "kubectl")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho out\necho 'error: context \"prd\" does not exist' >&2\nexit 1\n"), 0755)
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary = previous })
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := ExecuteTo([]string{"--context", "prd", "get", "pods"}, &stdout, &stderr); exitCode != 1 {
+		t.Errorf("ExecuteTo exit code = %d, want kubectl's 1", exitCode)
+	}
+	want := "error: context \"prd\" does not exist\nhint: your kubeconfig has no context \"prd\"; list the contexts with 'kctl ctx'\n"
+	if stdout.String() != "out\n" || stderr.String() != want {
+		t.Errorf("ExecuteTo wrote %q and %q, want %q and %q", stdout.String(), stderr.String(), "out\n", want)
+	}
+
+	_, got, exitCode := ExecuteWithOutput([]string{"--context", "prd", "get", "pods"})
+	if exitCode != 1 || got != want {
+		t.Errorf("ExecuteWithOutput = %q, %d, want %q, 1", got, exitCode, want)
+	}
+}
+
+func TestExecuteWithOutput_HintsWithoutProbes(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	fake := filepath.Join(dir, "kubectl")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho \"$*\" >> "+calls+"\necho 'error: You must be logged in to the server (Unauthorized)' >&2\nexit 1\n"), 0755)
+	previous := Binary
+	Binary = fake
+	t.Cleanup(func() { Binary = previous })
+
+	_, stderr, _ := ExecuteWithOutput([]string{"get", "pods"})
+	if want := "hint: the credentials of the current context were rejected"; !strings.Contains(stderr, want) {
+		t.Errorf("ExecuteWithOutput stderr = %q, want it to contain %q", stderr, want)
+	}
+	if data, _ := os.ReadFile(calls); string(data) != "get pods\n" {
+		t.Errorf("kubectl ran %q, want only the command itself", data)
+	}
+}
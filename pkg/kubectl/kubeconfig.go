@@ -0,0 +1,66 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KubeconfigPaths returns the kubeconfig files kubectl reads, in the order
+// it merges them: those listed in $KUBECONFIG, skipping empty entries and
+// repeats, or ~/.kube/config. Where files disagree the first one wins.
+func KubeconfigPaths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		return paths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// GetKubeconfigFromArgs returns the file selected with --kubeconfig in
+// args, if any
+func GetKubeconfigFromArgs(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--kubeconfig" && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if strings.HasPrefix(arg, "--kubeconfig=") {
+			return strings.TrimPrefix(arg, "--kubeconfig="), true
+		}
+	}
+	return "", false
+}
+
+// UseKubeconfigFrom points $KUBECONFIG at the file args select with
+// --kubeconfig, which kubectl reads instead of the merged files. kctl's
+// own lookups of the context, namespace and server then see the same
+// kubeconfig as the command. The returned function restores $KUBECONFIG.
+func UseKubeconfigFrom(args []string) (restore func()) {
+	path, ok := GetKubeconfigFromArgs(args)
+	if !ok {
+		return func() {}
+	}
+	previous, set := os.LookupEnv("KUBECONFIG")
+	os.Setenv("KUBECONFIG", path)
+	return func() {
+		if set {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	}
+}
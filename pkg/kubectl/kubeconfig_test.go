@@ -0,0 +1,41 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKubeconfigPaths(t *testing.T) {
+	sep := string(filepath.ListSeparator)
+	t.Setenv("KUBECONFIG", "/a"+sep+sep+"/b"+sep+"/a")
+	if got, want := KubeconfigPaths(), []string{"/a", "/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("KubeconfigPaths() = %v, want %v", got, want)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", sep)
+	if got, want := KubeconfigPaths(), []string{filepath.Join(home, ".kube", "config")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("KubeconfigPaths() without entries = %v, want %v", got, want)
+	}
+}
+
+func TestUseKubeconfigFrom(t *testing.T) {
+	t.Setenv("KUBECONFIG", "/a:/b")
+
+	restore := UseKubeconfigFrom([]string{"get", "pods", "--kubeconfig=/c"})
+	if got := os.Getenv("KUBECONFIG"); got != "/c" {
+		t.Errorf("KUBECONFIG with --kubeconfig = %q, want /c", got)
+	}
+	restore()
+	if got := os.Getenv("KUBECONFIG"); got != "/a:/b" {
+		t.Errorf("KUBECONFIG after restore = %q, want /a:/b", got)
+	}
+
+	UseKubeconfigFrom([]string{"exec", "pod", "--", "cat", "--kubeconfig", "/c"})()
+	if got := os.Getenv("KUBECONFIG"); got != "/a:/b" {
+		t.Errorf("KUBECONFIG with --kubeconfig after -- = %q, want /a:/b", got)
+	}
+}
@@ -0,0 +1,52 @@
+package kubectl
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// lookPath is replaced in tests
+var lookPath = exec.LookPath
+
+// builtinCommands are kubectl's own commands, which plugins can't shadow
+var builtinCommands = map[string]bool{
+	"annotate": true, "api-resources": true, "api-versions": true, "apply": true,
+	"attach": true, "auth": true, "autoscale": true, "certificate": true,
+	"cluster-info": true, "completion": true, "config": true, "cordon": true,
+	"cp": true, "create": true, "debug": true, "delete": true, "describe": true,
+	"diff": true, "drain": true, "edit": true, "events": true, "exec": true,
+	"explain": true, "expose": true, "get": true, "help": true, "kustomize": true,
+	"label": true, "logs": true, "options": true, "patch": true, "plugin": true,
+	"port-forward": true, "proxy": true, "replace": true, "rollout": true,
+	"run": true, "scale": true, "set": true, "taint": true, "top": true,
+	"uncordon": true, "version": true, "wait": true,
+}
+
+// FindPlugin reports whether args invoke a kubectl plugin rather than a
+// builtin command, resolving it the way kubectl does: the longest run of
+// leading words with a kubectl-<words> executable on PATH, dashes within a
+// word written as underscores. It returns the plugin's name (its words
+// joined with "-", e.g. "node-shell") and executable.
+func FindPlugin(args []string) (name, path string, ok bool) {
+	var words []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		words = append(words, arg)
+	}
+	if len(words) == 0 || builtinCommands[words[0]] {
+		return "", "", false
+	}
+
+	for n := len(words); n > 0; n-- {
+		parts := make([]string, n)
+		for i, w := range words[:n] {
+			parts[i] = strings.ReplaceAll(w, "-", "_")
+		}
+		if path, err := lookPath("kubectl-" + strings.Join(parts, "-")); err == nil {
+			return strings.Join(words[:n], "-"), path, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,42 @@
+package kubectl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindPlugin(t *testing.T) {
+	installed := map[string]bool{
+		"kubectl-node_shell": true,
+		"kubectl-cnpg":       true,
+		"kubectl-cnpg-psql":  true,
+		"kubectl-get":        true,
+	}
+	previous := lookPath
+	lookPath = func(file string) (string, error) {
+		if installed[file] {
+			return "/usr/local/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { lookPath = previous })
+
+	tests := []struct {
+		args     []string
+		wantName string
+		wantPath string
+	}{
+		{[]string{"node-shell", "node-1"}, "node-shell", "/usr/local/bin/kubectl-node_shell"},
+		{[]string{"cnpg", "psql", "db"}, "cnpg-psql", "/usr/local/bin/kubectl-cnpg-psql"},
+		{[]string{"cnpg", "status", "db"}, "cnpg", "/usr/local/bin/kubectl-cnpg"},
+		{[]string{"get", "pods"}, "", ""},               // builtins win
+		{[]string{"--context", "prod", "cnpg"}, "", ""}, // kubectl needs the plugin first
+		{[]string{"neat"}, "", ""},                      // not installed
+	}
+	for _, tt := range tests {
+		name, path, ok := FindPlugin(tt.args)
+		if name != tt.wantName || path != tt.wantPath || ok != (tt.wantName != "") {
+			t.Errorf("FindPlugin(%v) = %q, %q, %v; want %q, %q", tt.args, name, path, ok, tt.wantName, tt.wantPath)
+		}
+	}
+}
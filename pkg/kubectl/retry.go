@@ -0,0 +1,83 @@
+package kubectl
+
+import (
+	"strings"
+	"time"
+)
+
+// RetryPolicy says how often a kubectl command that couldn't reach the API
+// server runs again. It is only applied to commands that are safe to repeat.
+type RetryPolicy struct {
+	// Attempts is the most times a command runs; below 2 means no retries
+	Attempts int
+	// Backoff is the wait before the first retry, doubling for each later
+	// one up to MaxBackoff (when set)
+	Backoff, MaxBackoff time.Duration
+	// Notify, if set, is called before each retry with the error that
+	// caused it and the wait
+	Notify func(reason string, delay time.Duration, attempt, attempts int)
+}
+
+// Retry is the policy ExecuteWithOutput applies. The zero value retries
+// nothing.
+var Retry RetryPolicy
+
+// sleep is replaced in tests
+var sleep = time.Sleep
+
+// transientErrors are kubectl errors from failing to reach the API server,
+// which are worth trying again. Errors the server returned, and connection
+// errors that won't go away by themselves (such as a certificate kubectl
+// doesn't trust), are not.
+var transientErrors = []string{
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"tls handshake timeout",
+	"no route to host",
+	"no such host",
+	"network is unreachable",
+	"context deadline exceeded",
+	"client.timeout exceeded",
+	"http2: client connection lost",
+	"unexpected eof",
+}
+
+// TransientError returns the line of kubectl's stderr reporting a transient
+// connection failure, or "" when there is none
+func TransientError(stderr string) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		lower := strings.ToLower(line)
+		for _, msg := range transientErrors {
+			if strings.Contains(lower, msg) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+// Run calls run, which runs kubectl and returns its stderr and exit code,
+// until it succeeds, fails with an error that isn't transient or has run
+// Attempts times, and returns the last exit code
+func (p RetryPolicy) Run(run func() (stderr string, exitCode int)) int {
+	delay := p.Backoff
+	for attempt := 1; ; attempt++ {
+		stderr, exitCode := run()
+		if exitCode == 0 || attempt >= p.Attempts {
+			return exitCode
+		}
+		reason := TransientError(stderr)
+		if reason == "" {
+			return exitCode
+		}
+		if p.Notify != nil {
+			p.Notify(reason, delay, attempt, p.Attempts)
+		}
+		sleep(delay)
+		delay *= 2
+		if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+			delay = p.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Run(t *testing.T) {
+	var waits []time.Duration
+	previous := sleep
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = previous })
+
+	refused := "The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?\ndial tcp 10.0.0.1:6443: connect: connection refused"
+	policy := RetryPolicy{Attempts: 4, Backoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	runs := 0
+	exitCode := policy.Run(func() (string, int) {
+		runs++
+		return refused, 1
+	})
+	if exitCode != 1 || runs != 4 {
+		t.Errorf("Run of an unreachable server = %d after %d runs, want 1 after 4", exitCode, runs)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}; !reflect.DeepEqual(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+
+	runs = 0
+	exitCode = policy.Run(func() (string, int) {
+		runs++
+		if runs < 2 {
+			return "Unable to connect to the server: net/http: TLS handshake timeout", 1
+		}
+		return "", 0
+	})
+	if exitCode != 0 || runs != 2 {
+		t.Errorf("Run recovering on the second try = %d after %d runs", exitCode, runs)
+	}
+
+	runs = 0
+	policy.Run(func() (string, int) {
+		runs++
+		return `Error from server (NotFound): pods "web" not found`, 1
+	})
+	if runs != 1 {
+		t.Errorf("Run retried an error from the server %d times", runs-1)
+	}
+
+	runs = 0
+	RetryPolicy{}.Run(func() (string, int) {
+		runs++
+		return refused, 1
+	})
+	if runs != 1 {
+		t.Errorf("the zero RetryPolicy ran %d times", runs)
+	}
+}
+
+func TestTransientError(t *testing.T) {
+	if got := TransientError("W1016 warning\ndial tcp 10.0.0.1:6443: i/o timeout\n"); got != "dial tcp 10.0.0.1:6443: i/o timeout" {
+		t.Errorf("TransientError = %q", got)
+	}
+	if got := TransientError("Unable to connect to the server: x509: certificate signed by unknown authority"); got != "" {
+		t.Errorf("TransientError of a certificate error = %q, want none", got)
+	}
+}
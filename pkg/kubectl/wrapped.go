@@ -0,0 +1,58 @@
+package kubectl
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MarkWrapped sets WrappedEnv for the kubectl commands this process runs
+// to a random nonce, registered in dir under this process's ID. Only a
+// nonce registered by a kctl still running passes Wrapped, so setting the
+// variable by hand gets raw kubectl nothing. Registrations of kctl
+// processes that have exited are removed.
+func MarkWrapped(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if pid, ok := registeredPID(filepath.Join(dir, e.Name())); !ok || !processAlive(pid) {
+				os.Remove(filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(buf)
+	if err := os.WriteFile(filepath.Join(dir, nonce), []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		return err
+	}
+	return os.Setenv(WrappedEnv, nonce)
+}
+
+// Wrapped reports whether nonce, the WrappedEnv value of a kubectl
+// command, was registered in dir by a kctl that is still running
+func Wrapped(dir, nonce string) bool {
+	if nonce == "" || strings.ContainsAny(nonce, `/\.`) {
+		return false
+	}
+	pid, ok := registeredPID(filepath.Join(dir, nonce))
+	return ok && processAlive(pid)
+}
+
+// registeredPID reads the process ID a nonce was registered under
+func registeredPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil && pid > 0
+}
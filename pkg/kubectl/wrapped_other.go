@@ -0,0 +1,9 @@
+//go:build !unix
+
+package kubectl
+
+// processAlive can't check for processes here (Windows), so registrations
+// last until their files are removed
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,31 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarkWrapped(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wrapped")
+	t.Setenv(WrappedEnv, "")
+	// A registration left by a kctl that has exited
+	os.MkdirAll(dir, 0700)
+	os.WriteFile(filepath.Join(dir, "stale"), []byte("999999999"), 0600)
+
+	if err := MarkWrapped(dir); err != nil {
+		t.Fatal(err)
+	}
+	nonce := os.Getenv(WrappedEnv)
+	if !Wrapped(dir, nonce) {
+		t.Errorf("Wrapped(%q) = false for this process's nonce", nonce)
+	}
+	for _, forged := range []string{"", "1", "stale", "../wrapped/" + nonce} {
+		if Wrapped(dir, forged) {
+			t.Errorf("Wrapped(%q) = true", forged)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale")); !os.IsNotExist(err) {
+		t.Error("the stale registration wasn't removed")
+	}
+}
@@ -0,0 +1,312 @@
+// Package lease coordinates risky operations between engineers with a
+// coordination.k8s.io Lease in the target cluster
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+// Defaults for the Lease object
+const (
+	DefaultNamespace = "kube-system"
+	DefaultName      = "kctl-operations"
+	DefaultDuration  = 10 * time.Minute
+)
+
+// DefaultActions are the actions that take the lease unless configured
+var DefaultActions = []string{"drain", "delete"}
+
+// operationAnnotation records what the holder is doing
+const operationAnnotation = "kctl.io/operation"
+
+// errConflict is returned by a store when the object changed underneath us
+var errConflict = errors.New("lease was modified concurrently")
+
+// HeldError reports that someone else holds the lease
+type HeldError struct {
+	Holder    string
+	Operation string
+	Since     time.Time
+	Expires   time.Time
+}
+
+func (e *HeldError) Error() string {
+	msg := fmt.Sprintf("lease is held by %s since %s", e.Holder, e.Since.Local().Format("15:04"))
+	if e.Operation != "" {
+		msg += fmt.Sprintf(" (%s)", e.Operation)
+	}
+	return msg + fmt.Sprintf("; it expires at %s unless renewed", e.Expires.Local().Format("15:04"))
+}
+
+// Lease is one cluster's operation lease
+type Lease struct {
+	Context   string
+	Namespace string
+	Name      string
+	Duration  time.Duration
+	Identity  string
+
+	store store
+	now   func() time.Time
+
+	mu   sync.Mutex
+	held *object
+}
+
+// Actions returns the actions that take the lease under cfg
+func Actions(cfg config.LeaseConfig) []string {
+	if len(cfg.Actions) == 0 {
+		return DefaultActions
+	}
+	return cfg.Actions
+}
+
+// New returns the lease for the cluster behind context
+func New(context string, cfg config.LeaseConfig) *Lease {
+	l := &Lease{
+		Context:   context,
+		Namespace: cfg.Namespace,
+		Name:      cfg.Name,
+		Duration:  DefaultDuration,
+		Identity:  Identity(),
+		now:       time.Now,
+	}
+	if l.Namespace == "" {
+		l.Namespace = DefaultNamespace
+	}
+	if l.Name == "" {
+		l.Name = DefaultName
+	}
+	if d, err := time.ParseDuration(cfg.Duration); err == nil && d > 0 {
+		l.Duration = d
+	}
+	l.store = &kubectlStore{context: context, namespace: l.Namespace, name: l.Name}
+	return l
+}
+
+// Identity names the current engineer as user@host
+func Identity() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return name + "@" + host
+}
+
+// Acquire takes the lease for operation. It fails with a *HeldError when
+// someone else holds an unexpired lease.
+func (l *Lease) Acquire(operation string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for attempt := 0; attempt < 3; attempt++ {
+		current, err := l.store.get()
+		if err != nil {
+			return err
+		}
+
+		now := l.now()
+		if current == nil {
+			obj := l.newObject(operation, now)
+			if err := l.store.create(obj); err == errConflict {
+				continue
+			} else if err != nil {
+				return err
+			}
+			l.held = obj
+			return nil
+		}
+
+		if holder := current.Spec.HolderIdentity; holder != "" && holder != l.Identity && now.Before(current.expires()) {
+			return &HeldError{
+				Holder:    holder,
+				Operation: current.Metadata.Annotations[operationAnnotation],
+				Since:     current.Spec.AcquireTime.Time,
+				Expires:   current.expires(),
+			}
+		}
+
+		obj := l.newObject(operation, now)
+		obj.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+		if err := l.store.update(obj); err == errConflict {
+			continue
+		} else if err != nil {
+			return err
+		}
+		l.held = obj
+		return nil
+	}
+	return errConflict
+}
+
+// Renew extends a held lease by another Duration
+func (l *Lease) Renew() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held == nil {
+		return nil
+	}
+	l.held.Spec.RenewTime = &microTime{l.now()}
+	return l.store.update(l.held)
+}
+
+// Release gives up a held lease so others can take it immediately
+func (l *Lease) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held == nil {
+		return nil
+	}
+	l.held.Spec.HolderIdentity = ""
+	delete(l.held.Metadata.Annotations, operationAnnotation)
+	err := l.store.update(l.held)
+	l.held = nil
+	return err
+}
+
+// KeepAlive renews the lease in the background until stop is called, so
+// operations longer than Duration keep it. When a renewal fails, the lease
+// may lapse or already be someone else's: renewing stops and lost is
+// called with the error.
+func (l *Lease) KeepAlive(lost func(error)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(l.Duration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := l.Renew(); err != nil {
+					lost(err)
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (l *Lease) newObject(operation string, now time.Time) *object {
+	obj := &object{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"}
+	obj.Metadata.Name = l.Name
+	obj.Metadata.Namespace = l.Namespace
+	obj.Metadata.Annotations = map[string]string{operationAnnotation: operation}
+	obj.Spec.HolderIdentity = l.Identity
+	obj.Spec.LeaseDurationSeconds = int(l.Duration / time.Second)
+	obj.Spec.AcquireTime = &microTime{now}
+	obj.Spec.RenewTime = &microTime{now}
+	return obj
+}
+
+// object is the part of a Lease kctl reads and writes
+type object struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		ResourceVersion string            `json:"resourceVersion,omitempty"`
+		Annotations     map[string]string `json:"annotations,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       string     `json:"holderIdentity,omitempty"`
+		LeaseDurationSeconds int        `json:"leaseDurationSeconds,omitempty"`
+		AcquireTime          *microTime `json:"acquireTime,omitempty"`
+		RenewTime            *microTime `json:"renewTime,omitempty"`
+	} `json:"spec"`
+}
+
+// expires returns when the lease lapses without renewal
+func (o *object) expires() time.Time {
+	renewed := time.Time{}
+	if o.Spec.RenewTime != nil {
+		renewed = o.Spec.RenewTime.Time
+	} else if o.Spec.AcquireTime != nil {
+		renewed = o.Spec.AcquireTime.Time
+	}
+	return renewed.Add(time.Duration(o.Spec.LeaseDurationSeconds) * time.Second)
+}
+
+// microTime is the Kubernetes MicroTime wire format
+type microTime struct{ time.Time }
+
+func (t microTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+}
+
+// store reads and writes the Lease object
+type store interface {
+	// get returns nil when the Lease doesn't exist
+	get() (*object, error)
+	// create and update return errConflict when the object was created or
+	// changed by someone else first
+	create(obj *object) error
+	update(obj *object) error
+}
+
+type kubectlStore struct {
+	context, namespace, name string
+}
+
+func (s *kubectlStore) get() (*object, error) {
+	stdout, stderr, exitCode := kubectl.ExecuteWithOutput([]string{
+		"--context", s.context, "--request-timeout=10s",
+		"get", "lease", s.name, "-n", s.namespace, "-o", "json",
+	})
+	if exitCode != 0 {
+		if strings.Contains(stderr, "NotFound") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading lease %s/%s: %s", s.namespace, s.name, strings.TrimSpace(stderr))
+	}
+	var obj object
+	if err := json.Unmarshal([]byte(stdout), &obj); err != nil {
+		return nil, fmt.Errorf("reading lease %s/%s: %w", s.namespace, s.name, err)
+	}
+	return &obj, nil
+}
+
+func (s *kubectlStore) create(obj *object) error {
+	return s.write("create", obj)
+}
+
+func (s *kubectlStore) update(obj *object) error {
+	return s.write("replace", obj)
+}
+
+func (s *kubectlStore) write(verb string, obj *object) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	stdout, stderr, exitCode := kubectl.ExecuteWithInput([]string{
+		"--context", s.context, "--request-timeout=10s",
+		verb, "-f", "-", "-o", "json",
+	}, data)
+	if exitCode != 0 {
+		if strings.Contains(stderr, "AlreadyExists") || strings.Contains(stderr, "Conflict") ||
+			strings.Contains(stderr, "the object has been modified") {
+			return errConflict
+		}
+		return fmt.Errorf("writing lease %s/%s: %s", s.namespace, s.name, strings.TrimSpace(stderr))
+	}
+	// Keep the new resourceVersion for the next renewal
+	var written object
+	if err := json.Unmarshal([]byte(stdout), &written); err == nil {
+		obj.Metadata.ResourceVersion = written.Metadata.ResourceVersion
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package lease
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory API server holding one Lease
+type memStore struct {
+	obj     *object
+	version int
+}
+
+func (s *memStore) get() (*object, error) {
+	if s.obj == nil {
+		return nil, nil
+	}
+	copied := *s.obj
+	return &copied, nil
+}
+
+func (s *memStore) create(obj *object) error {
+	if s.obj != nil {
+		return errConflict
+	}
+	return s.save(obj)
+}
+
+func (s *memStore) update(obj *object) error {
+	if s.obj == nil || obj.Metadata.ResourceVersion != s.obj.Metadata.ResourceVersion {
+		return errConflict
+	}
+	return s.save(obj)
+}
+
+func (s *memStore) save(obj *object) error {
+	s.version++
+	obj.Metadata.ResourceVersion = strconv.Itoa(s.version)
+	copied := *obj
+	s.obj = &copied
+	return nil
+}
+
+func newTestLease(store *memStore, identity string, now *time.Time) *Lease {
+	return &Lease{
+		Namespace: DefaultNamespace,
+		Name:      DefaultName,
+		Duration:  10 * time.Minute,
+		Identity:  identity,
+		store:     store,
+		now:       func() time.Time { return *now },
+	}
+}
+
+func TestAcquire_Contention(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := &memStore{}
+	alice := newTestLease(store, "alice@laptop", &now)
+	bob := newTestLease(store, "bob@desk", &now)
+
+	if err := alice.Acquire("drain node-1"); err != nil {
+		t.Fatalf("alice Acquire failed: %v", err)
+	}
+
+	err := bob.Acquire("delete pod x")
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("bob Acquire = %v, want HeldError", err)
+	}
+	if held.Holder != "alice@laptop" || held.Operation != "drain node-1" || !held.Expires.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("HeldError = %+v", held)
+	}
+
+	// Renewal keeps it held past the original expiry
+	now = now.Add(8 * time.Minute)
+	if err := alice.Renew(); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	now = now.Add(5 * time.Minute)
+	if err := bob.Acquire("delete pod x"); !errors.As(err, &held) {
+		t.Errorf("bob Acquire after renewal = %v, want HeldError", err)
+	}
+
+	if err := alice.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := bob.Acquire("delete pod x"); err != nil {
+		t.Errorf("bob Acquire after release = %v", err)
+	}
+}
+
+func TestAcquire_Expired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := &memStore{}
+	alice := newTestLease(store, "alice@laptop", &now)
+	bob := newTestLease(store, "bob@desk", &now)
+
+	if err := alice.Acquire("drain node-1"); err != nil {
+		t.Fatalf("alice Acquire failed: %v", err)
+	}
+	now = now.Add(11 * time.Minute)
+	if err := bob.Acquire("drain node-2"); err != nil {
+		t.Errorf("Acquire of an expired lease = %v", err)
+	}
+	if store.obj.Spec.HolderIdentity != "bob@desk" {
+		t.Errorf("holder = %q, want bob@desk", store.obj.Spec.HolderIdentity)
+	}
+}
+
+func TestAcquire_SameIdentity(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := &memStore{}
+	first := newTestLease(store, "alice@laptop", &now)
+	second := newTestLease(store, "alice@laptop", &now)
+
+	if err := first.Acquire("drain node-1"); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if err := second.Acquire("drain node-2"); err != nil {
+		t.Errorf("Acquire by the same engineer = %v, want success", err)
+	}
+}
+
+func TestKeepAlive_Lost(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := &memStore{}
+	alice := newTestLease(store, "alice@laptop", &now)
+	alice.Duration = 30 * time.Millisecond
+	if err := alice.Acquire("drain node-1"); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	// Someone else changed the lease in the meantime
+	store.obj.Metadata.ResourceVersion = "changed"
+
+	lost := make(chan error, 1)
+	stop := alice.KeepAlive(func(err error) { lost <- err })
+	defer stop()
+	select {
+	case err := <-lost:
+		if !errors.Is(err, errConflict) {
+			t.Errorf("lost with %v, want a conflict", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a failed renewal went unreported")
+	}
+}
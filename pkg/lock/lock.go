@@ -0,0 +1,81 @@
+// Package lock stores the local read-only switch set by 'kctl lock'
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Modes of a lock
+const (
+	ModeConfirm = "confirm" // Mutating actions need confirmation on every cluster
+	ModeBlock   = "block"   // Mutating actions are blocked on every cluster
+)
+
+// State is an active lock
+type State struct {
+	Mode     string    `json:"mode"`
+	Reason   string    `json:"reason,omitempty"`
+	LockedAt time.Time `json:"locked_at"`
+	// ExpiresAt is when the lock lifts by itself; zero means until 'kctl unlock'
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the lock has lifted by itself at now
+func (s State) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// Path returns the default lock file location
+func Path() string {
+	return filepath.Join(config.DataDir(), "lock.json")
+}
+
+// Load returns the lock stored at path, or nil when kctl isn't locked.
+// An expired lock is removed.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("corrupt lock file %s: %w", path, err)
+	}
+	if state.Mode != ModeConfirm && state.Mode != ModeBlock {
+		return nil, fmt.Errorf("lock file %s has unknown mode %q", path, state.Mode)
+	}
+	if state.Expired(time.Now()) {
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save writes state to path
+func Save(path string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return statefile.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Clear removes the lock at path. It reports whether a lock was active.
+func Clear(path string) (bool, error) {
+	state, err := Load(path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return state != nil || err != nil, nil
+}
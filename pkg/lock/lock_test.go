@@ -0,0 +1,61 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "lock.json")
+
+	if state, err := Load(path); err != nil || state != nil {
+		t.Fatalf("Load without a lock = %v, %v; want nil, nil", state, err)
+	}
+
+	want := State{Mode: ModeBlock, Reason: "incident 42", LockedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil || got == nil {
+		t.Fatalf("Load = %v, %v", got, err)
+	}
+	if got.Mode != want.Mode || got.Reason != want.Reason || !got.LockedAt.Equal(want.LockedAt) || !got.ExpiresAt.IsZero() {
+		t.Errorf("Load = %+v, want %+v", *got, want)
+	}
+
+	active, err := Clear(path)
+	if err != nil || !active {
+		t.Errorf("Clear = %v, %v; want true, nil", active, err)
+	}
+	if active, _ := Clear(path); active {
+		t.Error("Second Clear should report no active lock")
+	}
+}
+
+func TestLoad_Expired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock.json")
+	past := time.Now().Add(-time.Minute)
+	if err := Save(path, State{Mode: ModeConfirm, LockedAt: past.Add(-time.Hour), ExpiresAt: past}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if state, err := Load(path); err != nil || state != nil {
+		t.Errorf("Load of expired lock = %v, %v; want nil, nil", state, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expired lock file should be removed")
+	}
+}
+
+func TestLoad_UnknownMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock.json")
+	if err := os.WriteFile(path, []byte(`{"mode":"maybe"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
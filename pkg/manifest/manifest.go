@@ -0,0 +1,281 @@
+// Package manifest reads the objects in the local files given to kubectl
+// with -f, so commands can be judged by what they contain
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Object identifies a Kubernetes object in a manifest
+type Object struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Images are the container images of the pods the object runs
+	Images []string
+}
+
+// extensions are the files kubectl reads from a directory
+var extensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// IsRemote reports whether a -f value is read by kubectl rather than from
+// the local filesystem: a URL or "-" for stdin
+func IsRemote(path string) bool {
+	return path == "-" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// Host returns the host a -f or -k target is downloaded from: the host of
+// a URL, or of the git repositories kustomize accepts, such as
+// "github.com/org/repo//deploy?ref=v1" and "git@github.com:org/repo". ok is
+// false for local paths and stdin.
+func Host(target string) (string, bool) {
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" && u.Host != "" {
+		return strings.ToLower(u.Hostname()), true
+	}
+	if rest, ok := strings.CutPrefix(target, "git@"); ok {
+		host, _, _ := strings.Cut(rest, ":")
+		return strings.ToLower(host), true
+	}
+	first, _, found := strings.Cut(target, "/")
+	if found && strings.Contains(first, ".") && first != "." && first != ".." {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			return strings.ToLower(first), true
+		}
+	}
+	return "", false
+}
+
+// Files returns path itself, or the manifest files kubectl reads from it
+// when it is a directory; with recursive, those of its subdirectories too
+func Files(path string, recursive bool) ([]string, error) {
+	return expand(path, recursive)
+}
+
+// CountKinds summarizes objects by kind, most common first, e.g.
+// "3 Deployment, 2 Service"
+func CountKinds(objects []Object) string {
+	counts := make(map[string]int)
+	var kinds []string
+	for _, o := range objects {
+		if counts[o.Kind] == 0 {
+			kinds = append(kinds, o.Kind)
+		}
+		counts[o.Kind]++
+	}
+	sort.SliceStable(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stdin is the manifest ReadStdin read
+var stdin []byte
+
+// ReadStdin reads the manifest piped to "-f -" from r, after which Load
+// returns its objects for "-". kubectl can no longer read it, so the data
+// returned must be passed on.
+func ReadStdin(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	stdin = data
+	return data, nil
+}
+
+// Unread returns the paths Load skips, whose objects only kubectl sees:
+// remote paths, and "-" unless ReadStdin has read it
+func Unread(paths []string) []string {
+	var unread []string
+	for _, path := range paths {
+		if path == "-" && stdin != nil {
+			continue
+		}
+		if IsRemote(path) {
+			unread = append(unread, path)
+		}
+	}
+	return unread
+}
+
+// Load returns the objects in the files at paths. A directory contributes
+// its manifest files, and with recursive those of its subdirectories.
+// Remote paths (see IsRemote) are skipped, and so is "-" (stdin) unless
+// ReadStdin has read it.
+func Load(paths []string, recursive bool) ([]Object, error) {
+	var objects []Object
+	for _, path := range paths {
+		if path == "-" {
+			found, err := Parse(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("stdin: %w", err)
+			}
+			objects = append(objects, found...)
+			continue
+		}
+		if IsRemote(path) {
+			continue
+		}
+		files, err := expand(path, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			found, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			objects = append(objects, found...)
+		}
+	}
+	return objects, nil
+}
+
+// Parse returns the objects in a YAML or JSON manifest, which may hold
+// several documents and List kinds
+func Parse(data []byte) ([]Object, error) {
+	var objects []Object
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc document
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, doc.objects()...)
+	}
+}
+
+// podSpec is the part of a pod spec Parse needs
+type podSpec struct {
+	Containers []struct {
+		Image string `yaml:"image"`
+	} `yaml:"containers"`
+	InitContainers []struct {
+		Image string `yaml:"image"`
+	} `yaml:"initContainers"`
+}
+
+// images returns the images of the spec's containers, init containers
+// first
+func (s podSpec) images() []string {
+	var images []string
+	for _, c := range s.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range s.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// podBearing are the kinds whose spec runs pods, by where their pod spec is
+var podBearing = map[string]bool{
+	"Pod": true, "Deployment": true, "StatefulSet": true, "DaemonSet": true,
+	"ReplicaSet": true, "ReplicationController": true, "Job": true, "CronJob": true,
+}
+
+// workloadSpec holds a Pod's containers, the template of the workloads
+// that create pods and a CronJob's job template
+type workloadSpec struct {
+	podSpec  `yaml:",inline"`
+	Template struct {
+		Spec podSpec `yaml:"spec"`
+	} `yaml:"template"`
+	JobTemplate struct {
+		Spec struct {
+			Template struct {
+				Spec podSpec `yaml:"spec"`
+			} `yaml:"template"`
+		} `yaml:"spec"`
+	} `yaml:"jobTemplate"`
+}
+
+// document is the part of a manifest document Parse needs. The spec is
+// only read for the images of pod-bearing kinds, as other kinds, such as
+// custom resources, give its fields their own shapes.
+type document struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec  yaml.Node  `yaml:"spec"`
+	Items []document `yaml:"items"`
+}
+
+func (d document) objects() []Object {
+	if strings.HasSuffix(d.Kind, "List") {
+		var objects []Object
+		for _, item := range d.Items {
+			objects = append(objects, item.objects()...)
+		}
+		return objects
+	}
+	if d.Kind == "" {
+		return nil
+	}
+	var images []string
+	if podBearing[d.Kind] && !d.Spec.IsZero() {
+		// A spec of the wrong shape yields the images that could be read;
+		// kubectl rejects the rest
+		var spec workloadSpec
+		_ = d.Spec.Decode(&spec)
+		images = append(append(spec.images(), spec.Template.Spec.images()...), spec.JobTemplate.Spec.Template.Spec.images()...)
+	}
+	return []Object{{Kind: d.Kind, Name: d.Metadata.Name, Namespace: d.Metadata.Namespace, Images: images}}
+}
+
+// expand returns path itself, or the manifest files in it when it is a
+// directory
+func expand(path string, recursive bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if extensions[filepath.Ext(p)] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
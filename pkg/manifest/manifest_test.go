@@ -0,0 +1,173 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: payments
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: payments
+spec:
+  template:
+    spec:
+      initContainers:
+        - image: busybox
+      containers:
+        - image: ghcr.io/acme/api:v2
+---
+kind: CronJob
+metadata:
+  name: report
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - image: ghcr.io/acme/report@sha256:abc
+---
+# empty document
+---
+{"apiVersion": "v1", "kind": "List", "items": [{"kind": "Namespace", "metadata": {"name": "billing"}}]}
+`
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Object{
+		{Kind: "Namespace", Name: "payments"},
+		{Kind: "Deployment", Name: "api", Namespace: "payments", Images: []string{"busybox", "ghcr.io/acme/api:v2"}},
+		{Kind: "CronJob", Name: "report", Images: []string{"ghcr.io/acme/report@sha256:abc"}},
+		{Kind: "Namespace", Name: "billing"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+
+	if _, err := Parse([]byte("kind: [unclosed")); err == nil {
+		t.Error("Parse accepted invalid YAML")
+	}
+}
+
+func TestParseCustomResources(t *testing.T) {
+	// Fields a pod spec has can have other shapes in custom resources
+	data := `apiVersion: example.com/v1
+kind: Pipeline
+metadata: {name: build}
+spec:
+  template: "{{ .Values.image }}"
+  containers: {main: {image: busybox}}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: odd}
+spec:
+  containers: "not a list"
+  initContainers:
+    - image: busybox
+`
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Object{{Kind: "Pipeline", Name: "build"}, {Kind: "Pod", Name: "odd", Images: []string{"busybox"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ns.yaml", "kind: Namespace\nmetadata:\n  name: a\n")
+	write("notes.txt", "kind: Namespace\nmetadata:\n  name: ignored\n")
+	write("sub/ns.yml", "kind: Namespace\nmetadata:\n  name: b\n")
+
+	names := func(objects []Object) []string {
+		var out []string
+		for _, o := range objects {
+			out = append(out, o.Name)
+		}
+		return out
+	}
+
+	objects, err := Load([]string{dir, "-", "https://example.com/app.yaml"}, false)
+	if err != nil || !reflect.DeepEqual(names(objects), []string{"a"}) {
+		t.Errorf("Load(dir) = %v, %v; want [a]", names(objects), err)
+	}
+	objects, err = Load([]string{dir}, true)
+	if err != nil || !reflect.DeepEqual(names(objects), []string{"a", "b"}) {
+		t.Errorf("Load(dir, recursive) = %v, %v; want [a b]", names(objects), err)
+	}
+	if _, err := Load([]string{filepath.Join(dir, "missing.yaml")}, false); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+	if got := Unread([]string{dir, "-", "https://example.com/app.yaml"}); !reflect.DeepEqual(got, []string{"-", "https://example.com/app.yaml"}) {
+		t.Errorf("Unread = %v, want stdin and the URL", got)
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		target string
+		host   string
+		ok     bool
+	}{
+		{"https://raw.githubusercontent.com/org/repo/main/deploy.yaml", "raw.githubusercontent.com", true},
+		{"HTTP://Example.com:8080/app.yaml", "example.com", true},
+		{"github.com/org/repo//deploy/overlays/prod?ref=v1.2.0", "github.com", true},
+		{"git@gitlab.example.com:org/repo.git", "gitlab.example.com", true},
+		{"overlays/prod", "", false},
+		{"./app.yaml", "", false},
+		{"-", "", false},
+	}
+	for _, tt := range tests {
+		host, ok := Host(tt.target)
+		if host != tt.host || ok != tt.ok {
+			t.Errorf("Host(%q) = %q, %v; want %q, %v", tt.target, host, ok, tt.host, tt.ok)
+		}
+	}
+}
+
+func TestCountKinds(t *testing.T) {
+	objects := []Object{{Kind: "Service"}, {Kind: "Deployment"}, {Kind: "ConfigMap"}, {Kind: "Deployment"}}
+	if got := CountKinds(objects); got != "2 Deployment, 1 ConfigMap, 1 Service" {
+		t.Errorf("CountKinds = %q", got)
+	}
+}
+
+func TestReadStdin(t *testing.T) {
+	t.Cleanup(func() { stdin = nil })
+	if objects, err := Load([]string{"-"}, false); err != nil || len(objects) != 0 {
+		t.Errorf("Load(-) before ReadStdin = %v, %v", objects, err)
+	}
+
+	manifest := "kind: Deployment\nmetadata:\n  name: api\n  namespace: shop\n"
+	data, err := ReadStdin(strings.NewReader(manifest))
+	if err != nil || string(data) != manifest {
+		t.Fatalf("ReadStdin = %q, %v", data, err)
+	}
+	objects, err := Load([]string{"-"}, false)
+	want := []Object{{Kind: "Deployment", Name: "api", Namespace: "shop"}}
+	if err != nil || !reflect.DeepEqual(objects, want) {
+		t.Errorf("Load(-) = %+v, %v, want %+v", objects, err, want)
+	}
+}
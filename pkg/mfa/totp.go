@@ -0,0 +1,131 @@
+// Package mfa implements the time-based one-time passwords (RFC 6238) kctl
+// asks for before critical actions
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// SecretName is the keyring entry holding the enrolled TOTP secret
+const SecretName = "mfa-totp"
+
+// Parameters compatible with every common authenticator app
+const (
+	period = 30 * time.Second
+	digits = 6
+	// skew is how many periods either side of now are accepted, for clock
+	// drift and codes typed just as they roll over
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 secret
+func GenerateSecret() (string, error) {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(key), nil
+}
+
+// Code returns the code for secret at t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return code(key, uint64(t.Unix())/uint64(period/time.Second)), nil
+}
+
+// Validate reports whether code is valid for secret at t
+func Validate(secret, code string, t time.Time) bool {
+	_, ok := match(secret, code, t)
+	return ok
+}
+
+// Errors of Use
+var (
+	ErrInvalid = errors.New("invalid code")
+	ErrReused  = errors.New("code was already used; wait for the next one")
+)
+
+// Use checks code like Validate and records its time step in the file at
+// path. A code from the recorded step or an earlier one is refused with
+// ErrReused, so a code that has been seen can't be replayed while it is
+// still valid.
+func Use(path, secret, code string, t time.Time) error {
+	step, ok := match(secret, code, t)
+	if !ok {
+		return ErrInvalid
+	}
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if data, err := os.ReadFile(path); err == nil {
+		if last, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil && step <= last {
+			return ErrReused
+		}
+	}
+	return statefile.WriteFile(path, []byte(strconv.FormatUint(step, 10)+"\n"), 0600)
+}
+
+// match returns the time step code is valid for, checking skew periods
+// either side of t
+func match(secret, code string, t time.Time) (uint64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != digits {
+		return 0, false
+	}
+	for i := -skew; i <= skew; i++ {
+		at := t.Add(time.Duration(i) * period)
+		want, err := Code(secret, at)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return uint64(at.Unix()) / uint64(period/time.Second), true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the otpauth:// URI authenticator apps enroll from
+func URI(secret, account string) string {
+	return (&url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/kctl:" + account,
+		RawQuery: url.Values{
+			"secret": {secret},
+			"issuer": {"kctl"},
+		}.Encode(),
+	}).String()
+}
+
+func code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
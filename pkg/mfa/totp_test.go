@@ -0,0 +1,84 @@
+package mfa
+
+import (
+	"encoding/base32"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil || got != tt.want {
+			t.Errorf("Code at %d = %s, %v, want %s", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"081804", true},
+		{" 081 804 ", true},
+		{"050471", true}, // next period
+		{"000000", false},
+		{"81804", false},
+	}
+	for _, tt := range tests {
+		if got := Validate(rfcSecret, tt.code, now); got != tt.want {
+			t.Errorf("Validate(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Code(secret, time.Now()); err != nil {
+		t.Errorf("Code with generated secret: %v", err)
+	}
+	if uri := URI(secret, "me@example.com"); !strings.HasPrefix(uri, "otpauth://totp/kctl:me@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("URI = %s", uri)
+	}
+}
+
+func TestUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mfa-step")
+	now := time.Unix(1111111109, 0)
+	if err := Use(path, rfcSecret, "081804", now); err != nil {
+		t.Fatalf("Use = %v, want the code accepted", err)
+	}
+	if err := Use(path, rfcSecret, "081804", now.Add(10*time.Second)); !errors.Is(err, ErrReused) {
+		t.Errorf("Use of the same code = %v, want ErrReused", err)
+	}
+	if err := Use(path, rfcSecret, "000000", now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Use of a wrong code = %v, want ErrInvalid", err)
+	}
+	if err := Use(path, rfcSecret, "050471", now); err != nil {
+		t.Errorf("Use of the next period's code = %v, want it accepted", err)
+	}
+	if err := Use(path, rfcSecret, "081804", now); !errors.Is(err, ErrReused) {
+		t.Errorf("Use of a code older than the last = %v, want ErrReused", err)
+	}
+}
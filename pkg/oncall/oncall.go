@@ -0,0 +1,99 @@
+// Package oncall asks PagerDuty whether the invoking engineer is currently
+// on call for a schedule
+package oncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+)
+
+// DefaultAPIURL is PagerDuty's REST API
+const DefaultAPIURL = "https://api.pagerduty.com"
+
+// DefaultTimeout bounds the request to PagerDuty
+const DefaultTimeout = 10 * time.Second
+
+// Email returns the PagerDuty login to check: the configured email, or
+// git's user.email
+func Email(cfg config.OnCallConfig) string {
+	if cfg.Email != "" {
+		return cfg.Email
+	}
+	out, err := exec.Command("git", "config", "--get", "user.email").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// IsOnCall reports whether email is currently on call for the configured
+// schedule, at any escalation level
+func IsOnCall(cfg config.OnCallConfig, email string) (bool, error) {
+	if cfg.ScheduleID == "" {
+		return false, fmt.Errorf("oncall.schedule_id is not set")
+	}
+	if email == "" {
+		return false, fmt.Errorf("no email to look up; set oncall.email")
+	}
+	token, err := secrets.Token(cfg.TokenEnv, cfg.TokenSecret)
+	if err != nil {
+		return false, err
+	}
+	if token == "" {
+		return false, fmt.Errorf("no PagerDuty token; set oncall.token_secret or oncall.token_env")
+	}
+
+	base := cfg.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	timeout := DefaultTimeout
+	if d, err := time.ParseDuration(cfg.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+
+	query := url.Values{
+		"schedule_ids[]": {cfg.ScheduleID},
+		"include[]":      {"users"},
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(base, "/")+"/oncalls?"+query.Encode(), nil)
+	if err != nil {
+		return false, fmt.Errorf("invalid oncall.api_url: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+token)
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return false, fmt.Errorf("querying PagerDuty: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("querying PagerDuty: %s", resp.Status)
+	}
+
+	var body struct {
+		OnCalls []struct {
+			User struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"oncalls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("querying PagerDuty: %w", err)
+	}
+	for _, oc := range body.OnCalls {
+		if strings.EqualFold(oc.User.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,43 @@
+package oncall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestIsOnCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oncalls" || r.URL.Query().Get("schedule_ids[]") != "PSCHED" ||
+			r.Header.Get("Authorization") != "Token token=secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"oncalls":[{"user":{"email":"alice@example.com"}},{"user":{"email":"bob@example.com"}}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("PD_TOKEN", "secret")
+
+	cfg := config.OnCallConfig{ScheduleID: "PSCHED", TokenEnv: "PD_TOKEN", APIURL: srv.URL}
+	for email, want := range map[string]bool{
+		"Bob@example.com":   true,
+		"carol@example.com": false,
+	} {
+		got, err := IsOnCall(cfg, email)
+		if err != nil || got != want {
+			t.Errorf("IsOnCall(%s) = %v, %v, want %v", email, got, err, want)
+		}
+	}
+
+	cfg.ScheduleID = "POTHER"
+	if _, err := IsOnCall(cfg, "bob@example.com"); err == nil {
+		t.Error("IsOnCall with a rejected request succeeded")
+	}
+
+	t.Setenv("PD_TOKEN", "")
+	if _, err := IsOnCall(cfg, "bob@example.com"); err == nil {
+		t.Error("IsOnCall without a token succeeded")
+	}
+}
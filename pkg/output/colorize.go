@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// statusPattern matches the status words colored in 'kubectl get' tables
+var statusPattern = regexp.MustCompile(`^(?:(?:Init:)?[A-Za-z]+(?:BackOff|Error|Failed|Pull)|Error|Failed|OOMKilled|Evicted|NotReady|Unknown|Lost|Pending|ContainerCreating|PodInitializing|Terminating|Init:\d+/\d+|SchedulingDisabled|Running|Ready|Completed|Succeeded|Bound|Active|Available)$`)
+
+// tokenPattern splits a table line into words; statuses can be joined by
+// commas, as in "Ready,SchedulingDisabled"
+var tokenPattern = regexp.MustCompile(`[^\s,]+`)
+
+// statusColor maps a matched status to its color
+func statusColor(status string) string {
+	switch status {
+	case "Running", "Ready", "Completed", "Succeeded", "Bound", "Active", "Available":
+		return ColorGreen
+	case "Pending", "ContainerCreating", "PodInitializing", "Terminating", "SchedulingDisabled":
+		return ColorYellow
+	}
+	if len(status) > 5 && status[:5] == "Init:" && status[5] >= '0' && status[5] <= '9' {
+		return ColorYellow
+	}
+	return ColorRed
+}
+
+// StatusColorizer colors status words in 'kubectl get' table output: red for
+// failures (CrashLoopBackOff, Error, ...), yellow for transitions (Pending,
+// Terminating, ...), green for healthy states (Running, Ready, ...). Lines
+// are passed through as soon as they are complete.
+type StatusColorizer struct {
+	w       io.Writer
+	partial []byte
+	header  bool
+}
+
+// NewStatusColorizer returns a colorizer writing to w. Close flushes a final
+// unterminated line.
+func NewStatusColorizer(w io.Writer) *StatusColorizer {
+	return &StatusColorizer{w: w, header: true}
+}
+
+func (c *StatusColorizer) Write(p []byte) (int, error) {
+	c.partial = append(c.partial, p...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := c.colorize(c.partial[:i+1])
+		c.partial = c.partial[i+1:]
+		if _, err := c.w.Write(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Close writes any remaining partial line
+func (c *StatusColorizer) Close() error {
+	if len(c.partial) == 0 {
+		return nil
+	}
+	_, err := c.w.Write(c.colorize(c.partial))
+	c.partial = nil
+	return err
+}
+
+func (c *StatusColorizer) colorize(line []byte) []byte {
+	if colorsDisabled {
+		return line
+	}
+	// The header names columns; it has no statuses
+	if c.header {
+		c.header = false
+		if bytes.HasPrefix(line, []byte("NAME")) || bytes.HasPrefix(line, []byte("NAMESPACE")) {
+			return line
+		}
+	}
+	return tokenPattern.ReplaceAllFunc(line, func(word []byte) []byte {
+		if !statusPattern.Match(word) {
+			return word
+		}
+		colored := append([]byte(statusColor(string(word))), word...)
+		return append(colored, ColorReset...)
+	})
+}
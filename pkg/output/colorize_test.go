@@ -0,0 +1,52 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatusColorizer(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewStatusColorizer(&buf)
+
+	input := "NAME    READY   STATUS             RESTARTS\n" +
+		"web-1   1/1     Running            0\n" +
+		"web-2   0/1     CrashLoopBackOff   7\n" +
+		"node-1  NotReady,SchedulingDisabled\n" +
+		"web-3   0/1     Pend"
+	// Split writes mid-line, as pipes do
+	c.Write([]byte(input))
+	c.Write([]byte("ing            0"))
+	c.Close()
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	if strings.Contains(lines[0], "\033") {
+		t.Errorf("header was colored: %q", lines[0])
+	}
+	for i, want := range []string{ColorGreen + "Running", ColorRed + "CrashLoopBackOff", ColorYellow + "SchedulingDisabled", ColorYellow + "Pending"} {
+		if !strings.Contains(lines[i+1], want+ColorReset) {
+			t.Errorf("line %d = %q, want %q colored", i+1, lines[i+1], want)
+		}
+	}
+}
+
+func TestStatusColor(t *testing.T) {
+	tests := map[string]string{
+		"ImagePullBackOff":      ColorRed,
+		"ErrImagePull":          ColorRed,
+		"Init:CrashLoopBackOff": ColorRed,
+		"Init:0/2":              ColorYellow,
+		"NotReady":              ColorRed,
+		"Ready":                 ColorGreen,
+		"Terminating":           ColorYellow,
+	}
+	for status, want := range tests {
+		if got := statusColor(status); got != want {
+			t.Errorf("statusColor(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -3,8 +3,11 @@ package output
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"strings"
+	"time"
 )
 
 // Color codes
@@ -36,47 +39,108 @@ func DisableColors() {
 	ColorSubLog = ""
 }
 
+// Chrome stream modes accepted by SetChromeStream
+const (
+	ChromeAuto   = "auto"
+	ChromeStdout = "stdout"
+	ChromeStderr = "stderr"
+)
+
+// chrome is the stream used for informational wrapper output (sublogs,
+// info and success messages). Warnings, errors and prompts always go to stderr.
+var chrome = os.Stdout
+
 func init() {
 	// Auto-disable colors if NO_COLOR env var is set
 	if os.Getenv("NO_COLOR") != "" {
 		DisableColors()
 	}
+	SetChromeStream(ChromeAuto)
 }
 
-func isTerminal() bool {
-	if colorsDisabled {
+// SetChromeStream selects where informational wrapper output is written.
+// "stderr" always uses stderr, "stdout" always uses stdout, and "auto" (or "")
+// uses stdout only when it is a terminal, so piped kubectl output stays clean.
+// Returns false if the mode is not recognized (auto is used instead).
+func SetChromeStream(mode string) bool {
+	switch mode {
+	case ChromeStdout:
+		chrome = os.Stdout
+	case ChromeStderr:
+		chrome = os.Stderr
+	case ChromeAuto, "":
+		if isCharDevice(os.Stdout) {
+			chrome = os.Stdout
+		} else {
+			chrome = os.Stderr
+		}
+	default:
+		SetChromeStream(ChromeAuto)
+		return false
+	}
+	return true
+}
+
+// ChromeWriter returns the stream used for informational wrapper output
+func ChromeWriter() io.Writer {
+	return chrome
+}
+
+// isCharDevice reports whether f refers to a terminal-like character device
+func isCharDevice(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	fileInfo, err := f.Stat()
+	if err != nil {
 		return false
 	}
-	fileInfo, _ := os.Stdout.Stat()
 	return (fileInfo.Mode() & os.ModeCharDevice) != 0
 }
 
+// isTerminal reports whether decorated (colored) output should be written to f
+func isTerminal(f *os.File) bool {
+	if colorsDisabled {
+		return false
+	}
+	return isCharDevice(f)
+}
+
+// IsStdoutTerminal reports whether stdout is attached to a terminal
+func IsStdoutTerminal() bool {
+	return isCharDevice(os.Stdout)
+}
+
+// IsStdinTerminal reports whether stdin is attached to a terminal
+func IsStdinTerminal() bool {
+	return isStdinTerminal()
+}
+
 func isStdinTerminal() bool {
-	fileInfo, _ := os.Stdin.Stat()
-	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+	return isCharDevice(os.Stdin)
 }
 
 // PrintCommand prints a command being executed
 func PrintCommand(args ...string) {
-	if !isTerminal() {
-		fmt.Printf("│ %s\n", strings.Join(args, " "))
+	if !isTerminal(chrome) {
+		fmt.Fprintf(chrome, "│ %s\n", strings.Join(args, " "))
 		return
 	}
-	fmt.Printf("%s│ %s%s\n", ColorSubLog, strings.Join(args, " "), ColorReset)
+	fmt.Fprintf(chrome, "%s│ %s%s\n", ColorSubLog, strings.Join(args, " "), ColorReset)
 }
 
 // PrintSublog prints a subordinate log message
 func PrintSublog(message string) {
-	if !isTerminal() {
-		fmt.Printf("│ %s\n", message)
+	if !isTerminal(chrome) {
+		fmt.Fprintf(chrome, "│ %s\n", message)
 		return
 	}
-	fmt.Printf("%s│ %s%s\n", ColorSubLog, message, ColorReset)
+	fmt.Fprintf(chrome, "%s│ %s%s\n", ColorSubLog, message, ColorReset)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(message string) {
-	if !isTerminal() {
+	if !isTerminal(os.Stderr) {
 		fmt.Fprintf(os.Stderr, "⚠️  %s\n", message)
 		return
 	}
@@ -85,7 +149,7 @@ func PrintWarning(message string) {
 
 // PrintError prints an error message
 func PrintError(message string) {
-	if !isTerminal() {
+	if !isTerminal(os.Stderr) {
 		fmt.Fprintf(os.Stderr, "❌ %s\n", message)
 		return
 	}
@@ -94,79 +158,280 @@ func PrintError(message string) {
 
 // PrintSuccess prints a success message
 func PrintSuccess(message string) {
-	if !isTerminal() {
-		fmt.Printf("✅ %s\n", message)
+	if !isTerminal(chrome) {
+		fmt.Fprintf(chrome, "✅ %s\n", message)
 		return
 	}
-	fmt.Printf("%s✅ %s%s\n", ColorGreen, message, ColorReset)
+	fmt.Fprintf(chrome, "%s✅ %s%s\n", ColorGreen, message, ColorReset)
 }
 
 // PrintInfo prints an info message
 func PrintInfo(message string) {
-	if !isTerminal() {
-		fmt.Printf("ℹ️  %s\n", message)
+	if !isTerminal(chrome) {
+		fmt.Fprintf(chrome, "ℹ️  %s\n", message)
+		return
+	}
+	fmt.Fprintf(chrome, "%sℹ️  %s%s\n", ColorCyan, message, ColorReset)
+}
+
+// PrintSummary prints a one-line result of a finished gated command
+func PrintSummary(action, cluster string, exitCode int, elapsed time.Duration) {
+	if exitCode == 0 {
+		PrintSuccess(fmt.Sprintf("%s on %s finished in %s (exit 0)", action, cluster, FormatDuration(elapsed)))
 		return
 	}
-	fmt.Printf("%sℹ️  %s%s\n", ColorCyan, message, ColorReset)
+	PrintError(fmt.Sprintf("%s on %s failed after %s (exit %d)", action, cluster, FormatDuration(elapsed), exitCode))
+}
+
+// FormatDuration renders d compactly: 850ms, 4.2s, 9m12s, 1h3m
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	case d < time.Hour:
+		return d.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
 }
 
 // PrintBlocked prints a blocked action message with styling
-func PrintBlocked(action, cluster, reason string) {
-	if !isTerminal() {
+func PrintBlocked(action, cluster, reason string, guidance Guidance) {
+	if !isTerminal(os.Stderr) {
 		fmt.Fprintf(os.Stderr, "🚫 BLOCKED: Action '%s' is not allowed on cluster '%s'\n", action, cluster)
 		fmt.Fprintf(os.Stderr, "│ Reason: %s\n", reason)
+		printGuidance(guidance)
 		return
 	}
 	fmt.Fprintf(os.Stderr, "%s🚫 BLOCKED:%s Action '%s' is not allowed on cluster '%s'%s\n",
 		ColorRed, ColorBold, action, cluster, ColorReset)
 	fmt.Fprintf(os.Stderr, "%s│ Reason: %s%s\n", ColorSubLog, reason, ColorReset)
+	printGuidance(guidance)
+}
+
+// Guidance is an optional configured message and runbook link shown with
+// a block or confirmation
+type Guidance struct {
+	Message string
+	DocsURL string
+	// Suggestions are kubectl commands (without "kubectl") to try instead
+	Suggestions []string
+}
+
+func printGuidance(g Guidance) {
+	color, reset := ColorSubLog, ColorReset
+	if !isTerminal(os.Stderr) {
+		color, reset = "", ""
+	}
+	if g.Message != "" {
+		fmt.Fprintf(os.Stderr, "%s│ %s%s\n", color, g.Message, reset)
+	}
+	if g.DocsURL != "" {
+		fmt.Fprintf(os.Stderr, "%s│ See: %s%s\n", color, g.DocsURL, reset)
+	}
+	if len(g.Suggestions) > 0 {
+		fmt.Fprintf(os.Stderr, "%s│ Consider instead:%s\n", color, reset)
+		for _, s := range g.Suggestions {
+			fmt.Fprintf(os.Stderr, "%s│   kubectl %s%s\n", color, s, reset)
+		}
+	}
 }
 
 // PrintConfirmationHeader prints the header for a confirmation prompt
-func PrintConfirmationHeader(action, cluster, tier string) {
-	if !isTerminal() {
+func PrintConfirmationHeader(action, cluster, tier string, guidance Guidance) {
+	if !isTerminal(os.Stderr) {
 		fmt.Fprintf(os.Stderr, "⚠️  CONFIRMATION REQUIRED\n")
 		fmt.Fprintf(os.Stderr, "│ Action:  %s\n", action)
 		fmt.Fprintf(os.Stderr, "│ Cluster: %s (%s)\n", cluster, tier)
+		printGuidance(guidance)
 		return
 	}
 	fmt.Fprintf(os.Stderr, "%s⚠️  CONFIRMATION REQUIRED%s\n", ColorYellow+ColorBold, ColorReset)
 	fmt.Fprintf(os.Stderr, "%s│ Action:  %s%s\n", ColorSubLog, action, ColorReset)
 	fmt.Fprintf(os.Stderr, "%s│ Cluster: %s%s (%s)%s\n", ColorSubLog, ColorCyan, cluster, tier, ColorReset)
+	printGuidance(guidance)
 }
 
 // PromptConfirmation asks the user to confirm an action
-// Returns true if confirmed, false otherwise
-func PromptConfirmation(prompt string) bool {
-	// If stdin is not a terminal (piped input), don't prompt
-	if !isStdinTerminal() {
-		PrintError("Cannot prompt for confirmation: stdin is not a terminal. Use --yes to skip confirmation.")
-		return false
+// Returns true if confirmed, false otherwise. With a phrase, only typing
+// exactly that phrase confirms; otherwise y or yes does.
+func PromptConfirmation(prompt, phrase string) bool {
+	hint := "[y/N]"
+	if phrase != "" {
+		hint = fmt.Sprintf("Type %q to confirm", phrase)
 	}
+	response, ok := readConfirmation(prompt, hint)
+	return ok && accepts(response, phrase)
+}
 
-	if isTerminal() {
-		fmt.Fprintf(os.Stderr, "%s%s [y/N]: %s", ColorYellow, prompt, ColorReset)
+// PromptConfirmationOrEdit is PromptConfirmation that also offers to edit
+// the command: edit is true when the user answered e or edit
+func PromptConfirmationOrEdit(prompt, phrase string) (confirmed, edit bool) {
+	hint := "[y/N/e(dit)]"
+	if phrase != "" {
+		hint = fmt.Sprintf("Type %q to confirm, or e to edit", phrase)
+	}
+	response, ok := readConfirmation(prompt, hint)
+	if !ok {
+		return false, false
+	}
+	if wantsEdit(response) {
+		return false, true
+	}
+	return accepts(response, phrase), false
+}
+
+// ttyPath is the controlling terminal prompts read from when stdin is not
+// a terminal; replaced in tests
+var ttyPath = "/dev/tty"
+
+// openPromptInput returns the terminal to read answers from: stdin, or the
+// controlling terminal when stdin carries something else, such as a manifest
+// piped to -f - or a heredoc. ok is false when there is no terminal at all,
+// e.g. in CI; close must be called when done.
+func openPromptInput() (in *os.File, close func(), ok bool) {
+	if isStdinTerminal() {
+		return os.Stdin, func() {}, true
+	}
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, false
+	}
+	return tty, func() { tty.Close() }, true
+}
+
+// PromptTerminal is the terminal prompts read answers from, for callers
+// that read more than a line; see openPromptInput
+func PromptTerminal() (in *os.File, close func(), ok bool) {
+	return openPromptInput()
+}
+
+// readConfirmation shows prompt and hint and reads the answer
+func readConfirmation(prompt, hint string) (string, bool) {
+	// Without any terminal to answer on, don't prompt
+	in, closeInput, ok := openPromptInput()
+	if !ok {
+		PrintError("Cannot prompt for confirmation: no terminal to answer on. Use --yes to skip confirmation.")
+		return "", false
+	}
+	defer closeInput()
+
+	if isTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "%s%s %s: %s", ColorYellow, prompt, hint, ColorReset)
 	} else {
-		fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+		fmt.Fprintf(os.Stderr, "%s %s: ", prompt, hint)
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(in)
 	response, err := reader.ReadString('\n')
 	if err != nil {
-		return false
+		return "", false
 	}
+	return response, true
+}
+
+// wantsEdit reports whether a confirmation response asks to edit
+func wantsEdit(response string) bool {
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "e" || response == "edit"
+}
 
-	response = strings.TrimSpace(strings.ToLower(response))
+// accepts reports whether a confirmation response confirms
+func accepts(response, phrase string) bool {
+	response = strings.TrimSpace(response)
+	if phrase != "" {
+		return response == phrase
+	}
+	response = strings.ToLower(response)
 	return response == "y" || response == "yes"
 }
 
+// PromptInput asks for a line of input on stderr and returns it trimmed.
+// ok is false when stdin is not a terminal or can't be read.
+func PromptInput(prompt string) (string, bool) {
+	in, closeInput, ok := openPromptInput()
+	if !ok {
+		PrintError("Cannot prompt: no terminal to answer on.")
+		return "", false
+	}
+	defer closeInput()
+	return promptInput(in, prompt)
+}
+
+// promptInput is PromptInput reading from in
+func promptInput(in *os.File, prompt string) (string, bool) {
+
+	if isTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "%s%s: %s", ColorYellow, prompt, ColorReset)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	}
+
+	reader := bufio.NewReader(in)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(response), true
+}
+
+// PromptSecret is PromptInput without echoing what is typed
+func PromptSecret(prompt string) (string, bool) {
+	in, closeInput, ok := openPromptInput()
+	if !ok {
+		PrintError("Cannot prompt: no terminal to answer on.")
+		return "", false
+	}
+	defer closeInput()
+	if err := stty(in, "-echo"); err == nil {
+		defer func() {
+			stty(in, "echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	return promptInput(in, prompt)
+}
+
+func stty(tty *os.File, args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
+
 // PrintContext prints the current context information
 func PrintContext(context, tier string) {
-	if !isTerminal() {
-		fmt.Printf("│ Context: %s (%s)\n", context, tier)
+	if !isTerminal(chrome) {
+		fmt.Fprintf(chrome, "│ Context: %s (%s)\n", context, tier)
 		return
 	}
-	fmt.Printf("%s│ Context: %s%s%s (%s)%s\n",
+	fmt.Fprintf(chrome, "%s│ Context: %s%s%s (%s)%s\n",
 		ColorSubLog, ColorCyan, context, ColorSubLog, tier, ColorReset)
 }
 
+// PrintBanner prints a one-line context reminder on stderr
+func PrintBanner(context, tier string) {
+	if !isTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "│ Context: %s (%s)\n", context, tier)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s│ Context: %s%s (%s)%s\n",
+		ColorRed+ColorBold, context, ColorReset+ColorRed, tier, ColorReset)
+}
+
+// FormatPrompt builds the interactive shell prompt, highlighting production tiers
+func FormatPrompt(context, tier, namespace string) string {
+	if !isTerminal(os.Stderr) {
+		return fmt.Sprintf("kctl [%s (%s) %s]> ", context, tier, namespace)
+	}
+	tierColor := ColorGreen
+	switch tier {
+	case "production":
+		tierColor = ColorRed + ColorBold
+	case "staging":
+		tierColor = ColorYellow
+	}
+	return fmt.Sprintf("%skctl%s [%s%s%s %s(%s)%s %s%s%s]> ",
+		ColorBold, ColorReset, ColorCyan, context, ColorReset,
+		tierColor, tier, ColorReset, ColorMagenta, namespace, ColorReset)
+}
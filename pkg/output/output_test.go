@@ -0,0 +1,172 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		response, phrase string
+		want             bool
+	}{
+		{"y\n", "", true},
+		{" YES \n", "", true},
+		{"n\n", "", false},
+		{"\n", "", false},
+		{"yes-production\n", "yes-production", true},
+		{"y\n", "yes-production", false},
+		{"YES-PRODUCTION\n", "yes-production", false},
+	}
+	for _, tt := range tests {
+		if got := accepts(tt.response, tt.phrase); got != tt.want {
+			t.Errorf("accepts(%q, %q) = %v, want %v", tt.response, tt.phrase, got, tt.want)
+		}
+	}
+}
+
+func TestWantsEdit(t *testing.T) {
+	for response, want := range map[string]bool{
+		"e\n":    true,
+		" Edit ": true,
+		"y\n":    false,
+		"\n":     false,
+	} {
+		if got := wantsEdit(response); got != want {
+			t.Errorf("wantsEdit(%q) = %v, want %v", response, got, want)
+		}
+	}
+}
+
+func TestPromptConfirmation_ReadsTTY(t *testing.T) {
+	// Stdin carries a piped manifest
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	tty := filepath.Join(t.TempDir(), "tty")
+	previousStdin, previousTTY := os.Stdin, ttyPath
+	os.Stdin, ttyPath = r, tty
+	t.Cleanup(func() { os.Stdin, ttyPath = previousStdin, previousTTY })
+
+	os.WriteFile(tty, []byte("y\n"), 0600)
+	if !PromptConfirmation("Proceed?", "") {
+		t.Error("PromptConfirmation didn't read the answer from the terminal")
+	}
+	os.WriteFile(tty, []byte("n\n"), 0600)
+	if PromptConfirmation("Proceed?", "") {
+		t.Error("PromptConfirmation accepted n")
+	}
+
+	os.Remove(tty)
+	if PromptConfirmation("Proceed?", "") {
+		t.Error("PromptConfirmation without a terminal should fail closed")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{1234567 * time.Microsecond, "1.2s"},
+		{4240 * time.Millisecond, "4.2s"},
+		{9*time.Minute + 12400*time.Millisecond, "9m12s"},
+		{time.Hour + 3*time.Minute + 20*time.Second, "1h3m"},
+		{26 * time.Hour, "26h0m"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	tests := []struct {
+		exitCode int
+		elapsed  time.Duration
+		want     string
+	}{
+		{0, 9*time.Minute + 12*time.Second, "✅ delete on app-prod finished in 9m12s (exit 0)\n"},
+		{1, 4200 * time.Millisecond, "❌ delete on app-prod failed after 4.2s (exit 1)\n"},
+	}
+	previousChrome, previousStderr := chrome, os.Stderr
+	t.Cleanup(func() { chrome, os.Stderr = previousChrome, previousStderr })
+	for _, tt := range tests {
+		f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		chrome, os.Stderr = f, f
+		PrintSummary("delete", "app-prod", tt.exitCode, tt.elapsed)
+		f.Close()
+		if got, _ := os.ReadFile(f.Name()); string(got) != tt.want {
+			t.Errorf("PrintSummary(exit %d) = %q, want %q", tt.exitCode, got, tt.want)
+		}
+	}
+}
+
+func TestSetChromeStream(t *testing.T) {
+	previousChrome, previousStdout, previousStderr := chrome, os.Stdout, os.Stderr
+	t.Cleanup(func() { chrome, os.Stdout, os.Stderr = previousChrome, previousStdout, previousStderr })
+	// /dev/null is a character device, so it stands in for a terminal
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tty.Close()
+	piped, err := os.Create(filepath.Join(t.TempDir(), "piped"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer piped.Close()
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	os.Stderr = stderr
+
+	tests := []struct {
+		mode   string
+		stdout *os.File
+		want   *os.File
+		ok     bool
+	}{
+		{ChromeStdout, piped, piped, true},
+		{ChromeStderr, tty, stderr, true},
+		{ChromeAuto, tty, tty, true},
+		{ChromeAuto, piped, stderr, true},
+		{"", piped, stderr, true},
+		{"sideways", tty, tty, false},
+		{"sideways", piped, stderr, false},
+	}
+	for _, tt := range tests {
+		os.Stdout = tt.stdout
+		if ok := SetChromeStream(tt.mode); ok != tt.ok || chrome != tt.want {
+			t.Errorf("SetChromeStream(%q) with stdout %s = %v, chrome %s; want %v, %s",
+				tt.mode, tt.stdout.Name(), ok, chrome.Name(), tt.ok, tt.want.Name())
+		}
+	}
+}
+
+func TestPrintSublog_NotATerminal(t *testing.T) {
+	previousChrome, previousDisabled := chrome, colorsDisabled
+	t.Cleanup(func() { chrome, colorsDisabled = previousChrome, previousDisabled })
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Colors are on, but the chrome stream isn't a terminal
+	chrome, colorsDisabled = f, false
+	PrintSublog("Namespace: prod")
+	if got, _ := os.ReadFile(f.Name()); string(got) != "│ Namespace: prod\n" {
+		t.Errorf("PrintSublog wrote %q, want it undecorated", got)
+	}
+}
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultPager is used when $PAGER is unset
+const DefaultPager = "less -R"
+
+// Pager holds back command output until it exceeds the terminal height,
+// then hands it to a pager. Shorter output is written through unchanged
+// when the Pager is closed.
+type Pager struct {
+	// OnStart is called just before the pager takes over the terminal
+	OnStart func()
+
+	command string
+	w       io.Writer
+	limit   int
+
+	buf   bytes.Buffer
+	lines int
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	quit  bool // the user closed the pager early
+}
+
+// NewPager returns a Pager for w running command ("" for $PAGER or
+// DefaultPager). It returns nil when stdout isn't a terminal or its height
+// is unknown.
+func NewPager(command string, w io.Writer) *Pager {
+	if !isCharDevice(os.Stdout) {
+		return nil
+	}
+	rows := terminalRows()
+	if rows <= 0 {
+		return nil
+	}
+	if command == "" {
+		command = os.Getenv("PAGER")
+	}
+	if command == "" {
+		command = DefaultPager
+	}
+	return newPager(command, w, rows-1)
+}
+
+func newPager(command string, w io.Writer, limit int) *Pager {
+	return &Pager{command: command, w: w, limit: limit}
+}
+
+func (p *Pager) Write(b []byte) (int, error) {
+	switch {
+	case p.quit:
+		return len(b), nil
+	case p.in != nil:
+		if _, err := p.in.Write(b); err != nil {
+			// Quitting the pager early is not an error
+			if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+				p.quit = true
+				return len(b), nil
+			}
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	p.buf.Write(b)
+	p.lines += bytes.Count(b, []byte("\n"))
+	if p.lines <= p.limit {
+		return len(b), nil
+	}
+	if err := p.start(); err != nil {
+		// No pager; show the output as it comes
+		p.in = nopWriteCloser{p.w}
+	}
+	_, err := p.in.Write(p.buf.Bytes())
+	p.buf.Reset()
+	return len(b), err
+}
+
+func (p *Pager) start() error {
+	cmd := exec.Command("sh", "-c", p.command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=R")
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if p.OnStart != nil {
+		p.OnStart()
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.in = cmd, in
+	return nil
+}
+
+// Close writes held-back output, or waits for the pager to exit
+func (p *Pager) Close() error {
+	if p.cmd == nil {
+		if p.in == nil {
+			_, err := p.w.Write(p.buf.Bytes())
+			return err
+		}
+		return nil
+	}
+	p.in.Close()
+	return p.cmd.Wait()
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// terminalRows returns the height of the terminal on stdout, or 0
+func terminalRows() int {
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		return rows
+	}
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdout
+	out, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0
+	}
+	rows, _ := strconv.Atoi(fields[0])
+	return rows
+}
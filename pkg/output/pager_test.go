@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPager_ShortOutputPassesThrough(t *testing.T) {
+	var buf bytes.Buffer
+	p := newPager("exit 1", &buf, 3)
+	p.Write([]byte("a\nb\n"))
+	p.Write([]byte("c\n"))
+	if buf.Len() != 0 {
+		t.Errorf("output written before Close: %q", buf.String())
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if buf.String() != "a\nb\nc\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestPager_LongOutputIsPaged(t *testing.T) {
+	var buf bytes.Buffer
+	paged := filepath.Join(t.TempDir(), "paged")
+	p := newPager("cat > "+paged, &buf, 2)
+	started := false
+	p.OnStart = func() { started = true }
+
+	p.Write([]byte("a\nb\n"))
+	p.Write([]byte("c\nd\n"))
+	p.Write([]byte("e\n"))
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(paged)
+	if !started || string(data) != "a\nb\nc\nd\ne\n" || buf.Len() != 0 {
+		t.Errorf("started=%v paged=%q direct=%q", started, data, buf.String())
+	}
+}
+
+func TestPager_QuitEarly(t *testing.T) {
+	var buf bytes.Buffer
+	p := newPager("head -c 1 >/dev/null", &buf, 1)
+	chunk := []byte(strings.Repeat("line\n", 1000))
+	for i := 0; i < 100; i++ {
+		if _, err := p.Write(chunk); err != nil {
+			t.Fatalf("Write after the pager quit failed: %v", err)
+		}
+	}
+	p.Close()
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner shows an elapsed-time spinner on stderr whenever the wrapped
+// command has been silent for a while. Output written through its writers
+// erases the spinner first, so it never mixes with command output.
+type Spinner struct {
+	delay time.Duration
+	start time.Time
+	out   io.Writer
+
+	mu      sync.Mutex
+	last    time.Time // last output, or start
+	drawn   bool
+	stopped bool
+	done    chan struct{}
+}
+
+// StartSpinner starts a spinner that appears after delay of silence. It
+// returns nil when stdout or stderr isn't a terminal; a nil Spinner passes
+// writes through and Stop is a no-op.
+func StartSpinner(delay time.Duration) *Spinner {
+	if !isCharDevice(os.Stdout) || !isCharDevice(os.Stderr) {
+		return nil
+	}
+	return startSpinner(delay, os.Stderr)
+}
+
+// startSpinner starts a spinner drawn on out
+func startSpinner(delay time.Duration, out io.Writer) *Spinner {
+	now := time.Now()
+	s := &Spinner{delay: delay, start: now, out: out, last: now, done: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+func (s *Spinner) run() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for frame := 0; ; frame++ {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			if !s.stopped && now.Sub(s.last) >= s.delay {
+				fmt.Fprintf(s.out, "\r\033[K%s%s %s%s", ColorSubLog,
+					spinnerFrames[frame%len(spinnerFrames)], FormatDuration(now.Sub(s.start).Truncate(time.Second)), ColorReset)
+				s.drawn = true
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// clear erases the spinner line; callers hold mu
+func (s *Spinner) clear() {
+	if s.drawn {
+		fmt.Fprint(s.out, "\r\033[K")
+		s.drawn = false
+	}
+}
+
+// Wrap returns a writer that erases the spinner before writing to w
+func (s *Spinner) Wrap(w io.Writer) io.Writer {
+	if s == nil {
+		return w
+	}
+	return spinnerWriter{s: s, w: w}
+}
+
+// Stop erases the spinner for good
+func (s *Spinner) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+		s.clear()
+	}
+}
+
+type spinnerWriter struct {
+	s *Spinner
+	w io.Writer
+}
+
+func (sw spinnerWriter) Write(p []byte) (int, error) {
+	sw.s.mu.Lock()
+	defer sw.s.mu.Unlock()
+	sw.s.clear()
+	sw.s.last = time.Now()
+	return sw.w.Write(p)
+}
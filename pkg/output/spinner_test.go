@@ -0,0 +1,52 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpinner(t *testing.T) {
+	var drawn, out bytes.Buffer
+	s := startSpinner(0, &drawn)
+	time.Sleep(250 * time.Millisecond)
+
+	s.mu.Lock()
+	frames := drawn.String()
+	// Keep it from drawing again, so the erase is the last thing written
+	s.delay = time.Hour
+	s.mu.Unlock()
+	if !strings.Contains(frames, spinnerFrames[0]) || !strings.Contains(frames, "0s") {
+		t.Fatalf("spinner drew %q, want a frame with the elapsed time", frames)
+	}
+
+	if _, err := s.Wrap(&out).Write([]byte("node/a cordoned\n")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "node/a cordoned\n" || !strings.HasSuffix(drawn.String(), "\r\033[K") {
+		t.Errorf("write = %q after %q, want the spinner erased first", out.String(), drawn.String())
+	}
+
+	s.Stop()
+	s.Stop()
+	after := drawn.Len()
+	time.Sleep(250 * time.Millisecond)
+	if drawn.Len() != after {
+		t.Errorf("spinner drew %q after Stop", drawn.String()[after:])
+	}
+}
+
+func TestStartSpinner_NotTerminal(t *testing.T) {
+	// Test output isn't a terminal
+	s := StartSpinner(0)
+	if s != nil {
+		s.Stop()
+		t.Fatal("StartSpinner started without a terminal")
+	}
+	var out bytes.Buffer
+	if w := s.Wrap(&out); w != &out {
+		t.Errorf("nil Spinner wrapped the writer")
+	}
+	s.Stop()
+}
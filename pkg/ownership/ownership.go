@@ -0,0 +1,96 @@
+// Package ownership finds the objects a command changes that another team
+// owns, going by the labels and annotations that name an object's team
+package ownership
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Object is an object owned by another team
+type Object struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Team      string
+}
+
+// String names the object, e.g. "Deployment shop/web"
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return o.Kind + " " + o.Name
+	}
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// object is the part of an object Foreign reads
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Items []object `json:"items"`
+}
+
+// Foreign finds the objects the command in args changes on context, with a
+// server-side dry run, and returns those whose first label or annotation
+// among keys names a team other than team. A delete's objects are read
+// with 'kubectl get' instead, as delete prints only names. Objects naming
+// no team are nobody's and aren't returned; teams are compared ignoring
+// case.
+func Foreign(context string, args []string, team string, keys []string) ([]Object, error) {
+	lookup := preview.DryRunArgs(context, args, "json")
+	if positional := rbac.Positional(args); len(positional) > 0 && positional[0] == "delete" {
+		lookup = preview.GetArgs(context, args, "json")
+	}
+	stdout, stderr, exitCode := kubectl.Run(lookup)
+	if exitCode != 0 {
+		return nil, fmt.Errorf("looking up the objects failed: %s", strings.TrimSpace(stderr))
+	}
+
+	var foreign []Object
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	for {
+		var o object
+		err := decoder.Decode(&o)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading the objects: %w", err)
+		}
+		objects := []object{o}
+		if strings.HasSuffix(o.Kind, "List") {
+			objects = o.Items
+		}
+		for _, o := range objects {
+			if owner := o.owner(keys); owner != "" && !strings.EqualFold(owner, team) {
+				foreign = append(foreign, Object{Kind: o.Kind, Name: o.Metadata.Name, Namespace: o.Metadata.Namespace, Team: owner})
+			}
+		}
+	}
+	return foreign, nil
+}
+
+// owner returns the team the first of keys o has as a label or annotation
+// names
+func (o object) owner(keys []string) string {
+	for _, key := range keys {
+		if team := o.Metadata.Labels[key]; team != "" {
+			return team
+		}
+		if team := o.Metadata.Annotations[key]; team != "" {
+			return team
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package ownership
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestForeign(t *testing.T) {
+	var ran string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		ran = strings.Join(args, " ")
+		return `{"kind":"Deployment","metadata":{"name":"web","namespace":"shop","labels":{"app.kubernetes.io/managed-by":"Payments"}}}
+{"kind":"List","items":[
+  {"kind":"Service","metadata":{"name":"web","namespace":"shop","annotations":{"owner":"checkout"}}},
+  {"kind":"ConfigMap","metadata":{"name":"settings","namespace":"shop"}},
+  {"kind":"Secret","metadata":{"name":"db","namespace":"shop","labels":{"owner":"search"},"annotations":{"app.kubernetes.io/managed-by":"payments"}}}
+]}`, "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	foreign, err := Foreign("app-prod", []string{"apply", "-f", "app.yaml"}, "payments", []string{"app.kubernetes.io/managed-by", "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Object{{Kind: "Service", Name: "web", Namespace: "shop", Team: "checkout"}}
+	if !reflect.DeepEqual(foreign, want) {
+		t.Errorf("Foreign = %+v, want %+v", foreign, want)
+	}
+	if !strings.Contains(ran, "--dry-run=server -o json") {
+		t.Errorf("ran kubectl %s, want a server dry run", ran)
+	}
+
+	kubectl.Run = func([]string) (string, string, int) { return "", "forbidden", 1 }
+	if _, err := Foreign("app-prod", []string{"delete", "pod", "web"}, "payments", nil); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("Foreign after a failed lookup = %v, want the error", err)
+	}
+}
+
+func TestForeign_Delete(t *testing.T) {
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		command := strings.Join(args, " ")
+		if strings.Contains(command, "--dry-run") {
+			// kubectl delete prints only names, whatever -o asks for
+			return "pod \"web\" deleted (server dry run)\n", "", 0
+		}
+		if command != "--context app-prod get pod web -n shop --ignore-not-found -o json" {
+			t.Errorf("ran kubectl %s", command)
+		}
+		return `{"kind":"Pod","metadata":{"name":"web","namespace":"shop","labels":{"owner":"checkout"}}}`, "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	foreign, err := Foreign("app-prod", []string{"delete", "pod", "web", "-n", "shop"}, "payments", []string{"owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Object{{Kind: "Pod", Name: "web", Namespace: "shop", Team: "checkout"}}; !reflect.DeepEqual(foreign, want) {
+		t.Errorf("Foreign = %+v, want %+v", foreign, want)
+	}
+}
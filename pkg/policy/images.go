@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/image"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// ImageProblems returns what the images in the local -f manifests of an
+// apply or create break under rules: registries allowed_registries doesn't
+// list and, with require_digests, images not pinned by digest. err is set
+// when some images can't be checked: those of remote manifests,
+// kustomizations and manifests that can't be read.
+func ImageProblems(args []string, action string, rules config.ResolvedRules) (problems []string, err error) {
+	if !rules.ChecksImages() || action != rbac.ActionApply && action != rbac.ActionCreate {
+		return nil, nil
+	}
+	if k := rbac.Kustomizations(args); len(k) > 0 {
+		return nil, fmt.Errorf("the images of kustomization %s can't be checked", strings.Join(k, ", "))
+	}
+	files := rbac.Filenames(args)
+	if unread := manifest.Unread(files); len(unread) > 0 {
+		return nil, fmt.Errorf("the images of %s can't be checked", strings.Join(unread, ", "))
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	objects, err := manifest.Load(files, rbac.HasFlag(args, "-R", "--recursive"))
+	if err != nil {
+		return nil, fmt.Errorf("the images can't be checked: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, o := range objects {
+		for _, name := range o.Images {
+			ref := image.Parse(name)
+			var problem string
+			switch {
+			case !rules.AllowsRegistry(ref.Name()):
+				problem = fmt.Sprintf("%s runs %s from %s, which allowed_registries doesn't list",
+					describeObject(o), name, ref.Registry)
+			case rules.RequireDigests && !ref.Pinned():
+				problem = fmt.Sprintf("%s runs %s, which isn't pinned by digest (require_digests)",
+					describeObject(o), name)
+			default:
+				continue
+			}
+			if !seen[problem] {
+				seen[problem] = true
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems, nil
+}
+
+// untrustedImages returns the reason to block an apply or create whose
+// images break the rules when untrusted_images is block. Images that
+// can't be checked are blocked too.
+func untrustedImages(args []string, action string, rules config.ResolvedRules) (string, bool) {
+	if rules.UntrustedImages != config.UntrustedImagesBlock {
+		return "", false
+	}
+	problems, err := ImageProblems(args, action, rules)
+	if err != nil {
+		return fmt.Sprintf("Tier '%s' blocks untrusted images, and %v", rules.Tier, err), true
+	}
+	if len(problems) == 0 {
+		return "", false
+	}
+	reason := fmt.Sprintf("Tier '%s' blocks untrusted images: %s", rules.Tier, problems[0])
+	if more := len(problems) - 1; more > 0 {
+		reason += fmt.Sprintf(" (and %d more)", more)
+	}
+	return reason, true
+}
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// maxReasonObjects is how many objects a reason names before "and N more"
+const maxReasonObjects = 3
+
+// unknownName stands in for the name of an object that has none yet
+// (generateName) or that can't be read
+const unknownName = "<unknown>"
+
+// decideObjects decides a change made with -f manifests for each object in
+// them and returns the strictest decision, or file's when no object draws
+// a stricter verdict. Objects without a name are decided by kind and
+// namespace. Manifests whose objects can't be read here (missing or
+// malformed files, remote ones and kustomizations) are decided under the
+// strictest rules their objects could fall under.
+func decideObjects(cfg *config.Config, context string, args []string, file Decision) Decision {
+	files := rbac.Filenames(args)
+	kustomizations := rbac.Kustomizations(args)
+	if file.Verdict == Block || len(files)+len(kustomizations) == 0 || !rbac.IsDestructive(file.Action) {
+		return file
+	}
+	unknown := append(manifest.Unread(files), kustomizations...)
+	objects, err := manifest.Load(files, rbac.HasFlag(args, "-R", "--recursive"))
+	if err != nil {
+		unknown = append(unknown, err.Error())
+	}
+
+	strictest := file
+	var drew []string
+	for _, o := range objects {
+		if o.Name == "" {
+			o.Name = unknownName
+		}
+		d := evaluate(cfg, context, objectArgs(args, o), file.Action, file.Plugin)
+		switch {
+		case rank(d.Verdict) > rank(strictest.Verdict):
+			strictest, drew = d, []string{describeObject(o)}
+		case rank(d.Verdict) == rank(strictest.Verdict) && len(drew) > 0:
+			drew = append(drew, describeObject(o))
+		}
+	}
+	if len(unknown) > 0 {
+		if d := decideUnknown(cfg, context, args, file); rank(d.Verdict) > rank(strictest.Verdict) {
+			d.Args, d.AddedFlags = rbac.AddFlags(args, d.Rules.AddFlags[file.Action])
+			d.Reason += fmt.Sprintf(" (objects can't be read from %s)", strings.Join(unknown, ", "))
+			return d
+		}
+	}
+	if len(drew) == 0 {
+		return file
+	}
+
+	strictest.Args, strictest.AddedFlags = rbac.AddFlags(args, strictest.Rules.AddFlags[file.Action])
+	strictest.Objects = drew
+	shown := drew
+	if len(shown) > maxReasonObjects {
+		shown = shown[:maxReasonObjects]
+	}
+	strictest.Reason += fmt.Sprintf(" (manifest: %s", strings.Join(shown, ", "))
+	if more := len(drew) - len(shown); more > 0 {
+		strictest.Reason += fmt.Sprintf(" and %d more", more)
+	}
+	strictest.Reason += ")"
+	return strictest
+}
+
+// objectArgs turns a command on manifest files into the same command on
+// one object in them, e.g. "apply -f app.yaml" into "apply
+// deployment/web -n shop". An object without a namespace keeps the
+// command's -n.
+func objectArgs(args []string, o manifest.Object) []string {
+	positional := rbac.Positional(args)
+	result := []string{positional[0], strings.ToLower(o.Kind) + "/" + o.Name}
+	verbSeen := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, _, _ := strings.Cut(arg, "=")
+		switch {
+		case arg == "--":
+			i = len(args)
+			continue
+		case name == "-f" || name == "--filename" || o.Namespace != "" && (name == "-n" || name == "--namespace"):
+			if !strings.Contains(arg, "=") {
+				i++
+			}
+			continue
+		case arg == "-R" || arg == "--recursive":
+			continue
+		case !verbSeen && arg == positional[0]:
+			verbSeen = true
+			continue
+		}
+		result = append(result, arg)
+		if rbac.FlagTakesValue(arg) && i+1 < len(args) {
+			i++
+			result = append(result, args[i])
+		}
+	}
+	if o.Namespace != "" {
+		result = append(result, "-n", o.Namespace)
+	}
+	return result
+}
+
+// decideUnknown decides args on objects that can't be read under the
+// strictest rules they could fall under: those of the cluster and of every
+// tier namespaces are assigned to, for objects of any kind the severity
+// table raises and, for deletes, a namespace
+func decideUnknown(cfg *config.Config, context string, args []string, file Decision) Decision {
+	cluster := cfg.GetClusterRules(context)
+	ruleSets := []config.ResolvedRules{cluster}
+	for _, tier := range cfg.NamespaceTiers() {
+		if rules, ok := cfg.GetTierRules(tier); ok && tier != cluster.Tier {
+			rules.Maintenance = cluster.Maintenance
+			rules.Freeze = cluster.Freeze
+			ruleSets = append(ruleSets, rules)
+		}
+	}
+	candidates := [][]string{args}
+	kinds := make([]string, 0, len(cfg.Severity.Kinds))
+	for kind, bump := range cfg.Severity.Kinds {
+		if bump > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		candidates = append(candidates, objectArgs(args, manifest.Object{Kind: kind, Name: unknownName}))
+	}
+	if file.Action == rbac.ActionDelete {
+		candidates = append(candidates, objectArgs(args, manifest.Object{Kind: "Namespace", Name: unknownName}))
+	}
+
+	strictest := file
+	for _, rules := range ruleSets {
+		for _, candidate := range candidates {
+			if d := decide(cfg, context, candidate, file.Action, file.Plugin, rules); rank(d.Verdict) > rank(strictest.Verdict) {
+				strictest = d
+			}
+		}
+	}
+	return strictest
+}
+
+// describeObject names a manifest object, e.g. "Deployment shop/web"
+func describeObject(o manifest.Object) string {
+	if o.Namespace == "" {
+		return o.Kind + " " + o.Name
+	}
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
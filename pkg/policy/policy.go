@@ -0,0 +1,457 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lock"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Verdict is the outcome of evaluating a kubectl command against the rules
+type Verdict string
+
+// Possible verdicts, ordered from least to most restrictive
+const (
+	Allow   Verdict = "allow"
+	Confirm Verdict = "confirm"
+	Block   Verdict = "block"
+)
+
+// Decision describes how a kubectl invocation should be handled
+type Decision struct {
+	Verdict Verdict  `json:"verdict"`
+	Action  string   `json:"action"`
+	Context string   `json:"context"`
+	Tier    string   `json:"tier"`
+	Reason  string   `json:"reason,omitempty"`
+	Args    []string `json:"args"`
+	// AddedFlags are the rules' add_flags that were added to Args
+	AddedFlags []string `json:"added_flags,omitempty"`
+	// Rule is the action entry that decided the verdict: the action itself,
+	// an alias, "*", "default", or "lock"
+	Rule string `json:"rule,omitempty"`
+	// Message and DocsURL are the configured guidance for a block or
+	// confirmation
+	Message string `json:"message,omitempty"`
+	DocsURL string `json:"docs_url,omitempty"`
+	// Suggestions are safer alternatives to a blocked command
+	Suggestions []string `json:"suggestions,omitempty"`
+	// Locked is set when 'kctl lock' tightened the verdict; its confirmation
+	// can't be skipped with --yes
+	Locked bool `json:"locked,omitempty"`
+	// Maintenance names the open maintenance window that downgraded a block
+	// to a confirmation
+	Maintenance string `json:"maintenance,omitempty"`
+	// Ticket is the change ticket given with --kctl-ticket
+	Ticket string `json:"ticket,omitempty"`
+	// Identity is the user the context authenticates to the cluster as
+	Identity string `json:"identity,omitempty"`
+	// Plugin is the executable of the kubectl plugin the command runs; the
+	// action is then the plugin's name
+	Plugin string `json:"plugin,omitempty"`
+	// Severity is how much damage the command can do, from none to critical
+	Severity string `json:"severity,omitempty"`
+	// Offline is set when the API server couldn't be reached and the rules
+	// let the command through anyway; checks that need the server are skipped
+	Offline bool `json:"offline,omitempty"`
+	// BreakGlass is set when the command needs break-glass approval: a
+	// confirmation --yes can't skip and the tier's approval_command
+	BreakGlass bool `json:"break_glass,omitempty"`
+	// Objects are the objects in the command's manifests that drew a
+	// stricter verdict than the command itself, e.g. "Namespace payments"
+	Objects []string `json:"objects,omitempty"`
+
+	Rules config.ResolvedRules `json:"-"`
+}
+
+// findPlugin is replaced in tests
+var findPlugin = kubectl.FindPlugin
+
+// Evaluate resolves the rules for the given context and decides whether
+// the kubectl args are allowed, need confirmation, or are blocked.
+// context must be the context the command will actually run against.
+// The decision's Args include any flags the rules add; run those.
+//
+// When the command's namespace is assigned a tier in cfg.Namespaces, it is
+// decided under that tier's rules too and the stricter verdict wins; on a
+// tie the cluster's decision stands.
+//
+// A change made with -f manifests is also decided for each object in them,
+// as the same command on that object; the strictest verdict wins and
+// Objects lists the objects that drew it.
+func Evaluate(cfg *config.Config, context string, args []string) Decision {
+	action := rbac.DetectAction(args)
+	plugin := ""
+	if name, path, ok := findPlugin(args); ok {
+		action, plugin = name, path
+	}
+	decision := evaluate(cfg, context, args, action, plugin)
+	return decideObjects(cfg, context, args, decision)
+}
+
+// evaluate decides args under the cluster's rules and those of the tiers
+// assigned to the namespaces they touch
+func evaluate(cfg *config.Config, context string, args []string, action, plugin string) Decision {
+	rules := cfg.GetClusterRules(context)
+	decision := decide(cfg, context, args, action, plugin, rules)
+
+	for _, ns := range namespaceRules(cfg, context, args, rules) {
+		d := decide(cfg, context, args, action, plugin, ns.rules)
+		if rank(d.Verdict) > rank(decision.Verdict) {
+			d.Reason += fmt.Sprintf(" (%s)", ns.label)
+			decision = d
+		}
+	}
+	return decision
+}
+
+// namespaceTier is the rules of a tier assigned to a namespace the command
+// touches
+type namespaceTier struct {
+	label string // e.g. "namespace 'payments'"
+	rules config.ResolvedRules
+}
+
+// namespaceRules returns the rules of the tiers assigned to the namespaces
+// args operate in: the -n namespace or the context's default for
+// namespaced resources, every assigned tier with --all-namespaces, and the
+// namespaces a namespace delete removes
+func namespaceRules(cfg *config.Config, context string, args []string, cluster config.ResolvedRules) []namespaceTier {
+	if len(cfg.Namespaces) == 0 {
+		return nil
+	}
+
+	var found []namespaceTier
+	add := func(namespace string) {
+		if rules, ok := cfg.GetNamespaceRules(context, namespace); ok && rules.Tier != cluster.Tier {
+			found = append(found, namespaceTier{fmt.Sprintf("namespace '%s'", namespace), rules})
+		}
+	}
+	for _, namespace := range rbac.DeletedNamespaces(args) {
+		add(namespace)
+	}
+	if isClusterScoped(cfg, context, args) {
+		return found
+	}
+
+	if rbac.HasFlag(args, "-A", "--all-namespaces") {
+		for _, tier := range cfg.NamespaceTiers() {
+			if rules, ok := cfg.GetTierRules(tier); ok && tier != cluster.Tier {
+				rules.Maintenance = cluster.Maintenance
+				rules.Freeze = cluster.Freeze
+				found = append(found, namespaceTier{fmt.Sprintf("--all-namespaces includes namespaces of tier '%s'", tier), rules})
+			}
+		}
+		return found
+	}
+
+	add(commandNamespace(cfg, context, args))
+	return found
+}
+
+// commandNamespace returns the namespace args operate in: the -n namespace,
+// or the context's default
+func commandNamespace(cfg *config.Config, context string, args []string) string {
+	if namespace, ok := rbac.Namespace(args); ok {
+		return namespace
+	}
+	if cfg.NamespaceLookup != nil {
+		if ns, err := cfg.NamespaceLookup(context); err == nil && ns != "" {
+			return ns
+		}
+	}
+	return "default"
+}
+
+// decide evaluates args under one set of rules
+func decide(cfg *config.Config, context string, args []string, action, plugin string, rules config.ResolvedRules) Decision {
+	args, added := rbac.AddFlags(args, rules.AddFlags[action])
+
+	decision := Decision{
+		Verdict:    Allow,
+		Action:     action,
+		Context:    context,
+		Tier:       rules.Tier,
+		Args:       args,
+		AddedFlags: added,
+		Plugin:     plugin,
+		Severity:   severity(cfg, context, action, args),
+		Rules:      rules,
+	}
+
+	if rules.Tier == "default" && cfg.Defaults.BlockUnclassifiedProd && rbac.IsDestructive(action) && config.LooksProduction(context) {
+		decision.Verdict = Block
+		decision.Rule = "block_unclassified_prod"
+		decision.Reason = fmt.Sprintf("'%s' looks like production but no cluster entry or tier covers it; classify it with 'kctl init --merge'",
+			context)
+		return decision
+	}
+
+	if rules.Freeze != "" && rbac.IsDestructive(action) {
+		decision.Verdict = Block
+		decision.Rule = "freeze"
+		decision.Reason = fmt.Sprintf("Changes to '%s' are frozen while freeze window '%s' is open", context, rules.Freeze)
+		return decision
+	}
+
+	if rbac.DeletesEverywhere(args) {
+		switch mode := cfg.Defaults.DeleteAllNamespacesMode(); {
+		case mode == config.DeleteAllBlock:
+			decision.Verdict = Block
+			decision.Rule = "delete_all_namespaces"
+			decision.Reason = "Deleting everything of a kind in every namespace is blocked on every tier (delete_all_namespaces: block)"
+			return decision
+		case mode == config.DeleteAllBreakGlass && rules.ApprovalCommand == "":
+			decision.Verdict = Block
+			decision.Rule = "delete_all_namespaces"
+			decision.Reason = fmt.Sprintf("Deleting everything of a kind in every namespace needs break-glass approval, and tier '%s' has no approval_command",
+				rules.Tier)
+			return decision
+		case mode == config.DeleteAllBreakGlass:
+			decision.BreakGlass = true
+		}
+	}
+
+	if rules.RequireExplicitContext && rbac.IsDestructive(action) {
+		if _, explicit := kubectl.GetContextFromArgs(args); !explicit {
+			decision.Verdict = Block
+			decision.Rule = "require_explicit_context"
+			decision.Reason = fmt.Sprintf("Tier '%s' requires an explicit context for '%s'; re-run with --context %s",
+				rules.Tier, action, context)
+			return decision
+		}
+	}
+
+	if rules.BlockUnqualifiedDeletes && rbac.IsUnqualifiedDelete(args) {
+		decision.Verdict = Block
+		decision.Rule = "block_unqualified_deletes"
+		decision.Reason = fmt.Sprintf("Tier '%s' blocks deletes without a name, selector or -f; --all needs an explicit -n",
+			rules.Tier)
+		return decision
+	}
+	if host, ok := disallowedHost(args, rules); ok {
+		decision.Verdict = Block
+		decision.Rule = "manifest_hosts"
+		decision.Reason = fmt.Sprintf("Tier '%s' only reads remote manifests from %s, not %s",
+			rules.Tier, strings.Join(rules.ManifestHosts, ", "), host)
+		return decision
+	}
+	if reason, ok := untrustedImages(args, action, rules); ok {
+		decision.Verdict = Block
+		decision.Rule = "untrusted_images"
+		decision.Reason = reason
+		return decision
+	}
+	if rules.RequireExplicitNamespace && rbac.IsDestructive(action) &&
+		!rbac.HasExplicitNamespace(args) && !isClusterScoped(cfg, context, args) {
+		decision.Verdict = Block
+		decision.Rule = "require_explicit_namespace"
+		decision.Reason = fmt.Sprintf("Tier '%s' requires an explicit namespace for '%s'; re-run with -n <namespace>",
+			rules.Tier, action)
+		return decision
+	}
+
+	if namespace, ok := disallowedNamespace(cfg, context, args, rules); ok && rbac.IsDestructive(action) {
+		decision.Verdict = Block
+		decision.Rule = "allowed_namespaces"
+		decision.Reason = fmt.Sprintf("Tier '%s' only allows changes in namespaces %s, not %s",
+			rules.Tier, strings.Join(rules.AllowedNamespaces, ", "), namespace)
+		return decision
+	}
+
+	outcome, rule := rbac.Resolve(action, rules)
+	switch {
+	case config.MatchesSeverity(rules.BlockSeverity, decision.Severity) && outcome != rbac.OutcomeBlock:
+		outcome, rule = rbac.OutcomeBlock, "block_severity"
+	case config.MatchesSeverity(rules.ConfirmSeverity, decision.Severity) && outcome == rbac.OutcomeAllow:
+		outcome, rule = rbac.OutcomeConfirm, "confirm_severity"
+	}
+	decision.Rule = rule
+	switch outcome {
+	case rbac.OutcomeBlock:
+		decision.Verdict = Block
+		decision.Reason = explain(action, decision.Severity, rules, rule, "is configured as blocked", "blocked_actions")
+		if rules.Maintenance != "" {
+			decision.Verdict = Confirm
+			decision.Maintenance = rules.Maintenance
+			decision.Reason += fmt.Sprintf("; downgraded to confirmation during maintenance window '%s'", rules.Maintenance)
+		} else {
+			kind, name, _ := resourceRef(cfg, context, args)
+			decision.Suggestions = cfg.SuggestionsFor(action, kind, name)
+		}
+	case rbac.OutcomeConfirm:
+		decision.Verdict = Confirm
+		decision.Reason = explain(action, decision.Severity, rules, rule, "requires confirmation", "require_confirmation")
+	}
+	if decision.BreakGlass && decision.Verdict == Allow {
+		decision.Verdict = Confirm
+		decision.Rule = "delete_all_namespaces"
+		decision.Reason = "Deleting everything of a kind in every namespace needs break-glass approval"
+	}
+	if decision.Verdict != Allow {
+		msg := rules.MessageFor(action, rule)
+		decision.Message, decision.DocsURL = msg.Message, msg.DocsURL
+	}
+
+	return decision
+}
+
+// resourceRef returns the singular kind and name of the first resource
+// args operate on and whether the kind is cluster-scoped. The cluster's own
+// names come first, so CRD plurals and short names resolve; kubectl's
+// built-in names are the fallback.
+func resourceRef(cfg *config.Config, context string, args []string) (kind, name string, clusterScoped bool) {
+	resource, name := rbac.ResourceName(args)
+	if resource != "" && cfg.ResourceLookup != nil {
+		if kind, namespaced, found := cfg.ResourceLookup(context, resource); found {
+			return kind, name, !namespaced
+		}
+	}
+	kind = rbac.Kind(resource)
+	return kind, name, rbac.ClusterScopedKind(kind)
+}
+
+func isClusterScoped(cfg *config.Config, context string, args []string) bool {
+	_, _, clusterScoped := resourceRef(cfg, context, args)
+	return clusterScoped
+}
+
+// severity returns how severe the command is under the config's severity
+// table. The kind is only resolved when the table raises kinds.
+func severity(cfg *config.Config, context, action string, args []string) string {
+	kind := ""
+	if len(cfg.Severity.Kinds) > 0 {
+		kind, _, _ = resourceRef(cfg, context, args)
+	}
+	return rbac.Severity(action, kind, args, cfg.Severity)
+}
+
+// rank orders verdicts from least to most restrictive
+func rank(v Verdict) int {
+	switch v {
+	case Block:
+		return 2
+	case Confirm:
+		return 1
+	}
+	return 0
+}
+
+// Stricter reports whether v is more restrictive than other
+func (v Verdict) Stricter(other Verdict) bool {
+	return rank(v) > rank(other)
+}
+
+// explain builds the reason for a block or confirmation, naming the
+// wildcard, default or severity threshold when no entry for the action
+// itself applied
+func explain(action, severity string, rules config.ResolvedRules, rule, verb, list string) string {
+	switch rule {
+	case "block_severity", "confirm_severity":
+		threshold := rules.BlockSeverity
+		if rule == "confirm_severity" {
+			threshold = rules.ConfirmSeverity
+		}
+		return fmt.Sprintf("Action '%s' is %s severity and %s for tier '%s' (%s: %s)", action, severity, verb, rules.Tier, rule, threshold)
+	case rbac.Wildcard:
+		return fmt.Sprintf("Action '%s' %s for tier '%s' (%s: \"*\")", action, verb, rules.Tier, list)
+	case "default":
+		return fmt.Sprintf("Action '%s' is not in allowed_actions for tier '%s' (default: %s)", action, rules.Tier, rules.Default)
+	}
+	return fmt.Sprintf("Action '%s' %s for tier '%s'", action, verb, rules.Tier)
+}
+
+// ApplyLock tightens d for an active 'kctl lock': mutating actions need
+// confirmation or are blocked, whatever the cluster's rules say. A nil
+// state leaves d unchanged.
+func ApplyLock(d Decision, state *lock.State) Decision {
+	if state == nil || !rbac.IsDestructive(d.Action) || d.Verdict == Block {
+		return d
+	}
+
+	verdict := Confirm
+	if state.Mode == lock.ModeBlock {
+		verdict = Block
+	}
+	if verdict == Confirm && d.Verdict == Confirm {
+		d.Locked = true
+		return d
+	}
+
+	d.Verdict = verdict
+	d.Locked = true
+	d.Rule = "lock"
+	d.Reason = LockReason(state)
+	d.Message, d.DocsURL = "", ""
+	return d
+}
+
+// LockReason explains an active lock and how to lift it
+func LockReason(state *lock.State) string {
+	reason := "kctl is locked"
+	if !state.ExpiresAt.IsZero() {
+		reason += " until " + state.ExpiresAt.Local().Format("15:04 Jan 2")
+	}
+	if state.Reason != "" {
+		reason += " (" + state.Reason + ")"
+	}
+	return reason + "; run 'kctl unlock' to lift it"
+}
+
+// disallowedHost returns the host of the first remote -f or -k target in
+// args that rules don't allow manifests to be read from
+func disallowedHost(args []string, rules config.ResolvedRules) (string, bool) {
+	if len(rules.ManifestHosts) == 0 {
+		return "", false
+	}
+	targets := append(rbac.Filenames(args), rbac.Kustomizations(args)...)
+	for _, target := range targets {
+		if host, remote := manifest.Host(target); remote && !rules.AllowsManifestHost(host) {
+			return host, true
+		}
+	}
+	return "", false
+}
+
+// disallowedNamespace returns a namespace args change that rules don't
+// allow changes in. Local -f manifests are left to the decisions on their
+// objects, which carry their own namespaces.
+func disallowedNamespace(cfg *config.Config, context string, args []string, rules config.ResolvedRules) (string, bool) {
+	if len(rules.AllowedNamespaces) == 0 {
+		return "", false
+	}
+	for _, namespace := range rbac.DeletedNamespaces(args) {
+		if !rules.AllowsNamespace(namespace) {
+			return fmt.Sprintf("'%s'", namespace), true
+		}
+	}
+	if isClusterScoped(cfg, context, args) || localManifests(args) {
+		return "", false
+	}
+	if rbac.HasFlag(args, "-A", "--all-namespaces") {
+		return "every namespace (--all-namespaces)", true
+	}
+	if namespace := commandNamespace(cfg, context, args); !rules.AllowsNamespace(namespace) {
+		return fmt.Sprintf("'%s'", namespace), true
+	}
+	return "", false
+}
+
+// localManifests reports whether args change only what their -f manifests
+// hold, all of which can be read here
+func localManifests(args []string) bool {
+	files := rbac.Filenames(args)
+	if len(files) == 0 || len(rbac.Kustomizations(args)) > 0 {
+		return false
+	}
+	for _, file := range files {
+		if file != "-" && manifest.IsRemote(file) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,802 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/lock"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+)
+
+func TestEvaluate(t *testing.T) {
+	cfg := &config.Config{
+		Clusters: map[string]config.ClusterRules{
+			"locked-prod": {
+				Tier:                "production",
+				RequireConfirmation: []string{"drain"},
+				BlockedActions:      config.Actions("delete"),
+			},
+		},
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"delete", "drain"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		context  string
+		args     []string
+		expected Verdict
+		tier     string
+	}{
+		{"safe get on prod", "app-prod", []string{"get", "pods"}, Allow, "production"},
+		{"delete on prod", "app-prod", []string{"delete", "pod", "foo"}, Confirm, "production"},
+		{"cordon covered by drain", "app-prod", []string{"cordon", "node-1"}, Confirm, "production"},
+		{"blocked on explicit cluster", "locked-prod", []string{"-n", "x", "delete", "pod", "foo"}, Block, "production"},
+		{"unknown cluster", "dev", []string{"delete", "pod", "foo"}, Allow, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, tt.context, tt.args)
+			if d.Verdict != tt.expected {
+				t.Errorf("Evaluate(%q, %v).Verdict = %q, want %q", tt.context, tt.args, d.Verdict, tt.expected)
+			}
+			if d.Tier != tt.tier {
+				t.Errorf("Evaluate(%q, %v).Tier = %q, want %q", tt.context, tt.args, d.Tier, tt.tier)
+			}
+			if d.Verdict != Allow && d.Reason == "" {
+				t.Errorf("Evaluate(%q, %v) returned %q without a reason", tt.context, tt.args, d.Verdict)
+			}
+		})
+	}
+}
+
+func TestEvaluate_RequireExplicitContext(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:               []string{"*-prod"},
+				RequireConfirmation:    []string{"delete"},
+				RequireExplicitContext: true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected Verdict
+	}{
+		{"implicit delete is blocked", []string{"delete", "pod", "foo"}, Block},
+		{"implicit scale is blocked", []string{"scale", "deploy/app", "--replicas=2"}, Block},
+		{"explicit delete needs confirmation", []string{"--context", "app-prod", "delete", "pod", "foo"}, Confirm},
+		{"explicit equals form", []string{"delete", "pod", "foo", "--context=app-prod"}, Confirm},
+		{"safe command allowed without context", []string{"get", "pods"}, Allow},
+		{"context after -- does not count", []string{"exec", "pod", "--", "echo", "--context=app-prod"}, Block},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-prod", tt.args)
+			if d.Verdict != tt.expected {
+				t.Errorf("Evaluate(%v).Verdict = %q, want %q (%s)", tt.args, d.Verdict, tt.expected, d.Reason)
+			}
+			if d.Verdict == Block && d.Rule != "require_explicit_context" {
+				t.Errorf("Evaluate(%v).Rule = %q, want require_explicit_context", tt.args, d.Rule)
+			}
+		})
+	}
+}
+
+func TestEvaluate_DefaultDeny(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"locked": {
+				Patterns:       []string{"*-locked"},
+				AllowedActions: []string{"get", "describe", "logs"},
+				Default:        config.DefaultDeny,
+			},
+		},
+	}
+
+	d := Evaluate(cfg, "app-locked", []string{"logs", "pod-1"})
+	if d.Verdict != Allow || d.Rule != "logs" {
+		t.Errorf("logs: Verdict = %q, Rule = %q; want allow by 'logs'", d.Verdict, d.Rule)
+	}
+
+	d = Evaluate(cfg, "app-locked", []string{"exec", "pod-1", "--", "sh"})
+	if d.Verdict != Block || d.Rule != "default" {
+		t.Errorf("exec: Verdict = %q, Rule = %q; want block by default", d.Verdict, d.Rule)
+	}
+	if !strings.Contains(d.Reason, "allowed_actions") {
+		t.Errorf("Reason %q should point at allowed_actions", d.Reason)
+	}
+}
+
+func TestEvaluate_Message(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:       []string{"*-prod"},
+				BlockedActions: config.Actions("delete"),
+				Messages: map[string]config.ActionMessage{
+					"delete": {Message: "Use the deploy pipeline", DocsURL: "https://wiki.example.com/deploys"},
+				},
+			},
+		},
+	}
+
+	d := Evaluate(cfg, "app-prod", []string{"delete", "pod", "x"})
+	if d.Message != "Use the deploy pipeline" || d.DocsURL != "https://wiki.example.com/deploys" {
+		t.Errorf("Decision guidance = %q, %q; want configured message and link", d.Message, d.DocsURL)
+	}
+	if d := Evaluate(cfg, "app-prod", []string{"get", "pods"}); d.Message != "" {
+		t.Errorf("Allowed command should carry no message, got %q", d.Message)
+	}
+}
+
+func TestApplyLock(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"drain"},
+				BlockedActions:      config.Actions("exec"),
+			},
+		},
+	}
+	confirm := &lock.State{Mode: lock.ModeConfirm}
+	block := &lock.State{Mode: lock.ModeBlock, Reason: "incident"}
+
+	tests := []struct {
+		name    string
+		context string
+		args    []string
+		state   *lock.State
+		verdict Verdict
+		locked  bool
+	}{
+		{"unlocked", "dev", []string{"delete", "pod", "x"}, nil, Allow, false},
+		{"read-only action", "dev", []string{"get", "pods"}, block, Allow, false},
+		{"confirm lock on dev", "dev", []string{"delete", "pod", "x"}, confirm, Confirm, true},
+		{"confirm lock keeps rule confirmation", "app-prod", []string{"drain", "node-1"}, confirm, Confirm, true},
+		{"block lock", "dev", []string{"apply", "-f", "x.yaml"}, block, Block, true},
+		{"already blocked", "app-prod", []string{"exec", "pod", "--", "sh"}, block, Block, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := ApplyLock(Evaluate(cfg, tt.context, tt.args), tt.state)
+			if d.Verdict != tt.verdict || d.Locked != tt.locked {
+				t.Errorf("ApplyLock(%v) = %q (locked %v), want %q (locked %v)", tt.args, d.Verdict, d.Locked, tt.verdict, tt.locked)
+			}
+		})
+	}
+
+	d := ApplyLock(Evaluate(cfg, "dev", []string{"delete", "pod", "x"}), block)
+	if !strings.Contains(d.Reason, "incident") || !strings.Contains(d.Reason, "kctl unlock") {
+		t.Errorf("Reason = %q, want the lock reason and how to lift it", d.Reason)
+	}
+}
+
+func TestEvaluate_MaintenanceWindow(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:       []string{"*-prod"},
+				BlockedActions: config.Actions("drain"),
+			},
+		},
+		MaintenanceWindows: map[string]config.MaintenanceWindow{
+			"patching": {
+				Clusters: []string{"app-*"},
+				Start:    time.Now().Add(-time.Hour),
+				End:      time.Now().Add(time.Hour),
+			},
+		},
+	}
+
+	d := Evaluate(cfg, "app-prod", []string{"drain", "node-1"})
+	if d.Verdict != Confirm || d.Maintenance != "patching" || !strings.Contains(d.Reason, "patching") {
+		t.Errorf("drain during window = %q (%s), want confirm naming the window", d.Verdict, d.Reason)
+	}
+	if d := Evaluate(cfg, "db-prod", []string{"drain", "node-1"}); d.Verdict != Block {
+		t.Errorf("drain outside window clusters = %q, want block", d.Verdict)
+	}
+}
+
+func TestEvaluate_Suggestions(t *testing.T) {
+	cfg := &config.Config{
+		Clusters: map[string]config.ClusterRules{
+			"locked-prod": {Tier: "production", BlockedActions: config.Actions("delete"), RequireConfirmation: []string{"scale"}},
+		},
+	}
+
+	d := Evaluate(cfg, "locked-prod", []string{"delete", "deploy", "web"})
+	if len(d.Suggestions) != 2 || d.Suggestions[0] != "scale deployment web --replicas=0" {
+		t.Errorf("Suggestions for blocked delete = %q", d.Suggestions)
+	}
+	if d := Evaluate(cfg, "locked-prod", []string{"scale", "deploy", "web", "--replicas=0"}); len(d.Suggestions) != 0 {
+		t.Errorf("Suggestions for a confirmation = %q, want none", d.Suggestions)
+	}
+}
+
+func TestEvaluate_AddFlags(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns: []string{"*-prod"},
+				AddFlags: map[string][]string{"delete": {"--wait=true"}, "rollout": {"--record"}},
+			},
+			"prod-eu": {
+				Inherits: "production",
+				Patterns: []string{"*-eu"},
+				AddFlags: map[string][]string{"delete": {"--wait=true", "--timeout=120s"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		context string
+		args    []string
+		want    string
+	}{
+		{"app-prod", []string{"delete", "pod", "x"}, "delete pod x --wait=true"},
+		{"app-eu", []string{"delete", "pod", "x"}, "delete pod x --wait=true --timeout=120s"},
+		{"app-eu", []string{"rollout", "undo", "deploy/x"}, "rollout undo deploy/x --record"},
+		{"app-prod", []string{"get", "pods"}, "get pods"},
+		{"dev", []string{"delete", "pod", "x"}, "delete pod x"},
+	}
+	for _, tt := range tests {
+		d := Evaluate(cfg, tt.context, tt.args)
+		if got := strings.Join(d.Args, " "); got != tt.want {
+			t.Errorf("Evaluate(%s, %v).Args = %q, want %q", tt.context, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate_ExplicitNamespace(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:                 []string{"*-prod"},
+				RequireConfirmation:      []string{"delete"},
+				RequireExplicitNamespace: true,
+				BlockUnqualifiedDeletes:  true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected Verdict
+	}{
+		{"delete without namespace", []string{"delete", "pod", "foo"}, Block},
+		{"delete with namespace", []string{"delete", "pod", "foo", "-n", "web"}, Confirm},
+		{"delete all in namespace", []string{"delete", "pods", "--all", "-n", "web"}, Confirm},
+		{"delete all without namespace", []string{"delete", "pods", "--all"}, Block},
+		{"delete all namespaces", []string{"delete", "pods", "--all", "-A"}, Block},
+		{"delete kind without name", []string{"delete", "pods", "-n", "web"}, Block},
+		{"cluster-scoped delete", []string{"delete", "node", "node-1"}, Confirm},
+		{"drain needs no namespace", []string{"drain", "node-1"}, Allow},
+		{"read without namespace", []string{"get", "pods"}, Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-prod", tt.args)
+			if d.Verdict != tt.expected {
+				t.Errorf("Evaluate(%v).Verdict = %q, want %q (%s)", tt.args, d.Verdict, tt.expected, d.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluate_ResourceLookup(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:                 []string{"*-prod"},
+				BlockedActions:           config.Actions("delete"),
+				RequireExplicitNamespace: true,
+			},
+		},
+		Suggestions: map[string][]string{"delete clusterissuer": {"describe clusterissuer {name}"}},
+	}
+	cfg.ResourceLookup = func(context, resource string) (string, bool, bool) {
+		if context == "app-prod" && (resource == "ciss" || resource == "clusterissuers.cert-manager.io") {
+			return "clusterissuer", false, true
+		}
+		return "", false, false
+	}
+
+	// A CRD short name resolves to a cluster-scoped kind, so no namespace is
+	// needed and the kind's suggestions apply
+	d := Evaluate(cfg, "app-prod", []string{"delete", "ciss", "letsencrypt"})
+	if d.Rule != "delete" || len(d.Suggestions) != 1 || d.Suggestions[0] != "describe clusterissuer letsencrypt" {
+		t.Errorf("delete ciss = rule %q, suggestions %q", d.Rule, d.Suggestions)
+	}
+	if d := Evaluate(cfg, "app-prod", []string{"delete", "clusterissuers.cert-manager.io/letsencrypt"}); d.Rule != "delete" {
+		t.Errorf("delete by group-qualified plural = rule %q, want delete", d.Rule)
+	}
+	// Names the cluster doesn't know fall back to kubectl's built-in ones
+	if d := Evaluate(cfg, "app-prod", []string{"delete", "no", "node-1"}); d.Rule != "delete" {
+		t.Errorf("delete no = rule %q, want delete", d.Rule)
+	}
+	if d := Evaluate(cfg, "app-prod", []string{"delete", "deploy", "web"}); d.Rule != "require_explicit_namespace" {
+		t.Errorf("delete deploy = rule %q, want require_explicit_namespace", d.Rule)
+	}
+}
+
+func TestEvaluate_NamespaceTiers(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"development": {Patterns: []string{"*-dev"}, BlockedActions: config.Actions("drain")},
+			"production":  {RequireConfirmation: []string{"delete", "scale"}},
+		},
+		Namespaces: map[string][]string{"production": {"payments", "prod-*"}},
+	}
+	cfg.NamespaceLookup = func(context string) (string, error) { return "prod-api", nil }
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected Verdict
+		tier     string
+	}{
+		{"delete in prod-like namespace", []string{"delete", "pod", "x", "-n", "payments"}, Confirm, "production"},
+		{"namespace joined to -n", []string{"delete", "deploy", "x", "-npayments"}, Confirm, "production"},
+		{"delete elsewhere", []string{"delete", "pod", "x", "-n", "scratch"}, Allow, "development"},
+		{"context's default namespace", []string{"scale", "deploy", "web", "--replicas=0"}, Confirm, "production"},
+		{"all namespaces", []string{"delete", "pods", "-l", "app=x", "-A"}, Confirm, "production"},
+		{"deleting the namespace", []string{"delete", "ns", "payments"}, Confirm, "production"},
+		{"cluster's stricter verdict stands", []string{"drain", "node-1"}, Block, "development"},
+		{"reads", []string{"get", "pods", "-n", "payments"}, Allow, "development"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-dev", tt.args)
+			if d.Verdict != tt.expected || d.Tier != tt.tier {
+				t.Errorf("Evaluate(%v) = %q in tier %q, want %q in %q (%s)", tt.args, d.Verdict, d.Tier, tt.expected, tt.tier, d.Reason)
+			}
+		})
+	}
+
+	d := Evaluate(cfg, "app-dev", []string{"delete", "pod", "x", "-n", "payments"})
+	if !strings.Contains(d.Reason, "namespace 'payments'") {
+		t.Errorf("Reason = %q, want it to name the namespace", d.Reason)
+	}
+}
+
+func TestEvaluate_Severity(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:        []string{"*-prod"},
+				BlockedActions:  config.Actions("drain"),
+				AllowedActions:  []string{"exec"},
+				ConfirmSeverity: "medium+",
+				BlockSeverity:   "critical",
+			},
+		},
+		Severity: config.SeverityConfig{
+			Actions: map[string]string{"exec": "high"},
+			Kinds:   map[string]int{"secret": 1},
+		},
+	}
+
+	web := filepath.Join(t.TempDir(), "web.yaml")
+	if err := os.WriteFile(web, []byte("kind: Deployment\nmetadata: {name: web}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		verdict  Verdict
+		rule     string
+		severity string
+	}{
+		{"exec raised by the table", []string{"exec", "web", "--", "sh"}, Confirm, "confirm_severity", "high"},
+		{"reading secrets", []string{"get", "secret", "tls"}, Allow, "default", "low"},
+		{"deleting secrets", []string{"delete", "secret", "tls", "-n", "web"}, Block, "block_severity", "critical"},
+		{"critical delete", []string{"delete", "ns", "team-a"}, Block, "block_severity", "critical"},
+		{"explicit block keeps its rule", []string{"drain", "node-1"}, Block, "drain", "high"},
+		{"low severity", []string{"apply", "-f", web}, Allow, "default", "low"},
+		{"unreadable manifests may hold secrets", []string{"apply", "-f", "https://example.com/app.yaml"}, Confirm, "confirm_severity", "medium"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-prod", tt.args)
+			if d.Verdict != tt.verdict || d.Rule != tt.rule || d.Severity != tt.severity {
+				t.Errorf("Evaluate(%v) = %q by %q at %q, want %q by %q at %q (%s)",
+					tt.args, d.Verdict, d.Rule, d.Severity, tt.verdict, tt.rule, tt.severity, d.Reason)
+			}
+		})
+	}
+
+	d := Evaluate(cfg, "app-prod", []string{"exec", "web", "--", "sh"})
+	if !strings.Contains(d.Reason, "high severity") || !strings.Contains(d.Reason, "confirm_severity: medium+") {
+		t.Errorf("Reason = %q, want the severity and threshold", d.Reason)
+	}
+}
+
+func TestEvaluate_Plugin(t *testing.T) {
+	previous := findPlugin
+	findPlugin = func(args []string) (string, string, bool) {
+		if args[0] == "node-shell" || args[0] == "neat" {
+			return args[0], "/usr/local/bin/kubectl-" + args[0], true
+		}
+		return "", "", false
+	}
+	t.Cleanup(func() { findPlugin = previous })
+
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"node-access"},
+				BlockedActions:      config.Actions("ssh-jump"),
+			},
+		},
+	}
+
+	d := Evaluate(cfg, "app-prod", []string{"node-shell", "node-1"})
+	if d.Verdict != Confirm || d.Action != "node-shell" || d.Plugin != "/usr/local/bin/kubectl-node-shell" {
+		t.Errorf("node-shell: got %q %q %q, want confirm by the node-access rule", d.Verdict, d.Action, d.Plugin)
+	}
+	if d := Evaluate(cfg, "app-prod", []string{"neat", "pod", "x"}); d.Verdict != Allow || d.Action != "neat" {
+		t.Errorf("neat: got %q %q, want allow", d.Verdict, d.Action)
+	}
+	if d := Evaluate(cfg, "app-prod", []string{"ssh-jump", "node-1"}); d.Verdict != Block {
+		t.Errorf("ssh-jump: got %q, want block", d.Verdict)
+	}
+}
+
+func TestEvaluate_ManifestHosts(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:      []string{"*-prod"},
+				ManifestHosts: []string{"github.com", "*.example.com"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected Verdict
+	}{
+		{"local file", []string{"apply", "-f", "deploy.yaml"}, Allow},
+		{"allowed URL", []string{"apply", "-f", "https://raw.example.com/app.yaml"}, Allow},
+		{"other URL", []string{"apply", "-f", "https://evil.test/app.yaml"}, Block},
+		{"allowed kustomization", []string{"apply", "-k", "github.com/org/repo//deploy?ref=v1"}, Allow},
+		{"other kustomization", []string{"apply", "--kustomize=gitlab.com/org/repo"}, Block},
+		{"any remote target", []string{"apply", "-f", "app.yaml", "-f", "http://evil.test/x.yaml"}, Block},
+		{"URL joined to -f", []string{"apply", "-fhttps://evil.test/x.yaml"}, Block},
+		{"kustomization joined to -k", []string{"apply", "-kgitlab.com/org/repo"}, Block},
+		{"allowed URL joined to -f", []string{"apply", "-fhttps://raw.example.com/app.yaml"}, Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-prod", tt.args)
+			if d.Verdict != tt.expected {
+				t.Errorf("Evaluate(%v).Verdict = %q, want %q (%s)", tt.args, d.Verdict, tt.expected, d.Reason)
+			}
+			if tt.expected == Block && d.Rule != "manifest_hosts" {
+				t.Errorf("Evaluate(%v).Rule = %q, want manifest_hosts", tt.args, d.Rule)
+			}
+		})
+	}
+}
+
+func TestEvaluate_BlockUnclassifiedProd(t *testing.T) {
+	cfg := config.Default()
+	cfg.Defaults.BlockUnclassifiedProd = true
+
+	tests := []struct {
+		name     string
+		context  string
+		args     []string
+		expected Verdict
+	}{
+		{"unclassified prod-looking change", "eu-prod-2", []string{"delete", "pod", "x"}, Block},
+		{"unclassified prod-looking read", "eu-prod-2", []string{"get", "pods"}, Allow},
+		{"unclassified other name", "payments", []string{"delete", "pod", "x"}, Allow},
+		{"classified production", "app-prod", []string{"delete", "pod", "x"}, Confirm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, tt.context, tt.args)
+			if d.Verdict != tt.expected {
+				t.Errorf("Evaluate(%s, %v).Verdict = %q, want %q (%s)", tt.context, tt.args, d.Verdict, tt.expected, d.Reason)
+			}
+		})
+	}
+
+	cfg.Defaults.BlockUnclassifiedProd = false
+	if d := Evaluate(cfg, "eu-prod-2", []string{"delete", "pod", "x"}); d.Verdict != Allow {
+		t.Errorf("without block_unclassified_prod, Verdict = %q", d.Verdict)
+	}
+}
+
+func TestEvaluate_ManifestObjects(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"development": {Patterns: []string{"*-dev"}},
+			"production":  {RequireConfirmation: []string{"apply", "delete"}, BlockSeverity: "critical"},
+		},
+		Namespaces: map[string][]string{"production": {"payments"}},
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	scratch := write("scratch.yaml", "kind: Deployment\nmetadata: {name: web, namespace: scratch}\n")
+	mixed := write("mixed.yaml", `kind: Deployment
+metadata: {name: web, namespace: scratch}
+---
+kind: Secret
+metadata: {name: db, namespace: payments}
+---
+kind: ConfigMap
+metadata: {name: settings}
+`)
+	namespace := write("namespace.yaml", "kind: Namespace\nmetadata: {name: payments}\n")
+	generated := write("generated.yaml", "kind: Job\nmetadata: {generateName: migrate-, namespace: payments}\n")
+
+	tests := []struct {
+		name    string
+		args    []string
+		verdict Verdict
+		objects []string
+	}{
+		{"objects outside assigned namespaces", []string{"apply", "-f", scratch}, Allow, nil},
+		{"an object in a production namespace", []string{"apply", "-f", mixed}, Confirm, []string{"Secret payments/db"}},
+		{"a stricter object in a different namespace", []string{"apply", "-f", mixed, "-n", "shop"}, Confirm, []string{"Secret payments/db"}},
+		{"the command itself needs confirmation", []string{"delete", "-f", mixed, "-n", "payments"}, Confirm, nil},
+		{"deleting a namespace object", []string{"delete", "-f", namespace}, Block, []string{"Namespace payments"}},
+		{"unreadable manifests", []string{"apply", "-f", filepath.Join(dir, "missing.yaml")}, Confirm, nil},
+		{"remote manifests", []string{"apply", "-f", "https://example.com/app.yaml"}, Confirm, nil},
+		{"kustomizations", []string{"apply", "-k", dir}, Confirm, nil},
+		{"an object without a name", []string{"create", "-f", generated}, Confirm, []string{"Job payments/<unknown>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "app-dev", tt.args)
+			if d.Verdict != tt.verdict || !reflect.DeepEqual(d.Objects, tt.objects) {
+				t.Errorf("Evaluate(%v) = %q for %v, want %q for %v (%s)", tt.args, d.Verdict, d.Objects, tt.verdict, tt.objects, d.Reason)
+			}
+			if !reflect.DeepEqual(d.Args, tt.args) {
+				t.Errorf("Args = %v, want the command's own %v", d.Args, tt.args)
+			}
+		})
+	}
+
+	d := Evaluate(cfg, "app-dev", []string{"apply", "-f", mixed})
+	if !strings.Contains(d.Reason, "(manifest: Secret payments/db)") {
+		t.Errorf("Reason = %q, want it to name the object", d.Reason)
+	}
+	d = Evaluate(cfg, "app-dev", []string{"apply", "-f", "https://example.com/app.yaml"})
+	if !strings.Contains(d.Reason, "objects can't be read from https://example.com/app.yaml") {
+		t.Errorf("Reason = %q, want it to name the unread manifest", d.Reason)
+	}
+}
+
+func TestObjectArgs(t *testing.T) {
+	o := manifest.Object{Kind: "Deployment", Name: "web", Namespace: "shop"}
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"apply", "-f", "app.yaml"}, []string{"apply", "deployment/web", "-n", "shop"}},
+		{[]string{"--context", "apply", "apply", "--filename=app.yaml", "-R", "-n", "other", "--server-side"},
+			[]string{"apply", "deployment/web", "--context", "apply", "--server-side", "-n", "shop"}},
+	}
+	for _, tt := range tests {
+		if got := objectArgs(tt.args, o); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("objectArgs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate_DeleteAllNamespaces(t *testing.T) {
+	everywhere := []string{"delete", "pods", "--all", "-A"}
+	tests := []struct {
+		name     string
+		mode     string
+		approval string
+		args     []string
+		verdict  Verdict
+		glass    bool
+	}{
+		{"break-glass with an approval command", "", "approve --wait", everywhere, Confirm, true},
+		{"break-glass without one", "", "", everywhere, Block, false},
+		{"blocked outright", config.DeleteAllBlock, "approve --wait", everywhere, Block, false},
+		{"left to the rules", config.DeleteAllRules, "", everywhere, Allow, false},
+		{"one namespace", "", "", []string{"delete", "pods", "--all", "-n", "shop"}, Allow, false},
+		{"dry run", "", "", []string{"delete", "pods", "--all", "-A", "--dry-run=server"}, Allow, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Defaults: config.DefaultsConfig{DeleteAllNamespaces: tt.mode},
+				Tiers: map[string]config.TierConfig{
+					"development": {Patterns: []string{"*-dev"}, ApprovalCommand: tt.approval},
+				},
+			}
+			d := Evaluate(cfg, "app-dev", tt.args)
+			if d.Verdict != tt.verdict || d.BreakGlass != tt.glass {
+				t.Errorf("Evaluate(%v) = %q, break-glass %v; want %q, %v (%s)", tt.args, d.Verdict, d.BreakGlass, tt.verdict, tt.glass, d.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluate_AllowedNamespaces(t *testing.T) {
+	cfg := &config.Config{
+		Clusters: map[string]config.ClusterRules{
+			"shared-prod": {Tier: "development", AllowedNamespaces: []string{"team-a", "team-a-*"}},
+		},
+		Tiers: map[string]config.TierConfig{"development": {Patterns: []string{"*-dev"}}},
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	own := write("own.yaml", "kind: Deployment\nmetadata: {name: web, namespace: team-a-jobs}\n")
+	other := write("other.yaml", "kind: Deployment\nmetadata: {name: web, namespace: team-b}\n")
+
+	tests := []struct {
+		name    string
+		args    []string
+		verdict Verdict
+	}{
+		{"an allowed namespace", []string{"delete", "pod", "web", "-n", "team-a-jobs"}, Allow},
+		{"another team's namespace", []string{"delete", "pod", "web", "-n", "team-b"}, Block},
+		{"another team's namespace joined to -n", []string{"delete", "pod", "web", "-nteam-b"}, Block},
+		{"an allowed namespace joined to -n", []string{"delete", "pod", "web", "-nteam-a"}, Allow},
+		{"the default namespace", []string{"scale", "deploy/web", "--replicas=0"}, Block},
+		{"every namespace", []string{"rollout", "restart", "deploy", "-A"}, Block},
+		{"reading", []string{"get", "pods", "-n", "team-b"}, Allow},
+		{"a manifest for an allowed namespace", []string{"apply", "-f", own}, Allow},
+		{"a manifest for another namespace", []string{"apply", "-f", other}, Block},
+		{"deleting another namespace", []string{"delete", "ns", "team-b", "-n", "team-a"}, Block},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(cfg, "shared-prod", tt.args)
+			if d.Verdict != tt.verdict {
+				t.Errorf("Evaluate(%v) = %q, want %q (%s)", tt.args, d.Verdict, tt.verdict, d.Reason)
+			}
+			if tt.verdict == Block && d.Rule != "allowed_namespaces" {
+				t.Errorf("Rule = %q, want allowed_namespaces", d.Rule)
+			}
+		})
+	}
+}
+
+func TestImageProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	data := `kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  template:
+    spec:
+      containers:
+        - image: ghcr.io/acme/web:v2
+        - image: nginx:1.25
+---
+kind: Job
+metadata: {name: migrate, namespace: shop}
+spec:
+  template:
+    spec:
+      containers:
+        - image: ghcr.io/acme/web@sha256:abc
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rules := config.ResolvedRules{Tier: "production", AllowedRegistries: []string{"ghcr.io/acme/*"}, RequireDigests: true}
+
+	got, err := ImageProblems([]string{"apply", "-f", path}, "apply", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Deployment shop/web runs ghcr.io/acme/web:v2, which isn't pinned by digest (require_digests)",
+		"Deployment shop/web runs nginx:1.25 from docker.io, which allowed_registries doesn't list",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImageProblems = %q, want %q", got, want)
+	}
+	if got, _ := ImageProblems([]string{"delete", "-f", path}, "delete", rules); got != nil {
+		t.Errorf("ImageProblems checked a delete: %q", got)
+	}
+
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {Patterns: []string{"*-prod"}, AllowedRegistries: []string{"ghcr.io/acme/*"}, UntrustedImages: config.UntrustedImagesBlock},
+			"staging":    {Patterns: []string{"*-staging"}, AllowedRegistries: []string{"ghcr.io/acme/*"}},
+		},
+	}
+	d := Evaluate(cfg, "app-prod", []string{"apply", "-f", path})
+	if d.Verdict != Block || d.Rule != "untrusted_images" {
+		t.Fatalf("Evaluate = %q by %q, want a block by untrusted_images", d.Verdict, d.Rule)
+	}
+	if want := "Tier 'production' blocks untrusted images: Deployment shop/web runs nginx:1.25 from docker.io, which allowed_registries doesn't list"; d.Reason != want {
+		t.Errorf("Reason = %q, want %q", d.Reason, want)
+	}
+	if d := Evaluate(cfg, "app-staging", []string{"apply", "-f", path}); d.Verdict == Block {
+		t.Errorf("untrusted_images warn blocked: %s", d.Reason)
+	}
+
+	// Images that can't be read are blocked as untrusted
+	broken := filepath.Join(dir, "broken.yaml")
+	if err := os.WriteFile(broken, []byte("kind: [unclosed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"apply", "-k", "overlays/prod"},
+		{"apply", "-f", "https://example.com/app.yaml"},
+		{"apply", "-f", filepath.Join(dir, "missing.yaml")},
+		{"apply", "-f", broken},
+	} {
+		if d := Evaluate(cfg, "app-prod", args); d.Verdict != Block || d.Rule != "untrusted_images" {
+			t.Errorf("Evaluate(%v) = %q by %q, want a block by untrusted_images", args, d.Verdict, d.Rule)
+		}
+	}
+}
+
+func TestEvaluate_FreezeWindow(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {Patterns: []string{"*-prod"}, BlockedActions: config.Actions("delete")},
+		},
+		MaintenanceWindows: map[string]config.MaintenanceWindow{
+			"patching": {Clusters: []string{"*"}, Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)},
+		},
+		FreezeWindows: map[string]config.MaintenanceWindow{
+			"year-end": {Clusters: []string{"*-prod"}, Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)},
+		},
+	}
+	tests := []struct {
+		context string
+		args    []string
+		verdict Verdict
+	}{
+		{"app-prod", []string{"scale", "deploy/web", "--replicas=2"}, Block},
+		{"app-prod", []string{"delete", "pod", "web"}, Block},
+		{"app-prod", []string{"get", "pods"}, Allow},
+		{"app-dev", []string{"scale", "deploy/web", "--replicas=2"}, Allow},
+	}
+	for _, tt := range tests {
+		d := Evaluate(cfg, tt.context, tt.args)
+		if d.Verdict != tt.verdict {
+			t.Errorf("Evaluate(%s, %v) = %q (%s), want %q", tt.context, tt.args, d.Verdict, d.Reason, tt.verdict)
+		}
+		if tt.verdict == Block && d.Rule != "freeze" {
+			t.Errorf("Evaluate(%s, %v).Rule = %q, want freeze", tt.context, tt.args, d.Rule)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package policytest checks rules against expected decisions, so changes to
+// shared policy can be tested before they reach anyone's laptop
+package policytest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// DefaultFile is the tests file used when none is given
+const DefaultFile = "policy-tests.yaml"
+
+// Case is a command and the decision the rules should reach for it
+type Case struct {
+	Name    string `yaml:"name,omitempty"`
+	Context string `yaml:"context"`
+	// Server is the API server URL the context points at, for rules
+	// matching tiers by server
+	Server string `yaml:"server,omitempty"`
+	// Command is the kubectl command line, with or without "kubectl"
+	Command string `yaml:"command"`
+	Expect  Expect `yaml:"expect"`
+}
+
+// Expect is the expected decision. Empty fields aren't checked.
+type Expect struct {
+	Verdict    policy.Verdict `yaml:"verdict"`
+	Tier       string         `yaml:"tier,omitempty"`
+	Action     string         `yaml:"action,omitempty"`
+	Rule       string         `yaml:"rule,omitempty"`
+	AddedFlags []string       `yaml:"added_flags,omitempty"`
+}
+
+// Label names the case in reports: its name, else its command
+func (c Case) Label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s: %s", c.Context, c.Command)
+}
+
+// Result is the outcome of one case
+type Result struct {
+	Case     Case
+	Decision policy.Decision
+	// Problems describe each way the decision differed from the expectation
+	Problems []string
+}
+
+// Passed reports whether the decision matched the expectation
+func (r Result) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// Load reads the cases from the tests file at path. Unknown keys are
+// errors, so a misspelt expectation can't pass silently.
+func Load(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tests []Case `yaml:"tests"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, c := range file.Tests {
+		if c.Context == "" || c.Command == "" || c.Expect.Verdict == "" {
+			return nil, fmt.Errorf("%s: test %d (%s) needs context, command and expect.verdict", path, i+1, c.Label())
+		}
+		switch c.Expect.Verdict {
+		case policy.Allow, policy.Confirm, policy.Block:
+		default:
+			return nil, fmt.Errorf("%s: test %d (%s): unknown verdict %q", path, i+1, c.Label(), c.Expect.Verdict)
+		}
+	}
+	return file.Tests, nil
+}
+
+// Run evaluates each case against cfg. Nothing is asked of the cluster:
+// tier lookups from cluster_meta are disabled and servers come from the
+// cases. cfg's lookups are replaced.
+func Run(cfg *config.Config, cases []Case) []Result {
+	servers := map[string]string{}
+	for _, c := range cases {
+		if c.Server != "" {
+			servers[c.Context] = c.Server
+		}
+	}
+	cfg.TierLookup = nil
+	cfg.ResourceLookup = nil
+	cfg.NamespaceLookup = nil
+	cfg.ServerLookup = func(context string) (string, error) { return servers[context], nil }
+
+	results := make([]Result, len(cases))
+	for i, c := range cases {
+		results[i] = run(cfg, c)
+	}
+	return results
+}
+
+func run(cfg *config.Config, c Case) Result {
+	result := Result{Case: c}
+	args, err := shell.SplitArgs(c.Command)
+	if err == nil && len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
+	}
+	if err != nil || len(args) == 0 {
+		result.Problems = []string{fmt.Sprintf("invalid command %q", c.Command)}
+		return result
+	}
+
+	d := policy.Evaluate(cfg, c.Context, args)
+	result.Decision = d
+	want := c.Expect
+	check := func(field, got, want string) {
+		if want != "" && got != want {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: got %q, want %q", field, got, want))
+		}
+	}
+	check("verdict", string(d.Verdict), string(want.Verdict))
+	check("tier", d.Tier, want.Tier)
+	check("action", d.Action, want.Action)
+	check("rule", d.Rule, want.Rule)
+	// "added_flags: []" expects none
+	if got := strings.Join(d.AddedFlags, " "); want.AddedFlags != nil && got != strings.Join(want.AddedFlags, " ") {
+		result.Problems = append(result.Problems, fmt.Sprintf("added_flags: got %v, want %v", d.AddedFlags, want.AddedFlags))
+	}
+	return result
+}
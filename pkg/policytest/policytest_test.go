@@ -0,0 +1,82 @@
+package policytest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func writeTests(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeTests(t, `tests:
+  - name: prod deletes need confirmation
+    context: app-prod
+    command: kubectl delete pod web
+    expect:
+      verdict: confirm
+      tier: production
+      added_flags: []
+`)
+	cases, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cases) != 1 || cases[0].Expect.Verdict != "confirm" || cases[0].Expect.AddedFlags == nil {
+		t.Errorf("Load = %+v", cases)
+	}
+
+	for _, bad := range []string{
+		"tests:\n  - context: app-prod\n    command: get pods\n    expect:\n      verdit: allow\n",
+		"tests:\n  - context: app-prod\n    command: get pods\n    expect:\n      verdict: maybe\n",
+		"tests:\n  - command: get pods\n    expect:\n      verdict: allow\n",
+	} {
+		if _, err := Load(writeTests(t, bad)); err == nil {
+			t.Errorf("Load accepted:\n%s", bad)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				Servers:             []string{"https://*.prod.internal"},
+				RequireConfirmation: []string{"delete"},
+				BlockedActions:      config.Actions("drain"),
+				AddFlags:            map[string][]string{"delete": {"--wait=true"}},
+			},
+		},
+	}
+	cases := []Case{
+		{Context: "app-prod", Command: "delete pod web",
+			Expect: Expect{Verdict: "confirm", Tier: "production", Rule: "delete", AddedFlags: []string{"--wait=true"}}},
+		{Context: "renamed", Server: "https://api.prod.internal", Command: "kubectl drain node-1",
+			Expect: Expect{Verdict: "block", Action: "drain"}},
+		{Name: "wrong expectation", Context: "app-prod", Command: "get pods",
+			Expect: Expect{Verdict: "block", AddedFlags: []string{}}},
+		{Context: "app-prod", Command: "get 'pods", Expect: Expect{Verdict: "allow"}},
+	}
+
+	results := Run(cfg, cases)
+	if !results[0].Passed() || !results[1].Passed() {
+		t.Errorf("expected cases failed: %v / %v", results[0].Problems, results[1].Problems)
+	}
+	if results[2].Passed() || len(results[2].Problems) != 1 || !strings.Contains(results[2].Problems[0], `verdict: got "allow", want "block"`) {
+		t.Errorf("wrong expectation problems = %v", results[2].Problems)
+	}
+	if results[3].Passed() {
+		t.Error("a case with an unparseable command passed")
+	}
+}
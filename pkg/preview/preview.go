@@ -0,0 +1,112 @@
+// Package preview finds the objects a mutating command would touch by
+// running it as a server-side dry run, by listing what its label selector
+// matches, or, for deletes, by reading the objects it names
+package preview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// previewable are the commands kubectl can dry-run with "-o name"
+var previewable = map[string]bool{
+	"delete": true,
+	"apply":  true,
+	"patch":  true,
+	"scale":  true,
+	"label":  true,
+	"set":    true,
+}
+
+// Supported reports whether the objects affected by args can be previewed
+func Supported(args []string) bool {
+	positional := rbac.Positional(args)
+	return len(positional) > 0 && previewable[positional[0]]
+}
+
+// Affected returns the objects ("kind/name") the command would touch on
+// context, as reported by a server-side dry run
+func Affected(context string, args []string) ([]string, error) {
+	stdout, stderr, exitCode := kubectl.Run(DryRunArgs(context, args, "name"))
+	if exitCode != 0 {
+		return nil, fmt.Errorf("dry run failed: %s", strings.TrimSpace(stderr))
+	}
+	var objects []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			objects = append(objects, fields[0])
+		}
+	}
+	return objects, nil
+}
+
+// DryRunArgs turns args into a server-side dry run on context printing the
+// objects in format, e.g. "name" or "json". Any output or dry-run flags of
+// the original command are dropped.
+func DryRunArgs(context string, args []string, format string) []string {
+	out := []string{"--context", context}
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		switch {
+		case arg == "-o" || arg == "--output":
+			i++
+		case strings.HasPrefix(arg, "-o") || strings.HasPrefix(arg, "--output=") || strings.HasPrefix(arg, "--dry-run"):
+		default:
+			out = append(out, arg)
+		}
+	}
+	out = append(out, "--dry-run=server", "-o", format)
+	return append(out, args[i:]...)
+}
+
+// deleteOnlyFlags are delete flags 'kubectl get' doesn't take, with
+// whether they take a separate value
+var deleteOnlyFlags = map[string]bool{
+	"--all": false, "--cascade": false, "--force": false, "--now": false,
+	"--wait": false, "--interactive": false, "-i": false, "--raw": true,
+	"--grace-period": true, "--timeout": true, "-o": true, "--output": true,
+}
+
+// GetArgs turns the delete in args into a 'kubectl get' on context of the
+// objects it deletes, printed in format. kubectl delete only prints names,
+// even as a dry run, so the objects themselves are read this way: the same
+// names, selectors, files and namespaces, without the delete's own flags.
+// Objects that are already gone are left out.
+func GetArgs(context string, args []string, format string) []string {
+	out := []string{"--context", context}
+	command := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name, _, joined := strings.Cut(arg, "=")
+		takesValue, deleteOnly := deleteOnlyFlags[name]
+		switch {
+		case deleteOnly:
+			if takesValue && !joined {
+				i++
+			}
+		case strings.HasPrefix(arg, "-o") || strings.HasPrefix(arg, "--dry-run"):
+		case strings.HasPrefix(arg, "-"):
+			out = append(out, arg)
+			if !joined && rbac.FlagTakesValue(arg) && i+1 < len(args) {
+				i++
+				out = append(out, args[i])
+			}
+		case command && arg == "delete":
+			out = append(out, "get")
+			command = false
+		default:
+			out = append(out, arg)
+		}
+	}
+	return append(out, "--ignore-not-found", "-o", format)
+}
@@ -0,0 +1,143 @@
+package preview
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestSupported(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"delete", "pods", "--all"}, true},
+		{[]string{"-n", "web", "apply", "-f", "app.yaml"}, true},
+		{[]string{"scale", "deploy/app", "--replicas=0"}, true},
+		{[]string{"drain", "node-1"}, false},
+		{[]string{"get", "pods"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Supported(tt.args); got != tt.want {
+			t.Errorf("Supported(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDryRunArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			[]string{"delete", "pods", "--all", "-n", "batch"},
+			"--context prod delete pods --all -n batch --dry-run=server -o name",
+		},
+		{
+			[]string{"apply", "-f", "app.yaml", "-o", "yaml", "--dry-run=client"},
+			"--context prod apply -f app.yaml --dry-run=server -o name",
+		},
+		{
+			[]string{"delete", "-f", "-", "--output=json", "--", "extra"},
+			"--context prod delete -f - --dry-run=server -o name -- extra",
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(DryRunArgs("prod", tt.args, "name"), " "); got != tt.want {
+			t.Errorf("DryRunArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			[]string{"delete", "pods", "--all", "-n", "batch", "--grace-period", "0", "--force"},
+			"--context prod get pods -n batch --ignore-not-found -o json",
+		},
+		{
+			[]string{"-n", "delete", "delete", "deploy", "web", "--cascade=orphan", "--wait=false", "-o", "name"},
+			"--context prod -n delete get deploy web --ignore-not-found -o json",
+		},
+		{
+			[]string{"delete", "-f", "app.yaml", "-l", "app=web", "--dry-run=client", "--timeout", "30s"},
+			"--context prod get -f app.yaml -l app=web --ignore-not-found -o json",
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(GetArgs("prod", tt.args, "json"), " "); got != tt.want {
+			t.Errorf("GetArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestAffected(t *testing.T) {
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	kubectl.Run = func(args []string) (string, string, int) {
+		return "pod/a\npod/b (server dry run)\n\n", "", 0
+	}
+	objects, err := Affected("prod", []string{"delete", "pods", "--all"})
+	if err != nil {
+		t.Fatalf("Affected() error = %v", err)
+	}
+	if want := []string{"pod/a", "pod/b"}; !reflect.DeepEqual(objects, want) {
+		t.Errorf("Affected() = %v, want %v", objects, want)
+	}
+
+	kubectl.Run = func(args []string) (string, string, int) {
+		return "", "error: the server doesn't have a resource type \"podz\"\n", 1
+	}
+	if _, err := Affected("prod", []string{"delete", "podz", "--all"}); err == nil || !strings.Contains(err.Error(), "podz") {
+		t.Errorf("Affected() error = %v, want the kubectl error", err)
+	}
+}
+
+func TestSelected(t *testing.T) {
+	previous := kubectl.Run
+	t.Cleanup(func() { kubectl.Run = previous })
+	var got []string
+	kubectl.Run = func(args []string) (string, string, int) {
+		got = args
+		return "Pod/web-1\tshop\nPod/web-2\tshop\n", "", 0
+	}
+
+	objects, err := Selected("prod", []string{"delete", "pods", "-l", "app=web", "-n", "shop", "--wait=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0] != (Object{Name: "Pod/web-1", Namespace: "shop"}) {
+		t.Errorf("Selected = %+v", objects)
+	}
+	if want := "--context prod --request-timeout=10s get pods -l app=web -n shop -o " + selectedTemplate; strings.Join(got, " ") != want {
+		t.Errorf("ran %q, want %q", strings.Join(got, " "), want)
+	}
+
+	tests := []struct {
+		args     []string
+		resource string
+	}{
+		{[]string{"rollout", "restart", "deployment", "--selector=tier=web"}, "deployment"},
+		{[]string{"drain", "-l", "pool=spot", "--ignore-daemonsets"}, "nodes"},
+		{[]string{"label", "pods", "-l", "app=web", "-A", "team=shop"}, "pods"},
+	}
+	for _, tt := range tests {
+		args, ok := selectorArgs("prod", tt.args)
+		if !ok || args[4] != tt.resource {
+			t.Errorf("selectorArgs(%v) = %v, %v; want a get of %s", tt.args, args, ok, tt.resource)
+		}
+	}
+	if args, _ := selectorArgs("prod", []string{"delete", "pods", "-l", "app=web", "-nshop"}); !strings.Contains(strings.Join(args, " "), " -n shop ") {
+		t.Errorf("selectorArgs with -nshop = %v, want the shop namespace", args)
+	}
+	if _, ok := selectorArgs("prod", []string{"delete", "pods", "web"}); ok {
+		t.Error("selectorArgs without a selector reported one")
+	}
+}
@@ -0,0 +1,87 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Object is an object a command's label selector matches
+type Object struct {
+	Name      string // Kind/name
+	Namespace string // empty for cluster-scoped objects
+}
+
+// selectedTemplate prints kind/name and namespace for each listed object
+const selectedTemplate = `jsonpath={range .items[*]}{.kind}/{.metadata.name}{"\t"}{.metadata.namespace}{"\n"}{end}`
+
+// Selector returns the label selector of args, if any
+func Selector(args []string) (string, bool) {
+	return rbac.FlagValue(args, "-l", "--selector")
+}
+
+// Selected lists the objects the label selector of args matches on
+// context, as the command itself would find them
+func Selected(context string, args []string) ([]Object, error) {
+	getArgs, ok := selectorArgs(context, args)
+	if !ok {
+		return nil, fmt.Errorf("can't tell which resources the selector applies to")
+	}
+	stdout, stderr, exitCode := kubectl.Run(getArgs)
+	if exitCode != 0 {
+		return nil, fmt.Errorf("listing the selected objects failed: %s", strings.TrimSpace(stderr))
+	}
+	var objects []Object
+	for _, line := range strings.Split(stdout, "\n") {
+		name, namespace, _ := strings.Cut(line, "\t")
+		if name = strings.TrimSpace(name); name != "" {
+			objects = append(objects, Object{Name: name, Namespace: strings.TrimSpace(namespace)})
+		}
+	}
+	return objects, nil
+}
+
+// selectorArgs turns args into a 'kubectl get' of the objects its selector
+// matches, keeping the namespace and field selector. ok is false without a
+// selector or when the resource type isn't on the command line.
+func selectorArgs(context string, args []string) ([]string, bool) {
+	selector, ok := Selector(args)
+	if !ok {
+		return nil, false
+	}
+	positional := rbac.Positional(args)
+	if len(positional) == 0 {
+		return nil, false
+	}
+	resource := ""
+	switch positional[0] {
+	case "drain", "cordon", "uncordon":
+		resource = "nodes"
+	case "rollout", "set":
+		// rollout restart deployment -l ..., set image deployment -l ...
+		if len(positional) > 2 {
+			resource = positional[2]
+		}
+	default:
+		if len(positional) > 1 {
+			resource = positional[1]
+		}
+	}
+	if resource == "" || strings.Contains(resource, "/") {
+		return nil, false
+	}
+
+	out := []string{"--context", context, "--request-timeout=10s", "get", resource, "-l", selector}
+	if namespace, ok := rbac.Namespace(args); ok {
+		out = append(out, "-n", namespace)
+	}
+	if rbac.HasFlag(args, "-A", "--all-namespaces") {
+		out = append(out, "--all-namespaces")
+	}
+	if fields, ok := rbac.FlagValue(args, "--field-selector"); ok {
+		out = append(out, "--field-selector", fields)
+	}
+	return append(out, "-o", selectedTemplate), true
+}
@@ -0,0 +1,55 @@
+// Package quantity reads and prints Kubernetes resource quantities such as
+// 500m, 2Gi or 1e3
+package quantity
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// suffixes are the multipliers of the decimal and binary suffixes
+var suffixes = map[string]float64{
+	"n": 1e-9, "u": 1e-6, "m": 1e-3, "": 1,
+	"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+}
+
+// Parse returns the value of a quantity in base units: cores for CPU,
+// bytes for memory
+func Parse(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] >= 'a' && s[i-1] <= 'z' || s[i-1] >= 'A' && s[i-1] <= 'Z') {
+		i--
+	}
+	multiplier, ok := suffixes[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return value * multiplier, nil
+}
+
+// CPU prints cores, e.g. 250m or 1.5
+func CPU(cores float64) string {
+	if cores < 1 {
+		return fmt.Sprintf("%dm", int(math.Round(cores*1000)))
+	}
+	return strconv.FormatFloat(math.Round(cores*100)/100, 'f', -1, 64)
+}
+
+// Bytes prints bytes with the largest binary suffix that fits, e.g. 512Mi
+// or 1.5Gi
+func Bytes(bytes float64) string {
+	for _, suffix := range []string{"Ti", "Gi", "Mi", "Ki"} {
+		if unit := suffixes[suffix]; bytes >= unit {
+			return strconv.FormatFloat(math.Round(bytes/unit*10)/10, 'f', -1, 64) + suffix
+		}
+	}
+	return strconv.FormatFloat(bytes, 'f', -1, 64)
+}
@@ -0,0 +1,34 @@
+package quantity
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for s, want := range map[string]float64{
+		"500m": 0.5, "2": 2, "0.25": 0.25, "1Gi": 1 << 30, "512Mi": 512 << 20,
+		"1G": 1e9, "128974848": 128974848, "1e3": 1000, "100Ki": 102400,
+	} {
+		if got, err := Parse(s); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "1Qi", "lots"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := CPU(0.25); got != "250m" {
+		t.Errorf("CPU(0.25) = %s", got)
+	}
+	if got := CPU(1.5); got != "1.5" {
+		t.Errorf("CPU(1.5) = %s", got)
+	}
+	if got := Bytes(1.5 * (1 << 30)); got != "1.5Gi" {
+		t.Errorf("Bytes(1.5Gi) = %s", got)
+	}
+	if got := Bytes(512 << 20); got != "512Mi" {
+		t.Errorf("Bytes(512Mi) = %s", got)
+	}
+}
@@ -0,0 +1,430 @@
+// Package quota checks the objects an apply or create adds against the
+// ResourceQuotas and LimitRanges of their namespaces, to warn about
+// admission failures that are certain before the command runs
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/preview"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/quantity"
+)
+
+// aliases are quota keys counting the same as another
+var aliases = map[string]string{"cpu": "requests.cpu", "memory": "requests.memory"}
+
+// counted are the kinds quotas count by object, with their quota key
+var counted = map[string]string{
+	"PersistentVolumeClaim": "persistentvolumeclaims",
+	"Service":               "services",
+	"ConfigMap":             "configmaps",
+	"Secret":                "secrets",
+}
+
+// container is the part of a container the checks read
+type container struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Requests map[string]string `json:"requests"`
+		Limits   map[string]string `json:"limits"`
+	} `json:"resources"`
+}
+
+// podSpec is the part of a pod spec the checks read
+type podSpec struct {
+	Containers     []container `json:"containers"`
+	InitContainers []container `json:"initContainers"`
+}
+
+// object is the part of an object the checks read
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		podSpec
+		Replicas    *int `json:"replicas"`
+		Parallelism *int `json:"parallelism"`
+		Template    struct {
+			Spec podSpec `json:"spec"`
+		} `json:"template"`
+		Resources struct {
+			Requests map[string]string `json:"requests"`
+		} `json:"resources"`
+	} `json:"spec"`
+	Status struct {
+		Hard map[string]string `json:"hard"`
+		Used map[string]string `json:"used"`
+	} `json:"status"`
+	Items []object `json:"items"`
+}
+
+// ref names o for kubectl, e.g. deployment/web
+func (o object) ref() string {
+	return strings.ToLower(o.Kind) + "/" + o.Metadata.Name
+}
+
+// pods returns the spec of the pods o creates and how many, or nil for
+// objects that don't create pods
+func (o object) pods() (*podSpec, int) {
+	switch o.Kind {
+	case "Pod":
+		return &o.Spec.podSpec, 1
+	case "Deployment", "StatefulSet", "ReplicaSet", "ReplicationController":
+		if o.Spec.Replicas == nil {
+			return &o.Spec.Template.Spec, 1
+		}
+		return &o.Spec.Template.Spec, *o.Spec.Replicas
+	case "Job":
+		if o.Spec.Parallelism == nil {
+			return &o.Spec.Template.Spec, 1
+		}
+		return &o.Spec.Template.Spec, *o.Spec.Parallelism
+	}
+	return nil, 0
+}
+
+// limitRange is the part of a LimitRange the checks read
+type limitRange struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Limits []struct {
+			Type           string            `json:"type"`
+			Default        map[string]string `json:"default"`
+			DefaultRequest map[string]string `json:"defaultRequest"`
+			Max            map[string]string `json:"max"`
+		} `json:"limits"`
+	} `json:"spec"`
+}
+
+// Check dry-runs the apply or create in args on context and returns a
+// warning for each object its namespace's LimitRanges would reject and for
+// each quota the new objects would exceed. Existing objects count only by
+// what the command adds to them.
+func Check(context string, args []string) ([]string, error) {
+	stdout, stderr, exitCode := kubectl.Run(preview.DryRunArgs(context, args, "json"))
+	if exitCode != 0 {
+		return nil, fmt.Errorf("dry run failed: %s", strings.TrimSpace(stderr))
+	}
+	objects, err := parseObjects(stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	byNamespace := make(map[string][]object)
+	for _, o := range objects {
+		ns := o.Metadata.Namespace
+		if ns == "" {
+			continue
+		}
+		if _, seen := byNamespace[ns]; !seen {
+			namespaces = append(namespaces, ns)
+		}
+		byNamespace[ns] = append(byNamespace[ns], o)
+	}
+
+	var warnings []string
+	for _, ns := range namespaces {
+		found, err := checkNamespace(context, ns, byNamespace[ns])
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, found...)
+	}
+	return warnings, nil
+}
+
+// checkNamespace checks the objects applied to namespace
+func checkNamespace(context, namespace string, objects []object) ([]string, error) {
+	base := []string{"--context", context, "--request-timeout=10s", "get", "-n", namespace}
+	var list struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := kubectl.GetJSON(append(base, "resourcequota,limitrange"), &list); err != nil {
+		return nil, fmt.Errorf("listing quotas in %s failed: %w", namespace, err)
+	}
+	var quotas []object
+	var ranges []limitRange
+	for _, item := range list.Items {
+		var kind struct {
+			Kind string `json:"kind"`
+		}
+		_ = json.Unmarshal(item, &kind)
+		switch kind.Kind {
+		case "ResourceQuota":
+			var q object
+			if json.Unmarshal(item, &q) == nil {
+				quotas = append(quotas, q)
+			}
+		case "LimitRange":
+			var r limitRange
+			if json.Unmarshal(item, &r) == nil {
+				ranges = append(ranges, r)
+			}
+		}
+	}
+	if len(quotas) == 0 && len(ranges) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]string, 0, len(objects))
+	for _, o := range objects {
+		refs = append(refs, o.ref())
+	}
+	stdout, stderr, exitCode := kubectl.Run(append(append(base, refs...), "--ignore-not-found", "-o", "json"))
+	if exitCode != 0 {
+		return nil, fmt.Errorf("reading the objects in %s failed: %s", namespace, strings.TrimSpace(stderr))
+	}
+	existing, err := parseObjects(stdout)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]object, len(existing))
+	for _, o := range existing {
+		live[o.ref()] = o
+	}
+
+	var warnings []string
+	added := make(map[string]float64)
+	for _, o := range objects {
+		if spec, _ := o.pods(); spec != nil {
+			warnings = append(warnings, checkLimits(o, spec, ranges, quotas)...)
+		}
+		current, exists := live[o.ref()]
+		for key, v := range demand(o, ranges) {
+			added[key] += v
+		}
+		if exists {
+			for key, v := range demand(current, ranges) {
+				added[key] -= v
+			}
+		}
+	}
+	return append(warnings, exceeded(namespace, quotas, added)...), nil
+}
+
+// defaults returns the requests and limits a container gets from ranges
+// when it sets none
+func defaults(ranges []limitRange) (requests, limits map[string]string) {
+	requests, limits = map[string]string{}, map[string]string{}
+	for _, r := range ranges {
+		for _, l := range r.Spec.Limits {
+			if l.Type != "Container" {
+				continue
+			}
+			for resource, v := range l.Default {
+				limits[resource] = v
+			}
+			for resource, v := range l.DefaultRequest {
+				requests[resource] = v
+			}
+		}
+	}
+	return requests, limits
+}
+
+// effective returns the requests and limits of c once ranges' defaults are
+// applied. A request left unset takes the container's own limit, as the API
+// server defaults it before admission, then the range's default.
+func effective(c container, ranges []limitRange) (requests, limits map[string]float64) {
+	defaultRequests, defaultLimits := defaults(ranges)
+	requests, limits = map[string]float64{}, map[string]float64{}
+	for _, resource := range []string{"cpu", "memory"} {
+		limit, ok := c.Resources.Limits[resource]
+		if !ok {
+			limit, ok = defaultLimits[resource]
+		}
+		if ok {
+			limits[resource], _ = quantity.Parse(limit)
+		}
+		request, ok := c.Resources.Requests[resource]
+		if _, limited := c.Resources.Limits[resource]; !ok && limited {
+			request, ok = limit, true
+		}
+		if !ok {
+			request, ok = defaultRequests[resource]
+		}
+		if ok {
+			requests[resource], _ = quantity.Parse(request)
+		} else if v, limited := limits[resource]; limited {
+			requests[resource] = v
+		}
+	}
+	return requests, limits
+}
+
+// checkLimits returns the reasons the pods of o would be rejected: a
+// container over a LimitRange's max, or without a request or limit a quota
+// needs every pod to set
+func checkLimits(o object, spec *podSpec, ranges []limitRange, quotas []object) []string {
+	var warnings []string
+	containers := append(append([]container(nil), spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		requests, limits := effective(c, ranges)
+		for _, r := range ranges {
+			for _, l := range r.Spec.Limits {
+				if l.Type != "Container" {
+					continue
+				}
+				for _, resource := range sortedKeys(l.Max) {
+					most, err := quantity.Parse(l.Max[resource])
+					if err != nil {
+						continue
+					}
+					for _, v := range []float64{requests[resource], limits[resource]} {
+						if v > most {
+							warnings = append(warnings, fmt.Sprintf("%s: container %s asks for %s %s, over the %s allowed by LimitRange %s; its pods will be rejected",
+								o.ref(), c.Name, format(resource, v), resource, format(resource, most), r.Metadata.Name))
+							break
+						}
+					}
+				}
+			}
+		}
+		for _, q := range quotas {
+			for _, key := range sortedKeys(q.Status.Hard) {
+				kind, resource, _ := strings.Cut(canonical(key), ".")
+				if kind != "requests" && kind != "limits" || resource != "cpu" && resource != "memory" {
+					continue
+				}
+				set := requests
+				if kind == "limits" {
+					set = limits
+				}
+				if _, ok := set[resource]; !ok {
+					warnings = append(warnings, fmt.Sprintf("%s: container %s sets no %s %s and no LimitRange gives a default, but ResourceQuota %s needs one; its pods will be rejected",
+						o.ref(), c.Name, resource, strings.TrimSuffix(kind, "s"), q.Metadata.Name))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// demand returns what o counts against quotas: its pods, their requests
+// and limits, one of a counted kind, and a claim's storage
+func demand(o object, ranges []limitRange) map[string]float64 {
+	d := make(map[string]float64)
+	if key, ok := counted[o.Kind]; ok {
+		d[key] = 1
+	}
+	if o.Kind == "PersistentVolumeClaim" {
+		d["requests.storage"], _ = quantity.Parse(o.Spec.Resources.Requests["storage"])
+	}
+	spec, n := o.pods()
+	if spec == nil {
+		return d
+	}
+	d["pods"] = float64(n)
+	pod := map[string]float64{}
+	for _, c := range spec.Containers {
+		requests, limits := effective(c, ranges)
+		for resource, v := range requests {
+			pod["requests."+resource] += v
+		}
+		for resource, v := range limits {
+			pod["limits."+resource] += v
+		}
+	}
+	// A pod needs the most any of its init containers asks for, if more
+	for _, c := range spec.InitContainers {
+		requests, limits := effective(c, ranges)
+		for resource, v := range requests {
+			pod["requests."+resource] = math.Max(pod["requests."+resource], v)
+		}
+		for resource, v := range limits {
+			pod["limits."+resource] = math.Max(pod["limits."+resource], v)
+		}
+	}
+	for key, v := range pod {
+		d[key] = v * float64(n)
+	}
+	return d
+}
+
+// exceeded returns a warning for each quota in namespace that added takes
+// over its hard limit
+func exceeded(namespace string, quotas []object, added map[string]float64) []string {
+	var warnings []string
+	for _, q := range quotas {
+		for _, key := range sortedKeys(q.Status.Hard) {
+			more := added[canonical(key)]
+			if more <= 0 {
+				continue
+			}
+			hard, err := quantity.Parse(q.Status.Hard[key])
+			if err != nil {
+				continue
+			}
+			used, _ := quantity.Parse(q.Status.Used[key])
+			if used+more > hard {
+				warnings = append(warnings, fmt.Sprintf("ResourceQuota %s/%s: %s would reach %s of %s (%s used, %s added); what goes over it won't be admitted",
+					namespace, q.Metadata.Name, key, format(key, used+more), format(key, hard), format(key, used), format(key, more)))
+			}
+		}
+	}
+	return warnings
+}
+
+// canonical returns the key a quota key counts as
+func canonical(key string) string {
+	if alias, ok := aliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// format prints v the way quotas on key are written
+func format(key string, v float64) string {
+	switch {
+	case strings.HasSuffix(key, "cpu"):
+		return quantity.CPU(v)
+	case strings.HasSuffix(key, "memory") || strings.HasSuffix(key, "storage"):
+		return quantity.Bytes(v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// parseObjects reads the objects kubectl prints: one object, several
+// objects one after another, or a List
+func parseObjects(data string) ([]object, error) {
+	var objects []object
+	decoder := json.NewDecoder(strings.NewReader(data))
+	for {
+		var o object
+		err := decoder.Decode(&o)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading the dry run: %w", err)
+		}
+		if strings.HasSuffix(o.Kind, "List") {
+			objects = append(objects, o.Items...)
+		} else if o.Kind != "" {
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package quota
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestCheck(t *testing.T) {
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		switch command := strings.Join(args, " "); {
+		case strings.Contains(command, "--dry-run=server"):
+			return `{"kind":"List","items":[
+  {"kind":"Deployment","metadata":{"name":"web","namespace":"shop"},"spec":{"replicas":4,"template":{"spec":{"containers":[
+    {"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}}},
+  {"kind":"Deployment","metadata":{"name":"worker","namespace":"shop"},"spec":{"replicas":1,"template":{"spec":{"containers":[
+    {"name":"app","resources":{"limits":{"cpu":"4"}}}]}}}},
+  {"kind":"Service","metadata":{"name":"web","namespace":"shop"}},
+  {"kind":"ConfigMap","metadata":{"name":"settings","namespace":"open"}}
+]}`, "", 0
+		case strings.Contains(command, "get -n shop resourcequota,limitrange"):
+			return `{"items":[
+  {"kind":"ResourceQuota","metadata":{"name":"compute"},"status":{
+    "hard":{"requests.cpu":"4","limits.memory":"8Gi","pods":"10","services":"2"},
+    "used":{"requests.cpu":"2500m","limits.memory":"1Gi","pods":"6","services":"2"}}},
+  {"kind":"LimitRange","metadata":{"name":"defaults"},"spec":{"limits":[
+    {"type":"Container","defaultRequest":{"cpu":"100m"},"max":{"cpu":"2"}}]}}
+]}`, "", 0
+		case strings.Contains(command, "get -n shop deployment/web deployment/worker service/web"):
+			return `{"kind":"List","items":[
+  {"kind":"Deployment","metadata":{"name":"web","namespace":"shop"},"spec":{"replicas":2,"template":{"spec":{"containers":[
+    {"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}}}
+]}`, "", 0
+		case strings.Contains(command, "get -n open resourcequota,limitrange"):
+			return `{"items":[]}`, "", 0
+		}
+		t.Errorf("unexpected kubectl %v", args)
+		return "", "", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	warnings, err := Check("app-prod", []string{"apply", "-f", "app.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"deployment/web: container app sets no memory limit and no LimitRange gives a default, but ResourceQuota compute needs one; its pods will be rejected",
+		"deployment/worker: container app asks for 4 cpu, over the 2 allowed by LimitRange defaults; its pods will be rejected",
+		"deployment/worker: container app sets no memory limit and no LimitRange gives a default, but ResourceQuota compute needs one; its pods will be rejected",
+		"ResourceQuota shop/compute: requests.cpu would reach 7.5 of 4 (2.5 used, 5 added); what goes over it won't be admitted",
+		"ResourceQuota shop/compute: services would reach 3 of 2 (2 used, 1 added); what goes over it won't be admitted",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Check =\n%s\nwant\n%s", strings.Join(warnings, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -18,7 +18,10 @@ const (
 	ActionCreate  = "create"
 	ActionExec    = "exec"
 	ActionRollout = "rollout"
-	ActionUnknown = "unknown"
+	// ActionNodeAccess is a shell on a node: 'kubectl debug node/...' or a
+	// node shell plugin
+	ActionNodeAccess = "node-access"
+	ActionUnknown    = "unknown"
 )
 
 // DestructiveActions maps kubectl commands to their action type
@@ -36,31 +39,154 @@ var DestructiveActions = map[string]string{
 	"rollout":  ActionRollout,
 }
 
+// ShellPlugins maps kubectl plugins that open a shell to the action whose
+// rules cover them: node-access for a shell on a node, exec for one in a
+// pod. They count as destructive.
+var ShellPlugins = map[string]string{
+	"node-shell": ActionNodeAccess,
+	"ssh-jump":   ActionNodeAccess,
+	"nsenter":    ActionNodeAccess,
+	"exec-as":    ActionExec,
+}
+
+// IsDestructive reports whether an action (as returned by DetectAction)
+// can modify cluster state
+func IsDestructive(action string) bool {
+	if action == ActionNodeAccess || ShellPlugins[action] != "" {
+		return true
+	}
+	for _, a := range DestructiveActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
 // Flags that take a value argument (the next arg is the value, not a command)
 var flagsWithValues = map[string]bool{
-	"-n":              true,
-	"--namespace":     true,
-	"-l":              true,
-	"--selector":      true,
-	"-o":              true,
-	"--output":        true,
-	"-f":              true,
-	"--filename":      true,
-	"--context":       true,
-	"--kubeconfig":    true,
-	"--cluster":       true,
-	"--user":          true,
-	"-c":              true,
-	"--container":     true,
+	"-n":               true,
+	"--namespace":      true,
+	"-l":               true,
+	"--selector":       true,
+	"-o":               true,
+	"--output":         true,
+	"-f":               true,
+	"--filename":       true,
+	"-k":               true,
+	"--kustomize":      true,
+	"--context":        true,
+	"--kubeconfig":     true,
+	"--cluster":        true,
+	"--user":           true,
+	"-c":               true,
+	"--container":      true,
 	"--field-selector": true,
-	"--sort-by":       true,
-	"--template":      true,
-	"-p":              true,
-	"--patch":         true,
-	"--type":          true,
-	"--replicas":      true,
-	"--timeout":       true,
-	"--grace-period":  true,
+	"--sort-by":        true,
+	"--template":       true,
+	"-p":               true,
+	"--patch":          true,
+	"--type":           true,
+	"--replicas":       true,
+	"--timeout":        true,
+	"--grace-period":   true,
+}
+
+// FlagTakesValue reports whether a kubectl flag consumes the following argument
+func FlagTakesValue(flag string) bool {
+	return flagsWithValues[flag]
+}
+
+// Positional returns the non-flag arguments in args (the command followed
+// by its operands), skipping flag values. Arguments after "--" belong to
+// the executed program and are not included.
+func Positional(args []string) []string {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return positional
+		case strings.HasPrefix(arg, "-"):
+			if !strings.Contains(arg, "=") && flagsWithValues[arg] {
+				i++
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional
+}
+
+// FlagValue returns the value of the first of names set in args, given as
+// "--flag value" or "--flag=value"
+func FlagValue(args []string, names ...string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				return args[i+1], true
+			}
+			if value, ok := strings.CutPrefix(arg, name+"="); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// HasFlag reports whether any of names is set in args, including boolean
+// flags written as "--flag=true"
+func HasFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, name := range names {
+			if arg == name || strings.HasPrefix(arg, name+"=") && arg != name+"=false" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddFlags returns args with each of flags ("--name" or "--name=value")
+// added, before any "--", unless args already set that flag. It also
+// returns the flags that were added.
+func AddFlags(args, flags []string) ([]string, []string) {
+	end := len(args)
+	for i, arg := range args {
+		if arg == "--" {
+			end = i
+			break
+		}
+	}
+
+	var added []string
+	for _, flag := range flags {
+		name, _, _ := strings.Cut(flag, "=")
+		set := false
+		for _, arg := range args[:end] {
+			if arg == name || strings.HasPrefix(arg, name+"=") {
+				set = true
+				break
+			}
+		}
+		if !set {
+			added = append(added, flag)
+		}
+	}
+	if len(added) == 0 {
+		return args, nil
+	}
+
+	result := make([]string, 0, len(args)+len(added))
+	result = append(result, args[:end]...)
+	result = append(result, added...)
+	return append(result, args[end:]...), added
 }
 
 // DetectAction analyzes kubectl arguments and returns the action type
@@ -96,6 +222,9 @@ func DetectAction(args []string) string {
 		if action, ok := DestructiveActions[arg]; ok {
 			return action
 		}
+		if arg == "debug" && debugsNode(args) {
+			return ActionNodeAccess
+		}
 
 		// For commands like "kubectl get", the first non-flag is the command
 		// If it's not a known destructive action, it's likely safe
@@ -105,24 +234,75 @@ func DetectAction(args []string) string {
 	return ActionUnknown
 }
 
-// IsBlocked checks if an action is blocked by the rules
-func IsBlocked(action string, rules config.ResolvedRules) bool {
-	for _, blocked := range rules.BlockedActions {
-		if matchAction(blocked, action) {
+// debugsNode reports whether a 'kubectl debug' command targets a node,
+// which starts a privileged pod with the node's filesystem mounted
+func debugsNode(args []string) bool {
+	for _, arg := range Positional(args) {
+		kind, _, found := strings.Cut(arg, "/")
+		if found && (kind == "node" || kind == "nodes" || kind == "no") {
 			return true
 		}
 	}
 	return false
 }
 
-// RequiresConfirmation checks if an action requires confirmation
-func RequiresConfirmation(action string, rules config.ResolvedRules) bool {
-	for _, confirm := range rules.RequireConfirmation {
-		if matchAction(confirm, action) {
-			return true
+// Outcomes returned by Resolve
+const (
+	OutcomeAllow   = "allow"
+	OutcomeConfirm = "confirm"
+	OutcomeBlock   = "block"
+)
+
+// Wildcard in an action list matches every action
+const Wildcard = "*"
+
+// Resolve decides how rules treat an action. Entries naming the action (or
+// an alias of it) win over "*" entries; at each level blocked beats
+// confirmation beats allowed. Actions no entry matches get the rules'
+// default. It returns the outcome and the entry that decided it, or
+// "default" when none did.
+func Resolve(action string, rules config.ResolvedRules) (outcome, rule string) {
+	lists := []struct {
+		outcome string
+		rules   []string
+	}{
+		{OutcomeBlock, rules.BlockedActions},
+		{OutcomeConfirm, rules.RequireConfirmation},
+		{OutcomeAllow, rules.AllowedActions},
+	}
+
+	for _, wildcard := range []bool{false, true} {
+		for _, list := range lists {
+			for _, r := range list.rules {
+				if (r == Wildcard) != wildcard {
+					continue
+				}
+				if wildcard || matchAction(r, action) {
+					return list.outcome, r
+				}
+			}
 		}
 	}
-	return false
+
+	switch rules.Default {
+	case config.DefaultDeny:
+		return OutcomeBlock, "default"
+	case config.DefaultConfirm:
+		return OutcomeConfirm, "default"
+	}
+	return OutcomeAllow, "default"
+}
+
+// IsBlocked checks if an action is blocked by the rules
+func IsBlocked(action string, rules config.ResolvedRules) bool {
+	outcome, _ := Resolve(action, rules)
+	return outcome == OutcomeBlock
+}
+
+// RequiresConfirmation checks if an action requires confirmation
+func RequiresConfirmation(action string, rules config.ResolvedRules) bool {
+	outcome, _ := Resolve(action, rules)
+	return outcome == OutcomeConfirm
 }
 
 // matchAction checks if an action matches a rule
@@ -150,7 +330,9 @@ func matchAction(rule, action string) bool {
 	case ActionApply:
 		return action == ActionApply || action == ActionCreate
 	case ActionExec:
-		return action == ActionExec
+		return action == ActionExec || ShellPlugins[action] == ActionExec
+	case ActionNodeAccess:
+		return action == ActionNodeAccess || ShellPlugins[action] == ActionNodeAccess
 	case ActionRollout:
 		return action == ActionRollout
 	}
@@ -158,20 +340,317 @@ func matchAction(rule, action string) bool {
 	return false
 }
 
-// GetActionSeverity returns a severity level for display purposes
-func GetActionSeverity(action string) string {
-	switch action {
-	case ActionDelete, ActionDrain:
-		return "high"
-	case ActionScale, ActionCordon:
-		return "medium"
-	case ActionEdit, ActionPatch, ActionRollout:
-		return "medium"
-	case ActionApply, ActionCreate:
-		return "low"
-	default:
-		return "none"
+// DefaultSeverities are the built-in severities of actions. Actions not
+// listed are "none"; severity.actions in the config replaces entries.
+var DefaultSeverities = map[string]string{
+	ActionDelete: "high", ActionDrain: "high", ActionNodeAccess: "high",
+	ActionScale: "medium", ActionCordon: "medium",
+	ActionEdit: "medium", ActionPatch: "medium", ActionRollout: "medium",
+	ActionApply: "low", ActionCreate: "low",
+}
+
+// GetActionSeverity returns the severity of an action from the config's
+// table, falling back to DefaultSeverities. A shell plugin has the
+// severity of the action it gives unless the table names the plugin.
+func GetActionSeverity(action string, table config.SeverityConfig) string {
+	if level, ok := table.Actions[action]; ok {
+		return level
 	}
+	if covered, ok := ShellPlugins[action]; ok {
+		action = covered
+		if level, ok := table.Actions[action]; ok {
+			return level
+		}
+	}
+	if level, ok := DefaultSeverities[action]; ok {
+		return level
+	}
+	return "none"
+}
+
+// SeverityCritical marks commands whose damage reaches beyond single
+// workloads; see CommandSeverity
+const SeverityCritical = "critical"
+
+// criticalKinds are resources whose deletion takes everything in or on them
+// with it
+var criticalKinds = map[string]bool{
+	"namespace": true, "namespaces": true, "ns": true,
+	"node": true, "nodes": true, "no": true,
+	"persistentvolume": true, "persistentvolumes": true, "pv": true,
+	"customresourcedefinition": true, "customresourcedefinitions": true, "crd": true, "crds": true,
+}
+
+// CommandSeverity is GetActionSeverity refined by the arguments: deleting
+// namespaces, nodes, persistent volumes or CRDs, or deleting with --all or
+// across all namespaces, is critical. The table's kinds then raise it for
+// the kind the command operates on.
+func CommandSeverity(args []string, table config.SeverityConfig) string {
+	kind, _ := ResourceRef(args)
+	return Severity(DetectAction(args), kind, args, table)
+}
+
+// Severity is CommandSeverity for an action and kind the caller has
+// already resolved, e.g. a plugin's name or a kind from the cluster's
+// api-resources
+func Severity(action, kind string, args []string, table config.SeverityConfig) string {
+	severity := GetActionSeverity(action, table)
+	if action == ActionDelete && isCriticalDelete(args) {
+		severity = SeverityCritical
+	}
+	if bump := table.Kinds[kind]; bump != 0 && kind != "" {
+		rank := config.SeverityRank(severity) + bump
+		rank = max(0, min(rank, len(config.SeverityLevels)-1))
+		severity = config.SeverityLevels[rank]
+	}
+	return severity
+}
+
+// isCriticalDelete reports whether a delete removes a critical kind or
+// everything of a kind
+func isCriticalDelete(args []string) bool {
+	if HasFlag(args, "--all", "--all-namespaces", "-A") {
+		return true
+	}
+	positional := Positional(args)
+	if len(positional) > 1 {
+		for _, ref := range strings.Split(positional[1], ",") {
+			kind, _, _ := strings.Cut(ref, "/")
+			kind, _, _ = strings.Cut(kind, ".")
+			if criticalKinds[strings.ToLower(kind)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shortNames maps kubectl's short resource names to singular kinds
+var shortNames = map[string]string{
+	"cm": "configmap", "cj": "cronjob", "crd": "customresourcedefinition",
+	"deploy": "deployment", "ds": "daemonset", "ep": "endpoints",
+	"hpa": "horizontalpodautoscaler", "ing": "ingress", "netpol": "networkpolicy",
+	"no": "node", "ns": "namespace", "pdb": "poddisruptionbudget", "po": "pod",
+	"pv": "persistentvolume", "pvc": "persistentvolumeclaim", "rs": "replicaset",
+	"sa": "serviceaccount", "sts": "statefulset", "svc": "service",
+}
+
+// clusterScoped are kinds that don't live in a namespace
+var clusterScoped = map[string]bool{
+	"namespace": true, "node": true, "persistentvolume": true,
+	"customresourcedefinition": true, "clusterrole": true, "clusterrolebinding": true,
+	"storageclass": true, "priorityclass": true, "ingressclass": true, "runtimeclass": true,
+	"validatingwebhookconfiguration": true, "mutatingwebhookconfiguration": true,
+	"apiservice": true, "certificatesigningrequest": true,
+	"csidriver": true, "csinode": true, "volumeattachment": true,
+}
+
+// HasExplicitNamespace reports whether args choose a namespace with -n,
+// --namespace or --all-namespaces rather than relying on the context's
+func HasExplicitNamespace(args []string) bool {
+	if _, ok := Namespace(args); ok {
+		return true
+	}
+	return HasFlag(args, "-A", "--all-namespaces")
+}
+
+// Namespace returns the namespace args select with -n or --namespace,
+// including the short flag joined to its value as kubectl accepts it:
+// -nprod
+func Namespace(args []string) (string, bool) {
+	if namespace, ok := FlagValue(args, "-n", "--namespace"); ok {
+		return namespace, true
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-n") && len(arg) > 2 && !strings.HasPrefix(arg, "--") {
+			return arg[2:], true
+		}
+	}
+	return "", false
+}
+
+// IsClusterScoped reports whether a command operates on a cluster-scoped
+// kind, where a namespace means nothing
+func IsClusterScoped(args []string) bool {
+	kind, _ := ResourceRef(args)
+	return ClusterScopedKind(kind)
+}
+
+// ClusterScopedKind reports whether a singular kind is one of the built-in
+// kinds that don't live in a namespace
+func ClusterScopedKind(kind string) bool {
+	return clusterScoped[kind]
+}
+
+// DeletesEverywhere reports whether args delete every object of a kind in
+// every namespace: a delete, not a dry run, with both --all and
+// --all-namespaces
+func DeletesEverywhere(args []string) bool {
+	return DetectAction(args) == ActionDelete && !IsDryRun(args) &&
+		HasFlag(args, "--all") && HasFlag(args, "-A", "--all-namespaces")
+}
+
+// IsUnqualifiedDelete reports whether a delete names no resources and has
+// no selector or -f/-k, so it removes everything of a kind. --all counts as
+// qualified only with an explicit namespace, never across all namespaces.
+func IsUnqualifiedDelete(args []string) bool {
+	if DetectAction(args) != ActionDelete {
+		return false
+	}
+	if HasFlag(args, "-f", "--filename", "-k", "--kustomize", "-l", "--selector", "--field-selector") {
+		return false
+	}
+	if HasFlag(args, "--all") {
+		return !HasExplicitNamespace(args) || HasFlag(args, "-A", "--all-namespaces")
+	}
+	positional := Positional(args)
+	if len(positional) < 2 {
+		return true
+	}
+	// "delete pod/x" or "delete pod x" name something; "delete pods" alone doesn't
+	return !strings.Contains(positional[1], "/") && len(positional) < 3
+}
+
+// DeletedNamespaces returns the namespaces a delete names on its command
+// line, from "namespace a b" or "ns/a" operands. Namespaces matched by a
+// selector or --all, or listed in -f manifests, aren't included.
+func DeletedNamespaces(args []string) []string {
+	if DetectAction(args) != ActionDelete || IsDryRun(args) {
+		return nil
+	}
+	positional := Positional(args)
+	if len(positional) < 2 {
+		return nil
+	}
+	operands := positional[1:]
+	if !strings.Contains(operands[0], "/") {
+		kind, _, _ := strings.Cut(strings.ToLower(operands[0]), ".")
+		if singular(kind) != "namespace" || len(operands) < 2 {
+			return nil
+		}
+		return operands[1:]
+	}
+	var names []string
+	for _, ref := range operands {
+		kind, name, _ := strings.Cut(ref, "/")
+		kind, _, _ = strings.Cut(strings.ToLower(kind), ".")
+		if singular(kind) == "namespace" && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsDryRun reports whether a command only simulates its changes with
+// --dry-run=client or --dry-run=server
+func IsDryRun(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--dry-run" || strings.HasPrefix(arg, "--dry-run=") && arg != "--dry-run=none" {
+			return true
+		}
+	}
+	return false
+}
+
+// Filenames returns the values of every -f/--filename flag in args
+func Filenames(args []string) []string {
+	return flagValues(args, "-f", "--filename")
+}
+
+// Kustomizations returns the values of every -k/--kustomize flag in args
+func Kustomizations(args []string) []string {
+	return flagValues(args, "-k", "--kustomize")
+}
+
+// flagValues returns the values of every use of a flag in args, including
+// the short flag joined to its value as kubectl accepts it: -fapp.yaml
+func flagValues(args []string, short, long string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return values
+		case (arg == short || arg == long) && i+1 < len(args):
+			values = append(values, args[i+1])
+			i++
+		case strings.HasPrefix(arg, long+"="):
+			values = append(values, strings.TrimPrefix(arg, long+"="))
+		case strings.HasPrefix(arg, short+"="):
+			values = append(values, strings.TrimPrefix(arg, short+"="))
+		case strings.HasPrefix(arg, short) && len(arg) > len(short) && !strings.HasPrefix(arg, "--"):
+			values = append(values, strings.TrimPrefix(arg, short))
+		}
+	}
+	return values
+}
+
+// nameFirst maps commands whose first operand is a name, not a kind, to
+// the kind they operate on
+var nameFirst = map[string]string{
+	"drain": "node", "cordon": "node", "uncordon": "node",
+	"exec": "pod", "logs": "pod", "attach": "pod", "port-forward": "pod",
+}
+
+// ResourceRef returns the singular kind and the name of the first resource
+// a command operates on, from "kind name" or "kind/name" operands. Either
+// may be empty.
+func ResourceRef(args []string) (kind, name string) {
+	resource, name := ResourceName(args)
+	return Kind(resource), name
+}
+
+// ResourceName returns the resource type as typed (lowercased, possibly a
+// short name, plural or group-qualified like "certificates.cert-manager.io")
+// and the name of the first resource a command operates on. Either may be
+// empty.
+func ResourceName(args []string) (resource, name string) {
+	positional := Positional(args)
+	if len(positional) < 2 {
+		return "", ""
+	}
+	ref := strings.Split(positional[1], ",")[0]
+	if k, ok := nameFirst[positional[0]]; ok && !strings.Contains(ref, "/") {
+		return k, ref
+	}
+	resource, name, _ = strings.Cut(ref, "/")
+	if name == "" && len(positional) > 2 {
+		name = positional[2]
+	}
+	return strings.ToLower(resource), name
+}
+
+// Kind turns a resource type as typed into the singular kind using
+// kubectl's built-in short names, dropping any API group. Names only a
+// cluster knows, like CRD short names, need 'kubectl api-resources'.
+func Kind(resource string) string {
+	resource, _, _ = strings.Cut(resource, ".")
+	if resource == "" {
+		return ""
+	}
+	return singular(resource)
+}
+
+// singular turns a short or plural resource name into the singular kind
+func singular(kind string) string {
+	if full, ok := shortNames[kind]; ok {
+		return full
+	}
+	switch {
+	case kind == "endpoints":
+		return kind
+	case strings.HasSuffix(kind, "ies"):
+		return strings.TrimSuffix(kind, "ies") + "y"
+	case strings.HasSuffix(kind, "sses"):
+		return strings.TrimSuffix(kind, "es")
+	}
+	return strings.TrimSuffix(kind, "s")
 }
 
 // DescribeAction returns a human-readable description of the action
@@ -197,8 +676,15 @@ func DescribeAction(action string) string {
 		return "Execute command in pod"
 	case ActionRollout:
 		return "Manage rollout"
+	case ActionNodeAccess:
+		return "Open a shell on a node"
 	default:
+		switch ShellPlugins[action] {
+		case ActionNodeAccess:
+			return "Open a shell on a node (" + action + " plugin)"
+		case ActionExec:
+			return "Execute command in pod (" + action + " plugin)"
+		}
 		return action
 	}
 }
-
@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
@@ -121,6 +122,16 @@ func TestDetectAction(t *testing.T) {
 			args:     []string{"rollout", "restart", "deployment/app"},
 			expected: ActionRollout,
 		},
+		{
+			name:     "debug node",
+			args:     []string{"debug", "-it", "node/worker-1", "--image=busybox"},
+			expected: ActionNodeAccess,
+		},
+		{
+			name:     "debug pod",
+			args:     []string{"debug", "-it", "web-1", "--image=busybox"},
+			expected: "debug",
+		},
 		{
 			name:     "patch action",
 			args:     []string{"patch", "deployment", "app", "-p", `{"spec":{"replicas":3}}`},
@@ -326,6 +337,11 @@ func TestMatchAction(t *testing.T) {
 		{"edit covers patch", "edit", "patch", true},
 		{"apply covers apply", "apply", "apply", true},
 		{"apply covers create", "apply", "create", true},
+		{"exec covers pod shell plugins", "exec", "exec-as", true},
+		{"exec doesn't cover node shells", "exec", "node-shell", false},
+		{"node-access covers node shell plugins", "node-access", "node-shell", true},
+		{"node-access covers debug node", "node-access", "node-access", true},
+		{"node-access doesn't cover other plugins", "node-access", "neat", false},
 	}
 
 	for _, tt := range tests {
@@ -366,6 +382,21 @@ func TestDescribeAction(t *testing.T) {
 	}
 }
 
+func TestSeverityActionsValidate(t *testing.T) {
+	// Every action and shell plugin can be given a severity
+	actions := map[string]string{ActionNodeAccess: "high"}
+	for command, action := range DestructiveActions {
+		actions[command], actions[action] = "high", "high"
+	}
+	for plugin := range ShellPlugins {
+		actions[plugin] = "high"
+	}
+	cfg := config.Config{Severity: config.SeverityConfig{Actions: actions}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
 func TestGetActionSeverity(t *testing.T) {
 	tests := []struct {
 		action   string
@@ -380,13 +411,15 @@ func TestGetActionSeverity(t *testing.T) {
 		{ActionRollout, "medium"},
 		{ActionApply, "low"},
 		{ActionCreate, "low"},
+		{ActionNodeAccess, "high"},
+		{"node-shell", "high"},
 		{"get", "none"},
 		{"describe", "none"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.action, func(t *testing.T) {
-			result := GetActionSeverity(tt.action)
+			result := GetActionSeverity(tt.action, config.SeverityConfig{})
 			if result != tt.expected {
 				t.Errorf("GetActionSeverity(%q) = %q, want %q", tt.action, result, tt.expected)
 			}
@@ -394,3 +427,333 @@ func TestGetActionSeverity(t *testing.T) {
 	}
 }
 
+func TestCommandSeverity(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"delete", "pod", "web-1"}, "high"},
+		{[]string{"delete", "ns", "team-a"}, SeverityCritical},
+		{[]string{"delete", "namespace/team-a"}, SeverityCritical},
+		{[]string{"-n", "x", "delete", "pvc,pv", "data"}, SeverityCritical},
+		{[]string{"delete", "crd", "widgets.example.com"}, SeverityCritical},
+		{[]string{"delete", "customresourcedefinitions.apiextensions.k8s.io", "widgets"}, SeverityCritical},
+		{[]string{"delete", "pods", "--all"}, SeverityCritical},
+		{[]string{"delete", "pods", "-A", "-l", "app=x"}, SeverityCritical},
+		{[]string{"drain", "node-1"}, "high"},
+		{[]string{"get", "ns"}, "none"},
+	}
+
+	for _, tt := range tests {
+		if got := CommandSeverity(tt.args, config.SeverityConfig{}); got != tt.expected {
+			t.Errorf("CommandSeverity(%v) = %q, want %q", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestCommandSeverity_Table(t *testing.T) {
+	table := config.SeverityConfig{
+		Actions: map[string]string{"exec": "high", "delete": "medium", "node-shell": "critical"},
+		Kinds:   map[string]int{"secret": 1, "deployment": 5, "configmap": -1},
+	}
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"exec", "-it", "web", "--", "sh"}, "high"},
+		{[]string{"delete", "pod", "web-1"}, "medium"},
+		{[]string{"delete", "ns", "team-a"}, SeverityCritical},
+		{[]string{"get", "secrets"}, "low"},
+		{[]string{"delete", "secret", "tls"}, "high"},
+		{[]string{"scale", "deploy", "web", "--replicas=0"}, SeverityCritical},
+		{[]string{"get", "cm"}, "none"},
+	}
+	for _, tt := range tests {
+		if got := CommandSeverity(tt.args, table); got != tt.expected {
+			t.Errorf("CommandSeverity(%v) = %q, want %q", tt.args, got, tt.expected)
+		}
+	}
+	if got := GetActionSeverity("node-shell", table); got != SeverityCritical {
+		t.Errorf("GetActionSeverity(node-shell) = %q, want the table's entry for the plugin", got)
+	}
+	if got := GetActionSeverity("nsenter", table); got != "high" {
+		t.Errorf("GetActionSeverity(nsenter) = %q, want node access's built-in severity", got)
+	}
+}
+
+func TestIsDestructive(t *testing.T) {
+	tests := []struct {
+		action   string
+		expected bool
+	}{
+		{ActionDelete, true},
+		{ActionCordon, true},
+		{ActionExec, true},
+		{"node-shell", true},
+		{"get", false},
+		{"logs", false},
+		{ActionUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			if result := IsDestructive(tt.action); result != tt.expected {
+				t.Errorf("IsDestructive(%q) = %v, want %v", tt.action, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	locked := config.ResolvedRules{
+		RequireConfirmation: []string{"*"},
+		AllowedActions:      []string{"get", "describe", "logs"},
+		BlockedActions:      []string{"delete"},
+	}
+	denyByDefault := config.ResolvedRules{
+		RequireConfirmation: []string{"scale"},
+		AllowedActions:      []string{"get"},
+		Default:             config.DefaultDeny,
+	}
+
+	tests := []struct {
+		name     string
+		action   string
+		rules    config.ResolvedRules
+		expected string
+		rule     string
+	}{
+		{"allowed exception to wildcard", "get", locked, OutcomeAllow, "get"},
+		{"wildcard confirmation", "exec", locked, OutcomeConfirm, "*"},
+		{"explicit block beats wildcard", ActionDelete, locked, OutcomeBlock, "delete"},
+		{"default deny", "exec", denyByDefault, OutcomeBlock, "default"},
+		{"listed action under default deny", ActionScale, denyByDefault, OutcomeConfirm, "scale"},
+		{"allowed under default deny", "get", denyByDefault, OutcomeAllow, "get"},
+		{"default confirm", "get", config.ResolvedRules{Default: config.DefaultConfirm}, OutcomeConfirm, "default"},
+		{"no rules", "get", config.ResolvedRules{}, OutcomeAllow, "default"},
+		{"wildcard block beats wildcard allow", "get", config.ResolvedRules{BlockedActions: []string{"*"}, AllowedActions: []string{"*"}}, OutcomeBlock, "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, rule := Resolve(tt.action, tt.rules)
+			if outcome != tt.expected || rule != tt.rule {
+				t.Errorf("Resolve(%q) = %q, %q; want %q, %q", tt.action, outcome, rule, tt.expected, tt.rule)
+			}
+		})
+	}
+}
+
+func TestPositional(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-n", "web", "delete", "pod", "a", "b", "--grace-period=0"}, []string{"delete", "pod", "a", "b"}},
+		{[]string{"scale", "--replicas", "3", "deploy/app"}, []string{"scale", "deploy/app"}},
+		{[]string{"exec", "pod", "--", "rm", "-rf", "/"}, []string{"exec", "pod"}},
+		{[]string{"--context=prod", "get", "pods", "-o", "wide"}, []string{"get", "pods"}},
+	}
+	for _, tt := range tests {
+		got := Positional(tt.args)
+		if len(got) != len(tt.want) {
+			t.Errorf("Positional(%v) = %v, want %v", tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Positional(%v) = %v, want %v", tt.args, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	args := []string{"scale", "deploy/app", "--replicas=3", "-n", "web", "--", "--replicas=9"}
+	if v, ok := FlagValue(args, "--replicas"); !ok || v != "3" {
+		t.Errorf("FlagValue(--replicas) = %q, %v; want 3", v, ok)
+	}
+	if v, ok := FlagValue(args, "-n", "--namespace"); !ok || v != "web" {
+		t.Errorf("FlagValue(-n) = %q, %v; want web", v, ok)
+	}
+	if _, ok := FlagValue(args, "--selector"); ok {
+		t.Error("FlagValue(--selector) should be unset")
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	if !HasFlag([]string{"delete", "pods", "--all"}, "--all") {
+		t.Error("HasFlag(--all) = false, want true")
+	}
+	if HasFlag([]string{"delete", "pods", "--all=false"}, "--all") {
+		t.Error("HasFlag(--all=false) = true, want false")
+	}
+	if HasFlag([]string{"exec", "pod", "--", "ls", "--all"}, "--all") {
+		t.Error("HasFlag should stop at --")
+	}
+}
+
+func TestResourceRef(t *testing.T) {
+	tests := []struct {
+		args       []string
+		kind, name string
+	}{
+		{[]string{"delete", "deployment", "web"}, "deployment", "web"},
+		{[]string{"-n", "prod", "delete", "deploy/web"}, "deployment", "web"},
+		{[]string{"delete", "deployments.apps", "web", "api"}, "deployment", "web"},
+		{[]string{"delete", "ingresses", "edge"}, "ingress", "edge"},
+		{[]string{"delete", "networkpolicies", "-l", "app=x"}, "networkpolicy", ""},
+		{[]string{"drain", "node-1"}, "node", "node-1"},
+		{[]string{"exec", "-it", "deploy/web", "--", "sh"}, "deployment", "web"},
+		{[]string{"get"}, "", ""},
+	}
+	for _, tt := range tests {
+		kind, name := ResourceRef(tt.args)
+		if kind != tt.kind || name != tt.name {
+			t.Errorf("ResourceRef(%v) = %q, %q, want %q, %q", tt.args, kind, name, tt.kind, tt.name)
+		}
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	tests := []struct {
+		args           []string
+		resource, name string
+	}{
+		{[]string{"delete", "Certificates.cert-manager.io", "web"}, "certificates.cert-manager.io", "web"},
+		{[]string{"delete", "cert/web"}, "cert", "web"},
+		{[]string{"drain", "node-1"}, "node", "node-1"},
+		{[]string{"get"}, "", ""},
+	}
+	for _, tt := range tests {
+		resource, name := ResourceName(tt.args)
+		if resource != tt.resource || name != tt.name {
+			t.Errorf("ResourceName(%v) = %q, %q, want %q, %q", tt.args, resource, name, tt.resource, tt.name)
+		}
+	}
+	if Kind("certificates.cert-manager.io") != "certificate" || Kind("") != "" {
+		t.Error("Kind misnormalized a resource")
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	flags := []string{"--wait=true", "--timeout=120s"}
+	tests := []struct {
+		args, want, added []string
+	}{
+		{[]string{"delete", "pod", "x"}, []string{"delete", "pod", "x", "--wait=true", "--timeout=120s"}, flags},
+		{[]string{"delete", "pod", "x", "--timeout", "5s"}, []string{"delete", "pod", "x", "--timeout", "5s", "--wait=true"}, []string{"--wait=true"}},
+		{[]string{"delete", "pod", "x", "--wait=false", "--timeout=1s"}, []string{"delete", "pod", "x", "--wait=false", "--timeout=1s"}, nil},
+		{[]string{"exec", "p", "--", "sh", "--wait"}, []string{"exec", "p", "--wait=true", "--timeout=120s", "--", "sh", "--wait"}, flags},
+	}
+	for _, tt := range tests {
+		got, added := AddFlags(tt.args, flags)
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(added, tt.added) {
+			t.Errorf("AddFlags(%v) = %v, %v, want %v, %v", tt.args, got, added, tt.want, tt.added)
+		}
+	}
+}
+
+func TestIsUnqualifiedDelete(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"delete", "pod", "x"}, false},
+		{[]string{"delete", "pod/x"}, false},
+		{[]string{"delete", "pods", "-l", "app=web"}, false},
+		{[]string{"delete", "-f", "manifest.yaml"}, false},
+		{[]string{"delete", "pods", "--all", "-n", "team-a"}, false},
+		{[]string{"delete", "pods", "--all", "-nteam-a"}, false},
+		{[]string{"delete", "pods", "--all", "--namespace=team-a"}, false},
+		{[]string{"delete", "pods", "--all"}, true},
+		{[]string{"delete", "pods", "--all", "-A"}, true},
+		{[]string{"delete", "pods"}, true},
+		{[]string{"get", "pods"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsUnqualifiedDelete(tt.args); got != tt.want {
+			t.Errorf("IsUnqualifiedDelete(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestHasExplicitNamespace(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"delete", "pod", "x", "-n", "prod"}, true},
+		{[]string{"--namespace=prod", "delete", "pod", "x"}, true},
+		{[]string{"delete", "pod", "x", "-nprod"}, true},
+		{[]string{"get", "pods", "-A"}, true},
+		{[]string{"delete", "pod", "x"}, false},
+		{[]string{"exec", "x", "--", "sh", "-n", "y"}, false},
+	}
+	for _, tt := range tests {
+		if got := HasExplicitNamespace(tt.args); got != tt.want {
+			t.Errorf("HasExplicitNamespace(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+	if !IsClusterScoped([]string{"delete", "ns", "x"}) || !IsClusterScoped([]string{"drain", "node-1"}) || IsClusterScoped([]string{"delete", "pod", "x"}) {
+		t.Error("IsClusterScoped misclassified a kind")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		ok   bool
+	}{
+		{[]string{"get", "pods", "-n", "shop"}, "shop", true},
+		{[]string{"get", "pods", "--namespace=shop"}, "shop", true},
+		{[]string{"get", "pods", "-n=shop"}, "shop", true},
+		{[]string{"get", "pods", "-nshop"}, "shop", true},
+		{[]string{"get", "pods", "-A"}, "", false},
+		{[]string{"exec", "x", "--", "sh", "-nshop"}, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := Namespace(tt.args); got != tt.want || ok != tt.ok {
+			t.Errorf("Namespace(%v) = %q, %v, want %q, %v", tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDeletedNamespaces(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"delete", "namespace", "payments"}, []string{"payments"}},
+		{[]string{"delete", "ns", "a", "b", "--wait=false"}, []string{"a", "b"}},
+		{[]string{"delete", "Namespaces.v1", "a"}, []string{"a"}},
+		{[]string{"delete", "ns/a", "pod/x", "namespace/b"}, []string{"a", "b"}},
+		{[]string{"delete", "ns", "-l", "team=x"}, nil},
+		{[]string{"delete", "ns", "a", "--dry-run=server"}, nil},
+		{[]string{"delete", "pod", "ns"}, nil},
+		{[]string{"get", "ns", "a"}, nil},
+	}
+	for _, tt := range tests {
+		if got := DeletedNamespaces(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DeletedNamespaces(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFilenames(t *testing.T) {
+	args := []string{"delete", "-f", "a.yaml", "--filename=b.yaml", "-f=c.yaml", "-fd.yaml", "--", "-f", "e.yaml"}
+	if got := Filenames(args); !reflect.DeepEqual(got, []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml"}) {
+		t.Errorf("Filenames = %v", got)
+	}
+	if got := Kustomizations([]string{"apply", "-k", "overlays/prod", "--kustomize=base", "-kgithub.com/org/repo"}); !reflect.DeepEqual(got, []string{"overlays/prod", "base", "github.com/org/repo"}) {
+		t.Errorf("Kustomizations = %v", got)
+	}
+	if got := Positional([]string{"apply", "-k", "overlays/prod"}); !reflect.DeepEqual(got, []string{"apply"}) {
+		t.Errorf("Positional took the -k directory for an operand: %v", got)
+	}
+	if IsDryRun([]string{"apply", "-f", "x", "--dry-run=none"}) || !IsDryRun([]string{"apply", "--dry-run=client"}) {
+		t.Error("IsDryRun misread --dry-run")
+	}
+}
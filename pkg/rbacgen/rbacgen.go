@@ -0,0 +1,291 @@
+// Package rbacgen approximates a tier's kctl rules with Kubernetes RBAC: a
+// role granting read access plus every action the rules don't block, bound
+// to a group
+package rbacgen
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Options control the generated manifests
+type Options struct {
+	// Group the role is bound to
+	Group string
+	// Name of the role and binding. Default: kctl-<tier>
+	Name string
+	// Namespace makes a Role and RoleBinding in it instead of a ClusterRole
+	// and ClusterRoleBinding
+	Namespace string
+}
+
+// PolicyRule is an RBAC rule
+type PolicyRule struct {
+	APIGroups []string `yaml:"apiGroups"`
+	Resources []string `yaml:"resources"`
+	Verbs     []string `yaml:"verbs"`
+}
+
+// resources are what the role grants reads and changes on, by API group.
+// They are listed rather than "*" so the role never covers secrets, and
+// subresources like pods/exec only come with the action that needs them.
+var resources = []PolicyRule{
+	{APIGroups: []string{""}, Resources: []string{
+		"configmaps", "endpoints", "events", "limitranges", "namespaces", "nodes", "persistentvolumeclaims",
+		"persistentvolumes", "pods", "replicationcontrollers", "resourcequotas", "serviceaccounts", "services",
+	}},
+	{APIGroups: []string{"apps"}, Resources: []string{"daemonsets", "deployments", "replicasets", "statefulsets"}},
+	{APIGroups: []string{"autoscaling"}, Resources: []string{"horizontalpodautoscalers"}},
+	{APIGroups: []string{"batch"}, Resources: []string{"cronjobs", "jobs"}},
+	{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses", "networkpolicies"}},
+	{APIGroups: []string{"policy"}, Resources: []string{"poddisruptionbudgets"}},
+}
+
+// on grants verbs on every resource in resources
+func on(verbs ...string) []PolicyRule {
+	rules := make([]PolicyRule, len(resources))
+	for i, r := range resources {
+		rules[i] = PolicyRule{APIGroups: r.APIGroups, Resources: r.Resources, Verbs: verbs}
+	}
+	return rules
+}
+
+// readRules are granted whatever the rules say; kctl has nothing to gate on
+// reads beyond listing them by name
+var readRules = append(on("get", "list", "watch"),
+	PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get", "list", "watch"}})
+
+// grants maps actions to the RBAC rules that allow them
+var grants = map[string][]PolicyRule{
+	rbac.ActionDelete: on("delete", "deletecollection"),
+	rbac.ActionDrain:  {{APIGroups: []string{""}, Resources: []string{"pods/eviction"}, Verbs: []string{"create"}}},
+	rbac.ActionCordon: {{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"patch", "update"}}},
+	rbac.ActionScale: {
+		{APIGroups: []string{""}, Resources: []string{"replicationcontrollers/scale"}, Verbs: []string{"patch", "update"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments/scale", "replicasets/scale", "statefulsets/scale"}, Verbs: []string{"patch", "update"}},
+	},
+	rbac.ActionEdit:   on("patch", "update"),
+	rbac.ActionPatch:  on("patch", "update"),
+	rbac.ActionApply:  on("create", "patch", "update"),
+	rbac.ActionCreate: on("create"),
+	rbac.ActionExec:   {{APIGroups: []string{""}, Resources: []string{"pods/exec", "pods/attach"}, Verbs: []string{"create", "get"}}},
+	rbac.ActionRollout: {{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets", "daemonsets"},
+		Verbs: []string{"patch", "update"}}},
+}
+
+// order lists the actions in grants, most common first
+var order = []string{
+	rbac.ActionDelete, rbac.ActionDrain, rbac.ActionCordon, rbac.ActionScale, rbac.ActionEdit,
+	rbac.ActionPatch, rbac.ActionApply, rbac.ActionCreate, rbac.ActionExec, rbac.ActionRollout,
+}
+
+// Plan lists how the rules translate to RBAC
+type Plan struct {
+	Tier string
+	// Granted are the actions the role allows, confirmed ones included
+	Granted []string
+	// Denied are the blocked actions the role leaves out
+	Denied []string
+	// Rules are the role's RBAC rules
+	Rules []PolicyRule
+	// Caveats describe where the role differs from the kctl rules
+	Caveats []string
+}
+
+// NewPlan translates rules into RBAC
+func NewPlan(rules config.ResolvedRules) Plan {
+	plan := Plan{Tier: rules.Tier, Rules: append([]PolicyRule{}, readRules...)}
+	var confirmed []string
+	for _, action := range order {
+		outcome, _ := rbac.Resolve(action, rules)
+		if outcome == rbac.OutcomeBlock {
+			plan.Denied = append(plan.Denied, action)
+			continue
+		}
+		plan.Granted = append(plan.Granted, action)
+		if outcome == rbac.OutcomeConfirm {
+			confirmed = append(confirmed, action)
+		}
+		for _, rule := range grants[action] {
+			if !coveredBy(plan.Rules, rule) {
+				plan.Rules = append(plan.Rules, rule)
+			}
+		}
+	}
+	plan.Rules = combine(prune(plan.Rules))
+
+	if len(confirmed) > 0 {
+		plan.Caveats = append(plan.Caveats, "granted without confirmation: "+strings.Join(confirmed, ", "))
+	}
+	// A broader grant for one action also allows another that is blocked
+	for _, denied := range plan.Denied {
+		for _, want := range grants[denied] {
+			if coveredBy(plan.Rules, want) {
+				plan.Caveats = append(plan.Caveats, fmt.Sprintf("%s is blocked but allowed by the other grants", denied))
+				break
+			}
+		}
+	}
+	plan.Caveats = append(plan.Caveats, "secrets and custom resources are left out; grant them separately where needed")
+	for _, action := range rules.BlockedActions {
+		if _, ok := grants[action]; !ok && action != rbac.Wildcard {
+			plan.Caveats = append(plan.Caveats, fmt.Sprintf("%s is blocked but not expressible in RBAC", action))
+		}
+	}
+	return plan
+}
+
+// Generate renders the role and binding for plan as a multi-document YAML
+// manifest
+func Generate(plan Plan, opts Options) ([]byte, error) {
+	if opts.Group == "" {
+		return nil, fmt.Errorf("a group to bind the role to is required")
+	}
+	if opts.Name == "" {
+		opts.Name = "kctl-" + plan.Tier
+	}
+
+	roleKind, bindingKind := "ClusterRole", "ClusterRoleBinding"
+	metadata := map[string]string{"name": opts.Name}
+	if opts.Namespace != "" {
+		roleKind, bindingKind = "Role", "RoleBinding"
+		metadata["namespace"] = opts.Namespace
+	}
+
+	role := struct {
+		APIVersion string            `yaml:"apiVersion"`
+		Kind       string            `yaml:"kind"`
+		Metadata   map[string]string `yaml:"metadata"`
+		Rules      []PolicyRule      `yaml:"rules"`
+	}{"rbac.authorization.k8s.io/v1", roleKind, metadata, plan.Rules}
+	binding := struct {
+		APIVersion string              `yaml:"apiVersion"`
+		Kind       string              `yaml:"kind"`
+		Metadata   map[string]string   `yaml:"metadata"`
+		Subjects   []map[string]string `yaml:"subjects"`
+		RoleRef    map[string]string   `yaml:"roleRef"`
+	}{
+		"rbac.authorization.k8s.io/v1", bindingKind, metadata,
+		[]map[string]string{{"kind": "Group", "name": opts.Group, "apiGroup": "rbac.authorization.k8s.io"}},
+		map[string]string{"kind": roleKind, "name": opts.Name, "apiGroup": "rbac.authorization.k8s.io"},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header(plan))
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range []any{role, binding} {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// header explains how the manifest approximates the rules
+func header(plan Plan) string {
+	lines := []string{
+		fmt.Sprintf("# Generated by 'kctl generate rbac' from tier '%s'", plan.Tier),
+		"# Grants: read access, " + orNone(plan.Granted),
+		"# Leaves out: " + orNone(plan.Denied),
+	}
+	for _, caveat := range plan.Caveats {
+		lines = append(lines, "# Note: "+caveat)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func orNone(actions []string) string {
+	if len(actions) == 0 {
+		return "none"
+	}
+	return strings.Join(actions, ", ")
+}
+
+// prune drops rules that broader later rules already grant
+func prune(rules []PolicyRule) []PolicyRule {
+	for i := 0; i < len(rules); {
+		others := append(append([]PolicyRule{}, rules[:i]...), rules[i+1:]...)
+		if coveredBy(others, rules[i]) {
+			rules = others
+			continue
+		}
+		i++
+	}
+	return rules
+}
+
+// combine merges the verbs of rules on the same resources into one rule
+func combine(rules []PolicyRule) []PolicyRule {
+	var combined []PolicyRule
+	for _, rule := range rules {
+		merged := false
+		for i, c := range combined {
+			if reflect.DeepEqual(c.APIGroups, rule.APIGroups) && reflect.DeepEqual(c.Resources, rule.Resources) {
+				combined[i].Verbs = appendMissing(append([]string{}, c.Verbs...), rule.Verbs)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			combined = append(combined, rule)
+		}
+	}
+	return combined
+}
+
+func appendMissing(list, values []string) []string {
+	for _, v := range values {
+		if !matches(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// coveredBy reports whether rules grant every verb of want on its resources
+func coveredBy(rules []PolicyRule, want PolicyRule) bool {
+	for _, verb := range want.Verbs {
+		for _, resource := range want.Resources {
+			granted := false
+			for _, r := range rules {
+				if matches(r.Verbs, verb) && matches(r.Resources, resource) && matchesAny(r.APIGroups, want.APIGroups) {
+					granted = true
+					break
+				}
+			}
+			if !granted {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matches(list []string, value string) bool {
+	for _, v := range list {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(list, values []string) bool {
+	for _, v := range values {
+		if matches(list, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package rbacgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestNewPlan(t *testing.T) {
+	plan := NewPlan(config.ResolvedRules{
+		Tier:                "production",
+		BlockedActions:      []string{"delete", "exec", "edit", "logs"},
+		RequireConfirmation: []string{"drain"},
+	})
+	if want := []string{"delete", "edit", "patch", "exec"}; !reflect.DeepEqual(plan.Denied, want) {
+		t.Errorf("Denied = %v, want %v", plan.Denied, want)
+	}
+	for _, rule := range plan.Rules {
+		for _, verb := range rule.Verbs {
+			if verb == "delete" {
+				t.Errorf("rule %+v grants delete", rule)
+			}
+		}
+	}
+	if coveredBy(plan.Rules, grants["exec"][0]) {
+		t.Error("the rules grant a blocked exec")
+	}
+	caveats := strings.Join(plan.Caveats, "\n")
+	for _, want := range []string{
+		"granted without confirmation: drain, cordon",
+		"logs is blocked but not expressible in RBAC",
+	} {
+		if !strings.Contains(caveats, want) {
+			t.Errorf("Caveats = %q, want %q", plan.Caveats, want)
+		}
+	}
+	if strings.Contains(caveats, "exec is blocked but allowed") {
+		t.Errorf("Caveats = %q, but no grant covers pods/exec", plan.Caveats)
+	}
+}
+
+func TestNewPlan_LeavesOutSecrets(t *testing.T) {
+	plan := NewPlan(config.ResolvedRules{Tier: "dev"})
+	for _, rule := range plan.Rules {
+		for _, resource := range rule.Resources {
+			if resource == "*" || resource == "secrets" {
+				t.Errorf("rule %+v grants %s", rule, resource)
+			}
+		}
+	}
+	for _, verb := range []string{"get", "patch", "delete"} {
+		if !coveredBy(plan.Rules, PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{verb}}) {
+			t.Errorf("dev rules don't grant %s on deployments", verb)
+		}
+	}
+}
+
+func TestNewPlan_SkipsCoveredRules(t *testing.T) {
+	plan := NewPlan(config.ResolvedRules{Tier: "dev"})
+	for i, rule := range plan.Rules {
+		others := append(append([]PolicyRule{}, plan.Rules[:i]...), plan.Rules[i+1:]...)
+		if coveredBy(others, rule) {
+			t.Errorf("rule %+v is already granted by the others", rule)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	plan := NewPlan(config.ResolvedRules{Tier: "production", BlockedActions: []string{"delete"}})
+	out, err := Generate(plan, Options{Group: "sre@corp"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{"kind: ClusterRole\n", "kind: ClusterRoleBinding", "name: kctl-production", "name: sre@corp", "# Leaves out: delete"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("manifest lacks %q:\n%s", want, out)
+		}
+	}
+
+	out, err = Generate(plan, Options{Group: "sre", Name: "oncall", Namespace: "payments"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{"kind: Role\n", "kind: RoleBinding", "namespace: payments", "name: oncall"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("namespaced manifest lacks %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := Generate(plan, Options{}); err == nil {
+		t.Error("Generate() without a group should fail")
+	}
+}
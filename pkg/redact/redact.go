@@ -0,0 +1,197 @@
+// Package redact removes secret values from what kctl records or passes on:
+// the data of Secrets, credentials given as flags, and strings that look
+// like tokens or private keys. Redacted values are replaced by Placeholder.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholder stands in for a redacted value
+const Placeholder = "REDACTED"
+
+// lastApplied is the annotation kubectl apply keeps a copy of the applied
+// object in, Secret data included
+const lastApplied = "kubectl.kubernetes.io/last-applied-configuration"
+
+// secretFlags take a credential as their value
+var secretFlags = map[string]bool{
+	"--token":           true,
+	"--password":        true,
+	"--docker-password": true,
+}
+
+// literalFlags take key=value pairs whose values are secret data
+var literalFlags = map[string]bool{"--from-literal": true}
+
+// tokens match credentials wherever they appear. Each keeps what its first
+// group matched, if any, and replaces the rest.
+var tokens = []*regexp.Regexp{
+	// A key cut off by a size cap is hidden up to where it stops
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|\z)`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+}
+
+// assignment matches a value given to a key named like a credential, as in
+// password=... or "token": "..."
+var assignment = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key)"?\s*[:=]\s*"?)([^\s"',]+)`)
+
+// keyLine splits a YAML or JSON line holding a key into its indentation,
+// key and value
+var keyLine = regexp.MustCompile(`^(\s*(?:- )?)("?)([^":\s]+)("?\s*:)\s*(.*)$`)
+
+// kindSecret matches the line giving a Secret's kind
+var kindSecret = regexp.MustCompile(`^\s*(?:- )?"?kind"?\s*:\s*"?Secret"?,?\s*$`)
+
+// Args returns a copy of kubectl args with the values of credential flags,
+// --from-literal values and anything looking like a token redacted
+func Args(args []string) []string {
+	redacted := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, inline := strings.Cut(arg, "=")
+		switch {
+		case !strings.HasPrefix(arg, "--"):
+			redacted[i] = Text(arg)
+		case secretFlags[name] && inline:
+			redacted[i] = name + "=" + Placeholder
+		case literalFlags[name] && inline:
+			redacted[i] = name + "=" + literal(value)
+		case (secretFlags[name] || literalFlags[name]) && i+1 < len(args):
+			redacted[i] = arg
+			i++
+			if secretFlags[name] {
+				redacted[i] = Placeholder
+			} else {
+				redacted[i] = literal(args[i])
+			}
+		default:
+			redacted[i] = Text(arg)
+		}
+	}
+	return redacted
+}
+
+// literal redacts the value of a key=value pair
+func literal(pair string) string {
+	key, _, _ := strings.Cut(pair, "=")
+	return key + "=" + Placeholder
+}
+
+// Redacted reports whether any of args had a value redacted
+func Redacted(args []string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, Placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text redacts what kubectl printed or a manifest holds: the data,
+// stringData and last-applied configuration of Secrets in YAML or JSON,
+// which keep their layout, then anything looking like a token
+func Text(s string) string {
+	if strings.Contains(s, "Secret") {
+		lines := strings.Split(s, "\n")
+		start := 0
+		for i := 0; i <= len(lines); i++ {
+			if i < len(lines) && strings.TrimSpace(lines[i]) != "---" {
+				continue
+			}
+			if doc := lines[start:i]; isSecret(doc) {
+				redactSecret(doc)
+			}
+			start = i + 1
+		}
+		s = strings.Join(lines, "\n")
+	}
+	for _, re := range tokens {
+		s = re.ReplaceAllString(s, "${1}"+Placeholder)
+	}
+	return assignment.ReplaceAllStringFunc(s, func(match string) string {
+		parts := assignment.FindStringSubmatch(match)
+		switch strings.ToLower(parts[2]) {
+		case "true", "false", "null", strings.ToLower(Placeholder):
+			return match
+		}
+		return parts[1] + Placeholder
+	})
+}
+
+// isSecret reports whether a document is, or lists, a Secret
+func isSecret(doc []string) bool {
+	for _, line := range doc {
+		if kindSecret.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecret replaces the values under data and stringData keys, and of
+// the last-applied annotation, in the lines of a Secret document
+func redactSecret(doc []string) {
+	hiding := -1 // the indentation of the key whose values are hidden
+	for i, line := range doc {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if hiding >= 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if indent > hiding {
+				doc[i] = hide(line, indent)
+				continue
+			}
+			hiding = -1
+		}
+		m := keyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		switch key, value := m[3], m[5]; {
+		case key != "data" && key != "stringData" && key != lastApplied:
+		case value == "" || value == "{" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			hiding = indent
+		case value != "{}" && value != "{},":
+			doc[i] = hide(line, indent)
+		}
+	}
+}
+
+// hide replaces the value on a line: a key keeps its name, JSON strings
+// their quotes and trailing comma
+func hide(line string, indent int) string {
+	m := keyLine.FindStringSubmatch(line)
+	if m == nil {
+		return line[:indent] + Placeholder
+	}
+	value := Placeholder
+	if strings.HasPrefix(m[5], `"`) {
+		value = `"` + Placeholder + `"`
+	}
+	if strings.HasSuffix(m[5], ",") {
+		value += ","
+	}
+	return m[1] + m[2] + m[3] + m[4] + " " + value
+}
+
+// Field reports whether the field at a JSON pointer of an object of kind
+// holds secret values: a Secret's data, stringData or last-applied
+// annotation
+func Field(kind, pointer string) bool {
+	if !strings.EqualFold(kind, "Secret") {
+		return false
+	}
+	for _, prefix := range []string{"/data", "/stringData"} {
+		if pointer == prefix || strings.HasPrefix(pointer, prefix+"/") {
+			return true
+		}
+	}
+	return pointer == "/metadata/annotations/"+strings.ReplaceAll(lastApplied, "/", "~1")
+}
@@ -0,0 +1,189 @@
+// Package retention prunes old entries from kctl's JSON-lines logs so the
+// data directory doesn't grow forever
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Interval is how often pruning runs on its own
+const Interval = 24 * time.Hour
+
+// Policy limits what a log keeps. Zero values keep everything.
+type Policy struct {
+	MaxAge  time.Duration
+	MaxSize int64 // bytes
+}
+
+// Enabled reports whether p prunes anything
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxSize > 0
+}
+
+// ParsePolicy reads a max age ("90d", "720h") and a max size ("50MB",
+// "1GiB", bytes); either may be empty
+func ParsePolicy(maxAge, maxSize string) (Policy, error) {
+	var p Policy
+	var err error
+	if maxAge != "" {
+		if p.MaxAge, err = parseAge(maxAge); err != nil {
+			return Policy{}, err
+		}
+	}
+	if maxSize != "" {
+		if p.MaxSize, err = parseSize(maxSize); err != nil {
+			return Policy{}, err
+		}
+	}
+	return p, nil
+}
+
+// Result describes what Prune removed
+type Result struct {
+	Removed, Kept int
+	// Freed is the number of bytes removed
+	Freed int64
+}
+
+// Prune drops the entries of the JSON-lines log at path that are older than
+// p.MaxAge, then the oldest remaining ones until the log fits p.MaxSize.
+// Entries are dated by their "time" field; undated lines are only removed
+// for size. With dryRun the log is left unchanged. A missing log is not an
+// error.
+func Prune(path string, p Policy, now time.Time, dryRun bool) (Result, error) {
+	if !dryRun {
+		// Entries appended while pruning would be lost
+		unlock, err := statefile.Lock(path)
+		if err != nil {
+			return Result{}, err
+		}
+		defer unlock()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Result{}, nil
+		}
+		return Result{}, err
+	}
+
+	var kept [][]byte
+	var result Result
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var entry struct {
+			Time time.Time `json:"time"`
+		}
+		if p.MaxAge > 0 && json.Unmarshal(line, &entry) == nil && !entry.Time.IsZero() && now.Sub(entry.Time) > p.MaxAge {
+			result.Removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Result{}, err
+	}
+
+	if p.MaxSize > 0 {
+		var size int64
+		for _, line := range kept {
+			size += int64(len(line)) + 1
+		}
+		for len(kept) > 0 && size > p.MaxSize {
+			size -= int64(len(kept[0])) + 1
+			kept = kept[1:]
+			result.Removed++
+		}
+	}
+
+	var out bytes.Buffer
+	for _, line := range kept {
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	result.Kept = len(kept)
+	result.Freed = int64(len(data) - out.Len())
+	if result.Removed == 0 || dryRun {
+		return result, nil
+	}
+
+	return result, statefile.WriteFile(path, out.Bytes(), 0600)
+}
+
+// StampPath returns the file recording when pruning last ran
+func StampPath() string {
+	return filepath.Join(config.DataDir(), "last-gc")
+}
+
+// Due reports whether pruning last ran more than Interval before now, as
+// recorded at stamp
+func Due(stamp string, now time.Time) bool {
+	info, err := os.Stat(stamp)
+	return err != nil || now.Sub(info.ModTime()) >= Interval
+}
+
+// MarkRun records at stamp that pruning ran now
+func MarkRun(stamp string, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(stamp), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(stamp, nil, 0600); err != nil {
+		return err
+	}
+	return os.Chtimes(stamp, now, now)
+}
+
+// parseAge reads a Go duration, also accepting whole days ("90d")
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid max age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid max age %q: use a duration like 90d or 720h", value)
+	}
+	return d, nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// parseSize reads a size in bytes with an optional unit ("50MB", "1GiB")
+func parseSize(value string) (int64, error) {
+	number, factor := value, int64(1)
+	for _, unit := range sizeUnits {
+		if n, ok := strings.CutSuffix(value, unit.suffix); ok {
+			number, factor = strings.TrimSpace(n), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid max size %q: use a size like 50MB or 1GiB", value)
+	}
+	return n * factor, nil
+}
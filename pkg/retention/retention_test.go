@@ -0,0 +1,115 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		age, size string
+		want      Policy
+		wantErr   bool
+	}{
+		{"", "", Policy{}, false},
+		{"90d", "", Policy{MaxAge: 90 * 24 * time.Hour}, false},
+		{"36h", "50MB", Policy{MaxAge: 36 * time.Hour, MaxSize: 50 * 1000 * 1000}, false},
+		{"", "1GiB", Policy{MaxSize: 1 << 30}, false},
+		{"", "4096", Policy{MaxSize: 4096}, false},
+		{"", "2 MiB", Policy{MaxSize: 2 << 20}, false},
+		{"ninety days", "", Policy{}, true},
+		{"-1d", "", Policy{}, true},
+		{"", "lots", Policy{}, true},
+		{"", "0MB", Policy{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.age, tt.size)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePolicy(%q, %q) = %+v, %v; want %+v, error %v", tt.age, tt.size, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// writeLog writes one entry per age, oldest first, plus an undated line
+func writeLog(t *testing.T, now time.Time, ages ...time.Duration) string {
+	t.Helper()
+	var lines []string
+	lines = append(lines, "not json")
+	for _, age := range ages {
+		lines = append(lines, fmt.Sprintf(`{"time":%q,"action":"delete"}`, now.Add(-age).Format(time.RFC3339)))
+	}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPrune_MaxAge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	path := writeLog(t, now, 100*day, 40*day, 10*day, time.Hour)
+
+	result, err := Prune(path, Policy{MaxAge: 30 * day}, now, true)
+	if err != nil || result.Removed != 2 || result.Kept != 3 {
+		t.Fatalf("dry run = %+v, %v; want 2 removed, 3 kept", result, err)
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 5 {
+		t.Errorf("dry run changed the log:\n%s", data)
+	}
+
+	if _, err := Prune(path, Policy{MaxAge: 30 * day}, now, false); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || lines[0] != "not json" || !strings.Contains(lines[1], now.Add(-10*day).Format(time.RFC3339)) {
+		t.Errorf("log after pruning:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("log mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestPrune_MaxSize(t *testing.T) {
+	now := time.Now()
+	path := writeLog(t, now, 3*time.Hour, 2*time.Hour, time.Hour)
+	data, _ := os.ReadFile(path)
+	lastLine := strings.SplitAfter(string(data), "\n")[3]
+
+	result, err := Prune(path, Policy{MaxSize: int64(len(lastLine))}, now, false)
+	if err != nil || result.Removed != 3 || result.Kept != 1 || result.Freed != int64(len(data)-len(lastLine)) {
+		t.Fatalf("Prune = %+v, %v", result, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != lastLine {
+		t.Errorf("log = %q, want only the newest entry %q", got, lastLine)
+	}
+}
+
+func TestPrune_Missing(t *testing.T) {
+	result, err := Prune(filepath.Join(t.TempDir(), "none.jsonl"), Policy{MaxAge: time.Hour}, time.Now(), false)
+	if err != nil || result != (Result{}) {
+		t.Errorf("Prune of missing log = %+v, %v", result, err)
+	}
+}
+
+func TestDue(t *testing.T) {
+	stamp := filepath.Join(t.TempDir(), "data", "last-gc")
+	now := time.Now()
+	if !Due(stamp, now) {
+		t.Error("Due without a stamp = false, want true")
+	}
+	if err := MarkRun(stamp, now); err != nil {
+		t.Fatalf("MarkRun failed: %v", err)
+	}
+	if Due(stamp, now.Add(time.Hour)) {
+		t.Error("Due an hour after a run = true, want false")
+	}
+	if !Due(stamp, now.Add(Interval)) {
+		t.Error("Due a day after a run = false, want true")
+	}
+}
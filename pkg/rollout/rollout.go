@@ -0,0 +1,104 @@
+// Package rollout follows the rollouts a command starts and builds the
+// 'rollout undo' that takes back one that fails
+package rollout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// kinds maps the names kubectl accepts for the workloads with rollouts,
+// and the kinds in manifests, to their resource
+var kinds = map[string]string{
+	"deployment": "deployment", "deployments": "deployment", "deploy": "deployment", "Deployment": "deployment",
+	"statefulset": "statefulset", "statefulsets": "statefulset", "sts": "statefulset", "StatefulSet": "statefulset",
+	"daemonset": "daemonset", "daemonsets": "daemonset", "ds": "daemonset", "DaemonSet": "daemonset",
+}
+
+// Workload is a Deployment, StatefulSet or DaemonSet whose rollout a
+// command starts
+type Workload struct {
+	// Ref is resource/name, e.g. deployment/web
+	Ref       string
+	Namespace string
+}
+
+// String names the workload, e.g. "deployment/web in shop"
+func (w Workload) String() string {
+	if w.Namespace == "" {
+		return w.Ref
+	}
+	return w.Ref + " in " + w.Namespace
+}
+
+// Workloads returns the workloads whose rollouts the rollout restart or
+// apply in args starts: those named on a rollout restart's command line,
+// or those in an apply's local -f manifests. Workloads picked by a
+// selector or from remote manifests aren't found.
+func Workloads(args []string) []Workload {
+	namespace, _ := rbac.Namespace(args)
+	positional := rbac.Positional(args)
+	var workloads []Workload
+	switch {
+	case len(positional) > 2 && positional[0] == "rollout" && positional[1] == "restart":
+		if rbac.HasFlag(args, "-l", "--selector", "-f", "--filename") {
+			return nil
+		}
+		operands := positional[2:]
+		if strings.Contains(operands[0], "/") {
+			for _, op := range operands {
+				resource, name, _ := strings.Cut(op, "/")
+				if kind, known := kinds[resource]; known && name != "" {
+					workloads = append(workloads, Workload{Ref: kind + "/" + name, Namespace: namespace})
+				}
+			}
+		} else if kind, known := kinds[operands[0]]; known {
+			for _, name := range operands[1:] {
+				workloads = append(workloads, Workload{Ref: kind + "/" + name, Namespace: namespace})
+			}
+		}
+	case len(positional) > 0 && positional[0] == "apply":
+		objects, err := manifest.Load(rbac.Filenames(args), rbac.HasFlag(args, "-R", "--recursive"))
+		if err != nil {
+			return nil
+		}
+		for _, o := range objects {
+			if kind, known := kinds[o.Kind]; known && o.Name != "" {
+				ns := o.Namespace
+				if ns == "" {
+					ns = namespace
+				}
+				workloads = append(workloads, Workload{Ref: kind + "/" + o.Name, Namespace: ns})
+			}
+		}
+	}
+	return workloads
+}
+
+// Status waits up to timeout for the rollout of w on context to finish,
+// returning why it didn't
+func Status(context string, w Workload, timeout time.Duration) error {
+	args := []string{"--context", context, "rollout", "status", w.Ref, "--timeout=" + timeout.String()}
+	if w.Namespace != "" {
+		args = append(args, "-n", w.Namespace)
+	}
+	_, stderr, exitCode := kubectl.Run(args)
+	if exitCode != 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// UndoArgs returns the kubectl arguments rolling w back on context
+func UndoArgs(context string, w Workload) []string {
+	args := []string{"--context", context, "rollout", "undo", w.Ref}
+	if w.Namespace != "" {
+		args = append(args, "-n", w.Namespace)
+	}
+	return args
+}
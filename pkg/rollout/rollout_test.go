@@ -0,0 +1,71 @@
+package rollout
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestWorkloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte(`kind: Deployment
+metadata: {name: web, namespace: shop}
+---
+kind: Service
+metadata: {name: web, namespace: shop}
+---
+kind: StatefulSet
+metadata: {name: db}
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want []Workload
+	}{
+		{[]string{"rollout", "restart", "deploy/web", "sts/db", "-n", "shop"},
+			[]Workload{{"deployment/web", "shop"}, {"statefulset/db", "shop"}}},
+		{[]string{"rollout", "restart", "deploy", "web", "-nshop"},
+			[]Workload{{"deployment/web", "shop"}}},
+		{[]string{"rollout", "restart", "deployment", "web", "api"},
+			[]Workload{{"deployment/web", ""}, {"deployment/api", ""}}},
+		{[]string{"rollout", "restart", "deployment", "-l", "app=web"}, nil},
+		{[]string{"rollout", "undo", "deployment/web"}, nil},
+		{[]string{"apply", "-f", path, "-n", "data"},
+			[]Workload{{"deployment/web", "shop"}, {"statefulset/db", "data"}}},
+		{[]string{"apply", "-f", "https://example.com/app.yaml"}, nil},
+	}
+	for _, tt := range tests {
+		if got := Workloads(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Workloads(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	var ran string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		ran = strings.Join(args, " ")
+		return "", "error: deployment \"web\" exceeded its progress deadline\n", 1
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	w := Workload{Ref: "deployment/web", Namespace: "shop"}
+	err := Status("app-prod", w, 5*time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "progress deadline") {
+		t.Errorf("Status = %v, want the failure", err)
+	}
+	if want := "--context app-prod rollout status deployment/web --timeout=5m0s -n shop"; ran != want {
+		t.Errorf("ran kubectl %s, want %s", ran, want)
+	}
+	if got := strings.Join(UndoArgs("app-prod", w), " "); got != "--context app-prod rollout undo deployment/web -n shop" {
+		t.Errorf("UndoArgs = %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+// Package schedule stores commands queued with 'kctl schedule' until they
+// are due
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Job is a queued kubectl command
+type Job struct {
+	ID      int       `json:"id"`
+	At      time.Time `json:"at"`
+	Context string    `json:"context"`
+	Args    []string  `json:"args"`
+	Ticket  string    `json:"ticket,omitempty"`
+	// Profile whose rules the job is checked against when it runs
+	Profile string `json:"profile,omitempty"`
+	// PreApproved is set when the confirmation was given at schedule time,
+	// so the job can run unattended
+	PreApproved bool      `json:"pre_approved,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Path returns the default queue file location
+func Path() string {
+	return filepath.Join(config.DataDir(), "schedule.json")
+}
+
+// Load returns the jobs queued at path, soonest first
+func Load(path string) ([]Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("corrupt schedule file %s: %w", path, err)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].At.Before(jobs[j].At) })
+	return jobs, nil
+}
+
+// Save writes jobs to path, removing the file when there are none
+func Save(path string, jobs []Job) error {
+	if len(jobs) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return statefile.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Add queues job at path and returns it with its ID assigned
+func Add(path string, job Job) (Job, error) {
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return Job{}, err
+	}
+	defer unlock()
+	jobs, err := Load(path)
+	if err != nil {
+		return Job{}, err
+	}
+	job.ID = 1
+	for _, j := range jobs {
+		if j.ID >= job.ID {
+			job.ID = j.ID + 1
+		}
+	}
+	return job, Save(path, append(jobs, job))
+}
+
+// Remove takes the job with id out of the queue at path. It reports
+// whether the job was queued; of processes removing the same job, only one
+// finds it.
+func Remove(path string, id int) (bool, error) {
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	jobs, err := Load(path)
+	if err != nil {
+		return false, err
+	}
+	for i, j := range jobs {
+		if j.ID == id {
+			return true, Save(path, append(jobs[:i], jobs[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// Due returns the jobs whose time has come at now
+func Due(jobs []Job, now time.Time) []Job {
+	var due []Job
+	for _, j := range jobs {
+		if !j.At.After(now) {
+			due = append(due, j)
+		}
+	}
+	return due
+}
+
+// ParseTime reads a --at value relative to now: a clock time ("22:00",
+// the next time it comes round), a duration from now ("90m") or an RFC 3339
+// timestamp
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("%s is in the past", value)
+		}
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration %s must be positive", value)
+		}
+		return now.Add(d), nil
+	}
+	if strings.Contains(value, ":") {
+		clock, err := time.ParseInLocation("15:04", value, now.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q, want HH:MM", value)
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM, a duration like 90m, or RFC 3339", value)
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "schedule.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	late, err := Add(path, Job{At: now.Add(2 * time.Hour), Context: "app-prod", Args: []string{"delete", "deploy", "old-api"}})
+	if err != nil || late.ID != 1 {
+		t.Fatalf("Add = %+v, %v; want ID 1", late, err)
+	}
+	early, err := Add(path, Job{At: now.Add(time.Hour), Context: "app-prod", Args: []string{"scale", "deploy/web", "--replicas=0"}})
+	if err != nil || early.ID != 2 {
+		t.Fatalf("Add = %+v, %v; want ID 2", early, err)
+	}
+
+	jobs, err := Load(path)
+	if err != nil || len(jobs) != 2 || jobs[0].ID != 2 {
+		t.Fatalf("Load = %+v, %v; want both jobs, soonest first", jobs, err)
+	}
+	if due := Due(jobs, now.Add(90*time.Minute)); len(due) != 1 || due[0].ID != 2 {
+		t.Errorf("Due = %+v, want job 2", due)
+	}
+
+	if ok, err := Remove(path, 2); !ok || err != nil {
+		t.Errorf("Remove(2) = %v, %v", ok, err)
+	}
+	if ok, _ := Remove(path, 2); ok {
+		t.Error("second Remove(2) should report nothing removed")
+	}
+	if ok, _ := Remove(path, 1); !ok {
+		t.Error("Remove(1) should remove the last job")
+	}
+	if jobs, err := Load(path); err != nil || len(jobs) != 0 {
+		t.Errorf("Load after removing everything = %+v, %v", jobs, err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"22:00", time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)},
+		{"09:15", time.Date(2026, 3, 11, 9, 15, 0, 0, time.UTC)},
+		{"90m", now.Add(90 * time.Minute)},
+		{"2026-03-12T01:00:00Z", time.Date(2026, 3, 12, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"25:00", "tonight", "-5m", "2026-03-01T00:00:00Z"} {
+		if _, err := ParseTime(bad, now); err == nil {
+			t.Errorf("ParseTime(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package secrets stores kctl's secrets in the OS keyring: the macOS
+// Keychain, the Windows Credential Manager, or the Secret Service (GNOME
+// Keyring, KWallet) on Linux. It shells out to the platform's own tool
+// rather than linking against it.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the keyring service every kctl secret is stored under
+const Service = "kubectl-enhanced"
+
+// ErrNotFound is returned by Get and Delete for a secret that isn't stored
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// Token returns a credential configured as an environment variable or a
+// keyring entry, or "" when neither is configured. A set environment
+// variable wins, so CI can inject tokens without a keyring.
+func Token(envVar, secretName string) (string, error) {
+	if envVar != "" {
+		if value := os.Getenv(envVar); value != "" {
+			return value, nil
+		}
+	}
+	if secretName != "" {
+		value, err := Get(secretName)
+		if errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("secret '%s' is not in the keyring; run 'kctl secret set %s'", secretName, secretName)
+		}
+		return value, err
+	}
+	if envVar != "" {
+		return "", fmt.Errorf("%s is not set", envVar)
+	}
+	return "", nil
+}
+
+// Set stores value under name, replacing any previous value
+func Set(name, value string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// Given as an argument, the value would show in ps; security -i
+		// reads the command from stdin instead
+		line, err := securityCommand("add-generic-password", "-U", "-s", Service, "-a", name, "-w", value)
+		if err != nil {
+			return err
+		}
+		_, err = run(strings.NewReader(line), "security", "-i")
+		return err
+	case "windows":
+		_, err := powershell(name, value, `$v.Add((New-Object Windows.Security.Credentials.PasswordCredential($s, $n, [Console]::In.ReadToEnd())))`)
+		return err
+	}
+	_, err := run(strings.NewReader(value), "secret-tool", "store", "--label", "kctl "+name, "service", Service, "account", name)
+	return err
+}
+
+// Get returns the value stored under name
+func Get(name string) (string, error) {
+	var out string
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = run(nil, "security", "find-generic-password", "-s", Service, "-a", name, "-w")
+	case "windows":
+		out, err = powershell(name, "", findCredential+`$c.RetrievePassword(); [Console]::Out.Write($c.Password)`)
+	default:
+		out, err = run(nil, "secret-tool", "lookup", "service", Service, "account", name)
+		if err == nil && out == "" {
+			err = ErrNotFound
+		}
+	}
+	if missing(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Delete removes the secret stored under name
+func Delete(name string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = run(nil, "security", "delete-generic-password", "-s", Service, "-a", name)
+	case "windows":
+		_, err = powershell(name, "", findCredential+`$v.Remove($c)`)
+	default:
+		// secret-tool clear succeeds whether or not anything was stored
+		if _, err = Get(name); err != nil {
+			return err
+		}
+		_, err = run(nil, "secret-tool", "clear", "service", Service, "account", name)
+	}
+	if missing(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// securityCommand returns the line that runs security(1) with args in its
+// interactive mode, each argument double-quoted
+func securityCommand(args ...string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return "", fmt.Errorf("the macOS keychain can't store values with line breaks")
+		}
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+	}
+	return strings.Join(quoted, " ") + "\n", nil
+}
+
+// findCredential sets $c to the credential stored under $n, exiting with
+// psNotFound (3) when there is none. PasswordVault.Retrieve throws the same
+// exception for a missing entry as for a vault it can't open.
+const findCredential = `$c = $v.RetrieveAll() | Where-Object { $_.Resource -eq $s -and $_.UserName -eq $n } | Select-Object -First 1; ` +
+	`if (-not $c) { exit 3 }; `
+
+// Exit codes the keyring tools use for an entry that isn't stored
+const (
+	// securityNotFound is errSecItemNotFound, as security(1) exits with it
+	securityNotFound = 44
+	// psNotFound is what findCredential exits with
+	psNotFound = 3
+)
+
+// missing reports whether err is the keyring tool saying the entry isn't
+// stored, rather than failing for another reason such as a locked keyring
+// or no D-Bus session
+func missing(err error) bool {
+	if errors.Is(err, ErrNotFound) {
+		return true
+	}
+	var toolErr *toolError
+	if !errors.As(err, &toolErr) {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exitCode(err) == securityNotFound
+	case "windows":
+		return exitCode(err) == psNotFound
+	}
+	// secret-tool exits 1 without a word when nothing is stored
+	return exitCode(err) == 1 && toolErr.stderr == ""
+}
+
+// powershell runs script against the Windows PasswordVault with $v, $s
+// (the service) and $n (name) set; input is the script's stdin
+func powershell(name, input, script string) (string, error) {
+	prelude := `$ErrorActionPreference = 'Stop'; ` +
+		`[void][Windows.Security.Credentials.PasswordVault, Windows.Security.Credentials, ContentType = WindowsRuntime]; ` +
+		`$v = New-Object Windows.Security.Credentials.PasswordVault; $s = $env:KCTL_SECRET_SERVICE; $n = $env:KCTL_SECRET_NAME; `
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", prelude+script)
+	cmd.Env = append(os.Environ(), "KCTL_SECRET_SERVICE="+Service, "KCTL_SECRET_NAME="+name)
+	return output(cmd, strings.NewReader(input))
+}
+
+func run(stdin *strings.Reader, name string, args ...string) (string, error) {
+	return output(exec.Command(name, args...), stdin)
+}
+
+func output(cmd *exec.Cmd, stdin *strings.Reader) (string, error) {
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("no keyring available: %w", err)
+	}
+	if err != nil {
+		return "", &toolError{tool: cmd.Args[0], err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
+
+// toolError is a keyring tool that ran and failed
+type toolError struct {
+	tool   string
+	err    error
+	stderr string
+}
+
+func (e *toolError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%s: %v: %s", e.tool, e.err, e.stderr)
+	}
+	return fmt.Sprintf("%s: %v", e.tool, e.err)
+}
+
+func (e *toolError) Unwrap() error { return e.err }
+
+// exitCode returns the exit code of a keyring tool that ran and failed, or
+// 0
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
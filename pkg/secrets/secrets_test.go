@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on PATH that keeps secrets as files
+func fakeSecretTool(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("secret-tool backend is Linux only")
+	}
+	bin := t.TempDir()
+	store := t.TempDir()
+	script := `#!/bin/sh
+cmd=$1; shift
+while [ "$1" = --label ]; do shift 2; done
+file="` + store + `/$2.$4"
+case $cmd in
+store) cat > "$file" ;;
+lookup) [ -f "$file" ] || exit 1; cat "$file" ;;
+clear) rm -f "$file" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSecretTool(t *testing.T) {
+	fakeSecretTool(t)
+
+	if _, err := Get("token"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Set = %v, want ErrNotFound", err)
+	}
+	if err := Set("token", "s3cret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := Get("token"); err != nil || got != "s3cret" {
+		t.Errorf("Get = %q, %v, want s3cret", got, err)
+	}
+	if err := Delete("token"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if err := Delete("token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_KeyringError(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("secret-tool backend is Linux only")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	_, err := Get("token")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "D-Bus") {
+		t.Errorf("Get with a broken keyring = %v, want the keyring's error", err)
+	}
+	if err := Delete("token"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete with a broken keyring = %v, want the keyring's error", err)
+	}
+	if _, err := Token("", "token"); err == nil || strings.Contains(err.Error(), "kctl secret set") {
+		t.Errorf("Token with a broken keyring = %v, want the keyring's error", err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	fakeSecretTool(t)
+	if err := Set("jira", "from-keyring"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JIRA_TOKEN", "from-env")
+	if got, err := Token("JIRA_TOKEN", "jira"); err != nil || got != "from-env" {
+		t.Errorf("Token with env set = %q, %v, want from-env", got, err)
+	}
+	t.Setenv("JIRA_TOKEN", "")
+	if got, err := Token("JIRA_TOKEN", "jira"); err != nil || got != "from-keyring" {
+		t.Errorf("Token with env empty = %q, %v, want from-keyring", got, err)
+	}
+	if _, err := Token("", "missing"); err == nil {
+		t.Error("Token for a missing keyring entry succeeded")
+	}
+	if _, err := Token("JIRA_TOKEN", ""); err == nil {
+		t.Error("Token for an unset variable succeeded")
+	}
+	if got, err := Token("", ""); err != nil || got != "" {
+		t.Errorf("Token with nothing configured = %q, %v, want empty", got, err)
+	}
+}
+
+func TestSecurityCommand(t *testing.T) {
+	line, err := securityCommand("add-generic-password", "-a", "jira", "-w", `p"a\ss word`)
+	if want := `"add-generic-password" "-a" "jira" "-w" "p\"a\\ss word"` + "\n"; err != nil || line != want {
+		t.Errorf("securityCommand = %q, %v, want %q", line, err, want)
+	}
+	if _, err := securityCommand("-w", "two\nlines"); err == nil {
+		t.Error("securityCommand accepted a value with a line break")
+	}
+}
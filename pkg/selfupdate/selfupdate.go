@@ -0,0 +1,300 @@
+// Package selfupdate finds kctl releases on GitHub and replaces the running
+// binary with a verified download
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// Defaults for where releases are published
+const (
+	DefaultRepository = "Junovy-Hosting/kubectl-enhanced-cli"
+	DefaultAPIURL     = "https://api.github.com"
+)
+
+// Release assets besides the binaries: sha256sum output for every asset,
+// and its Ed25519 signature in base64
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// CheckInterval is how often the new version notice looks for a release
+const CheckInterval = 24 * time.Hour
+
+// Timeouts for talking to the release server. The notice runs before a
+// command, so it gives up quickly.
+const (
+	DefaultTimeout = 2 * time.Minute
+	CheckTimeout   = 2 * time.Second
+)
+
+// Release is a published version and its downloadable assets by name
+type Release struct {
+	Version string
+	Assets  map[string]string // name -> download URL
+}
+
+// AssetName returns the binary asset for a platform, e.g.
+// "kubectl-enhanced-cli_linux_amd64" or "..._windows_amd64.exe"
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("kubectl-enhanced-cli_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the newest published release
+func Latest(cfg config.UpdateConfig, timeout time.Duration) (Release, error) {
+	repo := cfg.Repository
+	if repo == "" {
+		repo = DefaultRepository
+	}
+	base := cfg.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	body, err := get(strings.TrimSuffix(base, "/")+"/repos/"+repo+"/releases/latest", timeout)
+	if err != nil {
+		return Release{}, fmt.Errorf("looking up the latest release: %w", err)
+	}
+
+	var latest struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return Release{}, fmt.Errorf("looking up the latest release: %w", err)
+	}
+	release := Release{Version: latest.TagName, Assets: make(map[string]string)}
+	for _, a := range latest.Assets {
+		release.Assets[a.Name] = a.URL
+	}
+	return release, nil
+}
+
+// Newer reports whether version is a later release than current. Versions
+// are compared as dotted numbers with an optional "v"; a current version
+// that isn't one (such as "dev") is never older.
+func Newer(version, current string) bool {
+	v, ok := parseVersion(version)
+	c, okCurrent := parseVersion(current)
+	if !ok || !okCurrent {
+		return false
+	}
+	for i := range v {
+		if v[i] != c[i] {
+			return v[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads "v1.2.3"; anything after a "-" or "+" is ignored, so
+// "v1.2.3-4-gabc" from git describe reads as 1.2.3
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// Install downloads the release's binary for this platform, checks it
+// against the release checksums (and their signature when cfg has a public
+// key), and replaces the executable at path with it. The new binary is
+// written next to path and renamed over it, so path is never half-written.
+func Install(cfg config.UpdateConfig, release Release, path string) error {
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, ok := release.Assets[asset]
+	if !ok {
+		return fmt.Errorf("release %s has no %s", release.Version, asset)
+	}
+	checksumsURL, ok := release.Assets[ChecksumsAsset]
+	if !ok {
+		return fmt.Errorf("release %s has no %s", release.Version, ChecksumsAsset)
+	}
+
+	checksums, err := get(checksumsURL, DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", ChecksumsAsset, err)
+	}
+	if cfg.PublicKey != "" {
+		sigURL, ok := release.Assets[SignatureAsset]
+		if !ok {
+			return fmt.Errorf("release %s is not signed (no %s)", release.Version, SignatureAsset)
+		}
+		sig, err := get(sigURL, DefaultTimeout)
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", SignatureAsset, err)
+		}
+		if err := VerifySignature(cfg.PublicKey, checksums, sig); err != nil {
+			return err
+		}
+	}
+	want, err := checksumFor(checksums, asset)
+	if err != nil {
+		return err
+	}
+
+	binary, err := get(binaryURL, DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", asset, err)
+	}
+	if got := sha256.Sum256(binary); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("%s does not match its checksum; not installing it", asset)
+	}
+	return Replace(path, binary)
+}
+
+// VerifySignature checks sig, a base64 Ed25519 signature, over data with
+// publicKey, a base64 Ed25519 public key
+func VerifySignature(publicKey string, data, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("update.public_key is not a base64 Ed25519 public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(key, data, signature) {
+		return fmt.Errorf("the signature on %s is not valid; not installing", ChecksumsAsset)
+	}
+	return nil
+}
+
+// checksumFor finds the sha256 of name in sha256sum output
+func checksumFor(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// Replace atomically swaps the executable at path for binary. Symlinks are
+// followed so the installed file is replaced, not the link. Windows can't
+// overwrite a running executable, so the old one is moved aside first.
+func Replace(path string, binary []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("can't write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// get fetches url and returns the body
+func get(url string, timeout time.Duration) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json, application/octet-stream")
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// stamp records the last version check: when it ran and the newest
+// version it found
+type stamp struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// StampPath returns where the last version check is recorded
+func StampPath() string {
+	return filepath.Join(config.DataDir(), "update-check.json")
+}
+
+// Notice returns the newest release when it is newer than current, looking
+// it up at most once per CheckInterval and otherwise answering from the
+// last check recorded at path. Lookup failures are recorded like an
+// up-to-date answer so an unreachable server isn't asked on every command.
+func Notice(cfg config.UpdateConfig, current, path string, now time.Time) string {
+	var last stamp
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &last)
+	}
+	if now.Sub(last.CheckedAt) >= CheckInterval {
+		last.CheckedAt = now
+		if release, err := Latest(cfg, CheckTimeout); err == nil {
+			last.Latest = release.Version
+		}
+		if data, err := json.Marshal(last); err == nil {
+			_ = statefile.WriteFile(path, data, 0600)
+		}
+	}
+	if Newer(last.Latest, current) {
+		return last.Latest
+	}
+	return ""
+}
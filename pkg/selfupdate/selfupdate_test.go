@@ -0,0 +1,155 @@
+package selfupdate
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		version, current string
+		want             bool
+	}{
+		{"v1.3.0", "v1.2.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0-3-gabc123", false},
+		{"v2.0.0", "dev", false},
+		{"", "v1.0.0", false},
+		{"v1.0.0", "v1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.version, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.version, tt.current, got, tt.want)
+		}
+	}
+}
+
+// releaseServer serves a release of binary, signed with key when key is set
+func releaseServer(t *testing.T, binary []byte, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(binary)
+	checksums := fmt.Sprintf("%s  %s\n%s  other\n", hex.EncodeToString(sum[:]), asset, strings.Repeat("0", 64))
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/repos/acme/kctl/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		assets := fmt.Sprintf(`{"name": %q, "browser_download_url": %q}, {"name": %q, "browser_download_url": %q}`,
+			asset, server.URL+"/bin", ChecksumsAsset, server.URL+"/checksums")
+		if key != nil {
+			assets += fmt.Sprintf(`, {"name": %q, "browser_download_url": %q}`, SignatureAsset, server.URL+"/sig")
+		}
+		fmt.Fprintf(w, `{"tag_name": "v1.4.0", "assets": [%s]}`, assets)
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, checksums) })
+	mux.HandleFunc("/sig", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(checksums))))
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInstall(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := releaseServer(t, []byte("new kctl"), private)
+	cfg := config.UpdateConfig{Repository: "acme/kctl", APIURL: server.URL, PublicKey: base64.StdEncoding.EncodeToString(public)}
+
+	release, err := Latest(cfg, time.Second)
+	if err != nil || release.Version != "v1.4.0" {
+		t.Fatalf("Latest = %+v, %v", release, err)
+	}
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "kubectl-enhanced-cli")
+	if err := os.WriteFile(exe, []byte("old kctl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "kctl")
+	if err := os.Symlink(exe, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(cfg, release, link); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new kctl" {
+		t.Errorf("installed binary = %q", data)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("the symlink was replaced instead of its target")
+	}
+	if info, _ := os.Stat(exe); info.Mode().Perm() != 0755 {
+		t.Errorf("installed mode = %v, want 0755", info.Mode().Perm())
+	}
+
+	// A different key rejects the signature
+	other, _, _ := ed25519.GenerateKey(nil)
+	cfg.PublicKey = base64.StdEncoding.EncodeToString(other)
+	if err := Install(cfg, release, exe); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Install with the wrong key = %v, want a signature error", err)
+	}
+}
+
+func TestInstall_ChecksumMismatch(t *testing.T) {
+	server := releaseServer(t, []byte("new kctl"), nil)
+	cfg := config.UpdateConfig{Repository: "acme/kctl", APIURL: server.URL}
+	release, err := Latest(cfg, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release.Assets[AssetName(runtime.GOOS, runtime.GOARCH)] = server.URL + "/checksums"
+
+	exe := filepath.Join(t.TempDir(), "kctl")
+	os.WriteFile(exe, []byte("old kctl"), 0755)
+	if err := Install(cfg, release, exe); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Install of a tampered binary = %v, want a checksum error", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old kctl" {
+		t.Errorf("binary after a failed install = %q, want it untouched", data)
+	}
+
+	cfg.PublicKey = base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))
+	if err := Install(cfg, release, exe); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Install of an unsigned release with a key = %v, want it refused", err)
+	}
+}
+
+func TestNotice(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"tag_name": "v1.4.0"}`)
+	}))
+	defer server.Close()
+	cfg := config.UpdateConfig{Repository: "acme/kctl", APIURL: server.URL}
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Now()
+
+	if got := Notice(cfg, "v1.3.0", path, now); got != "v1.4.0" {
+		t.Errorf("Notice = %q, want v1.4.0", got)
+	}
+	if got := Notice(cfg, "v1.3.0", path, now.Add(time.Hour)); got != "v1.4.0" || requests != 1 {
+		t.Errorf("Notice within a day = %q after %d requests, want the recorded answer", got, requests)
+	}
+	if got := Notice(cfg, "v1.4.0", path, now.Add(25*time.Hour)); got != "" || requests != 2 {
+		t.Errorf("Notice when current = %q after %d requests", got, requests)
+	}
+}
@@ -0,0 +1,104 @@
+// Package server answers policy questions over HTTP so bots, ChatOps and CI
+// runners can consult the same rules as kctl without running kubectl
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// maxBodyBytes bounds a decision request
+const maxBodyBytes = 1 << 20
+
+// Request asks how the rules treat a kubectl command
+type Request struct {
+	// Context the command would run against. Default: its --context flag,
+	// which it must match when both are given
+	Context string `json:"context"`
+	// Args are the kubectl arguments, without "kubectl"
+	Args []string `json:"args"`
+	// User on whose behalf the command would run, echoed as the identity
+	User string `json:"user,omitempty"`
+}
+
+// errorResponse is the body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the decision API for cfg:
+//
+//	POST /v1/decision  Request in, policy.Decision out
+//	GET  /healthz      "ok"
+//
+// When token is set, /v1/decision requires "Authorization: Bearer <token>".
+func Handler(cfg *config.Config, token string) http.Handler {
+	return HandlerFor(func() *config.Config { return cfg }, token)
+}
+
+// HandlerFor is Handler for a config that can change while serving; each
+// request is decided with the config current returns
+func HandlerFor(current func() *config.Config, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/v1/decision", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"use POST"})
+			return
+		}
+		if token != "" && !authorized(r, token) {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{"missing or invalid bearer token"})
+			return
+		}
+
+		var req Request
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"invalid request: " + err.Error()})
+			return
+		}
+		if len(req.Args) == 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"args is required"})
+			return
+		}
+		context := req.Context
+		if named, ok := kubectl.GetContextFromArgs(req.Args); ok {
+			if context != "" && context != named {
+				writeJSON(w, http.StatusBadRequest, errorResponse{fmt.Sprintf("context %s differs from --context %s in args", context, named)})
+				return
+			}
+			context = named
+		}
+		if context == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"context is required when args have no --context"})
+			return
+		}
+
+		decision := policy.Evaluate(current(), context, req.Args)
+		decision.Identity = req.User
+		writeJSON(w, http.StatusOK, decision)
+	})
+	return mux
+}
+
+func authorized(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+func TestHandler(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {
+				Patterns:            []string{"*-prod"},
+				RequireConfirmation: []string{"delete"},
+				BlockedActions:      config.Actions("drain"),
+			},
+		},
+	}
+	srv := httptest.NewServer(Handler(cfg, "secret"))
+	defer srv.Close()
+
+	post := func(body, token string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/decision", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		body    string
+		verdict policy.Verdict
+		action  string
+	}{
+		{`{"context":"app-prod","args":["delete","pod","web-1"],"user":"bot@ci"}`, policy.Confirm, "delete"},
+		{`{"args":["--context","app-prod","drain","node-1"]}`, policy.Block, "drain"},
+		{`{"context":"kind-dev","args":["drain","node-1"]}`, policy.Allow, "drain"},
+	}
+	for _, tt := range tests {
+		resp := post(tt.body, "secret")
+		var d policy.Decision
+		if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || d.Verdict != tt.verdict || d.Action != tt.action {
+			t.Errorf("%s: got %d %q %q, want 200 %q %q", tt.body, resp.StatusCode, d.Verdict, d.Action, tt.verdict, tt.action)
+		}
+		if strings.Contains(tt.body, "bot@ci") && d.Identity != "bot@ci" {
+			t.Errorf("Identity = %q, want the user", d.Identity)
+		}
+	}
+
+	for _, bad := range []struct {
+		body, token string
+		status      int
+	}{
+		{`{"context":"app-prod","args":["get","pods"]}`, "", http.StatusUnauthorized},
+		{`{"context":"app-prod","args":["get","pods"]}`, "wrong", http.StatusUnauthorized},
+		{`{"args":["get","pods"]}`, "secret", http.StatusBadRequest},
+		{`{"context":"app-prod"}`, "secret", http.StatusBadRequest},
+		{`{"context":"app-prod","argv":["get"]}`, "secret", http.StatusBadRequest},
+		{`{"context":"kind-dev","args":["--context","app-prod","drain","node-1"]}`, "secret", http.StatusBadRequest},
+	} {
+		resp := post(bad.body, bad.token)
+		resp.Body.Close()
+		if resp.StatusCode != bad.status {
+			t.Errorf("%s with token %q: status %d, want %d", bad.body, bad.token, resp.StatusCode, bad.status)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/v1/decision")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/decision: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestHandler_Concurrent(t *testing.T) {
+	cfg := &config.Config{
+		Tiers: map[string]config.TierConfig{
+			"production": {Servers: []string{"https://prod.*"}, BlockedActions: config.Actions("delete")},
+			"staging":    {},
+		},
+		ServerLookup: func(context string) (string, error) { return "https://prod.example.com", nil },
+		TierLookup:   func(context string) (string, error) { return "", nil },
+	}
+	srv := httptest.NewServer(Handler(cfg, ""))
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"context":"app-%d","args":["delete","pod","web"]}`, i%5)
+			resp, err := http.Post(srv.URL+"/v1/decision", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var d policy.Decision
+			if err := json.NewDecoder(resp.Body).Decode(&d); err != nil || d.Verdict != policy.Block {
+				t.Errorf("%s: got %q (%v), want block", body, d.Verdict, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
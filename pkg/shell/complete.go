@@ -0,0 +1,167 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// podVerbs take a pod name as their first argument rather than a type
+var podVerbs = map[string]bool{"logs": true, "exec": true, "attach": true, "port-forward": true, "cp": true}
+
+// ClusterSources read completion candidates from the cluster a command
+// runs against. context and namespace are "" when the command doesn't set
+// them. Any source may be nil.
+type ClusterSources struct {
+	Contexts      func() []string
+	Namespaces    func(context string) []string
+	ResourceTypes func(context string) []string
+	Names         func(context, namespace, resource string) []string
+}
+
+// Complete completes verbs, then resource types, then object names of the
+// type typed, with namespaces after -n and contexts after --context. It
+// falls back to DefaultCompletions where the cluster has nothing to offer.
+func (s ClusterSources) Complete(previous []string, word string) []string {
+	context := flagValue(previous, "", "--context")
+	namespace := flagValue(previous, "-n", "--namespace")
+
+	if n := len(previous); n > 0 {
+		switch previous[n-1] {
+		case "--context":
+			return filterPrefix(call0(s.Contexts), word)
+		case "-n", "--namespace":
+			return filterPrefix(call1(s.Namespaces, context), word)
+		}
+	}
+	if strings.HasPrefix(word, "-") {
+		return nil
+	}
+
+	positional := positionalWords(previous)
+	switch {
+	case len(positional) == 0:
+		return DefaultCompletions(previous, word)
+	case strings.Contains(word, "/"):
+		resource, prefix, _ := strings.Cut(word, "/")
+		return withPrefix(resource+"/", filterPrefix(s.names(context, namespace, resource), prefix))
+	case len(positional) == 1 && podVerbs[positional[0]]:
+		return filterPrefix(s.names(context, namespace, "pods"), word)
+	case len(positional) == 1:
+		types := call1(s.ResourceTypes, context)
+		if len(types) == 0 {
+			return DefaultCompletions(previous, word)
+		}
+		return filterPrefix(types, word)
+	case podVerbs[positional[0]] || strings.ContainsAny(positional[1], ",/"):
+		return nil
+	default:
+		return filterPrefix(s.names(context, namespace, positional[1]), word)
+	}
+}
+
+// names returns the names of the objects of resource, or nil without a
+// Names source
+func (s ClusterSources) names(context, namespace, resource string) []string {
+	if s.Names == nil || resource == "" {
+		return nil
+	}
+	return s.Names(context, namespace, resource)
+}
+
+// positionalWords returns words without flags and their values
+func positionalWords(words []string) []string {
+	var positional []string
+	for i := 0; i < len(words); i++ {
+		if words[i] == "--" {
+			break
+		}
+		if strings.HasPrefix(words[i], "-") {
+			if !strings.Contains(words[i], "=") && rbac.FlagTakesValue(words[i]) {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, words[i])
+	}
+	return positional
+}
+
+// flagValue returns the value words give a flag, as "-n value",
+// "--flag value" or "--flag=value"
+func flagValue(words []string, short, long string) string {
+	value := ""
+	for i, w := range words {
+		switch {
+		case (w == long || short != "" && w == short) && i+1 < len(words):
+			value = words[i+1]
+		case strings.HasPrefix(w, long+"="):
+			value = strings.TrimPrefix(w, long+"=")
+		case short != "" && strings.HasPrefix(w, short+"="):
+			value = strings.TrimPrefix(w, short+"=")
+		}
+	}
+	return value
+}
+
+func withPrefix(prefix string, words []string) []string {
+	prefixed := make([]string, len(words))
+	for i, w := range words {
+		prefixed[i] = prefix + w
+	}
+	return prefixed
+}
+
+func call0(source func() []string) []string {
+	if source == nil {
+		return nil
+	}
+	return source()
+}
+
+func call1(source func(string) []string, arg string) []string {
+	if source == nil {
+		return nil
+	}
+	return source(arg)
+}
+
+// ListCache keeps lists read from a cluster for a while, so repeated Tabs
+// don't each wait for the API server
+type ListCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]cachedList
+}
+
+type cachedList struct {
+	values  []string
+	fetched time.Time
+}
+
+// NewListCache returns a ListCache keeping lists for ttl
+func NewListCache(ttl time.Duration) *ListCache {
+	return &ListCache{TTL: ttl, now: time.Now, entries: make(map[string]cachedList)}
+}
+
+// Get returns the list cached under key, calling fetch when there is none
+// or it is older than the TTL. Failed fetches aren't cached.
+func (c *ListCache) Get(key string, fetch func() ([]string, error)) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.entries[key]; ok && c.now().Sub(cached.fetched) < c.TTL {
+		return cached.values
+	}
+	values, err := fetch()
+	if err != nil {
+		return nil
+	}
+	sort.Strings(values)
+	c.entries[key] = cachedList{values: values, fetched: c.now()}
+	return values
+}
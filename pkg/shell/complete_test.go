@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClusterSourcesComplete(t *testing.T) {
+	var asked []string
+	sources := ClusterSources{
+		Contexts: func() []string { return []string{"prod", "staging"} },
+		Namespaces: func(context string) []string {
+			asked = append(asked, "namespaces@"+context)
+			return []string{"shop", "kube-system"}
+		},
+		ResourceTypes: func(context string) []string {
+			return []string{"deployments", "deploy", "pods", "po", "widgets"}
+		},
+		Names: func(context, namespace, resource string) []string {
+			asked = append(asked, resource+"@"+context+"/"+namespace)
+			return []string{"web-1", "web-2", "worker"}
+		},
+	}
+
+	tests := []struct {
+		name     string
+		previous []string
+		word     string
+		expected []string
+		asked    []string
+	}{
+		{"verb", nil, "dra", []string{"drain"}, nil},
+		{"cluster resource type", []string{"get"}, "wid", []string{"widgets"}, nil},
+		{"object name", []string{"delete", "pods", "-n", "shop"}, "web", []string{"web-1", "web-2"}, []string{"pods@/shop"}},
+		{"type/name", []string{"describe", "--context", "prod"}, "deploy/wo", []string{"deploy/worker"}, []string{"deploy@prod/"}},
+		{"pod verb", []string{"logs"}, "wor", []string{"worker"}, []string{"pods@/"}},
+		{"nothing after a pod", []string{"logs", "worker"}, "", nil, nil},
+		{"namespace", []string{"get", "pods", "--context", "staging", "-n"}, "sh", []string{"shop"}, []string{"namespaces@staging"}},
+		{"context", []string{"get", "--context"}, "pr", []string{"prod"}, nil},
+		{"flag", []string{"get", "pods"}, "--al", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asked = nil
+			got := sources.Complete(tt.previous, tt.word)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Complete(%q, %q) = %q, want %q", tt.previous, tt.word, got, tt.expected)
+			}
+			if !reflect.DeepEqual(asked, tt.asked) {
+				t.Errorf("Complete(%q, %q) asked %q, want %q", tt.previous, tt.word, asked, tt.asked)
+			}
+		})
+	}
+
+	if got := (ClusterSources{}).Complete([]string{"get"}, "depl"); !reflect.DeepEqual(got, []string{"deployments"}) {
+		t.Errorf("Complete without sources = %q, want the built-in resource types", got)
+	}
+}
+
+func TestListCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewListCache(30 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	fetches := 0
+	fetch := func() ([]string, error) {
+		fetches++
+		return []string{"b", "a"}, nil
+	}
+	if got := cache.Get("k", fetch); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Get = %q, want sorted [a b]", got)
+	}
+	cache.Get("k", fetch)
+	if fetches != 1 {
+		t.Errorf("fetched %d times within the TTL, want 1", fetches)
+	}
+	now = now.Add(time.Minute)
+	cache.Get("k", fetch)
+	if fetches != 2 {
+		t.Errorf("fetched %d times after the TTL, want 2", fetches)
+	}
+
+	failed := func() ([]string, error) { fetches++; return nil, errors.New("unreachable") }
+	cache.Get("down", failed)
+	cache.Get("down", failed)
+	if fetches != 4 {
+		t.Errorf("fetched %d times, want failures not to be cached", fetches)
+	}
+}
@@ -0,0 +1,335 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ErrOpenEditor is returned by EditLine when the user asks with Ctrl-X to
+// edit the line in $VISUAL or $EDITOR instead
+var ErrOpenEditor = errors.New("edit in editor")
+
+// lineEditor is a minimal terminal line editor with history and completion.
+// Raw mode is toggled with stty, so it works on any Unix-like terminal
+// without extra dependencies.
+type lineEditor struct {
+	in       *os.File
+	out      io.Writer
+	complete func(previous []string, word string) []string
+	history  []string
+	// external makes Ctrl-X return ErrOpenEditor
+	external bool
+}
+
+func newLineEditor(in *os.File, out io.Writer, complete func([]string, string) []string) *lineEditor {
+	return &lineEditor{in: in, out: out, complete: complete}
+}
+
+// AddHistory appends a line to the in-memory history, skipping repeats
+func (e *lineEditor) AddHistory(line string) {
+	if line == "" {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// EditLine lets the user edit line on the terminal in, with Tab completing
+// words through complete. It returns ErrOpenEditor when the user presses
+// Ctrl-X, and io.EOF on Ctrl-D once the line is empty.
+func EditLine(in *os.File, out io.Writer, prompt, line string, complete func(previous []string, word string) []string) (string, error) {
+	e := newLineEditor(in, out, complete)
+	e.external = true
+	return e.readLine(prompt, line)
+}
+
+// ReadLine reads one line of input with editing support.
+// It returns io.EOF when the user presses Ctrl-D on an empty line.
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	return e.readLine(prompt, "")
+}
+
+// readLine is ReadLine starting from initial
+func (e *lineEditor) readLine(prompt, initial string) (string, error) {
+	restore, err := makeRaw(e.in)
+	if err != nil && e.external {
+		// A line that can't be edited in place goes to the editor
+		return initial, ErrOpenEditor
+	}
+	if err != nil {
+		// No raw mode available: fall back to plain line input
+		fmt.Fprint(e.out, prompt)
+		return readCookedLine(e.in)
+	}
+	defer restore()
+
+	buf := []rune(initial)
+	pos := len(buf)
+	histIdx := len(e.history)
+	pending := ""
+	lastWasTab := false
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	redraw()
+
+	b := make([]byte, 1)
+	for {
+		if _, err := e.in.Read(b); err != nil {
+			return "", err
+		}
+		tab := false
+
+		switch b[0] {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+		case 3: // Ctrl-C: discard the line
+			fmt.Fprint(e.out, "^C\r\n")
+			buf, pos = buf[:0], 0
+			histIdx = len(e.history)
+			redraw()
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+				redraw()
+			}
+		case 24: // Ctrl-X
+			if e.external {
+				fmt.Fprint(e.out, "\r\n")
+				return string(buf), ErrOpenEditor
+			}
+		case 1: // Ctrl-A
+			pos = 0
+			redraw()
+		case 5: // Ctrl-E
+			pos = len(buf)
+			redraw()
+		case 21: // Ctrl-U
+			buf = append(buf[:0], buf[pos:]...)
+			pos = 0
+			redraw()
+		case 23: // Ctrl-W
+			start := pos
+			for start > 0 && buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && buf[start-1] != ' ' {
+				start--
+			}
+			buf = append(buf[:start], buf[pos:]...)
+			pos = start
+			redraw()
+		case 12: // Ctrl-L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+			redraw()
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+		case '\t':
+			tab = true
+			buf, pos = e.completeAt(buf, pos, lastWasTab, prompt)
+			redraw()
+		case 27: // Escape sequence
+			seq := e.readEscape()
+			switch seq {
+			case "[A": // Up
+				if histIdx > 0 {
+					if histIdx == len(e.history) {
+						pending = string(buf)
+					}
+					histIdx--
+					buf = []rune(e.history[histIdx])
+					pos = len(buf)
+					redraw()
+				}
+			case "[B": // Down
+				if histIdx < len(e.history) {
+					histIdx++
+					if histIdx == len(e.history) {
+						buf = []rune(pending)
+					} else {
+						buf = []rune(e.history[histIdx])
+					}
+					pos = len(buf)
+					redraw()
+				}
+			case "[C": // Right
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case "[D": // Left
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			case "[H", "OH", "[1~":
+				pos = 0
+				redraw()
+			case "[F", "OF", "[4~":
+				pos = len(buf)
+				redraw()
+			case "[3~": // Delete
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+					redraw()
+				}
+			}
+		default:
+			if b[0] >= 32 {
+				r := e.readRune(b[0])
+				buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+				pos++
+				redraw()
+			}
+		}
+		lastWasTab = tab
+	}
+}
+
+// readEscape reads the remainder of an ANSI escape sequence after ESC
+func (e *lineEditor) readEscape() string {
+	b := make([]byte, 1)
+	var seq []byte
+	for len(seq) < 6 {
+		if _, err := e.in.Read(b); err != nil {
+			break
+		}
+		seq = append(seq, b[0])
+		if len(seq) > 1 && (b[0] >= 'A' && b[0] <= 'Z' || b[0] == '~') {
+			break
+		}
+	}
+	return string(seq)
+}
+
+// readRune completes a UTF-8 sequence whose first byte has been read
+func (e *lineEditor) readRune(first byte) rune {
+	n := 0
+	switch {
+	case first&0xE0 == 0xC0:
+		n = 1
+	case first&0xF0 == 0xE0:
+		n = 2
+	case first&0xF8 == 0xF0:
+		n = 3
+	}
+	bytes := []byte{first}
+	b := make([]byte, 1)
+	for i := 0; i < n; i++ {
+		if _, err := e.in.Read(b); err != nil {
+			break
+		}
+		bytes = append(bytes, b[0])
+	}
+	return []rune(string(bytes))[0]
+}
+
+// completeAt completes the word under the cursor. On a repeated Tab with
+// several candidates, the candidates are listed below the prompt.
+func (e *lineEditor) completeAt(buf []rune, pos int, listAll bool, prompt string) ([]rune, int) {
+	start := pos
+	for start > 0 && buf[start-1] != ' ' {
+		start--
+	}
+	word := string(buf[start:pos])
+	previous := strings.Fields(string(buf[:start]))
+	if len(previous) > 0 && (previous[0] == "kubectl" || previous[0] == "kctl") {
+		previous = previous[1:]
+	}
+
+	candidates := e.complete(previous, word)
+	if len(candidates) == 0 {
+		return buf, pos
+	}
+
+	completion := candidates[0]
+	if len(candidates) > 1 {
+		completion = commonPrefix(candidates)
+		if listAll {
+			sort.Strings(candidates)
+			fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+		}
+	} else {
+		completion += " "
+	}
+
+	if len(completion) <= len(word) {
+		return buf, pos
+	}
+	insert := []rune(completion[len(word):])
+	rest := append([]rune{}, buf[pos:]...)
+	buf = append(append(buf[:pos], insert...), rest...)
+	return buf, pos + len(insert)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// makeRaw switches the terminal to raw mode and returns a restore function
+func makeRaw(tty *os.File) (func(), error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(tty, strings.TrimSpace(state))
+	}, nil
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// readCookedLine reads a line byte by byte so no input is buffered away
+// from prompts that read stdin afterwards
+func readCookedLine(in io.Reader) (string, error) {
+	var sb strings.Builder
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return sb.String(), nil
+			}
+			sb.WriteByte(b[0])
+		}
+		if err != nil {
+			if sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+	}
+}
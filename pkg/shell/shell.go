@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// Options configures an interactive shell session
+type Options struct {
+	// Prompt returns the prompt line shown before each command
+	Prompt func() string
+	// Run evaluates and executes one kubectl command, returning its exit code
+	Run func(args []string) int
+	// Complete returns completion candidates for the word being typed,
+	// given the words that precede it. Optional.
+	Complete func(previous []string, word string) []string
+	// History pre-populates the Up/Down history, oldest first. Optional.
+	History []string
+}
+
+// Run starts the read-eval loop until the user exits or input ends
+func Run(opts Options) error {
+	if opts.Run == nil {
+		return fmt.Errorf("shell: no command runner configured")
+	}
+	if opts.Complete == nil {
+		opts.Complete = DefaultCompletions
+	}
+
+	// The shell must survive Ctrl-C; kubectl still receives it as part of
+	// the terminal's foreground process group.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+		}
+	}()
+
+	interactive := isTerminal(os.Stdin)
+	var editor *lineEditor
+	var lines *bufio.Reader
+	if interactive {
+		editor = newLineEditor(os.Stdin, os.Stderr, opts.Complete)
+		for _, line := range opts.History {
+			editor.AddHistory(line)
+		}
+		output.PrintInfo("kctl shell - commands are checked against your rules before running")
+		output.PrintSublog("Type kubectl commands without the 'kubectl' prefix. 'exit' or Ctrl-D to quit.")
+	} else {
+		lines = bufio.NewReader(os.Stdin)
+	}
+
+	lastExit := 0
+	for {
+		var line string
+		var err error
+		if interactive {
+			prompt := ""
+			if opts.Prompt != nil {
+				prompt = opts.Prompt()
+			}
+			line, err = editor.ReadLine(prompt)
+		} else {
+			line, err = lines.ReadString('\n')
+			if err == io.EOF && line != "" {
+				err = nil
+			}
+		}
+		if err == io.EOF {
+			if interactive {
+				fmt.Fprintln(os.Stderr)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			output.PrintError(err.Error())
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			printHelp()
+			continue
+		case "kubectl", "kctl":
+			args = args[1:]
+			if len(args) == 0 {
+				continue
+			}
+		}
+
+		if interactive {
+			editor.AddHistory(strings.TrimSpace(line))
+		}
+		lastExit = opts.Run(args)
+		if lastExit != 0 && interactive {
+			output.PrintSublog(fmt.Sprintf("exit code %d", lastExit))
+		}
+	}
+}
+
+func printHelp() {
+	output.PrintSublog("Enter kubectl commands, e.g. 'get pods -n kube-system'")
+	output.PrintSublog("Every command is evaluated against the rules for the current context.")
+	output.PrintSublog("Append --yes to skip a confirmation prompt.")
+	output.PrintSublog("Tab completes verbs, resource types, names and namespaces; Up/Down browse history.")
+	output.PrintSublog("'exit', 'quit' or Ctrl-D leaves the shell.")
+}
+
+func isTerminal(f *os.File) bool {
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+}
@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Verbs lists kubectl commands offered by tab completion
+var Verbs = []string{
+	"annotate", "api-resources", "api-versions", "apply", "attach", "auth",
+	"autoscale", "certificate", "cluster-info", "config", "cordon", "cp",
+	"create", "debug", "delete", "describe", "diff", "drain", "edit",
+	"events", "exec", "explain", "expose", "get", "label", "logs", "patch",
+	"port-forward", "proxy", "replace", "rollout", "run", "scale", "set",
+	"taint", "top", "uncordon", "version", "wait",
+}
+
+// ResourceTypes lists common resource types offered by tab completion
+var ResourceTypes = []string{
+	"configmaps", "cronjobs", "daemonsets", "deployments", "endpoints",
+	"events", "horizontalpodautoscalers", "ingresses", "jobs", "namespaces",
+	"networkpolicies", "nodes", "persistentvolumeclaims", "persistentvolumes",
+	"poddisruptionbudgets", "pods", "replicasets", "roles", "rolebindings",
+	"secrets", "serviceaccounts", "services", "statefulsets", "storageclasses",
+	"clusterroles", "clusterrolebindings",
+}
+
+// DefaultCompletions completes verbs for the first word and resource
+// types for the word following a verb
+func DefaultCompletions(previous []string, word string) []string {
+	if strings.HasPrefix(word, "-") {
+		return nil
+	}
+	var source []string
+	switch nonFlagCount(previous) {
+	case 0:
+		source = Verbs
+	case 1:
+		source = ResourceTypes
+	default:
+		return nil
+	}
+	return filterPrefix(source, word)
+}
+
+// nonFlagCount counts positional words, skipping flags and their values
+func nonFlagCount(words []string) int {
+	count := 0
+	skipNext := false
+	for _, w := range words {
+		if skipNext {
+			skipNext = false
+			continue
+		}
+		if strings.HasPrefix(w, "-") {
+			skipNext = !strings.Contains(w, "=") && rbac.FlagTakesValue(w)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func filterPrefix(words []string, prefix string) []string {
+	var matches []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
+
+// SplitArgs splits a command line into arguments, honoring single quotes,
+// double quotes and backslash escapes the way a POSIX shell would
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(strings.TrimRight(line, "\r\n"))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]) {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+				inWord = true
+			}
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// JoinArgs is the inverse of SplitArgs: it joins arguments into a command
+// line, single-quoting any argument that would otherwise be split or expanded
+func JoinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t'\"\\$`") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
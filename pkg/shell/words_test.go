@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected []string
+		wantErr  bool
+	}{
+		{"simple", "get pods", []string{"get", "pods"}, false},
+		{"extra whitespace", "  get   pods \t-A ", []string{"get", "pods", "-A"}, false},
+		{"trailing newline", "get pods\n", []string{"get", "pods"}, false},
+		{"single quotes", `patch deploy app -p '{"spec":{"replicas":3}}'`, []string{"patch", "deploy", "app", "-p", `{"spec":{"replicas":3}}`}, false},
+		{"double quotes", `exec pod -- sh -c "echo hi"`, []string{"exec", "pod", "--", "sh", "-c", "echo hi"}, false},
+		{"escaped quote in double quotes", `label pod x note="a \"b\""`, []string{"label", "pod", "x", `note=a "b"`}, false},
+		{"backslash escape", `get pod my\ pod`, []string{"get", "pod", "my pod"}, false},
+		{"empty quoted arg", `annotate pod x ''`, []string{"annotate", "pod", "x", ""}, false},
+		{"empty line", "", nil, false},
+		{"unterminated quote", `get 'pods`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := SplitArgs(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitArgs(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("SplitArgs(%q) = %q, want %q", tt.line, args, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultCompletions(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		word     string
+		expected []string
+	}{
+		{"verb prefix", nil, "dra", []string{"drain"}},
+		{"verb after flags", []string{"-n", "default"}, "sca", []string{"scale"}},
+		{"resource after verb", []string{"get"}, "depl", []string{"deployments"}},
+		{"flag is not completed", []string{"get"}, "--all", nil},
+		{"nothing after resource", []string{"get", "pods"}, "a", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DefaultCompletions(tt.previous, tt.word)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("DefaultCompletions(%v, %q) = %v, want %v", tt.previous, tt.word, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoinArgs_RoundTrip(t *testing.T) {
+	inputs := [][]string{
+		{"get", "pods"},
+		{"patch", "deploy", "app", "-p", `{"spec":{"replicas":3}}`},
+		{"exec", "pod", "--", "sh", "-c", "echo 'hi there'"},
+		{"annotate", "pod", "x", ""},
+	}
+
+	for _, args := range inputs {
+		line := JoinArgs(args)
+		result, err := SplitArgs(line)
+		if err != nil {
+			t.Fatalf("SplitArgs(JoinArgs(%q)) failed: %v", args, err)
+		}
+		if !reflect.DeepEqual(result, args) {
+			t.Errorf("round trip of %q via %q = %q", args, line, result)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package statefile
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// ReadCache reads the JSON object cached at path. A missing or corrupt
+// cache reads as empty: a cache is only a cache, and is started over.
+func ReadCache[V any](path string) map[string]V {
+	cache := make(map[string]V)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(map[string]V)
+	}
+	return cache
+}
+
+// UpdateCache changes the JSON object cached at path with update, holding
+// path's lock from reading the cache to writing it back, so entries other
+// processes wrote since the caller read it aren't lost. A cache that
+// can't be written is left as it is.
+func UpdateCache[V any](path string, update func(cache map[string]V)) {
+	unlock, err := Lock(path)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	cache := ReadCache[V](path)
+	update(cache)
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	_ = WriteFile(path, data, 0600)
+}
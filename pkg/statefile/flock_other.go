@@ -0,0 +1,11 @@
+//go:build !unix
+
+package statefile
+
+import "os"
+
+// flock does nothing where the standard library has no file locks
+// (Windows); state files there still get atomic replacement from WriteFile
+func flock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package statefile reads and writes kctl's state files (the audit log,
+// history, lock, schedule and caches) so that kctl processes running at
+// the same time, in tmux splits or scripts, don't corrupt or lose each
+// other's writes
+package statefile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock takes an exclusive lock on path, waiting while another process
+// holds it, and returns the function releasing it. The lock is held on
+// path+".lock" rather than path itself, so it also covers replacing path
+// with WriteFile. Its directory is created as needed.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
+
+// WriteFile replaces path with data atomically, through a temporary file
+// renamed over it: readers see the old contents or the new, never part of
+// a write. Its directory is created as needed.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Append adds data to the end of path, creating it, while holding path's
+// lock
+func Append(path string, data []byte) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return appendFile(path, data)
+}
+
+// AppendLocked is Append for a caller already holding path's lock
+func AppendLocked(path string, data []byte) error {
+	return appendFile(path, data)
+}
+
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package statefile
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLock_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "counter")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+			data, _ := os.ReadFile(path)
+			n, _ := strconv.Atoi(string(data))
+			if err := WriteFile(path, []byte(strconv.Itoa(n+1)), 0600); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if data, _ := os.ReadFile(path); string(data) != "20" {
+		t.Errorf("counter = %q after 20 concurrent increments, want 20", data)
+	}
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	line := strings.Repeat("x", 8192) + "\n"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Append(path, []byte(line)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if got := strings.Count(string(data), line); got != 20 {
+		t.Errorf("log has %d intact lines, want 20", got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "lock.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("contents = %q, want second", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, %v; want 0600", info.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("WriteFile left temporary files: %v", entries)
+	}
+}
+
+func TestUpdateCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "tiers.json")
+	if cache := ReadCache[int](path); len(cache) != 0 {
+		t.Errorf("ReadCache of a missing cache = %v, want empty", cache)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			UpdateCache(path, func(cache map[string]int) { cache[strconv.Itoa(i)] = i })
+		}(i)
+	}
+	wg.Wait()
+	if cache := ReadCache[int](path); len(cache) != 20 {
+		t.Errorf("cache has %d entries after 20 concurrent updates, want 20", len(cache))
+	}
+
+	UpdateCache(path, func(cache map[string]int) { delete(cache, "3") })
+	if _, ok := ReadCache[int](path)["3"]; ok {
+		t.Error("UpdateCache didn't delete the entry")
+	}
+
+	os.WriteFile(path, []byte("{not json"), 0600)
+	if cache := ReadCache[int](path); len(cache) != 0 {
+		t.Errorf("ReadCache of a corrupt cache = %v, want empty", cache)
+	}
+}
@@ -0,0 +1,222 @@
+// Package telemetry keeps anonymous usage counts and, once the user opts in
+// with 'kctl telemetry on', reports them to the configured endpoint
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// ReportInterval is how often counts are sent
+const ReportInterval = 24 * time.Hour
+
+// Timeout for sending a report. Reports are sent before a command runs, so
+// they give up quickly.
+const Timeout = 2 * time.Second
+
+// State is the user's opt-in and the counts gathered since the last report.
+// It lives in the data directory rather than the config so a shared policy
+// can't opt anyone in.
+type State struct {
+	Enabled bool `json:"enabled"`
+	// ID is random and identifies an installation, not a person
+	ID       string         `json:"id,omitempty"`
+	Since    time.Time      `json:"since,omitempty"`
+	LastSent time.Time      `json:"last_sent,omitempty"`
+	Counts   map[string]int `json:"counts,omitempty"`
+}
+
+// Report is what is sent: no cluster, context, namespace, resource or user
+// names, only counts and which features the config turns on
+type Report struct {
+	ID       string         `json:"id"`
+	Version  string         `json:"version"`
+	OS       string         `json:"os"`
+	Arch     string         `json:"arch"`
+	Since    time.Time      `json:"since"`
+	Until    time.Time      `json:"until"`
+	Counts   map[string]int `json:"counts,omitempty"`
+	Features []string       `json:"features,omitempty"`
+}
+
+// Path returns where the opt-in and counts are kept
+func Path() string {
+	return filepath.Join(config.DataDir(), "telemetry.json")
+}
+
+// Load returns the state stored at path; without a file, telemetry is off
+func Load(path string) (State, error) {
+	var state State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("corrupt telemetry file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes state to path
+func Save(path string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return statefile.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Enable opts in, giving the installation an ID on first use
+func (s *State) Enable(now time.Time) error {
+	if s.ID == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		s.ID = hex.EncodeToString(b)
+	}
+	if !s.Enabled {
+		s.Enabled = true
+		s.Since = now
+		s.LastSent = now
+	}
+	return nil
+}
+
+// Disable opts out and drops the counts not yet sent
+func (s *State) Disable() {
+	s.Enabled = false
+	s.Counts = nil
+}
+
+// Count adds one to the count of a guarded command's verdict and action,
+// e.g. "verdict.confirm" and "action.delete". Only destructive actions are
+// counted by name, since anything else is whatever word the user typed:
+// plugin commands count as "action.plugin" and the rest as "action.other".
+func (s *State) Count(verdict, action string, plugin bool) {
+	if s.Counts == nil {
+		s.Counts = make(map[string]int)
+	}
+	switch {
+	case plugin:
+		action = "plugin"
+	case !rbac.IsDestructive(action):
+		action = "other"
+	}
+	s.Counts["verdict."+verdict]++
+	s.Counts["action."+action]++
+}
+
+// Due reports whether a report should be sent at now
+func (s State) Due(now time.Time) bool {
+	return s.Enabled && now.Sub(s.LastSent) >= ReportInterval
+}
+
+// Report returns what would be sent at now
+func (s State) Report(cfg *config.Config, version string, now time.Time) Report {
+	return Report{
+		ID:       s.ID,
+		Version:  version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Since:    s.Since,
+		Until:    now,
+		Counts:   s.Counts,
+		Features: Features(cfg),
+	}
+}
+
+// Sent starts a new reporting period at now
+func (s *State) Sent(now time.Time) {
+	s.Since = now
+	s.LastSent = now
+	s.Counts = nil
+}
+
+// Features returns the names of the optional features cfg turns on, sorted
+func Features(cfg *config.Config) []string {
+	var features []string
+	add := func(name string, on bool) {
+		if on {
+			features = append(features, name)
+		}
+	}
+	add("audit", cfg.Audit.Enabled)
+	add("cluster_meta", cfg.ClusterMeta.Enabled)
+	add("lease", cfg.Lease.Enabled)
+	add("verify", cfg.Verify.Enabled)
+	add("tickets", cfg.Tickets.Pattern != "" || cfg.Tickets.URL != "")
+	add("oncall", cfg.OnCall.ScheduleID != "")
+	add("shared_policy", cfg.Source.URL != "")
+	add("update_check", cfg.Update.Check)
+	add("maintenance_windows", len(cfg.MaintenanceWindows) > 0)
+	add("freeze_windows", len(cfg.FreezeWindows) > 0)
+	add("namespace_tiers", len(cfg.Namespaces) > 0)
+	add("severity", len(cfg.Severity.Actions) > 0 || len(cfg.Severity.Kinds) > 0)
+	add("suggestions", len(cfg.Suggestions) > 0)
+	add("history_disabled", cfg.History.Disabled)
+	add("ownership", cfg.Ownership.Enabled)
+	add("macros", len(cfg.Macros) > 0)
+	add("aliases", len(cfg.Aliases) > 0)
+
+	for _, tier := range cfg.Tiers {
+		add("require_ticket", tier.RequireTicket)
+		add("require_oncall", tier.RequireOnCall)
+		add("require_mfa", tier.RequireMFA)
+		add("health_check", tier.HealthCheck)
+		add("check_quota", tier.CheckQuota)
+		add("watch_rollouts", tier.WatchRollouts != "")
+		add("max_affected", tier.MaxAffected > 0)
+		add("retype_children", tier.RetypeChildren > 0)
+		add("scale_up_factor", tier.ScaleUpFactor > 0)
+		add("approval_command", tier.ApprovalCommand != "")
+		add("severity_rules", tier.ConfirmSeverity != "" || tier.BlockSeverity != "")
+		add("allowed_namespaces", len(tier.AllowedNamespaces) > 0)
+		add("image_provenance", len(tier.AllowedRegistries) > 0 || tier.RequireDigests)
+	}
+	for _, cluster := range cfg.Clusters {
+		add("allowed_namespaces", len(cluster.AllowedNamespaces) > 0)
+		add("image_provenance", len(cluster.AllowedRegistries) > 0 || cluster.RequireDigests)
+	}
+	sort.Strings(features)
+	unique := features[:0]
+	for i, f := range features {
+		if i == 0 || f != features[i-1] {
+			unique = append(unique, f)
+		}
+	}
+	return unique
+}
+
+// Send posts report as JSON to endpoint
+func Send(endpoint string, report Report, timeout time.Duration) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.json")
+	state, err := Load(path)
+	if err != nil || state.Enabled {
+		t.Fatalf("Load without a file = %+v, %v; want telemetry off", state, err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := state.Enable(now); err != nil {
+		t.Fatal(err)
+	}
+	id := state.ID
+	if len(id) != 32 {
+		t.Errorf("ID = %q, want 32 hex digits", id)
+	}
+	state.Count("confirm", "delete", false)
+	state.Count("allow", "get", false)
+	state.Count("confirm", "acme-db", true)
+	if err := Save(path, state); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"verdict.confirm": 2, "verdict.allow": 1, "action.delete": 1, "action.other": 1, "action.plugin": 1}
+	if !reflect.DeepEqual(state.Counts, want) {
+		t.Errorf("Counts = %v, want %v", state.Counts, want)
+	}
+	if state.Due(now.Add(time.Hour)) || !state.Due(now.Add(ReportInterval)) {
+		t.Error("a report should be due a day after opting in, not before")
+	}
+
+	state.Disable()
+	if state.Enabled || state.Counts != nil || state.Due(now.Add(48*time.Hour)) {
+		t.Errorf("after Disable: %+v", state)
+	}
+	state.Enable(now)
+	if state.ID != id {
+		t.Error("opting in again changed the installation ID")
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	cfg := &config.Config{
+		Audit:      config.AuditConfig{Enabled: true},
+		Namespaces: map[string][]string{"production": {"payments-*"}},
+		Tiers: map[string]config.TierConfig{
+			"production": {RequireMFA: true, MaxAffected: 10},
+			"staging":    {RequireMFA: true},
+		},
+	}
+	want := []string{"audit", "max_affected", "namespace_tiers", "require_mfa"}
+	if got := Features(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("Features = %v, want %v", got, want)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	state := State{}
+	state.Enable(now)
+	state.Count("block", "drain", false)
+	report := state.Report(&config.Config{}, "v1.2.0", now)
+	if err := Send(server.URL, report, time.Second); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got.ID != state.ID || got.Version != "v1.2.0" || got.Counts["verdict.block"] != 1 {
+		t.Errorf("received %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	if err := Send(failing.URL, report, time.Second); err == nil {
+		t.Error("Send to a failing endpoint succeeded")
+	}
+}
@@ -0,0 +1,138 @@
+// Package ticket validates change tickets passed with --kctl-ticket against
+// a ticket system's REST API, such as Jira or ServiceNow
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+)
+
+// DefaultTimeout bounds the request to the ticket system
+const DefaultTimeout = 10 * time.Second
+
+// DefaultStatusField is where the state is read from when none is configured
+const DefaultStatusField = "status"
+
+// Ticket is a validated change ticket
+type Ticket struct {
+	ID    string `json:"id"`
+	State string `json:"state,omitempty"`
+}
+
+// Validate checks that id matches the configured pattern and, when a URL is
+// configured, that the ticket exists and is in an approved state
+func Validate(cfg config.TicketConfig, id string) (Ticket, error) {
+	t := Ticket{ID: id}
+	if cfg.Pattern != "" {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return t, fmt.Errorf("invalid tickets.pattern: %w", err)
+		}
+		if !re.MatchString(id) {
+			return t, fmt.Errorf("ticket '%s' does not match %s", id, cfg.Pattern)
+		}
+	}
+	if cfg.URL == "" {
+		return t, nil
+	}
+
+	doc, err := fetch(cfg, id)
+	if err != nil {
+		return t, err
+	}
+	field := cfg.StatusField
+	if field == "" {
+		field = DefaultStatusField
+	}
+	state, ok := lookup(doc, field)
+	if !ok {
+		return t, fmt.Errorf("ticket '%s': response has no %s", id, field)
+	}
+	t.State = state
+	if len(cfg.ApprovedStates) == 0 {
+		return t, nil
+	}
+	for _, approved := range cfg.ApprovedStates {
+		if strings.EqualFold(state, approved) {
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("ticket '%s' is %s, not %s", id, state, strings.Join(cfg.ApprovedStates, " or "))
+}
+
+func fetch(cfg config.TicketConfig, id string) (any, error) {
+	timeout := DefaultTimeout
+	if d, err := time.ParseDuration(cfg.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.ReplaceAll(cfg.URL, "{ticket}", url.PathEscape(id)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tickets.url: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	token, err := secrets.Token(cfg.TokenEnv, cfg.TokenSecret)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		scheme := cfg.AuthScheme
+		if scheme == "" {
+			scheme = "Bearer"
+		}
+		req.Header.Set("Authorization", scheme+" "+token)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("looking up ticket '%s': %w", id, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("ticket '%s' not found", id)
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("looking up ticket '%s': %s", id, resp.Status)
+	}
+
+	var doc any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("looking up ticket '%s': %w", id, err)
+	}
+	return doc, nil
+}
+
+// lookup follows a dotted path through decoded JSON. Numeric segments index
+// arrays, so ServiceNow's table API works with "result.0.state".
+func lookup(doc any, path string) (string, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]any:
+			doc = v[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false
+			}
+			doc = v[i]
+		default:
+			return "", false
+		}
+	}
+	switch v := doc.(type) {
+	case string:
+		return v, true
+	case float64, bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package ticket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+)
+
+func TestValidate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/issue/CHG-1":
+			w.Write([]byte(`{"fields":{"status":{"name":"Approved"}}}`))
+		case "/issue/CHG-2":
+			w.Write([]byte(`{"fields":{"status":{"name":"In Review"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("TICKET_TOKEN", "secret")
+
+	cfg := config.TicketConfig{
+		Pattern:        `^CHG-[0-9]+$`,
+		URL:            srv.URL + "/issue/{ticket}",
+		TokenEnv:       "TICKET_TOKEN",
+		StatusField:    "fields.status.name",
+		ApprovedStates: []string{"approved", "Implement"},
+	}
+
+	got, err := Validate(cfg, "CHG-1")
+	if err != nil || got.State != "Approved" {
+		t.Fatalf("Validate(CHG-1) = %+v, %v, want approved", got, err)
+	}
+
+	tests := []struct {
+		id, want string
+	}{
+		{"CHG-2", "is In Review, not approved or Implement"},
+		{"CHG-3", "not found"},
+		{"INC-1", "does not match"},
+	}
+	for _, tt := range tests {
+		if _, err := Validate(cfg, tt.id); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%s) = %v, want error containing %q", tt.id, err, tt.want)
+		}
+	}
+
+	t.Setenv("TICKET_TOKEN", "wrong")
+	if _, err := Validate(cfg, "CHG-1"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Validate with bad token = %v, want 401", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"result": []any{map[string]any{"state": "scheduled", "number": float64(3)}},
+	}
+	tests := []struct {
+		path, want string
+		ok         bool
+	}{
+		{"result.0.state", "scheduled", true},
+		{"result.0.number", "3", true},
+		{"result.1.state", "", false},
+		{"result.state", "", false},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := lookup(doc, tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("lookup(%s) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
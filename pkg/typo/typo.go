@@ -0,0 +1,227 @@
+// Package typo catches mistyped names in commands that change named
+// objects: names the cluster has no object of, with the existing names
+// closest to them, and names a character away from another object's, which
+// are easy to mix up
+package typo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/fuzzy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// maxClosest is how many existing names are suggested for a missing one
+const maxClosest = 3
+
+// Ref is an object a command names, with its resource type as typed
+type Ref struct {
+	Resource string
+	Name     string
+}
+
+// Miss is a named object the cluster doesn't have
+type Miss struct {
+	Ref
+	// Closest are the existing names of the same type closest to Name,
+	// closest first
+	Closest []string
+}
+
+// Lookalike is a named object whose name is a character away from those
+// of other objects of its type
+type Lookalike struct {
+	Ref
+	Similar []string
+}
+
+// Refs returns the objects args name, from "type name..." or
+// "type/name..." operands. Commands selecting objects by label, --all or
+// manifests name none, and neither do names given for several types.
+func Refs(args []string) []Ref {
+	if rbac.HasFlag(args, "-l", "--selector", "--all", "-f", "--filename", "-k", "--kustomize") {
+		return nil
+	}
+	positional := rbac.Positional(args)
+	if len(positional) < 2 {
+		return nil
+	}
+	operands := positional[1:]
+
+	var refs []Ref
+	if strings.Contains(operands[0], "/") {
+		for _, operand := range operands {
+			for _, item := range strings.Split(operand, ",") {
+				resource, name, ok := strings.Cut(item, "/")
+				if !ok || resource == "" || name == "" {
+					return nil
+				}
+				refs = append(refs, Ref{Resource: strings.ToLower(resource), Name: name})
+			}
+		}
+		return refs
+	}
+	if strings.Contains(operands[0], ",") {
+		return nil
+	}
+	for _, name := range operands[1:] {
+		refs = append(refs, Ref{Resource: strings.ToLower(operands[0]), Name: name})
+	}
+	return refs
+}
+
+// Check looks up the objects args name on context, in the namespace args
+// give or the context's default, and returns the ones missing and the
+// ones with lookalikes. A type with no objects at all says nothing: the
+// namespace may not be the one the command runs in, or may not exist.
+func Check(context string, args []string) ([]Miss, []Lookalike, error) {
+	refs := Refs(args)
+	names := make(map[string][]string)
+	var misses []Miss
+	var lookalikes []Lookalike
+	for _, ref := range refs {
+		existing, ok := names[ref.Resource]
+		if !ok {
+			var err error
+			if existing, err = list(context, args, ref.Resource); err != nil {
+				return nil, nil, err
+			}
+			names[ref.Resource] = existing
+		}
+		if len(existing) == 0 {
+			continue
+		}
+		if !contains(existing, ref.Name) {
+			misses = append(misses, Miss{Ref: ref, Closest: Closest(ref.Name, existing)})
+			continue
+		}
+		if similar := similar(ref.Name, existing, refs); len(similar) > 0 {
+			lookalikes = append(lookalikes, Lookalike{Ref: ref, Similar: similar})
+		}
+	}
+	return misses, lookalikes, nil
+}
+
+// list returns the names of the objects of resource in the namespace args
+// select
+func list(context string, args []string, resource string) ([]string, error) {
+	listArgs := []string{"get", resource, "-o", "jsonpath={.items[*].metadata.name}", "--request-timeout=10s"}
+	if context != "" {
+		listArgs = append(listArgs, "--context", context)
+	}
+	if namespace, ok := rbac.Namespace(args); ok {
+		listArgs = append(listArgs, "-n", namespace)
+	}
+	stdout, stderr, exitCode := kubectl.Run(listArgs)
+	if exitCode != 0 {
+		return nil, fmt.Errorf("listing %s failed: %s", resource, strings.TrimSpace(stderr))
+	}
+	return strings.Fields(stdout), nil
+}
+
+// Closest returns up to three of names closest to name: those a few edits
+// away, nearest first, or else its best fuzzy matches
+func Closest(name string, names []string) []string {
+	limit := 1 + len(name)/5
+	type scored struct {
+		name     string
+		distance int
+	}
+	var near []scored
+	for _, n := range names {
+		if d := distance(name, n); d <= limit {
+			near = append(near, scored{n, d})
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		if near[i].distance != near[j].distance {
+			return near[i].distance < near[j].distance
+		}
+		return near[i].name < near[j].name
+	})
+
+	var closest []string
+	for _, s := range near {
+		closest = append(closest, s.name)
+	}
+	if len(closest) == 0 {
+		closest = fuzzy.Find(name, names)
+	}
+	if len(closest) > maxClosest {
+		closest = closest[:maxClosest]
+	}
+	return closest
+}
+
+// similar returns the names a character away from name, other than those
+// the command names itself. Names differing only in a digit, like the pods
+// of a StatefulSet, aren't easily mixed up and are left out.
+func similar(name string, names []string, refs []Ref) []string {
+	var found []string
+	for _, n := range names {
+		if n == name || distance(name, n) != 1 || digitApart(name, n) || named(refs, n) {
+			continue
+		}
+		found = append(found, n)
+	}
+	return found
+}
+
+// digitApart reports whether a and b differ only in one digit
+func digitApart(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			return isDigit(a[i]) && isDigit(b[i])
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func named(refs []Ref, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,100 @@
+package typo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+func TestRefs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []Ref
+	}{
+		{[]string{"delete", "pod", "web-0", "web-1", "-n", "shop"}, []Ref{{"pod", "web-0"}, {"pod", "web-1"}}},
+		{[]string{"scale", "--replicas=3", "Deploy/web"}, []Ref{{"deploy", "web"}}},
+		{[]string{"delete", "deploy/web,svc/web"}, []Ref{{"deploy", "web"}, {"svc", "web"}}},
+		{[]string{"delete", "pod,svc", "web"}, nil},
+		{[]string{"delete", "pods", "-l", "app=web"}, nil},
+		{[]string{"delete", "-f", "app.yaml"}, nil},
+		{[]string{"delete", "pods", "--all"}, nil},
+	}
+	for _, tt := range tests {
+		if got := Refs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Refs(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var listed []string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		listed = append(listed, strings.Join(args, " "))
+		return "payments payment web-0 web-1 checkout-api", "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	misses, lookalikes, err := Check("app-prod", []string{"delete", "deploy", "paymnets", "payments", "web-0", "-n", "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMisses := []Miss{{Ref: Ref{"deploy", "paymnets"}, Closest: []string{"payments"}}}
+	if !reflect.DeepEqual(misses, wantMisses) {
+		t.Errorf("misses = %+v, want %+v", misses, wantMisses)
+	}
+	wantLookalikes := []Lookalike{{Ref: Ref{"deploy", "payments"}, Similar: []string{"payment"}}}
+	if !reflect.DeepEqual(lookalikes, wantLookalikes) {
+		t.Errorf("lookalikes = %+v, want %+v", lookalikes, wantLookalikes)
+	}
+	want := []string{"get deploy -o jsonpath={.items[*].metadata.name} --request-timeout=10s --context app-prod -n shop"}
+	if !reflect.DeepEqual(listed, want) {
+		t.Errorf("listed %q, want %q", listed, want)
+	}
+}
+
+func TestCheckNamespace(t *testing.T) {
+	var listed []string
+	output := ""
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		listed = append(listed, strings.Join(args, " "))
+		return output, "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+
+	// The namespace joined to -n is the one listed
+	output = "web"
+	misses, _, err := Check("", []string{"delete", "deploy", "web", "-nshop"})
+	if err != nil || len(misses) != 0 {
+		t.Errorf("Check of an existing name = %+v, %v, want no misses", misses, err)
+	}
+	if want := "get deploy -o jsonpath={.items[*].metadata.name} --request-timeout=10s -n shop"; listed[0] != want {
+		t.Errorf("listed %q, want %q", listed[0], want)
+	}
+
+	// Nothing listed, as in a namespace that doesn't exist, isn't a miss
+	output = ""
+	if misses, _, err := Check("", []string{"delete", "deploy", "web", "-n", "nowhere"}); err != nil || len(misses) != 0 {
+		t.Errorf("Check with nothing listed = %+v, %v, want no misses", misses, err)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	names := []string{"checkout-api", "checkout-worker", "cart", "catalog"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"chekout-api", []string{"checkout-api"}},
+		{"checkout", []string{"checkout-api", "checkout-worker"}},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		if got := Closest(tt.name, names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Closest(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
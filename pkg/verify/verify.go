@@ -0,0 +1,161 @@
+// Package verify checks that a destructive command had the intended effect:
+// deleted resources are gone, scaled workloads reach their replica count,
+// drained or cordoned nodes are unschedulable
+package verify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// DefaultTimeout is how long a check polls before giving up
+const DefaultTimeout = 30 * time.Second
+
+// pollInterval is the pause between polls
+const pollInterval = 2 * time.Second
+
+// Check is a verifiable expectation about cluster state after a command
+type Check struct {
+	// Description states the expectation, e.g. "pod/web-1 is gone"
+	Description string
+	// probe reports whether the expectation holds and, if not, what was seen
+	probe func() (ok bool, seen string, err error)
+}
+
+// For returns the check for a command run against context. ok is false for
+// commands whose outcome can't be verified (selectors, files, --all, ...).
+func For(context string, args []string) (Check, bool) {
+	positional := rbac.Positional(args)
+	if len(positional) < 2 {
+		return Check{}, false
+	}
+	base := []string{"--context", context, "--request-timeout=10s"}
+	if ns, ok := rbac.FlagValue(args, "-n", "--namespace"); ok {
+		base = append(base, "-n", ns)
+	}
+	base = base[:len(base):len(base)] // probes append to base; never share its array
+	verb, operands := positional[0], positional[1:]
+
+	switch verb {
+	case "delete":
+		if rbac.HasFlag(args, "-f", "--filename", "-k", "--kustomize", "-l", "--selector", "--all", "--all-namespaces", "-A", "--field-selector") {
+			return Check{}, false
+		}
+		refs := resourceRefs(operands)
+		if len(refs) == 0 {
+			return Check{}, false
+		}
+		return Check{
+			Description: strings.Join(refs, ", ") + " gone",
+			probe: func() (bool, string, error) {
+				out, err := get(append(append(base, "get"), append(refs, "--ignore-not-found", "-o", "name")...))
+				if err != nil {
+					return false, "", err
+				}
+				remaining := strings.Fields(out)
+				return len(remaining) == 0, strings.Join(remaining, ", ") + " still present", nil
+			},
+		}, true
+
+	case "scale":
+		replicas, ok := rbac.FlagValue(args, "--replicas")
+		want, err := strconv.Atoi(replicas)
+		refs := resourceRefs(operands)
+		if !ok || err != nil || len(refs) != 1 || rbac.HasFlag(args, "-f", "--filename", "-l", "--selector", "--all") {
+			return Check{}, false
+		}
+		return Check{
+			Description: fmt.Sprintf("%s has %d ready replicas", refs[0], want),
+			probe: func() (bool, string, error) {
+				out, err := get(append(base, "get", refs[0], "-o", "jsonpath={.status.readyReplicas}"))
+				if err != nil {
+					return false, "", err
+				}
+				ready, _ := strconv.Atoi(strings.TrimSpace(out)) // unset means 0
+				return ready == want, fmt.Sprintf("%d ready", ready), nil
+			},
+		}, true
+
+	case "drain", "cordon", "uncordon":
+		if rbac.HasFlag(args, "-l", "--selector") {
+			return Check{}, false
+		}
+		want := verb != "uncordon"
+		state := "cordoned"
+		if !want {
+			state = "schedulable"
+		}
+		return Check{
+			Description: fmt.Sprintf("node %s %s", strings.Join(operands, ", "), state),
+			probe: func() (bool, string, error) {
+				for _, node := range operands {
+					out, err := get(append(base, "get", "node", node, "-o", "jsonpath={.spec.unschedulable}"))
+					if err != nil {
+						return false, "", err
+					}
+					if (strings.TrimSpace(out) == "true") != want {
+						return false, "node " + node + " is not " + state, nil
+					}
+				}
+				return true, "", nil
+			},
+		}, true
+	}
+	return Check{}, false
+}
+
+// Run polls the check until it holds or timeout passes. It returns nil when
+// verified, otherwise an error describing the last observed state.
+func (c Check) Run(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, seen, err := c.probe()
+		if ok {
+			return nil
+		}
+		if time.Now().Add(pollInterval).After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%s after %s", seen, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// resourceRefs turns "TYPE NAME..." or "TYPE/NAME..." operands into
+// TYPE/NAME references
+func resourceRefs(operands []string) []string {
+	if len(operands) == 0 {
+		return nil
+	}
+	if strings.Contains(operands[0], "/") {
+		for _, op := range operands {
+			if !strings.Contains(op, "/") {
+				return nil
+			}
+		}
+		return operands
+	}
+	if len(operands) < 2 || strings.Contains(operands[0], ",") {
+		return nil
+	}
+	refs := make([]string, 0, len(operands)-1)
+	for _, name := range operands[1:] {
+		refs = append(refs, operands[0]+"/"+name)
+	}
+	return refs
+}
+
+func get(args []string) (string, error) {
+	stdout, stderr, exitCode := kubectl.Run(args)
+	if exitCode != 0 {
+		return "", fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
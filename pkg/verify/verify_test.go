@@ -0,0 +1,99 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+)
+
+// fakeKubectl answers "get" calls from a queue of outputs
+func fakeKubectl(t *testing.T, outputs ...string) *[][]string {
+	t.Helper()
+	var calls [][]string
+	previous := kubectl.Run
+	kubectl.Run = func(args []string) (string, string, int) {
+		calls = append(calls, args)
+		out := outputs[0]
+		if len(outputs) > 1 {
+			outputs = outputs[1:]
+		}
+		return out, "", 0
+	}
+	t.Cleanup(func() { kubectl.Run = previous })
+	return &calls
+}
+
+func TestFor_Unverifiable(t *testing.T) {
+	for _, args := range [][]string{
+		{"delete", "-f", "app.yaml"},
+		{"delete", "pods", "-l", "app=web"},
+		{"delete", "pods", "--all"},
+		{"delete", "pods"},
+		{"scale", "deploy/app"},
+		{"apply", "-f", "app.yaml"},
+		{"get", "pods"},
+	} {
+		if _, ok := For("prod", args); ok {
+			t.Errorf("For(%v) should not be verifiable", args)
+		}
+	}
+}
+
+func TestDeleteCheck(t *testing.T) {
+	calls := fakeKubectl(t, "pod/a\n", "")
+	check, ok := For("prod", []string{"-n", "web", "delete", "pod", "a", "b"})
+	if !ok {
+		t.Fatal("delete pod a b should be verifiable")
+	}
+	if check.Description != "pod/a, pod/b gone" {
+		t.Errorf("Description = %q", check.Description)
+	}
+
+	ok, seen, err := check.probe()
+	if ok || err != nil || !strings.Contains(seen, "pod/a") {
+		t.Errorf("first probe = %v, %q, %v; want pod/a still present", ok, seen, err)
+	}
+	if ok, _, _ := check.probe(); !ok {
+		t.Error("second probe should find everything gone")
+	}
+
+	want := "--context prod --request-timeout=10s -n web get pod/a pod/b --ignore-not-found -o name"
+	if got := strings.Join((*calls)[0], " "); got != want {
+		t.Errorf("kubectl args = %q, want %q", got, want)
+	}
+}
+
+func TestScaleCheck(t *testing.T) {
+	fakeKubectl(t, "")
+	check, ok := For("prod", []string{"scale", "deployment", "app", "--replicas=0"})
+	if !ok {
+		t.Fatal("scale should be verifiable")
+	}
+	if ok, _, _ := check.probe(); !ok {
+		t.Error("unset readyReplicas should count as 0")
+	}
+
+	fakeKubectl(t, "1")
+	check, _ = For("prod", []string{"scale", "deploy/app", "--replicas", "3"})
+	if err := check.Run(time.Millisecond); err == nil || !strings.Contains(err.Error(), "1 ready") {
+		t.Errorf("Run = %v, want timeout naming 1 ready", err)
+	}
+}
+
+func TestDrainCheck(t *testing.T) {
+	fakeKubectl(t, "true")
+	check, ok := For("prod", []string{"drain", "node-1", "--ignore-daemonsets"})
+	if !ok {
+		t.Fatal("drain should be verifiable")
+	}
+	if err := check.Run(time.Second); err != nil {
+		t.Errorf("Run = %v, want verified", err)
+	}
+
+	check, _ = For("prod", []string{"uncordon", "node-1"})
+	if ok, _, _ := check.probe(); ok {
+		t.Error("uncordon check should fail while the node is unschedulable")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// Names the binary is installed under. kubectl runs kubectl-enhanced for
+// 'kubectl enhanced ...' and kubectl_complete-enhanced to complete its
+// arguments.
+const (
+	pluginBinary     = "kubectl-enhanced"
+	completionBinary = "kubectl_complete-enhanced"
+)
+
+// progName is how the user invoked kctl, for help texts: "kctl" or
+// "kubectl enhanced"
+var progName = "kctl"
+
+// invokedAs returns the name the binary was run under, without a Windows
+// .exe suffix
+func invokedAs() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
+
+// pluginGlobalFlags are the KUBECTL_PLUGINS_GLOBAL_FLAG_* variables the
+// kubectl plugin conventions use to hand global flags to a plugin, and the
+// flags they stand for
+var pluginGlobalFlags = []struct {
+	env   string
+	flags []string // the first is added; any of them counts as already set
+}{
+	{"KUBECTL_PLUGINS_GLOBAL_FLAG_CONTEXT", []string{"--context"}},
+	{"KUBECTL_PLUGINS_GLOBAL_FLAG_KUBECONFIG", []string{"--kubeconfig"}},
+	{"KUBECTL_PLUGINS_GLOBAL_FLAG_CLUSTER", []string{"--cluster"}},
+	{"KUBECTL_PLUGINS_GLOBAL_FLAG_USER", []string{"--user"}},
+	{"KUBECTL_PLUGINS_GLOBAL_FLAG_NAMESPACE", []string{"--namespace", "-n"}},
+}
+
+// withPluginGlobalFlags prepends the global flags kubectl handed over in
+// the environment, unless args already set them
+func withPluginGlobalFlags(args []string) []string {
+	var flags []string
+	for _, g := range pluginGlobalFlags {
+		value := os.Getenv(g.env)
+		if value == "" || rbac.HasFlag(args, g.flags...) {
+			continue
+		}
+		flags = append(flags, g.flags[0], value)
+	}
+	if len(flags) == 0 {
+		return args
+	}
+	return append(flags, args...)
+}
+
+// kctlCommands are kctl's own first words, offered by completion next to
+// kubectl's commands
+var kctlCommands = []struct{ name, description string }{
+	{"init", "Create a configuration file"},
+	{"shell", "Interactive guarded kubectl prompt"},
+	{"history", "List previously executed commands"},
+	{"rerun", "Re-run a history entry through the rules"},
+	{"alias", "List, add or remove command aliases"},
+	{"macros", "List the macros defined in the config"},
+	{"pick", "Choose an object from a list and run a command on it"},
+	{"ctx", "List or switch contexts"},
+	{"ns", "List or switch the current namespace"},
+	{"lock", "Require confirmation for every mutation"},
+	{"unlock", "Lift the lock"},
+	{"status", "Show the lock and the current context"},
+	{"whoami", "Show the context, identity, rules and lock"},
+	{"mfa", "Set up the TOTP code for critical actions"},
+	{"secret", "Store integration tokens in the OS keyring"},
+	{"generate", "Generate admission policies or RBAC from the rules"},
+	{"schedule", "Run a command later"},
+	{"simulate", "Show the verdict for a command without running it"},
+	{"policy", "Test the rules against expected decisions"},
+	{"audit", "Work with the audit log"},
+	{"serve", "Answer policy decisions over HTTP"},
+	{"proxy", "Serve a cluster's API locally, checked against the rules"},
+	{"ui", "Launch k9s, read-only on production"},
+	{"maintenance", "Housekeeping for kctl's data"},
+	{"cache", "Manage what kctl caches about clusters"},
+	{"self-update", "Install the latest release"},
+	{"profile", "List profiles or set the default one"},
+	{"telemetry", "Opt in to anonymous usage reports"},
+	{"exec-credential", "Issue cluster credentials unless kctl is locked"},
+}
+
+// configCompletions describe kctlConfigCommands for completion
+var configCompletions = []struct{ name, description string }{
+	{"show", "Print the effective configuration and resolved rules"},
+	{"get", "Print one config value"},
+	{"set", "Change one config value"},
+	{"unset", "Remove a config value"},
+	{"sync", "Pull the shared policy repository"},
+	{"validate", "Check the config"},
+}
+
+// handleCompletion answers kubectl's plugin completion protocol: kubectl
+// runs kubectl_complete-enhanced with the words typed after 'enhanced' and
+// reads candidates, one per line with an optional tab and description,
+// ended by ":<directive>". kubectl's own completion is asked for the
+// kubectl words, and kctl's commands are added where they apply.
+func handleCompletion(args []string) int {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	if err := pinKubectl(readConfig()); err != nil {
+		return 1
+	}
+	stdout, _, exitCode := kubectl.ExecuteWithOutput(append([]string{"__complete"}, args...))
+	var candidates []string
+	directive := ":4" // no file completion
+	if exitCode == 0 {
+		for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+			if strings.HasPrefix(line, ":") {
+				directive = line
+				break
+			}
+			if line != "" {
+				candidates = append(candidates, line)
+			}
+		}
+	}
+
+	var own []struct{ name, description string }
+	switch {
+	case len(args) == 1:
+		own = kctlCommands
+	case len(args) == 2 && args[0] == "config":
+		own = configCompletions
+	}
+	word := args[len(args)-1]
+	seen := make(map[string]bool)
+	for _, c := range own {
+		if strings.HasPrefix(c.name, word) {
+			seen[c.name] = true
+			fmt.Printf("%s\t%s\n", c.name, c.description)
+		}
+	}
+	for _, line := range candidates {
+		name, _, _ := strings.Cut(line, "\t")
+		if !seen[name] {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println(directive)
+	return 0
+}
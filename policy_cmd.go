@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policytest"
+)
+
+// handlePolicy dispatches the policy subcommands
+func handlePolicy(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl policy - Work with the rules
+
+Usage:
+  kctl policy test [--config PATH] [FILE]...
+
+Run 'kctl policy <subcommand> --help' for its flags.
+`)
+		return 0
+	}
+	if args[0] == "test" {
+		return policyTest(args[1:])
+	}
+	output.PrintError(fmt.Sprintf("Unknown policy subcommand: %s", args[0]))
+	return 1
+}
+
+// policyTest checks the rules against the expected decisions in tests
+// files and exits non-zero when any differ
+func policyTest(args []string) int {
+	configPath := ""
+	var files []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl policy test - Check the rules against expected decisions
+
+Usage:
+  kctl policy test [--config PATH] [FILE]...
+
+Flags:
+  --config PATH  Config to test. Default: the effective configuration
+
+FILE defaults to ` + policytest.DefaultFile + `, a list of cases:
+
+  tests:
+    - name: prod deletes need confirmation
+      context: payments-prod
+      command: delete pod web-1
+      expect:
+        verdict: confirm        # allow, confirm or block
+        tier: production        # optional, as are the rest
+        rule: delete
+        added_flags: ["--wait=true"]
+    - context: renamed-ctx
+      server: https://api.prod.internal:6443   # for servers patterns
+      command: drain node-1
+      expect: {verdict: block}
+
+Cases are evaluated like 'kctl serve' decisions: by the rules alone, without
+the local lock, tickets or anything asked of the cluster. Exits 1 if any
+case fails, so it can run in the policy repository's CI.
+`)
+			return 0
+		case "--config":
+			if i+1 >= len(args) {
+				output.PrintError("--config requires a value")
+				return 1
+			}
+			configPath = args[i+1]
+			i++
+		default:
+			files = append(files, args[i])
+		}
+	}
+	if len(files) == 0 {
+		files = []string{policytest.DefaultFile}
+	}
+
+	cfg := readPinnedConfig()
+	if configPath != "" {
+		var err error
+		if cfg, err = config.LoadFromPath(configPath); err != nil {
+			output.PrintError(fmt.Sprintf("Could not load %s: %v", configPath, err))
+			return 1
+		}
+	}
+
+	passed, failed := 0, 0
+	for _, file := range files {
+		cases, err := policytest.Load(file)
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		for _, result := range policytest.Run(cfg, cases) {
+			if result.Passed() {
+				passed++
+				fmt.Printf("PASS  %s\n", result.Case.Label())
+				continue
+			}
+			failed++
+			fmt.Printf("FAIL  %s\n", result.Case.Label())
+			for _, problem := range result.Problems {
+				fmt.Printf("      %s\n", problem)
+			}
+			if result.Decision.Reason != "" {
+				fmt.Printf("      (%s)\n", result.Decision.Reason)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+const profileFlag = "--kctl-profile"
+
+// profileEnv names a profile for every command of a shell session
+const profileEnv = "KCTL_PROFILE"
+
+// extractProfileFlag removes --kctl-profile NAME (or --kctl-profile=NAME)
+// from args so it never reaches kubectl, returning the profile name
+func extractProfileFlag(args []string) (string, []string) {
+	profile := ""
+	filteredArgs := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == profileFlag && i+1 < len(args):
+			profile = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], profileFlag+"="):
+			profile = strings.TrimPrefix(args[i], profileFlag+"=")
+		default:
+			filteredArgs = append(filteredArgs, args[i])
+		}
+	}
+	return profile, filteredArgs
+}
+
+// selectProfile activates the profile named by --kctl-profile, else
+// KCTL_PROFILE, else meta.yaml's default. A profile without a config file is
+// an error, so a typo never runs commands under the default rules; 'init'
+// and 'profile' may name one that doesn't exist yet.
+func selectProfile(flag string, args []string) error {
+	profile := flag
+	if profile == "" {
+		profile = os.Getenv(profileEnv)
+	}
+	if profile == "" {
+		meta, err := config.LoadMeta()
+		if err != nil {
+			return err
+		}
+		profile = meta.Profile
+	}
+	if err := config.SetProfile(profile); err != nil {
+		return err
+	}
+	if profile == "" || len(args) > 0 && (args[0] == "init" || args[0] == "profile") {
+		return nil
+	}
+	if _, err := os.Stat(config.ConfigPath()); os.IsNotExist(err) {
+		return fmt.Errorf("profile %q has no config at %s; create it with '%s %s %s init'", profile, config.ConfigPath(), progName, profileFlag, profile)
+	}
+	return nil
+}
+
+// handleProfile lists the profiles and sets the default one
+func handleProfile(args []string) int {
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		fmt.Printf(`%s profile - Switch between separate sets of rules
+
+Usage:
+  %[1]s profile               List the profiles, marking the active one
+  %[1]s profile use NAME      Make NAME the default profile
+  %[1]s profile use default   Go back to config.yaml
+
+Each profile is a complete config file, profiles/NAME.yaml in %s.
+A command uses the profile named by %s NAME, else the %s
+environment variable, else the default set with 'profile use'. Create a
+profile's config with '%[1]s %[3]s NAME init'.
+`, progName, config.ConfigDir(), profileFlag, profileEnv)
+		return 0
+	}
+
+	switch {
+	case len(args) == 0:
+		profiles, err := config.Profiles()
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		active := config.Profile()
+		for _, name := range append([]string{""}, profiles...) {
+			marker := "  "
+			if name == active {
+				marker = "* "
+			}
+			if name == "" {
+				fmt.Printf("%sdefault (config.yaml)\n", marker)
+			} else {
+				fmt.Printf("%s%s\n", marker, name)
+			}
+		}
+		return 0
+	case len(args) == 2 && args[0] == "use":
+		name := args[1]
+		if name == "default" {
+			name = ""
+		}
+		if err := config.CheckProfileName(name); err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		if name != "" {
+			if _, err := os.Stat(config.ProfilePath(name)); os.IsNotExist(err) {
+				output.PrintError(fmt.Sprintf("Profile %q has no config at %s; create it with '%s %s %s init'", name, config.ProfilePath(name), progName, profileFlag, name))
+				return 1
+			}
+		}
+		meta, err := config.LoadMeta()
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		meta.Profile = name
+		if err := config.SaveMeta(meta); err != nil {
+			output.PrintError(fmt.Sprintf("Could not save %s: %v", config.MetaPath(), err))
+			return 1
+		}
+		if name == "" {
+			output.PrintSuccess("Using the default config.yaml")
+		} else {
+			output.PrintSuccess(fmt.Sprintf("Using profile %s by default", name))
+		}
+		if env := os.Getenv(profileEnv); env != "" {
+			output.PrintWarning(fmt.Sprintf("%s=%s overrides the default in this shell", profileEnv, env))
+		}
+		return 0
+	default:
+		output.PrintError(fmt.Sprintf("Usage: %s profile [use NAME]", progName))
+		return 1
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/apiproxy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// Defaults of kctl proxy, as for kubectl proxy
+const (
+	defaultProxyAddress = "127.0.0.1"
+	defaultProxyPort    = "8001"
+)
+
+// handleProxy serves the API of a context locally, like 'kubectl proxy',
+// deciding each request with the rules first
+func handleProxy(args []string) int {
+	address, port := defaultProxyAddress, defaultProxyPort
+	contextName := ""
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		switch flag {
+		case "--help", "-h":
+			fmt.Print(`kctl proxy - Serve a cluster's API locally, checked against the rules
+
+Usage:
+  kctl proxy [--context NAME] [--port PORT] [--address ADDR]
+
+Flags:
+  --context NAME   Context to serve. Default: the current context
+  --port PORT      Local port. Default: ` + defaultProxyPort + `
+  --address ADDR   Listen address. Default: ` + defaultProxyAddress + `
+
+Like 'kubectl proxy', but every request is decided by its verb and path as
+the kubectl command it amounts to: DELETE /api/v1/namespaces/shop is
+'delete namespaces shop', PATCH .../deployments/web/scale is a scale. Point
+curl, k9s or scripts at it instead of the API server. Requests the rules
+block, or that need confirmation, get a 403 explaining why; run those
+through kctl. Mutating requests are audited, and the rules are reloaded when
+the config changes.
+`)
+			return 0
+		case "--context", "--port", "-p", "--address":
+			if !hasValue {
+				if i+1 >= len(args) {
+					output.PrintError(fmt.Sprintf("%s requires a value", flag))
+					return 1
+				}
+				i++
+				value = args[i]
+			}
+			switch flag {
+			case "--context":
+				contextName = value
+			case "--port", "-p":
+				port = value
+			case "--address":
+				address = value
+			}
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+
+	cfg := loadConfig()
+	if contextName == "" {
+		current, err := kubectl.GetCurrentContext()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+			return 1
+		}
+		contextName = current
+	}
+	if err := selectKubectl(cfg, contextName, nil); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dir, err := os.MkdirTemp("", "kctl-proxy-")
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not start kubectl proxy: %v", err))
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "kubectl.sock")
+	if err := startKubectlProxy(ctx, contextName, socket); err != nil {
+		output.PrintError(fmt.Sprintf("Could not start kubectl proxy: %v", err))
+		return 1
+	}
+
+	watcher := watchConfig(cfg)
+	go watcher.Run(config.DefaultWatchInterval, ctx.Done(), reportReload)
+	handler := apiproxy.Handler(apiproxy.Options{
+		// kubectl proxy only accepts requests for localhost
+		Upstream: &url.URL{Scheme: "http", Host: "localhost"},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+		Context: contextName,
+		Config:  watcher.Config,
+		Decide: func(cfg *config.Config, args []string) policy.Decision {
+			return policy.ApplyLock(policy.Evaluate(cfg, contextName, args), currentLock())
+		},
+		Record: func(cfg *config.Config, d policy.Decision, forwarded bool) {
+			outcome := audit.OutcomeExecuted
+			if !forwarded {
+				outcome = audit.OutcomeBlocked
+			}
+			recordAudit(cfg, d, audit.Entry{Outcome: outcome})
+		},
+	})
+
+	addr := net.JoinHostPort(address, port)
+	if !isLoopback(address) {
+		output.PrintWarning(fmt.Sprintf("Serving on %s; anyone who can reach it acts with your credentials", addr))
+	}
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	output.PrintSuccess(fmt.Sprintf("Serving %s (%s) on http://%s", contextName, watcher.Config().GetClusterRules(contextName).Tier, addr))
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		output.PrintError(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// startKubectlProxy runs 'kubectl proxy' for context on the unix socket
+// at socket, until ctx is done. The socket's directory is private, so only
+// kctl proxy reaches the API through it, never a client going around the
+// rules.
+func startKubectlProxy(ctx context.Context, contextName, socket string) error {
+	cmd := exec.CommandContext(ctx, kubectl.Binary, "--context", contextName, "proxy", "--unix-socket="+socket)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+
+	// kubectl prints "Starting to serve on /tmp/kctl-proxy-1/kubectl.sock";
+	// the rest of its output is drained so it never blocks
+	found := make(chan struct{}, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		sent := false
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "Starting to serve on ") && !sent {
+				found <- struct{}{}
+				sent = true
+			}
+		}
+		if !sent {
+			close(found)
+		}
+	}()
+	select {
+	case _, ok := <-found:
+		if !ok {
+			return fmt.Errorf("kubectl proxy exited")
+		}
+		return nil
+	case <-time.After(15 * time.Second):
+		cmd.Process.Kill()
+		return fmt.Errorf("kubectl proxy didn't start within 15s")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/quota"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// quotaNotes warns, on tiers with check_quota, about the objects an apply
+// or create adds that their namespace's ResourceQuotas or LimitRanges
+// won't admit. Dry runs and commands run offline aren't checked; if the
+// dry run fails, kubectl reports the problem.
+func quotaNotes(decision policy.Decision) []string {
+	if !decision.Rules.CheckQuota || decision.Action != rbac.ActionApply && decision.Action != rbac.ActionCreate ||
+		decision.Offline || rbac.IsDryRun(decision.Args) {
+		return nil
+	}
+	warnings, err := quota.Check(decision.Context, decision.Args)
+	if err != nil {
+		return nil
+	}
+	return warnings
+}
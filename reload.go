@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/gitsync"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// watchConfig returns a Watcher that reloads cfg when the config file, or
+// the shared policy it is layered on, changes. The shared policy is pulled
+// on its sync_interval as the Watcher checks.
+func watchConfig(cfg *config.Config) *config.Watcher {
+	w := config.NewWatcher(cfg, func() (*config.Config, error) {
+		cfg, err := loadConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		return prepareConfig(cfg), nil
+	}, configFiles)
+	w.Sync = syncSourceIfDue
+	return w
+}
+
+// configFiles returns the config file and, when it names a shared policy,
+// the synced copy of that
+func configFiles() []string {
+	path := config.ConfigPath()
+	files := []string{path}
+	if src, err := config.LoadSource(path); err == nil && src.Type != "" {
+		if shared, err := gitsync.ConfigFile(src); err == nil {
+			files = append(files, shared)
+		}
+	}
+	return files
+}
+
+// syncSourceIfDue pulls the shared policy repository when it is due
+func syncSourceIfDue() {
+	src, err := config.LoadSource(config.ConfigPath())
+	if err != nil || src.Type == "" || gitsync.Validate(src) != nil || !gitsync.Due(src) {
+		return
+	}
+	if err := gitsync.Sync(src, autoSyncTimeout); err != nil {
+		output.PrintWarning(fmt.Sprintf("Could not sync shared config (using last synced copy): %v", err))
+	}
+}
+
+// reportReload logs the outcome of a config check that found a change
+func reportReload(reloaded bool, err error) {
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Config changed but could not be loaded: %v (keeping the previous rules)", err))
+		return
+	}
+	output.PrintInfo(fmt.Sprintf("Config changed; reloaded the rules from %s", strings.Join(configFiles(), ", ")))
+}
+
+// checkReload checks for config changes once, logging a reload
+func checkReload(w *config.Watcher) {
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		reportReload(reloaded, err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// namespacesToRetype returns the namespaces a delete removes, named on the
+// command line or as Namespace objects in its -f manifests, whose names must
+// be typed to confirm it. It returns nil when defaults.retype_namespace_deletes
+// is off. err is set when manifests that may hold namespaces can't be read:
+// remote files, kustomizations and files that don't parse.
+func namespacesToRetype(cfg *config.Config, decision policy.Decision) (names []string, err error) {
+	if !cfg.Defaults.RetypesNamespaceDeletes() || decision.Action != rbac.ActionDelete || rbac.IsDryRun(decision.Args) {
+		return nil, nil
+	}
+	names = rbac.DeletedNamespaces(decision.Args)
+	if k := rbac.Kustomizations(decision.Args); len(k) > 0 {
+		return names, fmt.Errorf("can't tell which namespaces kustomization %s deletes", strings.Join(k, ", "))
+	}
+	files := rbac.Filenames(decision.Args)
+	if unread := manifest.Unread(files); len(unread) > 0 {
+		return names, fmt.Errorf("can't tell which namespaces %s deletes", strings.Join(unread, ", "))
+	}
+	if len(files) == 0 {
+		return names, nil
+	}
+	objects, err := manifest.Load(files, rbac.HasFlag(decision.Args, "-R", "--recursive"))
+	if err != nil {
+		return names, fmt.Errorf("can't tell which namespaces the manifests delete: %v", err)
+	}
+	for _, o := range objects {
+		if o.Kind == "Namespace" && o.Name != "" {
+			names = append(names, o.Name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rollout"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// watchRollouts follows the rollouts a confirmed rollout restart or apply
+// started, on tiers with watch_rollouts. For each that fails it prints the
+// 'rollout undo' taking it back and, at a terminal, offers to run it; the
+// undo is checked against the rules like any other command.
+func watchRollouts(cfg *config.Config, decision policy.Decision) {
+	wait := decision.Rules.RolloutWait()
+	if wait <= 0 || decision.Verdict != policy.Confirm || decision.Offline || rbac.IsDryRun(decision.Args) {
+		return
+	}
+	for _, w := range rollout.Workloads(decision.Args) {
+		output.PrintSublog(fmt.Sprintf("Watching the rollout of %s (up to %s)", w, wait))
+		err := rollout.Status(decision.Context, w, wait)
+		if err == nil {
+			output.PrintSuccess(fmt.Sprintf("Rolled out %s", w))
+			continue
+		}
+		output.PrintWarning(fmt.Sprintf("The rollout of %s failed: %v", w, err))
+		undo := rollout.UndoArgs(decision.Context, w)
+		output.PrintSublog("To roll it back, run:")
+		output.PrintSublog("  kctl " + shell.JoinArgs(undo))
+		if !output.IsStdinTerminal() || !output.PromptConfirmation(fmt.Sprintf("Roll back %s now?", w), "") {
+			continue
+		}
+		runGuarded(cfg, decision.Context, undo, false)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/redact"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/schedule"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// schedulePollInterval is how often 'kctl schedule run --loop' checks the
+// queue
+const schedulePollInterval = 30 * time.Second
+
+// handleSchedule queues kubectl commands to run later and runs them when due
+func handleSchedule(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl schedule - Run a command later
+
+Usage:
+  kctl schedule --at TIME [--approve] <kubectl-args>
+  kctl schedule list
+  kctl schedule cancel <id>
+  kctl schedule run [--loop]
+
+Flags:
+  --at TIME   When to run: a clock time (22:00, the next time it comes
+              round), a duration from now (90m) or an RFC 3339 timestamp
+  --approve   Give the confirmation now so the command can run unattended
+  --loop      Keep running, checking the queue every 30s
+
+The command is checked against the rules when it is scheduled, and blocked
+commands are refused. It is checked again when it runs, against the context
+it was scheduled for. A command needing confirmation asks for it when it
+runs unless it was scheduled with --approve. 'kctl schedule run' executes
+the due commands; run it from cron or a systemd timer, or keep
+'kctl schedule run --loop' open in a terminal.
+`)
+		return 0
+	}
+
+	switch args[0] {
+	case "list":
+		return listScheduled()
+	case "cancel":
+		return cancelScheduled(args[1:])
+	case "run":
+		return runScheduled(args[1:])
+	}
+	return scheduleCommand(args)
+}
+
+// scheduleCommand checks a command against the rules and queues it
+func scheduleCommand(args []string) int {
+	var at time.Time
+	approve := false
+	for len(args) > 0 {
+		if args[0] == "--approve" {
+			approve = true
+			args = args[1:]
+			continue
+		}
+		if args[0] != "--at" {
+			break
+		}
+		if len(args) < 2 {
+			output.PrintError("--at requires a value")
+			return 1
+		}
+		t, err := schedule.ParseTime(args[1], time.Now())
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		at = t
+		args = args[2:]
+	}
+	if at.IsZero() {
+		output.PrintError("--at is required")
+		return 1
+	}
+	if len(args) == 0 {
+		output.PrintError("No kubectl command to schedule")
+		return 1
+	}
+
+	cfg := loadConfig()
+	ticketID, args := extractTicketFlag(args)
+	kubectl.UseKubeconfigFrom(args)
+	context, err := resolveContext(args)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		return 1
+	}
+	// Pin the context; the current one may have changed by the time it runs
+	if !hasContextFlag(args) {
+		args = append([]string{"--context", context}, args...)
+	}
+
+	decision := policy.ApplyLock(policy.Evaluate(cfg, context, args), currentLock())
+	if decision.Verdict == policy.Block {
+		output.PrintBlocked(decision.Action, context, decision.Reason, guidance(decision))
+		return 1
+	}
+	decision.Ticket = ticketID
+	if _, err := checkTicket(cfg, decision); err != nil {
+		output.PrintBlocked(decision.Action, context, err.Error(), guidance(decision))
+		return 1
+	}
+
+	job := schedule.Job{At: at, Context: context, Args: args, Ticket: ticketID, Profile: config.Profile(), CreatedAt: time.Now().UTC()}
+	if decision.Verdict == policy.Confirm {
+		if approve {
+			output.PrintConfirmationHeader(rbac.DescribeAction(decision.Action), context, decision.Tier, guidance(decision))
+			output.PrintSublog(fmt.Sprintf("Command: kubectl %s", shell.JoinArgs(redact.Args(args))))
+			output.PrintSublog(fmt.Sprintf("Runs at: %s", at.Local().Format("2006-01-02 15:04")))
+			fmt.Fprintln(os.Stderr)
+			if !output.PromptConfirmation("Approve it to run unattended?", decision.Rules.ConfirmationPhrase) {
+				output.PrintSublog("Not scheduled")
+				return 0
+			}
+			job.PreApproved = true
+		} else {
+			output.PrintSublog(fmt.Sprintf("%s; it will ask for confirmation when it runs (--approve to confirm now)", decision.Reason))
+		}
+	}
+
+	job, err = schedule.Add(schedule.Path(), job)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not save the schedule: %v", err))
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Scheduled #%d for %s: kubectl %s", job.ID, at.Local().Format("2006-01-02 15:04"), shell.JoinArgs(args)))
+	return 0
+}
+
+// listScheduled prints the queue
+func listScheduled() int {
+	jobs, err := schedule.Load(schedule.Path())
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read the schedule: %v", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		fmt.Println("Nothing scheduled")
+		return 0
+	}
+	for _, job := range jobs {
+		approved := ""
+		if job.PreApproved {
+			approved = "  (pre-approved)"
+		}
+		fmt.Printf("%5d  %s  %s  %s%s\n", job.ID, job.At.Local().Format("2006-01-02 15:04"),
+			job.Context, shell.JoinArgs(job.Args), approved)
+	}
+	return 0
+}
+
+// cancelScheduled removes a job from the queue
+func cancelScheduled(args []string) int {
+	if len(args) != 1 {
+		output.PrintError("Usage: kctl schedule cancel <id>")
+		return 1
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Invalid id: %s", args[0]))
+		return 1
+	}
+	removed, err := schedule.Remove(schedule.Path(), id)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not update the schedule: %v", err))
+		return 1
+	}
+	if !removed {
+		output.PrintError(fmt.Sprintf("No scheduled command #%d", id))
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Cancelled #%d", id))
+	return 0
+}
+
+// runScheduled executes the due jobs, once or, with --loop, until
+// interrupted. It returns 1 if any job failed.
+func runScheduled(args []string) int {
+	loop := false
+	for _, arg := range args {
+		if arg != "--loop" {
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", arg))
+			return 1
+		}
+		loop = true
+	}
+
+	cfg := loadConfig()
+	status := 0
+	for {
+		if runDueJobs(cfg) != 0 {
+			status = 1
+		}
+		if !loop {
+			return status
+		}
+		time.Sleep(schedulePollInterval)
+	}
+}
+
+// profileConfig returns the config of profile, which is cfg when profile is
+// the active one. Unlike readConfig, a missing or broken config is an error
+// rather than the defaults.
+func profileConfig(cfg *config.Config, profile string) (*config.Config, error) {
+	active := config.Profile()
+	if profile == active {
+		return cfg, nil
+	}
+	if err := config.SetProfile(profile); err != nil {
+		return nil, err
+	}
+	defer config.SetProfile(active)
+	other, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return prepareConfig(other), nil
+}
+
+// runDueJobs takes the due jobs off the queue and runs each through the
+// rules again
+func runDueJobs(cfg *config.Config) int {
+	path := schedule.Path()
+	jobs, err := schedule.Load(path)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read the schedule: %v", err))
+		return 1
+	}
+
+	status := 0
+	for _, job := range schedule.Due(jobs, time.Now()) {
+		// Dequeue first so a crash mid-run can't run it twice, and skip it
+		// when another 'schedule run' already took it
+		removed, err := schedule.Remove(path, job.ID)
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Could not update the schedule: %v", err))
+			return 1
+		}
+		if !removed {
+			continue
+		}
+		args := job.Args
+		if job.Ticket != "" {
+			args = append([]string{ticketFlag, job.Ticket}, args...)
+		}
+		output.PrintSublog(fmt.Sprintf("Running scheduled #%d: kubectl %s", job.ID, shell.JoinArgs(job.Args)))
+		jobCfg, err := profileConfig(cfg, job.Profile)
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Scheduled #%d not run: profile %s: %v", job.ID, job.Profile, err))
+			status = 1
+			continue
+		}
+		restore := kubectl.UseKubeconfigFrom(args)
+		if code := runGuarded(jobCfg, job.Context, args, job.PreApproved); code != 0 {
+			output.PrintWarning(fmt.Sprintf("Scheduled #%d exited with %d", job.ID, code))
+			status = 1
+		}
+		restore()
+	}
+	return status
+}
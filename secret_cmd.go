@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+)
+
+// handleSecret manages the keyring entries integrations read their tokens
+// from
+func handleSecret(args []string) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`kctl secret - Store integration tokens in the OS keyring
+
+Usage:
+  kctl secret set <name>   Store a value, typed at a prompt or piped on stdin
+  kctl secret get <name>   Print a stored value
+  kctl secret rm <name>    Delete a stored value
+
+Refer to a stored token from the config with token_secret, e.g.
+tickets.token_secret, oncall.token_secret or source.token_secret. Values
+live in the macOS Keychain, the Windows Credential Manager, or the Secret
+Service on Linux, never in config.yaml.
+`)
+		return 0
+	}
+	if len(args) != 2 {
+		output.PrintError(fmt.Sprintf("Usage: kctl secret %s <name>", args[0]))
+		return 1
+	}
+	name := args[1]
+
+	switch args[0] {
+	case "set":
+		value, ok := readSecretValue(name)
+		if !ok {
+			return 1
+		}
+		if value == "" {
+			output.PrintError("Refusing to store an empty value")
+			return 1
+		}
+		if err := secrets.Set(name, value); err != nil {
+			output.PrintError(fmt.Sprintf("Could not store secret: %v", err))
+			return 1
+		}
+		output.PrintSuccess(fmt.Sprintf("Stored secret '%s'", name))
+	case "get":
+		value, err := secrets.Get(name)
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Could not read secret '%s': %v", name, err))
+			return 1
+		}
+		fmt.Println(value)
+	case "rm":
+		if err := secrets.Delete(name); err != nil {
+			output.PrintError(fmt.Sprintf("Could not delete secret '%s': %v", name, err))
+			return 1
+		}
+		output.PrintSuccess(fmt.Sprintf("Deleted secret '%s'", name))
+	default:
+		output.PrintError(fmt.Sprintf("Unknown secret command: %s", args[0]))
+		return 1
+	}
+	return 0
+}
+
+// readSecretValue prompts for the value without echo, or reads the first
+// line of piped stdin, so values never end up in shell history
+func readSecretValue(name string) (string, bool) {
+	if output.IsStdinTerminal() {
+		return output.PromptSecret(fmt.Sprintf("Value for '%s'", name))
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		output.PrintError("No value on stdin")
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
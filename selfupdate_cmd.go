@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/selfupdate"
+)
+
+// handleSelfUpdate replaces the running binary with the latest release
+func handleSelfUpdate(args []string) int {
+	checkOnly, force := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--help", "-h":
+			fmt.Printf(`%s self-update - Install the latest release
+
+Usage:
+  %s self-update [--check] [--force]
+
+Flags:
+  --check  Only report whether a newer release exists
+  --force  Install the latest release even if it isn't newer, or this is a
+           development build
+
+Downloads the release binary for this platform from GitHub
+(update.repository, default %s), checks it against the release's
+%s and, when update.public_key is set, that file's Ed25519 signature,
+then swaps it in for the installed binary in one rename.
+`, progName, progName, selfupdate.DefaultRepository, selfupdate.ChecksumsAsset)
+			return 0
+		case "--check":
+			checkOnly = true
+		case "--force":
+			force = true
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", arg))
+			return 1
+		}
+	}
+
+	cfg := readConfig()
+	release, err := selfupdate.Latest(cfg.Update, selfupdate.DefaultTimeout)
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	newer := selfupdate.Newer(release.Version, Version)
+	if checkOnly {
+		if newer {
+			fmt.Printf("%s is available (you have %s)\n", release.Version, Version)
+		} else {
+			fmt.Printf("%s is the latest release (you have %s)\n", release.Version, Version)
+		}
+		return 0
+	}
+	if !newer && !force {
+		fmt.Printf("Already up to date (%s; latest release %s). Use --force to reinstall.\n", Version, release.Version)
+		return 0
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Can't find the running binary: %v", err))
+		return 1
+	}
+	if cfg.Update.PublicKey == "" {
+		output.PrintWarning("update.public_key is not set; checking the checksum only, not a signature")
+	}
+	if err := selfupdate.Install(cfg.Update, release, exe); err != nil {
+		output.PrintError(fmt.Sprintf("Update failed: %v", err))
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Updated %s to %s", Version, release.Version))
+	return 0
+}
+
+// noticeUpdate prints a notice when update.check is on and a newer release
+// exists. The release server is asked at most once a day, and only for
+// interactive use.
+func noticeUpdate(cfg *config.Config) {
+	if !cfg.Update.Check || !output.IsStdinTerminal() {
+		return
+	}
+	if latest := selfupdate.Notice(cfg.Update, Version, selfupdate.StampPath(), time.Now()); latest != "" {
+		output.PrintInfo(fmt.Sprintf("kctl %s is available (you have %s); run '%s self-update'", latest, Version, progName))
+	}
+}
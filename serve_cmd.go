@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/secrets"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/server"
+)
+
+// defaultServeAddr keeps the decision server local unless asked otherwise
+const defaultServeAddr = "127.0.0.1:8787"
+
+// handleServe answers policy decisions over HTTP until interrupted
+func handleServe(args []string) int {
+	addr := defaultServeAddr
+	var tokenEnv, tokenSecret string
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		switch flag {
+		case "--help", "-h":
+			fmt.Print(`kctl serve - Answer policy decisions over HTTP
+
+Usage:
+  kctl serve [--addr HOST:PORT] [--token-env VAR | --token-secret NAME]
+
+Flags:
+  --addr HOST:PORT     Listen address. Default: ` + defaultServeAddr + `
+  --token-env VAR      Require "Authorization: Bearer <token>" with the token
+                       from environment variable VAR
+  --token-secret NAME  ...or from keyring entry NAME (see 'kctl secret')
+
+Endpoints:
+  POST /v1/decision  {"context": "app-prod", "args": ["delete", "pod", "web"],
+                      "user": "bot@ci"} returns the decision as JSON, with
+                     verdict allow, confirm or block, the tier and the reason
+  GET  /healthz      Liveness check
+
+The rules are reloaded when the config file or the shared policy changes;
+a change that doesn't load keeps the previous rules. Nothing is executed:
+callers decide what to do with the verdict.
+`)
+			return 0
+		case "--addr", "--token-env", "--token-secret":
+			if i+1 >= len(args) {
+				output.PrintError(fmt.Sprintf("%s requires a value", flag))
+				return 1
+			}
+			i++
+			switch flag {
+			case "--addr":
+				addr = args[i]
+			case "--token-env":
+				tokenEnv = args[i]
+			case "--token-secret":
+				tokenSecret = args[i]
+			}
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", flag))
+			return 1
+		}
+	}
+
+	token, err := secrets.Token(tokenEnv, tokenSecret)
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Could not read the API token: %v", err))
+		return 1
+	}
+	if host, _, err := net.SplitHostPort(addr); token == "" && (err != nil || !isLoopback(host)) {
+		output.PrintWarning(fmt.Sprintf("Serving on %s without a token; anyone who can reach it can read decisions", addr))
+	}
+
+	watcher := watchConfig(readPinnedConfig())
+	go watcher.Run(config.DefaultWatchInterval, nil, reportReload)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           server.HandlerFor(watcher.Config, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	output.PrintSuccess(fmt.Sprintf("Serving policy decisions on http://%s/v1/decision", addr))
+	if err := srv.ListenAndServe(); err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	return 0
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
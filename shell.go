@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// handleShell runs the interactive guarded kubectl prompt
+func handleShell(args []string) int {
+	for _, arg := range args {
+		switch arg {
+		case "--help", "-h":
+			fmt.Print(`kctl shell - Interactive guarded kubectl prompt
+
+Usage:
+  kctl shell
+
+Description:
+  Opens a prompt showing the current context, tier and namespace. Each
+  entered kubectl command (without the 'kubectl' prefix) is evaluated
+  against the configured rules before it runs, exactly like 'kctl <args>';
+  'run NAME param=value...' expands the macro NAME first. Append --yes to a command to skip its confirmation prompt. Changes to the
+  config file or the shared policy take effect at the next prompt.
+
+Keys:
+  Tab         Complete verbs, resource types, object names, namespaces
+              (after -n) and contexts (after --context) from the cluster
+  Up/Down     Browse command history
+  Ctrl-C      Discard the current line
+  Ctrl-D      Exit (or type 'exit')
+`)
+			return 0
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", arg))
+			return 1
+		}
+	}
+
+	watcher := watchConfig(loadConfig())
+
+	err := shell.Run(shell.Options{
+		History:  historyLines(),
+		Complete: clusterCompletions(),
+		Prompt: func() string {
+			checkReload(watcher)
+			context, err := kubectl.GetCurrentContext()
+			if err != nil {
+				return "kctl (no context)> "
+			}
+			rules := watcher.Config().GetClusterRules(context)
+			return output.FormatPrompt(context, rules.Tier, kubectl.GetNamespace(nil))
+		},
+		Run: func(args []string) int {
+			args, err := expandAlias(watcher.Config(), args)
+			if err != nil {
+				output.PrintError(err.Error())
+				return 1
+			}
+			skipConfirm, args := extractYesFlag(args)
+			if expanded, ok, err := expandMacro(watcher.Config(), args); ok {
+				if err != nil {
+					output.PrintError(err.Error())
+					return 1
+				}
+				args = expanded
+			}
+			defer kubectl.UseKubeconfigFrom(args)()
+			context, err := resolveContext(args)
+			if err != nil {
+				output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+				return 1
+			}
+			return runGuarded(watcher.Config(), context, args, skipConfirm)
+		},
+	})
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	return 0
+}
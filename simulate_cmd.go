@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/shell"
+)
+
+// simulation is what would happen to a command, without running it
+type simulation struct {
+	policy.Decision
+	// Requirements are the checks the command would still have to pass
+	// after the verdict: ticket, on-call, approval, TOTP code, ...
+	Requirements []string `json:"requirements,omitempty"`
+}
+
+// handleSimulate evaluates a command against the rules and prints the
+// decision without running anything
+func handleSimulate(args []string) int {
+	context, jsonOutput := "", false
+	for len(args) > 0 && args[0] != "--" {
+		switch args[0] {
+		case "--help", "-h":
+			fmt.Print(`kctl simulate - Show how the rules treat a command, without running it
+
+Usage:
+  kctl simulate [--context NAME] [-o json] -- <kubectl-args>
+
+Flags:
+  --context NAME  Evaluate as if NAME were the current context. Default: the
+                  current context. A --context in the kubectl arguments is
+                  used as given, as it would be when run; NAME must match it.
+  -o json         Print the decision as JSON
+
+Prints the verdict (allow, confirm or block), the rule that decided it, the
+flags the rules would add and the checks still required before the command
+could run. The local lock applies, as it would when run. Nothing is sent to
+the cluster: cluster_meta tier lookups are skipped and max_affected is
+reported, not counted.
+`)
+			return 0
+		case "--context", "-o", "--output":
+			if len(args) < 2 {
+				output.PrintError(fmt.Sprintf("%s requires a value", args[0]))
+				return 1
+			}
+			if args[0] == "--context" {
+				context = args[1]
+			} else if args[1] == "json" {
+				jsonOutput = true
+			} else {
+				output.PrintError(fmt.Sprintf("Unknown output format: %s (want json)", args[1]))
+				return 1
+			}
+			args = args[2:]
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s (separate the kubectl arguments with --)", args[0]))
+			return 1
+		}
+	}
+	if len(args) < 2 {
+		output.PrintError("No kubectl command to simulate; put it after --")
+		return 1
+	}
+	args = args[1:]
+
+	cfg := readPinnedConfig()
+	cfg.TierLookup = nil // would query the cluster
+	cfg.ResourceLookup = nil
+	kubectl.UseKubeconfigFrom(args)
+	if named, ok := kubectl.GetContextFromArgs(args); ok {
+		if context != "" && context != named {
+			output.PrintError(fmt.Sprintf("--context %s differs from --context %s in the kubectl arguments", context, named))
+			return 1
+		}
+		context = named
+	}
+	if context == "" {
+		var err error
+		if context, err = resolveContext(args); err != nil {
+			output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+			return 1
+		}
+	}
+
+	sim := simulate(cfg, context, args)
+	if jsonOutput {
+		data, err := json.MarshalIndent(sim, "", "  ")
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	printSimulation(sim)
+	return 0
+}
+
+// simulate sends args through the same gates as runGuarded, stopping short
+// of anything that prompts, calls out or touches the cluster
+func simulate(cfg *config.Config, context string, args []string) simulation {
+	ticketID, args := extractTicketFlag(args)
+	skipConfirm, args := extractYesFlag(args)
+	g := decide(cfg, context, args, ticketID, skipConfirm, true)
+	if g.decision.Verdict == policy.Block {
+		return simulation{Decision: g.decision}
+	}
+	if stop := g.runGates(); stop != nil {
+		d := g.decision
+		d.Verdict, d.Rule, d.Reason = policy.Block, stop.rule, stop.err.Error()
+		return simulation{Decision: d}
+	}
+
+	d := g.decision
+	if g.deleteWait > 0 {
+		g.require("the delete finishing within %s", g.deleteWait)
+	}
+	prompt, phrase := g.confirmation()
+	switch {
+	case len(g.retype) > 0:
+		g.require("typing %q to confirm the namespace delete", strings.Join(g.retype, " "))
+	case len(g.uncertain) > 0:
+		g.require("confirmation (not skipped with --yes): %s", strings.Join(g.uncertain, "; "))
+	case g.breakGlass:
+		g.require("break-glass confirmation (not skipped with --yes)")
+	case d.Verdict == policy.Confirm && !prompt:
+		g.require("confirmation (skipped with --yes)")
+	case prompt && phrase != "":
+		g.require("typing %q to confirm", phrase)
+	case prompt:
+		g.require("confirmation")
+	}
+	if needsMFA(d) {
+		g.require("a TOTP code")
+	}
+	if g.needsApprovalCommand() {
+		g.require("approval from %s", d.Rules.ApprovalCommand)
+	}
+	if cfg.Lease.Enabled && leaseCovers(cfg.Lease, d.Action) {
+		g.require("the operation lease")
+	}
+	if timeout := d.Rules.TimeoutFor(d.Action); timeout > 0 {
+		g.require("finishing within %s", timeout)
+	}
+	return simulation{Decision: d, Requirements: g.requirements}
+}
+
+func printSimulation(sim simulation) {
+	d := sim.Decision
+	tier := d.Tier
+	if tier == "" {
+		tier = "none"
+	}
+	rule := ""
+	if d.Rule != "" {
+		rule = fmt.Sprintf(" (rule: %s)", d.Rule)
+	}
+
+	fmt.Printf("Verdict:   %s%s\n", d.Verdict, rule)
+	fmt.Printf("Action:    %s (severity %s)\n", d.Action, d.Severity)
+	fmt.Printf("Context:   %s (tier %s)\n", d.Context, tier)
+	if d.Reason != "" {
+		fmt.Printf("Reason:    %s\n", d.Reason)
+	}
+	if len(d.Objects) > 0 {
+		fmt.Printf("Objects:   %s\n", strings.Join(d.Objects, ", "))
+	}
+	fmt.Printf("Command:   kubectl %s\n", shell.JoinArgs(d.Args))
+	if len(d.AddedFlags) > 0 {
+		fmt.Printf("Adds:      %s\n", strings.Join(d.AddedFlags, " "))
+	}
+	if d.Plugin != "" {
+		fmt.Printf("Plugin:    %s\n", d.Plugin)
+	}
+	if d.Message != "" {
+		fmt.Printf("Message:   %s\n", d.Message)
+	}
+	if d.DocsURL != "" {
+		fmt.Printf("Docs:      %s\n", d.DocsURL)
+	}
+	for i, s := range d.Suggestions {
+		label := ""
+		if i == 0 {
+			label = "Instead:"
+		}
+		fmt.Printf("%-10s kubectl %s\n", label, s)
+	}
+	for i, r := range sim.Requirements {
+		label := ""
+		if i == 0 {
+			label = "Requires:"
+		}
+		fmt.Printf("%-10s %s\n", label, r)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/manifest"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+)
+
+// printSources describes where a command's manifests come from: the host
+// of a remote -f URL or -k repository, the files and kinds in a local
+// directory or kustomization, and the objects piped to -f -
+func printSources(decision policy.Decision) {
+	recursive := rbac.HasFlag(decision.Args, "-R", "--recursive")
+	for _, target := range rbac.Filenames(decision.Args) {
+		if target == "-" {
+			printStdinManifest()
+			continue
+		}
+		if host, remote := manifest.Host(target); remote {
+			output.PrintSublog(fmt.Sprintf("Manifest from %s: %s", host, target))
+			continue
+		}
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			continue
+		}
+		files, err := manifest.Files(target, recursive)
+		if err != nil {
+			output.PrintSublog(fmt.Sprintf("Directory %s: could not be read (%v)", target, err))
+			continue
+		}
+		desc := fmt.Sprintf("Directory %s: %d files", target, len(files))
+		if recursive {
+			desc += " (recursive)"
+		}
+		if objects, err := manifest.Load(files, false); err == nil && len(objects) > 0 {
+			desc += ", " + manifest.CountKinds(objects)
+		}
+		output.PrintSublog(desc)
+	}
+
+	for _, target := range rbac.Kustomizations(decision.Args) {
+		if host, remote := manifest.Host(target); remote {
+			output.PrintSublog(fmt.Sprintf("Kustomization from %s: %s", host, target))
+			continue
+		}
+		stdout, stderr, exitCode := kubectl.ExecuteWithOutput([]string{"kustomize", target})
+		if exitCode != 0 {
+			output.PrintSublog(fmt.Sprintf("Kustomization %s: could not be rendered (%s)", target, strings.TrimSpace(stderr)))
+			continue
+		}
+		objects, err := manifest.Parse([]byte(stdout))
+		if err != nil || len(objects) == 0 {
+			output.PrintSublog(fmt.Sprintf("Kustomization %s renders no objects", target))
+			continue
+		}
+		output.PrintSublog(fmt.Sprintf("Kustomization %s: %s", target, manifest.CountKinds(objects)))
+	}
+}
+
+// readPipedManifest reads a manifest piped to -f -, so the confirmation can
+// show what it holds, and passes it on to kubectl
+func readPipedManifest(args []string) error {
+	if output.IsStdinTerminal() || !slices.Contains(rbac.Filenames(args), "-") {
+		return nil
+	}
+	data, err := manifest.ReadStdin(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("could not read the manifest on stdin: %w", err)
+	}
+	kubectl.Input = data
+	return nil
+}
+
+// printStdinManifest lists the objects in the manifest readPipedManifest
+// read
+func printStdinManifest() {
+	if kubectl.Input == nil {
+		return
+	}
+	objects, err := manifest.Load([]string{"-"}, false)
+	switch {
+	case err != nil:
+		output.PrintSublog(fmt.Sprintf("Manifest from stdin: could not be parsed (%v)", err))
+		return
+	case len(objects) == 0:
+		output.PrintSublog("Manifest from stdin holds no objects")
+		return
+	}
+	output.PrintSublog(fmt.Sprintf("Manifest from stdin: %s", manifest.CountKinds(objects)))
+	for i, o := range objects {
+		if i == maxShownSelected {
+			output.PrintSublog(fmt.Sprintf("  ... and %d more", len(objects)-i))
+			break
+		}
+		if o.Namespace != "" {
+			output.PrintSublog(fmt.Sprintf("  %s/%s (%s)", o.Kind, o.Name, o.Namespace))
+		} else {
+			output.PrintSublog(fmt.Sprintf("  %s/%s", o.Kind, o.Name))
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/fuzzy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+)
+
+// previousContextPath stores the context active before the last 'kctl ctx' switch
+func previousContextPath() string {
+	return filepath.Join(config.DataDir(), "previous-context")
+}
+
+// handleCtx lists or switches kubectl contexts, showing each context's tier
+func handleCtx(args []string) int {
+	skipConfirm, args := extractYesFlag(args)
+	if len(args) > 1 || len(args) == 1 && (args[0] == "--help" || args[0] == "-h") {
+		fmt.Print(`kctl ctx - List or switch kubectl contexts
+
+Usage:
+  kctl ctx             List contexts with their tiers
+  kctl ctx <name>      Switch to the context matching <name> (fuzzy)
+  kctl ctx -           Switch back to the previous context
+
+Flags:
+  --yes, -y   Skip the confirmation when switching into production
+
+Switching into a production-tier context requires confirmation.
+`)
+		if len(args) > 1 {
+			return 1
+		}
+		return 0
+	}
+
+	cfg := loadConfig()
+	current, _ := kubectl.GetCurrentContext()
+	contexts, err := kubectl.GetAllContexts()
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	if len(args) == 0 {
+		for _, ctx := range contexts {
+			marker := " "
+			if ctx == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %s (%s)\n", marker, ctx, cfg.GetClusterRules(ctx).Tier)
+		}
+		return 0
+	}
+
+	target := args[0]
+	if target == "-" {
+		data, err := os.ReadFile(previousContextPath())
+		if err != nil || strings.TrimSpace(string(data)) == "" {
+			output.PrintError("No previous context recorded")
+			return 1
+		}
+		target = strings.TrimSpace(string(data))
+		// It may come from another $KUBECONFIG
+		if !slices.Contains(contexts, target) {
+			output.PrintError(fmt.Sprintf("The previous context %s isn't in the current kubeconfig", target))
+			return 1
+		}
+	} else {
+		target, err = pickOne("context", target, contexts)
+		if err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+	}
+
+	if target == current {
+		output.PrintContext(target, cfg.GetClusterRules(target).Tier)
+		output.PrintSublog("Already the current context")
+		return 0
+	}
+
+	tier := cfg.GetClusterRules(target).Tier
+	output.PrintContext(target, tier)
+	if cfg.IsProduction(tier) && !skipConfirm {
+		output.PrintWarning(fmt.Sprintf("'%s' is a %s context", target, tier))
+		if !output.PromptConfirmation(fmt.Sprintf("Switch to %s?", target), "") {
+			output.PrintSublog("Context switch cancelled")
+			return 0
+		}
+	}
+
+	if err := kubectl.UseContext(target); err != nil {
+		output.PrintError(fmt.Sprintf("Failed to switch context: %v", err))
+		return 1
+	}
+	if current != "" {
+		statefile.WriteFile(previousContextPath(), []byte(current+"\n"), 0600)
+	}
+	output.PrintSuccess(fmt.Sprintf("Switched to context %s (%s)", target, tier))
+	return 0
+}
+
+// handleNs lists or switches the namespace of the current context
+func handleNs(args []string) int {
+	if len(args) > 1 || len(args) == 1 && (args[0] == "--help" || args[0] == "-h") {
+		fmt.Print(`kctl ns - List or switch the current namespace
+
+Usage:
+  kctl ns              List namespaces in the current context
+  kctl ns <name>       Set the namespace matching <name> (fuzzy) as default
+`)
+		if len(args) > 1 {
+			return 1
+		}
+		return 0
+	}
+
+	cfg := loadConfig()
+	context, err := kubectl.GetCurrentContext()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+		return 1
+	}
+	tier := cfg.GetClusterRules(context).Tier
+	currentNs := kubectl.GetNamespace(nil)
+
+	namespaces, err := kubectl.GetNamespaces()
+	if err != nil {
+		output.PrintError(fmt.Sprintf("Failed to list namespaces: %v", err))
+		return 1
+	}
+
+	if len(args) == 0 {
+		output.PrintContext(context, tier)
+		for _, ns := range namespaces {
+			marker := " "
+			if ns == currentNs {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, ns)
+		}
+		return 0
+	}
+
+	target, err := pickOne("namespace", args[0], namespaces)
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+
+	output.PrintContext(context, tier)
+	if err := kubectl.SetNamespace(target); err != nil {
+		output.PrintError(fmt.Sprintf("Failed to set namespace: %v", err))
+		return 1
+	}
+	output.PrintSuccess(fmt.Sprintf("Namespace set to %s", target))
+	return 0
+}
+
+// pickOne resolves a fuzzy query to exactly one candidate
+func pickOne(kind, query string, candidates []string) (string, error) {
+	matches := fuzzy.Find(query, candidates)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no %s matches '%s'", kind, query)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("'%s' matches several %ss: %s", query, kind, strings.Join(matches, ", "))
+	}
+}
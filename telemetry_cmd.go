@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/statefile"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/telemetry"
+)
+
+// handleTelemetry turns the anonymous usage report on or off and shows what
+// it contains
+func handleTelemetry(args []string) int {
+	if len(args) != 1 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Printf(`%s telemetry - Opt in to anonymous usage reports
+
+Usage:
+  %[1]s telemetry on|off|status
+
+Once on, kctl counts the verdicts and actions of guarded commands and sends
+them once a day to telemetry.endpoint with its version, OS, architecture,
+the optional features the config turns on and a random installation ID.
+Cluster, context, namespace, resource and user names are never sent.
+'status' prints the pending report. Telemetry is off until you turn it on;
+the config can only say where reports go.
+`, progName)
+		if len(args) != 1 {
+			return 1
+		}
+		return 0
+	}
+
+	path := telemetry.Path()
+	state, err := telemetry.Load(path)
+	if err != nil {
+		output.PrintError(err.Error())
+		return 1
+	}
+	cfg := readConfig()
+	now := time.Now().UTC()
+
+	switch args[0] {
+	case "on":
+		if err := state.Enable(now); err != nil {
+			output.PrintError(fmt.Sprintf("Could not enable telemetry: %v", err))
+			return 1
+		}
+	case "off":
+		state.Disable()
+	case "status":
+		if !state.Enabled {
+			fmt.Println("Telemetry is off")
+			return 0
+		}
+		if cfg.Telemetry.Endpoint == "" {
+			fmt.Println("Telemetry is on, but no telemetry.endpoint is configured; counts are kept locally")
+		} else {
+			fmt.Printf("Telemetry is on, reporting to %s\n", cfg.Telemetry.Endpoint)
+		}
+		data, _ := json.MarshalIndent(state.Report(cfg, Version, now), "", "  ")
+		fmt.Printf("Next report:\n%s\n", data)
+		return 0
+	default:
+		output.PrintError(fmt.Sprintf("Unknown telemetry command: %s (use on, off or status)", args[0]))
+		return 1
+	}
+
+	if err := telemetry.Save(path, state); err != nil {
+		output.PrintError(fmt.Sprintf("Could not save the telemetry setting: %v", err))
+		return 1
+	}
+	if state.Enabled {
+		output.PrintSuccess("Telemetry on; thank you. See what is sent with '" + progName + " telemetry status'")
+		if cfg.Telemetry.Endpoint == "" {
+			output.PrintWarning("No telemetry.endpoint is configured; counts are kept locally until one is")
+		}
+	} else {
+		output.PrintSuccess("Telemetry off")
+	}
+	return 0
+}
+
+// recordUsage counts a guarded command when the user opted in to telemetry,
+// and sends the counts when a report is due. Failures never get in the way
+// of the command; a failed report is retried the next day.
+func recordUsage(cfg *config.Config, decision policy.Decision) {
+	path := telemetry.Path()
+	// Without a file telemetry is off; don't leave a lock file behind
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	// Counts from kctl processes running at the same time add up
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	state, err := telemetry.Load(path)
+	if err != nil || !state.Enabled {
+		return
+	}
+	state.Count(string(decision.Verdict), decision.Action, decision.Plugin != "")
+
+	now := time.Now().UTC()
+	if cfg.Telemetry.Endpoint != "" && state.Due(now) {
+		if err := telemetry.Send(cfg.Telemetry.Endpoint, state.Report(cfg, Version, now), telemetry.Timeout); err == nil {
+			state.Sent(now)
+		} else {
+			state.LastSent = now
+		}
+	}
+	_ = telemetry.Save(path, state)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/ticket"
+)
+
+const ticketFlag = "--kctl-ticket"
+
+// extractTicketFlag removes --kctl-ticket ID (or --kctl-ticket=ID) from args
+// so it never reaches kubectl, returning the ticket ID
+func extractTicketFlag(args []string) (string, []string) {
+	id := ""
+	filteredArgs := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == ticketFlag && i+1 < len(args):
+			id = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], ticketFlag+"="):
+			id = strings.TrimPrefix(args[i], ticketFlag+"=")
+		default:
+			filteredArgs = append(filteredArgs, args[i])
+		}
+	}
+	return id, filteredArgs
+}
+
+// checkTicket validates the decision's ticket when the rules require one
+// for its destructive action. The returned error is the reason to block the
+// command; a nil ticket means none was checked.
+func checkTicket(cfg *config.Config, decision policy.Decision) (*ticket.Ticket, error) {
+	id := decision.Ticket
+	if !ticketRequired(decision) {
+		return nil, nil
+	}
+	if id == "" {
+		return nil, fmt.Errorf("Tier '%s' requires a change ticket for '%s'; re-run with %s <id>",
+			decision.Tier, decision.Action, ticketFlag)
+	}
+	t, err := ticket.Validate(cfg.Tickets, id)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/rbac"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/typo"
+)
+
+// checkNames looks up the objects a delete or scale names before it runs.
+// A name the cluster doesn't have is an error suggesting the closest
+// existing names, rather than kubectl's NotFound after the other names
+// were changed; names a character away from another object's come back as
+// notes. Dry runs, offline commands and deletes with --ignore-not-found
+// aren't checked, and neither is anything when the lookup fails.
+func checkNames(decision policy.Decision) (notes []string, err error) {
+	if !namesChecked(decision) {
+		return nil, nil
+	}
+	misses, lookalikes, lookupErr := typo.Check(decision.Context, decision.Args)
+	if lookupErr != nil {
+		return nil, nil
+	}
+	for _, l := range lookalikes {
+		notes = append(notes, fmt.Sprintf("%s %s looks like %s; make sure it's the one you mean",
+			rbac.Kind(l.Resource), l.Name, strings.Join(l.Similar, ", ")))
+	}
+	if len(misses) == 0 {
+		return notes, nil
+	}
+
+	var problems []string
+	for _, m := range misses {
+		problem := fmt.Sprintf("%s %s doesn't exist", rbac.Kind(m.Resource), m.Name)
+		if len(m.Closest) > 0 {
+			problem += fmt.Sprintf(" (did you mean %s?)", strings.Join(m.Closest, ", "))
+		}
+		problems = append(problems, problem)
+	}
+	return nil, fmt.Errorf("%s; nothing was changed", strings.Join(problems, "; "))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/audit"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/policy"
+)
+
+// handleUI launches k9s, or the configured terminal UI, on a context:
+// read-only on production tiers and while kctl is locked, read-write
+// elsewhere. The session is audited.
+func handleUI(args []string) int {
+	contextName := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Print(`kctl ui - Launch a terminal UI with the context's rules in mind
+
+Usage:
+  kctl ui [--context NAME]
+
+Launches k9s (or ui.command) on the context, read-only where the rules call
+for it: on production and tiers inheriting from it (or ui.readonly_tiers),
+and on every cluster while kctl is locked. Elsewhere it is read-write. The
+session is recorded in the audit log.
+`)
+			return 0
+		case "--context":
+			if i+1 >= len(args) {
+				output.PrintError("--context requires a value")
+				return 1
+			}
+			contextName = args[i+1]
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+
+	cfg := loadConfig()
+	if contextName == "" {
+		current, err := kubectl.GetCurrentContext()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+			return 1
+		}
+		contextName = current
+	}
+
+	tier := cfg.GetClusterRules(contextName).Tier
+	readOnly := cfg.UIReadOnly(tier)
+	reason := fmt.Sprintf("tier '%s'", tier)
+	if state := currentLock(); state != nil && !readOnly {
+		readOnly, reason = true, "kctl is locked"
+	}
+	decision := policy.Decision{Verdict: policy.Allow, Action: "ui", Context: contextName, Tier: tier}
+
+	argv, ok := cfg.UI.UIArgs(contextName, readOnly)
+	if !ok {
+		// Only a configured command can lack read-only flags
+		msg := fmt.Sprintf("%s has no read-only mode configured (ui.readonly_flags), and %s needs one", cfg.UI.Command, reason)
+		decision.Verdict, decision.Reason = policy.Block, msg
+		output.PrintBlocked("ui", contextName, msg, output.Guidance{})
+		recordAudit(cfg, decision, audit.Entry{Outcome: audit.OutcomeBlocked})
+		return 1
+	}
+	decision.Args = argv
+	mode := "read-write"
+	if readOnly {
+		mode = "read-only"
+		output.PrintSublog(fmt.Sprintf("Starting %s read-only (%s)", argv[0], reason))
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	start := time.Now()
+	err := cmd.Run()
+	exitCode := 0
+	if err != nil {
+		exitCode = 1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			output.PrintError(fmt.Sprintf("Could not start %s: %v", argv[0], err))
+		}
+	}
+	recordAudit(cfg, decision, audit.Entry{
+		Outcome:    audit.OutcomeExecuted,
+		Reason:     mode + " session",
+		ExitCode:   exitCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+	return exitCode
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/config"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/identity"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/kubectl"
+	"github.com/bobbydrake/kubectl-enhanced-cli/pkg/output"
+)
+
+// whoamiView is what 'kctl whoami' shows
+type whoamiView struct {
+	Context   string `yaml:"context"`
+	Tier      string `yaml:"tier"`
+	Namespace string `yaml:"namespace"`
+	// Entry is the clusters entry the rules come from, if any
+	Entry      string   `yaml:"entry,omitempty"`
+	User       string   `yaml:"user,omitempty"`
+	Groups     []string `yaml:"groups,omitempty"`
+	UserSource string   `yaml:"user_source,omitempty"`
+	// Lock describes the lock, "" when unlocked
+	Lock        string `yaml:"lock,omitempty"`
+	Maintenance string `yaml:"maintenance,omitempty"`
+	Freeze      string `yaml:"freeze,omitempty"`
+	// Temporary are the unexpired temporary rules applying to the context
+	Temporary []temporaryRule      `yaml:"temporary,omitempty"`
+	Rules     config.ResolvedRules `yaml:"rules"`
+}
+
+// temporaryRule is a config entry carrying expires_at
+type temporaryRule struct {
+	Path      string    `yaml:"path"`
+	ExpiresAt time.Time `yaml:"expires_at"`
+}
+
+// handleWhoami shows who and where commands run as, and under which rules
+func handleWhoami(args []string) int {
+	format := ""
+	context := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--help", "-h":
+			fmt.Printf(`%s whoami - Show who and where commands run as, and under which rules
+
+Usage:
+  %[1]s whoami [--context NAME] [-o yaml|json]
+
+Shows the context (default: the current one), its tier and namespace, the
+user and groups the cluster sees (from 'kubectl auth whoami' when the
+cluster supports it, else from the kubeconfig), the lock, open maintenance
+and freeze windows, temporary rules in effect and the resolved rules.
+`, progName)
+			return 0
+		case "--context":
+			if i+1 >= len(args) {
+				output.PrintError("--context requires a value")
+				return 1
+			}
+			context = args[i+1]
+			i++
+		case "-o", "--output":
+			if i+1 >= len(args) {
+				output.PrintError(fmt.Sprintf("%s requires a value", args[i]))
+				return 1
+			}
+			format = args[i+1]
+			i++
+		default:
+			output.PrintError(fmt.Sprintf("Unknown flag: %s", args[i]))
+			return 1
+		}
+	}
+	if format != "" && format != "yaml" && format != "json" {
+		output.PrintError(fmt.Sprintf("Unknown format %q (use yaml or json)", format))
+		return 1
+	}
+
+	cfg := loadConfig()
+	if context == "" {
+		current, err := kubectl.GetCurrentContext()
+		if err != nil {
+			output.PrintError(fmt.Sprintf("Failed to get current context: %v", err))
+			return 1
+		}
+		context = current
+	}
+	namespace, err := kubectl.GetContextNamespace(context)
+	if err != nil {
+		namespace = "default"
+	}
+	rules := cfg.GetClusterRules(context)
+	view := whoamiView{
+		Context:     context,
+		Tier:        rules.Tier,
+		Namespace:   namespace,
+		Maintenance: rules.Maintenance,
+		Freeze:      rules.Freeze,
+		Temporary:   temporaryRules(cfg, context, rules.Tier),
+		Rules:       rules,
+	}
+	view.Entry, _ = cfg.ClusterEntry(context)
+	if id := identity.Resolve(context); id.Username != "" {
+		view.User, view.Groups, view.UserSource = id.Username, id.Groups, id.Source
+	}
+	if state := currentLock(); state != nil {
+		view.Lock = describeLock(state)
+	}
+
+	if format != "" {
+		if err := printStructured(view, format); err != nil {
+			output.PrintError(err.Error())
+			return 1
+		}
+		return 0
+	}
+	printWhoami(view)
+	return 0
+}
+
+// temporaryRules returns the unexpired entries with expires_at in the
+// config file and the shared policy it is layered over that apply to
+// context: those of the clusters entry it gets its rules from, else those
+// of its tier and the tiers that one inherits
+func temporaryRules(cfg *config.Config, context, tier string) []temporaryRule {
+	var expirations []config.Expiration
+	for _, path := range configFiles() {
+		doc, err := config.LoadDocument(path)
+		if err != nil {
+			continue
+		}
+		if found, err := doc.Expirations(); err == nil {
+			expirations = append(expirations, found...)
+		}
+	}
+	var sections []string
+	if entry, ok := cfg.ClusterEntry(context); ok {
+		sections = append(sections, "clusters."+entry)
+	} else {
+		seen := make(map[string]bool)
+		for name := tier; name != "" && !seen[name]; name = cfg.Tiers[name].Inherits {
+			seen[name] = true
+			sections = append(sections, "tiers."+name)
+		}
+	}
+	var rules []temporaryRule
+	listed := make(map[string]bool)
+	for _, e := range expirations {
+		// The same entry in both files is in effect once
+		if e.Expired() || listed[e.Path] {
+			continue
+		}
+		listed[e.Path] = true
+		for _, section := range sections {
+			if e.Path == section || strings.HasPrefix(e.Path, section+".") || strings.HasPrefix(e.Path, section+" ") {
+				rules = append(rules, temporaryRule{Path: e.Path, ExpiresAt: e.ExpiresAt})
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// printWhoami prints view for humans
+func printWhoami(view whoamiView) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	tier := view.Tier
+	if view.Entry != "" {
+		tier += fmt.Sprintf(" (clusters entry %s)", view.Entry)
+	}
+	fmt.Fprintf(w, "Context:\t%s\n", view.Context)
+	fmt.Fprintf(w, "Tier:\t%s\n", tier)
+	fmt.Fprintf(w, "Namespace:\t%s\n", view.Namespace)
+	if view.User != "" {
+		fmt.Fprintf(w, "User:\t%s (from %s)\n", view.User, view.UserSource)
+	} else {
+		fmt.Fprintf(w, "User:\tunknown\n")
+	}
+	if len(view.Groups) > 0 {
+		fmt.Fprintf(w, "Groups:\t%s\n", strings.Join(view.Groups, ", "))
+	}
+	if view.Lock != "" {
+		fmt.Fprintf(w, "Lock:\t%s\n", view.Lock)
+	} else {
+		fmt.Fprintf(w, "Lock:\tunlocked\n")
+	}
+	if view.Maintenance != "" {
+		fmt.Fprintf(w, "Maintenance:\twindow '%s' is open; blocked actions need confirmation\n", view.Maintenance)
+	}
+	if view.Freeze != "" {
+		fmt.Fprintf(w, "Freeze:\twindow '%s' is open; changes are blocked\n", view.Freeze)
+	}
+	for i, t := range view.Temporary {
+		label := ""
+		if i == 0 {
+			label = "Temporary:"
+		}
+		fmt.Fprintf(w, "%s\t%s until %s\n", label, t.Path, t.ExpiresAt.Local().Format("15:04 Jan 2"))
+	}
+
+	r := view.Rules
+	fmt.Fprintf(w, "Blocked:\t%s\n", listOrNone(r.BlockedActions))
+	fmt.Fprintf(w, "Confirm:\t%s\n", listOrNone(r.RequireConfirmation))
+	if len(r.AllowedActions) > 0 {
+		fmt.Fprintf(w, "Allowed:\t%s\n", strings.Join(r.AllowedActions, ", "))
+	}
+	if r.Default != "" {
+		fmt.Fprintf(w, "Default:\t%s\n", r.Default)
+	}
+	if requires := requirements(r); len(requires) > 0 {
+		fmt.Fprintf(w, "Requires:\t%s\n", strings.Join(requires, ", "))
+	}
+	w.Flush()
+}
+
+// requirements lists what the rules ask of commands beyond confirmation
+func requirements(r config.ResolvedRules) []string {
+	var requires []string
+	for _, req := range []struct {
+		on   bool
+		what string
+	}{
+		{r.RequireExplicitContext, "explicit --context"},
+		{r.RequireExplicitNamespace, "explicit --namespace"},
+		{r.RequireTicket, "change ticket"},
+		{r.RequireOnCall, "on-call"},
+		{r.RequireMFA, "TOTP code"},
+		{r.ApprovalCommand != "", "external approval"},
+		{r.ConfirmationPhrase != "", fmt.Sprintf("phrase %q", r.ConfirmationPhrase)},
+	} {
+		if req.on {
+			requires = append(requires, req.what)
+		}
+	}
+	return requires
+}
+
+// listOrNone joins list, or says it is empty
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}